@@ -6,8 +6,11 @@
 package addresses
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcutil"
@@ -30,6 +33,18 @@ const (
 
 	// AddressTypeP2SH represents Shell P2SH addresses (script hash)
 	AddressTypeP2SH = "p2sh"
+
+	// AddressTypeP2WSH represents Shell P2WSH addresses (xsl1... witness
+	// script hash)
+	AddressTypeP2WSH = "p2wsh"
+
+	// MaxP2SHMultiSigKeys is the maximum number of keys in a P2SH multisig
+	// script, bounded by the 520 byte redeem script push limit
+	MaxP2SHMultiSigKeys = 15
+
+	// MaxP2WSHMultiSigKeys is the maximum number of keys in a P2WSH
+	// multisig script, bounded by OP_CHECKMULTISIG
+	MaxP2WSHMultiSigKeys = 20
 )
 
 var (
@@ -167,6 +182,127 @@ func (addr *ShellP2PKHAddress) IsForNetwork(params *chaincfg.Params) bool {
 	return addr.netParams.Name == params.Name
 }
 
+// ShellP2SHAddress represents a Shell P2SH address
+type ShellP2SHAddress struct {
+	hash      [20]byte
+	netParams *chaincfg.Params
+}
+
+// NewShellP2SHAddress creates a new Shell P2SH address from a script hash
+func NewShellP2SHAddress(scriptHash []byte, params *chaincfg.Params) (*ShellP2SHAddress, error) {
+	if len(scriptHash) != 20 {
+		return nil, fmt.Errorf("script hash must be 20 bytes")
+	}
+
+	var hash [20]byte
+	copy(hash[:], scriptHash)
+
+	return &ShellP2SHAddress{
+		hash:      hash,
+		netParams: params,
+	}, nil
+}
+
+// NewShellP2SHAddressFromScript creates a new Shell P2SH address paying to
+// the given redeem script
+func NewShellP2SHAddressFromScript(redeemScript []byte, params *chaincfg.Params) (*ShellP2SHAddress, error) {
+	return NewShellP2SHAddress(btcutil.Hash160(redeemScript), params)
+}
+
+// String returns the base58 encoded Shell P2SH address
+func (addr *ShellP2SHAddress) String() string {
+	// Create versioned payload
+	payload := make([]byte, 21)
+	payload[0] = addr.netParams.ScriptHashAddrID
+	copy(payload[1:], addr.hash[:])
+
+	// Calculate checksum
+	checksum := chainhash.DoubleHashB(payload)[:4]
+
+	// Encode with base58
+	fullPayload := append(payload, checksum...)
+	return base58.Encode(fullPayload)
+}
+
+// ScriptAddress returns the script hash
+func (addr *ShellP2SHAddress) ScriptAddress() []byte {
+	return addr.hash[:]
+}
+
+// AddressType returns the address type
+func (addr *ShellP2SHAddress) AddressType() string {
+	return AddressTypeP2SH
+}
+
+// IsForNetwork checks if the address is for the given network
+func (addr *ShellP2SHAddress) IsForNetwork(params *chaincfg.Params) bool {
+	return addr.netParams.Name == params.Name
+}
+
+// ShellP2WSHAddress represents a Shell P2WSH address (xsl1...)
+type ShellP2WSHAddress struct {
+	witnessProgram [32]byte
+	netParams      *chaincfg.Params
+}
+
+// NewShellP2WSHAddress creates a new Shell P2WSH address from the SHA256
+// hash of a witness script
+func NewShellP2WSHAddress(witnessProgram []byte, params *chaincfg.Params) (*ShellP2WSHAddress, error) {
+	if len(witnessProgram) != 32 {
+		return nil, fmt.Errorf("witness program must be 32 bytes")
+	}
+
+	var program [32]byte
+	copy(program[:], witnessProgram)
+
+	return &ShellP2WSHAddress{
+		witnessProgram: program,
+		netParams:      params,
+	}, nil
+}
+
+// NewShellP2WSHAddressFromScript creates a new Shell P2WSH address paying to
+// the given witness script
+func NewShellP2WSHAddressFromScript(witnessScript []byte, params *chaincfg.Params) (*ShellP2WSHAddress, error) {
+	program := sha256.Sum256(witnessScript)
+	return NewShellP2WSHAddress(program[:], params)
+}
+
+// String returns the bech32 encoded Shell P2WSH address
+func (addr *ShellP2WSHAddress) String() string {
+	// Convert witness program to 5-bit groups for bech32
+	conv, err := bech32.ConvertBits(addr.witnessProgram[:], 8, 5, true)
+	if err != nil {
+		return ""
+	}
+
+	// Prepend witness version 0
+	data := append([]byte{0}, conv...)
+
+	// Encode with Shell HRP
+	encoded, err := bech32.Encode(ShellSegwitHRP, data)
+	if err != nil {
+		return ""
+	}
+
+	return encoded
+}
+
+// ScriptAddress returns the witness program
+func (addr *ShellP2WSHAddress) ScriptAddress() []byte {
+	return addr.witnessProgram[:]
+}
+
+// AddressType returns the address type
+func (addr *ShellP2WSHAddress) AddressType() string {
+	return AddressTypeP2WSH
+}
+
+// IsForNetwork checks if the address is for the given network
+func (addr *ShellP2WSHAddress) IsForNetwork(params *chaincfg.Params) bool {
+	return addr.netParams.Name == params.Name
+}
+
 // GenerateShellAddress generates a Shell address from a public key
 func GenerateShellAddress(pubKey *btcec.PublicKey, addressType string, params *chaincfg.Params) (ShellAddress, error) {
 	switch addressType {
@@ -214,8 +350,12 @@ func ParseShellAddress(address string, params *chaincfg.Params) (ShellAddress, e
 	hash := payload[1:]
 
 	// Check address version and create appropriate address type
-	if version == params.PubKeyHashAddrID {
+	switch version {
+	case params.PubKeyHashAddrID:
 		return NewShellP2PKHAddress(hash, params)
+
+	case params.ScriptHashAddrID:
+		return NewShellP2SHAddress(hash, params)
 	}
 
 	return nil, ErrUnsupportedAddressType
@@ -234,6 +374,12 @@ func parseShellBech32Address(data []byte, params *chaincfg.Params) (ShellAddress
 	}
 
 	switch witnessVersion {
+	case 0: // P2WSH
+		if len(witnessProgram) != 32 {
+			return nil, ErrUnsupportedAddressType
+		}
+		return NewShellP2WSHAddress(witnessProgram, params)
+
 	case 1: // Taproot
 		if len(witnessProgram) != 32 {
 			return nil, ErrInvalidAddress
@@ -269,6 +415,21 @@ func CreateShellScript(addr ShellAddress) ([]byte, error) {
 			AddOp(txscript.OP_CHECKSIG).
 			Script()
 
+	case *ShellP2SHAddress:
+		// P2SH script: OP_HASH160 <script-hash> OP_EQUAL
+		return txscript.NewScriptBuilder().
+			AddOp(txscript.OP_HASH160).
+			AddData(a.hash[:]).
+			AddOp(txscript.OP_EQUAL).
+			Script()
+
+	case *ShellP2WSHAddress:
+		// P2WSH script: OP_0 <32-byte-script-hash>
+		return txscript.NewScriptBuilder().
+			AddOp(txscript.OP_0).
+			AddData(a.witnessProgram[:]).
+			Script()
+
 	default:
 		return nil, ErrUnsupportedAddressType
 	}
@@ -316,6 +477,13 @@ func GetAddressInfo(address string, params *chaincfg.Params) (map[string]interfa
 
 	case *ShellP2PKHAddress:
 		info["pubkey_hash"] = fmt.Sprintf("%x", a.hash[:])
+
+	case *ShellP2SHAddress:
+		info["redeem_script_hash"] = fmt.Sprintf("%x", a.hash[:])
+
+	case *ShellP2WSHAddress:
+		info["witness_version"] = byte(0)
+		info["witness_program"] = fmt.Sprintf("%x", a.witnessProgram[:])
 	}
 
 	return info, nil
@@ -348,35 +516,98 @@ func IsValidShellAddressFormat(address string) bool {
 	return true
 }
 
-// GenerateMultiSigAddress generates a multi-signature Shell address
-func GenerateMultiSigAddress(pubKeys []*btcec.PublicKey, required int, params *chaincfg.Params) (ShellAddress, error) {
+// SortPublicKeys returns a copy of pubKeys sorted lexicographically by their
+// compressed serialization as specified by BIP67. ErrInvalidPublicKey is
+// returned if any of the keys is nil
+func SortPublicKeys(pubKeys []*btcec.PublicKey) ([]*btcec.PublicKey, error) {
+	sorted := make([]*btcec.PublicKey, len(pubKeys))
+	for i, pubKey := range pubKeys {
+		if pubKey == nil {
+			return nil, ErrInvalidPublicKey
+		}
+		sorted[i] = pubKey
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].SerializeCompressed(),
+			sorted[j].SerializeCompressed()) < 0
+	})
+
+	return sorted, nil
+}
+
+// CreateMultiSigScript creates an m-of-n OP_CHECKMULTISIG script with the
+// public keys in the given order
+func CreateMultiSigScript(pubKeys []*btcec.PublicKey, required int) ([]byte, error) {
 	if required <= 0 || required > len(pubKeys) {
 		return nil, fmt.Errorf("invalid required signatures: %d of %d", required, len(pubKeys))
 	}
 
-	if len(pubKeys) > 15 {
-		return nil, fmt.Errorf("too many public keys: %d (max 15)", len(pubKeys))
+	if len(pubKeys) > MaxP2WSHMultiSigKeys {
+		return nil, fmt.Errorf("too many public keys: %d (max %d)",
+			len(pubKeys), MaxP2WSHMultiSigKeys)
 	}
 
-	// Create multi-sig script
 	builder := txscript.NewScriptBuilder()
-	builder.AddOp(txscript.OP_1 + byte(required) - 1) // OP_M
+	builder.AddInt64(int64(required)) // OP_M
 
 	for _, pubKey := range pubKeys {
+		if pubKey == nil {
+			return nil, ErrInvalidPublicKey
+		}
 		builder.AddData(pubKey.SerializeCompressed())
 	}
 
-	builder.AddOp(txscript.OP_1 + byte(len(pubKeys)) - 1) // OP_N
+	builder.AddInt64(int64(len(pubKeys))) // OP_N
 	builder.AddOp(txscript.OP_CHECKMULTISIG)
 
-	script, err := builder.Script()
+	return builder.Script()
+}
+
+// CreateSortedMultiSigScript creates an m-of-n OP_CHECKMULTISIG script with
+// the public keys in BIP67 order, so every participant derives the same
+// script regardless of the order the keys were exchanged in
+func CreateSortedMultiSigScript(pubKeys []*btcec.PublicKey, required int) ([]byte, error) {
+	sorted, err := SortPublicKeys(pubKeys)
 	if err != nil {
 		return nil, err
 	}
 
-	// Hash the script for P2SH-like functionality
-	scriptHash := btcutil.Hash160(script)
+	return CreateMultiSigScript(sorted, required)
+}
+
+// GenerateMultiSigAddress generates a P2SH multi-signature Shell address
+// using sortedmulti (BIP67) key ordering
+func GenerateMultiSigAddress(pubKeys []*btcec.PublicKey, required int, params *chaincfg.Params) (ShellAddress, error) {
+	return GenerateMultiSigAddressOfType(pubKeys, required, AddressTypeP2SH, params)
+}
 
-	// For now, return as P2PKH-style address (in practice, would need P2SH support)
-	return NewShellP2PKHAddress(scriptHash, params)
+// GenerateMultiSigAddressOfType generates a P2SH or P2WSH multi-signature
+// Shell address using sortedmulti (BIP67) key ordering
+func GenerateMultiSigAddressOfType(pubKeys []*btcec.PublicKey, required int,
+	addressType string, params *chaincfg.Params) (ShellAddress, error) {
+
+	maxKeys := MaxP2WSHMultiSigKeys
+	if addressType == AddressTypeP2SH {
+		maxKeys = MaxP2SHMultiSigKeys
+	}
+	if len(pubKeys) > maxKeys {
+		return nil, fmt.Errorf("too many public keys: %d (max %d)", len(pubKeys), maxKeys)
+	}
+
+	script, err := CreateSortedMultiSigScript(pubKeys, required)
+	if err != nil {
+		return nil, err
+	}
+
+	switch addressType {
+	case AddressTypeP2SH:
+		return NewShellP2SHAddressFromScript(script, params)
+
+	case AddressTypeP2WSH:
+		return NewShellP2WSHAddressFromScript(script, params)
+
+	default:
+		return nil, ErrUnsupportedAddressType
+	}
 }
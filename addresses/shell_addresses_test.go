@@ -5,6 +5,7 @@
 package addresses
 
 import (
+	"encoding/hex"
 	"strings"
 	"testing"
 
@@ -388,9 +389,52 @@ func TestGenerateMultiSigAddress(t *testing.T) {
 			t.Fatal("Multisig address should not be nil")
 		}
 
+		if addr.AddressType() != AddressTypeP2SH {
+			t.Errorf("Expected address type %s, got %s", AddressTypeP2SH, addr.AddressType())
+		}
+
+		// Key order must not change the address
+		reversed := []*btcec.PublicKey{pubKeys[2], pubKeys[1], pubKeys[0]}
+		addr2, err := GenerateMultiSigAddress(reversed, 2, params)
+		if err != nil {
+			t.Fatalf("Failed to generate reversed multisig address: %v", err)
+		}
+		if addr.String() != addr2.String() {
+			t.Errorf("Multisig address depends on key order: %s vs %s", addr, addr2)
+		}
+
+		// The address must round trip through the parser
+		parsed, err := ParseShellAddress(addr.String(), params)
+		if err != nil {
+			t.Fatalf("Failed to parse multisig address: %v", err)
+		}
+		if parsed.AddressType() != AddressTypeP2SH {
+			t.Errorf("Parsed address type %s, expected %s", parsed.AddressType(), AddressTypeP2SH)
+		}
+
 		t.Logf("Generated 2-of-3 multisig address: %s", addr.String())
 	})
 
+	t.Run("Valid2of3Witness", func(t *testing.T) {
+		addr, err := GenerateMultiSigAddressOfType(pubKeys, 2, AddressTypeP2WSH, params)
+		if err != nil {
+			t.Fatalf("Failed to generate 2-of-3 P2WSH multisig address: %v", err)
+		}
+
+		if !strings.HasPrefix(addr.String(), "xsl1q") {
+			t.Errorf("Expected xsl1q prefix, got %s", addr.String())
+		}
+
+		script, err := CreateSortedMultiSigScript(pubKeys, 2)
+		if err != nil {
+			t.Fatalf("Failed to create multisig script: %v", err)
+		}
+		expected, _ := NewShellP2WSHAddressFromScript(script, params)
+		if addr.String() != expected.String() {
+			t.Errorf("P2WSH address mismatch: %s vs %s", addr, expected)
+		}
+	})
+
 	t.Run("InvalidRequired", func(t *testing.T) {
 		_, err := GenerateMultiSigAddress(pubKeys, 0, params)
 		if err == nil {
@@ -414,5 +458,142 @@ func TestGenerateMultiSigAddress(t *testing.T) {
 		if err == nil {
 			t.Error("Expected error for too many public keys")
 		}
+
+		// P2WSH allows up to 20 keys
+		_, err = GenerateMultiSigAddressOfType(manyKeys, 8, AddressTypeP2WSH, params)
+		if err != nil {
+			t.Errorf("Unexpected error for 16-key P2WSH multisig: %v", err)
+		}
+	})
+}
+
+func TestSortedMultiSigScript(t *testing.T) {
+	// BIP67 test vector 1
+	keyHexes := []string{
+		"02ff12471208c14bd580709cb2358d98975247d8765f92bc25eab3b2763ed605f8",
+		"02fe6f0a5a297eb38c391581c4413e084773ea23954d93f7753db7dc0adc188b2f",
+	}
+
+	var pubKeys []*btcec.PublicKey
+	for _, keyHex := range keyHexes {
+		keyBytes, _ := hex.DecodeString(keyHex)
+		pubKey, err := btcec.ParsePubKey(keyBytes)
+		if err != nil {
+			t.Fatalf("Failed to parse public key: %v", err)
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+
+	script, err := CreateSortedMultiSigScript(pubKeys, 2)
+	if err != nil {
+		t.Fatalf("Failed to create sorted multisig script: %v", err)
+	}
+
+	expected := "5221" + keyHexes[1] + "21" + keyHexes[0] + "52ae"
+	if hex.EncodeToString(script) != expected {
+		t.Errorf("Sorted multisig script mismatch:\n got %x\nwant %s", script, expected)
+	}
+
+	// The unsorted script keeps the given order
+	unsorted, err := CreateMultiSigScript(pubKeys, 2)
+	if err != nil {
+		t.Fatalf("Failed to create multisig script: %v", err)
+	}
+	if hex.EncodeToString(unsorted) == expected {
+		t.Error("Unsorted script should keep the input key order")
+	}
+
+	// Nil keys are rejected before sorting
+	_, err = CreateSortedMultiSigScript([]*btcec.PublicKey{pubKeys[0], nil}, 1)
+	if err != ErrInvalidPublicKey {
+		t.Errorf("Expected ErrInvalidPublicKey, got %v", err)
+	}
+}
+
+func TestShellScriptHashAddresses(t *testing.T) {
+	params := &chaincfg.MainNetParams
+	redeemScript := []byte{0x51} // OP_TRUE
+
+	t.Run("P2SH", func(t *testing.T) {
+		addr, err := NewShellP2SHAddressFromScript(redeemScript, params)
+		if err != nil {
+			t.Fatalf("Failed to create P2SH address: %v", err)
+		}
+
+		parsed, err := ParseShellAddress(addr.String(), params)
+		if err != nil {
+			t.Fatalf("Failed to parse P2SH address: %v", err)
+		}
+		if parsed.AddressType() != AddressTypeP2SH {
+			t.Errorf("Expected address type %s, got %s", AddressTypeP2SH, parsed.AddressType())
+		}
+
+		script, err := CreateShellScript(parsed)
+		if err != nil {
+			t.Fatalf("Failed to create P2SH script: %v", err)
+		}
+
+		// OP_HASH160 <20 bytes> OP_EQUAL
+		if len(script) != 23 || script[0] != 0xa9 || script[22] != 0x87 {
+			t.Errorf("Unexpected P2SH script %x", script)
+		}
+
+		info, err := GetAddressInfo(addr.String(), params)
+		if err != nil {
+			t.Fatalf("Failed to get address info: %v", err)
+		}
+		if info["type"] != AddressTypeP2SH {
+			t.Errorf("Unexpected info type %v", info["type"])
+		}
+
+		if err := ValidateShellAddress(addr.String(), params); err != nil {
+			t.Errorf("P2SH address should be valid: %v", err)
+		}
+	})
+
+	t.Run("P2WSH", func(t *testing.T) {
+		addr, err := NewShellP2WSHAddressFromScript(redeemScript, params)
+		if err != nil {
+			t.Fatalf("Failed to create P2WSH address: %v", err)
+		}
+
+		parsed, err := ParseShellAddress(addr.String(), params)
+		if err != nil {
+			t.Fatalf("Failed to parse P2WSH address: %v", err)
+		}
+		if parsed.AddressType() != AddressTypeP2WSH {
+			t.Errorf("Expected address type %s, got %s", AddressTypeP2WSH, parsed.AddressType())
+		}
+
+		script, err := CreateShellScript(parsed)
+		if err != nil {
+			t.Fatalf("Failed to create P2WSH script: %v", err)
+		}
+
+		// OP_0 <32 bytes>
+		if len(script) != 34 || script[0] != 0x00 || script[1] != 0x20 {
+			t.Errorf("Unexpected P2WSH script %x", script)
+		}
+
+		info, err := GetAddressInfo(addr.String(), params)
+		if err != nil {
+			t.Fatalf("Failed to get address info: %v", err)
+		}
+		if info["witness_version"] != byte(0) {
+			t.Errorf("Unexpected witness version %v", info["witness_version"])
+		}
+
+		if err := ValidateShellAddress(addr.String(), params); err != nil {
+			t.Errorf("P2WSH address should be valid: %v", err)
+		}
+	})
+
+	t.Run("InvalidLengths", func(t *testing.T) {
+		if _, err := NewShellP2SHAddress(make([]byte, 32), params); err == nil {
+			t.Error("Expected error for 32-byte P2SH hash")
+		}
+		if _, err := NewShellP2WSHAddress(make([]byte, 20), params); err == nil {
+			t.Error("Expected error for 20-byte P2WSH program")
+		}
 	})
 }
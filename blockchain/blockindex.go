@@ -91,11 +91,22 @@ type blockNode struct {
 	// reconstructing headers from memory.  These must be treated as
 	// immutable and are intentionally ordered to avoid padding on 64-bit
 	// platforms.
-	version    int32
-	bits       uint32
-	nonce      uint32
-	timestamp  int64
-	merkleRoot chainhash.Hash
+	version      int32
+	bits         uint32
+	nonce        uint32
+	timestamp    int64
+	thermalProof uint64
+	merkleRoot   chainhash.Hash
+
+	// algoBits holds the difficulty bits of the latest block of each
+	// proof-of-work algorithm in the chain up to and including this node.
+	// It is used to normalize the work of the algorithms once MobileX is
+	// active.
+	algoBits [numPowAlgorithms]uint32
+
+	// powAlgo is the proof-of-work algorithm the block was mined with as
+	// encoded in its version.
+	powAlgo PowAlgorithm
 
 	// status is a bitfield representing the validation state of the block. The
 	// status field, unlike the other fields, may be written to and so should
@@ -110,18 +121,27 @@ type blockNode struct {
 // initially creating a node.
 func initBlockNode(node *blockNode, blockHeader *wire.BlockHeader, parent *blockNode) {
 	*node = blockNode{
-		hash:       blockHeader.BlockHash(),
-		workSum:    CalcWork(blockHeader.Bits),
-		version:    blockHeader.Version,
-		bits:       blockHeader.Bits,
-		nonce:      blockHeader.Nonce,
-		timestamp:  blockHeader.Timestamp.Unix(),
-		merkleRoot: blockHeader.MerkleRoot,
+		hash:         blockHeader.BlockHash(),
+		version:      blockHeader.Version,
+		bits:         blockHeader.Bits,
+		nonce:        blockHeader.Nonce,
+		timestamp:    blockHeader.Timestamp.Unix(),
+		thermalProof: blockHeader.ThermalProof,
+		merkleRoot:   blockHeader.MerkleRoot,
+		powAlgo:      PowAlgorithmFromVersion(blockHeader.Version),
 	}
 	if parent != nil {
 		node.parent = parent
 		node.height = parent.height + 1
-		node.workSum = node.workSum.Add(parent.workSum, node.workSum)
+		node.algoBits = parent.algoBits
+	}
+
+	// Once blocks of both algorithms exist, every block contributes the
+	// normalized work of both of them.  See calcAlgoWork for details.
+	node.algoBits[node.powAlgo] = node.bits
+	node.workSum = calcAlgoWork(&node.algoBits)
+	if parent != nil {
+		node.workSum.Add(parent.workSum, node.workSum)
 		node.buildAncestor()
 	}
 }
@@ -145,7 +165,9 @@ func (node *blockNode) Equals(other *blockNode) bool {
 		node.bits == other.bits &&
 		node.nonce == other.nonce &&
 		node.timestamp == other.timestamp &&
+		node.thermalProof == other.thermalProof &&
 		node.merkleRoot == other.merkleRoot &&
+		node.powAlgo == other.powAlgo &&
 		node.status == other.status
 }
 
@@ -159,12 +181,13 @@ func (node *blockNode) Header() wire.BlockHeader {
 		prevHash = &node.parent.hash
 	}
 	return wire.BlockHeader{
-		Version:    node.version,
-		PrevBlock:  *prevHash,
-		MerkleRoot: node.merkleRoot,
-		Timestamp:  time.Unix(node.timestamp, 0),
-		Bits:       node.bits,
-		Nonce:      node.nonce,
+		Version:      node.version,
+		PrevBlock:    *prevHash,
+		MerkleRoot:   node.merkleRoot,
+		Timestamp:    time.Unix(node.timestamp, 0),
+		Bits:         node.bits,
+		Nonce:        node.nonce,
+		ThermalProof: node.thermalProof,
 	}
 }
 
@@ -234,6 +257,13 @@ func (node *blockNode) Bits() uint32 {
 	return node.bits
 }
 
+// Version returns the blockNode's version.
+//
+// NOTE: Part of the HeaderCtx interface.
+func (node *blockNode) Version() int32 {
+	return node.version
+}
+
 // Timestamp returns the blockNode's timestamp.
 //
 // NOTE: Part of the HeaderCtx interface.
@@ -103,6 +103,7 @@ type BlockChain struct {
 	sigCache            *txscript.SigCache
 	indexManager        IndexManager
 	hashCache           *txscript.HashCache
+	powHasher           PowHasher
 
 	// The following fields are calculated based upon the provided chain
 	// parameters.  They are also set when the instance is created and
@@ -1452,6 +1453,19 @@ func (b *BlockChain) HeaderByHash(hash *chainhash.Hash) (wire.BlockHeader, error
 	return node.Header(), nil
 }
 
+// BlockPowAlgorithm returns the proof-of-work algorithm the block with the
+// given hash was mined with as recorded in the block index.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) BlockPowAlgorithm(hash *chainhash.Hash) (PowAlgorithm, error) {
+	node := b.index.LookupNode(hash)
+	if node == nil {
+		return 0, fmt.Errorf("block %s is not known", hash)
+	}
+
+	return node.powAlgo, nil
+}
+
 // MainChainHasBlock returns whether or not the block with the given hash is in
 // the main chain.
 //
@@ -2093,6 +2107,13 @@ type Config struct {
 	// time is adjusted to be in agreement with other peers.
 	TimeSource MedianTimeSource
 
+	// PowHasher computes the proof-of-work hash blocks are checked against
+	// their target with.  It is expected to share the RandomX epochs of
+	// the miner so each RandomX cache is only computed once.
+	//
+	// This field is required.
+	PowHasher PowHasher
+
 	// SigCache defines a signature cache to use when when validating
 	// signatures.  This is typically most useful when individual
 	// transactions are already being validated prior to their inclusion in
@@ -2137,6 +2158,9 @@ func New(config *Config) (*BlockChain, error) {
 	if config.TimeSource == nil {
 		return nil, AssertError("blockchain.New timesource is nil")
 	}
+	if config.PowHasher == nil {
+		return nil, AssertError("blockchain.New proof-of-work hasher is nil")
+	}
 
 	// Generate a checkpoint by height map from the provided checkpoints
 	// and assert the provided checkpoints are sorted by height as required.
@@ -2174,6 +2198,7 @@ func New(config *Config) (*BlockChain, error) {
 		index:               newBlockIndex(config.DB, params),
 		utxoCache:           newUtxoCache(config.DB, config.UtxoCacheMaxSize),
 		hashCache:           config.HashCache,
+		powHasher:           config.PowHasher,
 		bestChain:           newChainView(nil),
 		orphans:             make(map[chainhash.Hash]*orphanBlock),
 		prevOrphans:         make(map[chainhash.Hash][]*orphanBlock),
@@ -179,6 +179,7 @@ func chainSetup(dbName string, params *chaincfg.Params) (*BlockChain, func(), er
 		ChainParams: &paramsCopy,
 		Checkpoints: nil,
 		TimeSource:  NewMedianTime(),
+		PowHasher:   testhelper.DoubleHashPowHasher{},
 		SigCache:    txscript.NewSigCache(1000),
 	})
 	if err != nil {
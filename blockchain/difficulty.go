@@ -128,13 +128,14 @@ func findPrevTestNetDifficulty(startNode HeaderCtx, c ChainCtx) uint32 {
 }
 
 // calcNextRequiredDifficulty calculates the required difficulty for the block
-// after the passed previous HeaderCtx based on the difficulty retarget rules.
-// This function differs from the exported CalcNextRequiredDifficulty in that
-// the exported version uses the current best chain as the previous HeaderCtx
-// while this function accepts any block node. This function accepts a ChainCtx
-// parameter that gives the necessary difficulty context variables.
-func calcNextRequiredDifficulty(lastNode HeaderCtx, newBlockTime time.Time,
-	c ChainCtx) (uint32, error) {
+// mined with the passed algorithm after the passed previous HeaderCtx based on
+// the difficulty retarget rules.  This function differs from the exported
+// CalcNextRequiredDifficulty in that the exported version uses the current best
+// chain as the previous HeaderCtx while this function accepts any block node.
+// This function accepts a ChainCtx parameter that gives the necessary
+// difficulty context variables.
+func calcNextRequiredDifficulty(lastNode HeaderCtx, algo PowAlgorithm,
+	newBlockTime time.Time, c ChainCtx) (uint32, error) {
 
 	// Emulate the same behavior as Bitcoin Core that for regtest there is
 	// no difficulty retargeting.
@@ -147,6 +148,11 @@ func calcNextRequiredDifficulty(lastNode HeaderCtx, newBlockTime time.Time,
 		return c.ChainParams().PowLimitBits, nil
 	}
 
+	// Once MobileX is active each algorithm retargets independently.
+	if isMobileXActive(c.ChainParams(), lastNode.Height()+1) {
+		return calcNextAlgoDifficulty(lastNode, algo, newBlockTime, c)
+	}
+
 	// Return the previous block's difficulty requirements if this block
 	// is not at a difficulty retarget interval.
 	if (lastNode.Height()+1)%c.BlocksPerRetarget() != 0 {
@@ -230,14 +236,34 @@ func calcNextRequiredDifficulty(lastNode HeaderCtx, newBlockTime time.Time,
 	return newTargetBits, nil
 }
 
-// CalcNextRequiredDifficulty calculates the required difficulty for the block
-// after the end of the current best chain based on the difficulty retarget
-// rules.
+// CalcNextRequiredDifficulty calculates the required difficulty for the
+// RandomX block after the end of the current best chain based on the
+// difficulty retarget rules.
 //
 // This function is safe for concurrent access.
 func (b *BlockChain) CalcNextRequiredDifficulty(timestamp time.Time) (uint32, error) {
+	return b.CalcNextRequiredAlgoDifficulty(PowAlgoRandomX, timestamp)
+}
+
+// CalcNextRequiredAlgoDifficulty calculates the required difficulty for the
+// block mined with the passed algorithm after the end of the current best
+// chain based on the difficulty retarget rules.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) CalcNextRequiredAlgoDifficulty(algo PowAlgorithm,
+	timestamp time.Time) (uint32, error) {
+
 	b.chainLock.Lock()
-	difficulty, err := calcNextRequiredDifficulty(b.bestChain.Tip(), timestamp, b)
+	difficulty, err := calcNextRequiredDifficulty(b.bestChain.Tip(), algo,
+		timestamp, b)
 	b.chainLock.Unlock()
 	return difficulty, err
 }
+
+// IsMobileXActive returns whether MobileX mining is active for the block after
+// the end of the current best chain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) IsMobileXActive() bool {
+	return isMobileXActive(b.chainParams, b.BestSnapshot().Height+1)
+}
@@ -228,6 +228,10 @@ const (
 	// ErrInvalidThermalProof indicates that a block's thermal proof
 	// for mobile mining failed validation or is missing when required.
 	ErrInvalidThermalProof
+
+	// ErrUnexpectedPowAlgorithm indicates a block's version encodes a
+	// proof-of-work algorithm that is not active at its height.
+	ErrUnexpectedPowAlgorithm
 )
 
 // Map of ErrorCode values back to their constant names for pretty printing.
@@ -277,6 +281,7 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrPrevBlockNotBest:          "ErrPrevBlockNotBest",
 	ErrTimewarpAttack:            "ErrTimewarpAttack",
 	ErrInvalidThermalProof:       "ErrInvalidThermalProof",
+	ErrUnexpectedPowAlgorithm:    "ErrUnexpectedPowAlgorithm",
 }
 
 // String returns the ErrorCode as a human-readable name.
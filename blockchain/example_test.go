@@ -15,6 +15,8 @@ import (
 	"github.com/toole-brendan/shell/database"
 	_ "github.com/toole-brendan/shell/database/ffldb"
 	"github.com/toole-brendan/shell/internal/convert"
+	"github.com/toole-brendan/shell/mining"
+	"github.com/toole-brendan/shell/mining/randomx"
 )
 
 // This example demonstrates how to create a new chain instance and use
@@ -45,10 +47,21 @@ func ExampleBlockChain_ProcessBlock() {
 	// ordinarily keep a reference to the median time source and add time
 	// values obtained from other peers on the network so the local time is
 	// adjusted to be in agreement with other peers.
+	//
+	// Blocks are hashed with the RandomX epochs of a seed manager, which a
+	// node would share with its miner.
+	seeds := randomx.NewSeedManager(&randomx.SeedConfig{
+		GenesisHash: chaincfg.MainNetParams.GenesisHash,
+		Rotation:    chaincfg.MainNetParams.RandomXSeedRotation,
+	})
+	defer seeds.Stop()
+	policy := mining.NewMiningPolicy(&chaincfg.MainNetParams)
+	policy.SetRandomXSeeds(seeds)
 	chain, err := blockchain.New(&blockchain.Config{
 		DB:          db,
 		ChainParams: &chaincfg.MainNetParams,
 		TimeSource:  blockchain.NewMedianTime(),
+		PowHasher:   policy,
 	})
 	if err != nil {
 		fmt.Printf("Failed to create chain instance: %v\n", err)
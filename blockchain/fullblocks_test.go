@@ -14,6 +14,7 @@ import (
 
 	"github.com/toole-brendan/shell/blockchain"
 	"github.com/toole-brendan/shell/blockchain/fullblocktests"
+	"github.com/toole-brendan/shell/blockchain/internal/testhelper"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/toole-brendan/shell/chaincfg"
 	"github.com/toole-brendan/shell/chaincfg/chainhash"
@@ -120,6 +121,7 @@ func chainSetup(dbName string, params *chaincfg.Params) (*blockchain.BlockChain,
 		ChainParams: &paramsCopy,
 		Checkpoints: nil,
 		TimeSource:  blockchain.NewMedianTime(),
+		PowHasher:   testhelper.DoubleHashPowHasher{},
 		SigCache:    txscript.NewSigCache(1000),
 	})
 	if err != nil {
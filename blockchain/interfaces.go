@@ -3,6 +3,7 @@ package blockchain
 import (
 	"github.com/toole-brendan/shell/chaincfg"
 	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/wire"
 )
 
 // ChainCtx is an interface that abstracts away blockchain parameters.
@@ -43,6 +44,9 @@ type HeaderCtx interface {
 	// Bits returns the header's bits.
 	Bits() uint32
 
+	// Version returns the header's version.
+	Version() int32
+
 	// Timestamp returns the header's timestamp.
 	Timestamp() int64
 
@@ -53,3 +57,13 @@ type HeaderCtx interface {
 	// blocks before it in the chain.
 	RelativeAncestorCtx(distance int32) HeaderCtx
 }
+
+// PowHasher is an interface that computes the proof-of-work hash of block
+// headers.  The hash depends on the algorithm encoded in the header version
+// and, since both algorithms are keyed by the RandomX seed of the block's
+// epoch, on the height of the block.
+type PowHasher interface {
+	// PowHash returns the proof-of-work hash of the passed header of the
+	// block at the passed height.
+	PowHash(header *wire.BlockHeader, height int32) (chainhash.Hash, error)
+}
@@ -128,6 +128,16 @@ func MakeSpendableOut(block *wire.MsgBlock, txIndex, txOutIndex uint32) Spendabl
 	return MakeSpendableOutForTx(block.Transactions[txIndex], txOutIndex)
 }
 
+// DoubleHashPowHasher is a proof-of-work hasher that hashes block headers with
+// their double sha256 block hash, which is what SolveBlock solves for.  It
+// lets tests process blocks without computing any RandomX epochs.
+type DoubleHashPowHasher struct{}
+
+// PowHash returns the double sha256 hash of the passed header.
+func (DoubleHashPowHasher) PowHash(header *wire.BlockHeader, height int32) (chainhash.Hash, error) {
+	return header.BlockHash(), nil
+}
+
 // SolveBlock attempts to find a nonce which makes the passed block header hash
 // to a value less than the target difficulty.  When a successful solution is
 // found true is returned and the nonce field of the passed header is updated
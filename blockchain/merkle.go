@@ -192,6 +192,63 @@ func CalcMerkleRoot(transactions []*btcutil.Tx, witness bool) chainhash.Hash {
 	return s.calcMerkleRoot(transactions, witness)
 }
 
+// CoinbaseMerkleBranch returns the merkle branch that links the first
+// transaction of the passed slice, which is expected to be the coinbase, to
+// the merkle root.  Each entry is the right-hand sibling of the coinbase path
+// at successive levels of the tree, starting from the leaves.
+//
+// Since the coinbase is always the left-most leaf, none of the branch entries
+// depend on it.  This allows miners that roll an extra nonce in the coinbase,
+// such as those speaking the Stratum protocol, to recompute the merkle root
+// from the new coinbase hash alone via MerkleRootFromBranch.
+//
+// A block containing only the coinbase has an empty branch since the coinbase
+// hash is the merkle root.
+func CoinbaseMerkleBranch(transactions []*btcutil.Tx) []*chainhash.Hash {
+	if len(transactions) < 2 {
+		return nil
+	}
+
+	level := make([]*chainhash.Hash, len(transactions))
+	for i, tx := range transactions {
+		var h chainhash.Hash
+		copy(h[:], tx.Hash()[:])
+		level[i] = &h
+	}
+
+	var branch []*chainhash.Hash
+	for len(level) > 1 {
+		branch = append(branch, level[1])
+
+		// The parent of the coinbase path is left nil since it is never
+		// part of the branch.  All other parents are calculated the same
+		// way as BuildMerkleTreeStore, including duplicating a lone left
+		// child.
+		next := make([]*chainhash.Hash, (len(level)+1)/2)
+		for i := 2; i < len(level); i += 2 {
+			right := level[i]
+			if i+1 < len(level) {
+				right = level[i+1]
+			}
+			newHash := HashMerkleBranches(level[i], right)
+			next[i/2] = &newHash
+		}
+		level = next
+	}
+
+	return branch
+}
+
+// MerkleRootFromBranch folds the passed coinbase hash through a merkle branch
+// as returned by CoinbaseMerkleBranch and returns the resulting merkle root.
+func MerkleRootFromBranch(coinbaseHash *chainhash.Hash, branch []*chainhash.Hash) chainhash.Hash {
+	root := *coinbaseHash
+	for _, sibling := range branch {
+		root = HashMerkleBranches(&root, sibling)
+	}
+	return root
+}
+
 // ExtractWitnessCommitment attempts to locate, and return the witness
 // commitment for a block. The witness commitment is of the form:
 // SHA256(witness root || witness nonce). The function additionally returns a
@@ -31,6 +31,32 @@ func TestMerkle(t *testing.T) {
 	}
 }
 
+// TestCoinbaseMerkleBranch ensures folding the coinbase hash through the
+// branch returned by CoinbaseMerkleBranch yields the block merkle root for a
+// range of transaction counts, including unbalanced trees.
+func TestCoinbaseMerkleBranch(t *testing.T) {
+	for size := 1; size <= 9; size++ {
+		txs := make([]*btcutil.Tx, size)
+		for i := range txs {
+			msgTx := wire.NewMsgTx(2)
+			msgTx.LockTime = uint32(i)
+			txs[i] = convert.NewShellTx(msgTx)
+		}
+
+		branch := CoinbaseMerkleBranch(txs)
+		coinbaseHash := convert.HashToShell(txs[0].Hash())
+		got := MerkleRootFromBranch(coinbaseHash, branch)
+		want := CalcMerkleRoot(txs, false)
+		require.Equal(t, want, got, "size %d", size)
+	}
+
+	block := convert.NewShellBlock(&Block100000)
+	branch := CoinbaseMerkleBranch(block.Transactions())
+	coinbaseHash := Block100000.Transactions[0].TxHash()
+	got := MerkleRootFromBranch(&coinbaseHash, branch)
+	require.Equal(t, Block100000.Header.MerkleRoot, got)
+}
+
 func makeHashes(size int) []*chainhash.Hash {
 	var hashes = make([]*chainhash.Hash, size)
 	for i := range hashes {
@@ -0,0 +1,308 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/toole-brendan/shell/chaincfg"
+)
+
+// PowAlgorithm identifies the proof-of-work algorithm a block was mined with.
+type PowAlgorithm uint8
+
+const (
+	// PowAlgoRandomX identifies blocks mined with RandomX.  All blocks
+	// before MobileX activation are RandomX blocks.
+	PowAlgoRandomX PowAlgorithm = iota
+
+	// PowAlgoMobileX identifies blocks mined with MobileX.
+	PowAlgoMobileX
+
+	// numPowAlgorithms is the number of proof-of-work algorithms the chain
+	// retargets independently once MobileX is active.
+	numPowAlgorithms = 2
+)
+
+const (
+	// powAlgoMobileXBit is the version bit that marks a block as mined
+	// with MobileX.  It is one of the bits of the version bits scheme that
+	// is not assigned to any deployment and is only valid once MobileX is
+	// active.
+	powAlgoMobileXBit = 27
+
+	// algoAveragingWindow is the number of most recent block intervals of
+	// an algorithm whose targets and timespan are averaged to calculate its
+	// next difficulty.  At two algorithms and 5 minute blocks this covers
+	// roughly 12 hours of blocks per algorithm.
+	algoAveragingWindow = 72
+
+	// algoSearchLimit is the maximum number of blocks searched backwards
+	// from the tip for blocks of an algorithm.  An algorithm with no blocks
+	// within the limit restarts at the proof of work limit so that it can
+	// recover after its hashrate disappeared for a long time.
+	algoSearchLimit = algoAveragingWindow * numPowAlgorithms * 4
+)
+
+// powAlgorithmStrings is a map of proof-of-work algorithms back to their
+// names for pretty printing.
+var powAlgorithmStrings = map[PowAlgorithm]string{
+	PowAlgoRandomX: "randomx",
+	PowAlgoMobileX: "mobilex",
+}
+
+// String returns the PowAlgorithm as a human-readable name.
+func (a PowAlgorithm) String() string {
+	if s, ok := powAlgorithmStrings[a]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown PowAlgorithm (%d)", uint8(a))
+}
+
+// PowAlgorithmFromVersion returns the proof-of-work algorithm encoded in the
+// passed block version.
+func PowAlgorithmFromVersion(version int32) PowAlgorithm {
+	v := uint32(version)
+	if v&vbTopMask == vbTopBits && v&(1<<powAlgoMobileXBit) != 0 {
+		return PowAlgoMobileX
+	}
+	return PowAlgoRandomX
+}
+
+// SetPowAlgorithm returns the passed block version with the passed
+// proof-of-work algorithm encoded in it.  The version must use the version
+// bits scheme for MobileX to be encoded.
+func SetPowAlgorithm(version int32, algo PowAlgorithm) int32 {
+	v := uint32(version) &^ (1 << powAlgoMobileXBit)
+	if algo == PowAlgoMobileX {
+		v |= 1 << powAlgoMobileXBit
+	}
+	return int32(v)
+}
+
+// isMobileXActive returns whether MobileX mining is active for a block at the
+// passed height.
+func isMobileXActive(params *chaincfg.Params, height int32) bool {
+	return height >= params.MobileXActivationHeight
+}
+
+// calcAlgoWork calculates the work a block contributes to the chain once
+// MobileX is active from the latest difficulty bits of each algorithm.
+//
+// Each block contributes the geometric mean of the work of the latest block of
+// every algorithm mined so far regardless of which algorithm mined it.  The
+// work per hash of RandomX and MobileX differs by orders of magnitude, so this
+// keeps either algorithm from dominating chain selection.  Algorithms without
+// any blocks yet are left out so the work is unchanged until the first MobileX
+// block.
+//
+// This does not require an attacker to out-mine both algorithms.  A chain
+// mined with a single algorithm keeps the latest bits of the other one, so
+// with k times the honest hashrate of its algorithm the attacker's difficulty
+// retargets to k times the honest one and each of its blocks is worth sqrt(k)
+// honest blocks.  It only produces blocks of one algorithm though, at half the
+// rate of the honest chain, so the attacker matches the chain's work with
+// about four times the honest hashrate of a single algorithm, regardless of
+// the hashrate of the other algorithm.  The technical specification states
+// this bound as the security assumption of dual-algorithm mining.
+func calcAlgoWork(algoBits *[numPowAlgorithms]uint32) *big.Int {
+	product := big.NewInt(1)
+	var numAlgos int
+	for _, bits := range algoBits {
+		if bits == 0 {
+			continue
+		}
+		product.Mul(product, CalcWork(bits))
+		numAlgos++
+	}
+
+	switch numAlgos {
+	case 0:
+		return big.NewInt(0)
+	case 1:
+		return product
+	}
+
+	// There are only two algorithms, so the geometric mean is the square
+	// root of the product.
+	return product.Sqrt(product)
+}
+
+// calcNextAlgoDifficulty calculates the required difficulty for a block of the
+// passed algorithm after the passed previous HeaderCtx once MobileX is active.
+//
+// Each algorithm retargets every block from the blocks it mined itself,
+// targeting numPowAlgorithms times the chain's block spacing so that each
+// algorithm produces an equal share of blocks.  The new target is the average
+// target of the last algoAveragingWindow blocks of the algorithm scaled by how
+// long those blocks took compared to the expected timespan.  The adjustment is
+// limited by the chain's retarget adjustment factor, and since the average
+// target of the window is used rather than the latest one, the limit bounds
+// the difference to the window instead of compounding every block.
+func calcNextAlgoDifficulty(lastNode HeaderCtx, algo PowAlgorithm,
+	newBlockTime time.Time, c ChainCtx) (uint32, error) {
+
+	params := c.ChainParams()
+
+	// For networks that support it, allow special reduction of the
+	// required difficulty once too much time has elapsed without mining a
+	// block.
+	if params.ReduceMinDifficulty {
+		reductionTime := int64(params.MinDiffReductionTime / time.Second)
+		if newBlockTime.Unix() > lastNode.Timestamp()+reductionTime {
+			return params.PowLimitBits, nil
+		}
+	}
+
+	// Gather the most recent blocks of the algorithm, newest first.
+	window := make([]HeaderCtx, 0, algoAveragingWindow+1)
+	iterNode := lastNode
+	for i := 0; iterNode != nil && i < algoSearchLimit; i++ {
+		if PowAlgorithmFromVersion(iterNode.Version()) == algo {
+			window = append(window, iterNode)
+			if len(window) > algoAveragingWindow {
+				break
+			}
+		}
+		iterNode = iterNode.Parent()
+	}
+
+	// An algorithm starts at the proof of work limit and keeps the
+	// difficulty of its first block until there is an interval to measure.
+	switch len(window) {
+	case 0:
+		return params.PowLimitBits, nil
+	case 1:
+		return window[0].Bits(), nil
+	}
+
+	// Average the targets of the blocks that end each interval.
+	intervals := int64(len(window) - 1)
+	avgTarget := new(big.Int)
+	for _, node := range window[:intervals] {
+		avgTarget.Add(avgTarget, CompactToBig(node.Bits()))
+	}
+	avgTarget.Div(avgTarget, big.NewInt(intervals))
+
+	// Limit the amount of adjustment that can occur to the average
+	// difficulty of the window.
+	targetTimePerBlock := int64(params.TargetTimePerBlock / time.Second)
+	targetTimespan := intervals * targetTimePerBlock * numPowAlgorithms
+	minTimespan := targetTimespan / params.RetargetAdjustmentFactor
+	maxTimespan := targetTimespan * params.RetargetAdjustmentFactor
+	actualTimespan := window[0].Timestamp() - window[intervals].Timestamp()
+	adjustedTimespan := actualTimespan
+	if actualTimespan < minTimespan {
+		adjustedTimespan = minTimespan
+	} else if actualTimespan > maxTimespan {
+		adjustedTimespan = maxTimespan
+	}
+
+	// Calculate new target difficulty as:
+	//  averageTarget * (adjustedTimespan / targetTimespan)
+	newTarget := new(big.Int).Mul(avgTarget, big.NewInt(adjustedTimespan))
+	newTarget.Div(newTarget, big.NewInt(targetTimespan))
+
+	// Limit new value to the proof of work limit.
+	if newTarget.Cmp(params.PowLimit) > 0 {
+		newTarget.Set(params.PowLimit)
+	}
+
+	newTargetBits := BigToCompact(newTarget)
+	log.Tracef("%v difficulty at block height %d: %08x over %d "+
+		"intervals (actual timespan %v, adjusted timespan %v, target "+
+		"timespan %v)", algo, lastNode.Height()+1, newTargetBits,
+		intervals, time.Duration(actualTimespan)*time.Second,
+		time.Duration(adjustedTimespan)*time.Second,
+		time.Duration(targetTimespan)*time.Second)
+
+	return newTargetBits, nil
+}
+
+// AlgoDistribution summarizes the blocks of the main chain a proof-of-work
+// algorithm mined within a range of heights.
+type AlgoDistribution struct {
+	// Algorithm is the proof-of-work algorithm.
+	Algorithm PowAlgorithm
+
+	// Blocks is the number of blocks the algorithm mined.
+	Blocks int32
+
+	// Work is the work the algorithm proved for its blocks according to
+	// their difficulty bits.  The work of different algorithms is not
+	// comparable.
+	Work *big.Int
+
+	// ChainWork is the work the blocks of the algorithm added to the
+	// chain, which is normalized across the algorithms once MobileX is
+	// active.  See calcAlgoWork for details.
+	ChainWork *big.Int
+
+	// LastHeight is the height of the latest block the algorithm mined
+	// within the range, or -1 when it mined none.
+	LastHeight int32
+}
+
+// MiningDistribution summarizes the blocks of the main chain within a range of
+// heights by the proof-of-work algorithm they were mined with.
+type MiningDistribution struct {
+	// StartHeight and EndHeight are the heights of the first and last
+	// block of the range.
+	StartHeight int32
+	EndHeight   int32
+
+	// Algorithms holds a summary for every proof-of-work algorithm,
+	// including those that mined no blocks, ordered by algorithm.
+	Algorithms []AlgoDistribution
+}
+
+// MiningDistribution returns how the passed number of most recent blocks of
+// the main chain are distributed over the proof-of-work algorithms.  The
+// window is limited to the blocks of the main chain.  The algorithm of each
+// block is read from the block index, so no blocks are loaded.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) MiningDistribution(window int32) (*MiningDistribution, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("window of %d blocks is not positive",
+			window)
+	}
+
+	tip := b.bestChain.Tip()
+	dist := &MiningDistribution{
+		StartHeight: tip.height,
+		EndHeight:   tip.height,
+		Algorithms:  make([]AlgoDistribution, numPowAlgorithms),
+	}
+	for i := range dist.Algorithms {
+		dist.Algorithms[i] = AlgoDistribution{
+			Algorithm:  PowAlgorithm(i),
+			Work:       new(big.Int),
+			ChainWork:  new(big.Int),
+			LastHeight: -1,
+		}
+	}
+
+	node := tip
+	for i := int32(0); node != nil && i < window; i++ {
+		algo := &dist.Algorithms[node.powAlgo]
+		algo.Blocks++
+		algo.Work.Add(algo.Work, CalcWork(node.bits))
+		algo.ChainWork.Add(algo.ChainWork, node.workSum)
+		if node.parent != nil {
+			algo.ChainWork.Sub(algo.ChainWork, node.parent.workSum)
+		}
+		if algo.LastHeight < 0 {
+			algo.LastHeight = node.height
+		}
+
+		dist.StartHeight = node.height
+		node = node.parent
+	}
+
+	return dist, nil
+}
@@ -0,0 +1,481 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/toole-brendan/shell/chaincfg"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/internal/convert"
+	"github.com/toole-brendan/shell/wire"
+)
+
+const (
+	// testRandomXBits and testMobileXBits are the difficulties used for
+	// the blocks of each algorithm in the dual-algorithm tests.
+	testRandomXBits = 0x1c0fffff
+	testMobileXBits = 0x1d00ffff
+)
+
+// dualAlgoParams returns a copy of the main network parameters with MobileX
+// active at the passed height.
+func dualAlgoParams(activationHeight int32) *chaincfg.Params {
+	params := chaincfg.MainNetParams
+	params.MobileXActivationHeight = activationHeight
+	return &params
+}
+
+// algoVersion returns a version bits block version for the passed algorithm.
+func algoVersion(algo PowAlgorithm) int32 {
+	return SetPowAlgorithm(vbTopBits, algo)
+}
+
+// extendAlgoChain extends the passed node with blocks mined with the passed
+// algorithms in order, each spacing after the previous one.
+func extendAlgoChain(node *blockNode, algos []PowAlgorithm,
+	spacing time.Duration) *blockNode {
+
+	for _, algo := range algos {
+		bits := uint32(testRandomXBits)
+		if algo == PowAlgoMobileX {
+			bits = testMobileXBits
+		}
+		timestamp := time.Unix(node.timestamp, 0).Add(spacing)
+		node = newFakeNode(node, algoVersion(algo), bits, timestamp)
+	}
+	return node
+}
+
+// repeatAlgos returns the passed algorithm pattern repeated count times.
+func repeatAlgos(count int, pattern ...PowAlgorithm) []PowAlgorithm {
+	algos := make([]PowAlgorithm, 0, count*len(pattern))
+	for i := 0; i < count; i++ {
+		algos = append(algos, pattern...)
+	}
+	return algos
+}
+
+// scaleBits returns the passed difficulty bits with the target multiplied by
+// num/denom.
+func scaleBits(bits uint32, num, denom int64) uint32 {
+	target := CompactToBig(bits)
+	target.Mul(target, big.NewInt(num))
+	target.Div(target, big.NewInt(denom))
+	return BigToCompact(target)
+}
+
+// TestPowAlgorithmVersion ensures the proof-of-work algorithm round trips
+// through the block version without disturbing the other version bits.
+func TestPowAlgorithmVersion(t *testing.T) {
+	tests := []struct {
+		version int32
+		algo    PowAlgorithm
+		want    int32
+	}{
+		{vbTopBits, PowAlgoRandomX, vbTopBits},
+		{vbTopBits, PowAlgoMobileX, vbTopBits | 1<<powAlgoMobileXBit},
+		{vbTopBits | 0x3, PowAlgoMobileX, vbTopBits | 1<<powAlgoMobileXBit | 0x3},
+		{vbTopBits | 1<<powAlgoMobileXBit, PowAlgoRandomX, vbTopBits},
+	}
+
+	for i, test := range tests {
+		got := SetPowAlgorithm(test.version, test.algo)
+		if got != test.want {
+			t.Errorf("SetPowAlgorithm #%d: got %08x, want %08x", i,
+				got, test.want)
+			continue
+		}
+		if algo := PowAlgorithmFromVersion(got); algo != test.algo {
+			t.Errorf("PowAlgorithmFromVersion #%d: got %v, want %v",
+				i, algo, test.algo)
+		}
+	}
+
+	// The algorithm bit only applies to version bits versions.
+	legacy := int32(vbLegacyBlockVersion | 1<<powAlgoMobileXBit)
+	if algo := PowAlgorithmFromVersion(legacy); algo != PowAlgoRandomX {
+		t.Errorf("PowAlgorithmFromVersion: got %v for legacy version, "+
+			"want %v", algo, PowAlgoRandomX)
+	}
+}
+
+// TestCalcNextAlgoDifficulty ensures each algorithm retargets independently
+// from its own blocks once MobileX is active.
+func TestCalcNextAlgoDifficulty(t *testing.T) {
+	params := dualAlgoParams(1)
+	chain := newFakeChain(params)
+	genesis := chain.bestChain.Tip()
+	blockSpacing := params.TargetTimePerBlock
+	both := []PowAlgorithm{PowAlgoRandomX, PowAlgoMobileX}
+
+	tests := []struct {
+		name    string
+		tip     *blockNode
+		algo    PowAlgorithm
+		want    uint32
+		wantErr bool
+	}{{
+		name: "first MobileX block starts at the limit",
+		tip: extendAlgoChain(genesis, repeatAlgos(10,
+			PowAlgoRandomX), blockSpacing),
+		algo: PowAlgoMobileX,
+		want: params.PowLimitBits,
+	}, {
+		name: "algorithms alternating on schedule",
+		tip:  extendAlgoChain(genesis, repeatAlgos(100, both...), blockSpacing),
+		algo: PowAlgoRandomX,
+		want: testRandomXBits,
+	}, {
+		name: "MobileX alternating on schedule",
+		tip:  extendAlgoChain(genesis, repeatAlgos(100, both...), blockSpacing),
+		algo: PowAlgoMobileX,
+		want: testMobileXBits,
+	}, {
+		name: "MobileX mining twice as fast",
+		tip: extendAlgoChain(genesis, repeatAlgos(100,
+			PowAlgoMobileX), blockSpacing),
+		algo: PowAlgoMobileX,
+		want: scaleBits(testMobileXBits, 1, 2),
+	}, {
+		name: "adjustment limited by the retarget factor",
+		tip: extendAlgoChain(genesis, repeatAlgos(100,
+			PowAlgoMobileX), time.Second),
+		algo: PowAlgoMobileX,
+		want: scaleBits(testMobileXBits, 1,
+			params.RetargetAdjustmentFactor),
+	}, {
+		name: "RandomX unaffected by fast MobileX blocks",
+		tip: extendAlgoChain(extendAlgoChain(genesis, repeatAlgos(100,
+			both...), blockSpacing), repeatAlgos(20,
+			PowAlgoMobileX), time.Second),
+		algo: PowAlgoRandomX,
+		want: testRandomXBits,
+	}}
+
+	for _, test := range tests {
+		// Use the time of the next scheduled block so the difficulty is
+		// only determined by the previous blocks.
+		newBlockTime := time.Unix(test.tip.timestamp, 0).Add(blockSpacing)
+		got, err := calcNextRequiredDifficulty(test.tip, test.algo,
+			newBlockTime, chain)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got bits %08x, want %08x", test.name, got,
+				test.want)
+		}
+	}
+}
+
+// TestCalcAlgoWork ensures every block contributes the normalized work of both
+// algorithms once a MobileX block has been mined.
+func TestCalcAlgoWork(t *testing.T) {
+	chain := newFakeChain(dualAlgoParams(1))
+	genesis := chain.bestChain.Tip()
+	spacing := chain.chainParams.TargetTimePerBlock
+
+	// Before any MobileX block, blocks contribute their own work.
+	randomXNode := extendAlgoChain(genesis, []PowAlgorithm{PowAlgoRandomX},
+		spacing)
+	gotWork := new(big.Int).Sub(randomXNode.workSum, genesis.workSum)
+	if wantWork := CalcWork(testRandomXBits); gotWork.Cmp(wantWork) != 0 {
+		t.Fatalf("RandomX block work: got %v, want %v", gotWork,
+			wantWork)
+	}
+
+	// Afterwards every block contributes the geometric mean of the work of
+	// the latest block of each algorithm.
+	wantWork := new(big.Int).Mul(CalcWork(testRandomXBits),
+		CalcWork(testMobileXBits))
+	wantWork.Sqrt(wantWork)
+
+	prevNode := randomXNode
+	for _, algo := range []PowAlgorithm{PowAlgoMobileX, PowAlgoRandomX} {
+		node := extendAlgoChain(prevNode, []PowAlgorithm{algo}, spacing)
+		gotWork := new(big.Int).Sub(node.workSum, prevNode.workSum)
+		if gotWork.Cmp(wantWork) != 0 {
+			t.Fatalf("%v block work: got %v, want %v", algo, gotWork,
+				wantWork)
+		}
+		prevNode = node
+	}
+}
+
+// TestMobileXBeforeActivation ensures blocks claiming to be mined with MobileX
+// are rejected before it activates.
+func TestMobileXBeforeActivation(t *testing.T) {
+	chain := newFakeChain(dualAlgoParams(10))
+	genesis := chain.bestChain.Tip()
+
+	header := &wire.BlockHeader{
+		Version:   algoVersion(PowAlgoMobileX),
+		PrevBlock: genesis.hash,
+		Bits:      testMobileXBits,
+		Timestamp: time.Unix(genesis.timestamp, 0).Add(time.Minute),
+	}
+	err := CheckBlockHeaderContext(header, genesis, BFFastAdd, chain, true)
+	var ruleErr RuleError
+	if !errors.As(err, &ruleErr) ||
+		ruleErr.ErrorCode != ErrUnexpectedPowAlgorithm {
+
+		t.Fatalf("unexpected error: got %v, want %v", err,
+			ErrUnexpectedPowAlgorithm)
+	}
+
+	// RandomX blocks are still accepted.
+	header.Version = algoVersion(PowAlgoRandomX)
+	err = CheckBlockHeaderContext(header, genesis, BFFastAdd, chain, true)
+	if err != nil {
+		t.Fatalf("unexpected error for RandomX block: %v", err)
+	}
+}
+
+// algoHasher is a proof-of-work hasher that returns a fixed hash for each
+// algorithm, or err when set, and records the heights it hashed blocks at.
+type algoHasher struct {
+	hashes  map[PowAlgorithm]chainhash.Hash
+	err     error
+	heights []int32
+}
+
+// PowHash returns the hash of the algorithm of the passed header.
+func (h *algoHasher) PowHash(header *wire.BlockHeader, height int32) (chainhash.Hash, error) {
+	h.heights = append(h.heights, height)
+	if h.err != nil {
+		return chainhash.Hash{}, h.err
+	}
+	return h.hashes[PowAlgorithmFromVersion(header.Version)], nil
+}
+
+// TestCheckPowHash ensures blocks are checked against the proof-of-work hash
+// of the algorithm they were mined with rather than their block hash.
+func TestCheckPowHash(t *testing.T) {
+	var highHash chainhash.Hash
+	for i := range highHash {
+		highHash[i] = 0xff
+	}
+	hasher := &algoHasher{
+		hashes: map[PowAlgorithm]chainhash.Hash{
+			PowAlgoRandomX: {},
+			PowAlgoMobileX: highHash,
+		},
+	}
+	chain := newFakeChain(dualAlgoParams(0))
+	chain.powHasher = hasher
+
+	header := &wire.BlockHeader{
+		Version: algoVersion(PowAlgoRandomX),
+		Bits:    testRandomXBits,
+	}
+	if err := chain.checkPowHash(header, 5, BFNone); err != nil {
+		t.Fatalf("unexpected error for RandomX block: %v", err)
+	}
+
+	header.Version = algoVersion(PowAlgoMobileX)
+	err := chain.checkPowHash(header, 6, BFNone)
+	var ruleErr RuleError
+	if !errors.As(err, &ruleErr) || ruleErr.ErrorCode != ErrHighHash {
+		t.Fatalf("unexpected error for MobileX block: got %v, want %v",
+			err, ErrHighHash)
+	}
+
+	// The check is skipped without hashing when requested.
+	if err := chain.checkPowHash(header, 7, BFNoPoWCheck); err != nil {
+		t.Fatalf("unexpected error without proof of work check: %v", err)
+	}
+
+	if len(hasher.heights) != 2 || hasher.heights[0] != 5 ||
+		hasher.heights[1] != 6 {
+
+		t.Fatalf("blocks hashed at heights %v, want [5 6]",
+			hasher.heights)
+	}
+
+	// Failing to compute the hash is not blamed on the block.
+	hasher.err = errors.New("seed unavailable")
+	err = chain.checkPowHash(header, 8, BFNone)
+	if err == nil || !errors.Is(err, hasher.err) {
+		t.Fatalf("unexpected error for failed hash: got %v, want %v",
+			err, hasher.err)
+	}
+	if errors.As(err, &ruleErr) {
+		t.Fatalf("failed hash reported as rule error %v", err)
+	}
+}
+
+// TestCheckOrphanPowHash ensures the proof of work of orphan blocks is checked
+// at the height claimed by their coinbase.
+func TestCheckOrphanPowHash(t *testing.T) {
+	var highHash chainhash.Hash
+	for i := range highHash {
+		highHash[i] = 0xff
+	}
+	hasher := &algoHasher{
+		hashes: map[PowAlgorithm]chainhash.Hash{
+			PowAlgoRandomX: {},
+			PowAlgoMobileX: highHash,
+		},
+	}
+	chain := newFakeChain(dualAlgoParams(0))
+	chain.powHasher = hasher
+
+	// The coinbase claims a height of 300.
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+		wire.MaxPrevOutIndex), []byte{0x02, 0x2c, 0x01}, nil))
+	coinbase.AddTxOut(wire.NewTxOut(0, []byte{0x51}))
+	newBlock := func(algo PowAlgorithm) *btcutil.Block {
+		return convert.NewShellBlock(&wire.MsgBlock{
+			Header: wire.BlockHeader{
+				Version: algoVersion(algo),
+				Bits:    testRandomXBits,
+			},
+			Transactions: []*wire.MsgTx{coinbase},
+		})
+	}
+
+	err := chain.checkOrphanPowHash(newBlock(PowAlgoRandomX), BFNone)
+	if err != nil {
+		t.Fatalf("unexpected error for RandomX orphan: %v", err)
+	}
+
+	err = chain.checkOrphanPowHash(newBlock(PowAlgoMobileX), BFNone)
+	var ruleErr RuleError
+	if !errors.As(err, &ruleErr) || ruleErr.ErrorCode != ErrHighHash {
+		t.Fatalf("unexpected error for MobileX orphan: got %v, want %v",
+			err, ErrHighHash)
+	}
+
+	if len(hasher.heights) != 2 || hasher.heights[0] != 300 ||
+		hasher.heights[1] != 300 {
+
+		t.Fatalf("orphans hashed at heights %v, want [300 300]",
+			hasher.heights)
+	}
+}
+
+// TestMiningDistribution ensures the most recent blocks of the main chain are
+// summarized by the algorithm recorded for them in the block index.
+func TestMiningDistribution(t *testing.T) {
+	chain := newFakeChain(dualAlgoParams(1))
+	genesis := chain.bestChain.Tip()
+	spacing := chain.chainParams.TargetTimePerBlock
+
+	// Heights 1 through 5 are mined with RandomX, MobileX, RandomX,
+	// RandomX and MobileX.
+	tip := extendAlgoChain(genesis, []PowAlgorithm{PowAlgoRandomX,
+		PowAlgoMobileX, PowAlgoRandomX, PowAlgoRandomX, PowAlgoMobileX},
+		spacing)
+	chain.bestChain.SetTip(tip)
+
+	tests := []struct {
+		name       string
+		window     int32
+		start      int32
+		blocks     [numPowAlgorithms]int32
+		lastHeight [numPowAlgorithms]int32
+	}{
+		{"single block", 1, 5, [2]int32{0, 1}, [2]int32{-1, 5}},
+		{"recent blocks", 3, 3, [2]int32{2, 1}, [2]int32{4, 5}},
+		{"whole chain", 100, 0, [2]int32{4, 2}, [2]int32{4, 5}},
+	}
+
+	for _, test := range tests {
+		dist, err := chain.MiningDistribution(test.window)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+		if dist.StartHeight != test.start || dist.EndHeight != tip.height {
+			t.Errorf("%s: got heights %d-%d, want %d-%d", test.name,
+				dist.StartHeight, dist.EndHeight, test.start,
+				tip.height)
+		}
+
+		// The chain work of the algorithms adds up to the work of the
+		// range.
+		chainWork := new(big.Int)
+		for i, algo := range dist.Algorithms {
+			if algo.Algorithm != PowAlgorithm(i) {
+				t.Errorf("%s: got %v at index %d", test.name,
+					algo.Algorithm, i)
+			}
+			if algo.Blocks != test.blocks[i] ||
+				algo.LastHeight != test.lastHeight[i] {
+
+				t.Errorf("%s: %v mined %d blocks up to height %d, "+
+					"want %d up to %d", test.name, algo.Algorithm,
+					algo.Blocks, algo.LastHeight, test.blocks[i],
+					test.lastHeight[i])
+			}
+			chainWork.Add(chainWork, algo.ChainWork)
+		}
+		wantWork := new(big.Int).Set(tip.workSum)
+		if start := tip.Ancestor(test.start - 1); start != nil {
+			wantWork.Sub(wantWork, start.workSum)
+		}
+		if chainWork.Cmp(wantWork) != 0 {
+			t.Errorf("%s: got chain work %v, want %v", test.name,
+				chainWork, wantWork)
+		}
+	}
+
+	// MobileX blocks prove work according to their own difficulty.
+	dist, err := chain.MiningDistribution(2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mobileX := dist.Algorithms[PowAlgoMobileX]
+	if wantWork := CalcWork(testMobileXBits); mobileX.Work.Cmp(wantWork) != 0 {
+		t.Errorf("MobileX work: got %v, want %v", mobileX.Work, wantWork)
+	}
+
+	if _, err := chain.MiningDistribution(0); err == nil {
+		t.Errorf("MiningDistribution: expected error for empty window")
+	}
+}
+
+// TestBlockNodePowMetadata ensures the algorithm and thermal proof of blocks
+// are recorded in the block index and restored with their headers.
+func TestBlockNodePowMetadata(t *testing.T) {
+	chain := newFakeChain(dualAlgoParams(1))
+	genesis := chain.bestChain.Tip()
+
+	header := wire.BlockHeader{
+		Version:      algoVersion(PowAlgoMobileX),
+		PrevBlock:    genesis.hash,
+		Timestamp:    time.Unix(genesis.timestamp, 0).Add(time.Minute),
+		Bits:         testMobileXBits,
+		ThermalProof: 0x0102030405060708,
+	}
+	node := newBlockNode(&header, genesis)
+	chain.index.AddNode(node)
+
+	if node.powAlgo != PowAlgoMobileX {
+		t.Errorf("got algorithm %v, want %v", node.powAlgo,
+			PowAlgoMobileX)
+	}
+	if got := node.Header(); got != header {
+		t.Errorf("Header: got %+v, want %+v", got, header)
+	}
+
+	algo, err := chain.BlockPowAlgorithm(&node.hash)
+	if err != nil {
+		t.Fatalf("BlockPowAlgorithm: unexpected error: %v", err)
+	}
+	if algo != PowAlgoMobileX {
+		t.Errorf("BlockPowAlgorithm: got %v, want %v", algo,
+			PowAlgoMobileX)
+	}
+	if _, err := chain.BlockPowAlgorithm(&header.MerkleRoot); err == nil {
+		t.Errorf("BlockPowAlgorithm: expected error for unknown block")
+	}
+}
@@ -130,6 +130,29 @@ func (b *BlockChain) processOrphans(hash *chainhash.Hash, flags BehaviorFlags) e
 	return nil
 }
 
+// checkOrphanPowHash ensures the proof-of-work hash of the passed orphan block
+// is less than the target difficulty claimed by its bits.  The hash depends on
+// the RandomX epoch of the block, so it is computed at the height the block
+// claims in its coinbase since the height of its parent is not known.  The
+// block is checked again at its actual height once it is connected.
+//
+// The flags modify the behavior of this function as follows:
+//   - BFNoPoWCheck: The check is not performed.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) checkOrphanPowHash(block *btcutil.Block, flags BehaviorFlags) error {
+	if flags&BFNoPoWCheck == BFNoPoWCheck {
+		return nil
+	}
+
+	height, err := ExtractCoinbaseHeight(block.Transactions()[0])
+	if err != nil {
+		return err
+	}
+	header := convert.ToShellBlockHeader(&block.MsgBlock().Header)
+	return b.checkPowHash(header, height, flags)
+}
+
 // ProcessBlock is the main workhorse for handling insertion of new blocks into
 // the block chain.  It includes functionality such as rejecting duplicate
 // blocks, ensuring blocks follow all rules, orphan handling, and insertion into
@@ -218,6 +241,14 @@ func (b *BlockChain) ProcessBlock(block *btcutil.Block, flags BehaviorFlags) (bo
 		return false, false, err
 	}
 	if !prevHashExists {
+		// Orphans are kept in memory until their parent arrives, so
+		// their proof of work is checked now rather than when they are
+		// connected to keep the orphan pool from being filled for free.
+		err := b.checkOrphanPowHash(block, flags)
+		if err != nil {
+			return false, false, err
+		}
+
 		log.Infof("Adding orphan block %v with parent %v", blockHash, prevHash)
 		b.addOrphanBlock(block)
 
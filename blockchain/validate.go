@@ -309,13 +309,13 @@ func CheckTransactionSanity(tx *btcutil.Tx) error {
 }
 
 // checkProofOfWork ensures the block header bits which indicate the target
-// difficulty is in min/max range and that the block hash is less than the
-// target difficulty as claimed.
+// difficulty is in min/max range.
 //
-// The flags modify the behavior of this function as follows:
-//   - BFNoPoWCheck: The check to ensure the block hash is less than the target
-//     difficulty is not performed.
-func checkProofOfWork(header *wire.BlockHeader, powLimit *big.Int, flags BehaviorFlags) error {
+// Whether the block hash is less than the target difficulty is not checked
+// here since the proof-of-work hash depends on the algorithm the block was
+// mined with and on the RandomX epoch of its height, which is only known once
+// the block connects to the chain.  See checkPowHash.
+func checkProofOfWork(header *wire.BlockHeader, powLimit *big.Int) error {
 	// The target difficulty must be larger than zero.
 	target := CompactToBig(header.Bits)
 	if target.Sign() <= 0 {
@@ -331,27 +331,50 @@ func checkProofOfWork(header *wire.BlockHeader, powLimit *big.Int, flags Behavio
 		return ruleError(ErrUnexpectedDifficulty, str)
 	}
 
-	// The block hash must be less than the claimed target unless the flag
-	// to avoid proof of work checks is set.
-	if flags&BFNoPoWCheck != BFNoPoWCheck {
-		// The block hash must be less than the claimed target.
-		hash := header.BlockHash()
-		hashNum := HashToBig(&hash)
-		if hashNum.Cmp(target) > 0 {
-			str := fmt.Sprintf("block hash of %064x is higher than "+
-				"expected max of %064x", hashNum, target)
-			return ruleError(ErrHighHash, str)
-		}
-	}
-
 	return nil
 }
 
 // CheckProofOfWork ensures the block header bits which indicate the target
-// difficulty is in min/max range and that the block hash is less than the
-// target difficulty as claimed.
+// difficulty is in min/max range.  The proof-of-work hash itself is checked
+// by the chain with its PowHasher when the block is connected.
 func CheckProofOfWork(block *btcutil.Block, powLimit *big.Int) error {
-	return checkProofOfWork(convert.ToShellBlockHeader(&block.MsgBlock().Header), powLimit, BFNone)
+	return checkProofOfWork(convert.ToShellBlockHeader(&block.MsgBlock().Header), powLimit)
+}
+
+// checkPowHash ensures the proof-of-work hash of the passed header of the
+// block at the passed height is less than the target difficulty claimed by
+// its bits.  The hash is computed by the chain's PowHasher with the algorithm
+// encoded in the header version, so RandomX blocks are hashed with RandomX
+// and MobileX blocks with MobileX, both keyed by the RandomX seed of the
+// block's epoch.
+//
+// The flags modify the behavior of this function as follows:
+//   - BFNoPoWCheck: The check is not performed.
+func (b *BlockChain) checkPowHash(header *wire.BlockHeader, height int32, flags BehaviorFlags) error {
+	if flags&BFNoPoWCheck == BFNoPoWCheck {
+		return nil
+	}
+
+	// Failing to compute the hash is a local failure rather than a fault of
+	// the block, so it is not reported as a rule error.
+	hash, err := b.powHasher.PowHash(header, height)
+	if err != nil {
+		return fmt.Errorf("unable to compute the %v proof-of-work hash "+
+			"of the block: %w", PowAlgorithmFromVersion(header.Version),
+			err)
+	}
+
+	// The block hash must be less than the claimed target.
+	target := CompactToBig(header.Bits)
+	hashNum := HashToBig(&hash)
+	if hashNum.Cmp(target) > 0 {
+		str := fmt.Sprintf("%v block hash of %064x is higher than "+
+			"expected max of %064x",
+			PowAlgorithmFromVersion(header.Version), hashNum, target)
+		return ruleError(ErrHighHash, str)
+	}
+
+	return nil
 }
 
 // CountSigOps returns the number of signature operations for all transaction
@@ -436,15 +459,14 @@ func CountP2SHSigOps(tx *btcutil.Tx, isCoinBaseTx bool, utxoView *UtxoViewpoint)
 // ensure it is sane before continuing with processing.  These checks are
 // context free.
 //
-// The flags do not modify the behavior of this function directly, however they
-// are needed to pass along to checkProofOfWork.
+// The flags do not modify the behavior of this function.  The proof-of-work
+// hash is checked once the height of the block is known, see checkPowHash.
 func CheckBlockHeaderSanity(header *wire.BlockHeader, powLimit *big.Int,
 	timeSource MedianTimeSource, flags BehaviorFlags) error {
 
-	// Ensure the proof of work bits in the block header is in min/max range
-	// and the block hash is less than the target value described by the
-	// bits.
-	err := checkProofOfWork(header, powLimit, flags)
+	// Ensure the proof of work bits in the block header is in min/max
+	// range.
+	err := checkProofOfWork(header, powLimit)
 	if err != nil {
 		return err
 	}
@@ -695,6 +717,7 @@ func CheckBlockHeaderContext(header *wire.BlockHeader, prevNode HeaderCtx,
 	blockHeight := prevNode.Height() + 1
 
 	params := c.ChainParams()
+	algo := PowAlgorithmFromVersion(header.Version)
 
 	fastAdd := flags&BFFastAdd == BFFastAdd
 	if !fastAdd {
@@ -702,7 +725,7 @@ func CheckBlockHeaderContext(header *wire.BlockHeader, prevNode HeaderCtx,
 		// the calculated difficulty based on the previous block and
 		// difficulty retarget rules.
 		expectedDifficulty, err := calcNextRequiredDifficulty(
-			prevNode, header.Timestamp, c,
+			prevNode, algo, header.Timestamp, c,
 		)
 		if err != nil {
 			return err
@@ -738,7 +761,7 @@ func CheckBlockHeaderContext(header *wire.BlockHeader, prevNode HeaderCtx,
 
 		// MobileX Thermal Proof Validation
 		// Check if MobileX deployment is active
-		if blockHeight >= params.MobileXActivationHeight {
+		if isMobileXActive(params, blockHeight) {
 			// Validate thermal proof for mobile mining
 			if header.ThermalProof == 0 {
 				return ruleError(ErrInvalidThermalProof,
@@ -760,6 +783,14 @@ func CheckBlockHeaderContext(header *wire.BlockHeader, prevNode HeaderCtx,
 		}
 	}
 
+	// Reject blocks claiming to be mined with MobileX before it is active
+	// since the algorithm determines the difficulty and work of the block.
+	if algo != PowAlgoRandomX && !isMobileXActive(params, blockHeight) {
+		str := fmt.Sprintf("block at height %d is mined with %v before "+
+			"it is active", blockHeight, algo)
+		return ruleError(ErrUnexpectedPowAlgorithm, str)
+	}
+
 	// Reject outdated block versions once a majority of the network
 	// has upgraded.  These were originally voted on by BIP0034,
 	// BIP0065, and BIP0066.
@@ -842,7 +873,15 @@ func assertNoTimeWarp(blockHeight, blocksPerReTarget int32, headerTimestamp,
 func (b *BlockChain) checkBlockContext(block *btcutil.Block, prevNode *blockNode, flags BehaviorFlags) error {
 	// Perform all block header related validation checks.
 	header := &block.MsgBlock().Header
-	err := CheckBlockHeaderContext(convert.ToShellBlockHeader(header), prevNode, flags, b, false)
+	shellHeader := convert.ToShellBlockHeader(header)
+	err := CheckBlockHeaderContext(shellHeader, prevNode, flags, b, false)
+	if err != nil {
+		return err
+	}
+
+	// Ensure the proof-of-work hash of the block is less than its target
+	// now that its height, and thereby its RandomX epoch, is known.
+	err = b.checkPowHash(shellHeader, prevNode.height+1, flags)
 	if err != nil {
 		return err
 	}
@@ -1346,6 +1385,18 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *btcutil.Block, vi
 		scriptFlags |= txscript.ScriptVerifyTaproot
 	}
 
+	// Enforce the vault policies of Shell vault leaves once the vault
+	// covenant soft-fork is active.
+	vaultState, err := b.deploymentState(
+		node.parent, chaincfg.DeploymentVaultCovenants,
+	)
+	if err != nil {
+		return err
+	}
+	if vaultState == ThresholdActive {
+		scriptFlags |= txscript.ScriptVerifyShellVault
+	}
+
 	// Now that the inexpensive checks are done and have passed, verify the
 	// transactions are actually allowed to spend the coins by running the
 	// expensive ECDSA signature check scripts.  Doing this last helps
@@ -1497,6 +1548,18 @@ func isValidThermalProof(header *wire.BlockHeader) bool {
 	return true
 }
 
+// CheckThermalProof ensures the thermal proof in the passed MobileX block
+// header is present and within the range accepted by consensus.  It allows
+// callers that have the thermal proof of a block before it is handed to the
+// chain, such as the mining RPCs, to reject invalid proofs early.
+func CheckThermalProof(header *wire.BlockHeader) error {
+	if !isValidThermalProof(header) {
+		return ruleError(ErrInvalidThermalProof,
+			"block thermal proof failed validation")
+	}
+	return nil
+}
+
 // A compile-time assertion to ensure BlockChain implements the ChainCtx
 // interface.
 var _ ChainCtx = (*BlockChain)(nil)
@@ -92,6 +92,12 @@ func (c bitConditionChecker) MinerConfirmationWindow() uint32 {
 //
 // This is part of the thresholdConditionChecker interface implementation.
 func (c bitConditionChecker) Condition(node *blockNode) (bool, error) {
+	// The MobileX algorithm bit is not a rule change, so it never counts
+	// toward an unknown rule activation.
+	if c.bit == powAlgoMobileXBit {
+		return false, nil
+	}
+
 	conditionMask := uint32(1) << c.bit
 	version := uint32(node.version)
 	if version&vbTopMask != vbTopBits {
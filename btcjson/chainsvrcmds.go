@@ -572,6 +572,22 @@ func NewGetMiningInfoCmd() *GetMiningInfoCmd {
 	return &GetMiningInfoCmd{}
 }
 
+// GetMiningDistributionCmd defines the getminingdistribution JSON-RPC command.
+type GetMiningDistributionCmd struct {
+	Window *int32 `jsonrpcdefault:"144"`
+}
+
+// NewGetMiningDistributionCmd returns a new instance which can be used to issue
+// a getminingdistribution JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetMiningDistributionCmd(window *int32) *GetMiningDistributionCmd {
+	return &GetMiningDistributionCmd{
+		Window: window,
+	}
+}
+
 // GetNetworkInfoCmd defines the getnetworkinfo JSON-RPC command.
 type GetNetworkInfoCmd struct{}
 
@@ -1133,6 +1149,7 @@ func init() {
 	MustRegisterCmd("getinfo", (*GetInfoCmd)(nil), flags)
 	MustRegisterCmd("getmempoolentry", (*GetMempoolEntryCmd)(nil), flags)
 	MustRegisterCmd("getmempoolinfo", (*GetMempoolInfoCmd)(nil), flags)
+	MustRegisterCmd("getminingdistribution", (*GetMiningDistributionCmd)(nil), flags)
 	MustRegisterCmd("getmininginfo", (*GetMiningInfoCmd)(nil), flags)
 	MustRegisterCmd("getnetworkinfo", (*GetNetworkInfoCmd)(nil), flags)
 	MustRegisterCmd("getnettotals", (*GetNetTotalsCmd)(nil), flags)
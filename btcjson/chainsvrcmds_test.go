@@ -724,6 +724,32 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"getmempoolinfo","params":[],"id":1}`,
 			unmarshalled: &btcjson.GetMempoolInfoCmd{},
 		},
+		{
+			name: "getminingdistribution",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getminingdistribution")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetMiningDistributionCmd(nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getminingdistribution","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetMiningDistributionCmd{
+				Window: btcjson.Int32(144),
+			},
+		},
+		{
+			name: "getminingdistribution optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getminingdistribution", 1008)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetMiningDistributionCmd(btcjson.Int32(1008))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getminingdistribution","params":[1008],"id":1}`,
+			unmarshalled: &btcjson.GetMiningDistributionCmd{
+				Window: btcjson.Int32(1008),
+			},
+		},
 		{
 			name: "getmininginfo",
 			newCmd: func() (interface{}, error) {
@@ -30,6 +30,8 @@ type GetBlockHeaderVerboseResult struct {
 	Nonce         uint64  `json:"nonce"`
 	Bits          string  `json:"bits"`
 	Difficulty    float64 `json:"difficulty"`
+	ThermalProof  uint64  `json:"thermalproof"`
+	PowAlgo       string  `json:"powalgo"`
 	PreviousHash  string  `json:"previousblockhash,omitempty"`
 	NextHash      string  `json:"nextblockhash,omitempty"`
 }
@@ -88,6 +90,8 @@ type GetBlockVerboseResult struct {
 	Nonce         uint32        `json:"nonce"`
 	Bits          string        `json:"bits"`
 	Difficulty    float64       `json:"difficulty"`
+	ThermalProof  uint64        `json:"thermalproof"`
+	PowAlgo       string        `json:"powalgo"`
 	PreviousHash  string        `json:"previousblockhash"`
 	NextHash      string        `json:"nextblockhash,omitempty"`
 }
@@ -114,6 +118,8 @@ type GetBlockVerboseTxResult struct {
 	Nonce         uint32        `json:"nonce"`
 	Bits          string        `json:"bits"`
 	Difficulty    float64       `json:"difficulty"`
+	ThermalProof  uint64        `json:"thermalproof"`
+	PowAlgo       string        `json:"powalgo"`
 	PreviousHash  string        `json:"previousblockhash"`
 	NextHash      string        `json:"nextblockhash,omitempty"`
 }
@@ -724,6 +730,23 @@ type Vout struct {
 	ScriptPubKey ScriptPubKeyResult `json:"scriptPubKey"`
 }
 
+// RandomXEpochResult models a RandomX seed epoch of the getmininginfo command.
+type RandomXEpochResult struct {
+	SeedHeight int32  `json:"seedheight"`
+	SeedHash   string `json:"seedhash"`
+	State      string `json:"state"`
+}
+
+// RandomXEpochsResult models the RandomX seed epochs of the getmininginfo
+// command.
+type RandomXEpochsResult struct {
+	SeedRotation int32               `json:"seedrotation"`
+	FullMemory   bool                `json:"fullmemory"`
+	Previous     *RandomXEpochResult `json:"previous,omitempty"`
+	Current      RandomXEpochResult  `json:"current"`
+	Next         RandomXEpochResult  `json:"next"`
+}
+
 // GetMiningInfoResult models the data from the getmininginfo command.
 type GetMiningInfoResult struct {
 	Blocks             int64   `json:"blocks"`
@@ -738,6 +761,28 @@ type GetMiningInfoResult struct {
 	NetworkHashPS      float64 `json:"networkhashps"`
 	PooledTx           uint64  `json:"pooledtx"`
 	TestNet            bool    `json:"testnet"`
+
+	RandomX *RandomXEpochsResult `json:"randomx,omitempty"`
+}
+
+// MiningDistributionAlgoResult models the blocks mined by a proof-of-work
+// algorithm in the result of the getminingdistribution command.
+type MiningDistributionAlgoResult struct {
+	Algorithm  string  `json:"algorithm"`
+	Blocks     int32   `json:"blocks"`
+	Share      float64 `json:"share"`
+	Work       string  `json:"work"`
+	ChainWork  string  `json:"chainwork"`
+	LastHeight int32   `json:"lastheight"`
+}
+
+// GetMiningDistributionResult models the data from the getminingdistribution
+// command.
+type GetMiningDistributionResult struct {
+	StartHeight int32                          `json:"startheight"`
+	EndHeight   int32                          `json:"endheight"`
+	Blocks      int32                          `json:"blocks"`
+	Algorithms  []MiningDistributionAlgoResult `json:"algorithms"`
 }
 
 // GetWorkResult models the data from the getwork command.
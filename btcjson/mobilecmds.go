@@ -72,15 +72,41 @@ type GetMobileStatsCmd struct {
 
 // Result types for mobile mining commands
 
+// GetMobileBlockTemplateResultTx models a transaction entry of the
+// getmobileblocktemplate result.
+type GetMobileBlockTemplateResultTx struct {
+	Data         string  `json:"data"`
+	Hash         string  `json:"hash"`
+	TxID         string  `json:"txid"`
+	Depends      []int64 `json:"depends"`
+	Fee          int64   `json:"fee"`
+	OperationFee int64   `json:"operation_fee"` // Shell operation fee portion
+	SigOps       int64   `json:"sigops"`
+	Weight       int64   `json:"weight"`
+}
+
 // GetMobileBlockTemplateResult contains the result of getmobileblocktemplate.
 type GetMobileBlockTemplateResult struct {
 	// Standard template fields
-	Bits         string   `json:"bits"`
-	CurTime      int64    `json:"curtime"`
-	Height       int64    `json:"height"`
-	PreviousHash string   `json:"previousblockhash"`
-	Target       string   `json:"target"`
-	Transactions []string `json:"transactions"`
+	Bits                     string                           `json:"bits"`
+	CurTime                  int64                            `json:"curtime"`
+	Height                   int64                            `json:"height"`
+	PreviousHash             string                           `json:"previousblockhash"`
+	Target                   string                           `json:"target"`
+	Version                  int32                            `json:"version"`
+	MinTime                  int64                            `json:"mintime"`
+	WeightLimit              int64                            `json:"weightlimit"`
+	SigOpLimit               int64                            `json:"sigoplimit"`
+	CoinbaseValue            int64                            `json:"coinbasevalue"`
+	Transactions             []GetMobileBlockTemplateResultTx `json:"transactions"`
+	DefaultWitnessCommitment string                           `json:"default_witness_commitment,omitempty"`
+
+	// Stratum-style coinbase fields for extranonce rolling
+	Coinbase1      string   `json:"coinbase1"`       // Coinbase before the extranonce
+	Coinbase2      string   `json:"coinbase2"`       // Coinbase after the extranonce
+	ExtraNonceSize int      `json:"extranonce_size"` // Extranonce length in bytes
+	MerkleBranch   []string `json:"merkle_branch"`   // Coinbase merkle branch
+	MerkleRoot     string   `json:"merkleroot"`      // Root with a zero extranonce
 
 	// Mobile-specific fields
 	MobileTarget    string         `json:"mobile_target"`      // Adjusted for mobile difficulty
@@ -50,6 +50,9 @@ const (
 	defaultMaxRPCClients         = 10
 	defaultMaxRPCWebsockets      = 25
 	defaultMaxRPCConcurrentReqs  = 20
+	defaultMetricsPort           = "9334"
+	defaultRandomXJobPort        = "3333"
+	defaultMaxRandomXJobClients  = 25
 	defaultDbType                = "ffldb"
 	defaultFreeTxRelayLimit      = 15.0
 	defaultTrickleInterval       = peer.DefaultTrickleInterval
@@ -130,6 +133,7 @@ type config struct {
 	LogDir               string        `long:"logdir" description:"Directory to log output."`
 	MaxOrphanTxs         int           `long:"maxorphantx" description:"Max number of orphan transactions to keep in memory"`
 	MaxPeers             int           `long:"maxpeers" description:"Max number of inbound and outbound peers"`
+	MetricsListeners     []string      `long:"metricslisten" description:"Add an interface/port to serve metrics in the OpenMetrics format on at /metrics -- NOTE: Metrics are not served unless this is set (default port: 9334)"`
 	MiningAddrs          []string      `long:"miningaddr" description:"Add the specified payment address to the list of addresses to use for generated blocks -- At least one address is required if the generate option is set"`
 	MinRelayTxFee        float64       `long:"minrelaytxfee" description:"The minimum transaction fee in BTC/kB to be considered a non-zero fee."`
 	DisableBanning       bool          `long:"nobanning" description:"Disable banning of misbehaving peers"`
@@ -167,6 +171,10 @@ type config struct {
 	RPCQuirks            bool          `long:"rpcquirks" description:"Mirror some JSON-RPC quirks of Bitcoin Core -- NOTE: Discouraged unless interoperability issues need to be worked around"`
 	RPCPass              string        `short:"P" long:"rpcpass" default-mask:"-" description:"Password for RPC connections"`
 	RPCUser              string        `short:"u" long:"rpcuser" description:"Username for RPC connections"`
+	RandomXJobListeners  []string      `long:"rxjoblisten" description:"Add an interface/port to serve RandomX jobs to external miners such as xmrig on -- NOTE: Jobs are not served unless this is set (default port: 3333)"`
+	RandomXJobMaxClients int           `long:"rxjobmaxclients" description:"Max number of external RandomX miners connected at once"`
+	RandomXJobPass       string        `long:"rxjobpass" default-mask:"-" description:"Password external RandomX miners must log in with -- NOTE: Any login is accepted unless this is set"`
+	RandomXJobWhitelists []string      `long:"rxjobwhitelist" description:"Add an IP network or IP external RandomX miners may connect from (eg. 192.168.1.0/24 or ::1) -- NOTE: Miners may connect from anywhere unless this is set"`
 	SigCacheMaxSize      uint          `long:"sigcachemaxsize" description:"The maximum number of entries in the signature verification cache"`
 	SimNet               bool          `long:"simnet" description:"Use the simulation test network"`
 	SigNet               bool          `long:"signet" description:"Use the signet test network"`
@@ -190,6 +198,14 @@ type config struct {
 	miningAddrs          []btcutil.Address
 	minRelayTxFee        btcutil.Amount
 	whitelists           []*net.IPNet
+	rxJobWhitelists      []*net.IPNet
+
+	// The following are only set by tests that run several servers in one
+	// process.  timeSource replaces the median time source of the server
+	// and allowSelfConns disables the detection of connections to self,
+	// whose nonces are shared by every server in the process.
+	timeSource     blockchain.MedianTimeSource
+	allowSelfConns bool
 }
 
 // serviceOptions defines the configuration options for the daemon as a service on
@@ -325,6 +341,31 @@ func normalizeAddress(addr, defaultPort string) string {
 	return addr
 }
 
+// parseIPNet parses an IP network or an IP, such as 192.168.1.0/24 or ::1.  A
+// single IP is returned as the network holding only that IP.
+func parseIPNet(addr string) (*net.IPNet, error) {
+	_, ipnet, err := net.ParseCIDR(addr)
+	if err == nil {
+		return ipnet, nil
+	}
+
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP network or IP %q", addr)
+	}
+	var bits int
+	if ip.To4() == nil {
+		// IPv6
+		bits = 128
+	} else {
+		bits = 32
+	}
+	return &net.IPNet{
+		IP:   ip,
+		Mask: net.CIDRMask(bits, bits),
+	}, nil
+}
+
 // normalizeAddresses returns a new slice with all the passed peer addresses
 // normalized with the given default port, and all duplicates removed.
 func normalizeAddresses(addrs []string, defaultPort string) []string {
@@ -423,6 +464,7 @@ func loadConfig() (*config, []string, error) {
 		BanDuration:          defaultBanDuration,
 		BanThreshold:         defaultBanThreshold,
 		RPCMaxClients:        defaultMaxRPCClients,
+		RandomXJobMaxClients: defaultMaxRandomXJobClients,
 		RPCMaxWebsockets:     defaultMaxRPCWebsockets,
 		RPCMaxConcurrentReqs: defaultMaxRPCConcurrentReqs,
 		DataDir:              defaultDataDir,
@@ -710,36 +752,41 @@ func loadConfig() (*config, []string, error) {
 
 	// Validate any given whitelisted IP addresses and networks.
 	if len(cfg.Whitelists) > 0 {
-		var ip net.IP
 		cfg.whitelists = make([]*net.IPNet, 0, len(cfg.Whitelists))
 
 		for _, addr := range cfg.Whitelists {
-			_, ipnet, err := net.ParseCIDR(addr)
+			ipnet, err := parseIPNet(addr)
 			if err != nil {
-				ip = net.ParseIP(addr)
-				if ip == nil {
-					str := "%s: The whitelist value of '%s' is invalid"
-					err = fmt.Errorf(str, funcName, addr)
-					fmt.Fprintln(os.Stderr, err)
-					fmt.Fprintln(os.Stderr, usageMessage)
-					return nil, nil, err
-				}
-				var bits int
-				if ip.To4() == nil {
-					// IPv6
-					bits = 128
-				} else {
-					bits = 32
-				}
-				ipnet = &net.IPNet{
-					IP:   ip,
-					Mask: net.CIDRMask(bits, bits),
-				}
+				str := "%s: The whitelist value of '%s' is invalid"
+				err = fmt.Errorf(str, funcName, addr)
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, usageMessage)
+				return nil, nil, err
 			}
 			cfg.whitelists = append(cfg.whitelists, ipnet)
 		}
 	}
 
+	// Validate any given IP addresses and networks external RandomX miners
+	// may connect from.
+	if len(cfg.RandomXJobWhitelists) > 0 {
+		cfg.rxJobWhitelists = make([]*net.IPNet, 0,
+			len(cfg.RandomXJobWhitelists))
+
+		for _, addr := range cfg.RandomXJobWhitelists {
+			ipnet, err := parseIPNet(addr)
+			if err != nil {
+				str := "%s: The rxjobwhitelist value of '%s' is " +
+					"invalid"
+				err = fmt.Errorf(str, funcName, addr)
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, usageMessage)
+				return nil, nil, err
+			}
+			cfg.rxJobWhitelists = append(cfg.rxJobWhitelists, ipnet)
+		}
+	}
+
 	// --addPeer and --connect do not mix.
 	if len(cfg.AddPeers) > 0 && len(cfg.ConnectPeers) > 0 {
 		str := "%s: the --addpeer and --connect options can not be " +
@@ -966,6 +1013,17 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Ensure there is at least one mining address when RandomX jobs are
+	// served since the blocks external miners solve pay to them.
+	if len(cfg.RandomXJobListeners) > 0 && len(cfg.MiningAddrs) == 0 {
+		str := "%s: the rxjoblisten option is set, but there are no " +
+			"mining addresses specified "
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Add default port to all listener addresses if needed and remove
 	// duplicate addresses.
 	cfg.Listeners = normalizeAddresses(cfg.Listeners,
@@ -976,6 +1034,16 @@ func loadConfig() (*config, []string, error) {
 	cfg.RPCListeners = normalizeAddresses(cfg.RPCListeners,
 		activeNetParams.rpcPort)
 
+	// Add default port to all metrics listener addresses if needed and
+	// remove duplicate addresses.
+	cfg.MetricsListeners = normalizeAddresses(cfg.MetricsListeners,
+		defaultMetricsPort)
+
+	// Add default port to all RandomX job listener addresses if needed and
+	// remove duplicate addresses.
+	cfg.RandomXJobListeners = normalizeAddresses(cfg.RandomXJobListeners,
+		defaultRandomXJobPort)
+
 	// Only allow TLS to be disabled if the RPC is bound to localhost
 	// addresses.
 	if !cfg.DisableRPC && cfg.DisableTLS {
@@ -0,0 +1,110 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package descriptor
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// checksumLength is the number of characters in a descriptor checksum
+	checksumLength = 8
+
+	// inputCharset is the set of characters a descriptor may contain, in
+	// the order used by the checksum
+	inputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+		"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+		"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+
+	// checksumCharset is the alphabet of the checksum itself
+	checksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+// polyMod feeds val into the checksum state c, see BIP 380
+func polyMod(c uint64, val int) uint64 {
+	c0 := c >> 35
+	c = ((c & 0x7ffffffff) << 5) ^ uint64(val)
+	if c0&1 != 0 {
+		c ^= 0xf5dee51989
+	}
+	if c0&2 != 0 {
+		c ^= 0xa9fdca3312
+	}
+	if c0&4 != 0 {
+		c ^= 0x1bab10e32d
+	}
+	if c0&8 != 0 {
+		c ^= 0x3706b1677a
+	}
+	if c0&16 != 0 {
+		c ^= 0x644d626ffd
+	}
+
+	return c
+}
+
+// Checksum computes the BIP 380 checksum of a descriptor without its
+// checksum suffix
+func Checksum(desc string) (string, error) {
+	c := uint64(1)
+	class, classCount := 0, 0
+	for _, ch := range desc {
+		pos := strings.IndexRune(inputCharset, ch)
+		if pos < 0 {
+			return "", fmt.Errorf("invalid descriptor character %q", ch)
+		}
+
+		// Characters are fed in as a 5 bit position, with the upper
+		// bits grouped three at a time.
+		c = polyMod(c, pos&31)
+		class = class*3 + pos>>5
+		classCount++
+		if classCount == 3 {
+			c = polyMod(c, class)
+			class, classCount = 0, 0
+		}
+	}
+	if classCount > 0 {
+		c = polyMod(c, class)
+	}
+	for i := 0; i < checksumLength; i++ {
+		c = polyMod(c, 0)
+	}
+	c ^= 1
+
+	var checksum [checksumLength]byte
+	for i := range checksum {
+		checksum[i] = checksumCharset[(c>>(5*(7-i)))&31]
+	}
+
+	return string(checksum[:]), nil
+}
+
+// splitChecksum separates desc from its checksum, verifying the checksum
+// if present
+func splitChecksum(desc string) (string, error) {
+	idx := strings.LastIndexByte(desc, '#')
+	if idx < 0 {
+		return desc, nil
+	}
+
+	body, checksum := desc[:idx], desc[idx+1:]
+	if len(checksum) != checksumLength {
+		return "", fmt.Errorf("descriptor checksum %q must be %d "+
+			"characters", checksum, checksumLength)
+	}
+
+	expected, err := Checksum(body)
+	if err != nil {
+		return "", err
+	}
+	if checksum != expected {
+		return "", fmt.Errorf("descriptor checksum %s does not match "+
+			"expected %s", checksum, expected)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,451 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package descriptor implements output script descriptors for Shell
+// Reserve. Besides the BIP 380 checksum and the tr(), wsh(), sh() and pkh()
+// script expressions, taproot trees may hold Shell leaves:
+//
+//	pk(KEY)                        single key
+//	multi_a(k,KEY,...)             k of n, keys in the given order
+//	sortedmulti_a(k,KEY,...)       k of n, keys sorted
+//	channel(KEY,KEY)               2 of 2 payment channel
+//	vault(k,COLD,[older(N),][template(HASH),]HOT,...)
+//	                               vault policy, see txscript.VaultPolicy
+//	raw(HEX)                       arbitrary tapscript
+//
+// Keys are hex encoded compressed public keys or, inside tr(), x-only keys.
+package descriptor
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/toole-brendan/shell/addresses"
+	"github.com/toole-brendan/shell/chaincfg"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/txscript"
+)
+
+// Type is the top level script expression of a descriptor
+type Type string
+
+const (
+	// TypeTaproot is a tr() descriptor
+	TypeTaproot Type = "tr"
+
+	// TypeWitnessScriptHash is a wsh() descriptor
+	TypeWitnessScriptHash Type = "wsh"
+
+	// TypeScriptHash is a sh() descriptor
+	TypeScriptHash Type = "sh"
+
+	// TypeNestedWitnessScriptHash is a sh(wsh()) descriptor
+	TypeNestedWitnessScriptHash Type = "sh(wsh)"
+
+	// TypePubKeyHash is a pkh() descriptor
+	TypePubKeyHash Type = "pkh"
+)
+
+var (
+	// ErrInvalidDescriptor is returned when a descriptor cannot be parsed
+	ErrInvalidDescriptor = errors.New("invalid descriptor")
+
+	// ErrUnsupportedExpression is returned for script expressions this
+	// package does not implement
+	ErrUnsupportedExpression = errors.New("unsupported descriptor expression")
+)
+
+// Key is a public key along with the text it was parsed from
+type Key struct {
+	PubKey *btcec.PublicKey
+	XOnly  bool
+	text   string
+}
+
+// String returns the key as it appears in the descriptor
+func (k *Key) String() string {
+	return k.text
+}
+
+// Descriptor is a parsed output descriptor
+type Descriptor struct {
+	// Type is the top level script expression
+	Type Type
+
+	// Key is the key of a pkh() descriptor or the internal key of a tr()
+	// descriptor
+	Key *Key
+
+	// Tree is the script tree of a tr() descriptor, nil for key only
+	// outputs
+	Tree *TreeNode
+
+	// MultiSig is the multisig expression of a wsh() or sh() descriptor
+	MultiSig *Leaf
+}
+
+// Parse parses a descriptor, verifying its checksum if it has one
+func Parse(desc string) (*Descriptor, error) {
+	body, err := splitChecksum(desc)
+	if err != nil {
+		return nil, err
+	}
+
+	name, args, err := splitExpression(body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "tr":
+		return parseTaproot(args)
+
+	case "wsh", "sh":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%w: %s() takes one argument",
+				ErrInvalidDescriptor, name)
+		}
+
+		descType := Type(name)
+		inner := args[0]
+		if name == "sh" && strings.HasPrefix(inner, "wsh(") {
+			innerName, innerArgs, err := splitExpression(inner)
+			if err != nil {
+				return nil, err
+			}
+			if innerName != "wsh" || len(innerArgs) != 1 {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidDescriptor,
+					inner)
+			}
+
+			descType = TypeNestedWitnessScriptHash
+			inner = innerArgs[0]
+		}
+
+		leaf, err := parseLeaf(inner, false)
+		if err != nil {
+			return nil, err
+		}
+		if leaf.Type != LeafMulti && leaf.Type != LeafSortedMulti {
+			return nil, fmt.Errorf("%w: %s() only supports multisig",
+				ErrUnsupportedExpression, name)
+		}
+
+		maxKeys := addresses.MaxP2WSHMultiSigKeys
+		if descType == TypeScriptHash {
+			maxKeys = addresses.MaxP2SHMultiSigKeys
+		}
+		if len(leaf.Keys) > maxKeys {
+			return nil, fmt.Errorf("%w: %d keys exceeds maximum of %d",
+				ErrInvalidDescriptor, len(leaf.Keys), maxKeys)
+		}
+
+		return &Descriptor{Type: descType, MultiSig: leaf}, nil
+
+	case "pkh":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%w: pkh() takes one argument",
+				ErrInvalidDescriptor)
+		}
+
+		key, err := parseKey(args[0], false)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Descriptor{Type: TypePubKeyHash, Key: key}, nil
+
+	default:
+		return nil, fmt.Errorf("%w: %s()", ErrUnsupportedExpression, name)
+	}
+}
+
+// parseTaproot parses the arguments of a tr() expression
+func parseTaproot(args []string) (*Descriptor, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, fmt.Errorf("%w: tr() takes a key and an optional tree",
+			ErrInvalidDescriptor)
+	}
+
+	key, err := parseKey(args[0], true)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := &Descriptor{Type: TypeTaproot, Key: key}
+	if len(args) == 2 {
+		desc.Tree, err = parseTree(args[1], 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return desc, nil
+}
+
+// String returns the descriptor along with its checksum
+func (d *Descriptor) String() string {
+	var body string
+	switch d.Type {
+	case TypeTaproot:
+		body = "tr(" + d.Key.String()
+		if d.Tree != nil {
+			body += "," + d.Tree.String()
+		}
+		body += ")"
+
+	case TypeWitnessScriptHash, TypeScriptHash:
+		body = string(d.Type) + "(" + d.MultiSig.String() + ")"
+
+	case TypeNestedWitnessScriptHash:
+		body = "sh(wsh(" + d.MultiSig.String() + "))"
+
+	case TypePubKeyHash:
+		body = "pkh(" + d.Key.String() + ")"
+	}
+
+	// The body is built from parsed expressions so it only holds
+	// characters the checksum accepts.
+	checksum, _ := Checksum(body)
+	return body + "#" + checksum
+}
+
+// Script returns the witness or redeem script of a wsh() or sh()
+// descriptor
+func (d *Descriptor) Script() ([]byte, error) {
+	if d.MultiSig == nil {
+		return nil, fmt.Errorf("%s() descriptors have no script", d.Type)
+	}
+
+	return d.MultiSig.Script(), nil
+}
+
+// OutputKey returns the tweaked output key of a tr() descriptor
+func (d *Descriptor) OutputKey() (*btcec.PublicKey, error) {
+	if d.Type != TypeTaproot {
+		return nil, fmt.Errorf("%s() descriptors have no output key", d.Type)
+	}
+
+	if d.Tree == nil {
+		return txscript.ComputeTaprootKeyNoScript(d.Key.PubKey), nil
+	}
+
+	root := d.Tree.TapNode().TapHash()
+	return txscript.ComputeTaprootOutputKey(d.Key.PubKey, root[:]), nil
+}
+
+// PkScript returns the output script described by the descriptor
+func (d *Descriptor) PkScript() ([]byte, error) {
+	switch d.Type {
+	case TypeTaproot:
+		outputKey, err := d.OutputKey()
+		if err != nil {
+			return nil, err
+		}
+		return txscript.PayToTaprootScript(outputKey)
+
+	case TypeWitnessScriptHash:
+		script, err := d.Script()
+		if err != nil {
+			return nil, err
+		}
+		return witnessScriptHashScript(script)
+
+	case TypeScriptHash, TypeNestedWitnessScriptHash:
+		redeemScript, err := d.RedeemScript()
+		if err != nil {
+			return nil, err
+		}
+		return txscript.NewScriptBuilder().
+			AddOp(txscript.OP_HASH160).
+			AddData(btcutil.Hash160(redeemScript)).
+			AddOp(txscript.OP_EQUAL).
+			Script()
+
+	case TypePubKeyHash:
+		return txscript.NewScriptBuilder().
+			AddOp(txscript.OP_DUP).
+			AddOp(txscript.OP_HASH160).
+			AddData(btcutil.Hash160(d.Key.PubKey.SerializeCompressed())).
+			AddOp(txscript.OP_EQUALVERIFY).
+			AddOp(txscript.OP_CHECKSIG).
+			Script()
+
+	default:
+		return nil, fmt.Errorf("%w: %s()", ErrUnsupportedExpression, d.Type)
+	}
+}
+
+// RedeemScript returns the redeem script of a sh() or sh(wsh())
+// descriptor
+func (d *Descriptor) RedeemScript() ([]byte, error) {
+	script, err := d.Script()
+	if err != nil {
+		return nil, err
+	}
+
+	switch d.Type {
+	case TypeScriptHash:
+		return script, nil
+
+	case TypeNestedWitnessScriptHash:
+		return witnessScriptHashScript(script)
+
+	default:
+		return nil, fmt.Errorf("%s() descriptors have no redeem script",
+			d.Type)
+	}
+}
+
+// Address returns the Shell address of the descriptor's output
+func (d *Descriptor) Address(params *chaincfg.Params) (addresses.ShellAddress, error) {
+	switch d.Type {
+	case TypeTaproot:
+		outputKey, err := d.OutputKey()
+		if err != nil {
+			return nil, err
+		}
+		return addresses.NewShellTaprootAddress(outputKey, params)
+
+	case TypeWitnessScriptHash:
+		script, err := d.Script()
+		if err != nil {
+			return nil, err
+		}
+		return addresses.NewShellP2WSHAddressFromScript(script, params)
+
+	case TypeScriptHash, TypeNestedWitnessScriptHash:
+		redeemScript, err := d.RedeemScript()
+		if err != nil {
+			return nil, err
+		}
+		return addresses.NewShellP2SHAddressFromScript(redeemScript, params)
+
+	case TypePubKeyHash:
+		return addresses.NewShellP2PKHAddress(
+			btcutil.Hash160(d.Key.PubKey.SerializeCompressed()), params,
+		)
+
+	default:
+		return nil, addresses.ErrUnsupportedAddressType
+	}
+}
+
+// witnessScriptHashScript returns the version 0 witness program paying to
+// script
+func witnessScriptHashScript(script []byte) ([]byte, error) {
+	hash := chainhash.HashB(script)
+	return txscript.NewScriptBuilder().
+		AddOp(txscript.OP_0).
+		AddData(hash).
+		Script()
+}
+
+// parseKey parses a hex encoded public key. X-only keys are only accepted
+// when xOnly is set
+func parseKey(text string, xOnly bool) (*Key, error) {
+	raw, err := hex.DecodeString(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: key %q is not hex", ErrInvalidDescriptor,
+			text)
+	}
+
+	switch {
+	case len(raw) == btcec.PubKeyBytesLenCompressed:
+		pubKey, err := btcec.ParsePubKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %s: %v", ErrInvalidDescriptor,
+				text, err)
+		}
+		return &Key{PubKey: pubKey, text: strings.ToLower(text)}, nil
+
+	case len(raw) == schnorr.PubKeyBytesLen && xOnly:
+		pubKey, err := schnorr.ParsePubKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %s: %v", ErrInvalidDescriptor,
+				text, err)
+		}
+		return &Key{PubKey: pubKey, XOnly: true,
+			text: strings.ToLower(text)}, nil
+
+	default:
+		return nil, fmt.Errorf("%w: unsupported key %q",
+			ErrInvalidDescriptor, text)
+	}
+}
+
+// parseThreshold parses the threshold of a multisig or vault expression
+func parseThreshold(text string, numKeys int) (int, error) {
+	threshold, err := strconv.Atoi(text)
+	if err != nil || threshold <= 0 || threshold > numKeys {
+		return 0, fmt.Errorf("%w: invalid threshold %q for %d keys",
+			ErrInvalidDescriptor, text, numKeys)
+	}
+
+	return threshold, nil
+}
+
+// splitExpression splits a NAME(ARG,...) expression into its name and top
+// level arguments
+func splitExpression(expr string) (string, []string, error) {
+	open := strings.IndexByte(expr, '(')
+	if open <= 0 || !strings.HasSuffix(expr, ")") {
+		return "", nil, fmt.Errorf("%w: %q", ErrInvalidDescriptor, expr)
+	}
+
+	args, err := splitArgs(expr[open+1 : len(expr)-1])
+	if err != nil {
+		return "", nil, err
+	}
+
+	return expr[:open], args, nil
+}
+
+// splitArgs splits s at the commas that are not nested in parentheses or
+// braces
+func splitArgs(s string) ([]string, error) {
+	var (
+		args  []string
+		depth int
+		start int
+	)
+	for i, ch := range s {
+		switch ch {
+		case '(', '{':
+			depth++
+
+		case ')', '}':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("%w: unbalanced %q",
+					ErrInvalidDescriptor, s)
+			}
+
+		case ',':
+			if depth == 0 {
+				args = append(args, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("%w: unbalanced %q", ErrInvalidDescriptor, s)
+	}
+
+	args = append(args, s[start:])
+	for _, arg := range args {
+		if arg == "" {
+			return nil, fmt.Errorf("%w: empty argument in %q",
+				ErrInvalidDescriptor, s)
+		}
+	}
+
+	return args, nil
+}
@@ -0,0 +1,281 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package descriptor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/toole-brendan/shell/addresses"
+	"github.com/toole-brendan/shell/chaincfg"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/psbt"
+	"github.com/toole-brendan/shell/txscript"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// testKeys returns n deterministic private keys
+func testKeys(n int) []*btcec.PrivateKey {
+	keys := make([]*btcec.PrivateKey, n)
+	for i := range keys {
+		var secret [32]byte
+		secret[31] = byte(i + 1)
+		keys[i], _ = btcec.PrivKeyFromBytes(secret[:])
+	}
+
+	return keys
+}
+
+func xOnlyHex(key *btcec.PrivateKey) string {
+	return hex.EncodeToString(schnorr.SerializePubKey(key.PubKey()))
+}
+
+func compressedHex(key *btcec.PrivateKey) string {
+	return hex.EncodeToString(key.PubKey().SerializeCompressed())
+}
+
+// TestChecksum tests the descriptor checksum
+func TestChecksum(t *testing.T) {
+	checksum, err := Checksum("raw(deadbeef)")
+	if err != nil {
+		t.Fatalf("Failed to compute checksum: %v", err)
+	}
+	if checksum != "89f8spxm" {
+		t.Errorf("Expected checksum 89f8spxm, got %s", checksum)
+	}
+
+	if _, err := Checksum("raw(deadbeef)\n"); err == nil {
+		t.Error("Expected error for invalid character")
+	}
+
+	keys := testKeys(1)
+	desc := "pkh(" + compressedHex(keys[0]) + ")"
+	if _, err := Parse(desc + "#" + checksum); err == nil {
+		t.Error("Expected error for mismatched checksum")
+	}
+	if _, err := Parse(desc + "#abc"); err == nil {
+		t.Error("Expected error for short checksum")
+	}
+}
+
+// TestParseInvalid tests rejecting malformed descriptors
+func TestParseInvalid(t *testing.T) {
+	keys := testKeys(3)
+	tests := []struct {
+		desc string
+		err  error
+	}{
+		{"tr(" + xOnlyHex(keys[0]), ErrInvalidDescriptor},
+		{"wpkh(" + compressedHex(keys[0]) + ")", ErrUnsupportedExpression},
+		{"wsh(pk(" + compressedHex(keys[0]) + "))", ErrUnsupportedExpression},
+		{"pkh(" + xOnlyHex(keys[0]) + ")", ErrInvalidDescriptor},
+		{"tr(" + xOnlyHex(keys[0]) + ",pk(zz))", ErrInvalidDescriptor},
+		{"tr(" + xOnlyHex(keys[0]) + ",{pk(" + xOnlyHex(keys[1]) + ")})",
+			ErrInvalidDescriptor},
+		{"tr(" + xOnlyHex(keys[0]) + ",multi_a(3," + xOnlyHex(keys[1]) +
+			"," + xOnlyHex(keys[2]) + "))", ErrInvalidDescriptor},
+		{"tr(" + xOnlyHex(keys[0]) + ",vault(2," + xOnlyHex(keys[1]) +
+			",older(x)," + xOnlyHex(keys[2]) + "))", ErrInvalidDescriptor},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.desc)
+		if !errors.Is(err, test.err) {
+			t.Errorf("Parse(%s): expected %v, got %v", test.desc, test.err,
+				err)
+		}
+	}
+}
+
+// TestMultiSigAddress tests that wsh() and sh() sortedmulti descriptors
+// match the multisig addresses of the addresses package
+func TestMultiSigAddress(t *testing.T) {
+	params := &chaincfg.MainNetParams
+	keys := testKeys(3)
+
+	var pubKeys []*btcec.PublicKey
+	for _, key := range keys {
+		pubKeys = append(pubKeys, key.PubKey())
+	}
+	multi := fmt.Sprintf("sortedmulti(2,%s,%s,%s)", compressedHex(keys[2]),
+		compressedHex(keys[0]), compressedHex(keys[1]))
+
+	tests := []struct {
+		desc        string
+		addressType string
+	}{
+		{"wsh(" + multi + ")", addresses.AddressTypeP2WSH},
+		{"sh(" + multi + ")", addresses.AddressTypeP2SH},
+	}
+
+	for _, test := range tests {
+		d, err := Parse(test.desc)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", test.desc, err)
+		}
+
+		addr, err := d.Address(params)
+		if err != nil {
+			t.Fatalf("Failed to get address: %v", err)
+		}
+		expected, err := addresses.GenerateMultiSigAddressOfType(pubKeys, 2,
+			test.addressType, params)
+		if err != nil {
+			t.Fatalf("Failed to generate address: %v", err)
+		}
+		if addr.String() != expected.String() {
+			t.Errorf("%s: expected address %s, got %s", test.desc,
+				expected, addr)
+		}
+
+		// Round trip through the checksummed form.
+		d2, err := Parse(d.String())
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", d.String(), err)
+		}
+		if d2.String() != d.String() {
+			t.Errorf("Expected %s, got %s", d.String(), d2.String())
+		}
+	}
+}
+
+// TestTaprootTree tests parsing and spending a taproot tree holding both
+// tapscript and vault leaves
+func TestTaprootTree(t *testing.T) {
+	keys := testKeys(7)
+	templateHash := chainhash.HashH([]byte("template"))
+	tree := fmt.Sprintf("{pk(%s),{sortedmulti_a(2,%s,%s),vault(2,%s,"+
+		"older(144),template(%s),%s,%s,%s)}}", xOnlyHex(keys[1]),
+		xOnlyHex(keys[3]), xOnlyHex(keys[2]), compressedHex(keys[6]),
+		templateHash, xOnlyHex(keys[4]), xOnlyHex(keys[5]),
+		xOnlyHex(keys[2]))
+	desc := "tr(" + xOnlyHex(keys[0]) + "," + tree + ")"
+
+	d, err := Parse(desc)
+	if err != nil {
+		t.Fatalf("Failed to parse descriptor: %v", err)
+	}
+	checksum, _ := Checksum(desc)
+	if d.String() != desc+"#"+checksum {
+		t.Fatalf("Expected %s#%s, got %s", desc, checksum, d.String())
+	}
+
+	leaves, err := d.TapLeaves()
+	if err != nil {
+		t.Fatalf("Failed to get leaves: %v", err)
+	}
+	if len(leaves) != 3 {
+		t.Fatalf("Expected 3 leaves, got %d", len(leaves))
+	}
+	vault := leaves[2].Leaf.Vault
+	if vault == nil || vault.Threshold != 2 || vault.Delay != 144 ||
+		vault.TemplateHash != templateHash {
+
+		t.Fatalf("Unexpected vault policy %+v", vault)
+	}
+
+	pkScript, err := d.PkScript()
+	if err != nil {
+		t.Fatalf("Failed to get pkScript: %v", err)
+	}
+	outputKey, _ := d.OutputKey()
+
+	// Every control block must commit to the output key.
+	for i, lp := range leaves {
+		cb, err := txscript.ParseControlBlock(lp.ControlBlock)
+		if err != nil {
+			t.Fatalf("Leaf %d: failed to parse control block: %v", i, err)
+		}
+		root := cb.RootHash(lp.Leaf.Script())
+		computed := txscript.ComputeTaprootOutputKey(d.Key.PubKey, root)
+		if !bytes.Equal(schnorr.SerializePubKey(computed),
+			schnorr.SerializePubKey(outputKey)) {
+
+			t.Errorf("Leaf %d: control block does not commit to output "+
+				"key", i)
+		}
+	}
+
+	var in psbt.PInput
+	if err := d.UpdateInput(&in); err != nil {
+		t.Fatalf("Failed to update input: %v", err)
+	}
+	if len(in.TaprootLeafScript) != 2 || len(in.ShellLeaves) != 1 {
+		t.Fatalf("Expected 2 tapscript and 1 Shell leaves, got %d and %d",
+			len(in.TaprootLeafScript), len(in.ShellLeaves))
+	}
+	policy, err := in.ShellLeaves[0].VaultPolicy()
+	if err != nil || policy.Delay != 144 {
+		t.Errorf("Unexpected Shell leaf policy: %v", err)
+	}
+
+	var out psbt.POutput
+	if err := d.UpdateOutput(&out); err != nil {
+		t.Fatalf("Failed to update output: %v", err)
+	}
+	expectedDepths := []uint8{1, 2, 2}
+	for i, leaf := range out.TaprootTapTree {
+		if leaf.Depth != expectedDepths[i] {
+			t.Errorf("Leaf %d: expected depth %d, got %d", i,
+				expectedDepths[i], leaf.Depth)
+		}
+	}
+
+	// Spend the sortedmulti_a leaf through the PSBT finalizer.
+	prevOut := wire.NewTxOut(1e8, pkScript)
+	p, err := psbt.New(
+		[]*wire.OutPoint{wire.NewOutPoint(&chainhash.Hash{0x03}, 0)},
+		[]*wire.TxOut{wire.NewTxOut(1e8-1000, []byte{txscript.OP_TRUE})},
+		2, 0, []uint32{wire.MaxTxInSequenceNum})
+	if err != nil {
+		t.Fatalf("Failed to create packet: %v", err)
+	}
+	p.Inputs[0] = in
+	p.Inputs[0].WitnessUtxo = prevOut
+
+	tapLeaf := leaves[1].Leaf.TapLeaf()
+	leafHash := tapLeaf.TapHash()
+	fetcher := txscript.NewCannedPrevOutputFetcher(pkScript, prevOut.Value)
+	sigHashes := txscript.NewTxSigHashes(p.UnsignedTx, fetcher)
+	for _, key := range []*btcec.PrivateKey{keys[2], keys[3]} {
+		sig, err := txscript.RawTxInTapscriptSignature(p.UnsignedTx,
+			sigHashes, 0, prevOut.Value, pkScript, tapLeaf,
+			txscript.SigHashDefault, key)
+		if err != nil {
+			t.Fatalf("Failed to sign: %v", err)
+		}
+		p.Inputs[0].TaprootScriptSpendSig = append(
+			p.Inputs[0].TaprootScriptSpendSig,
+			&psbt.TaprootScriptSpendSig{
+				XOnlyPubKey: schnorr.SerializePubKey(key.PubKey()),
+				LeafHash:    leafHash[:],
+				Signature:   sig,
+				SigHash:     txscript.SigHashDefault,
+			})
+	}
+
+	if err := psbt.MaybeFinalizeAll(p); err != nil {
+		t.Fatalf("Failed to finalize: %v", err)
+	}
+	tx, err := psbt.Extract(p)
+	if err != nil {
+		t.Fatalf("Failed to extract transaction: %v", err)
+	}
+
+	vm, err := txscript.NewEngine(pkScript, tx, 0,
+		txscript.StandardVerifyFlags|txscript.ScriptVerifyShellVault, nil,
+		txscript.NewTxSigHashes(tx, fetcher), prevOut.Value, fetcher)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	if err := vm.Execute(); err != nil {
+		t.Fatalf("Leaf spend failed: %v", err)
+	}
+}
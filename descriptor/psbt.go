@@ -0,0 +1,113 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package descriptor
+
+import (
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/toole-brendan/shell/psbt"
+	"github.com/toole-brendan/shell/txscript"
+)
+
+// UpdateInput fills in the scripts, taproot data and Shell leaves needed to
+// sign and finalize an input spending the descriptor's output. Leaves
+// committed under a Shell leaf version are added as Shell leaves, the others
+// as tapscript leaves
+func (d *Descriptor) UpdateInput(in *psbt.PInput) error {
+	switch d.Type {
+	case TypeTaproot:
+		in.TaprootInternalKey = schnorr.SerializePubKey(d.Key.PubKey)
+		in.TaprootMerkleRoot = nil
+		in.TaprootLeafScript = nil
+		in.ShellLeaves = nil
+		if d.Tree == nil {
+			return nil
+		}
+
+		root := d.Tree.TapNode().TapHash()
+		in.TaprootMerkleRoot = root[:]
+
+		leaves, err := d.TapLeaves()
+		if err != nil {
+			return err
+		}
+		for _, lp := range leaves {
+			tapLeaf := lp.Leaf.TapLeaf()
+			if tapLeaf.LeafVersion == txscript.BaseLeafVersion {
+				in.TaprootLeafScript = append(in.TaprootLeafScript,
+					&psbt.TaprootTapLeafScript{
+						ControlBlock: lp.ControlBlock,
+						Script:       tapLeaf.Script,
+						LeafVersion:  tapLeaf.LeafVersion,
+					})
+				continue
+			}
+
+			in.ShellLeaves = append(in.ShellLeaves, &psbt.ShellTapLeaf{
+				ControlBlock: lp.ControlBlock,
+				Leaf:         tapLeaf.Script,
+				LeafVersion:  tapLeaf.LeafVersion,
+			})
+		}
+
+	case TypeWitnessScriptHash:
+		in.WitnessScript = d.MultiSig.Script()
+
+	case TypeScriptHash:
+		in.RedeemScript = d.MultiSig.Script()
+
+	case TypeNestedWitnessScriptHash:
+		redeemScript, err := d.RedeemScript()
+		if err != nil {
+			return err
+		}
+		in.RedeemScript = redeemScript
+		in.WitnessScript = d.MultiSig.Script()
+	}
+
+	return nil
+}
+
+// UpdateOutput fills in the scripts and taproot data describing an output
+// paying to the descriptor
+func (d *Descriptor) UpdateOutput(out *psbt.POutput) error {
+	switch d.Type {
+	case TypeTaproot:
+		out.TaprootInternalKey = schnorr.SerializePubKey(d.Key.PubKey)
+		out.TaprootTapTree = nil
+		if d.Tree == nil {
+			return nil
+		}
+
+		leaves, err := d.TapLeaves()
+		if err != nil {
+			return err
+		}
+		for _, lp := range leaves {
+			tapLeaf := lp.Leaf.TapLeaf()
+			out.TaprootTapTree = append(out.TaprootTapTree,
+				psbt.TaprootTapLeaf{
+					Depth:       lp.Depth,
+					LeafVersion: tapLeaf.LeafVersion,
+					Script:      tapLeaf.Script,
+				})
+		}
+
+	case TypeWitnessScriptHash:
+		out.WitnessScript = d.MultiSig.Script()
+
+	case TypeScriptHash:
+		out.RedeemScript = d.MultiSig.Script()
+
+	case TypeNestedWitnessScriptHash:
+		redeemScript, err := d.RedeemScript()
+		if err != nil {
+			return err
+		}
+		out.RedeemScript = redeemScript
+		out.WitnessScript = d.MultiSig.Script()
+	}
+
+	return nil
+}
@@ -0,0 +1,478 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package descriptor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/toole-brendan/shell/addresses"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/txscript"
+)
+
+// maxMultiAKeys is the maximum number of keys in a multi_a() leaf, bounded
+// by the tapscript stack size
+const maxMultiAKeys = 999
+
+// LeafType is the script expression of a leaf
+type LeafType string
+
+const (
+	// LeafPk is a pk() leaf
+	LeafPk LeafType = "pk"
+
+	// LeafMultiA is a multi_a() leaf
+	LeafMultiA LeafType = "multi_a"
+
+	// LeafSortedMultiA is a sortedmulti_a() leaf
+	LeafSortedMultiA LeafType = "sortedmulti_a"
+
+	// LeafChannel is a channel() leaf
+	LeafChannel LeafType = "channel"
+
+	// LeafVault is a vault() leaf
+	LeafVault LeafType = "vault"
+
+	// LeafRaw is a raw() leaf
+	LeafRaw LeafType = "raw"
+
+	// LeafMulti is the multi() expression of wsh() and sh() descriptors
+	LeafMulti LeafType = "multi"
+
+	// LeafSortedMulti is the sortedmulti() expression of wsh() and sh()
+	// descriptors
+	LeafSortedMulti LeafType = "sortedmulti"
+)
+
+// Leaf is a script expression of a taproot tree, or the multisig
+// expression of a wsh() or sh() descriptor
+type Leaf struct {
+	// Type is the script expression of the leaf
+	Type LeafType
+
+	// Threshold is the number of signatures required by multisig and vault
+	// leaves
+	Threshold int
+
+	// Keys are the keys of the leaf, the hot keys of a vault
+	Keys []*Key
+
+	// Vault is the policy of a vault leaf
+	Vault *txscript.VaultPolicy
+
+	// coldKey is the cold key of a vault leaf
+	coldKey *Key
+
+	// script is the script, or vault policy, the leaf commits to
+	script []byte
+}
+
+// Script returns the script the leaf commits to. For vault leaves this is
+// the serialized policy
+func (l *Leaf) Script() []byte {
+	return l.script
+}
+
+// TapLeaf returns the tapscript leaf of a taproot tree leaf
+func (l *Leaf) TapLeaf() txscript.TapLeaf {
+	if l.Type == LeafVault {
+		return txscript.NewTapLeaf(txscript.ShellTaprootLeafVersion, l.script)
+	}
+
+	return txscript.NewBaseTapLeaf(l.script)
+}
+
+// String returns the leaf as it appears in the descriptor
+func (l *Leaf) String() string {
+	var args []string
+	switch l.Type {
+	case LeafRaw:
+		args = append(args, hex.EncodeToString(l.script))
+
+	case LeafVault:
+		args = append(args, strconv.Itoa(l.Threshold), l.coldKey.String())
+		if l.Vault.Delay != 0 {
+			args = append(args, fmt.Sprintf("older(%d)", l.Vault.Delay))
+		}
+		var zeroHash chainhash.Hash
+		if l.Vault.TemplateHash != zeroHash {
+			args = append(args, "template("+l.Vault.TemplateHash.String()+")")
+		}
+
+	case LeafMultiA, LeafSortedMultiA, LeafMulti, LeafSortedMulti:
+		args = append(args, strconv.Itoa(l.Threshold))
+	}
+
+	for _, key := range l.Keys {
+		args = append(args, key.String())
+	}
+
+	return string(l.Type) + "(" + strings.Join(args, ",") + ")"
+}
+
+// parseLeaf parses a leaf expression. Taproot leaves are accepted when
+// taproot is set, multi() and sortedmulti() otherwise
+func parseLeaf(expr string, taproot bool) (*Leaf, error) {
+	name, args, err := splitExpression(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf := &Leaf{Type: LeafType(name)}
+	switch {
+	case taproot && leaf.Type == LeafPk:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%w: pk() takes one key",
+				ErrInvalidDescriptor)
+		}
+		if leaf.Keys, err = parseKeys(args, true); err != nil {
+			return nil, err
+		}
+		leaf.script, err = txscript.NewScriptBuilder().
+			AddData(schnorr.SerializePubKey(leaf.Keys[0].PubKey)).
+			AddOp(txscript.OP_CHECKSIG).
+			Script()
+
+	case taproot && leaf.Type == LeafChannel:
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%w: channel() takes two keys",
+				ErrInvalidDescriptor)
+		}
+		if leaf.Keys, err = parseKeys(args, true); err != nil {
+			return nil, err
+		}
+		leaf.Threshold = 2
+		leaf.script, err = txscript.NewScriptBuilder().
+			AddData(schnorr.SerializePubKey(leaf.Keys[0].PubKey)).
+			AddOp(txscript.OP_CHECKSIGVERIFY).
+			AddData(schnorr.SerializePubKey(leaf.Keys[1].PubKey)).
+			AddOp(txscript.OP_CHECKSIG).
+			Script()
+
+	case taproot && (leaf.Type == LeafMultiA || leaf.Type == LeafSortedMultiA):
+		if len(args) < 2 || len(args) > maxMultiAKeys+1 {
+			return nil, fmt.Errorf("%w: %s() takes a threshold and 1 to "+
+				"%d keys", ErrInvalidDescriptor, name, maxMultiAKeys)
+		}
+		if leaf.Keys, err = parseKeys(args[1:], true); err != nil {
+			return nil, err
+		}
+		leaf.Threshold, err = parseThreshold(args[0], len(leaf.Keys))
+		if err != nil {
+			return nil, err
+		}
+		leaf.script, err = multiAScript(leaf.Keys, leaf.Threshold,
+			leaf.Type == LeafSortedMultiA)
+
+	case taproot && leaf.Type == LeafVault:
+		err = parseVault(leaf, args)
+
+	case taproot && leaf.Type == LeafRaw:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%w: raw() takes one script",
+				ErrInvalidDescriptor)
+		}
+		leaf.script, err = hex.DecodeString(args[0])
+		if err != nil || strings.ToLower(args[0]) != args[0] {
+			return nil, fmt.Errorf("%w: raw() script %q is not lower "+
+				"case hex", ErrInvalidDescriptor, args[0])
+		}
+
+	case !taproot && (leaf.Type == LeafMulti || leaf.Type == LeafSortedMulti):
+		if len(args) < 2 {
+			return nil, fmt.Errorf("%w: %s() takes a threshold and keys",
+				ErrInvalidDescriptor, name)
+		}
+		if leaf.Keys, err = parseKeys(args[1:], false); err != nil {
+			return nil, err
+		}
+		leaf.Threshold, err = parseThreshold(args[0], len(leaf.Keys))
+		if err != nil {
+			return nil, err
+		}
+
+		pubKeys := make([]*btcec.PublicKey, len(leaf.Keys))
+		for i, key := range leaf.Keys {
+			pubKeys[i] = key.PubKey
+		}
+		if leaf.Type == LeafSortedMulti {
+			leaf.script, err = addresses.CreateSortedMultiSigScript(
+				pubKeys, leaf.Threshold,
+			)
+		} else {
+			leaf.script, err = addresses.CreateMultiSigScript(
+				pubKeys, leaf.Threshold,
+			)
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: %s()", ErrUnsupportedExpression, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return leaf, nil
+}
+
+// parseVault parses the arguments of a vault() leaf into leaf
+func parseVault(leaf *Leaf, args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("%w: vault() takes a threshold, a cold key and "+
+			"hot keys", ErrInvalidDescriptor)
+	}
+
+	coldKey, err := parseKey(args[1], true)
+	if err != nil {
+		return err
+	}
+
+	policy := &txscript.VaultPolicy{ColdKey: coldKey.PubKey}
+	rest := args[2:]
+	if strings.HasPrefix(rest[0], "older(") {
+		_, delayArgs, err := splitExpression(rest[0])
+		if err != nil || len(delayArgs) != 1 {
+			return fmt.Errorf("%w: %s", ErrInvalidDescriptor, rest[0])
+		}
+		delay, err := strconv.ParseUint(delayArgs[0], 10, 32)
+		if err != nil || delay == 0 {
+			return fmt.Errorf("%w: invalid delay %q",
+				ErrInvalidDescriptor, delayArgs[0])
+		}
+		policy.Delay = uint32(delay)
+		rest = rest[1:]
+	}
+	if len(rest) > 0 && strings.HasPrefix(rest[0], "template(") {
+		_, hashArgs, err := splitExpression(rest[0])
+		if err != nil || len(hashArgs) != 1 {
+			return fmt.Errorf("%w: %s", ErrInvalidDescriptor, rest[0])
+		}
+		hash, err := chainhash.NewHashFromStr(hashArgs[0])
+		if err != nil || len(hashArgs[0]) != chainhash.MaxHashStringSize {
+			return fmt.Errorf("%w: invalid template hash %q",
+				ErrInvalidDescriptor, hashArgs[0])
+		}
+		policy.TemplateHash = *hash
+		rest = rest[1:]
+	}
+
+	if leaf.Keys, err = parseKeys(rest, true); err != nil {
+		return err
+	}
+	if len(leaf.Keys) > txscript.MaxVaultHotKeys {
+		return fmt.Errorf("%w: vault() takes at most %d hot keys",
+			ErrInvalidDescriptor, txscript.MaxVaultHotKeys)
+	}
+	leaf.Threshold, err = parseThreshold(args[0], len(leaf.Keys))
+	if err != nil {
+		return err
+	}
+
+	policy.Threshold = uint8(leaf.Threshold)
+	for _, key := range leaf.Keys {
+		policy.HotKeys = append(policy.HotKeys, key.PubKey)
+	}
+
+	leaf.script, err = policy.Serialize()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDescriptor, err)
+	}
+	leaf.coldKey = coldKey
+	leaf.Vault = policy
+
+	return nil
+}
+
+// parseKeys parses a list of keys
+func parseKeys(args []string, xOnly bool) ([]*Key, error) {
+	keys := make([]*Key, len(args))
+	for i, arg := range args {
+		key, err := parseKey(arg, xOnly)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+
+	return keys, nil
+}
+
+// multiAScript builds the tapscript of a k of n multi_a() leaf:
+//
+//	<key_1> OP_CHECKSIG <key_2> OP_CHECKSIGADD ... <k> OP_NUMEQUAL
+func multiAScript(keys []*Key, threshold int, sorted bool) ([]byte, error) {
+	xOnlyKeys := make([][]byte, len(keys))
+	for i, key := range keys {
+		xOnlyKeys[i] = schnorr.SerializePubKey(key.PubKey)
+	}
+	if sorted {
+		sort.Slice(xOnlyKeys, func(i, j int) bool {
+			return bytes.Compare(xOnlyKeys[i], xOnlyKeys[j]) < 0
+		})
+	}
+
+	builder := txscript.NewScriptBuilder()
+	for i, key := range xOnlyKeys {
+		builder.AddData(key)
+		if i == 0 {
+			builder.AddOp(txscript.OP_CHECKSIG)
+		} else {
+			builder.AddOp(txscript.OP_CHECKSIGADD)
+		}
+	}
+	builder.AddInt64(int64(threshold))
+	builder.AddOp(txscript.OP_NUMEQUAL)
+
+	return builder.Script()
+}
+
+// TreeNode is a node of a taproot script tree: either a leaf or a branch
+// with two children
+type TreeNode struct {
+	Leaf  *Leaf
+	Left  *TreeNode
+	Right *TreeNode
+}
+
+// parseTree parses a taproot tree expression
+func parseTree(expr string, depth int) (*TreeNode, error) {
+	if depth > txscript.ControlBlockMaxNodeCount {
+		return nil, fmt.Errorf("%w: tree deeper than %d",
+			ErrInvalidDescriptor, txscript.ControlBlockMaxNodeCount)
+	}
+
+	if !strings.HasPrefix(expr, "{") {
+		leaf, err := parseLeaf(expr, true)
+		if err != nil {
+			return nil, err
+		}
+		return &TreeNode{Leaf: leaf}, nil
+	}
+
+	if !strings.HasSuffix(expr, "}") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidDescriptor, expr)
+	}
+	args, err := splitArgs(expr[1 : len(expr)-1])
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 2 {
+		return nil, fmt.Errorf("%w: branch must have two children",
+			ErrInvalidDescriptor)
+	}
+
+	left, err := parseTree(args[0], depth+1)
+	if err != nil {
+		return nil, err
+	}
+	right, err := parseTree(args[1], depth+1)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TreeNode{Left: left, Right: right}, nil
+}
+
+// String returns the tree as it appears in the descriptor
+func (n *TreeNode) String() string {
+	if n.Leaf != nil {
+		return n.Leaf.String()
+	}
+
+	return "{" + n.Left.String() + "," + n.Right.String() + "}"
+}
+
+// TapNode returns the tapscript tree node committing to the tree
+func (n *TreeNode) TapNode() txscript.TapNode {
+	if n.Leaf != nil {
+		return n.Leaf.TapLeaf()
+	}
+
+	return txscript.NewTapBranch(n.Left.TapNode(), n.Right.TapNode())
+}
+
+// TapLeafProof is a leaf of a tr() descriptor along with its depth in the
+// tree and the control block for spending it
+type TapLeafProof struct {
+	Leaf         *Leaf
+	Depth        uint8
+	ControlBlock []byte
+}
+
+// TapLeaves returns the leaves of a tr() descriptor in depth first order
+func (d *Descriptor) TapLeaves() ([]*TapLeafProof, error) {
+	if d.Type != TypeTaproot {
+		return nil, fmt.Errorf("%s() descriptors have no script tree", d.Type)
+	}
+	if d.Tree == nil {
+		return nil, nil
+	}
+
+	outputKey, err := d.OutputKey()
+	if err != nil {
+		return nil, err
+	}
+	outputKeyYIsOdd := outputKey.SerializeCompressed()[0] ==
+		secp.PubKeyFormatCompressedOdd
+
+	var proofs []*TapLeafProof
+	for _, lp := range collectLeaves(d.Tree, 0) {
+		controlBlock := txscript.ControlBlock{
+			InternalKey:     d.Key.PubKey,
+			OutputKeyYIsOdd: outputKeyYIsOdd,
+			LeafVersion:     lp.leaf.TapLeaf().LeafVersion,
+			InclusionProof:  lp.inclusionProof,
+		}
+		cb, err := controlBlock.ToBytes()
+		if err != nil {
+			return nil, err
+		}
+
+		proofs = append(proofs, &TapLeafProof{
+			Leaf:         lp.leaf,
+			Depth:        uint8(lp.depth),
+			ControlBlock: cb,
+		})
+	}
+
+	return proofs, nil
+}
+
+// leafPath is a leaf along with its depth and the sibling hashes from the
+// leaf up to the root
+type leafPath struct {
+	leaf           *Leaf
+	depth          int
+	inclusionProof []byte
+}
+
+// collectLeaves returns the leaves below n in depth first order
+func collectLeaves(n *TreeNode, depth int) []*leafPath {
+	if n.Leaf != nil {
+		return []*leafPath{{leaf: n.Leaf, depth: depth}}
+	}
+
+	left := collectLeaves(n.Left, depth+1)
+	right := collectLeaves(n.Right, depth+1)
+
+	leftHash := n.Left.TapNode().TapHash()
+	rightHash := n.Right.TapNode().TapHash()
+	for _, lp := range left {
+		lp.inclusionProof = append(lp.inclusionProof, rightHash[:]...)
+	}
+	for _, lp := range right {
+		lp.inclusionProof = append(lp.inclusionProof, leftHash[:]...)
+	}
+
+	return append(left, right...)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/toole-brendan/shell/mempool"
 	"github.com/toole-brendan/shell/mining"
 	"github.com/toole-brendan/shell/mining/cpuminer"
+	"github.com/toole-brendan/shell/mining/randomx"
 	"github.com/toole-brendan/shell/netsync"
 	"github.com/toole-brendan/shell/peer"
 	"github.com/toole-brendan/shell/txscript"
@@ -65,6 +66,7 @@ var (
 	indxLog = backendLog.Logger("INDX")
 	minrLog = backendLog.Logger("MINR")
 	peerLog = backendLog.Logger("PEER")
+	rndxLog = backendLog.Logger("RNDX")
 	rpcsLog = backendLog.Logger("RPCS")
 	scrpLog = backendLog.Logger("SCRP")
 	srvrLog = backendLog.Logger("SRVR")
@@ -83,6 +85,7 @@ func init() {
 	mining.UseLogger(minrLog)
 	cpuminer.UseLogger(minrLog)
 	peer.UseLogger(peerLog)
+	randomx.UseLogger(rndxLog)
 	txscript.UseLogger(scrpLog)
 	netsync.UseLogger(syncLog)
 	mempool.UseLogger(txmpLog)
@@ -101,6 +104,7 @@ var subsystemLoggers = map[string]btclog.Logger{
 	"INDX":                indxLog,
 	"MINR":                minrLog,
 	"PEER":                peerLog,
+	"RNDX":                rndxLog,
 	"RPCS":                rpcsLog,
 	"SCRP":                scrpLog,
 	"SRVR":                srvrLog,
@@ -1406,11 +1406,23 @@ func (mp *TxPool) checkMempoolAcceptance(tx *btcutil.Tx,
 		}
 	}
 
+	// Shell vault leaves are only enforced, and therefore only standard,
+	// once the vault covenant soft-fork is active.
+	scriptFlags := txscript.StandardVerifyFlags
+	vaultActive, err := mp.cfg.IsDeploymentActive(
+		chaincfg.DeploymentVaultCovenants,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if vaultActive {
+		scriptFlags |= txscript.ScriptVerifyShellVault
+	}
+
 	// Verify crypto signatures for each input and reject the transaction
 	// if any don't verify.
 	err = blockchain.ValidateTransactionScripts(tx, utxoView,
-		txscript.StandardVerifyFlags, mp.cfg.SigCache,
-		mp.cfg.HashCache)
+		scriptFlags, mp.cfg.SigCache, mp.cfg.HashCache)
 	if err != nil {
 		if cerr, ok := err.(blockchain.RuleError); ok {
 			return nil, chainRuleError(cerr)
@@ -0,0 +1,305 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/toole-brendan/shell/blockchain"
+	"github.com/toole-brendan/shell/mempool"
+	"github.com/toole-brendan/shell/openmetrics"
+)
+
+const (
+	// metricsPath is the HTTP path metrics are served on.
+	metricsPath = "/metrics"
+
+	// metricsReadTimeout is how long the metrics server waits for a scrape
+	// request to be read.
+	metricsReadTimeout = 10 * time.Second
+)
+
+// mempoolFeeRateBuckets are the upper bounds in satoshis per virtual byte of
+// the mempool fee rate histogram.
+var mempoolFeeRateBuckets = []float64{1, 2, 3, 5, 10, 20, 50, 100, 200, 500,
+	1000}
+
+// nodeMetrics collects the metrics of the node for the metrics server.  It
+// tracks chain reorganizations itself since the chain only reports the blocks
+// that were disconnected and connected.
+type nodeMetrics struct {
+	server *server
+
+	// The following fields are protected by reorgMtx.
+	reorgMtx       sync.Mutex
+	disconnected   int32 // Blocks disconnected since the last connected one
+	reorgs         uint64
+	lastReorgDepth int32
+	maxReorgDepth  int32
+}
+
+// Ensure nodeMetrics implements the openmetrics.Collector interface.
+var _ openmetrics.Collector = (*nodeMetrics)(nil)
+
+// newNodeMetrics returns a collector of the metrics of the passed server that
+// tracks reorganizations of its chain from now on.
+func newNodeMetrics(s *server) *nodeMetrics {
+	m := &nodeMetrics{server: s}
+	s.chain.Subscribe(m.handleChainNotification)
+	return m
+}
+
+// handleChainNotification counts the blocks disconnected from the main chain
+// and records a reorganization of that depth once a block is connected again.
+func (m *nodeMetrics) handleChainNotification(n *blockchain.Notification) {
+	m.reorgMtx.Lock()
+	defer m.reorgMtx.Unlock()
+
+	switch n.Type {
+	case blockchain.NTBlockDisconnected:
+		m.disconnected++
+
+	case blockchain.NTBlockConnected:
+		if m.disconnected == 0 {
+			return
+		}
+		m.reorgs++
+		m.lastReorgDepth = m.disconnected
+		if m.disconnected > m.maxReorgDepth {
+			m.maxReorgDepth = m.disconnected
+		}
+		m.disconnected = 0
+	}
+}
+
+// Collect returns the current metrics of the node.
+//
+// This is part of the openmetrics.Collector interface.
+func (m *nodeMetrics) Collect() []*openmetrics.Family {
+	var families []*openmetrics.Family
+	families = append(families, m.chainFamilies()...)
+	families = append(families, m.mempoolFamilies()...)
+	families = append(families, m.peerFamilies()...)
+	families = append(families, m.miningFamilies()...)
+	return families
+}
+
+// chainFamilies returns the metrics of the best chain and its
+// reorganizations.
+func (m *nodeMetrics) chainFamilies() []*openmetrics.Family {
+	best := m.server.chain.BestSnapshot()
+
+	m.reorgMtx.Lock()
+	reorgs := m.reorgs
+	lastReorgDepth := m.lastReorgDepth
+	maxReorgDepth := m.maxReorgDepth
+	m.reorgMtx.Unlock()
+
+	return []*openmetrics.Family{
+		openmetrics.NewGauge("shell_chain_height",
+			"Height of the best chain.", float64(best.Height)),
+		openmetrics.NewCounter("shell_chain_reorgs",
+			"Reorganizations of the best chain.", float64(reorgs)),
+		openmetrics.NewGauge("shell_chain_reorg_depth",
+			"Blocks disconnected by the last reorganization.",
+			float64(lastReorgDepth)),
+		openmetrics.NewGauge("shell_chain_reorg_depth_max",
+			"Blocks disconnected by the deepest reorganization.",
+			float64(maxReorgDepth)),
+	}
+}
+
+// mempoolFamilies returns the size of the mempool and a histogram of the fee
+// rates of its transactions.
+func (m *nodeMetrics) mempoolFamilies() []*openmetrics.Family {
+	descs := m.server.txMemPool.TxDescs()
+
+	var size int
+	feeRates := openmetrics.NewHistogram(mempoolFeeRateBuckets)
+	for _, desc := range descs {
+		size += desc.Tx.MsgTx().SerializeSize()
+		vsize := mempool.GetTxVirtualSize(desc.Tx)
+		if vsize > 0 {
+			feeRates.Observe(float64(desc.Fee) / float64(vsize))
+		}
+	}
+
+	return []*openmetrics.Family{
+		openmetrics.NewGauge("shell_mempool_transactions",
+			"Transactions in the mempool.", float64(len(descs))),
+		openmetrics.NewGauge("shell_mempool_size_bytes",
+			"Serialized size of the transactions in the mempool.",
+			float64(size)).WithUnit("bytes"),
+		{
+			Name: "shell_mempool_fee_rate",
+			Help: "Fee rates of the transactions in the mempool in " +
+				"satoshis per virtual byte.",
+			Type:    openmetrics.Histogram,
+			Samples: []openmetrics.Sample{{Histogram: feeRates}},
+		},
+	}
+}
+
+// peerFamilies returns the number of connected peers by direction along with
+// the bytes exchanged with all peers.
+func (m *nodeMetrics) peerFamilies() []*openmetrics.Family {
+	s := m.server
+
+	var inbound, outbound int
+	replyChan := make(chan []*serverPeer)
+	select {
+	case s.query <- getPeersMsg{reply: replyChan}:
+		for _, sp := range <-replyChan {
+			if sp.Inbound() {
+				inbound++
+			} else {
+				outbound++
+			}
+		}
+	case <-s.quit:
+	}
+
+	bytesReceived, bytesSent := s.NetTotals()
+	return []*openmetrics.Family{
+		openmetrics.NewGauge("shell_peers", "Connected peers by direction.",
+			float64(inbound),
+			openmetrics.Label{Name: "direction", Value: "inbound"}).
+			Add(float64(outbound),
+				openmetrics.Label{Name: "direction", Value: "outbound"}),
+		openmetrics.NewCounter("shell_network_bytes",
+			"Bytes exchanged with peers by direction.",
+			float64(bytesReceived),
+			openmetrics.Label{Name: "direction", Value: "received"}).
+			Add(float64(bytesSent),
+				openmetrics.Label{Name: "direction", Value: "sent"}),
+	}
+}
+
+// miningFamilies returns the hash rate of the CPU miner and of the mobile
+// miners reported to the node by proof-of-work algorithm along with the mobile
+// mining statistics.
+func (m *nodeMetrics) miningFamilies() []*openmetrics.Family {
+	var cpuHashRate float64
+	if m.server.cpuMiner != nil {
+		cpuHashRate = m.server.cpuMiner.HashesPerSecond()
+	}
+
+	mobileState.RLock()
+	mobileHashRate := mobileState.mobileHashrate
+	mobileMiners := mobileState.activeMinerCount
+	thermalViolations := mobileState.thermalViolations
+	npuUtilization := mobileState.npuUtilization
+	mobileBlocks := mobileState.blocksFoundMobile
+	mobileState.RUnlock()
+
+	return []*openmetrics.Family{
+		openmetrics.NewGauge("shell_hashrate",
+			"Hash rate in H/s of the miners of the node by "+
+				"proof-of-work algorithm.", cpuHashRate,
+			openmetrics.Label{
+				Name:  "algorithm",
+				Value: blockchain.PowAlgoRandomX.String(),
+			}).
+			Add(mobileHashRate, openmetrics.Label{
+				Name:  "algorithm",
+				Value: blockchain.PowAlgoMobileX.String(),
+			}),
+		openmetrics.NewGauge("shell_mobile_miners",
+			"Active mobile miners.", float64(mobileMiners)),
+		openmetrics.NewCounter("shell_mobile_thermal_violations",
+			"Mobile work submitted with an invalid thermal proof.",
+			float64(thermalViolations)),
+		openmetrics.NewGauge("shell_mobile_npu_utilization",
+			"Average NPU utilization of mobile miners as reported "+
+				"by getmobilestats.", npuUtilization),
+		openmetrics.NewCounter("shell_mobile_blocks",
+			"Blocks found by mobile miners.", float64(mobileBlocks)),
+	}
+}
+
+// metricsServer serves the metrics of the node in the OpenMetrics text format
+// over HTTP.
+type metricsServer struct {
+	started  int32
+	shutdown int32
+
+	listeners  []net.Listener
+	httpServer *http.Server
+	wg         sync.WaitGroup
+}
+
+// newMetricsServer returns a metrics server that serves the metrics of the
+// passed registry on the passed listeners.
+func newMetricsServer(listeners []net.Listener, registry *openmetrics.Registry) *metricsServer {
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, registry)
+	return &metricsServer{
+		listeners: listeners,
+		httpServer: &http.Server{
+			Handler:     mux,
+			ReadTimeout: metricsReadTimeout,
+		},
+	}
+}
+
+// Start begins serving metrics on the listeners of the server.
+func (m *metricsServer) Start() {
+	if atomic.AddInt32(&m.started, 1) != 1 {
+		return
+	}
+
+	for _, listener := range m.listeners {
+		m.wg.Add(1)
+		go func(listener net.Listener) {
+			srvrLog.Infof("Metrics server listening on %s",
+				listener.Addr())
+			err := m.httpServer.Serve(listener)
+			if !errors.Is(err, http.ErrServerClosed) {
+				srvrLog.Errorf("Metrics server on %s failed: %v",
+					listener.Addr(), err)
+			}
+			srvrLog.Tracef("Metrics listener done for %s",
+				listener.Addr())
+			m.wg.Done()
+		}(listener)
+	}
+}
+
+// Stop closes the listeners of the server and waits for them to finish.
+func (m *metricsServer) Stop() error {
+	if atomic.AddInt32(&m.shutdown, 1) != 1 {
+		return nil
+	}
+
+	err := m.httpServer.Close()
+	m.wg.Wait()
+	return err
+}
+
+// setupMetricsListeners returns a slice of listeners that are configured for
+// use with the metrics server depending on the configured listen addresses.
+func setupMetricsListeners() ([]net.Listener, error) {
+	netAddrs, err := parseListeners(cfg.MetricsListeners)
+	if err != nil {
+		return nil, err
+	}
+
+	listeners := make([]net.Listener, 0, len(netAddrs))
+	for _, addr := range netAddrs {
+		listener, err := net.Listen(addr.Network(), addr.String())
+		if err != nil {
+			srvrLog.Warnf("Can't listen on %s: %v", addr, err)
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toole-brendan/shell/openmetrics"
+)
+
+// TestMetricsServer ensures the metrics server exposes the chain, reorg,
+// mempool and peer metrics of a node after a reorganization.
+func TestMetricsServer(t *testing.T) {
+	h := newSimHarness(t, 2)
+	metrics := newNodeMetrics(h.Node(0).server)
+
+	h.MineBlocks(0, 3)
+	h.Connect(0, 1)
+	h.WaitForSync()
+
+	// Node 0 reorganizes onto the longer chain of node 1 once the
+	// partition heals, disconnecting the two blocks it mined on its own.
+	h.Partition([]int{0}, []int{1})
+	h.AdvanceClock(time.Minute)
+	h.MineBlocks(0, 2)
+	h.MineBlocks(1, 3)
+	h.Heal()
+	h.WaitForSync()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	registry := openmetrics.NewRegistry()
+	registry.Register(metrics)
+	server := newMetricsServer([]net.Listener{listener}, registry)
+	server.Start()
+	defer server.Stop()
+
+	resp, err := http.Get("http://" + listener.Addr().String() + metricsPath)
+	if err != nil {
+		t.Fatalf("unable to scrape metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Content-Type"); got != openmetrics.ContentType {
+		t.Fatalf("got content type %q", got)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("unable to read metrics: %v", err)
+	}
+
+	exposition := string(body)
+	wantLines := []string{
+		"shell_chain_height 6",
+		"shell_chain_reorgs_total 1",
+		"shell_chain_reorg_depth 2",
+		"shell_chain_reorg_depth_max 2",
+		"shell_mempool_transactions 0",
+		"shell_mempool_fee_rate_count 0",
+		`shell_peers{direction="inbound"} 0`,
+		`shell_peers{direction="outbound"} 1`,
+		`shell_hashrate{algorithm="randomx"} 0`,
+		`shell_hashrate{algorithm="mobilex"} 0`,
+	}
+	for _, line := range wantLines {
+		if !strings.Contains(exposition, "\n"+line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, exposition)
+		}
+	}
+	if !strings.HasSuffix(exposition, "# EOF\n") {
+		t.Errorf("exposition not terminated by # EOF")
+	}
+}
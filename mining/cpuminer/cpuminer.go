@@ -67,6 +67,11 @@ type Config struct {
 	// rules and handling as any other block coming from the network.
 	ProcessBlock func(*btcutil.Block, blockchain.BehaviorFlags) (bool, error)
 
+	// PowHasher computes the proof-of-work hash solved blocks must meet
+	// their target with.  It should be the same hasher the chain validates
+	// blocks with.
+	PowHasher blockchain.PowHasher
+
 	// ConnectedCount defines the function to use to obtain how many other
 	// peers the server is connected to.  This is used by the automatic
 	// persistent mining routine to determine whether or it should attempt
@@ -270,13 +275,16 @@ func (m *CPUMiner) solveBlock(msgBlock *wire.MsgBlock, blockHeight int32,
 				// Non-blocking select to fall through
 			}
 
-			// Update the nonce and hash the block header.  Each
-			// hash is actually a double sha256 (two hashes), so
-			// increment the number of hashes completed for each
-			// attempt accordingly.
+			// Update the nonce and hash the block header with the
+			// proof-of-work hash of its algorithm.
 			header.Nonce = i
-			hash := header.BlockHash()
-			hashesCompleted += 2
+			hash, err := m.cfg.PowHasher.PowHash(header, blockHeight)
+			if err != nil {
+				log.Errorf("Unable to hash block at height %d: %v",
+					blockHeight, err)
+				return false
+			}
+			hashesCompleted++
 
 			// The block is solved when the new block hash is less
 			// than the target difficulty.  Yay!
@@ -33,6 +33,11 @@ const (
 	// and is used to monitor BIP16 support as well as blocks that are
 	// generated via btcd.
 	CoinbaseFlags = "/P2SH/btcd/"
+
+	// CoinbaseExtraNonceSize is the number of bytes reserved in the coinbase
+	// script by SplitCoinbase for external miners to roll as an extra
+	// nonce.
+	CoinbaseExtraNonceSize = 8
 )
 
 // TxDesc is a descriptor about a transaction in a transaction source along with
@@ -955,6 +960,56 @@ func (g *BlkTmplGenerator) UpdateExtraNonce(msgBlock *wire.MsgBlock, blockHeight
 	return nil
 }
 
+// SplitCoinbase prepares the coinbase of the passed block for extra nonce
+// rolling by external miners.  The coinbase script is regenerated with the
+// extra nonce as a fixed-size push of CoinbaseExtraNonceSize zero bytes, the
+// merkle root is updated to match, and the serialized coinbase (without
+// witness data) is returned split into the parts before and after the extra
+// nonce.  Miners rebuild the coinbase as coinbase1 || extranonce || coinbase2.
+//
+// The passed block is modified, so callers that share a template must pass a
+// copy.
+func SplitCoinbase(msgBlock *wire.MsgBlock, blockHeight int32) ([]byte, []byte, error) {
+	heightScript, err := txscript.NewScriptBuilder().
+		AddInt64(int64(blockHeight)).Script()
+	if err != nil {
+		return nil, nil, err
+	}
+	coinbaseScript, err := txscript.NewScriptBuilder().
+		AddInt64(int64(blockHeight)).
+		AddData(make([]byte, CoinbaseExtraNonceSize)).
+		AddData([]byte(CoinbaseFlags)).Script()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(coinbaseScript) > blockchain.MaxCoinbaseScriptLen {
+		return nil, nil, fmt.Errorf("coinbase transaction script length "+
+			"of %d is out of range (min: %d, max: %d)",
+			len(coinbaseScript), blockchain.MinCoinbaseScriptLen,
+			blockchain.MaxCoinbaseScriptLen)
+	}
+	coinbaseTx := msgBlock.Transactions[0]
+	coinbaseTx.TxIn[0].SignatureScript = coinbaseScript
+
+	block := convert.NewShellBlock(msgBlock)
+	msgBlock.Header.MerkleRoot = blockchain.CalcMerkleRoot(
+		block.Transactions(), false)
+
+	var buf bytes.Buffer
+	buf.Grow(coinbaseTx.SerializeSizeStripped())
+	if err := coinbaseTx.SerializeNoWitness(&buf); err != nil {
+		return nil, nil, err
+	}
+
+	// The extra nonce follows the version, input count, previous outpoint,
+	// script length, height push and the push opcode of the extra nonce.
+	offset := 4 + wire.VarIntSerializeSize(uint64(len(coinbaseTx.TxIn))) +
+		36 + wire.VarIntSerializeSize(uint64(len(coinbaseScript))) +
+		len(heightScript) + 1
+	serialized := buf.Bytes()
+	return serialized[:offset], serialized[offset+CoinbaseExtraNonceSize:], nil
+}
+
 // BestSnapshot returns information about the current best chain block and
 // related state as of the current point in time using the chain instance
 // associated with the block template generator.  The returned state must be
@@ -5,11 +5,16 @@
 package mining
 
 import (
+	"bytes"
 	"container/heap"
 	"math/rand"
 	"testing"
 
 	"github.com/btcsuite/btcd/btcutil"
+	"github.com/toole-brendan/shell/blockchain"
+	"github.com/toole-brendan/shell/chaincfg"
+	"github.com/toole-brendan/shell/internal/convert"
+	"github.com/toole-brendan/shell/wire"
 )
 
 // TestTxFeePrioHeap ensures the priority queue for transaction fees and
@@ -108,3 +113,56 @@ func TestTxFeePrioHeap(t *testing.T) {
 		highest = prioItem
 	}
 }
+
+// TestSplitCoinbase ensures the coinbase parts returned by SplitCoinbase
+// rebuild the block coinbase around any extra nonce and that the merkle branch
+// of the block links the rebuilt coinbase to the correct merkle root.
+func TestSplitCoinbase(t *testing.T) {
+	const height = 1000
+	coinbaseScript, err := standardCoinbaseScript(height, 0)
+	if err != nil {
+		t.Fatalf("standardCoinbaseScript: %v", err)
+	}
+	coinbaseTx, err := createCoinbaseTx(&chaincfg.MainNetParams,
+		coinbaseScript, height, nil)
+	if err != nil {
+		t.Fatalf("createCoinbaseTx: %v", err)
+	}
+
+	var msgBlock wire.MsgBlock
+	msgBlock.AddTransaction(convert.MsgTxToShell(coinbaseTx.MsgTx()))
+	for i := 0; i < 4; i++ {
+		tx := wire.NewMsgTx(wire.TxVersion)
+		tx.LockTime = uint32(i)
+		msgBlock.AddTransaction(tx)
+	}
+
+	coinbase1, coinbase2, err := SplitCoinbase(&msgBlock, height)
+	if err != nil {
+		t.Fatalf("SplitCoinbase: %v", err)
+	}
+	branch := blockchain.CoinbaseMerkleBranch(
+		convert.NewShellBlock(&msgBlock).Transactions())
+
+	extraNonce := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	serialized := append(append(append([]byte{}, coinbase1...),
+		extraNonce...), coinbase2...)
+	var rolled wire.MsgTx
+	if err := rolled.Deserialize(bytes.NewReader(serialized)); err != nil {
+		t.Fatalf("rebuilt coinbase does not deserialize: %v", err)
+	}
+	script := rolled.TxIn[0].SignatureScript
+	if !bytes.Contains(script, extraNonce) {
+		t.Fatalf("rebuilt coinbase script %x does not contain extra "+
+			"nonce %x", script, extraNonce)
+	}
+
+	msgBlock.Transactions[0] = &rolled
+	want := blockchain.CalcMerkleRoot(
+		convert.NewShellBlock(&msgBlock).Transactions(), false)
+	rolledHash := rolled.TxHash()
+	got := blockchain.MerkleRootFromBranch(&rolledHash, branch)
+	if got != want {
+		t.Fatalf("merkle root mismatch - got %v, want %v", got, want)
+	}
+}
@@ -293,7 +293,8 @@ func (opt *ARM64Optimizer) MemoryBarrier() {
 	_ = atomic.LoadUint32(&x)
 }
 
-// ARMSpecificHash implements ARM-optimized hash mixing.
+// ARMSpecificHash implements ARM-optimized hash mixing.  It is the MobileX
+// word mix and must return exactly the words of the reference MixCore.
 func (opt *ARM64Optimizer) ARMSpecificHash(state []uint32) []uint32 {
 	// This would use ARM-specific instructions like:
 	// - REV (byte reverse) for endianness
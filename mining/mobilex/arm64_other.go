@@ -0,0 +1,30 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+//go:build !arm64
+// +build !arm64
+
+package mobilex
+
+// ARM64Optimizer provides the portable counterpart of the ARM64 optimizations
+// on other architectures such as x86.  It runs the reference implementations
+// so hashes match those computed on ARM64 devices.
+type ARM64Optimizer struct{}
+
+// NewARM64Optimizer creates a new portable optimizer.
+func NewARM64Optimizer() *ARM64Optimizer {
+	return &ARM64Optimizer{}
+}
+
+// HasNEON returns whether NEON vector instructions are available, which is
+// never the case off ARM64.
+func (opt *ARM64Optimizer) HasNEON() bool {
+	return false
+}
+
+// ARMSpecificHash implements the MobileX word mix using the reference
+// MixCore.
+func (opt *ARM64Optimizer) ARMSpecificHash(state []uint32) []uint32 {
+	return MixCore(state)
+}
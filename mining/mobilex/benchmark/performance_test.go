@@ -2,8 +2,10 @@ package benchmark
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,6 +15,11 @@ import (
 	"github.com/toole-brendan/shell/wire"
 )
 
+// profileDB is the device profile database benchmarked devices are recorded
+// in, such as with go test -bench . -args -profiledb=profiles.json.
+var profileDB = flag.String("profiledb", "", "record benchmarked devices in "+
+	"this device profile database")
+
 // TestDevice represents a test device configuration
 type TestDevice struct {
 	Name     string
@@ -82,6 +89,22 @@ func benchmarkDevice(b *testing.B, device TestDevice) {
 	b.ReportMetric(avgHashRate, "H/s")
 	b.ReportMetric(float64(device.Cores), "cores")
 	b.ReportMetric(float64(device.BigCores), "big_cores")
+
+	// Extend the device profiles with the measured hash rate
+	if *profileDB != "" {
+		result := &Result{
+			Model:    device.Name,
+			SoC:      device.SoC,
+			Cores:    device.Cores,
+			HashRate: avgHashRate,
+		}
+		if device.NPU != "None" {
+			result.NPUType = strings.ToLower(device.NPU)
+		}
+		if _, err := RecordResult(*profileDB, result); err != nil {
+			b.Fatalf("Failed to record device profile: %v", err)
+		}
+	}
 }
 
 // BenchmarkNPUvsGPU compares NPU performance against CPU fallback
@@ -0,0 +1,92 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package benchmark
+
+import (
+	"errors"
+	"os"
+
+	"github.com/toole-brendan/shell/mining/mobilex"
+)
+
+// Hash rates in H/s from which measured devices are classed as flagship and
+// midrange devices.  Slower devices are budget devices.
+const (
+	flagshipHashRate = 100
+	midrangeHashRate = 60
+)
+
+// Result is the outcome of benchmarking MobileX on a device.
+type Result struct {
+	Model    string  // Device model, such as "Pixel 8"
+	SoC      string  // System on Chip name
+	Cores    int     // Number of cores mined on
+	NPUType  string  // "coreml", "nnapi", etc., empty without an NPU
+	HashRate float64 // Measured hash rate in H/s
+}
+
+// ThermalClass returns the thermal class of a device that mines at the passed
+// hash rate.
+func ThermalClass(hashRate float64) string {
+	switch {
+	case hashRate >= flagshipHashRate:
+		return mobilex.ThermalClassFlagship
+	case hashRate >= midrangeHashRate:
+		return mobilex.ThermalClassMidrange
+	default:
+		return mobilex.ThermalClassBudget
+	}
+}
+
+// UpdateProfile adds the profile of the benchmarked device to the passed
+// registry and returns it.  The profile is named after the device model, or
+// its SoC when the model is unknown, and replaces an existing profile of that
+// name while keeping its patterns.
+func UpdateProfile(devices *mobilex.DeviceRegistry, result *Result) *mobilex.DeviceProfile {
+	name := result.Model
+	if name == "" {
+		name = result.SoC
+	}
+
+	profile := devices.Lookup(result.Model, result.SoC)
+	if profile.Name != name {
+		profile = &mobilex.DeviceProfile{Name: name, SoC: result.SoC}
+	}
+	if result.SoC != "" {
+		profile.SoC = result.SoC
+	}
+	profile.MaxHashRate = result.HashRate
+	profile.CoreCount = result.Cores
+	profile.HasNPU = result.NPUType != ""
+	profile.NPUType = result.NPUType
+	profile.ThermalClass = ThermalClass(result.HashRate)
+
+	devices.Add(profile)
+	return profile
+}
+
+// RecordResult adds the profile of the benchmarked device to the device
+// profile database at the passed path, creating it if needed.  The database
+// only holds measured devices so it can be passed to LoadDeviceRegistry to
+// override the default profiles.
+func RecordResult(dbPath string, result *Result) (*mobilex.DeviceProfile, error) {
+	devices := &mobilex.DeviceRegistry{}
+	data, err := os.ReadFile(dbPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := devices.Merge(data); err != nil {
+			return nil, err
+		}
+	}
+
+	profile := UpdateProfile(devices, result)
+	if err := devices.Save(dbPath); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package benchmark
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/toole-brendan/shell/mining/mobilex"
+)
+
+// TestRecordResult ensures benchmark results extend a device profile database
+// that then overrides the default profiles.
+func TestRecordResult(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "profiles.json")
+
+	results := []*Result{
+		{Model: "Galaxy A55", SoC: "Exynos 1480", Cores: 8,
+			NPUType: "nnapi", HashRate: 65},
+		{Model: "Pixel 8", SoC: "Tensor G3", Cores: 9,
+			NPUType: "nnapi", HashRate: 90},
+		{SoC: "Helio G99", Cores: 8, HashRate: 30},
+		{Model: "Galaxy A55", SoC: "Exynos 1480", Cores: 8,
+			NPUType: "nnapi", HashRate: 105},
+	}
+	for _, result := range results {
+		if _, err := RecordResult(dbPath, result); err != nil {
+			t.Fatalf("RecordResult: %v", err)
+		}
+	}
+
+	devices, err := mobilex.LoadDeviceRegistry(dbPath)
+	if err != nil {
+		t.Fatalf("LoadDeviceRegistry: %v", err)
+	}
+
+	tests := []struct {
+		model    string
+		soc      string
+		want     string
+		hashRate float64
+		class    string
+		npu      bool
+	}{
+		{"Galaxy A55", "", "Galaxy A55", 105, mobilex.ThermalClassFlagship, true},
+		{"", "Exynos 1480", "Galaxy A55", 105, mobilex.ThermalClassFlagship, true},
+		{"Pixel 8", "", "Pixel 8", 90, mobilex.ThermalClassMidrange, true},
+		{"", "Helio G99", "Helio G99", 30, mobilex.ThermalClassBudget, false},
+		{"Galaxy S24", "", "Galaxy S24", 120, mobilex.ThermalClassFlagship, true},
+	}
+	for _, test := range tests {
+		p := devices.Lookup(test.model, test.soc)
+		if p.Name != test.want || p.MaxHashRate != test.hashRate ||
+			p.ThermalClass != test.class || p.HasNPU != test.npu {
+
+			t.Errorf("lookup %q/%q: got %+v", test.model, test.soc, p)
+		}
+	}
+
+	// Updating a default profile keeps its patterns.
+	defaults := mobilex.NewDeviceRegistry()
+	p := UpdateProfile(defaults, &Result{Model: "Pixel 8", SoC: "Tensor G3",
+		Cores: 8, NPUType: "nnapi", HashRate: 50})
+	if len(p.Models) == 0 || p.ThermalClass != mobilex.ThermalClassBudget {
+		t.Fatalf("unexpected updated profile %+v", p)
+	}
+	if got := defaults.Lookup("Pixel 8 Pro", "").MaxHashRate; got != 50 {
+		t.Fatalf("got hash rate %v, want 50", got)
+	}
+}
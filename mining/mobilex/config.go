@@ -46,6 +46,11 @@ type Config struct {
 	ThermalThrottleStart float64 // Temperature to start throttling
 	ThermalThrottleStop  float64 // Temperature to stop mining
 
+	// Device sensors.  Nil sources use the platform default, which reads
+	// sysfs on Linux and Android.
+	TemperatureSource TemperatureSource
+	PowerSource       PowerSource
+
 	// Mining intensity levels
 	IntensityLight  MiningIntensity
 	IntensityMedium MiningIntensity
@@ -137,81 +142,23 @@ func LightModeConfig() *Config {
 	return cfg
 }
 
-// DeviceProfile represents a specific device's mining capabilities.
-type DeviceProfile struct {
-	Name         string
-	SoC          string // System on Chip name
-	MaxHashRate  float64
-	CoreCount    int
-	HasNPU       bool
-	NPUType      string // "coreml", "nnapi", "snpe", etc.
-	ThermalClass string // "flagship", "midrange", "budget"
-}
-
-// GetDeviceProfile returns optimized settings for known device types.
-func GetDeviceProfile(deviceName string) *DeviceProfile {
-	profiles := map[string]*DeviceProfile{
-		"iPhone 15 Pro": {
-			Name:         "iPhone 15 Pro",
-			SoC:          "A17 Pro",
-			MaxHashRate:  150.0,
-			CoreCount:    6,
-			HasNPU:       true,
-			NPUType:      "coreml",
-			ThermalClass: "flagship",
-		},
-		"Galaxy S24": {
-			Name:         "Galaxy S24",
-			SoC:          "Snapdragon 8 Gen 3",
-			MaxHashRate:  120.0,
-			CoreCount:    8,
-			HasNPU:       true,
-			NPUType:      "nnapi",
-			ThermalClass: "flagship",
-		},
-		"Pixel 8": {
-			Name:         "Pixel 8",
-			SoC:          "Tensor G3",
-			MaxHashRate:  100.0,
-			CoreCount:    8,
-			HasNPU:       true,
-			NPUType:      "nnapi",
-			ThermalClass: "flagship",
-		},
-	}
-
-	if profile, ok := profiles[deviceName]; ok {
-		return profile
-	}
-
-	// Default profile for unknown devices
-	return &DeviceProfile{
-		Name:         "Unknown",
-		SoC:          "Unknown",
-		MaxHashRate:  50.0,
-		CoreCount:    4,
-		HasNPU:       false,
-		ThermalClass: "budget",
-	}
-}
-
 // OptimizeForDevice adjusts configuration based on device profile.
 func (c *Config) OptimizeForDevice(profile *DeviceProfile) {
 	switch profile.ThermalClass {
-	case "flagship":
+	case ThermalClassFlagship:
 		c.MaxOperatingTemp = 45.0
 		c.OptimalOperatingTemp = 38.0
 		c.BigCores = min(profile.CoreCount/2, 4)
 		c.LittleCores = min(profile.CoreCount/2, 4)
 
-	case "midrange":
+	case ThermalClassMidrange:
 		c.MaxOperatingTemp = 43.0
 		c.OptimalOperatingTemp = 40.0
 		c.BigCores = min(profile.CoreCount/2, 2)
 		c.LittleCores = min(profile.CoreCount/2, 4)
 		c.RandomXMemory = 1 * 1024 * 1024 * 1024 // 1GB
 
-	case "budget":
+	case ThermalClassBudget:
 		c.MaxOperatingTemp = 42.0
 		c.OptimalOperatingTemp = 40.0
 		c.BigCores = 1
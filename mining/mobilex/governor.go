@@ -0,0 +1,227 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mobilex
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// governorSmoothing is the weight of a new temperature reading in the
+	// exponential moving average the governor controls on.  Sensor
+	// readings jump by a degree or more between samples, so they are
+	// smoothed to keep the worker counts from flapping.
+	governorSmoothing = 0.3
+
+	// governorKp, governorKi and governorKd are the proportional, integral
+	// and derivative gains of the governor.  The output is the fraction of
+	// the configured cores to run, so for example a reading one degree
+	// above the target removes 8% of the cores right away.
+	governorKp = 0.08
+	governorKi = 0.004
+	governorKd = 0.2
+
+	// governorInitialOutput is the output of the governor before the
+	// integral term has settled, which matches the medium intensity the
+	// scheduler starts at.
+	governorInitialOutput = 0.5
+
+	// governorMaxStep is the longest interval between two readings that is
+	// integrated.  Longer gaps, such as while the miner was stopped, are
+	// clamped so a single reading can't wind up the integral term.
+	governorMaxStep = 10 * time.Second
+)
+
+// GovernorAction describes why the thermal governor made a decision.
+type GovernorAction int
+
+const (
+	// GovernorRegulate indicates the worker counts follow the governor
+	// output to hold the temperature at OptimalOperatingTemp.
+	GovernorRegulate GovernorAction = iota
+
+	// GovernorHardStop indicates the temperature reached
+	// ThermalThrottleStop and mining stopped.
+	GovernorHardStop
+
+	// GovernorCoolDown indicates mining stays stopped until the
+	// temperature falls back to ThermalThrottleStart.
+	GovernorCoolDown
+
+	// GovernorResume indicates mining resumed after a hard stop.
+	GovernorResume
+)
+
+// governorActionStrings is a map of governor actions back to their names for
+// pretty printing.
+var governorActionStrings = map[GovernorAction]string{
+	GovernorRegulate: "regulate",
+	GovernorHardStop: "hard stop",
+	GovernorCoolDown: "cool down",
+	GovernorResume:   "resume",
+}
+
+// String returns the GovernorAction as a human-readable name.
+func (a GovernorAction) String() string {
+	if s, ok := governorActionStrings[a]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown GovernorAction (%d)", int(a))
+}
+
+// GovernorDecision records a single decision of the thermal governor along
+// with the inputs it was based on so device behavior can be explained.
+type GovernorDecision struct {
+	Time          time.Time
+	Action        GovernorAction
+	Temperature   float64 // Raw temperature reading in Celsius
+	Smoothed      float64 // Smoothed temperature in Celsius
+	Target        float64 // Target temperature in Celsius
+	Output        float64 // Fraction of the configured cores to run
+	BigWorkers    int     // Number of big core workers to run
+	LittleWorkers int     // Number of little core workers to run
+	NPUInterval   int     // Iterations between NPU operations
+}
+
+// Stopped returns whether mining is stopped as a result of the decision.
+func (d *GovernorDecision) Stopped() bool {
+	return d.Action == GovernorHardStop || d.Action == GovernorCoolDown
+}
+
+// ThermalGovernor is a PID controller that holds the device temperature at
+// OptimalOperatingTemp by scaling the number of big and little core workers
+// and the NPU interval together.  Independently of the controller, mining is
+// stopped once a reading reaches ThermalThrottleStop and only resumes once a
+// reading falls back to ThermalThrottleStart.
+//
+// The governor is not safe for concurrent use.
+type ThermalGovernor struct {
+	cfg         *Config
+	bigCores    int
+	littleCores int
+
+	smoothed  float64
+	integral  float64
+	prevError float64
+	lastTime  time.Time
+	output    float64
+	stopped   bool
+}
+
+// NewThermalGovernor returns a thermal governor for the passed configuration
+// that scales up to the passed number of big and little cores.
+func NewThermalGovernor(cfg *Config, bigCores, littleCores int) *ThermalGovernor {
+	return &ThermalGovernor{
+		cfg:         cfg,
+		bigCores:    bigCores,
+		littleCores: littleCores,
+		output:      governorInitialOutput,
+	}
+}
+
+// Update feeds a temperature reading taken at the passed time to the governor
+// and returns its decision.
+func (g *ThermalGovernor) Update(temp float64, now time.Time) GovernorDecision {
+	first := g.lastTime.IsZero()
+	dt := now.Sub(g.lastTime)
+	if dt > governorMaxStep {
+		dt = governorMaxStep
+	}
+	g.lastTime = now
+
+	if first {
+		g.smoothed = temp
+	} else {
+		g.smoothed += governorSmoothing * (temp - g.smoothed)
+	}
+
+	decision := GovernorDecision{
+		Time:        now,
+		Temperature: temp,
+		Smoothed:    g.smoothed,
+		Target:      g.cfg.OptimalOperatingTemp,
+	}
+
+	// The hard stop acts on the raw reading so a quickly rising
+	// temperature is not hidden by the smoothing.
+	switch {
+	case temp >= g.cfg.ThermalThrottleStop:
+		decision.Action = GovernorHardStop
+	case g.stopped && temp > g.cfg.ThermalThrottleStart:
+		decision.Action = GovernorCoolDown
+	case g.stopped:
+		decision.Action = GovernorResume
+	default:
+		decision.Action = GovernorRegulate
+	}
+
+	if decision.Stopped() {
+		// Restart the controller from scratch after cooling down
+		// rather than with an integral term wound up while stopped.
+		g.stopped = true
+		g.integral = 0
+		g.prevError = 0
+		g.output = 0
+		decision.NPUInterval = g.npuInterval()
+		return decision
+	}
+
+	if g.stopped {
+		g.stopped = false
+		g.smoothed = temp
+		g.output = governorInitialOutput
+		g.prevError = g.cfg.OptimalOperatingTemp - temp
+		decision.Smoothed = temp
+	} else if !first && dt > 0 {
+		// The error is positive when the device is cooler than the
+		// target so the output grows.
+		seconds := dt.Seconds()
+		err := g.cfg.OptimalOperatingTemp - g.smoothed
+		derivative := (err - g.prevError) / seconds
+		g.prevError = err
+
+		// Only integrate while that doesn't push a saturated output
+		// further out of range to avoid integral windup.
+		integral := g.integral + err*seconds
+		output := governorInitialOutput + governorKp*err +
+			governorKi*integral + governorKd*derivative
+		if (output < 1 || err < 0) && (output > 0 || err > 0) {
+			g.integral = integral
+		} else {
+			output = governorInitialOutput + governorKp*err +
+				governorKi*g.integral + governorKd*derivative
+		}
+		g.output = math.Max(0, math.Min(1, output))
+	} else {
+		g.prevError = g.cfg.OptimalOperatingTemp - g.smoothed
+	}
+
+	decision.Output = g.output
+	decision.BigWorkers, decision.LittleWorkers = g.workers()
+	decision.NPUInterval = g.npuInterval()
+	return decision
+}
+
+// workers returns the number of big and little core workers to run for the
+// current output.  One little core keeps running while mining is not stopped
+// since it coordinates the NPU and memory accesses.
+func (g *ThermalGovernor) workers() (int, int) {
+	big := int(math.Round(g.output * float64(g.bigCores)))
+	little := int(math.Round(g.output * float64(g.littleCores)))
+	if little == 0 && g.littleCores > 0 {
+		little = 1
+	}
+	return big, little
+}
+
+// npuInterval returns the number of iterations between NPU operations for the
+// current output.  The interval grows up to twice the configured interval as
+// the output falls so the NPU is throttled together with the cores.
+func (g *ThermalGovernor) npuInterval() int {
+	base := float64(g.cfg.NPUInterval)
+	return int(math.Round(base + base*(1-g.output)))
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mobilex
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// simThermalModel is a first order thermal model of a phone.  Each running
+// worker heats the device, which loses heat to the ambient air proportionally
+// to its temperature difference.  Readings carry sensor noise.
+type simThermalModel struct {
+	temp        float64
+	ambient     float64
+	idleHeat    float64 // Heating in degrees per second with no workers
+	bigHeat     float64 // Heating per big core worker in degrees per second
+	littleHeat  float64 // Heating per little core worker in degrees per second
+	dissipation float64 // Fraction of the temperature difference lost per second
+	noise       float64 // Maximum sensor noise in degrees
+	rng         *rand.Rand
+}
+
+// step advances the model by one second with the passed workers running and
+// returns the resulting sensor reading.
+func (m *simThermalModel) step(bigWorkers, littleWorkers int) float64 {
+	heat := m.idleHeat + float64(bigWorkers)*m.bigHeat +
+		float64(littleWorkers)*m.littleHeat
+	m.temp += heat - m.dissipation*(m.temp-m.ambient)
+	return m.temp + (m.rng.Float64()*2-1)*m.noise
+}
+
+// newSimThermalModel returns a thermal model starting at the ambient
+// temperature that settles around 55 degrees with all 8 cores running.
+func newSimThermalModel(ambient float64) *simThermalModel {
+	return &simThermalModel{
+		temp:        ambient,
+		ambient:     ambient,
+		idleHeat:    0.1,
+		bigHeat:     0.25,
+		littleHeat:  0.07,
+		dissipation: 0.05,
+		noise:       0.5,
+		rng:         rand.New(rand.NewSource(1)),
+	}
+}
+
+// runGovernor runs the passed governor against the passed thermal model for
+// the passed number of seconds, starting with the workers of the passed
+// previous decisions, and returns them with every new decision appended.
+func runGovernor(g *ThermalGovernor, model *simThermalModel, decisions []GovernorDecision, seconds int) []GovernorDecision {
+	start := time.Unix(1700000000, 0)
+	big, little := 0, 0
+	if len(decisions) > 0 {
+		last := decisions[len(decisions)-1]
+		big, little = last.BigWorkers, last.LittleWorkers
+	}
+	for i := 0; i < seconds; i++ {
+		reading := model.step(big, little)
+		now := start.Add(time.Duration(len(decisions)) * time.Second)
+		d := g.Update(reading, now)
+		big, little = d.BigWorkers, d.LittleWorkers
+		decisions = append(decisions, d)
+	}
+	return decisions
+}
+
+// TestThermalGovernorRegulates ensures the governor holds the simulated device
+// at the optimal temperature without stopping.
+func TestThermalGovernorRegulates(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.OptimalOperatingTemp = 40
+	cfg.ThermalThrottleStart = 45
+	cfg.ThermalThrottleStop = 48
+
+	g := NewThermalGovernor(cfg, 4, 4)
+	decisions := runGovernor(g, newSimThermalModel(25), nil, 900)
+
+	var sum float64
+	settled := decisions[600:]
+	for i, d := range decisions {
+		if d.Action != GovernorRegulate {
+			t.Fatalf("second %d: action %v at %.1f degrees, want %v",
+				i, d.Action, d.Temperature, GovernorRegulate)
+		}
+		if d.NPUInterval < cfg.NPUInterval ||
+			d.NPUInterval > 2*cfg.NPUInterval {
+
+			t.Fatalf("second %d: NPU interval %d out of range", i,
+				d.NPUInterval)
+		}
+	}
+	for _, d := range settled {
+		sum += d.Smoothed
+	}
+	mean := sum / float64(len(settled))
+	if math.Abs(mean-cfg.OptimalOperatingTemp) > 0.5 {
+		t.Fatalf("settled at %.2f degrees, want %.2f", mean,
+			cfg.OptimalOperatingTemp)
+	}
+
+	// The device must be throttled below full power to hold the target,
+	// and the NPU interval must follow the cores.
+	last := settled[len(settled)-1]
+	if last.BigWorkers == 4 && last.LittleWorkers == 4 {
+		t.Fatalf("running all cores at %.1f degrees", last.Smoothed)
+	}
+	if last.NPUInterval <= cfg.NPUInterval {
+		t.Fatalf("NPU interval %d not throttled with output %.2f",
+			last.NPUInterval, last.Output)
+	}
+}
+
+// TestThermalGovernorHardStop ensures the governor stops mining once the
+// temperature reaches ThermalThrottleStop and only resumes once it falls back
+// to ThermalThrottleStart.
+func TestThermalGovernorHardStop(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.OptimalOperatingTemp = 40
+	cfg.ThermalThrottleStart = 45
+	cfg.ThermalThrottleStop = 48
+
+	// The device starts out hot, cools down and regulates, is then left in
+	// the sun where even the idle device exceeds the stop temperature, and
+	// is finally moved back into the shade.
+	g := NewThermalGovernor(cfg, 4, 4)
+	model := newSimThermalModel(30)
+	model.temp = 52
+	decisions := runGovernor(g, model, nil, 300)
+	model.ambient = 50
+	decisions = runGovernor(g, model, decisions, 150)
+	model.ambient = 30
+	decisions = runGovernor(g, model, decisions, 300)
+
+	stops, resumes := 0, 0
+	stopped := false
+	for i, d := range decisions {
+		switch d.Action {
+		case GovernorHardStop:
+			if d.Temperature < cfg.ThermalThrottleStop {
+				t.Fatalf("second %d: hard stop at %.1f degrees", i,
+					d.Temperature)
+			}
+			if !stopped {
+				stops++
+			}
+			stopped = true
+		case GovernorCoolDown:
+			if !stopped {
+				t.Fatalf("second %d: cool down while running", i)
+			}
+			if d.Temperature <= cfg.ThermalThrottleStart {
+				t.Fatalf("second %d: still stopped at %.1f "+
+					"degrees", i, d.Temperature)
+			}
+		case GovernorResume:
+			if !stopped {
+				t.Fatalf("second %d: resumed while running", i)
+			}
+			if d.Temperature > cfg.ThermalThrottleStart {
+				t.Fatalf("second %d: resumed at %.1f degrees", i,
+					d.Temperature)
+			}
+			resumes++
+			stopped = false
+		case GovernorRegulate:
+			if stopped {
+				t.Fatalf("second %d: regulating while stopped", i)
+			}
+		}
+		if d.Stopped() && (d.BigWorkers != 0 || d.LittleWorkers != 0) {
+			t.Fatalf("second %d: %d big and %d little workers while "+
+				"stopped", i, d.BigWorkers, d.LittleWorkers)
+		}
+	}
+	if stops != 2 || resumes != 2 {
+		t.Fatalf("got %d stops and %d resumes, want 2 of each", stops,
+			resumes)
+	}
+	if last := decisions[len(decisions)-1]; last.Action != GovernorRegulate {
+		t.Fatalf("final action %v, want %v", last.Action,
+			GovernorRegulate)
+	}
+}
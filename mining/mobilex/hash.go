@@ -0,0 +1,210 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mobilex
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"sync"
+
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/mining/mobilex/npu/fallback"
+	"github.com/toole-brendan/shell/mining/randomx"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// The MobileX hash (version 1) of a block header is specified as follows.  All
+// integers are little endian and only integer arithmetic is used, so every
+// platform must produce the same result bit-for-bit.
+//
+//  1. input is the 80-byte serialization of the header's Version, PrevBlock,
+//     MerkleRoot, Timestamp (uint32 seconds), Bits and Nonce.  ThermalProof
+//     is excluded since it is only generated once a solution is found.
+//  2. core is the 32-byte RandomX hash of input under the epoch's cache.
+//  3. mixed is core read as eight uint32 words, each transformed by
+//     w = rotl(w, 13); w ^= w >> 7; w ^= w << 17 (see MixCore).
+//  4. tensor is the 3072 bytes SHA256(mixed || i) for i = 0..95, with i
+//     encoded as a uint32, laid out as a 32x32x3 uint8 tensor in HWC order.
+//  5. npu is fallback.QuantizedConvolution(tensor).
+//  6. The MobileX hash is SHA256(mixed || SHA256(npu)).
+//
+// Steps 3 to 6 are implemented by HashFromCore, which is the part that
+// platform-specific paths such as NEON mixing or NPU execution must reproduce.
+
+const (
+	// HashVersion is the version of the MobileX hash specification
+	// implemented by this package.
+	HashVersion = 1
+
+	// hashInputSize is the size of the header serialization hashed by
+	// MobileX.
+	hashInputSize = 80
+
+	// coreSize is the size of the RandomX core hash.
+	coreSize = 32
+)
+
+// CoreHasher computes the RandomX core of the MobileX hash.  It is satisfied
+// by *randomx.VM.
+type CoreHasher interface {
+	CalcHash(input []byte) []byte
+}
+
+// hashInput returns the header serialization hashed by MobileX.
+func hashInput(header *wire.BlockHeader) []byte {
+	var buf [hashInputSize]byte
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(header.Version))
+	copy(buf[4:36], header.PrevBlock[:])
+	copy(buf[36:68], header.MerkleRoot[:])
+	binary.LittleEndian.PutUint32(buf[68:72], uint32(header.Timestamp.Unix()))
+	binary.LittleEndian.PutUint32(buf[72:76], header.Bits)
+	binary.LittleEndian.PutUint32(buf[76:80], header.Nonce)
+	return buf[:]
+}
+
+// mixWord applies the MobileX word mix to a single uint32.
+func mixWord(w uint32) uint32 {
+	w = bits.RotateLeft32(w, 13)
+	w ^= w >> 7
+	w ^= w << 17
+	return w
+}
+
+// MixCore returns the reference mix of the passed core words.  Optimized
+// implementations such as ARM64Optimizer.ARMSpecificHash must return the same
+// words.
+func MixCore(words []uint32) []uint32 {
+	mixed := make([]uint32, len(words))
+	for i, w := range words {
+		mixed[i] = mixWord(w)
+	}
+	return mixed
+}
+
+// HashFromCore computes the MobileX hash from the RandomX core hash of a
+// header using the reference implementation of the mixing and NPU steps.
+func HashFromCore(core []byte) chainhash.Hash {
+	return hashFromCore(core, MixCore)
+}
+
+// hashFromCore computes the MobileX hash from the RandomX core hash of a
+// header using the passed word mixer.
+func hashFromCore(core []byte, mix func([]uint32) []uint32) chainhash.Hash {
+	var padded [coreSize]byte
+	copy(padded[:], core)
+	mixed := uint32sToBytes(mix(bytesToUint32s(padded[:])))
+
+	// Expand the mixed state into the NPU input tensor.
+	tensor := make([]byte, 0, fallback.QuantizedSize)
+	var block [coreSize + 4]byte
+	copy(block[:coreSize], mixed)
+	for i := uint32(0); len(tensor) < fallback.QuantizedSize; i++ {
+		binary.LittleEndian.PutUint32(block[coreSize:], i)
+		sum := sha256.Sum256(block[:])
+		tensor = append(tensor, sum[:]...)
+	}
+
+	npuDigest := sha256.Sum256(fallback.QuantizedConvolution(tensor))
+
+	final := make([]byte, 0, coreSize+sha256.Size)
+	final = append(final, mixed...)
+	final = append(final, npuDigest[:]...)
+	return chainhash.Hash(sha256.Sum256(final))
+}
+
+// HashHeader computes the MobileX hash of the passed header using the
+// reference implementation on top of the passed RandomX core.
+func HashHeader(core CoreHasher, header *wire.BlockHeader) chainhash.Hash {
+	return HashFromCore(core.CalcHash(hashInput(header)))
+}
+
+// HashHeaderAt computes the MobileX hash of the passed header of the block at
+// the passed height on top of the RandomX epoch of the height.  MobileX blocks
+// are keyed by the same seed as RandomX blocks at the same height, so they
+// share the epochs computed by the seed manager.
+func HashHeaderAt(seeds *randomx.SeedManager, height int32, header *wire.BlockHeader) (chainhash.Hash, error) {
+	core, err := seeds.Hash(height, hashInput(header))
+	if err != nil {
+		return chainhash.Hash{}, err
+	}
+	return HashFromCore(core), nil
+}
+
+// Verifier is the reference MobileX verifier used by pools and block
+// validation.  RandomX VMs are not safe for concurrent use, so the verifier
+// serializes access to its core.
+type Verifier struct {
+	mtx  sync.Mutex
+	core CoreHasher
+
+	// closers release the RandomX resources owned by the verifier.
+	closers []func()
+}
+
+// NewVerifier returns a verifier that computes hashes on top of the passed
+// RandomX core.  The caller retains ownership of the core.
+func NewVerifier(core CoreHasher) *Verifier {
+	return &Verifier{core: core}
+}
+
+// NewLightVerifier returns a verifier backed by a light-mode RandomX VM for
+// the passed seed.  Light mode only needs the RandomX cache, which keeps
+// verification cheap at the cost of slower hashing.  Close must be called to
+// release the VM.
+func NewLightVerifier(seed []byte) (*Verifier, error) {
+	cache, err := randomx.NewCache(seed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create RandomX cache: %w", err)
+	}
+	vm, err := randomx.NewVM(cache, nil)
+	if err != nil {
+		cache.Close()
+		return nil, fmt.Errorf("failed to create RandomX VM: %w", err)
+	}
+
+	return &Verifier{
+		core:    vm,
+		closers: []func(){vm.Close, cache.Close},
+	}, nil
+}
+
+// Hash returns the MobileX hash of the passed header.
+func (v *Verifier) Hash(header *wire.BlockHeader) chainhash.Hash {
+	v.mtx.Lock()
+	core := v.core.CalcHash(hashInput(header))
+	v.mtx.Unlock()
+
+	return HashFromCore(core)
+}
+
+// Verify returns an error if the MobileX hash of the passed header is above
+// the passed target.
+func (v *Verifier) Verify(header *wire.BlockHeader, target *big.Int) error {
+	if target.Sign() <= 0 {
+		return errors.New("target must be positive")
+	}
+
+	hash := v.Hash(header)
+	if HashToBig(&hash).Cmp(target) > 0 {
+		return fmt.Errorf("MobileX hash %v is higher than target %064x",
+			hash, target)
+	}
+	return nil
+}
+
+// Close releases the RandomX resources owned by the verifier.
+func (v *Verifier) Close() {
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	for _, closeFn := range v.closers {
+		closeFn()
+	}
+	v.closers = nil
+}
@@ -233,6 +233,24 @@ func (hs *HeterogeneousScheduler) adjustWorkers() {
 		targetLittleCores = len(hs.littleCores)
 	}
 
+	hs.applyWorkerCounts(targetBigCores, targetLittleCores)
+}
+
+// SetWorkerCounts activates the passed number of big and little cores, clamped
+// to the cores available.  Unlike the intensity levels, this lets the thermal
+// governor scale both core types in single core steps.
+func (hs *HeterogeneousScheduler) SetWorkerCounts(bigCores, littleCores int) {
+	hs.mutex.Lock()
+	defer hs.mutex.Unlock()
+
+	hs.applyWorkerCounts(bigCores, littleCores)
+}
+
+// applyWorkerCounts activates the first passed number of big and little cores
+// and deactivates the rest.
+//
+// This function MUST be called with the scheduler mutex held (for writes).
+func (hs *HeterogeneousScheduler) applyWorkerCounts(targetBigCores, targetLittleCores int) {
 	// Update active cores
 	activeCores := 0
 
@@ -6,9 +6,12 @@ package mobilex
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/toole-brendan/shell/openmetrics"
 )
 
 // MiningMetrics contains real-time mining performance metrics.
@@ -27,6 +30,11 @@ type MetricsCollector struct {
 	metricsMutex sync.RWMutex
 	maxMetrics   int
 
+	// governorDecisions and thermalViolations are protected by
+	// metricsMutex.
+	governorDecisions []GovernorDecision
+	thermalViolations uint64
+
 	errors      map[string]uint64
 	errorsMutex sync.RWMutex
 
@@ -92,6 +100,32 @@ func (mc *MetricsCollector) Record(metrics MiningMetrics) {
 	}
 }
 
+// RecordGovernorDecision records a decision of the thermal governor.  The most
+// recent decisions are kept, up to the same limit as the metrics history.
+func (mc *MetricsCollector) RecordGovernorDecision(decision GovernorDecision) {
+	mc.metricsMutex.Lock()
+	defer mc.metricsMutex.Unlock()
+
+	if decision.Action == GovernorHardStop {
+		mc.thermalViolations++
+	}
+	mc.governorDecisions = append(mc.governorDecisions, decision)
+	if len(mc.governorDecisions) > mc.maxMetrics {
+		mc.governorDecisions = mc.governorDecisions[len(mc.governorDecisions)-mc.maxMetrics:]
+	}
+}
+
+// GetGovernorDecisions returns the recorded thermal governor decisions, oldest
+// first.
+func (mc *MetricsCollector) GetGovernorDecisions() []GovernorDecision {
+	mc.metricsMutex.RLock()
+	defer mc.metricsMutex.RUnlock()
+
+	decisions := make([]GovernorDecision, len(mc.governorDecisions))
+	copy(decisions, mc.governorDecisions)
+	return decisions
+}
+
 // RecordError records an error occurrence.
 func (mc *MetricsCollector) RecordError(errorType string, err error) {
 	mc.errorsMutex.Lock()
@@ -284,12 +318,66 @@ func (mc *MetricsCollector) GetThermalEfficiency() float64 {
 	return float64(optimalCount) / float64(len(mc.metrics)) * 100
 }
 
+// Collect returns the most recent metrics, the number of thermal violations
+// and the recorded errors as OpenMetrics families.
+//
+// This is part of the openmetrics.Collector interface.
+func (mc *MetricsCollector) Collect() []*openmetrics.Family {
+	mc.metricsMutex.RLock()
+	var current MiningMetrics
+	if len(mc.metrics) > 0 {
+		current = mc.metrics[len(mc.metrics)-1]
+	}
+	thermalViolations := mc.thermalViolations
+	mc.metricsMutex.RUnlock()
+
+	errorsFamily := &openmetrics.Family{
+		Name: "mobilex_errors",
+		Help: "MobileX mining errors by type.",
+		Type: openmetrics.Counter,
+	}
+	mc.errorsMutex.RLock()
+	errorTypes := make([]string, 0, len(mc.errors))
+	for errorType := range mc.errors {
+		errorTypes = append(errorTypes, errorType)
+	}
+	sort.Strings(errorTypes)
+	for _, errorType := range errorTypes {
+		errorsFamily.Add(float64(mc.errors[errorType]),
+			openmetrics.Label{Name: "type", Value: errorType})
+	}
+	mc.errorsMutex.RUnlock()
+
+	return []*openmetrics.Family{
+		openmetrics.NewGauge("mobilex_hashrate",
+			"Current MobileX hash rate in H/s.", current.HashRate),
+		openmetrics.NewCounter("mobilex_hashes",
+			"MobileX hashes completed.",
+			float64(atomic.LoadUint64(&mc.totalHashes))),
+		openmetrics.NewGauge("mobilex_temperature_celsius",
+			"Current device temperature.", current.Temperature).
+			WithUnit("celsius"),
+		openmetrics.NewGauge("mobilex_power_watts",
+			"Current power consumption.", current.PowerUsage).
+			WithUnit("watts"),
+		openmetrics.NewGauge("mobilex_npu_utilization_ratio",
+			"Current NPU utilization.", current.NPUUtilization/100).
+			WithUnit("ratio"),
+		openmetrics.NewCounter("mobilex_thermal_violations",
+			"Temperature readings that stopped mining.",
+			float64(thermalViolations)),
+		errorsFamily,
+	}
+}
+
 // Reset clears all collected metrics.
 func (mc *MetricsCollector) Reset() {
 	mc.metricsMutex.Lock()
 	defer mc.metricsMutex.Unlock()
 
 	mc.metrics = mc.metrics[:0]
+	mc.governorDecisions = nil
+	mc.thermalViolations = 0
 	mc.totalHashes = 0
 	mc.avgHashRate = 0
 	mc.avgTemperature = 0
@@ -0,0 +1,58 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mobilex
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/toole-brendan/shell/openmetrics"
+)
+
+// TestMetricsCollectorCollect ensures the collector exports the latest mining
+// metrics, thermal violations and errors as OpenMetrics families.
+func TestMetricsCollectorCollect(t *testing.T) {
+	mc := NewMetricsCollector()
+	mc.Record(MiningMetrics{HashRate: 80, Temperature: 50, PowerUsage: 3})
+	mc.Record(MiningMetrics{
+		HashRate:        120,
+		HashesCompleted: 5000,
+		Temperature:     42.5,
+		PowerUsage:      4,
+		NPUUtilization:  25,
+	})
+	for _, action := range []GovernorAction{GovernorRegulate,
+		GovernorHardStop, GovernorHardStop, GovernorCoolDown} {
+
+		mc.RecordGovernorDecision(GovernorDecision{Action: action})
+	}
+	mc.RecordError("npu", errors.New("npu busy"))
+	mc.RecordError("npu", errors.New("npu busy"))
+	mc.RecordError("header", errors.New("bad header"))
+
+	var buf bytes.Buffer
+	if err := openmetrics.Encode(&buf, mc.Collect()); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	wantLines := []string{
+		"mobilex_hashrate 120",
+		"mobilex_hashes_total 5000",
+		"mobilex_temperature_celsius 42.5",
+		"mobilex_power_watts 4",
+		"mobilex_npu_utilization_ratio 0.25",
+		"mobilex_thermal_violations_total 2",
+		`mobilex_errors_total{type="header"} 1`,
+		`mobilex_errors_total{type="npu"} 2`,
+	}
+	exposition := buf.String()
+	for _, line := range wantLines {
+		if !strings.Contains(exposition, line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, exposition)
+		}
+	}
+}
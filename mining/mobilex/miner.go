@@ -22,6 +22,46 @@ import (
 	"github.com/toole-brendan/shell/wire"
 )
 
+// pausePollInterval is how often a paused miner checks whether it may resume.
+const pausePollInterval = 100 * time.Millisecond
+
+// PauseReason describes why a miner is paused.
+type PauseReason int32
+
+const (
+	// PauseNone indicates the miner is not paused.
+	PauseNone PauseReason = iota
+
+	// PauseThermal indicates the device reached ThermalThrottleStop.
+	// Mining resumes once the device cools down to ThermalThrottleStart.
+	PauseThermal
+
+	// PauseNotCharging indicates the device is running on battery while
+	// RequireCharging is set.
+	PauseNotCharging
+
+	// PauseBatteryLow indicates the battery level is below
+	// MinBatteryLevel.
+	PauseBatteryLow
+)
+
+// pauseReasonStrings is a map of pause reasons back to their names for pretty
+// printing.
+var pauseReasonStrings = map[PauseReason]string{
+	PauseNone:        "none",
+	PauseThermal:     "thermal",
+	PauseNotCharging: "not charging",
+	PauseBatteryLow:  "battery low",
+}
+
+// String returns the PauseReason as a human-readable name.
+func (r PauseReason) String() string {
+	if s, ok := pauseReasonStrings[r]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown PauseReason (%d)", int32(r))
+}
+
 // MobileXMiner implements mobile-optimized mining with MobileX algorithm.
 type MobileXMiner struct {
 	cfg           *Config
@@ -41,6 +81,13 @@ type MobileXMiner struct {
 	bestHash        chainhash.Hash
 	bestHashMutex   sync.RWMutex
 
+	// Device state
+	tempSource  TemperatureSource
+	powerSource PowerSource
+	governor    *ThermalGovernor
+	npuInterval int32 // atomic
+	pauseReason int32 // atomic PauseReason
+
 	// Metrics
 	startTime        time.Time
 	metricsCollector *MetricsCollector
@@ -107,6 +154,15 @@ func NewMobileXMiner(cfg *Config) (*MobileXMiner, error) {
 		return nil, fmt.Errorf("failed to create RandomX VM: %w", err)
 	}
 
+	tempSource := cfg.TemperatureSource
+	if tempSource == nil {
+		tempSource = defaultTemperatureSource()
+	}
+	powerSource := cfg.PowerSource
+	if powerSource == nil {
+		powerSource = defaultPowerSource()
+	}
+
 	miner := &MobileXMiner{
 		cfg:              cfg,
 		arm64:            arm64,
@@ -116,6 +172,10 @@ func NewMobileXMiner(cfg *Config) (*MobileXMiner, error) {
 		cache:            cache,
 		dataset:          dataset,
 		vm:               vm,
+		tempSource:       tempSource,
+		powerSource:      powerSource,
+		governor:         NewThermalGovernor(cfg, cfg.BigCores, cfg.LittleCores),
+		npuInterval:      int32(cfg.NPUInterval),
 		metricsCollector: NewMetricsCollector(),
 	}
 
@@ -134,6 +194,9 @@ func (m *MobileXMiner) Start(ctx context.Context) error {
 	// Start heterogeneous core scheduling
 	m.heterogeneous.Start()
 
+	// Don't start hashing before the device state allows it.
+	m.updateDeviceState()
+
 	// Start thermal monitoring
 	go m.thermalMonitoringLoop(ctx)
 
@@ -186,6 +249,16 @@ func (m *MobileXMiner) SolveBlock(msgBlock *wire.MsgBlock, blockHeight int32,
 			// Update metrics periodically
 			m.updateMetrics()
 		default:
+			// Wait while the device state does not allow mining.
+			if m.PauseReason() != PauseNone {
+				select {
+				case <-quit:
+					return false, nil
+				case <-time.After(pausePollInterval):
+				}
+				continue
+			}
+
 			// Attempt to solve block
 			found, err := m.mineIteration(&msgBlock.Header, targetDifficulty, blockHeight)
 			if err != nil {
@@ -234,56 +307,32 @@ func (m *MobileXMiner) mineIteration(header *wire.BlockHeader, target *big.Int,
 }
 
 // computeMobileXHash computes the MobileX hash for a block header.
+//
+// The word mix runs on the ARM64 optimizer when NEON is enabled, which must
+// reproduce the reference MixCore exactly.  See hash.go for the
+// specification.
 func (m *MobileXMiner) computeMobileXHash(header *wire.BlockHeader) chainhash.Hash {
-	// Serialize header
-	headerBytes := serializeBlockHeader(header)
-
-	// Apply ARM64 optimizations if available
+	mix := MixCore
 	if m.cfg.UseNEON && m.arm64.HasNEON() {
-		// Pre-process with NEON vector operations
-		headerBytes = m.arm64.VectorHash(headerBytes)
+		mix = m.arm64.ARMSpecificHash
 	}
 
-	// Run through RandomX VM
-	vmOutput := m.vm.CalcHash(headerBytes)
-
-	// Apply additional mobile-specific mixing
-	// This ensures mobile hardware advantages
-	mixed := m.applyMobileMixing(vmOutput)
-
-	// Convert to chainhash.Hash
-	var hash chainhash.Hash
-	copy(hash[:], mixed)
-
-	return hash
+	core := m.vm.CalcHash(hashInput(header))
+	return hashFromCore(core, mix)
 }
 
-// applyMobileMixing applies mobile-specific mixing to the RandomX output.
-func (m *MobileXMiner) applyMobileMixing(randomxHash []byte) []byte {
-	// Convert to uint32s for ARM-specific operations
-	uint32Data := bytesToUint32s(randomxHash)
-
-	// Apply ARM-specific hash operations
-	mixed := m.arm64.ARMSpecificHash(uint32Data)
-
-	// Mix with heterogeneous core scheduling state
-	coreState := m.heterogeneous.GetCoreState()
-	for i := range mixed {
-		mixed[i] ^= coreState
-	}
-
-	// Final hash
-	finalBytes := uint32sToBytes(mixed)
-	finalHash := sha256.Sum256(finalBytes)
-
-	return finalHash[:]
+// Hash returns the MobileX hash of the passed header as computed by the
+// miner's platform-specific path.
+func (m *MobileXMiner) Hash(header *wire.BlockHeader) chainhash.Hash {
+	return m.computeMobileXHash(header)
 }
 
 // shouldRunNPU determines if NPU operations should run this iteration.
 func (m *MobileXMiner) shouldRunNPU() bool {
-	// Run NPU every N iterations based on configuration
+	// Run NPU every N iterations as set by the thermal governor
 	hashCount := atomic.LoadUint64(&m.hashesCompleted)
-	return m.cfg.NPUEnabled && (hashCount%uint64(m.cfg.NPUInterval) == 0)
+	interval := uint64(atomic.LoadInt32(&m.npuInterval))
+	return m.cfg.NPUEnabled && interval > 0 && hashCount%interval == 0
 }
 
 // runNPUStep executes NPU operations and feeds results back into mining.
@@ -337,32 +386,77 @@ func (m *MobileXMiner) thermalMonitoringLoop(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			// Get current temperature (placeholder - would read from sensors)
-			temp := m.readTemperature()
+			m.updateDeviceState()
+		}
+	}
+}
+
+// updateDeviceState reads the device sensors, feeds the temperature to the
+// thermal verification and pauses or resumes mining according to the thermal
+// limits and the battery policy.  A sensor that can't be read leaves the state
+// it governs unchanged.
+func (m *MobileXMiner) updateDeviceState() {
+	reason := m.PauseReason()
+
+	thermalPaused := reason == PauseThermal
+	if m.tempSource != nil {
+		temp, err := m.tempSource.Temperature()
+		if err != nil {
+			m.metricsCollector.RecordError("temperature_error", err)
+		} else {
 			m.thermal.UpdateTemperature(temp)
 
-			// Check thermal limits
-			if temp > m.cfg.ThermalThrottleStop {
-				// Stop mining if too hot
-				atomic.StoreInt32(&m.mining, 0)
-			} else if temp > m.cfg.ThermalThrottleStart {
-				// Reduce intensity
-				m.heterogeneous.ReduceIntensity()
-			} else if temp < m.cfg.OptimalOperatingTemp {
-				// Can increase intensity
-				m.heterogeneous.IncreaseIntensity()
-			}
+			// The governor scales the workers and NPU interval to
+			// hold the optimal temperature and stops mining when
+			// the device gets too hot.
+			decision := m.governor.Update(temp, time.Now())
+			m.metricsCollector.RecordGovernorDecision(decision)
+			m.heterogeneous.SetWorkerCounts(decision.BigWorkers,
+				decision.LittleWorkers)
+			atomic.StoreInt32(&m.npuInterval,
+				int32(decision.NPUInterval))
+			thermalPaused = decision.Stopped()
 		}
 	}
+
+	batteryReason := PauseNone
+	if reason == PauseNotCharging || reason == PauseBatteryLow {
+		batteryReason = reason
+	}
+	if m.powerSource != nil {
+		status, err := m.powerSource.PowerStatus()
+		if err != nil {
+			m.metricsCollector.RecordError("power_error", err)
+		} else {
+			batteryReason = batteryPauseReason(m.cfg, status)
+		}
+	}
+
+	newReason := batteryReason
+	if thermalPaused {
+		newReason = PauseThermal
+	}
+	atomic.StoreInt32(&m.pauseReason, int32(newReason))
+}
+
+// batteryPauseReason returns why the battery policy of the passed config does
+// not allow mining with the passed power status, or PauseNone when it does.
+func batteryPauseReason(cfg *Config, status PowerStatus) PauseReason {
+	if !status.HasBattery {
+		return PauseNone
+	}
+	if cfg.RequireCharging && !status.Charging {
+		return PauseNotCharging
+	}
+	if status.BatteryLevel < cfg.MinBatteryLevel {
+		return PauseBatteryLow
+	}
+	return PauseNone
 }
 
-// readTemperature reads the current device temperature.
-func (m *MobileXMiner) readTemperature() float64 {
-	// In real implementation, this would read from:
-	// - /sys/class/thermal/thermal_zone*/temp on Android
-	// - IOKit on iOS
-	// For now, return a simulated value
-	return 40.0 + float64(atomic.LoadUint64(&m.hashesCompleted)%10)*0.5
+// PauseReason returns why the miner is paused, or PauseNone when it is not.
+func (m *MobileXMiner) PauseReason() PauseReason {
+	return PauseReason(atomic.LoadInt32(&m.pauseReason))
 }
 
 // updateMetrics updates mining metrics.
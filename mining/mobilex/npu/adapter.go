@@ -16,6 +16,12 @@ var (
 	ErrNPUExecutionFailed = errors.New("NPU execution failed")
 	ErrNPUModelInvalid    = errors.New("NPU model is invalid")
 	ErrNPUTimeout         = errors.New("NPU operation timed out")
+	ErrNPUInputInvalid    = errors.New("NPU input does not match the model")
+
+	// ErrNPUModelUnsupported indicates an adapter cannot run a quantized
+	// model with output identical to Execute.  Callers fall back to Execute
+	// when an adapter returns it.
+	ErrNPUModelUnsupported = errors.New("NPU cannot run the model exactly")
 )
 
 // NPUAdapter is the interface for platform-specific NPU implementations.
@@ -29,6 +35,12 @@ type NPUAdapter interface {
 	// RunConvolution executes a convolution operation on the NPU.
 	RunConvolution(input Tensor) (Tensor, error)
 
+	// RunModel executes a quantized model on the NPU.  The output must be
+	// identical to the output of Execute for the same model and input, and
+	// adapters that cannot guarantee that must return
+	// ErrNPUModelUnsupported.
+	RunModel(model *Model, input []uint8) ([]uint8, error)
+
 	// GetPerformanceMetrics returns current NPU performance metrics.
 	GetPerformanceMetrics() NPUMetrics
 
@@ -138,7 +150,7 @@ func (m *NPUManager) SetFallback(fallback func(Tensor) (Tensor, error)) {
 // ExecuteConvolution runs a convolution operation, falling back to CPU if needed.
 func (m *NPUManager) ExecuteConvolution(input Tensor) (Tensor, error) {
 	// Check if NPU is available
-	if !m.adapter.IsAvailable() {
+	if m.adapter == nil || !m.adapter.IsAvailable() {
 		if m.fallbackFunc != nil {
 			return m.fallbackFunc(input)
 		}
@@ -166,6 +178,28 @@ func (m *NPUManager) ExecuteConvolution(input Tensor) (Tensor, error) {
 	return output, nil
 }
 
+// ExecuteModel runs a quantized model on the NPU, falling back to the
+// reference executor when there is no NPU or it cannot run the model.  The
+// adapter of the manager must have passed VerifyAdapter for the model, which
+// DetectAdapter ensures, so the output is always identical to Execute.
+func (m *NPUManager) ExecuteModel(model *Model, input []uint8) ([]uint8, error) {
+	if m.adapter == nil || !m.adapter.IsAvailable() {
+		return Execute(model, input)
+	}
+
+	startTime := time.Now()
+	output, err := m.adapter.RunModel(model, input)
+	if err != nil {
+		return Execute(model, input)
+	}
+
+	metrics := m.adapter.GetPerformanceMetrics()
+	metrics.InferenceTime = time.Since(startTime)
+	m.recordMetrics(metrics)
+
+	return output, nil
+}
+
 // recordMetrics stores performance metrics for analysis.
 func (m *NPUManager) recordMetrics(metrics NPUMetrics) {
 	m.metrics = append(m.metrics, metrics)
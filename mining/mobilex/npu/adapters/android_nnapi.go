@@ -2,8 +2,8 @@
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
-//go:build android
-// +build android
+//go:build android && cgo
+// +build android,cgo
 
 package adapters
 
@@ -80,11 +80,20 @@ import (
 	"errors"
 	"runtime"
 	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/toole-brendan/shell/mining/mobilex/npu"
 )
 
+// nnapiPriority is the priority of the NNAPI adapter in the NPU adapter
+// registry.
+const nnapiPriority = 10
+
+func init() {
+	_ = npu.RegisterAdapter("nnapi", nnapiPriority, NewAndroidNNAPIAdapter)
+}
+
 // AndroidNNAPIAdapter implements NPU operations using Android's Neural Networks API
 type AndroidNNAPIAdapter struct {
 	model       *C.ANeuralNetworksModel
@@ -111,7 +120,7 @@ func NewAndroidNNAPIAdapter() npu.NPUAdapter {
 }
 
 // Initialize sets up the NNAPI model and compilation
-func (a *AndroidNNAPIAdapter) Initialize(config *npu.ModelConfig) error {
+func (a *AndroidNNAPIAdapter) Initialize(modelPath string) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -144,8 +153,8 @@ func (a *AndroidNNAPIAdapter) Initialize(config *npu.ModelConfig) error {
 	return nil
 }
 
-// ExecuteConvolution runs depthwise separable convolution on the NPU
-func (a *AndroidNNAPIAdapter) ExecuteConvolution(input npu.Tensor) (npu.Tensor, error) {
+// RunConvolution runs depthwise separable convolution on the NPU
+func (a *AndroidNNAPIAdapter) RunConvolution(input npu.Tensor) (npu.Tensor, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -153,7 +162,7 @@ func (a *AndroidNNAPIAdapter) ExecuteConvolution(input npu.Tensor) (npu.Tensor,
 		return npu.Tensor{}, errors.New("adapter not initialized")
 	}
 
-	startTime := npu.GetTimeNanos()
+	startTime := time.Now()
 
 	// Create execution
 	var execution *C.ANeuralNetworksExecution
@@ -203,16 +212,23 @@ func (a *AndroidNNAPIAdapter) ExecuteConvolution(input npu.Tensor) (npu.Tensor,
 	}
 
 	// Update metrics
-	endTime := npu.GetTimeNanos()
-	a.metrics.ExecutionTimeNs = endTime - startTime
+	a.metrics.InferenceTime = time.Since(startTime)
 	a.metrics.Utilization = 0.8 // Estimate 80% NPU utilization
 	a.metrics.PowerUsage = 2.5  // Estimate 2.5W for NPU operation
 
 	return npu.CreateTensor(output, input.Shape), nil
 }
 
-// GetMetrics returns NPU performance metrics
-func (a *AndroidNNAPIAdapter) GetMetrics() npu.NPUMetrics {
+// RunModel always returns npu.ErrNPUModelUnsupported since NNAPI drivers
+// requantize with vendor-specific rounding and cannot reproduce the integer
+// semantics of quantized models exactly.  Callers fall back to the reference
+// executor.
+func (a *AndroidNNAPIAdapter) RunModel(model *npu.Model, input []uint8) ([]uint8, error) {
+	return nil, npu.ErrNPUModelUnsupported
+}
+
+// GetPerformanceMetrics returns NPU performance metrics
+func (a *AndroidNNAPIAdapter) GetPerformanceMetrics() npu.NPUMetrics {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 	return a.metrics
@@ -225,19 +241,20 @@ func (a *AndroidNNAPIAdapter) IsAvailable() bool {
 	return true
 }
 
-// GetDeviceInfo returns information about the NPU device
-func (a *AndroidNNAPIAdapter) GetDeviceInfo() npu.DeviceInfo {
-	return npu.DeviceInfo{
-		DeviceType:   "Android NNAPI",
-		DeviceName:   "Qualcomm Hexagon DSP", // Example, would detect actual device
+// GetHardwareInfo returns information about the NPU hardware
+func (a *AndroidNNAPIAdapter) GetHardwareInfo() npu.HardwareInfo {
+	return npu.HardwareInfo{
+		Vendor:       "Android NNAPI",
+		Model:        "Qualcomm Hexagon DSP", // Example, would detect actual device
 		ComputeUnits: 1,
-		MaxFreqMHz:   800,
-		Architecture: "Hexagon",
+		MaxFrequency: 800,
+		SupportedOps: []string{"Conv2D"},
+		Precision:    []string{"fp32"},
 	}
 }
 
-// cleanup releases NNAPI resources
-func (a *AndroidNNAPIAdapter) cleanup() {
+// Shutdown releases NNAPI resources
+func (a *AndroidNNAPIAdapter) Shutdown() error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -252,6 +269,12 @@ func (a *AndroidNNAPIAdapter) cleanup() {
 	}
 
 	a.initialized = false
+	return nil
+}
+
+// cleanup is called by the finalizer
+func (a *AndroidNNAPIAdapter) cleanup() {
+	_ = a.Shutdown()
 }
 
 // generateDepthwiseWeights generates simple depthwise separable convolution weights
@@ -2,8 +2,8 @@
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
-//go:build !android
-// +build !android
+//go:build !android || !cgo
+// +build !android !cgo
 
 package adapters
 
@@ -13,10 +13,11 @@ import (
 	"github.com/toole-brendan/shell/mining/mobilex/npu"
 )
 
-// AndroidNNAPIAdapter stub for non-Android platforms
+// AndroidNNAPIAdapter stub for non-Android platforms and builds without cgo
 type AndroidNNAPIAdapter struct{}
 
-// NewAndroidNNAPIAdapter creates a stub adapter on non-Android platforms
+// NewAndroidNNAPIAdapter creates a stub adapter on non-Android platforms.
+// The stub is not registered, so npu.DetectAdapter never selects it.
 func NewAndroidNNAPIAdapter() npu.NPUAdapter {
 	return &AndroidNNAPIAdapter{}
 }
@@ -36,6 +37,11 @@ func (a *AndroidNNAPIAdapter) RunConvolution(input npu.Tensor) (npu.Tensor, erro
 	return npu.Tensor{}, errors.New("Android NNAPI not available on this platform")
 }
 
+// RunModel executes a quantized model on the NPU
+func (a *AndroidNNAPIAdapter) RunModel(model *npu.Model, input []uint8) ([]uint8, error) {
+	return nil, npu.ErrNPUModelUnsupported
+}
+
 // GetPerformanceMetrics returns NPU metrics
 func (a *AndroidNNAPIAdapter) GetPerformanceMetrics() npu.NPUMetrics {
 	return npu.NPUMetrics{}
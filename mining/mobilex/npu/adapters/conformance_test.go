@@ -0,0 +1,51 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package adapters
+
+import (
+	"testing"
+
+	"github.com/toole-brendan/shell/mining/mobilex/npu"
+	"github.com/toole-brendan/shell/mining/mobilex/npu/fallback"
+)
+
+// TestAdapterConformance ensures every adapter registered on the platform the
+// tests run on either declines to run the MobileX model or produces output
+// identical to the reference executor, so NPU results can never split
+// consensus.
+func TestAdapterConformance(t *testing.T) {
+	model := fallback.MobileXModel()
+
+	reference := npu.NewReferenceAdapter(model)
+	if err := npu.VerifyAdapter(reference, model); err != nil {
+		t.Fatalf("reference adapter: %v", err)
+	}
+
+	names := npu.SupportedAdapters()
+	if len(names) == 0 {
+		t.Skip("no NPU adapters registered on this platform")
+	}
+	for _, name := range names {
+		adapter, err := npu.NewAdapter(name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !adapter.IsAvailable() {
+			t.Logf("%s: not available", name)
+			continue
+		}
+		if err := adapter.Initialize(""); err != nil {
+			t.Logf("%s: unable to initialize: %v", name, err)
+			continue
+		}
+		if err := npu.VerifyAdapter(adapter, model); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if err := adapter.Shutdown(); err != nil {
+			t.Errorf("%s: unable to shut down: %v", name, err)
+		}
+	}
+}
@@ -2,8 +2,9 @@
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
-//go:build ios || darwin
+//go:build (ios || darwin) && cgo
 // +build ios darwin
+// +build cgo
 
 package adapters
 
@@ -87,6 +88,14 @@ import (
 	"github.com/toole-brendan/shell/mining/mobilex/npu"
 )
 
+// coreMLPriority is the priority of the Core ML adapter in the NPU adapter
+// registry.
+const coreMLPriority = 10
+
+func init() {
+	_ = npu.RegisterAdapter("coreml", coreMLPriority, NewIOSCoreMLAdapter)
+}
+
 // IOSCoreMLAdapter implements NPU operations using Apple's Core ML
 type IOSCoreMLAdapter struct {
 	model       unsafe.Pointer
@@ -167,6 +176,13 @@ func (a *IOSCoreMLAdapter) RunConvolution(input npu.Tensor) (npu.Tensor, error)
 	return npu.CreateTensor(output, input.Shape), nil
 }
 
+// RunModel always returns npu.ErrNPUModelUnsupported since the Neural Engine
+// computes in fp16 and cannot reproduce the integer semantics of quantized
+// models exactly.  Callers fall back to the reference executor.
+func (a *IOSCoreMLAdapter) RunModel(model *npu.Model, input []uint8) ([]uint8, error) {
+	return nil, npu.ErrNPUModelUnsupported
+}
+
 // GetPerformanceMetrics returns NPU performance metrics
 func (a *IOSCoreMLAdapter) GetPerformanceMetrics() npu.NPUMetrics {
 	a.mu.Lock()
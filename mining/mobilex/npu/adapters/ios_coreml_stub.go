@@ -2,8 +2,8 @@
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
-//go:build !ios && !darwin
-// +build !ios,!darwin
+//go:build (!ios && !darwin) || !cgo
+// +build !ios,!darwin !cgo
 
 package adapters
 
@@ -13,10 +13,11 @@ import (
 	"github.com/toole-brendan/shell/mining/mobilex/npu"
 )
 
-// IOSCoreMLAdapter stub for non-iOS/macOS platforms
+// IOSCoreMLAdapter stub for non-iOS/macOS platforms and builds without cgo
 type IOSCoreMLAdapter struct{}
 
-// NewIOSCoreMLAdapter creates a stub adapter on non-iOS/macOS platforms
+// NewIOSCoreMLAdapter creates a stub adapter on non-iOS/macOS platforms.
+// The stub is not registered, so npu.DetectAdapter never selects it.
 func NewIOSCoreMLAdapter() npu.NPUAdapter {
 	return &IOSCoreMLAdapter{}
 }
@@ -36,6 +37,11 @@ func (a *IOSCoreMLAdapter) RunConvolution(input npu.Tensor) (npu.Tensor, error)
 	return npu.Tensor{}, errors.New("Core ML not available on this platform")
 }
 
+// RunModel executes a quantized model on the NPU
+func (a *IOSCoreMLAdapter) RunModel(model *npu.Model, input []uint8) ([]uint8, error) {
+	return nil, npu.ErrNPUModelUnsupported
+}
+
 // GetPerformanceMetrics returns NPU metrics
 func (a *IOSCoreMLAdapter) GetPerformanceMetrics() npu.NPUMetrics {
 	return npu.NPUMetrics{}
@@ -213,7 +213,7 @@ func initializeBias() []float32 {
 // GetPerformanceMetrics returns CPU fallback performance metrics.
 func (cf *CPUNeuralFallback) GetPerformanceMetrics() npu.NPUMetrics {
 	return npu.NPUMetrics{
-		InferenceTime:      time.Duration(cf.performancePenalty) * time.Millisecond,
+		InferenceTime:      time.Duration(cf.performancePenalty * float64(time.Millisecond)),
 		PowerUsage:         5.0, // Estimated 5W for CPU computation
 		Utilization:        float64(cf.numThreads) / float64(runtime.NumCPU()) * 100,
 		MemoryUsed:         32 * 32 * 3 * 4 * 2, // Input + output tensors
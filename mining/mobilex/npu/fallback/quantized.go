@@ -0,0 +1,96 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fallback
+
+import (
+	"github.com/toole-brendan/shell/mining/mobilex/npu"
+)
+
+const (
+	// QuantizedHeight is the height of the quantized convolution tensor.
+	QuantizedHeight = 32
+
+	// QuantizedWidth is the width of the quantized convolution tensor.
+	QuantizedWidth = 32
+
+	// QuantizedChannels is the channel count of the quantized convolution
+	// tensor.
+	QuantizedChannels = 3
+
+	// QuantizedSize is the number of elements in a quantized convolution
+	// tensor.
+	QuantizedSize = QuantizedHeight * QuantizedWidth * QuantizedChannels
+
+	// quantizedShift is the right shift that requantizes the int32
+	// accumulators back to uint8.
+	quantizedShift = 8
+)
+
+// mobileXModel is the quantized model run by QuantizedConvolution.
+var mobileXModel = newMobileXModel()
+
+// newMobileXModel returns the quantized model of the MobileX hash.  Its
+// weights are consensus constants of the MobileX hash and must never change
+// without a new MobileX version.
+func newMobileXModel() *npu.Model {
+	return &npu.Model{
+		Name:     "mobilex",
+		Version:  1,
+		Height:   QuantizedHeight,
+		Width:    QuantizedWidth,
+		Channels: QuantizedChannels,
+		Nodes: []npu.Node{{
+			// 3x3 depthwise kernel of each channel in row-major
+			// order.
+			Op: npu.OpDepthwiseConv3x3,
+			Weights: []int32{
+				1, 2, 1, 2, 4, 2, 1, 2, 1,
+				-1, 0, 1, -2, 0, 2, -1, 0, 1,
+				0, -1, 0, -1, 5, -1, 0, -1, 0,
+			},
+		}, {
+			// 1x1 pointwise weights indexed by output channel and
+			// then input channel, and the bias of each output
+			// channel.
+			Op: npu.OpPointwiseConv,
+			Weights: []int32{
+				3, -1, 2,
+				1, 4, -2,
+				-2, 1, 3,
+			},
+			Bias: []int32{16, -8, 4},
+		}, {
+			Op: npu.OpReLU,
+		}, {
+			Op:    npu.OpRequantize,
+			Shift: quantizedShift,
+		}},
+	}
+}
+
+// MobileXModel returns the quantized model of the MobileX hash.  Each call
+// returns a new copy, so modifying it does not affect QuantizedConvolution.
+func MobileXModel() *npu.Model {
+	return newMobileXModel()
+}
+
+// QuantizedConvolution runs the integer-only depthwise separable convolution
+// used by the MobileX hash on a 32x32x3 uint8 tensor in HWC layout.
+//
+// The depthwise step applies each channel's 3x3 kernel with zero padding, the
+// pointwise step mixes the channels and adds the bias, and the result is
+// passed through ReLU and requantized to uint8 by shifting right by 8 bits and
+// saturating at 255.  It runs MobileXModel with the reference executor, and
+// every NPU adapter that runs the model must produce identical output.
+//
+// The input must contain exactly QuantizedSize elements or this function will
+// panic.
+func QuantizedConvolution(input []uint8) []uint8 {
+	output, err := npu.Execute(mobileXModel, input)
+	if err != nil {
+		panic(err)
+	}
+	return output
+}
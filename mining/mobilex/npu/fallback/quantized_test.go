@@ -0,0 +1,75 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fallback
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+// TestQuantizedConvolutionVectors ensures the output of the MobileX model does
+// not change, since the MobileX hash commits to it.
+func TestQuantizedConvolutionVectors(t *testing.T) {
+	ramp := make([]uint8, QuantizedSize)
+	for i := range ramp {
+		ramp[i] = uint8(i*7 + 3)
+	}
+	saturated := make([]uint8, QuantizedSize)
+	for i := range saturated {
+		saturated[i] = 0xff
+	}
+
+	tests := []struct {
+		name  string
+		input []uint8
+		want  string
+	}{
+		{
+			name:  "ramp",
+			input: ramp,
+			want:  "6000cbd30a3b29e11081f86bc5f7a115f983c6ec5c7fd8db371c5efe3546b0fb",
+		},
+		{
+			name:  "saturated",
+			input: saturated,
+			want:  "b343640ab3e83bb5353ba7f2240c05d8c6e297f53377b8beb6124ff164a1c3f7",
+		},
+	}
+
+	for _, test := range tests {
+		digest := sha256.Sum256(QuantizedConvolution(test.input))
+		if got := hex.EncodeToString(digest[:]); got != test.want {
+			t.Errorf("%s: output digest %s, want %s", test.name, got,
+				test.want)
+		}
+	}
+}
+
+// TestMobileXModelCopy ensures modifying the model returned by MobileXModel
+// does not affect QuantizedConvolution.
+func TestMobileXModelCopy(t *testing.T) {
+	input := make([]uint8, QuantizedSize)
+	for i := range input {
+		input[i] = uint8(i)
+	}
+	want := QuantizedConvolution(input)
+
+	model := MobileXModel()
+	if err := model.Validate(); err != nil {
+		t.Fatalf("MobileXModel is invalid: %v", err)
+	}
+	for i := range model.Nodes[0].Weights {
+		model.Nodes[0].Weights[i] = 0
+	}
+
+	got := QuantizedConvolution(input)
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("output changed at %d after modifying a copy "+
+				"of the model", i)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package npu
+
+import (
+	"fmt"
+)
+
+// OpType identifies an operator of a quantized model.
+type OpType uint8
+
+// Operators supported by quantized models.  They form a small subset of the
+// ONNX operator set with the integer semantics pinned down exactly so every
+// executor of a model produces identical output.
+const (
+	// OpDepthwiseConv3x3 convolves each channel with its own 3x3 kernel
+	// using stride one and zero padding of one.  The weights hold the
+	// kernel of each channel in row-major order.
+	OpDepthwiseConv3x3 OpType = iota + 1
+
+	// OpPointwiseConv is a 1x1 convolution that mixes the channels of each
+	// element.  The weights are indexed by output channel and then input
+	// channel, and the bias, when present, holds one value per output
+	// channel.
+	OpPointwiseConv
+
+	// OpReLU replaces negative values with zero.
+	OpReLU
+
+	// OpRequantize shifts each value right by the shift of the node and
+	// saturates the result to the range of a uint8.
+	OpRequantize
+)
+
+// opTypeStrings is a map of operators back to their constant names for pretty
+// printing.
+var opTypeStrings = map[OpType]string{
+	OpDepthwiseConv3x3: "DepthwiseConv3x3",
+	OpPointwiseConv:    "PointwiseConv",
+	OpReLU:             "ReLU",
+	OpRequantize:       "Requantize",
+}
+
+// String returns the OpType in human-readable form.
+func (op OpType) String() string {
+	if s, ok := opTypeStrings[op]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown OpType (%d)", uint8(op))
+}
+
+// Node is a single operator of a quantized model.
+type Node struct {
+	Op      OpType
+	Weights []int32
+	Bias    []int32
+	Shift   uint8
+}
+
+// Model is a quantized neural network in a minimal ONNX-like form: a linear
+// sequence of integer operators applied to a uint8 tensor in HWC layout.
+// Every intermediate value is an int32 that wraps on overflow, and the last
+// node must requantize the values back to uint8.
+type Model struct {
+	Name     string
+	Version  uint32
+	Height   int
+	Width    int
+	Channels int
+	Nodes    []Node
+}
+
+// InputSize returns the number of elements of the input tensor of the model.
+func (m *Model) InputSize() int {
+	return m.Height * m.Width * m.Channels
+}
+
+// OutputChannels returns the channel count of the output tensor of the model.
+// The model must be valid.
+func (m *Model) OutputChannels() int {
+	channels := m.Channels
+	for _, node := range m.Nodes {
+		if node.Op == OpPointwiseConv {
+			channels = len(node.Weights) / channels
+		}
+	}
+	return channels
+}
+
+// OutputSize returns the number of elements of the output tensor of the model.
+// The model must be valid.
+func (m *Model) OutputSize() int {
+	return m.Height * m.Width * m.OutputChannels()
+}
+
+// Validate returns ErrNPUModelInvalid wrapped with the reason when the shape
+// of the model or the parameters of one of its nodes are inconsistent.
+func (m *Model) Validate() error {
+	if m.Height <= 0 || m.Width <= 0 || m.Channels <= 0 {
+		return fmt.Errorf("%w: input shape %dx%dx%d", ErrNPUModelInvalid,
+			m.Height, m.Width, m.Channels)
+	}
+	if len(m.Nodes) == 0 || m.Nodes[len(m.Nodes)-1].Op != OpRequantize {
+		return fmt.Errorf("%w: model must end with %v", ErrNPUModelInvalid,
+			OpRequantize)
+	}
+
+	channels := m.Channels
+	for i, node := range m.Nodes {
+		switch node.Op {
+		case OpDepthwiseConv3x3:
+			if len(node.Weights) != channels*9 {
+				return fmt.Errorf("%w: node %d (%v) has %d weights, "+
+					"want %d", ErrNPUModelInvalid, i, node.Op,
+					len(node.Weights), channels*9)
+			}
+
+		case OpPointwiseConv:
+			if len(node.Weights) == 0 || len(node.Weights)%channels != 0 {
+				return fmt.Errorf("%w: node %d (%v) has %d weights "+
+					"for %d input channels", ErrNPUModelInvalid, i,
+					node.Op, len(node.Weights), channels)
+			}
+			channels = len(node.Weights) / channels
+			if len(node.Bias) != 0 && len(node.Bias) != channels {
+				return fmt.Errorf("%w: node %d (%v) has %d biases, "+
+					"want %d", ErrNPUModelInvalid, i, node.Op,
+					len(node.Bias), channels)
+			}
+
+		case OpReLU:
+
+		case OpRequantize:
+			if node.Shift > 31 {
+				return fmt.Errorf("%w: node %d (%v) shifts by %d",
+					ErrNPUModelInvalid, i, node.Op, node.Shift)
+			}
+
+		default:
+			return fmt.Errorf("%w: node %d has unsupported operator %v",
+				ErrNPUModelInvalid, i, node.Op)
+		}
+	}
+
+	return nil
+}
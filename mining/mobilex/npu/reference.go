@@ -0,0 +1,235 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package npu
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Execute runs the passed quantized model on a uint8 tensor in HWC layout with
+// the reference integer semantics of its operators.  It is the definition of
+// the output of a model: the output of every adapter running a model must be
+// identical to it, since the MobileX hash commits to the output of its model.
+func Execute(model *Model, input []uint8) ([]uint8, error) {
+	if err := model.Validate(); err != nil {
+		return nil, err
+	}
+	if len(input) != model.InputSize() {
+		return nil, fmt.Errorf("%w: %d elements for a %dx%dx%d model",
+			ErrNPUInputInvalid, len(input), model.Height, model.Width,
+			model.Channels)
+	}
+
+	values := make([]int32, len(input))
+	for i, v := range input {
+		values[i] = int32(v)
+	}
+
+	h, w, c := model.Height, model.Width, model.Channels
+	for _, node := range model.Nodes {
+		switch node.Op {
+		case OpDepthwiseConv3x3:
+			values = depthwiseConv3x3(values, h, w, c, node.Weights)
+
+		case OpPointwiseConv:
+			values = pointwiseConv(values, c, node.Weights, node.Bias)
+			c = len(node.Weights) / c
+
+		case OpReLU:
+			for i, v := range values {
+				if v < 0 {
+					values[i] = 0
+				}
+			}
+
+		case OpRequantize:
+			for i, v := range values {
+				v >>= node.Shift
+				if v < 0 {
+					v = 0
+				} else if v > math.MaxUint8 {
+					v = math.MaxUint8
+				}
+				values[i] = v
+			}
+		}
+	}
+
+	// The model ends with a requantization, so every value fits a uint8.
+	output := make([]uint8, len(values))
+	for i, v := range values {
+		output[i] = uint8(v)
+	}
+	return output, nil
+}
+
+// depthwiseConv3x3 convolves each of the c channels of the passed h by w
+// tensor with its own 3x3 kernel using zero padding of one.
+func depthwiseConv3x3(input []int32, h, w, c int, weights []int32) []int32 {
+	output := make([]int32, len(input))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			for ch := 0; ch < c; ch++ {
+				kernel := weights[ch*9 : ch*9+9]
+				var sum int32
+				for ky := 0; ky < 3; ky++ {
+					iy := y + ky - 1
+					if iy < 0 || iy >= h {
+						continue
+					}
+					for kx := 0; kx < 3; kx++ {
+						ix := x + kx - 1
+						if ix < 0 || ix >= w {
+							continue
+						}
+						sum += input[(iy*w+ix)*c+ch] *
+							kernel[ky*3+kx]
+					}
+				}
+				output[(y*w+x)*c+ch] = sum
+			}
+		}
+	}
+	return output
+}
+
+// pointwiseConv mixes the c input channels of each element of the passed
+// tensor into len(weights)/c output channels and adds the bias, if any.
+func pointwiseConv(input []int32, c int, weights, bias []int32) []int32 {
+	outChannels := len(weights) / c
+	elements := len(input) / c
+	output := make([]int32, elements*outChannels)
+	for i := 0; i < elements; i++ {
+		pixel := input[i*c : i*c+c]
+		for out := 0; out < outChannels; out++ {
+			var sum int32
+			if len(bias) != 0 {
+				sum = bias[out]
+			}
+			for in := 0; in < c; in++ {
+				sum += pixel[in] * weights[out*c+in]
+			}
+			output[i*outChannels+out] = sum
+		}
+	}
+	return output
+}
+
+// ReferenceAdapter is an NPUAdapter that runs models on the CPU with Execute.
+// It is always available and serves as the baseline the output of the
+// hardware adapters is compared against.
+type ReferenceAdapter struct {
+	model *Model
+
+	mtx     sync.Mutex
+	metrics NPUMetrics
+}
+
+// Ensure ReferenceAdapter implements the NPUAdapter interface.
+var _ NPUAdapter = (*ReferenceAdapter)(nil)
+
+// NewReferenceAdapter returns a reference adapter that runs the passed model
+// for RunConvolution.
+func NewReferenceAdapter(model *Model) *ReferenceAdapter {
+	return &ReferenceAdapter{model: model}
+}
+
+// IsAvailable returns true since the reference adapter only needs the CPU.
+//
+// This is part of the NPUAdapter interface.
+func (a *ReferenceAdapter) IsAvailable() bool {
+	return true
+}
+
+// Initialize validates the model of the adapter.  The reference adapter does
+// not load models from files, so the path is ignored.
+//
+// This is part of the NPUAdapter interface.
+func (a *ReferenceAdapter) Initialize(modelPath string) error {
+	return a.model.Validate()
+}
+
+// RunConvolution quantizes the passed tensor, which holds values in the range
+// [0, 1], runs the model of the adapter on it and returns the dequantized
+// output.
+//
+// This is part of the NPUAdapter interface.
+func (a *ReferenceAdapter) RunConvolution(input Tensor) (Tensor, error) {
+	quantized := make([]uint8, len(input.Data))
+	for i, v := range input.Data {
+		quantized[i] = uint8(math.Round(math.Max(0, math.Min(1,
+			float64(v))) * math.MaxUint8))
+	}
+
+	output, err := a.RunModel(a.model, quantized)
+	if err != nil {
+		return Tensor{}, err
+	}
+
+	data := make([]float32, len(output))
+	for i, v := range output {
+		data[i] = float32(v) / math.MaxUint8
+	}
+	shape := []int{a.model.Height, a.model.Width, a.model.OutputChannels()}
+	return CreateTensor(data, shape), nil
+}
+
+// RunModel runs the passed model with Execute.
+//
+// This is part of the NPUAdapter interface.
+func (a *ReferenceAdapter) RunModel(model *Model, input []uint8) ([]uint8, error) {
+	start := time.Now()
+	output, err := Execute(model, input)
+	if err != nil {
+		return nil, err
+	}
+	elapsed := time.Since(start)
+
+	a.mtx.Lock()
+	a.metrics.InferenceTime = elapsed
+	if elapsed > 0 {
+		a.metrics.InferencesPerSec = float64(time.Second) /
+			float64(elapsed)
+	}
+	a.mtx.Unlock()
+
+	return output, nil
+}
+
+// GetPerformanceMetrics returns the metrics of the last model run.
+//
+// This is part of the NPUAdapter interface.
+func (a *ReferenceAdapter) GetPerformanceMetrics() NPUMetrics {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.metrics
+}
+
+// GetHardwareInfo describes the reference executor.
+//
+// This is part of the NPUAdapter interface.
+func (a *ReferenceAdapter) GetHardwareInfo() HardwareInfo {
+	ops := make([]string, 0, len(opTypeStrings))
+	for op := OpDepthwiseConv3x3; op <= OpRequantize; op++ {
+		ops = append(ops, op.String())
+	}
+	return HardwareInfo{
+		Vendor:       "Shell",
+		Model:        "Reference Executor",
+		ComputeUnits: 1,
+		SupportedOps: ops,
+		Precision:    []string{"int32"},
+	}
+}
+
+// Shutdown does nothing since the reference adapter holds no resources.
+//
+// This is part of the NPUAdapter interface.
+func (a *ReferenceAdapter) Shutdown() error {
+	return nil
+}
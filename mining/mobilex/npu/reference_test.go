@@ -0,0 +1,178 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package npu
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestExecute ensures the reference executor applies the integer semantics of
+// each operator.
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name  string
+		model Model
+		input []uint8
+		want  []uint8
+	}{{
+		name: "depthwise zero padding",
+		model: Model{Height: 2, Width: 2, Channels: 1, Nodes: []Node{
+			{Op: OpDepthwiseConv3x3, Weights: []int32{
+				0, 0, 0,
+				0, 1, 1,
+				0, 0, 0,
+			}},
+			{Op: OpRequantize},
+		}},
+		input: []uint8{4, 8, 12, 16},
+		want:  []uint8{12, 8, 28, 16},
+	}, {
+		name: "pointwise widens channels",
+		model: Model{Height: 1, Width: 2, Channels: 1, Nodes: []Node{
+			{Op: OpPointwiseConv, Weights: []int32{2, -1},
+				Bias: []int32{1, 0}},
+			{Op: OpRequantize},
+		}},
+		input: []uint8{10, 200},
+		want:  []uint8{21, 0, 255, 0},
+	}, {
+		name: "relu before shift",
+		model: Model{Height: 1, Width: 1, Channels: 2, Nodes: []Node{
+			{Op: OpPointwiseConv, Weights: []int32{1, -1, -1, 1}},
+			{Op: OpReLU},
+			{Op: OpRequantize, Shift: 2},
+		}},
+		input: []uint8{200, 100},
+		want:  []uint8{25, 0},
+	}, {
+		name: "negative values saturate to zero",
+		model: Model{Height: 1, Width: 1, Channels: 1, Nodes: []Node{
+			{Op: OpPointwiseConv, Weights: []int32{1},
+				Bias: []int32{-1000}},
+			{Op: OpRequantize, Shift: 4},
+		}},
+		input: []uint8{0},
+		want:  []uint8{0},
+	}}
+
+	for _, test := range tests {
+		got, err := Execute(&test.model, test.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !bytes.Equal(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+		if len(got) != test.model.OutputSize() {
+			t.Errorf("%s: output size %d, want %d", test.name,
+				len(got), test.model.OutputSize())
+		}
+	}
+}
+
+// TestExecuteErrors ensures the reference executor rejects invalid models and
+// inputs that do not match the model.
+func TestExecuteErrors(t *testing.T) {
+	requantize := Node{Op: OpRequantize}
+	tests := []struct {
+		name  string
+		model Model
+		input []uint8
+		err   error
+	}{{
+		name:  "empty shape",
+		model: Model{Nodes: []Node{requantize}},
+		err:   ErrNPUModelInvalid,
+	}, {
+		name:  "no nodes",
+		model: Model{Height: 1, Width: 1, Channels: 1},
+		input: []uint8{0},
+		err:   ErrNPUModelInvalid,
+	}, {
+		name: "no final requantization",
+		model: Model{Height: 1, Width: 1, Channels: 1, Nodes: []Node{
+			requantize, {Op: OpReLU},
+		}},
+		input: []uint8{0},
+		err:   ErrNPUModelInvalid,
+	}, {
+		name: "depthwise weight count",
+		model: Model{Height: 1, Width: 1, Channels: 2, Nodes: []Node{
+			{Op: OpDepthwiseConv3x3, Weights: make([]int32, 9)},
+			requantize,
+		}},
+		input: []uint8{0, 0},
+		err:   ErrNPUModelInvalid,
+	}, {
+		name: "pointwise weight count",
+		model: Model{Height: 1, Width: 1, Channels: 2, Nodes: []Node{
+			{Op: OpPointwiseConv, Weights: make([]int32, 3)},
+			requantize,
+		}},
+		input: []uint8{0, 0},
+		err:   ErrNPUModelInvalid,
+	}, {
+		name: "pointwise bias count",
+		model: Model{Height: 1, Width: 1, Channels: 2, Nodes: []Node{
+			{Op: OpPointwiseConv, Weights: make([]int32, 4),
+				Bias: make([]int32, 1)},
+			requantize,
+		}},
+		input: []uint8{0, 0},
+		err:   ErrNPUModelInvalid,
+	}, {
+		name: "shift too large",
+		model: Model{Height: 1, Width: 1, Channels: 1, Nodes: []Node{
+			{Op: OpRequantize, Shift: 32},
+		}},
+		input: []uint8{0},
+		err:   ErrNPUModelInvalid,
+	}, {
+		name: "unknown operator",
+		model: Model{Height: 1, Width: 1, Channels: 1, Nodes: []Node{
+			{Op: OpType(0xff)}, requantize,
+		}},
+		input: []uint8{0},
+		err:   ErrNPUModelInvalid,
+	}, {
+		name: "input size",
+		model: Model{Height: 1, Width: 1, Channels: 1, Nodes: []Node{
+			requantize,
+		}},
+		input: []uint8{0, 0},
+		err:   ErrNPUInputInvalid,
+	}}
+
+	for _, test := range tests {
+		_, err := Execute(&test.model, test.input)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: got error %v, want %v", test.name, err,
+				test.err)
+		}
+	}
+}
+
+// TestOpTypeStringer tests the stringized output for the OpType type.
+func TestOpTypeStringer(t *testing.T) {
+	tests := []struct {
+		in   OpType
+		want string
+	}{
+		{OpDepthwiseConv3x3, "DepthwiseConv3x3"},
+		{OpPointwiseConv, "PointwiseConv"},
+		{OpReLU, "ReLU"},
+		{OpRequantize, "Requantize"},
+		{0xff, "Unknown OpType (255)"},
+	}
+
+	for _, test := range tests {
+		if got := test.in.String(); got != test.want {
+			t.Errorf("String: got %q, want %q", got, test.want)
+		}
+	}
+}
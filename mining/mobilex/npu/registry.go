@@ -0,0 +1,188 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package npu
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrAdapterRegistered indicates an adapter with the same name has
+	// already been registered.
+	ErrAdapterRegistered = errors.New("NPU adapter already registered")
+
+	// ErrUnknownAdapter indicates no adapter with the requested name has
+	// been registered.
+	ErrUnknownAdapter = errors.New("unknown NPU adapter")
+
+	// ErrNPUOutputMismatch indicates an adapter produced output that differs
+	// from the reference executor.
+	ErrNPUOutputMismatch = errors.New("NPU output differs from the reference executor")
+)
+
+// AdapterFactory returns a new, uninitialized NPU adapter.
+type AdapterFactory func() NPUAdapter
+
+// registeredAdapter is an adapter factory registered under a name.
+type registeredAdapter struct {
+	name     string
+	priority int
+	factory  AdapterFactory
+}
+
+var (
+	// adaptersMtx protects adapters.
+	adaptersMtx sync.Mutex
+
+	// adapters holds all of the registered NPU adapters by name.
+	adapters = make(map[string]*registeredAdapter)
+)
+
+// RegisterAdapter adds an NPU adapter to the adapters DetectAdapter chooses
+// from.  Platform adapters register themselves from init functions in the
+// files built for their platform.  Adapters with a higher priority are tried
+// first.  ErrAdapterRegistered will be returned if an adapter with the same
+// name has already been registered.
+func RegisterAdapter(name string, priority int, factory AdapterFactory) error {
+	adaptersMtx.Lock()
+	defer adaptersMtx.Unlock()
+
+	if _, exists := adapters[name]; exists {
+		return fmt.Errorf("%w: %q", ErrAdapterRegistered, name)
+	}
+	adapters[name] = &registeredAdapter{
+		name:     name,
+		priority: priority,
+		factory:  factory,
+	}
+	return nil
+}
+
+// sortedAdapters returns the registered adapters by descending priority and
+// then by name.
+func sortedAdapters() []*registeredAdapter {
+	adaptersMtx.Lock()
+	sorted := make([]*registeredAdapter, 0, len(adapters))
+	for _, adapter := range adapters {
+		sorted = append(sorted, adapter)
+	}
+	adaptersMtx.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].priority != sorted[j].priority {
+			return sorted[i].priority > sorted[j].priority
+		}
+		return sorted[i].name < sorted[j].name
+	})
+	return sorted
+}
+
+// SupportedAdapters returns the names of the registered NPU adapters in the
+// order DetectAdapter tries them.
+func SupportedAdapters() []string {
+	sorted := sortedAdapters()
+	names := make([]string, 0, len(sorted))
+	for _, adapter := range sorted {
+		names = append(names, adapter.name)
+	}
+	return names
+}
+
+// NewAdapter returns a new, uninitialized instance of the adapter registered
+// under the passed name.  ErrUnknownAdapter will be returned if no adapter
+// with the name has been registered.
+func NewAdapter(name string) (NPUAdapter, error) {
+	adaptersMtx.Lock()
+	adapter, exists := adapters[name]
+	adaptersMtx.Unlock()
+
+	if !exists {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAdapter, name)
+	}
+	return adapter.factory(), nil
+}
+
+// DetectAdapter returns the registered adapter with the highest priority that
+// is available, initializes and passes VerifyAdapter for the passed model.  It
+// returns nil when no adapter qualifies, in which case the model must be run
+// with Execute.
+func DetectAdapter(model *Model) NPUAdapter {
+	for _, registered := range sortedAdapters() {
+		adapter := registered.factory()
+		if !adapter.IsAvailable() {
+			continue
+		}
+		if err := adapter.Initialize(""); err != nil {
+			continue
+		}
+		if err := VerifyAdapter(adapter, model); err != nil {
+			_ = adapter.Shutdown()
+			continue
+		}
+		return adapter
+	}
+	return nil
+}
+
+// ConformanceInputs returns the inputs VerifyAdapter runs the passed model on:
+// tensors of all zeros and all 255s, a ramp, and tensors derived from SHA-256
+// like the tensors of the MobileX hash.
+func ConformanceInputs(model *Model) [][]uint8 {
+	size := model.InputSize()
+	zeros := make([]uint8, size)
+	ones := bytes.Repeat([]uint8{0xff}, size)
+	ramp := make([]uint8, size)
+	for i := range ramp {
+		ramp[i] = uint8(i)
+	}
+	inputs := [][]uint8{zeros, ones, ramp}
+
+	const hashedInputs = 4
+	var counter [8]byte
+	for n := uint32(0); n < hashedInputs; n++ {
+		input := make([]uint8, 0, size+sha256.Size)
+		for i := uint32(0); len(input) < size; i++ {
+			binary.LittleEndian.PutUint32(counter[:4], n)
+			binary.LittleEndian.PutUint32(counter[4:], i)
+			sum := sha256.Sum256(counter[:])
+			input = append(input, sum[:]...)
+		}
+		inputs = append(inputs, input[:size])
+	}
+	return inputs
+}
+
+// VerifyAdapter runs the passed model on the adapter for each of the
+// conformance inputs and returns ErrNPUOutputMismatch if any output differs
+// from Execute.  An adapter that returns ErrNPUModelUnsupported never runs the
+// model, so it passes since its callers fall back to Execute.
+func VerifyAdapter(adapter NPUAdapter, model *Model) error {
+	for i, input := range ConformanceInputs(model) {
+		want, err := Execute(model, input)
+		if err != nil {
+			return err
+		}
+
+		got, err := adapter.RunModel(model, input)
+		if errors.Is(err, ErrNPUModelUnsupported) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(got, want) {
+			info := adapter.GetHardwareInfo()
+			return fmt.Errorf("%w: %s %s on conformance input %d",
+				ErrNPUOutputMismatch, info.Vendor, info.Model, i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package npu
+
+import (
+	"errors"
+	"testing"
+)
+
+// testModel is a small quantized model exercising every operator.
+var testModel = &Model{
+	Name:     "test",
+	Height:   4,
+	Width:    4,
+	Channels: 2,
+	Nodes: []Node{
+		{Op: OpDepthwiseConv3x3, Weights: []int32{
+			1, 2, 1, 2, 4, 2, 1, 2, 1,
+			0, -1, 0, -1, 5, -1, 0, -1, 0,
+		}},
+		{Op: OpPointwiseConv, Weights: []int32{2, -1, 1, 3},
+			Bias: []int32{8, -8}},
+		{Op: OpReLU},
+		{Op: OpRequantize, Shift: 4},
+	},
+}
+
+// fakeAdapter is an NPU adapter whose availability and model output are
+// controlled by the test.
+type fakeAdapter struct {
+	ReferenceAdapter
+
+	available   bool
+	unsupported bool
+	corrupt     bool
+}
+
+func (a *fakeAdapter) IsAvailable() bool {
+	return a.available
+}
+
+func (a *fakeAdapter) Initialize(modelPath string) error {
+	return nil
+}
+
+func (a *fakeAdapter) RunModel(model *Model, input []uint8) ([]uint8, error) {
+	if a.unsupported {
+		return nil, ErrNPUModelUnsupported
+	}
+	output, err := Execute(model, input)
+	if err == nil && a.corrupt {
+		// Emulate an NPU that rounds when requantizing.
+		output[len(output)/2]++
+	}
+	return output, err
+}
+
+// TestVerifyAdapter ensures adapters whose output differs from the reference
+// executor are rejected.
+func TestVerifyAdapter(t *testing.T) {
+	tests := []struct {
+		name    string
+		adapter NPUAdapter
+		err     error
+	}{
+		{"reference", NewReferenceAdapter(testModel), nil},
+		{"unsupported model", &fakeAdapter{unsupported: true}, nil},
+		{"corrupt output", &fakeAdapter{corrupt: true},
+			ErrNPUOutputMismatch},
+	}
+
+	for _, test := range tests {
+		err := VerifyAdapter(test.adapter, testModel)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: got error %v, want %v", test.name, err,
+				test.err)
+		}
+	}
+}
+
+// TestDetectAdapter ensures DetectAdapter chooses the available adapter with
+// the highest priority that matches the reference executor.
+func TestDetectAdapter(t *testing.T) {
+	// No adapters are registered on the platforms the tests run on, so
+	// nothing is detected yet.
+	if adapter := DetectAdapter(testModel); adapter != nil {
+		t.Fatalf("detected adapter %v with no adapters registered",
+			adapter.GetHardwareInfo())
+	}
+
+	good := &fakeAdapter{available: true}
+	adapters := []struct {
+		name     string
+		priority int
+		adapter  NPUAdapter
+	}{
+		{"test-unavailable", 30, &fakeAdapter{}},
+		{"test-corrupt", 20, &fakeAdapter{available: true, corrupt: true}},
+		{"test-good", 10, good},
+		{"test-fallback", 0, NewReferenceAdapter(testModel)},
+	}
+	for _, a := range adapters {
+		adapter := a.adapter
+		factory := func() NPUAdapter { return adapter }
+		if err := RegisterAdapter(a.name, a.priority, factory); err != nil {
+			t.Fatalf("RegisterAdapter(%s): unexpected error: %v",
+				a.name, err)
+		}
+	}
+
+	err := RegisterAdapter("test-good", 0, func() NPUAdapter { return good })
+	if !errors.Is(err, ErrAdapterRegistered) {
+		t.Fatalf("RegisterAdapter: got error %v, want %v", err,
+			ErrAdapterRegistered)
+	}
+
+	names := SupportedAdapters()
+	if len(names) != len(adapters) {
+		t.Fatalf("SupportedAdapters: got %v, want %d adapters", names,
+			len(adapters))
+	}
+	for i, a := range adapters {
+		if names[i] != a.name {
+			t.Errorf("SupportedAdapters: adapter %d is %s, want %s",
+				i, names[i], a.name)
+		}
+	}
+
+	if adapter := DetectAdapter(testModel); adapter != good {
+		t.Errorf("DetectAdapter: got %v, want the test-good adapter",
+			adapter)
+	}
+
+	if _, err := NewAdapter("test-missing"); !errors.Is(err, ErrUnknownAdapter) {
+		t.Errorf("NewAdapter: got error %v, want %v", err,
+			ErrUnknownAdapter)
+	}
+	if adapter, err := NewAdapter("test-good"); err != nil || adapter != good {
+		t.Errorf("NewAdapter: got %v, %v, want the test-good adapter",
+			adapter, err)
+	}
+}
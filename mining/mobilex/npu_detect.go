@@ -9,56 +9,18 @@ import (
 	"strings"
 
 	"github.com/toole-brendan/shell/mining/mobilex/npu"
-	"github.com/toole-brendan/shell/mining/mobilex/npu/adapters"
+	"github.com/toole-brendan/shell/mining/mobilex/npu/fallback"
+
+	// Register the NPU adapters of the platform.
+	_ "github.com/toole-brendan/shell/mining/mobilex/npu/adapters"
 )
 
-// DetectNPUAdapter detects and returns the appropriate NPU adapter for the current platform.
-// This is exported for use in demos and testing.
+// DetectNPUAdapter returns the registered NPU adapter with the highest
+// priority that is available on this device and produces output identical to
+// the reference executor for the MobileX model.  It returns nil when there is
+// no such adapter.  This is exported for use in demos and testing.
 func DetectNPUAdapter() npu.NPUAdapter {
-	// Detect platform and return appropriate adapter
-	switch runtime.GOOS {
-	case "android":
-		return detectAndroidNPU()
-	case "darwin":
-		return detectAppleNPU()
-	case "ios":
-		return detectAppleNPU()
-	default:
-		// No NPU support on other platforms
-		return nil
-	}
-}
-
-// detectAndroidNPU detects and initializes Android NPU support.
-func detectAndroidNPU() npu.NPUAdapter {
-	adapter := adapters.NewAndroidNNAPIAdapter()
-
-	// Check if the adapter is actually available
-	if adapter.IsAvailable() {
-		// Try to initialize with default model
-		if err := adapter.Initialize(""); err == nil {
-			return adapter
-		}
-	}
-
-	// NPU not available or initialization failed
-	return nil
-}
-
-// detectAppleNPU detects and initializes Apple Neural Engine support.
-func detectAppleNPU() npu.NPUAdapter {
-	adapter := adapters.NewIOSCoreMLAdapter()
-
-	// Check if Core ML is available
-	if adapter.IsAvailable() {
-		// Try to initialize with default model
-		if err := adapter.Initialize(""); err == nil {
-			return adapter
-		}
-	}
-
-	// Neural Engine not available
-	return nil
+	return npu.DetectAdapter(fallback.MobileXModel())
 }
 
 // GetNPUInfo returns information about available NPU hardware.
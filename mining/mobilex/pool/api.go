@@ -0,0 +1,403 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultBlocksLimit and maxBlocksLimit bound the blocks listed by
+	// the blocks endpoint.
+	defaultBlocksLimit = 50
+	maxBlocksLimit     = 500
+
+	// hashRateHistoryPeriod is the period covered by the pool hash rate
+	// history.
+	hashRateHistoryPeriod = 24 * time.Hour
+)
+
+// PoolStatsResult models the response of GET /api/v1/pool.
+type PoolStatsResult struct {
+	HashRate           float64             `json:"hashrate"`
+	Workers            int                 `json:"workers"` // Online workers
+	Miners             int                 `json:"miners"`  // Accounts with online workers
+	Height             int32               `json:"height"`
+	NetworkDifficulty  float64             `json:"network_difficulty"`
+	PayoutScheme       string              `json:"payout_scheme"`
+	PoolFeePercent     float64             `json:"pool_fee_percent"`
+	PayoutThreshold    float64             `json:"payout_threshold"`
+	AverageTemperature float64             `json:"average_temperature"`
+	ThermalEfficiency  float64             `json:"thermal_efficiency"` // Percent of samples in the optimal range
+	HashRateHistory    []PoolHashRatePoint `json:"hashrate_history"`
+}
+
+// PoolHashRatePoint is a pool hash rate sample.
+type PoolHashRatePoint struct {
+	Time     int64   `json:"time"`
+	HashRate float64 `json:"hashrate"`
+}
+
+// WorkerDetailResult models the response of GET /api/v1/workers/{worker}.
+type WorkerDetailResult struct {
+	WorkerStats
+	ThermalCompliance float64         `json:"thermal_compliance"` // Percent of compliant reports
+	ThermalHistory    []ThermalSample `json:"thermal_history"`
+}
+
+// DeviceBreakdownResult models an entry of GET /api/v1/devices.
+type DeviceBreakdownResult struct {
+	DeviceType string  `json:"device_type"`
+	SocModel   string  `json:"soc_model"`
+	Workers    int     `json:"workers"`
+	NPUWorkers int     `json:"npu_workers"`
+	HashRate   float64 `json:"hashrate"`
+}
+
+// BlockResult models an entry of GET /api/v1/blocks.
+type BlockResult struct {
+	Height int32       `json:"height"`
+	Hash   string      `json:"hash"`
+	Reward int64       `json:"reward"`
+	Status BlockStatus `json:"status"`
+}
+
+// BalanceResult models the response of GET /api/v1/balances/{account}.
+// Amounts are in satoshis.
+type BalanceResult struct {
+	Account  string `json:"account"`
+	Immature int64  `json:"immature"`
+	Mature   int64  `json:"mature"`
+	Pending  int64  `json:"pending"` // Immature and mature credits not yet paid
+	Paid     int64  `json:"paid"`
+}
+
+// ErrorResult models the response of a failed request.
+type ErrorResult struct {
+	Error string `json:"error"`
+}
+
+// apiSchemas holds the JSON schemas of the API responses, served by the
+// schemas endpoint.
+//
+//go:embed schema/*.schema.json
+var apiSchemas embed.FS
+
+// errLedgerDisabled is returned by ledger backed endpoints when the pool
+// runs without a database.
+var errLedgerDisabled = errors.New("share accounting is disabled")
+
+// APIServer serves pool and worker statistics over HTTP for mobile apps and
+// dashboards.
+type APIServer struct {
+	stratum    *StratumServer
+	httpServer *http.Server
+}
+
+// NewAPIServer creates an API server for the stratum server's pool.
+func NewAPIServer(s *StratumServer) *APIServer {
+	api := &APIServer{stratum: s}
+	api.httpServer = &http.Server{
+		Addr:              s.cfg.HTTPEndpoint,
+		Handler:           api.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return api
+}
+
+// Handler returns the API's HTTP handler.
+func (api *APIServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/pool", api.handlePool)
+	mux.HandleFunc("GET /api/v1/workers", api.handleWorkers)
+	mux.HandleFunc("GET /api/v1/workers/{worker}", api.handleWorker)
+	mux.HandleFunc("GET /api/v1/devices", api.handleDevices)
+	mux.HandleFunc("GET /api/v1/blocks", api.handleBlocks)
+	mux.HandleFunc("GET /api/v1/balances", api.handleBalances)
+	mux.HandleFunc("GET /api/v1/balances/{account}", api.handleBalance)
+	mux.HandleFunc("GET /api/v1/schemas/{name}", api.handleSchema)
+
+	return mux
+}
+
+// Start begins serving the API on the configured HTTP endpoint.
+func (api *APIServer) Start() error {
+	listener, err := net.Listen("tcp", api.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+
+	go api.httpServer.Serve(listener)
+
+	return nil
+}
+
+// Stop shuts the API server down.
+func (api *APIServer) Stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return api.httpServer.Shutdown(ctx)
+}
+
+// handlePool serves pool wide statistics.
+func (api *APIServer) handlePool(w http.ResponseWriter, r *http.Request) {
+	s := api.stratum
+	result := PoolStatsResult{
+		PayoutScheme:      s.cfg.PayoutScheme,
+		PoolFeePercent:    s.cfg.PoolFeePercent,
+		PayoutThreshold:   s.cfg.PayoutThreshold,
+		ThermalEfficiency: s.metrics.GetThermalEfficiency(),
+		HashRateHistory:   []PoolHashRatePoint{},
+	}
+	if job := s.jobManager.GetCurrentJob(); job != nil {
+		result.Height = job.Height
+		result.NetworkDifficulty = targetDifficulty(job.Target)
+	}
+
+	var (
+		accounts  = make(map[string]struct{})
+		reporting int
+	)
+	for _, stats := range s.stats.workerStats() {
+		if !stats.Online {
+			continue
+		}
+		result.Workers++
+		result.HashRate += stats.HashRate
+		accounts[stats.Account] = struct{}{}
+		if stats.Temperature > 0 {
+			result.AverageTemperature += stats.Temperature
+			reporting++
+		}
+	}
+	result.Miners = len(accounts)
+	if reporting > 0 {
+		result.AverageTemperature /= float64(reporting)
+	}
+
+	for _, point := range s.metrics.GetHashRateHistory(hashRateHistoryPeriod) {
+		result.HashRateHistory = append(result.HashRateHistory,
+			PoolHashRatePoint{
+				Time:     point.Timestamp.Unix(),
+				HashRate: point.HashRate,
+			})
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleWorkers lists all workers seen by the pool. The account query
+// parameter restricts the list to one account.
+func (api *APIServer) handleWorkers(w http.ResponseWriter, r *http.Request) {
+	account := r.URL.Query().Get("account")
+
+	workers := []WorkerStats{}
+	for _, stats := range api.stratum.stats.workerStats() {
+		if account == "" || stats.Account == account {
+			workers = append(workers, stats)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, workers)
+}
+
+// handleWorker serves a worker's statistics and thermal history.
+func (api *APIServer) handleWorker(w http.ResponseWriter, r *http.Request) {
+	stats, thermal, ok := api.stratum.stats.workerDetail(r.PathValue("worker"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("unknown worker"))
+		return
+	}
+
+	result := WorkerDetailResult{
+		WorkerStats:    stats,
+		ThermalHistory: thermal,
+	}
+	if len(thermal) > 0 {
+		var compliant int
+		for _, sample := range thermal {
+			if sample.Compliant {
+				compliant++
+			}
+		}
+		result.ThermalCompliance = float64(compliant) /
+			float64(len(thermal)) * 100
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleDevices serves online workers grouped by device.
+func (api *APIServer) handleDevices(w http.ResponseWriter, r *http.Request) {
+	type deviceKey struct{ deviceType, socModel string }
+	devices := make(map[deviceKey]*DeviceBreakdownResult)
+
+	for _, stats := range api.stratum.stats.workerStats() {
+		if !stats.Online {
+			continue
+		}
+
+		key := deviceKey{stats.DeviceType, stats.SocModel}
+		device, ok := devices[key]
+		if !ok {
+			device = &DeviceBreakdownResult{
+				DeviceType: stats.DeviceType,
+				SocModel:   stats.SocModel,
+			}
+			devices[key] = device
+		}
+		device.Workers++
+		device.HashRate += stats.HashRate
+		if stats.NPUCapable {
+			device.NPUWorkers++
+		}
+	}
+
+	result := make([]DeviceBreakdownResult, 0, len(devices))
+	for _, device := range devices {
+		result = append(result, *device)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Workers != result[j].Workers {
+			return result[i].Workers > result[j].Workers
+		}
+		if result[i].DeviceType != result[j].DeviceType {
+			return result[i].DeviceType < result[j].DeviceType
+		}
+		return result[i].SocModel < result[j].SocModel
+	})
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleBlocks serves the blocks most recently found by the pool.
+func (api *APIServer) handleBlocks(w http.ResponseWriter, r *http.Request) {
+	ledger := api.stratum.ledger
+	if ledger == nil {
+		writeError(w, http.StatusServiceUnavailable, errLedgerDisabled)
+		return
+	}
+
+	limit := defaultBlocksLimit
+	if param := r.URL.Query().Get("limit"); param != "" {
+		n, err := strconv.Atoi(param)
+		if err != nil || n <= 0 || n > maxBlocksLimit {
+			writeError(w, http.StatusBadRequest,
+				errors.New("limit must be between 1 and 500"))
+			return
+		}
+		limit = n
+	}
+
+	blocks, err := ledger.RecentBlocks(limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	result := make([]BlockResult, 0, len(blocks))
+	for _, block := range blocks {
+		result = append(result, BlockResult{
+			Height: block.Height,
+			Hash:   block.Hash.String(),
+			Reward: block.Reward,
+			Status: block.Status,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleBalances serves every account with an unpaid balance.
+func (api *APIServer) handleBalances(w http.ResponseWriter, r *http.Request) {
+	ledger := api.stratum.ledger
+	if ledger == nil {
+		writeError(w, http.StatusServiceUnavailable, errLedgerDisabled)
+		return
+	}
+
+	balances, err := ledger.Balances()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	result := make([]BalanceResult, 0, len(balances))
+	for account, balance := range balances {
+		if balance.Immature+balance.Mature == 0 {
+			continue
+		}
+		result = append(result, balanceResult(account, balance))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Account < result[j].Account
+	})
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleBalance serves the balance of an account.
+func (api *APIServer) handleBalance(w http.ResponseWriter, r *http.Request) {
+	ledger := api.stratum.ledger
+	if ledger == nil {
+		writeError(w, http.StatusServiceUnavailable, errLedgerDisabled)
+		return
+	}
+
+	account := r.PathValue("account")
+	balance, err := ledger.Balance(account)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, balanceResult(account, balance))
+}
+
+// handleSchema serves the JSON schema of an API response, such as
+// pool.schema.json for GET /api/v1/pool.
+func (api *APIServer) handleSchema(w http.ResponseWriter, r *http.Request) {
+	schema, err := apiSchemas.ReadFile("schema/" + r.PathValue("name"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, errors.New("unknown schema"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(schema)
+}
+
+// balanceResult converts a ledger balance to its API result.
+func balanceResult(account string, balance *Balance) BalanceResult {
+	return BalanceResult{
+		Account:  account,
+		Immature: balance.Immature,
+		Mature:   balance.Mature,
+		Pending:  balance.Immature + balance.Mature,
+		Paid:     balance.Paid,
+	}
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes err as a JSON error response.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ErrorResult{Error: err.Error()})
+}
@@ -0,0 +1,377 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/shell/chaincfg"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+)
+
+// getJSON fetches path from server, validates the response against the named
+// schema and decodes it into v.
+func getJSON(t *testing.T, server *httptest.Server, path, schema string, v interface{}) int {
+	t.Helper()
+
+	resp, err := http.Get(server.URL + path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	requireSchema(t, schema, body)
+	require.NoError(t, json.Unmarshal(body, v))
+
+	return resp.StatusCode
+}
+
+// requireSchema validates a JSON document against the named API schema.
+func requireSchema(t *testing.T, name string, data []byte) {
+	t.Helper()
+
+	var doc interface{}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	require.NoError(t, validateSchema(loadSchema(t, name), doc, "$"),
+		"%s: %s", name, data)
+}
+
+// loadSchema returns the named API schema.
+func loadSchema(t *testing.T, name string) map[string]interface{} {
+	t.Helper()
+
+	data, err := apiSchemas.ReadFile("schema/" + name)
+	require.NoError(t, err)
+
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	return schema
+}
+
+// validateSchema validates v against the subset of JSON Schema used by the
+// API schemas: type, enum, minimum, maximum, pattern, format date-time,
+// properties, required, additionalProperties, items and references to other
+// API schemas.
+func validateSchema(schema map[string]interface{}, v interface{}, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		data, err := apiSchemas.ReadFile("schema/" + ref)
+		if err != nil {
+			return err
+		}
+		var refSchema map[string]interface{}
+		if err := json.Unmarshal(data, &refSchema); err != nil {
+			return err
+		}
+		return validateSchema(refSchema, v, path)
+	}
+
+	if types, ok := schema["type"]; ok {
+		var allowed []interface{}
+		switch types := types.(type) {
+		case string:
+			allowed = []interface{}{types}
+		case []interface{}:
+			allowed = types
+		}
+		var match bool
+		for _, typ := range allowed {
+			if schemaType(typ.(string), v) {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return fmt.Errorf("%s: %v is not of type %v", path, v, types)
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		var match bool
+		for _, value := range enum {
+			if value == v {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return fmt.Errorf("%s: %v is not one of %v", path, v, enum)
+		}
+	}
+
+	switch v := v.(type) {
+	case float64:
+		if min, ok := schema["minimum"].(float64); ok && v < min {
+			return fmt.Errorf("%s: %v is less than %v", path, v, min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && v > max {
+			return fmt.Errorf("%s: %v is greater than %v", path, v, max)
+		}
+
+	case string:
+		if pattern, ok := schema["pattern"].(string); ok &&
+			!regexp.MustCompile(pattern).MatchString(v) {
+
+			return fmt.Errorf("%s: %q does not match %s", path, v, pattern)
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				return fmt.Errorf("%s: %v", path, err)
+			}
+		}
+
+	case []interface{}:
+		items, ok := schema["items"].(map[string]interface{})
+		if !ok {
+			break
+		}
+		for i, item := range v {
+			err := validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return err
+			}
+		}
+
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := v[name.(string)]; !ok {
+				return fmt.Errorf("%s: missing property %q", path, name)
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := properties[name].(map[string]interface{})
+			if !ok {
+				if schema["additionalProperties"] == false {
+					return fmt.Errorf("%s: unexpected property %q",
+						path, name)
+				}
+				continue
+			}
+			err := validateSchema(property, v[name], path+"."+name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// schemaType returns whether v, as decoded by encoding/json, is of the JSON
+// Schema type typ.
+func schemaType(typ string, v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return typ == "null"
+	case bool:
+		return typ == "boolean"
+	case float64:
+		return typ == "number" || typ == "integer" && v == math.Trunc(v)
+	case string:
+		return typ == "string"
+	case []interface{}:
+		return typ == "array"
+	case map[string]interface{}:
+		return typ == "object"
+	}
+	return false
+}
+
+// TestAPIServer tests the pool REST API
+func TestAPIServer(t *testing.T) {
+	cfg := DefaultPoolConfig()
+	cfg.DatabasePath = filepath.Join(t.TempDir(), "pool.db")
+	cfg.PPLNSWindow = 10
+
+	s, err := NewStratumServer(cfg, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	defer s.ledger.Close()
+
+	alice, bob := testAccount(t, 1), testAccount(t, 2)
+	job := s.jobManager.GetCurrentJob()
+
+	// Alice mines on a flagship phone with an NPU, bob on a tablet that
+	// runs hot and has since disconnected.
+	s.stats.setOnline(alice+".phone", true)
+	s.stats.deviceInfo(alice+".phone", "Android", "Snapdragon 8 Gen 3", true, 45)
+	s.stats.shareAccepted(alice+".phone", 2)
+	s.stats.shareAccepted(alice+".phone", 2)
+	s.stats.shareRejected(alice+".phone", false)
+	s.stats.thermalReport(alice+".phone", 40, 5, 1000, false)
+	s.stats.thermalReport(alice+".phone", 47, 6, 900, true)
+	require.NoError(t, s.payouts.ShareAccepted(alice+".phone", 2, true, job))
+
+	s.stats.setOnline(bob+".tablet", true)
+	s.stats.deviceInfo(bob+".tablet", "iOS", "A16", false, 0)
+	s.stats.shareAccepted(bob+".tablet", 1)
+	s.stats.shareRejected(bob+".tablet", true)
+	s.stats.setOnline(bob+".tablet", false)
+	require.NoError(t, s.payouts.ShareAccepted(bob+".tablet", 1, false, job))
+
+	found := chainhash.Hash{0x01}
+	require.NoError(t, s.payouts.BlockFound(1000, &found, 1e8))
+	s.stats.sample()
+
+	server := httptest.NewServer(NewAPIServer(s).Handler())
+	defer server.Close()
+
+	var pool PoolStatsResult
+	require.Equal(t, http.StatusOK, getJSON(t, server, "/api/v1/pool", "pool.schema.json", &pool))
+	require.Equal(t, 1, pool.Workers)
+	require.Equal(t, 1, pool.Miners)
+	require.Equal(t, PayoutPPLNS, pool.PayoutScheme)
+	require.InDelta(t, 4*hashesPerShare/hashRateWindow.Seconds(), pool.HashRate, 1)
+	require.Len(t, pool.HashRateHistory, 1)
+
+	var workers []WorkerStats
+	require.Equal(t, http.StatusOK, getJSON(t, server, "/api/v1/workers", "workers.schema.json", &workers))
+	require.Len(t, workers, 2)
+
+	workers = nil
+	getJSON(t, server, "/api/v1/workers?account="+bob, "workers.schema.json",
+		&workers)
+	require.Len(t, workers, 1)
+	require.Equal(t, uint64(1), workers[0].ThermalRejects)
+	require.False(t, workers[0].Online)
+
+	var worker WorkerDetailResult
+	require.Equal(t, http.StatusOK,
+		getJSON(t, server, "/api/v1/workers/"+alice+".phone",
+			"worker.schema.json", &worker))
+	require.Equal(t, uint64(2), worker.AcceptedShares)
+	require.Equal(t, uint64(1), worker.RejectedShares)
+	require.Equal(t, float64(47), worker.Temperature)
+	require.Len(t, worker.ThermalHistory, 2)
+	require.False(t, worker.ThermalHistory[1].Compliant)
+	require.Equal(t, float64(50), worker.ThermalCompliance)
+
+	var apiErr ErrorResult
+	require.Equal(t, http.StatusNotFound,
+		getJSON(t, server, "/api/v1/workers/nobody", "error.schema.json",
+			&apiErr))
+	require.NotEmpty(t, apiErr.Error)
+
+	var devices []DeviceBreakdownResult
+	getJSON(t, server, "/api/v1/devices", "devices.schema.json", &devices)
+	require.Equal(t, []DeviceBreakdownResult{{
+		DeviceType: "Android",
+		SocModel:   "Snapdragon 8 Gen 3",
+		Workers:    1,
+		NPUWorkers: 1,
+		HashRate:   pool.HashRate,
+	}}, devices)
+
+	var blocks []BlockResult
+	getJSON(t, server, "/api/v1/blocks", "blocks.schema.json", &blocks)
+	require.Equal(t, []BlockResult{{
+		Height: 1000,
+		Hash:   found.String(),
+		Reward: 1e8,
+		Status: BlockImmature,
+	}}, blocks)
+	require.Equal(t, http.StatusBadRequest,
+		getJSON(t, server, "/api/v1/blocks?limit=0", "error.schema.json",
+			&apiErr))
+
+	// Alice's difficulty 2 NPU share weighs 2.2 against bob's 1.
+	var balances []BalanceResult
+	getJSON(t, server, "/api/v1/balances", "balances.schema.json", &balances)
+	require.Len(t, balances, 2)
+
+	var balance BalanceResult
+	getJSON(t, server, "/api/v1/balances/"+alice, "balance.schema.json",
+		&balance)
+	require.Equal(t, int64(68062500), balance.Immature)
+	require.Equal(t, balance.Immature, balance.Pending)
+
+	// Every schema is served and references only served schemas.
+	schemas, err := fs.Glob(apiSchemas, "schema/*.schema.json")
+	require.NoError(t, err)
+	for _, name := range schemas {
+		name = strings.TrimPrefix(name, "schema/")
+		resp, err := http.Get(server.URL + "/api/v1/schemas/" + name)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode, name)
+		require.Equal(t, "application/schema+json",
+			resp.Header.Get("Content-Type"))
+		require.True(t, json.Valid(body), name)
+	}
+	require.Equal(t, http.StatusNotFound, getJSON(t, server,
+		"/api/v1/schemas/nothing.schema.json", "error.schema.json", &apiErr))
+}
+
+// TestAPISchemasRejectDrift tests that the API schemas reject responses that
+// drift from them.
+func TestAPISchemasRejectDrift(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		doc    string
+	}{{
+		name:   "missing property",
+		schema: "balance.schema.json",
+		doc:    `{"account":"a","immature":1,"mature":0,"paid":0}`,
+	}, {
+		name:   "undocumented property",
+		schema: "error.schema.json",
+		doc:    `{"error":"e","code":1}`,
+	}, {
+		name:   "wrong type",
+		schema: "blocks.schema.json",
+		doc: `[{"height":"1","hash":"` + strings.Repeat("0", 64) +
+			`","reward":1,"status":"immature"}]`,
+	}, {
+		name:   "unknown enum value",
+		schema: "blocks.schema.json",
+		doc: `[{"height":1,"hash":"` + strings.Repeat("0", 64) +
+			`","reward":1,"status":"lost"}]`,
+	}, {
+		name:   "fractional integer",
+		schema: "devices.schema.json",
+		doc: `[{"device_type":"iOS","soc_model":"A16","workers":1.5,` +
+			`"npu_workers":0,"hashrate":1}]`,
+	}, {
+		name:   "nested reference",
+		schema: "workers.schema.json",
+		doc: `[{"worker":"w","account":"a","online":true,` +
+			`"npu_capable":false,"difficulty":1,"hashrate":1,` +
+			`"reported_hashrate":1,"accepted_shares":1,` +
+			`"rejected_shares":0,"thermal_rejects":0,"temperature":40,` +
+			`"last_share":"2025-01-01T00:00:00Z",` +
+			`"reputation":{"score":101,"status":"trusted","proofs":0}}]`,
+	}}
+
+	for _, test := range tests {
+		var doc interface{}
+		require.NoError(t, json.Unmarshal([]byte(test.doc), &doc))
+		err := validateSchema(loadSchema(t, test.schema), doc, "$")
+		require.Error(t, err, test.name)
+	}
+}
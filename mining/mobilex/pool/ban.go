@@ -0,0 +1,86 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/toole-brendan/shell/connmgr"
+)
+
+// Ban scores of misbehaving miners. They are transient so honest miners
+// that occasionally send a bad share are never banned, and stale shares,
+// which race new jobs, score nothing.
+const (
+	invalidShareBanScore     = 10
+	duplicateShareBanScore   = 20
+	unauthorizedBanScore     = 10
+	malformedMessageBanScore = 20
+)
+
+// shareBanScore returns the ban score of a share rejected with err.
+func shareBanScore(err error) uint32 {
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		return 0
+	case errors.Is(err, ErrDuplicateShare):
+		return duplicateShareBanScore
+	default:
+		return invalidShareBanScore
+	}
+}
+
+// addBanScore increases the ban score of a connection. Once the score exceeds
+// the ban threshold the connection is closed and true is returned. Scores for
+// shares also ban the worker that submitted them, which is passed as worker,
+// while protocol misbehavior only costs the connection. Hosts are never
+// banned since many miners may share an address behind carrier-grade NAT. A
+// zero threshold disables banning.
+func (s *StratumServer) addBanScore(score *connmgr.DynamicBanScore, conn net.Conn, worker string, transient uint32) bool {
+	if s.cfg.BanThreshold == 0 || transient == 0 {
+		return false
+	}
+
+	if score.Increase(0, transient) <= s.cfg.BanThreshold {
+		return false
+	}
+
+	if worker != "" {
+		s.ban(worker, conn)
+		return true
+	}
+	conn.Close()
+
+	return true
+}
+
+// ban bans a worker for the configured ban duration and closes its
+// connection.
+func (s *StratumServer) ban(worker string, conn net.Conn) {
+	s.bansMu.Lock()
+	s.bans[worker] = time.Now().Add(s.cfg.BanDuration)
+	s.bansMu.Unlock()
+
+	conn.Close()
+}
+
+// isBanned returns whether a worker is banned.
+func (s *StratumServer) isBanned(worker string) bool {
+	s.bansMu.Lock()
+	defer s.bansMu.Unlock()
+
+	expiry, ok := s.bans[worker]
+	if !ok {
+		return false
+	}
+	if time.Now().After(expiry) {
+		delete(s.bans, worker)
+		return false
+	}
+
+	return true
+}
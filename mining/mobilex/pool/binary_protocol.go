@@ -0,0 +1,783 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+)
+
+// The binary protocol follows the Stratum V2 mining protocol: each encrypted
+// frame carries one message behind a six byte header holding the extension
+// type, message type and payload length. Integers are little endian,
+// strings and byte arrays are prefixed by a one byte length, and miners
+// work on standard channels that receive header-only jobs, so no coinbase
+// or merkle branch data crosses the mobile link. Device information and
+// thermal reports are carried by messages of the Shell mobile extension.
+const (
+	// BinaryProtocolVersion is the version of the binary protocol spoken
+	// by the pool.
+	BinaryProtocolVersion = 2
+
+	// MiningProtocol identifies the mining subprotocol in SetupConnection.
+	MiningProtocol = 0
+
+	// MobileExtensionType is the extension type of Shell mobile messages.
+	MobileExtensionType = 0x4d58
+
+	// binaryHeaderSize is the size of a message header.
+	binaryHeaderSize = 6
+
+	// maxBinaryPayloadSize is the largest message payload that fits in a
+	// frame.
+	maxBinaryPayloadSize = MaxNoisePayloadSize - binaryHeaderSize
+)
+
+// Message types of the mining subprotocol.
+const (
+	MsgTypeSetupConnection        = 0x00
+	MsgTypeSetupConnectionSuccess = 0x01
+	MsgTypeSetupConnectionError   = 0x02
+	MsgTypeOpenChannel            = 0x10
+	MsgTypeOpenChannelSuccess     = 0x11
+	MsgTypeOpenChannelError       = 0x12
+	MsgTypeNewMiningJob           = 0x15
+	MsgTypeSubmitShares           = 0x1a
+	MsgTypeSubmitSharesSuccess    = 0x1c
+	MsgTypeSubmitSharesError      = 0x1d
+	MsgTypeSetNewPrevHash         = 0x20
+	MsgTypeSetTarget              = 0x21
+)
+
+// Message types of the Shell mobile extension.
+const (
+	MsgTypeReportThermal        = 0x01
+	MsgTypeReportThermalSuccess = 0x02
+	MsgTypeBlockFound           = 0x03
+)
+
+// SetupConnection flags.
+const (
+	// SetupFlagNPU is set by devices that mine with an NPU.
+	SetupFlagNPU = 1 << 0
+
+	// supportedSetupFlags are the flags understood by the pool.
+	supportedSetupFlags = SetupFlagNPU
+)
+
+// Error codes sent in error messages.
+const (
+	ErrCodeUnsupportedProtocol = "unsupported-protocol"
+	ErrCodeVersionMismatch     = "protocol-version-mismatch"
+	ErrCodeUnsupportedFlags    = "unsupported-feature-flags"
+	ErrCodeUnknownUser         = "unknown-user"
+	ErrCodeBannedUser          = "banned-user"
+	ErrCodeInvalidChannelID    = "invalid-channel-id"
+	ErrCodeInvalidJobID        = "invalid-job-id"
+	ErrCodeInvalidThermalProof = "invalid-thermal-proof"
+	ErrCodeDuplicateShare      = "duplicate-share"
+	ErrCodeDifficultyTooLow    = "difficulty-too-low"
+	ErrCodeInvalidShare        = "invalid-share"
+)
+
+// ErrMalformedMessage is returned for messages that cannot be decoded.
+var ErrMalformedMessage = errors.New("malformed binary message")
+
+// BinaryMessage is a message of the binary protocol.
+type BinaryMessage interface {
+	// ExtensionType returns the extension the message belongs to, zero
+	// for the mining subprotocol.
+	ExtensionType() uint16
+
+	// MsgType returns the message type within its extension.
+	MsgType() uint8
+
+	encode(w *binaryWriter)
+	decode(r *binaryReader)
+}
+
+// SetupConnection opens a connection and describes the mining device.
+type SetupConnection struct {
+	Protocol     uint8
+	MinVersion   uint16
+	MaxVersion   uint16
+	Flags        uint32
+	DeviceType   string  // iOS, Android
+	SocModel     string  // Snapdragon 8 Gen 3, A17 Pro, etc.
+	Firmware     string  // Miner software and version
+	DeviceID     string  // Optional device identifier
+	ThermalLimit float32 // Max temperature in °C
+}
+
+// SetupConnectionSuccess accepts a connection.
+type SetupConnectionSuccess struct {
+	UsedVersion uint16
+	Flags       uint32
+}
+
+// SetupConnectionError rejects a connection.
+type SetupConnectionError struct {
+	Flags     uint32
+	ErrorCode string
+}
+
+// OpenChannel opens a standard channel for a worker.
+type OpenChannel struct {
+	RequestID       uint32
+	User            string // Worker name, account.worker
+	NominalHashRate float32
+	MaxTarget       chainhash.Hash // Little endian 256 bit target
+}
+
+// OpenChannelSuccess assigns a channel to a worker.
+type OpenChannelSuccess struct {
+	RequestID        uint32
+	ChannelID        uint32
+	Target           chainhash.Hash
+	ExtranoncePrefix []byte
+}
+
+// OpenChannelError rejects a channel.
+type OpenChannelError struct {
+	RequestID uint32
+	ErrorCode string
+}
+
+// NewMiningJob is a header-only job. A job on the channel's current chain
+// tip is active immediately, otherwise it becomes active once the
+// SetNewPrevHash with the same job ID is received.
+type NewMiningJob struct {
+	ChannelID     uint32
+	JobID         uint32
+	Version       uint32
+	MerkleRoot    chainhash.Hash
+	Height        uint32
+	ThermalTarget float32
+}
+
+// SetNewPrevHash moves a channel to a new chain tip, activating the job
+// with its job ID and discarding older jobs.
+type SetNewPrevHash struct {
+	ChannelID uint32
+	JobID     uint32
+	PrevHash  chainhash.Hash
+	MinNTime  uint32
+	NBits     uint32
+}
+
+// SetTarget changes a channel's share target.
+type SetTarget struct {
+	ChannelID uint32
+	MaxTarget chainhash.Hash
+}
+
+// SubmitShares submits a share found on a standard channel.
+type SubmitShares struct {
+	ChannelID      uint32
+	SequenceNumber uint32
+	JobID          uint32
+	Nonce          uint32
+	NTime          uint32
+	Version        uint32
+	ThermalProof   uint64
+}
+
+// SubmitSharesSuccess acknowledges accepted shares.
+type SubmitSharesSuccess struct {
+	ChannelID               uint32
+	LastSequenceNumber      uint32
+	NewSubmitsAcceptedCount uint32
+	NewSharesSum            uint64
+}
+
+// SubmitSharesError rejects a share.
+type SubmitSharesError struct {
+	ChannelID      uint32
+	SequenceNumber uint32
+	ErrorCode      string
+}
+
+// ReportThermal reports a device's thermal state.
+type ReportThermal struct {
+	ChannelID   uint32
+	Temperature float32 // °C
+	PowerUsage  float32 // Watts
+	HashRate    float32 // Hashes per second
+	Throttled   bool
+}
+
+// ReportThermalSuccess acknowledges a thermal report.
+type ReportThermalSuccess struct {
+	ChannelID uint32
+}
+
+// BlockFound tells the miner whether a block it found was accepted.
+type BlockFound struct {
+	ChannelID uint32
+	Height    uint32
+	BlockHash chainhash.Hash
+	Accepted  bool
+	Reason    string
+}
+
+// ExtensionType returns the mining subprotocol extension type.
+func (*SetupConnection) ExtensionType() uint16 { return 0 }
+
+// MsgType returns MsgTypeSetupConnection.
+func (*SetupConnection) MsgType() uint8 { return MsgTypeSetupConnection }
+
+func (m *SetupConnection) encode(w *binaryWriter) {
+	w.u8(m.Protocol)
+	w.u16(m.MinVersion)
+	w.u16(m.MaxVersion)
+	w.u32(m.Flags)
+	w.str(m.DeviceType)
+	w.str(m.SocModel)
+	w.str(m.Firmware)
+	w.str(m.DeviceID)
+	w.f32(m.ThermalLimit)
+}
+
+func (m *SetupConnection) decode(r *binaryReader) {
+	m.Protocol = r.u8()
+	m.MinVersion = r.u16()
+	m.MaxVersion = r.u16()
+	m.Flags = r.u32()
+	m.DeviceType = r.str()
+	m.SocModel = r.str()
+	m.Firmware = r.str()
+	m.DeviceID = r.str()
+	m.ThermalLimit = r.f32()
+}
+
+// ExtensionType returns the mining subprotocol extension type.
+func (*SetupConnectionSuccess) ExtensionType() uint16 { return 0 }
+
+// MsgType returns MsgTypeSetupConnectionSuccess.
+func (*SetupConnectionSuccess) MsgType() uint8 { return MsgTypeSetupConnectionSuccess }
+
+func (m *SetupConnectionSuccess) encode(w *binaryWriter) {
+	w.u16(m.UsedVersion)
+	w.u32(m.Flags)
+}
+
+func (m *SetupConnectionSuccess) decode(r *binaryReader) {
+	m.UsedVersion = r.u16()
+	m.Flags = r.u32()
+}
+
+// ExtensionType returns the mining subprotocol extension type.
+func (*SetupConnectionError) ExtensionType() uint16 { return 0 }
+
+// MsgType returns MsgTypeSetupConnectionError.
+func (*SetupConnectionError) MsgType() uint8 { return MsgTypeSetupConnectionError }
+
+func (m *SetupConnectionError) encode(w *binaryWriter) {
+	w.u32(m.Flags)
+	w.str(m.ErrorCode)
+}
+
+func (m *SetupConnectionError) decode(r *binaryReader) {
+	m.Flags = r.u32()
+	m.ErrorCode = r.str()
+}
+
+// ExtensionType returns the mining subprotocol extension type.
+func (*OpenChannel) ExtensionType() uint16 { return 0 }
+
+// MsgType returns MsgTypeOpenChannel.
+func (*OpenChannel) MsgType() uint8 { return MsgTypeOpenChannel }
+
+func (m *OpenChannel) encode(w *binaryWriter) {
+	w.u32(m.RequestID)
+	w.str(m.User)
+	w.f32(m.NominalHashRate)
+	w.hash(&m.MaxTarget)
+}
+
+func (m *OpenChannel) decode(r *binaryReader) {
+	m.RequestID = r.u32()
+	m.User = r.str()
+	m.NominalHashRate = r.f32()
+	r.hash(&m.MaxTarget)
+}
+
+// ExtensionType returns the mining subprotocol extension type.
+func (*OpenChannelSuccess) ExtensionType() uint16 { return 0 }
+
+// MsgType returns MsgTypeOpenChannelSuccess.
+func (*OpenChannelSuccess) MsgType() uint8 { return MsgTypeOpenChannelSuccess }
+
+func (m *OpenChannelSuccess) encode(w *binaryWriter) {
+	w.u32(m.RequestID)
+	w.u32(m.ChannelID)
+	w.hash(&m.Target)
+	w.bytes(m.ExtranoncePrefix)
+}
+
+func (m *OpenChannelSuccess) decode(r *binaryReader) {
+	m.RequestID = r.u32()
+	m.ChannelID = r.u32()
+	r.hash(&m.Target)
+	m.ExtranoncePrefix = r.bytes()
+}
+
+// ExtensionType returns the mining subprotocol extension type.
+func (*OpenChannelError) ExtensionType() uint16 { return 0 }
+
+// MsgType returns MsgTypeOpenChannelError.
+func (*OpenChannelError) MsgType() uint8 { return MsgTypeOpenChannelError }
+
+func (m *OpenChannelError) encode(w *binaryWriter) {
+	w.u32(m.RequestID)
+	w.str(m.ErrorCode)
+}
+
+func (m *OpenChannelError) decode(r *binaryReader) {
+	m.RequestID = r.u32()
+	m.ErrorCode = r.str()
+}
+
+// ExtensionType returns the mining subprotocol extension type.
+func (*NewMiningJob) ExtensionType() uint16 { return 0 }
+
+// MsgType returns MsgTypeNewMiningJob.
+func (*NewMiningJob) MsgType() uint8 { return MsgTypeNewMiningJob }
+
+func (m *NewMiningJob) encode(w *binaryWriter) {
+	w.u32(m.ChannelID)
+	w.u32(m.JobID)
+	w.u32(m.Version)
+	w.hash(&m.MerkleRoot)
+	w.u32(m.Height)
+	w.f32(m.ThermalTarget)
+}
+
+func (m *NewMiningJob) decode(r *binaryReader) {
+	m.ChannelID = r.u32()
+	m.JobID = r.u32()
+	m.Version = r.u32()
+	r.hash(&m.MerkleRoot)
+	m.Height = r.u32()
+	m.ThermalTarget = r.f32()
+}
+
+// ExtensionType returns the mining subprotocol extension type.
+func (*SetNewPrevHash) ExtensionType() uint16 { return 0 }
+
+// MsgType returns MsgTypeSetNewPrevHash.
+func (*SetNewPrevHash) MsgType() uint8 { return MsgTypeSetNewPrevHash }
+
+func (m *SetNewPrevHash) encode(w *binaryWriter) {
+	w.u32(m.ChannelID)
+	w.u32(m.JobID)
+	w.hash(&m.PrevHash)
+	w.u32(m.MinNTime)
+	w.u32(m.NBits)
+}
+
+func (m *SetNewPrevHash) decode(r *binaryReader) {
+	m.ChannelID = r.u32()
+	m.JobID = r.u32()
+	r.hash(&m.PrevHash)
+	m.MinNTime = r.u32()
+	m.NBits = r.u32()
+}
+
+// ExtensionType returns the mining subprotocol extension type.
+func (*SetTarget) ExtensionType() uint16 { return 0 }
+
+// MsgType returns MsgTypeSetTarget.
+func (*SetTarget) MsgType() uint8 { return MsgTypeSetTarget }
+
+func (m *SetTarget) encode(w *binaryWriter) {
+	w.u32(m.ChannelID)
+	w.hash(&m.MaxTarget)
+}
+
+func (m *SetTarget) decode(r *binaryReader) {
+	m.ChannelID = r.u32()
+	r.hash(&m.MaxTarget)
+}
+
+// ExtensionType returns the mining subprotocol extension type.
+func (*SubmitShares) ExtensionType() uint16 { return 0 }
+
+// MsgType returns MsgTypeSubmitShares.
+func (*SubmitShares) MsgType() uint8 { return MsgTypeSubmitShares }
+
+func (m *SubmitShares) encode(w *binaryWriter) {
+	w.u32(m.ChannelID)
+	w.u32(m.SequenceNumber)
+	w.u32(m.JobID)
+	w.u32(m.Nonce)
+	w.u32(m.NTime)
+	w.u32(m.Version)
+	w.u64(m.ThermalProof)
+}
+
+func (m *SubmitShares) decode(r *binaryReader) {
+	m.ChannelID = r.u32()
+	m.SequenceNumber = r.u32()
+	m.JobID = r.u32()
+	m.Nonce = r.u32()
+	m.NTime = r.u32()
+	m.Version = r.u32()
+	m.ThermalProof = r.u64()
+}
+
+// ExtensionType returns the mining subprotocol extension type.
+func (*SubmitSharesSuccess) ExtensionType() uint16 { return 0 }
+
+// MsgType returns MsgTypeSubmitSharesSuccess.
+func (*SubmitSharesSuccess) MsgType() uint8 { return MsgTypeSubmitSharesSuccess }
+
+func (m *SubmitSharesSuccess) encode(w *binaryWriter) {
+	w.u32(m.ChannelID)
+	w.u32(m.LastSequenceNumber)
+	w.u32(m.NewSubmitsAcceptedCount)
+	w.u64(m.NewSharesSum)
+}
+
+func (m *SubmitSharesSuccess) decode(r *binaryReader) {
+	m.ChannelID = r.u32()
+	m.LastSequenceNumber = r.u32()
+	m.NewSubmitsAcceptedCount = r.u32()
+	m.NewSharesSum = r.u64()
+}
+
+// ExtensionType returns the mining subprotocol extension type.
+func (*SubmitSharesError) ExtensionType() uint16 { return 0 }
+
+// MsgType returns MsgTypeSubmitSharesError.
+func (*SubmitSharesError) MsgType() uint8 { return MsgTypeSubmitSharesError }
+
+func (m *SubmitSharesError) encode(w *binaryWriter) {
+	w.u32(m.ChannelID)
+	w.u32(m.SequenceNumber)
+	w.str(m.ErrorCode)
+}
+
+func (m *SubmitSharesError) decode(r *binaryReader) {
+	m.ChannelID = r.u32()
+	m.SequenceNumber = r.u32()
+	m.ErrorCode = r.str()
+}
+
+// ExtensionType returns MobileExtensionType.
+func (*ReportThermal) ExtensionType() uint16 { return MobileExtensionType }
+
+// MsgType returns MsgTypeReportThermal.
+func (*ReportThermal) MsgType() uint8 { return MsgTypeReportThermal }
+
+func (m *ReportThermal) encode(w *binaryWriter) {
+	w.u32(m.ChannelID)
+	w.f32(m.Temperature)
+	w.f32(m.PowerUsage)
+	w.f32(m.HashRate)
+	w.boolean(m.Throttled)
+}
+
+func (m *ReportThermal) decode(r *binaryReader) {
+	m.ChannelID = r.u32()
+	m.Temperature = r.f32()
+	m.PowerUsage = r.f32()
+	m.HashRate = r.f32()
+	m.Throttled = r.boolean()
+}
+
+// ExtensionType returns MobileExtensionType.
+func (*ReportThermalSuccess) ExtensionType() uint16 { return MobileExtensionType }
+
+// MsgType returns MsgTypeReportThermalSuccess.
+func (*ReportThermalSuccess) MsgType() uint8 { return MsgTypeReportThermalSuccess }
+
+func (m *ReportThermalSuccess) encode(w *binaryWriter) {
+	w.u32(m.ChannelID)
+}
+
+func (m *ReportThermalSuccess) decode(r *binaryReader) {
+	m.ChannelID = r.u32()
+}
+
+// ExtensionType returns MobileExtensionType.
+func (*BlockFound) ExtensionType() uint16 { return MobileExtensionType }
+
+// MsgType returns MsgTypeBlockFound.
+func (*BlockFound) MsgType() uint8 { return MsgTypeBlockFound }
+
+func (m *BlockFound) encode(w *binaryWriter) {
+	w.u32(m.ChannelID)
+	w.u32(m.Height)
+	w.hash(&m.BlockHash)
+	w.boolean(m.Accepted)
+	w.str(m.Reason)
+}
+
+func (m *BlockFound) decode(r *binaryReader) {
+	m.ChannelID = r.u32()
+	m.Height = r.u32()
+	r.hash(&m.BlockHash)
+	m.Accepted = r.boolean()
+	m.Reason = r.str()
+}
+
+// newBinaryMessage returns an empty message of the given type.
+func newBinaryMessage(extensionType uint16, msgType uint8) (BinaryMessage, error) {
+	switch extensionType {
+	case 0:
+		switch msgType {
+		case MsgTypeSetupConnection:
+			return &SetupConnection{}, nil
+		case MsgTypeSetupConnectionSuccess:
+			return &SetupConnectionSuccess{}, nil
+		case MsgTypeSetupConnectionError:
+			return &SetupConnectionError{}, nil
+		case MsgTypeOpenChannel:
+			return &OpenChannel{}, nil
+		case MsgTypeOpenChannelSuccess:
+			return &OpenChannelSuccess{}, nil
+		case MsgTypeOpenChannelError:
+			return &OpenChannelError{}, nil
+		case MsgTypeNewMiningJob:
+			return &NewMiningJob{}, nil
+		case MsgTypeSetNewPrevHash:
+			return &SetNewPrevHash{}, nil
+		case MsgTypeSetTarget:
+			return &SetTarget{}, nil
+		case MsgTypeSubmitShares:
+			return &SubmitShares{}, nil
+		case MsgTypeSubmitSharesSuccess:
+			return &SubmitSharesSuccess{}, nil
+		case MsgTypeSubmitSharesError:
+			return &SubmitSharesError{}, nil
+		}
+
+	case MobileExtensionType:
+		switch msgType {
+		case MsgTypeReportThermal:
+			return &ReportThermal{}, nil
+		case MsgTypeReportThermalSuccess:
+			return &ReportThermalSuccess{}, nil
+		case MsgTypeBlockFound:
+			return &BlockFound{}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: unknown message type %#x of extension %#x",
+		ErrMalformedMessage, msgType, extensionType)
+}
+
+// EncodeBinaryMessage serializes a message with its header.
+func EncodeBinaryMessage(msg BinaryMessage) ([]byte, error) {
+	w := &binaryWriter{}
+	w.buf.Write(make([]byte, binaryHeaderSize))
+	msg.encode(w)
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	frame := w.buf.Bytes()
+	length := len(frame) - binaryHeaderSize
+	if length > maxBinaryPayloadSize {
+		return nil, fmt.Errorf("message payload of %d bytes exceeds "+
+			"maximum of %d", length, maxBinaryPayloadSize)
+	}
+
+	binary.LittleEndian.PutUint16(frame[0:2], msg.ExtensionType())
+	frame[2] = msg.MsgType()
+	frame[3] = byte(length)
+	frame[4] = byte(length >> 8)
+	frame[5] = byte(length >> 16)
+
+	return frame, nil
+}
+
+// DecodeBinaryMessage deserializes a message with its header.
+func DecodeBinaryMessage(frame []byte) (BinaryMessage, error) {
+	if len(frame) < binaryHeaderSize {
+		return nil, fmt.Errorf("%w: short header", ErrMalformedMessage)
+	}
+
+	extensionType := binary.LittleEndian.Uint16(frame[0:2])
+	msgType := frame[2]
+	length := int(frame[3]) | int(frame[4])<<8 | int(frame[5])<<16
+	payload := frame[binaryHeaderSize:]
+	if length != len(payload) {
+		return nil, fmt.Errorf("%w: payload is %d bytes, header says %d",
+			ErrMalformedMessage, len(payload), length)
+	}
+
+	msg, err := newBinaryMessage(extensionType, msgType)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &binaryReader{buf: payload}
+	msg.decode(r)
+	if r.err == nil && len(r.buf) != 0 {
+		r.err = fmt.Errorf("%w: %d trailing bytes", ErrMalformedMessage,
+			len(r.buf))
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return msg, nil
+}
+
+// ReadBinaryMessage reads the next message from an encrypted connection.
+func ReadBinaryMessage(conn *NoiseConn) (BinaryMessage, error) {
+	frame, err := conn.ReadFrame()
+	if err != nil {
+		return nil, err
+	}
+	return DecodeBinaryMessage(frame)
+}
+
+// WriteBinaryMessage writes a message to an encrypted connection.
+func WriteBinaryMessage(conn *NoiseConn, msg BinaryMessage) error {
+	frame, err := EncodeBinaryMessage(msg)
+	if err != nil {
+		return err
+	}
+	return conn.WriteFrame(frame)
+}
+
+// binaryWriter serializes message fields, keeping the first error.
+type binaryWriter struct {
+	buf bytes.Buffer
+	err error
+}
+
+func (w *binaryWriter) u8(v uint8) {
+	w.buf.WriteByte(v)
+}
+
+func (w *binaryWriter) u16(v uint16) {
+	w.buf.Write(binary.LittleEndian.AppendUint16(nil, v))
+}
+
+func (w *binaryWriter) u32(v uint32) {
+	w.buf.Write(binary.LittleEndian.AppendUint32(nil, v))
+}
+
+func (w *binaryWriter) u64(v uint64) {
+	w.buf.Write(binary.LittleEndian.AppendUint64(nil, v))
+}
+
+func (w *binaryWriter) f32(v float32) {
+	w.u32(math.Float32bits(v))
+}
+
+func (w *binaryWriter) boolean(v bool) {
+	if v {
+		w.u8(1)
+	} else {
+		w.u8(0)
+	}
+}
+
+func (w *binaryWriter) hash(h *chainhash.Hash) {
+	w.buf.Write(h[:])
+}
+
+// bytes writes a byte array of up to 255 bytes.
+func (w *binaryWriter) bytes(b []byte) {
+	if len(b) > math.MaxUint8 {
+		if w.err == nil {
+			w.err = fmt.Errorf("field of %d bytes exceeds maximum of %d",
+				len(b), math.MaxUint8)
+		}
+		return
+	}
+	w.u8(uint8(len(b)))
+	w.buf.Write(b)
+}
+
+// str writes a string of up to 255 bytes.
+func (w *binaryWriter) str(s string) {
+	w.bytes([]byte(s))
+}
+
+// binaryReader deserializes message fields. Reads past the end of the
+// payload set err and return zero values.
+type binaryReader struct {
+	buf []byte
+	err error
+}
+
+// next consumes n bytes of the payload.
+func (r *binaryReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if len(r.buf) < n {
+		r.err = fmt.Errorf("%w: short payload", ErrMalformedMessage)
+		return nil
+	}
+
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *binaryReader) u8() uint8 {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *binaryReader) u16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *binaryReader) u32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *binaryReader) u64() uint64 {
+	if b := r.next(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *binaryReader) f32() float32 {
+	return math.Float32frombits(r.u32())
+}
+
+func (r *binaryReader) boolean() bool {
+	return r.u8() != 0
+}
+
+func (r *binaryReader) hash(h *chainhash.Hash) {
+	copy(h[:], r.next(chainhash.HashSize))
+}
+
+func (r *binaryReader) bytes() []byte {
+	n := r.u8()
+	b := r.next(int(n))
+	if b == nil {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
+
+func (r *binaryReader) str() string {
+	return string(r.bytes())
+}
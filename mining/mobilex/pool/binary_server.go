@@ -0,0 +1,630 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"context"
+	"crypto/ecdh"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/connmgr"
+	"github.com/toole-brendan/shell/mining/mobilex"
+)
+
+// headerOnlyExtranonce2 is the extranonce2 of the coinbase of header-only
+// jobs, which leave no extranonce for miners to roll.
+var headerOnlyExtranonce2 = make([]byte, extranonce2Size)
+
+// BinaryServer serves the encrypted binary protocol on its own port. It
+// shares jobs, share validation, statistics and payouts with the JSON
+// Stratum server, so miners on either protocol mine for the same pool.
+type BinaryServer struct {
+	stratum *StratumServer
+	key     *ecdh.PrivateKey
+
+	// Network
+	listener      net.Listener
+	conns         map[uint64]*binaryConn
+	connsMu       sync.Mutex
+	nextConnID    uint64
+	nextChannelID uint32
+
+	// Shutdown
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// binaryConn is a miner connected over the binary protocol.
+type binaryConn struct {
+	id    uint64
+	noise *NoiseConn
+
+	// Device described by SetupConnection
+	deviceType   string
+	socModel     string
+	thermalLimit float64
+	npuCapable   bool
+
+	// mu guards channels, which jobs announced by the job manager update
+	// from outside the connection's goroutine
+	mu       sync.Mutex
+	channels map[uint32]*binaryChannel
+
+	// Misbehavior
+	banScore connmgr.DynamicBanScore
+}
+
+// binaryChannel is a standard channel opened by a worker.
+type binaryChannel struct {
+	id         uint32
+	workerName string
+	difficulty float64
+	vardiff    *vardiff
+	extranonce []byte
+
+	// Jobs on the current chain tip by job ID
+	jobs     map[uint32]*MiningJob
+	prevHash string
+}
+
+// NewBinaryServer creates a binary protocol server for the stratum server's
+// pool, loading or creating the pool's static Noise key.
+func NewBinaryServer(s *StratumServer) (*BinaryServer, error) {
+	key, err := LoadOrCreateNoiseKey(s.cfg.NoiseKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load noise key: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &BinaryServer{
+		stratum: s,
+		key:     key,
+		conns:   make(map[uint64]*binaryConn),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	s.binary = b
+	s.addJobListener(b.broadcastJob)
+
+	return b, nil
+}
+
+// PublicKey returns the pool's static Noise public key, which miners use to
+// authenticate the pool.
+func (b *BinaryServer) PublicKey() []byte {
+	return b.key.PublicKey().Bytes()
+}
+
+// Start begins listening for binary protocol connections.
+func (b *BinaryServer) Start() error {
+	listener, err := net.Listen("tcp", b.stratum.cfg.BinaryEndpoint)
+	if err != nil {
+		return fmt.Errorf("failed to start binary server: %w", err)
+	}
+
+	b.listener = listener
+
+	b.wg.Add(1)
+	go b.acceptConnections()
+
+	return nil
+}
+
+// Stop closes the listener and all connections.
+func (b *BinaryServer) Stop() {
+	b.cancel()
+
+	if b.listener != nil {
+		b.listener.Close()
+	}
+
+	b.connsMu.Lock()
+	for _, conn := range b.conns {
+		conn.noise.Close()
+	}
+	b.connsMu.Unlock()
+
+	b.wg.Wait()
+}
+
+// acceptConnections accepts new miner connections.
+func (b *BinaryServer) acceptConnections() {
+	defer b.wg.Done()
+
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			select {
+			case <-b.ctx.Done():
+				return
+			default:
+				continue
+			}
+		}
+
+		b.wg.Add(1)
+		go b.handleConn(conn)
+	}
+}
+
+// handleConn performs the handshake and serves a single miner connection.
+// Malformed messages add to the miner's ban score and close the
+// connection.
+func (b *BinaryServer) handleConn(netConn net.Conn) {
+	defer b.wg.Done()
+
+	timeout := b.stratum.cfg.ConnectionTimeout
+	netConn.SetDeadline(time.Now().Add(timeout))
+
+	noise, err := NoiseServer(netConn, b.key)
+	if err != nil {
+		netConn.Close()
+		return
+	}
+
+	conn := &binaryConn{
+		id:       atomic.AddUint64(&b.nextConnID, 1),
+		noise:    noise,
+		channels: make(map[uint32]*binaryChannel),
+	}
+
+	b.connsMu.Lock()
+	b.conns[conn.id] = conn
+	b.connsMu.Unlock()
+	defer b.removeConn(conn)
+
+	// The first message must set the connection up.
+	msg, err := ReadBinaryMessage(noise)
+	if err != nil {
+		b.malformed(conn, err)
+		return
+	}
+	setup, ok := msg.(*SetupConnection)
+	if !ok || !b.handleSetup(conn, setup) {
+		return
+	}
+
+	for {
+		netConn.SetDeadline(time.Now().Add(timeout))
+
+		msg, err := ReadBinaryMessage(noise)
+		if err != nil {
+			b.malformed(conn, err)
+			return
+		}
+
+		switch msg := msg.(type) {
+		case *OpenChannel:
+			err = b.handleOpenChannel(conn, msg)
+		case *SubmitShares:
+			err = b.handleSubmitShares(conn, msg)
+		case *ReportThermal:
+			err = b.handleReportThermal(conn, msg)
+		default:
+			err = fmt.Errorf("unexpected message type %#x", msg.MsgType())
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// malformed adds to the ban score of a miner whose message could not be
+// read because it was malformed or failed authentication.
+func (b *BinaryServer) malformed(conn *binaryConn, err error) {
+	if errors.Is(err, ErrMalformedMessage) || errors.Is(err, ErrNoiseDecrypt) {
+		b.stratum.addBanScore(&conn.banScore, conn.noise.Conn(), "",
+			malformedMessageBanScore)
+	}
+}
+
+// handleSetup negotiates the protocol version and records the device. It
+// reports whether the connection was accepted.
+func (b *BinaryServer) handleSetup(conn *binaryConn, msg *SetupConnection) bool {
+	var errorCode string
+	switch {
+	case msg.Protocol != MiningProtocol:
+		errorCode = ErrCodeUnsupportedProtocol
+	case msg.MinVersion > BinaryProtocolVersion ||
+		msg.MaxVersion < BinaryProtocolVersion:
+		errorCode = ErrCodeVersionMismatch
+	case msg.Flags&^supportedSetupFlags != 0:
+		errorCode = ErrCodeUnsupportedFlags
+	}
+	if errorCode != "" {
+		WriteBinaryMessage(conn.noise, &SetupConnectionError{
+			Flags:     msg.Flags &^ supportedSetupFlags,
+			ErrorCode: errorCode,
+		})
+		return false
+	}
+
+	conn.deviceType = msg.DeviceType
+	conn.socModel = msg.SocModel
+	conn.thermalLimit = float64(msg.ThermalLimit)
+	conn.npuCapable = msg.Flags&SetupFlagNPU != 0
+
+	err := WriteBinaryMessage(conn.noise, &SetupConnectionSuccess{
+		UsedVersion: BinaryProtocolVersion,
+	})
+	return err == nil
+}
+
+// handleOpenChannel opens a standard channel for a worker and sends it the
+// current job.
+func (b *BinaryServer) handleOpenChannel(conn *binaryConn, msg *OpenChannel) error {
+	if msg.User == "" {
+		return WriteBinaryMessage(conn.noise, &OpenChannelError{
+			RequestID: msg.RequestID,
+			ErrorCode: ErrCodeUnknownUser,
+		})
+	}
+
+	s := b.stratum
+	if s.isBanned(msg.User) {
+		return WriteBinaryMessage(conn.noise, &OpenChannelError{
+			RequestID: msg.RequestID,
+			ErrorCode: ErrCodeBannedUser,
+		})
+	}
+	ch := &binaryChannel{
+		id:         atomic.AddUint32(&b.nextChannelID, 1),
+		workerName: msg.User,
+		vardiff: newVardiff(s.cfg,
+			deviceThermalClass(s.devices, "", conn.socModel),
+			time.Now()),
+		jobs: make(map[uint32]*MiningJob),
+	}
+	ch.extranonce = extranonce1(atomic.AddUint64(&s.nextClientID, 1))
+
+	// Respect the highest target the device asked for, also when
+	// retargeting.
+	if maxTarget := mobilex.HashToBig(&msg.MaxTarget); maxTarget.Sign() > 0 {
+		maxDiff := targetDifficulty(targetToHex(maxTarget))
+		if maxDiff > ch.vardiff.minDifficulty {
+			ch.vardiff.minDifficulty = maxDiff
+		}
+		if maxDiff > ch.vardiff.maxDifficulty {
+			ch.vardiff.maxDifficulty = maxDiff
+		}
+	}
+	ch.difficulty = ch.vardiff.clamp(s.cfg.InitialDifficulty)
+
+	s.stats.setOnline(ch.workerName, true)
+	s.stats.deviceInfo(ch.workerName, conn.deviceType, conn.socModel,
+		conn.npuCapable, conn.thermalLimit)
+
+	conn.mu.Lock()
+	conn.channels[ch.id] = ch
+	conn.mu.Unlock()
+
+	err := WriteBinaryMessage(conn.noise, &OpenChannelSuccess{
+		RequestID:        msg.RequestID,
+		ChannelID:        ch.id,
+		Target:           b.shareTarget(ch.difficulty),
+		ExtranoncePrefix: ch.extranonce,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Channels opened before the pool has a job get one with the first
+	// template.
+	job := s.jobManager.GetCurrentJob()
+	if job == nil {
+		return nil
+	}
+	return b.sendJob(conn, ch, job)
+}
+
+// handleSubmitShares validates a share submitted on a channel.
+func (b *BinaryServer) handleSubmitShares(conn *binaryConn, msg *SubmitShares) error {
+	s := b.stratum
+
+	conn.mu.Lock()
+	ch, ok := conn.channels[msg.ChannelID]
+	var (
+		job        *MiningJob
+		worker     string
+		difficulty float64
+		extranonce []byte
+	)
+	if ok {
+		job = ch.jobs[msg.JobID]
+		worker = ch.workerName
+		difficulty = ch.difficulty
+		extranonce = ch.extranonce
+	}
+	conn.mu.Unlock()
+
+	reject := func(errorCode string) error {
+		return WriteBinaryMessage(conn.noise, &SubmitSharesError{
+			ChannelID:      msg.ChannelID,
+			SequenceNumber: msg.SequenceNumber,
+			ErrorCode:      errorCode,
+		})
+	}
+
+	if !ok {
+		err := reject(ErrCodeInvalidChannelID)
+		s.addBanScore(&conn.banScore, conn.noise.Conn(), "",
+			unauthorizedBanScore)
+		return err
+	}
+	if job == nil {
+		s.stats.shareRejected(worker, false)
+		return reject(ErrCodeInvalidJobID)
+	}
+
+	// Version rolling is not supported.
+	if msg.Version != uint32(job.Version) {
+		s.stats.shareRejected(worker, false)
+		return reject(ErrCodeInvalidShare)
+	}
+
+	// Header-only jobs have no extranonce to roll, so the coinbase of
+	// the channel's jobs has a zero extranonce2.
+	share := &Share{
+		ClientID:     conn.id,
+		WorkerName:   worker,
+		JobID:        job.ID,
+		Extranonce1:  hex.EncodeToString(extranonce),
+		Extranonce2:  hex.EncodeToString(headerOnlyExtranonce2),
+		Ntime:        fmt.Sprintf("%08x", msg.NTime),
+		Nonce:        fmt.Sprintf("%08x", msg.Nonce),
+		ThermalProof: fmt.Sprintf("%016x", msg.ThermalProof),
+		Difficulty:   difficulty,
+		SubmittedAt:  time.Now(),
+	}
+
+	result, err := s.shareValidator.ValidateShare(share, job)
+	if err != nil {
+		s.stats.shareRejected(worker, errors.Is(err, ErrThermalProof))
+
+		rejectErr := reject(binaryShareErrorCode(err))
+		banned := s.addBanScore(&conn.banScore, conn.noise.Conn(),
+			worker, shareBanScore(err))
+		if !banned && errors.Is(err, ErrThermalProof) {
+			s.updateReputation(worker, conn.noise.Conn())
+		}
+		return rejectErr
+	}
+
+	s.stats.shareAccepted(worker, difficulty)
+	if s.updateReputation(worker, conn.noise.Conn()) {
+		return nil
+	}
+
+	s.recordShare(worker, difficulty, s.npuBonus(worker, conn.npuCapable),
+		job)
+
+	err = WriteBinaryMessage(conn.noise, &SubmitSharesSuccess{
+		ChannelID:               msg.ChannelID,
+		LastSequenceNumber:      msg.SequenceNumber,
+		NewSubmitsAcceptedCount: 1,
+		NewSharesSum:            uint64(difficulty),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Retarget the channel's difficulty if due
+	conn.mu.Lock()
+	newDiff, retarget := ch.vardiff.shareAccepted(ch.difficulty, time.Now())
+	if retarget {
+		ch.difficulty = newDiff
+	}
+	conn.mu.Unlock()
+	if retarget {
+		err := WriteBinaryMessage(conn.noise, &SetTarget{
+			ChannelID: msg.ChannelID,
+			MaxTarget: b.shareTarget(newDiff),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if result.MeetsNetworkDifficulty {
+		found := &BlockFound{
+			ChannelID: msg.ChannelID,
+			Height:    uint32(job.Height),
+			BlockHash: result.Block.BlockHash(),
+			Accepted:  true,
+		}
+		if err := s.processBlock(job, result.Block); err != nil {
+			found.Accepted = false
+			found.Reason = err.Error()
+		}
+		return WriteBinaryMessage(conn.noise, found)
+	}
+
+	return nil
+}
+
+// handleReportThermal records a thermal report, lowering the channel's
+// difficulty when the device is throttling. Channels that stopped finding
+// shares are retargeted as well.
+func (b *BinaryServer) handleReportThermal(conn *binaryConn, msg *ReportThermal) error {
+	conn.mu.Lock()
+	ch, ok := conn.channels[msg.ChannelID]
+	var (
+		worker     string
+		difficulty float64
+		retarget   bool
+	)
+	if ok {
+		worker = ch.workerName
+		if msg.Throttled {
+			difficulty = ch.vardiff.clamp(ch.difficulty * 0.8)
+			retarget = difficulty != ch.difficulty
+			ch.vardiff.reset(time.Now())
+		} else {
+			difficulty, retarget = ch.vardiff.retarget(ch.difficulty,
+				time.Now())
+		}
+		ch.difficulty = difficulty
+	}
+	conn.mu.Unlock()
+
+	if !ok {
+		// Thermal reports have no error response, so unknown channels
+		// are ignored.
+		return nil
+	}
+
+	b.stratum.stats.thermalReport(worker, float64(msg.Temperature),
+		float64(msg.PowerUsage), float64(msg.HashRate), msg.Throttled)
+	if b.stratum.updateReputation(worker, conn.noise.Conn()) {
+		return nil
+	}
+
+	if retarget {
+		err := WriteBinaryMessage(conn.noise, &SetTarget{
+			ChannelID: msg.ChannelID,
+			MaxTarget: b.shareTarget(difficulty),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return WriteBinaryMessage(conn.noise, &ReportThermalSuccess{
+		ChannelID: msg.ChannelID,
+	})
+}
+
+// binaryShareErrorCode returns the error code of a share rejected with err.
+func binaryShareErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		return ErrCodeInvalidJobID
+	case errors.Is(err, ErrDuplicateShare):
+		return ErrCodeDuplicateShare
+	case errors.Is(err, ErrLowDifficulty):
+		return ErrCodeDifficultyTooLow
+	case errors.Is(err, ErrThermalProof):
+		return ErrCodeInvalidThermalProof
+	default:
+		return ErrCodeInvalidShare
+	}
+}
+
+// sendJob sends a header-only job to a channel. Jobs on a new chain tip are
+// followed by SetNewPrevHash, which activates the job and discards the
+// channel's older jobs.
+func (b *BinaryServer) sendJob(conn *binaryConn, ch *binaryChannel, job *MiningJob) error {
+	jobID, err := strconv.ParseUint(job.ID, 10, 32)
+	if err != nil {
+		return fmt.Errorf("job ID %q does not fit the binary protocol: %w",
+			job.ID, err)
+	}
+
+	conn.mu.Lock()
+	newTip := job.CleanJobs || ch.prevHash != job.PreviousHash
+	if newTip {
+		ch.jobs = make(map[uint32]*MiningJob)
+		ch.prevHash = job.PreviousHash
+	}
+	ch.jobs[uint32(jobID)] = job
+	conn.mu.Unlock()
+
+	// Each channel mines its own coinbase, so it gets its own merkle
+	// root.
+	coinbaseTx, err := job.coinbase(ch.extranonce, headerOnlyExtranonce2)
+	if err != nil {
+		return err
+	}
+
+	err = WriteBinaryMessage(conn.noise, &NewMiningJob{
+		ChannelID:     ch.id,
+		JobID:         uint32(jobID),
+		Version:       uint32(job.Version),
+		MerkleRoot:    job.merkleRoot(coinbaseTx),
+		Height:        uint32(job.Height),
+		ThermalTarget: float32(job.ThermalTarget),
+	})
+	if err != nil || !newTip {
+		return err
+	}
+
+	prevHash, err := chainhash.NewHashFromStr(job.PreviousHash)
+	if err != nil {
+		return err
+	}
+
+	return WriteBinaryMessage(conn.noise, &SetNewPrevHash{
+		ChannelID: ch.id,
+		JobID:     uint32(jobID),
+		PrevHash:  *prevHash,
+		MinNTime:  uint32(time.Now().Unix()),
+		NBits:     job.Bits,
+	})
+}
+
+// broadcastJob sends a new job to every open channel.
+func (b *BinaryServer) broadcastJob(job *MiningJob) {
+	type target struct {
+		conn *binaryConn
+		ch   *binaryChannel
+	}
+
+	var targets []target
+	b.connsMu.Lock()
+	for _, conn := range b.conns {
+		conn.mu.Lock()
+		for _, ch := range conn.channels {
+			targets = append(targets, target{conn, ch})
+		}
+		conn.mu.Unlock()
+	}
+	b.connsMu.Unlock()
+
+	for _, t := range targets {
+		b.sendJob(t.conn, t.ch, job)
+	}
+}
+
+// removeConn closes a connection and marks its workers offline.
+func (b *BinaryServer) removeConn(conn *binaryConn) {
+	conn.noise.Close()
+
+	conn.mu.Lock()
+	for _, ch := range conn.channels {
+		b.stratum.stats.setOnline(ch.workerName, false)
+	}
+	conn.mu.Unlock()
+
+	b.connsMu.Lock()
+	delete(b.conns, conn.id)
+	b.connsMu.Unlock()
+}
+
+// shareTarget returns the target of shares at difficulty as sent on the
+// wire.
+func (b *BinaryServer) shareTarget(difficulty float64) chainhash.Hash {
+	return targetToHash(b.stratum.shareValidator.difficultyToTarget(difficulty))
+}
+
+// targetToHash encodes a target as a little endian 256 bit integer.
+func targetToHash(target *big.Int) chainhash.Hash {
+	var hash chainhash.Hash
+	buf := target.FillBytes(make([]byte, chainhash.HashSize))
+	for i := range buf {
+		hash[i] = buf[len(buf)-1-i]
+	}
+	return hash
+}
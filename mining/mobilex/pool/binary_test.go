@@ -0,0 +1,286 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/shell/chaincfg"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+)
+
+// TestNoiseHandshake tests the encrypted transport and that clients only
+// connect to the pool holding the key they expect
+func TestNoiseHandshake(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "noise.key")
+	key, err := LoadOrCreateNoiseKey(path)
+	require.NoError(t, err)
+	reloaded, err := LoadOrCreateNoiseKey(path)
+	require.NoError(t, err)
+	require.True(t, key.Equal(reloaded))
+
+	handshake := func(serverKey []byte) (*NoiseConn, *NoiseConn, error) {
+		clientConn, serverConn := net.Pipe()
+		t.Cleanup(func() {
+			clientConn.Close()
+			serverConn.Close()
+		})
+
+		type result struct {
+			conn *NoiseConn
+			err  error
+		}
+		done := make(chan result, 1)
+		go func() {
+			conn, err := NoiseServer(serverConn, key)
+			if err != nil {
+				serverConn.Close()
+			}
+			done <- result{conn, err}
+		}()
+
+		client, clientErr := NoiseClient(clientConn, serverKey)
+		server := <-done
+		if clientErr != nil {
+			return nil, nil, clientErr
+		}
+		return client, server.conn, server.err
+	}
+
+	client, server, err := handshake(key.PublicKey().Bytes())
+	require.NoError(t, err)
+
+	go client.WriteFrame([]byte("hello pool"))
+	frame, err := server.ReadFrame()
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello pool"), frame)
+
+	go server.WriteFrame([]byte("hello miner"))
+	frame, err = client.ReadFrame()
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello miner"), frame)
+
+	// A pool impersonated with another key fails the handshake.
+	other, err := LoadOrCreateNoiseKey(filepath.Join(t.TempDir(), "other.key"))
+	require.NoError(t, err)
+	_, _, err = handshake(other.PublicKey().Bytes())
+	require.Error(t, err)
+}
+
+// TestBinaryMessages tests encoding and decoding binary messages
+func TestBinaryMessages(t *testing.T) {
+	msgs := []BinaryMessage{
+		&SetupConnection{
+			Protocol:     MiningProtocol,
+			MinVersion:   2,
+			MaxVersion:   2,
+			Flags:        SetupFlagNPU,
+			DeviceType:   "Android",
+			SocModel:     "Snapdragon 8 Gen 3",
+			Firmware:     "shell-miner/1.0",
+			ThermalLimit: 45,
+		},
+		&SetupConnectionSuccess{UsedVersion: 2},
+		&SetupConnectionError{Flags: 4, ErrorCode: ErrCodeUnsupportedFlags},
+		&OpenChannel{RequestID: 1, User: "alice.phone", NominalHashRate: 1000},
+		&OpenChannelSuccess{RequestID: 1, ChannelID: 2, Target: chainhash.Hash{0xff},
+			ExtranoncePrefix: []byte{0, 0, 0, 2}},
+		&OpenChannelError{RequestID: 1, ErrorCode: ErrCodeUnknownUser},
+		&NewMiningJob{ChannelID: 2, JobID: 3, Version: 1, MerkleRoot: chainhash.Hash{1},
+			Height: 100, ThermalTarget: 45},
+		&SetNewPrevHash{ChannelID: 2, JobID: 3, PrevHash: chainhash.Hash{2},
+			MinNTime: 1700000000, NBits: 0x1d00ffff},
+		&SetTarget{ChannelID: 2, MaxTarget: chainhash.Hash{3}},
+		&SubmitShares{ChannelID: 2, SequenceNumber: 4, JobID: 3, Nonce: 5,
+			NTime: 1700000000, Version: 1, ThermalProof: 6},
+		&SubmitSharesSuccess{ChannelID: 2, LastSequenceNumber: 4,
+			NewSubmitsAcceptedCount: 1, NewSharesSum: 1},
+		&SubmitSharesError{ChannelID: 2, SequenceNumber: 4, ErrorCode: ErrCodeInvalidShare},
+		&ReportThermal{ChannelID: 2, Temperature: 41.5, PowerUsage: 4,
+			HashRate: 900, Throttled: true},
+		&ReportThermalSuccess{ChannelID: 2},
+		&BlockFound{ChannelID: 2, Height: 100, BlockHash: chainhash.Hash{4},
+			Reason: "rejected"},
+	}
+
+	for _, msg := range msgs {
+		frame, err := EncodeBinaryMessage(msg)
+		require.NoError(t, err)
+
+		decoded, err := DecodeBinaryMessage(frame)
+		require.NoError(t, err)
+		require.Equal(t, msg, decoded)
+
+		// Truncated and padded payloads are rejected.
+		_, err = DecodeBinaryMessage(frame[:len(frame)-1])
+		require.True(t, errors.Is(err, ErrMalformedMessage))
+		_, err = DecodeBinaryMessage(append(frame, 0))
+		require.True(t, errors.Is(err, ErrMalformedMessage))
+	}
+
+	_, err := DecodeBinaryMessage([]byte{0, 0, 0x7f, 0, 0, 0})
+	require.True(t, errors.Is(err, ErrMalformedMessage))
+}
+
+// TestBinaryServer tests negotiating a connection, opening a channel and
+// mining on header-only jobs over the binary protocol
+func TestBinaryServer(t *testing.T) {
+	cfg := DefaultPoolConfig()
+	cfg.DatabasePath = ""
+	cfg.BinaryEndpoint = "127.0.0.1:0"
+	cfg.NoiseKeyPath = filepath.Join(t.TempDir(), "noise.key")
+	cfg.ThermalCompliance = false
+	cfg.PoolAddress = testAccount(t, 0xff)
+
+	s, err := NewStratumServer(cfg, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	newTip := func(height int64, prevHash chainhash.Hash) *MiningJob {
+		result := testTemplate(height, prevHash)
+		template, err := templateFromResult(&result)
+		require.NoError(t, err)
+		require.NoError(t, s.jobManager.updateJob(template))
+		return s.jobManager.GetCurrentJob()
+	}
+	poolJob := newTip(100, chainhash.Hash{0x07})
+
+	b, err := NewBinaryServer(s)
+	require.NoError(t, err)
+	require.NoError(t, b.Start())
+	defer b.Stop()
+
+	dial := func() *NoiseConn {
+		conn, err := net.Dial("tcp", b.listener.Addr().String())
+		require.NoError(t, err)
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+		noise, err := NoiseClient(conn, b.PublicKey())
+		require.NoError(t, err)
+		t.Cleanup(func() { noise.Close() })
+		return noise
+	}
+	send := func(conn *NoiseConn, msg BinaryMessage) {
+		require.NoError(t, WriteBinaryMessage(conn, msg))
+	}
+	recv := func(conn *NoiseConn) BinaryMessage {
+		msg, err := ReadBinaryMessage(conn)
+		require.NoError(t, err)
+		return msg
+	}
+
+	// Miners that cannot speak the pool's version are turned away.
+	old := dial()
+	send(old, &SetupConnection{Protocol: MiningProtocol, MinVersion: 1, MaxVersion: 1})
+	require.Equal(t, &SetupConnectionError{ErrorCode: ErrCodeVersionMismatch}, recv(old))
+
+	conn := dial()
+	send(conn, &SetupConnection{
+		Protocol:     MiningProtocol,
+		MinVersion:   1,
+		MaxVersion:   BinaryProtocolVersion,
+		Flags:        SetupFlagNPU,
+		DeviceType:   "Android",
+		SocModel:     "Snapdragon 8 Gen 3",
+		ThermalLimit: 45,
+	})
+	require.Equal(t, &SetupConnectionSuccess{UsedVersion: BinaryProtocolVersion}, recv(conn))
+
+	// Opening a channel returns the share target and the current job.
+	send(conn, &OpenChannel{RequestID: 7, User: "alice.phone"})
+	opened := recv(conn).(*OpenChannelSuccess)
+	require.Equal(t, uint32(7), opened.RequestID)
+	require.Equal(t, targetToHash(diffOneTarget), opened.Target)
+
+	// The job commits to a coinbase with the channel's extranonce.
+	channelRoot := func(job *MiningJob) chainhash.Hash {
+		coinbase, err := job.coinbase(opened.ExtranoncePrefix,
+			headerOnlyExtranonce2)
+		require.NoError(t, err)
+		return job.merkleRoot(coinbase)
+	}
+	job := recv(conn).(*NewMiningJob)
+	require.Equal(t, opened.ChannelID, job.ChannelID)
+	require.Equal(t, uint32(1), job.JobID)
+	require.Equal(t, channelRoot(poolJob), job.MerkleRoot)
+	prevHash := recv(conn).(*SetNewPrevHash)
+	require.Equal(t, job.JobID, prevHash.JobID)
+	require.Equal(t, chainhash.Hash{0x07}, prevHash.PrevHash)
+	require.Equal(t, uint32(0x1d00ffff), prevHash.NBits)
+
+	stats, _, ok := s.stats.workerDetail("alice.phone")
+	require.True(t, ok)
+	require.True(t, stats.Online)
+	require.True(t, stats.NPUCapable)
+	require.Equal(t, "Snapdragon 8 Gen 3", stats.SocModel)
+
+	// Shares on unknown channels and jobs, and shares above the target,
+	// are rejected.
+	share := SubmitShares{
+		ChannelID:      opened.ChannelID,
+		SequenceNumber: 1,
+		JobID:          job.JobID,
+		NTime:          uint32(time.Now().Unix()),
+		Version:        job.Version,
+	}
+	badChannel := share
+	badChannel.ChannelID++
+	send(conn, &badChannel)
+	require.Equal(t, ErrCodeInvalidChannelID, recv(conn).(*SubmitSharesError).ErrorCode)
+
+	badJob := share
+	badJob.JobID = 99
+	send(conn, &badJob)
+	require.Equal(t, ErrCodeInvalidJobID, recv(conn).(*SubmitSharesError).ErrorCode)
+
+	send(conn, &share)
+	require.Equal(t, &SubmitSharesError{
+		ChannelID:      share.ChannelID,
+		SequenceNumber: 1,
+		ErrorCode:      ErrCodeDifficultyTooLow,
+	}, recv(conn))
+
+	// A new chain tip reaches the channel as a header-only job.
+	poolJob = newTip(101, chainhash.Hash{0x08})
+	job = recv(conn).(*NewMiningJob)
+	require.Equal(t, uint32(2), job.JobID)
+	require.Equal(t, uint32(101), job.Height)
+	require.Equal(t, uint32(4), job.Version)
+	require.Equal(t, channelRoot(poolJob), job.MerkleRoot)
+	prevHash = recv(conn).(*SetNewPrevHash)
+	require.Equal(t, chainhash.Hash{0x08}, prevHash.PrevHash)
+
+	// The old job is gone from the channel.
+	send(conn, &share)
+	require.Equal(t, ErrCodeInvalidJobID, recv(conn).(*SubmitSharesError).ErrorCode)
+
+	// Throttling lowers the channel's difficulty.
+	send(conn, &ReportThermal{
+		ChannelID:   opened.ChannelID,
+		Temperature: 47,
+		PowerUsage:  6,
+		HashRate:    900,
+		Throttled:   true,
+	})
+	target := recv(conn).(*SetTarget)
+	require.Equal(t, targetToHash(s.shareValidator.difficultyToTarget(0.8)),
+		target.MaxTarget)
+	require.IsType(t, &ReportThermalSuccess{}, recv(conn))
+
+	stats, thermal, _ := s.stats.workerDetail("alice.phone")
+	require.Equal(t, uint64(3), stats.RejectedShares)
+	require.Equal(t, 47.0, stats.Temperature)
+	require.Len(t, thermal, 1)
+	require.False(t, thermal[0].Compliant)
+
+	// Closing the connection takes the worker offline.
+	conn.Close()
+	require.Eventually(t, func() bool {
+		stats, _, _ := s.stats.workerDetail("alice.phone")
+		return !stats.Online
+	}, 5*time.Second, 10*time.Millisecond)
+}
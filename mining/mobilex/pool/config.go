@@ -12,13 +12,18 @@ import (
 type PoolConfig struct {
 	// Network configuration
 	StratumEndpoint   string        // TCP endpoint for Stratum protocol
+	BinaryEndpoint    string        // TCP endpoint for the encrypted binary protocol
 	HTTPEndpoint      string        // HTTP endpoint for REST API
 	ConnectionTimeout time.Duration // Connection timeout
+	NoiseKeyPath      string        // Pool's static Noise key, created if missing
 
 	// Pool parameters
 	PoolAddress     string  // Pool's Shell address for rewards
 	PoolFeePercent  float64 // Pool fee percentage (e.g., 1.0 for 1%)
 	PayoutThreshold float64 // Minimum payout amount in XSL
+	PayoutScheme    string  // PayoutPPLNS or PayoutPPS
+	PPLNSWindow     int     // Number of recent shares paid by PPLNS or averaged by PPS
+	PayoutFeeRate   int64   // Payout transaction fee rate in satoshis per kB
 
 	// Difficulty settings
 	InitialDifficulty   float64       // Starting difficulty for new miners
@@ -26,10 +31,24 @@ type PoolConfig struct {
 	MaxMobileDifficulty float64       // Maximum difficulty for mobile devices
 	DifficultyRetarget  time.Duration // How often to adjust difficulty
 
+	// Vardiff settings
+	TargetSharesPerMinute map[string]float64 // Share rate by device thermal class
+	VardiffVariance       float64            // Share rate error tolerated before retargeting
+
+	// Misbehavior settings
+	BanThreshold uint32        // Ban score at which miners are banned, 0 disables banning
+	BanDuration  time.Duration // How long misbehaving miners stay banned
+
+	// Thermal reputation settings
+	ThermalAnomalyThreshold  float64 // Z-score at which a temperature is an outlier
+	ReputationDowngradeScore float64 // Reputation below which workers lose the NPU bonus
+	ReputationBanScore       float64 // Reputation at which workers are banned, 0 disables banning
+
 	// Mobile-specific settings
 	ThermalCompliance  bool    // Enforce thermal proof validation
 	NPUBonus           float64 // Bonus multiplier for NPU-enabled devices
 	DeviceOptimization bool    // Enable device-specific optimizations
+	DeviceProfilesPath string  // Device profile database overriding the defaults
 
 	// Database configuration
 	DatabasePath string // Path to pool database
@@ -45,16 +64,35 @@ type PoolConfig struct {
 func DefaultPoolConfig() *PoolConfig {
 	return &PoolConfig{
 		StratumEndpoint:   ":3333",
+		BinaryEndpoint:    ":3336",
 		HTTPEndpoint:      ":8080",
 		ConnectionTimeout: 30 * time.Second,
+		NoiseKeyPath:      "pool.noise.key",
 
 		PoolFeePercent:  1.0,
 		PayoutThreshold: 1.0, // 1 XSL minimum
+		PayoutScheme:    PayoutPPLNS,
+		PPLNSWindow:     10000,
+		PayoutFeeRate:   1000,
 
 		InitialDifficulty:   1.0,
 		MinMobileDifficulty: 0.1,
 		MaxMobileDifficulty: 100.0,
-		DifficultyRetarget:  5 * time.Minute,
+		DifficultyRetarget:  90 * time.Second,
+
+		TargetSharesPerMinute: map[string]float64{
+			thermalClassFlagship: 4,
+			thermalClassMidrange: 3,
+			thermalClassBudget:   2,
+		},
+		VardiffVariance: 0.3, // Retarget when 30% off the target rate
+
+		BanThreshold: 100,
+		BanDuration:  time.Hour,
+
+		ThermalAnomalyThreshold:  3.0,
+		ReputationDowngradeScore: 75,
+		ReputationBanScore:       30,
 
 		ThermalCompliance:  true,
 		NPUBonus:           1.1, // 10% bonus for NPU miners
@@ -5,24 +5,43 @@
 package pool
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/big"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/toole-brendan/shell/addresses"
 	"github.com/toole-brendan/shell/blockchain"
 	"github.com/toole-brendan/shell/chaincfg"
 	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/internal/convert"
+	"github.com/toole-brendan/shell/txscript"
 	"github.com/toole-brendan/shell/wire"
 )
 
+const (
+	// extranonce1Size is the size of the extranonce the pool assigns to
+	// each miner so no two miners search the same coinbase.
+	extranonce1Size = 4
+
+	// extranonce2Size is the size of the extranonce miners roll.
+	extranonce2Size = 4
+
+	// coinbaseFlags is pushed at the end of the coinbase script.
+	coinbaseFlags = "/Shell Mobile Pool/"
+)
+
 // JobManager manages mining jobs for the pool.
 type JobManager struct {
 	cfg         *PoolConfig
 	chainParams *chaincfg.Params
+	node        *NodeClient
 
 	// Current job
 	currentJob   atomic.Value // *MiningJob
@@ -32,89 +51,179 @@ type JobManager struct {
 	currentTemplate *BlockTemplate
 	templateMutex   sync.RWMutex
 
+	// Job notification
+	jobHandler func(*MiningJob)
+	handlerMtx sync.Mutex
+
 	// Update tracking
 	lastUpdate     time.Time
 	updateInterval time.Duration
+	retryInterval  time.Duration
 
 	// Shutdown
-	quit chan struct{}
+	quit     chan struct{}
+	quitOnce sync.Once
 }
 
 // BlockTemplate represents a block template from the node.
 type BlockTemplate struct {
 	Height        int32
+	Version       int32
 	PreviousBlock chainhash.Hash
+	Bits          uint32
 	Transactions  []*wire.MsgTx
 	CoinbaseValue int64
 	Target        *big.Int
 	MinTime       int64
 	CurTime       int64
+
+	// WitnessCommitment is the script of the coinbase output committing
+	// to the witnesses of the transactions, if any has one
+	WitnessCommitment []byte
+
+	// LongPollID identifies the template to the node's long poll
+	LongPollID string
 }
 
 // NewJobManager creates a new job manager.
 func NewJobManager(cfg *PoolConfig, chainParams *chaincfg.Params) *JobManager {
-	jm := &JobManager{
+	return &JobManager{
 		cfg:            cfg,
 		chainParams:    chainParams,
+		node:           NewNodeClient(cfg),
 		updateInterval: 30 * time.Second,
+		retryInterval:  5 * time.Second,
 		quit:           make(chan struct{}),
 	}
+}
 
-	// Initialize with empty job
-	jm.currentJob.Store(&MiningJob{
-		ID:               "0",
-		Height:           0,
-		PreviousHash:     "0000000000000000000000000000000000000000000000000000000000000000",
-		Target:           "00000000ffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
-		MobileDifficulty: cfg.InitialDifficulty,
-	})
-
-	return jm
+// SetJobHandler registers a function called with every new job.
+func (jm *JobManager) SetJobHandler(handler func(*MiningJob)) {
+	jm.handlerMtx.Lock()
+	jm.jobHandler = handler
+	jm.handlerMtx.Unlock()
 }
 
-// Start begins the job management loop.
+// Start begins the job management loop. Templates are fetched with long
+// polling so a new job is created as soon as the node has new work. Nodes
+// that do not return a long poll ID are polled every update interval.
 func (jm *JobManager) Start(ctx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	// Initial job update
-	jm.updateJob()
-
-	ticker := time.NewTicker(jm.updateInterval)
-	defer ticker.Stop()
-
-	for {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
 		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			jm.updateJob()
 		case <-jm.quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var longPollID string
+	for {
+		template, err := jm.node.GetBlockTemplate(ctx, longPollID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			// Keep serving the existing job and request a fresh
+			// template once the node is reachable again.
+			longPollID = ""
+			if !jm.wait(ctx, jm.retryInterval) {
+				return
+			}
+			continue
+		}
+
+		if err := jm.updateJob(template); err != nil {
+			// Keep serving the existing job. The template is
+			// retried since the pool's own settings are usually
+			// at fault.
+			log.Errorf("Failed to create job at height %d: %v",
+				template.Height, err)
+			longPollID = ""
+			if !jm.wait(ctx, jm.retryInterval) {
+				return
+			}
+			continue
+		}
+
+		longPollID = template.LongPollID
+		if longPollID == "" && !jm.wait(ctx, jm.updateInterval) {
 			return
 		}
 	}
 }
 
+// wait blocks for d, returning false if the context ends first.
+func (jm *JobManager) wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 // Stop stops the job manager.
 func (jm *JobManager) Stop() {
-	close(jm.quit)
+	jm.quitOnce.Do(func() { close(jm.quit) })
 }
 
-// GetCurrentJob returns the current mining job.
+// GetCurrentJob returns the current mining job, or nil before the first
+// block template is received.
 func (jm *JobManager) GetCurrentJob() *MiningJob {
-	return jm.currentJob.Load().(*MiningJob)
+	job, _ := jm.currentJob.Load().(*MiningJob)
+	return job
+}
+
+// SubmitBlock submits a solved block to the node.
+func (jm *JobManager) SubmitBlock(block *wire.MsgBlock) error {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		jm.cfg.ConnectionTimeout)
+	defer cancel()
+
+	return jm.node.SubmitBlock(ctx, block)
 }
 
-// updateJob fetches a new block template and creates a job.
-func (jm *JobManager) updateJob() {
-	// Get block template from node
-	template, err := jm.getBlockTemplate()
+// BlockHash returns the hash of the main chain block at height.
+func (jm *JobManager) BlockHash(height int32) (*chainhash.Hash, error) {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		jm.cfg.ConnectionTimeout)
+	defer cancel()
+
+	return jm.node.GetBlockHash(ctx, height)
+}
+
+// updateJob creates a new job from a block template and announces it.
+// Miners are told to drop their old jobs when the template builds on a new
+// tip, since shares for the previous tip can no longer become blocks.
+func (jm *JobManager) updateJob(template *BlockTemplate) error {
+	// Build the coinbase and the merkle branch linking it to the
+	// template's transactions.
+	coinbaseTx, err := jm.buildCoinbase(template)
 	if err != nil {
-		// Log error but continue with existing job
-		return
+		return err
+	}
+	coinbase1, coinbase2, err := splitCoinbase(coinbaseTx, template.Height)
+	if err != nil {
+		return err
+	}
+	txns := make([]*btcutil.Tx, 0, len(template.Transactions)+1)
+	txns = append(txns, convert.NewShellTx(coinbaseTx))
+	for _, tx := range template.Transactions {
+		txns = append(txns, convert.NewShellTx(tx))
 	}
 
 	// Store template
 	jm.templateMutex.Lock()
+	cleanJobs := jm.currentTemplate == nil ||
+		jm.currentTemplate.PreviousBlock != template.PreviousBlock
 	jm.currentTemplate = template
 	jm.templateMutex.Unlock()
 
@@ -127,7 +236,14 @@ func (jm *JobManager) updateJob() {
 		PreviousHash:     template.PreviousBlock.String(),
 		CoinbaseValue:    template.CoinbaseValue,
 		Target:           targetToHex(template.Target),
+		Bits:             template.Bits,
 		MobileDifficulty: jm.cfg.InitialDifficulty,
+		CleanJobs:        cleanJobs,
+		Version:          template.Version,
+		Coinbase1:        coinbase1,
+		Coinbase2:        coinbase2,
+		MerkleBranch:     blockchain.CoinbaseMerkleBranch(txns),
+		template:         template,
 
 		// Mobile-specific fields
 		ThermalTarget: 45.0, // 45°C target
@@ -142,71 +258,67 @@ func (jm *JobManager) updateJob() {
 	// Store new job
 	jm.currentJob.Store(job)
 	jm.lastUpdate = time.Now()
-}
 
-// getBlockTemplate fetches a block template from the node.
-func (jm *JobManager) getBlockTemplate() (*BlockTemplate, error) {
-	// This would connect to the Shell node via RPC
-	// For now, return a mock template
-
-	// In production, this would use btcjson.NewGetBlockTemplateCmd
-	// and make an RPC call to the node
-
-	mockTemplate := &BlockTemplate{
-		Height:        100000,
-		PreviousBlock: chainhash.Hash{}, // Would be real previous block
-		Transactions:  []*wire.MsgTx{},
-		CoinbaseValue: 95 * 1e8, // 95 XSL
-		Target:        blockchain.CompactToBig(0x1d00ffff),
-		MinTime:       time.Now().Unix() - 600,
-		CurTime:       time.Now().Unix(),
+	jm.handlerMtx.Lock()
+	handler := jm.jobHandler
+	jm.handlerMtx.Unlock()
+	if handler != nil {
+		handler(job)
 	}
 
-	return mockTemplate, nil
+	return nil
 }
 
-// CreateCoinbase creates a coinbase transaction for a miner.
-func (jm *JobManager) CreateCoinbase(extraNonce1, extraNonce2 string, minerAddress string) (*wire.MsgTx, error) {
-	jm.templateMutex.RLock()
-	template := jm.currentTemplate
-	jm.templateMutex.RUnlock()
-
-	if template == nil {
-		return nil, fmt.Errorf("no block template available")
+// coinbase returns the coinbase of the job with the passed extranonces.
+func (job *MiningJob) coinbase(extranonce1, extranonce2 []byte) (*wire.MsgTx, error) {
+	if len(extranonce1) != extranonce1Size {
+		return nil, errors.New("invalid extranonce1")
+	}
+	if len(extranonce2) != extranonce2Size {
+		return nil, errors.New("invalid extranonce2")
 	}
 
-	// Create coinbase transaction
-	coinbaseTx := wire.NewMsgTx(wire.TxVersion)
-
-	// Coinbase input
-	prevOut := wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex)
-	txIn := wire.NewTxIn(prevOut, nil, nil)
-
-	// Build coinbase script
-	// Height + ExtraNonce1 + ExtraNonce2 + Pool signature
-	scriptSig := BuildCoinbaseScript(template.Height, extraNonce1, extraNonce2, "Shell Mobile Pool")
-	txIn.SignatureScript = scriptSig
+	serialized := make([]byte, 0, len(job.Coinbase1)+extranonce1Size+
+		extranonce2Size+len(job.Coinbase2))
+	serialized = append(serialized, job.Coinbase1...)
+	serialized = append(serialized, extranonce1...)
+	serialized = append(serialized, extranonce2...)
+	serialized = append(serialized, job.Coinbase2...)
 
-	coinbaseTx.AddTxIn(txIn)
+	var coinbaseTx wire.MsgTx
+	if err := coinbaseTx.DeserializeNoWitness(bytes.NewReader(serialized)); err != nil {
+		return nil, fmt.Errorf("invalid coinbase: %w", err)
+	}
+	return &coinbaseTx, nil
+}
 
-	// Calculate pool fee
-	poolFee := int64(float64(template.CoinbaseValue) * jm.cfg.PoolFeePercent / 100.0)
-	minerReward := template.CoinbaseValue - poolFee
+// merkleRoot returns the merkle root of the job's block with the passed
+// coinbase.
+func (job *MiningJob) merkleRoot(coinbaseTx *wire.MsgTx) chainhash.Hash {
+	hash := coinbaseTx.TxHash()
+	return blockchain.MerkleRootFromBranch(&hash, job.MerkleBranch)
+}
 
-	// Miner output
-	minerScript, err := AddrToScript(minerAddress)
+// buildCoinbase creates the coinbase of a job paying the template's coinbase
+// value to the pool address. The extranonce is left zero for miners to fill
+// in, and the witness commitment of the template is included when the
+// template has one.
+func (jm *JobManager) buildCoinbase(template *BlockTemplate) (*wire.MsgTx, error) {
+	script, err := coinbaseScript(template.Height)
 	if err != nil {
 		return nil, err
 	}
-	coinbaseTx.AddTxOut(wire.NewTxOut(minerReward, minerScript))
+	poolScript, err := AddrToScript(jm.cfg.PoolAddress, jm.chainParams)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool address: %w", err)
+	}
 
-	// Pool fee output
-	if poolFee > 0 && jm.cfg.PoolAddress != "" {
-		poolScript, err := AddrToScript(jm.cfg.PoolAddress)
-		if err != nil {
-			return nil, err
-		}
-		coinbaseTx.AddTxOut(wire.NewTxOut(poolFee, poolScript))
+	coinbaseTx := wire.NewMsgTx(wire.TxVersion)
+	prevOut := wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex)
+	coinbaseTx.AddTxIn(wire.NewTxIn(prevOut, script, nil))
+	coinbaseTx.AddTxOut(wire.NewTxOut(template.CoinbaseValue, poolScript))
+	if len(template.WitnessCommitment) > 0 {
+		coinbaseTx.AddTxOut(wire.NewTxOut(0, template.WitnessCommitment))
 	}
 
 	return coinbaseTx, nil
@@ -235,38 +347,60 @@ func generateNPUWork(height int32) []byte {
 	return work
 }
 
-// BuildCoinbaseScript builds the coinbase script.
-func BuildCoinbaseScript(height int32, extraNonce1, extraNonce2, poolSig string) []byte {
-	// Simplified coinbase script building
-	// In production, this would properly serialize all components
-
-	script := make([]byte, 0, 100)
-
-	// Add block height (BIP34)
-	heightBytes := make([]byte, 4)
-	heightBytes[0] = byte(height)
-	heightBytes[1] = byte(height >> 8)
-	heightBytes[2] = byte(height >> 16)
-	heightBytes[3] = byte(height >> 24)
-	script = append(script, byte(len(heightBytes)))
-	script = append(script, heightBytes...)
-
-	// Add extra nonces
-	en1, _ := hex.DecodeString(extraNonce1)
-	script = append(script, en1...)
-	en2, _ := hex.DecodeString(extraNonce2)
-	script = append(script, en2...)
-
-	// Add pool signature
-	script = append(script, byte(len(poolSig)))
-	script = append(script, []byte(poolSig)...)
-
-	return script
+// coinbaseScript returns the coinbase script of a block at the passed height
+// with a zero extranonce. The extranonce is pushed right after the height so
+// splitCoinbase can find it.
+func coinbaseScript(height int32) ([]byte, error) {
+	script, err := txscript.NewScriptBuilder().
+		AddInt64(int64(height)).
+		AddData(make([]byte, extranonce1Size+extranonce2Size)).
+		AddData([]byte(coinbaseFlags)).Script()
+	if err != nil {
+		return nil, err
+	}
+	if len(script) > blockchain.MaxCoinbaseScriptLen {
+		return nil, fmt.Errorf("coinbase script length of %d exceeds "+
+			"the maximum of %d", len(script),
+			blockchain.MaxCoinbaseScriptLen)
+	}
+	return script, nil
+}
+
+// splitCoinbase returns the serialized coinbase (without witness data) of a
+// block at the passed height split into the parts before and after the
+// extranonce. Miners rebuild the coinbase as
+// coinbase1 || extranonce1 || extranonce2 || coinbase2.
+func splitCoinbase(coinbaseTx *wire.MsgTx, height int32) ([]byte, []byte, error) {
+	heightScript, err := txscript.NewScriptBuilder().
+		AddInt64(int64(height)).Script()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(coinbaseTx.SerializeSizeStripped())
+	if err := coinbaseTx.SerializeNoWitness(&buf); err != nil {
+		return nil, nil, err
+	}
+
+	// The extranonce follows the version, input count, previous outpoint,
+	// script length, height push and the push opcode of the extranonce.
+	script := coinbaseTx.TxIn[0].SignatureScript
+	offset := 4 + wire.VarIntSerializeSize(uint64(len(coinbaseTx.TxIn))) +
+		36 + wire.VarIntSerializeSize(uint64(len(script))) +
+		len(heightScript) + 1
+	serialized := buf.Bytes()
+	end := offset + extranonce1Size + extranonce2Size
+	return serialized[:offset:offset], serialized[end:], nil
 }
 
-// AddrToScript converts an address string to a script.
-func AddrToScript(addr string) ([]byte, error) {
-	// This would decode the address and create the appropriate script
-	// For now, return a placeholder P2PKH script
-	return []byte{0x76, 0xa9, 0x14}, nil // OP_DUP OP_HASH160 <push 20>
+// AddrToScript returns the script paying to the passed address on the passed
+// network.
+func AddrToScript(addr string, params *chaincfg.Params) ([]byte, error) {
+	decoded, err := addresses.ParseShellAddress(addr, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return addresses.CreateShellScript(decoded)
 }
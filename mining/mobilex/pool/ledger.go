@@ -0,0 +1,466 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+)
+
+// Ledger key prefixes. Shares are keyed by a sequence number so the most
+// recent shares can be read back in order, blocks by height and hash.
+var (
+	sharePrefix   = []byte("s")
+	blockPrefix   = []byte("b")
+	balancePrefix = []byte("a")
+	payoutPrefix  = []byte("p")
+	seqKey        = []byte("nextseq")
+)
+
+// ErrUnknownBlock is returned when a block is not in the ledger.
+var ErrUnknownBlock = errors.New("block not found in ledger")
+
+// BlockStatus is the crediting state of a block found by the pool.
+type BlockStatus string
+
+const (
+	// BlockImmature is a found block whose coinbase has not matured.
+	BlockImmature BlockStatus = "immature"
+
+	// BlockMatured is a found block whose credits have been paid into
+	// miner balances.
+	BlockMatured BlockStatus = "matured"
+
+	// BlockOrphaned is a found block that left the main chain before
+	// maturing. Its credits are void.
+	BlockOrphaned BlockStatus = "orphaned"
+)
+
+// ShareRecord is an accepted share stored in the ledger.
+type ShareRecord struct {
+	Seq        uint64  `json:"seq"`
+	Account    string  `json:"account"`
+	Worker     string  `json:"worker"`
+	Difficulty float64 `json:"difficulty"`
+	NPU        bool    `json:"npu"`
+	Time       int64   `json:"time"`
+}
+
+// BlockRecord is a block found by the pool along with the miner credits it
+// pays once its coinbase matures.
+type BlockRecord struct {
+	Height  int32            `json:"height"`
+	Hash    chainhash.Hash   `json:"hash"`
+	Reward  int64            `json:"reward"`
+	Status  BlockStatus      `json:"status"`
+	Credits map[string]int64 `json:"credits"`
+}
+
+// Balance is a miner account's standing with the pool in satoshis.
+type Balance struct {
+	Immature int64 `json:"immature"` // Credits from unmatured blocks
+	Mature   int64 `json:"mature"`   // Credits awaiting payout
+	Paid     int64 `json:"paid"`     // Total paid out
+}
+
+// PayoutRecord is a payout transaction and the amounts it pays.
+type PayoutRecord struct {
+	TxHash  chainhash.Hash   `json:"txhash"`
+	Amounts map[string]int64 `json:"amounts"`
+	Fee     int64            `json:"fee"`
+	Time    int64            `json:"time"`
+}
+
+// ShareLedger persists accepted shares, found blocks and miner balances in
+// a goleveldb database.
+type ShareLedger struct {
+	db *leveldb.DB
+
+	// mu serializes read-modify-write updates of balances and blocks
+	mu      sync.Mutex
+	nextSeq uint64
+
+	// prunedSeq is the sequence number below which shares are known to be
+	// pruned, so pruning does not rescan deleted shares
+	prunedSeq uint64
+}
+
+// OpenShareLedger opens the ledger at path, creating it if needed.
+func OpenShareLedger(path string) (*ShareLedger, error) {
+	db, err := leveldb.OpenFile(path, &opt.Options{
+		Compression: opt.NoCompression,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open share ledger: %w", err)
+	}
+
+	l := &ShareLedger{db: db}
+	seq, err := db.Get(seqKey, nil)
+	switch {
+	case err == nil && len(seq) == 8:
+		l.nextSeq = binary.BigEndian.Uint64(seq)
+	case err != nil && err != leveldb.ErrNotFound:
+		db.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Close closes the ledger database.
+func (l *ShareLedger) Close() error {
+	return l.db.Close()
+}
+
+// AddShare records an accepted share, assigning it the next sequence number.
+func (l *ShareLedger) AddShare(share *ShareRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	share.Seq = l.nextSeq
+	value, err := json.Marshal(share)
+	if err != nil {
+		return err
+	}
+
+	var seq [8]byte
+	binary.BigEndian.PutUint64(seq[:], share.Seq+1)
+
+	batch := new(leveldb.Batch)
+	batch.Put(shareKey(share.Seq), value)
+	batch.Put(seqKey, seq[:])
+	if err := l.db.Write(batch, nil); err != nil {
+		return err
+	}
+	l.nextSeq++
+
+	return nil
+}
+
+// RecentShares returns up to n of the most recent shares, newest first.
+func (l *ShareLedger) RecentShares(n int) ([]*ShareRecord, error) {
+	iter := l.db.NewIterator(util.BytesPrefix(sharePrefix), nil)
+	defer iter.Release()
+
+	var shares []*ShareRecord
+	for ok := iter.Last(); ok && len(shares) < n; ok = iter.Prev() {
+		var share ShareRecord
+		if err := json.Unmarshal(iter.Value(), &share); err != nil {
+			return nil, err
+		}
+		shares = append(shares, &share)
+	}
+
+	return shares, iter.Error()
+}
+
+// PruneShares deletes all but the keep most recent shares and returns the
+// deleted shares, oldest first.
+func (l *ShareLedger) PruneShares(keep int) ([]*ShareRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if keep < 0 || uint64(keep) >= l.nextSeq {
+		return nil, nil
+	}
+	end := l.nextSeq - uint64(keep)
+	if end <= l.prunedSeq {
+		return nil, nil
+	}
+
+	iter := l.db.NewIterator(&util.Range{
+		Start: shareKey(l.prunedSeq),
+		Limit: shareKey(end),
+	}, nil)
+	defer iter.Release()
+
+	var pruned []*ShareRecord
+	batch := new(leveldb.Batch)
+	for iter.Next() {
+		var share ShareRecord
+		if err := json.Unmarshal(iter.Value(), &share); err != nil {
+			return nil, err
+		}
+		pruned = append(pruned, &share)
+		batch.Delete(iter.Key())
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	if err := l.db.Write(batch, nil); err != nil {
+		return nil, err
+	}
+	l.prunedSeq = end
+
+	return pruned, nil
+}
+
+// AddBlock records a found block, crediting its miners as immature.
+func (l *ShareLedger) AddBlock(block *BlockRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	block.Status = BlockImmature
+	batch := new(leveldb.Batch)
+	if err := l.putBlock(batch, block); err != nil {
+		return err
+	}
+	err := l.updateBalances(batch, block.Credits, func(b *Balance, amt int64) {
+		b.Immature += amt
+	})
+	if err != nil {
+		return err
+	}
+
+	return l.db.Write(batch, nil)
+}
+
+// ImmatureBlocks returns the found blocks whose coinbase has not matured,
+// lowest height first.
+func (l *ShareLedger) ImmatureBlocks() ([]*BlockRecord, error) {
+	iter := l.db.NewIterator(util.BytesPrefix(blockPrefix), nil)
+	defer iter.Release()
+
+	var blocks []*BlockRecord
+	for iter.Next() {
+		var block BlockRecord
+		if err := json.Unmarshal(iter.Value(), &block); err != nil {
+			return nil, err
+		}
+		if block.Status == BlockImmature {
+			blocks = append(blocks, &block)
+		}
+	}
+
+	return blocks, iter.Error()
+}
+
+// RecentBlocks returns up to n of the most recently found blocks, highest
+// first.
+func (l *ShareLedger) RecentBlocks(n int) ([]*BlockRecord, error) {
+	iter := l.db.NewIterator(util.BytesPrefix(blockPrefix), nil)
+	defer iter.Release()
+
+	var blocks []*BlockRecord
+	for ok := iter.Last(); ok && len(blocks) < n; ok = iter.Prev() {
+		var block BlockRecord
+		if err := json.Unmarshal(iter.Value(), &block); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, &block)
+	}
+
+	return blocks, iter.Error()
+}
+
+// Block returns the found block at height with the given hash.
+func (l *ShareLedger) Block(height int32, hash *chainhash.Hash) (*BlockRecord, error) {
+	value, err := l.db.Get(blockKey(height, hash), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, ErrUnknownBlock
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var block BlockRecord
+	if err := json.Unmarshal(value, &block); err != nil {
+		return nil, err
+	}
+
+	return &block, nil
+}
+
+// SetBlockStatus moves an immature block to matured or orphaned, moving its
+// credits into mature balances or voiding them.
+func (l *ShareLedger) SetBlockStatus(height int32, hash *chainhash.Hash, status BlockStatus) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	block, err := l.Block(height, hash)
+	if err != nil {
+		return err
+	}
+	if block.Status != BlockImmature {
+		return fmt.Errorf("block %s is already %s", hash, block.Status)
+	}
+
+	block.Status = status
+	batch := new(leveldb.Batch)
+	if err := l.putBlock(batch, block); err != nil {
+		return err
+	}
+	err = l.updateBalances(batch, block.Credits, func(b *Balance, amt int64) {
+		b.Immature -= amt
+		if status == BlockMatured {
+			b.Mature += amt
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return l.db.Write(batch, nil)
+}
+
+// Credit adds amounts directly to the mature balances of accounts.
+func (l *ShareLedger) Credit(amounts map[string]int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	batch := new(leveldb.Batch)
+	err := l.updateBalances(batch, amounts, func(b *Balance, amt int64) {
+		b.Mature += amt
+	})
+	if err != nil {
+		return err
+	}
+
+	return l.db.Write(batch, nil)
+}
+
+// Balance returns the balance of an account.
+func (l *ShareLedger) Balance(account string) (*Balance, error) {
+	value, err := l.db.Get(balanceKey(account), nil)
+	if err == leveldb.ErrNotFound {
+		return &Balance{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var balance Balance
+	if err := json.Unmarshal(value, &balance); err != nil {
+		return nil, err
+	}
+
+	return &balance, nil
+}
+
+// Balances returns the balances of all accounts.
+func (l *ShareLedger) Balances() (map[string]*Balance, error) {
+	iter := l.db.NewIterator(util.BytesPrefix(balancePrefix), nil)
+	defer iter.Release()
+
+	balances := make(map[string]*Balance)
+	for iter.Next() {
+		var balance Balance
+		if err := json.Unmarshal(iter.Value(), &balance); err != nil {
+			return nil, err
+		}
+		account := string(iter.Key()[len(balancePrefix):])
+		balances[account] = &balance
+	}
+
+	return balances, iter.Error()
+}
+
+// RecordPayout stores a sent payout and moves its amounts from mature
+// balances to paid.
+func (l *ShareLedger) RecordPayout(payout *PayoutRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Check every balance covers its amount before changing any.
+	for account, amount := range payout.Amounts {
+		balance, err := l.Balance(account)
+		if err != nil {
+			return err
+		}
+		if balance.Mature < amount {
+			return fmt.Errorf("payout of %d to %s exceeds mature "+
+				"balance %d", amount, account, balance.Mature)
+		}
+	}
+
+	value, err := json.Marshal(payout)
+	if err != nil {
+		return err
+	}
+
+	batch := new(leveldb.Batch)
+	batch.Put(append(append([]byte{}, payoutPrefix...), payout.TxHash[:]...),
+		value)
+	err = l.updateBalances(batch, payout.Amounts, func(b *Balance, amt int64) {
+		b.Mature -= amt
+		b.Paid += amt
+	})
+	if err != nil {
+		return err
+	}
+
+	return l.db.Write(batch, nil)
+}
+
+// putBlock adds a block record to batch.
+func (l *ShareLedger) putBlock(batch *leveldb.Batch, block *BlockRecord) error {
+	value, err := json.Marshal(block)
+	if err != nil {
+		return err
+	}
+	batch.Put(blockKey(block.Height, &block.Hash), value)
+
+	return nil
+}
+
+// updateBalances applies update to the balance of every account in amounts,
+// adding the results to batch. Accounts are visited in sorted order so the
+// batch is deterministic.
+func (l *ShareLedger) updateBalances(batch *leveldb.Batch, amounts map[string]int64,
+	update func(*Balance, int64)) error {
+
+	accounts := make([]string, 0, len(amounts))
+	for account := range amounts {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+
+	for _, account := range accounts {
+		balance, err := l.Balance(account)
+		if err != nil {
+			return err
+		}
+		update(balance, amounts[account])
+
+		value, err := json.Marshal(balance)
+		if err != nil {
+			return err
+		}
+		batch.Put(balanceKey(account), value)
+	}
+
+	return nil
+}
+
+// shareKey returns the ledger key of the share with sequence number seq.
+func shareKey(seq uint64) []byte {
+	key := make([]byte, len(sharePrefix)+8)
+	copy(key, sharePrefix)
+	binary.BigEndian.PutUint64(key[len(sharePrefix):], seq)
+	return key
+}
+
+// blockKey returns the ledger key of a found block.
+func blockKey(height int32, hash *chainhash.Hash) []byte {
+	key := make([]byte, len(blockPrefix)+4+chainhash.HashSize)
+	copy(key, blockPrefix)
+	binary.BigEndian.PutUint32(key[len(blockPrefix):], uint32(height))
+	copy(key[len(blockPrefix)+4:], hash[:])
+	return key
+}
+
+// balanceKey returns the ledger key of an account balance.
+func balanceKey(account string) []byte {
+	return append(append([]byte{}, balancePrefix...), account...)
+}
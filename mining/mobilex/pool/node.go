@@ -0,0 +1,234 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/toole-brendan/shell/blockchain"
+	"github.com/toole-brendan/shell/btcjson"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// ErrBlockRejected is returned when the node rejects a submitted block.
+var ErrBlockRejected = errors.New("block rejected")
+
+// templateCapabilities are the getblocktemplate capabilities requested by
+// the pool. The pool only uses getblocktemplate to long poll for new work.
+var templateCapabilities = []string{"coinbasevalue", "longpoll"}
+
+// NodeClient is a minimal JSON-RPC client for the Shell node's mining calls.
+type NodeClient struct {
+	url        string
+	user       string
+	pass       string
+	httpClient *http.Client
+	nextID     uint64
+}
+
+// NewNodeClient creates a client for the node configured in cfg.
+func NewNodeClient(cfg *PoolConfig) *NodeClient {
+	host := net.JoinHostPort(cfg.NodeRPCHost, strconv.Itoa(cfg.NodeRPCPort))
+
+	return &NodeClient{
+		url:  "http://" + host,
+		user: cfg.NodeRPCUser,
+		pass: cfg.NodeRPCPass,
+
+		// Long poll requests block until the node has new work, so no
+		// overall timeout is set. Callers bound requests via context.
+		httpClient: &http.Client{},
+	}
+}
+
+// GetBlockTemplate requests a MobileX block template from the node. When
+// longPollID is set the call blocks until the template it identifies is
+// replaced.
+//
+// The template comes from getmobileblocktemplate, whose version and bits are
+// those of MobileX blocks once MobileX is active, unlike the RandomX ones of
+// getblocktemplate. getmobileblocktemplate has no long poll, so the long poll
+// of getblocktemplate signals new work and provides the long poll ID.
+func (c *NodeClient) GetBlockTemplate(ctx context.Context, longPollID string) (*BlockTemplate, error) {
+	pollCmd := btcjson.NewGetBlockTemplateCmd(&btcjson.TemplateRequest{
+		Mode:         "template",
+		Capabilities: templateCapabilities,
+		LongPollID:   longPollID,
+		Rules:        []string{"segwit"},
+	})
+
+	var poll btcjson.GetBlockTemplateResult
+	if err := c.call(ctx, pollCmd, &poll); err != nil {
+		return nil, err
+	}
+
+	// A template that changes between the two calls is newer than the
+	// long poll ID, so the next long poll returns right away.
+	cmd := &btcjson.GetMobileBlockTemplateCmd{
+		Request: &btcjson.MobileTemplateRequest{Mode: "template"},
+	}
+
+	var result btcjson.GetMobileBlockTemplateResult
+	if err := c.call(ctx, cmd, &result); err != nil {
+		return nil, err
+	}
+
+	template, err := templateFromResult(&result)
+	if err != nil {
+		return nil, err
+	}
+	template.LongPollID = poll.LongPollID
+
+	return template, nil
+}
+
+// SubmitBlock submits a solved block to the node. A block the node does not
+// accept is reported as ErrBlockRejected wrapping the node's reason.
+func (c *NodeClient) SubmitBlock(ctx context.Context, block *wire.MsgBlock) error {
+	var buf bytes.Buffer
+	if err := block.Serialize(&buf); err != nil {
+		return err
+	}
+
+	cmd := btcjson.NewSubmitBlockCmd(hex.EncodeToString(buf.Bytes()), nil)
+
+	// The node returns null on success and the rejection reason otherwise.
+	var reason *string
+	if err := c.call(ctx, cmd, &reason); err != nil {
+		return err
+	}
+	if reason != nil {
+		return fmt.Errorf("%w: %s", ErrBlockRejected, *reason)
+	}
+
+	return nil
+}
+
+// GetBlockHash returns the hash of the main chain block at height.
+func (c *NodeClient) GetBlockHash(ctx context.Context, height int32) (*chainhash.Hash, error) {
+	var hash string
+	if err := c.call(ctx, btcjson.NewGetBlockHashCmd(int64(height)), &hash); err != nil {
+		return nil, err
+	}
+
+	return chainhash.NewHashFromStr(hash)
+}
+
+// call issues cmd to the node and unmarshals the result into result.
+func (c *NodeClient) call(ctx context.Context, cmd interface{}, result interface{}) error {
+	id := atomic.AddUint64(&c.nextID, 1)
+	body, err := btcjson.MarshalCmd(btcjson.RpcVersion1, id, cmd)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url,
+		bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(c.user, c.pass)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// The node reports RPC errors in the body, which may come with a non
+	// 200 status, so only fail on the status when the body is not a
+	// JSON-RPC response.
+	var rpcResp btcjson.Response
+	if err := json.Unmarshal(respBody, &rpcResp); err != nil {
+		return fmt.Errorf("node returned status %s: %s", resp.Status,
+			bytes.TrimSpace(respBody))
+	}
+	if rpcResp.Error != nil {
+		return rpcResp.Error
+	}
+
+	return json.Unmarshal(rpcResp.Result, result)
+}
+
+// templateFromResult converts a getmobileblocktemplate result into a
+// template. The pool builds its own coinbase, so the coinbase parts of the
+// result are not used.
+func templateFromResult(result *btcjson.GetMobileBlockTemplateResult) (*BlockTemplate, error) {
+	if result.CoinbaseValue <= 0 {
+		return nil, errors.New("block template is missing the coinbase value")
+	}
+
+	prevHash, err := chainhash.NewHashFromStr(result.PreviousHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid previous block hash: %w", err)
+	}
+
+	bits, err := strconv.ParseUint(result.Bits, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bits %q: %w", result.Bits, err)
+	}
+
+	target := blockchain.CompactToBig(uint32(bits))
+	if result.Target != "" {
+		var ok bool
+		target, ok = new(big.Int).SetString(result.Target, 16)
+		if !ok {
+			return nil, fmt.Errorf("invalid target %q", result.Target)
+		}
+	}
+
+	var witnessCommitment []byte
+	if result.DefaultWitnessCommitment != "" {
+		witnessCommitment, err = hex.DecodeString(result.DefaultWitnessCommitment)
+		if err != nil {
+			return nil, fmt.Errorf("invalid witness commitment: %w", err)
+		}
+	}
+
+	txns := make([]*wire.MsgTx, 0, len(result.Transactions))
+	for i, txResult := range result.Transactions {
+		serialized, err := hex.DecodeString(txResult.Data)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+
+		var tx wire.MsgTx
+		if err := tx.Deserialize(bytes.NewReader(serialized)); err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		txns = append(txns, &tx)
+	}
+
+	return &BlockTemplate{
+		Height:            int32(result.Height),
+		Version:           result.Version,
+		PreviousBlock:     *prevHash,
+		Bits:              uint32(bits),
+		Transactions:      txns,
+		CoinbaseValue:     result.CoinbaseValue,
+		Target:            target,
+		WitnessCommitment: witnessCommitment,
+		MinTime:           result.MinTime,
+		CurTime:           result.CurTime,
+	}, nil
+}
@@ -0,0 +1,247 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/shell/btcjson"
+	"github.com/toole-brendan/shell/chaincfg"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// stubNode is a local stand-in for the node's mining RPCs. Templates are
+// served in order, each long poll moving on to the next one.
+type stubNode struct {
+	mu        sync.Mutex
+	templates []btcjson.GetMobileBlockTemplateResult
+	current   int
+	next      chan struct{}
+	submitted []string
+	reject    string
+}
+
+func (n *stubNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var req btcjson.Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var result interface{}
+	switch req.Method {
+	case "getblocktemplate":
+		var request btcjson.TemplateRequest
+		if err := json.Unmarshal(req.Params[0], &request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		idx := 0
+		if request.LongPollID != "" {
+			idx, _ = strconv.Atoi(request.LongPollID)
+
+			// Block until the test releases the next template.
+			select {
+			case <-n.next:
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		n.mu.Lock()
+		if idx >= len(n.templates) {
+			idx = len(n.templates) - 1
+		}
+		n.current = idx
+		n.mu.Unlock()
+
+		result = btcjson.GetBlockTemplateResult{
+			LongPollID: strconv.Itoa(idx + 1),
+		}
+
+	case "getmobileblocktemplate":
+		n.mu.Lock()
+		result = n.templates[n.current]
+		n.mu.Unlock()
+
+	case "submitblock":
+		var hexBlock string
+		if err := json.Unmarshal(req.Params[0], &hexBlock); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		n.mu.Lock()
+		n.submitted = append(n.submitted, hexBlock)
+		if n.reject != "" {
+			result = "rejected: " + n.reject
+		}
+		n.mu.Unlock()
+
+	default:
+		result = nil
+	}
+
+	resp, _ := btcjson.MarshalResponse(btcjson.RpcVersion1, req.ID, result, nil)
+	w.Write(resp)
+}
+
+// newStubNode starts a stub node and returns a pool config pointing at it.
+func newStubNode(t *testing.T, templates ...btcjson.GetMobileBlockTemplateResult) (*stubNode, *PoolConfig) {
+	node := &stubNode{templates: templates, next: make(chan struct{})}
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	cfg := DefaultPoolConfig()
+	cfg.NodeRPCHost = host
+	cfg.NodeRPCPort, _ = strconv.Atoi(port)
+	cfg.NodeRPCUser = "user"
+	cfg.NodeRPCPass = "pass"
+	cfg.ConnectionTimeout = 5 * time.Second
+	cfg.PoolAddress = testAccount(t, 0xff)
+
+	return node, cfg
+}
+
+// testTemplate returns a template result building on prevHash.
+func testTemplate(height int64, prevHash chainhash.Hash, txns ...*wire.MsgTx) btcjson.GetMobileBlockTemplateResult {
+	result := btcjson.GetMobileBlockTemplateResult{
+		Bits:          "1d00ffff",
+		CurTime:       time.Now().Unix(),
+		Height:        height,
+		PreviousHash:  prevHash.String(),
+		Version:       4,
+		CoinbaseValue: 95 * 1e8,
+		Target:        "00000000ffff0000000000000000000000000000000000000000000000000000",
+		MinTime:       time.Now().Unix() - 600,
+	}
+
+	for _, tx := range txns {
+		var buf bytes.Buffer
+		tx.Serialize(&buf)
+		result.Transactions = append(result.Transactions,
+			btcjson.GetMobileBlockTemplateResultTx{
+				Data: hex.EncodeToString(buf.Bytes()),
+				TxID: tx.TxHash().String(),
+			})
+	}
+
+	return result
+}
+
+// TestNodeClient tests fetching templates from and submitting blocks to a
+// node
+func TestNodeClient(t *testing.T) {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0x01}, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{0x51}))
+
+	prevHash := chainhash.Hash{0xaa}
+	node, cfg := newStubNode(t, testTemplate(1000, prevHash, tx))
+	client := NewNodeClient(cfg)
+
+	template, err := client.GetBlockTemplate(context.Background(), "")
+	require.NoError(t, err)
+	require.Equal(t, int32(1000), template.Height)
+	require.Equal(t, int32(4), template.Version)
+	require.Equal(t, prevHash, template.PreviousBlock)
+	require.Equal(t, uint32(0x1d00ffff), template.Bits)
+	require.Equal(t, int64(95*1e8), template.CoinbaseValue)
+	require.Equal(t, "1", template.LongPollID)
+	require.Len(t, template.Transactions, 1)
+	require.Equal(t, tx.TxHash(), template.Transactions[0].TxHash())
+
+	block := &wire.MsgBlock{Header: wire.BlockHeader{PrevBlock: prevHash}}
+	require.NoError(t, client.SubmitBlock(context.Background(), block))
+
+	node.mu.Lock()
+	require.Len(t, node.submitted, 1)
+	node.reject = "duplicate"
+	node.mu.Unlock()
+
+	err = client.SubmitBlock(context.Background(), block)
+	require.True(t, errors.Is(err, ErrBlockRejected))
+	require.Contains(t, err.Error(), "duplicate")
+
+	// Wrong credentials surface as an error.
+	cfg.NodeRPCPass = "wrong"
+	_, err = NewNodeClient(cfg).GetBlockTemplate(context.Background(), "")
+	require.Error(t, err)
+}
+
+// TestJobManagerLongPoll tests that the job manager follows the node's long
+// poll and only asks miners to drop their jobs on a new tip
+func TestJobManagerLongPoll(t *testing.T) {
+	tipA := chainhash.Hash{0x0a}
+	tipB := chainhash.Hash{0x0b}
+	node, cfg := newStubNode(t,
+		testTemplate(100, tipA),
+		testTemplate(100, tipA),
+		testTemplate(101, tipB),
+	)
+
+	jm := NewJobManager(cfg, &chaincfg.MainNetParams)
+	jobs := make(chan *MiningJob, 3)
+	jm.SetJobHandler(func(job *MiningJob) { jobs <- job })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go jm.Start(ctx, &wg)
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	nextJob := func() *MiningJob {
+		select {
+		case job := <-jobs:
+			return job
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for job")
+			return nil
+		}
+	}
+
+	job := nextJob()
+	require.Equal(t, int32(100), job.Height)
+	require.True(t, job.CleanJobs)
+	require.Equal(t, job, jm.GetCurrentJob())
+
+	// Updated transactions on the same tip keep existing jobs valid.
+	node.next <- struct{}{}
+	job = nextJob()
+	require.Equal(t, tipA.String(), job.PreviousHash)
+	require.False(t, job.CleanJobs)
+
+	// A new tip replaces them.
+	node.next <- struct{}{}
+	job = nextJob()
+	require.Equal(t, int32(101), job.Height)
+	require.Equal(t, tipB.String(), job.PreviousHash)
+	require.True(t, job.CleanJobs)
+}
@@ -0,0 +1,430 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// The binary protocol runs over Noise_NK_25519_ChaChaPoly_SHA256. Miners
+// know the pool's static public key ahead of time, so the handshake
+// authenticates the pool and a man in the middle cannot hijack the
+// connection or redirect hashrate.
+const (
+	noiseProtocolName = "Noise_NK_25519_ChaChaPoly_SHA256"
+
+	// noisePrologue binds the handshake to the Shell mining protocol.
+	noisePrologue = "Shell MobileX mining"
+
+	// NoiseKeySize is the size of Noise public and private keys.
+	NoiseKeySize = 32
+
+	// noiseTagSize is the size of the authentication tag of an
+	// encrypted message.
+	noiseTagSize = chacha20poly1305.Overhead
+
+	// MaxNoiseMessageSize is the largest Noise message on the wire.
+	MaxNoiseMessageSize = 65535
+
+	// MaxNoisePayloadSize is the largest plaintext carried by one
+	// encrypted frame.
+	MaxNoisePayloadSize = MaxNoiseMessageSize - noiseTagSize
+)
+
+var (
+	// ErrNoiseHandshake is returned when the Noise handshake fails, for
+	// example because the pool does not hold the expected static key.
+	ErrNoiseHandshake = errors.New("noise handshake failed")
+
+	// ErrNoiseDecrypt is returned for frames that fail authentication.
+	ErrNoiseDecrypt = errors.New("noise frame failed authentication")
+
+	// errNoiseNonceExhausted is returned when a cipher state has used
+	// all of its nonces.
+	errNoiseNonceExhausted = errors.New("noise nonces exhausted")
+)
+
+// noiseCipherState is a Noise CipherState.
+type noiseCipherState struct {
+	aead  cipher.AEAD
+	nonce uint64
+}
+
+// newNoiseCipherState creates a cipher state keyed with k.
+func newNoiseCipherState(k []byte) *noiseCipherState {
+	aead, err := chacha20poly1305.New(k)
+	if err != nil {
+		// The key is always 32 bytes.
+		panic(err)
+	}
+	return &noiseCipherState{aead: aead}
+}
+
+// nextNonce returns the next nonce in the ChaChaPoly encoding.
+func (cs *noiseCipherState) nextNonce() ([]byte, error) {
+	if cs.nonce == ^uint64(0) {
+		return nil, errNoiseNonceExhausted
+	}
+
+	var nonce [chacha20poly1305.NonceSize]byte
+	binary.LittleEndian.PutUint64(nonce[4:], cs.nonce)
+	cs.nonce++
+
+	return nonce[:], nil
+}
+
+// encrypt encrypts plaintext with associated data ad.
+func (cs *noiseCipherState) encrypt(ad, plaintext []byte) ([]byte, error) {
+	nonce, err := cs.nextNonce()
+	if err != nil {
+		return nil, err
+	}
+	return cs.aead.Seal(nil, nonce, plaintext, ad), nil
+}
+
+// decrypt decrypts ciphertext with associated data ad.
+func (cs *noiseCipherState) decrypt(ad, ciphertext []byte) ([]byte, error) {
+	nonce, err := cs.nextNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := cs.aead.Open(nil, nonce, ciphertext, ad)
+	if err != nil {
+		return nil, ErrNoiseDecrypt
+	}
+	return plaintext, nil
+}
+
+// noiseSymmetricState is a Noise SymmetricState.
+type noiseSymmetricState struct {
+	ck [sha256.Size]byte
+	h  [sha256.Size]byte
+	cs *noiseCipherState
+}
+
+// newNoiseSymmetricState initializes the symmetric state for the protocol.
+func newNoiseSymmetricState() *noiseSymmetricState {
+	ss := &noiseSymmetricState{}
+
+	// The protocol name is exactly the hash length so it is used as is.
+	copy(ss.h[:], noiseProtocolName)
+	ss.ck = ss.h
+	ss.mixHash([]byte(noisePrologue))
+
+	return ss
+}
+
+// mixHash mixes data into the handshake hash.
+func (ss *noiseSymmetricState) mixHash(data []byte) {
+	h := sha256.New()
+	h.Write(ss.h[:])
+	h.Write(data)
+	h.Sum(ss.h[:0])
+}
+
+// mixKey mixes DH output into the chaining key and rekeys the cipher.
+func (ss *noiseSymmetricState) mixKey(ikm []byte) {
+	ck, k := noiseHKDF(ss.ck[:], ikm)
+	copy(ss.ck[:], ck)
+	ss.cs = newNoiseCipherState(k)
+}
+
+// encryptAndHash encrypts a handshake payload and mixes the result into the
+// handshake hash.
+func (ss *noiseSymmetricState) encryptAndHash(plaintext []byte) ([]byte, error) {
+	if ss.cs == nil {
+		ss.mixHash(plaintext)
+		return plaintext, nil
+	}
+
+	ciphertext, err := ss.cs.encrypt(ss.h[:], plaintext)
+	if err != nil {
+		return nil, err
+	}
+	ss.mixHash(ciphertext)
+
+	return ciphertext, nil
+}
+
+// decryptAndHash decrypts a handshake payload and mixes the ciphertext into
+// the handshake hash.
+func (ss *noiseSymmetricState) decryptAndHash(ciphertext []byte) ([]byte, error) {
+	if ss.cs == nil {
+		ss.mixHash(ciphertext)
+		return ciphertext, nil
+	}
+
+	plaintext, err := ss.cs.decrypt(ss.h[:], ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	ss.mixHash(ciphertext)
+
+	return plaintext, nil
+}
+
+// split returns the initiator and responder transport cipher states.
+func (ss *noiseSymmetricState) split() (*noiseCipherState, *noiseCipherState) {
+	k1, k2 := noiseHKDF(ss.ck[:], nil)
+	return newNoiseCipherState(k1), newNoiseCipherState(k2)
+}
+
+// noiseHKDF is the two output HKDF of the Noise specification.
+func noiseHKDF(ck, ikm []byte) ([]byte, []byte) {
+	mac := hmac.New(sha256.New, ck)
+	mac.Write(ikm)
+	tempKey := mac.Sum(nil)
+
+	mac = hmac.New(sha256.New, tempKey)
+	mac.Write([]byte{0x01})
+	out1 := mac.Sum(nil)
+
+	mac = hmac.New(sha256.New, tempKey)
+	mac.Write(out1)
+	mac.Write([]byte{0x02})
+	out2 := mac.Sum(nil)
+
+	return out1, out2
+}
+
+// NoiseConn is an encrypted connection carrying length prefixed frames.
+type NoiseConn struct {
+	conn net.Conn
+
+	readMtx sync.Mutex
+	recv    *noiseCipherState
+
+	writeMtx sync.Mutex
+	send     *noiseCipherState
+}
+
+// NoiseClient performs the initiator side of the handshake over conn,
+// authenticating the pool by its static public key.
+func NoiseClient(conn net.Conn, serverKey []byte) (*NoiseConn, error) {
+	rs, err := ecdh.X25519().NewPublicKey(serverKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid server key: %v",
+			ErrNoiseHandshake, err)
+	}
+
+	e, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	ss := newNoiseSymmetricState()
+	ss.mixHash(rs.Bytes())
+
+	// -> e, es
+	ss.mixHash(e.PublicKey().Bytes())
+	es, err := e.ECDH(rs)
+	if err != nil {
+		return nil, err
+	}
+	ss.mixKey(es)
+	payload, err := ss.encryptAndHash(nil)
+	if err != nil {
+		return nil, err
+	}
+	msg := append(e.PublicKey().Bytes(), payload...)
+	if err := writeNoiseMessage(conn, msg); err != nil {
+		return nil, err
+	}
+
+	// <- e, ee
+	msg, err = readNoiseMessage(conn)
+	if err != nil {
+		return nil, err
+	}
+	if len(msg) != NoiseKeySize+noiseTagSize {
+		return nil, ErrNoiseHandshake
+	}
+	re, err := ecdh.X25519().NewPublicKey(msg[:NoiseKeySize])
+	if err != nil {
+		return nil, ErrNoiseHandshake
+	}
+	ss.mixHash(re.Bytes())
+	ee, err := e.ECDH(re)
+	if err != nil {
+		return nil, ErrNoiseHandshake
+	}
+	ss.mixKey(ee)
+	if _, err := ss.decryptAndHash(msg[NoiseKeySize:]); err != nil {
+		return nil, ErrNoiseHandshake
+	}
+
+	send, recv := ss.split()
+	return &NoiseConn{conn: conn, send: send, recv: recv}, nil
+}
+
+// NoiseServer performs the responder side of the handshake over conn using
+// the pool's static key.
+func NoiseServer(conn net.Conn, staticKey *ecdh.PrivateKey) (*NoiseConn, error) {
+	ss := newNoiseSymmetricState()
+	ss.mixHash(staticKey.PublicKey().Bytes())
+
+	// -> e, es
+	msg, err := readNoiseMessage(conn)
+	if err != nil {
+		return nil, err
+	}
+	if len(msg) != NoiseKeySize+noiseTagSize {
+		return nil, ErrNoiseHandshake
+	}
+	re, err := ecdh.X25519().NewPublicKey(msg[:NoiseKeySize])
+	if err != nil {
+		return nil, ErrNoiseHandshake
+	}
+	ss.mixHash(re.Bytes())
+	es, err := staticKey.ECDH(re)
+	if err != nil {
+		return nil, ErrNoiseHandshake
+	}
+	ss.mixKey(es)
+	if _, err := ss.decryptAndHash(msg[NoiseKeySize:]); err != nil {
+		return nil, ErrNoiseHandshake
+	}
+
+	// <- e, ee
+	e, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	ss.mixHash(e.PublicKey().Bytes())
+	ee, err := e.ECDH(re)
+	if err != nil {
+		return nil, ErrNoiseHandshake
+	}
+	ss.mixKey(ee)
+	payload, err := ss.encryptAndHash(nil)
+	if err != nil {
+		return nil, err
+	}
+	msg = append(e.PublicKey().Bytes(), payload...)
+	if err := writeNoiseMessage(conn, msg); err != nil {
+		return nil, err
+	}
+
+	recv, send := ss.split()
+	return &NoiseConn{conn: conn, send: send, recv: recv}, nil
+}
+
+// ReadFrame reads and decrypts the next frame.
+func (c *NoiseConn) ReadFrame() ([]byte, error) {
+	c.readMtx.Lock()
+	defer c.readMtx.Unlock()
+
+	msg, err := readNoiseMessage(c.conn)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.recv.decrypt(nil, msg)
+}
+
+// WriteFrame encrypts and writes a frame.
+func (c *NoiseConn) WriteFrame(frame []byte) error {
+	if len(frame) > MaxNoisePayloadSize {
+		return fmt.Errorf("frame of %d bytes exceeds maximum of %d",
+			len(frame), MaxNoisePayloadSize)
+	}
+
+	c.writeMtx.Lock()
+	defer c.writeMtx.Unlock()
+
+	msg, err := c.send.encrypt(nil, frame)
+	if err != nil {
+		return err
+	}
+
+	return writeNoiseMessage(c.conn, msg)
+}
+
+// Conn returns the underlying connection.
+func (c *NoiseConn) Conn() net.Conn {
+	return c.conn
+}
+
+// Close closes the underlying connection.
+func (c *NoiseConn) Close() error {
+	return c.conn.Close()
+}
+
+// readNoiseMessage reads a message prefixed by its big endian 16 bit length.
+func readNoiseMessage(r io.Reader) ([]byte, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+
+	msg := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// writeNoiseMessage writes a message prefixed by its big endian 16 bit
+// length.
+func writeNoiseMessage(w io.Writer, msg []byte) error {
+	if len(msg) > MaxNoiseMessageSize {
+		return fmt.Errorf("noise message of %d bytes exceeds maximum of %d",
+			len(msg), MaxNoiseMessageSize)
+	}
+
+	buf := make([]byte, 2+len(msg))
+	binary.BigEndian.PutUint16(buf, uint16(len(msg)))
+	copy(buf[2:], msg)
+
+	_, err := w.Write(buf)
+	return err
+}
+
+// LoadOrCreateNoiseKey loads the pool's static Noise key from path, a hex
+// encoded X25519 private key, generating and saving a new key if the file
+// does not exist.
+func LoadOrCreateNoiseKey(path string) (*ecdh.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		raw, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid noise key file %s: %w", path,
+				err)
+		}
+		return ecdh.X25519().NewPrivateKey(raw)
+
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	encoded := hex.EncodeToString(key.Bytes()) + "\n"
+	if err := os.WriteFile(path, []byte(encoded), 0600); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
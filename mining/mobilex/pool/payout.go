@@ -0,0 +1,393 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/toole-brendan/shell/addresses"
+	"github.com/toole-brendan/shell/chaincfg"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// Payout schemes supported by the pool.
+const (
+	// PayoutPPLNS splits each found block between the last N shares once
+	// the block's coinbase matures.
+	PayoutPPLNS = "pplns"
+
+	// PayoutPPS pays a fixed expected value for every accepted share,
+	// with the pool keeping the rewards of the blocks it finds. The NPU
+	// bonus is normalized over the last N shares so that it moves rewards
+	// to NPU miners without raising the total paid.
+	PayoutPPS = "pps"
+)
+
+const (
+	// maxPayoutOutputs is the number of miners paid by one payout
+	// transaction. Remaining miners are paid by the next payout.
+	maxPayoutOutputs = 500
+
+	// payoutInputSigSize is the estimated signature script size of a
+	// payout input, used to size the fee before the pool wallet signs.
+	payoutInputSigSize = 107
+)
+
+// ErrInsufficientPayoutFunds is returned when the inputs given to the payout
+// builder cannot cover the payouts and fee.
+var ErrInsufficientPayoutFunds = errors.New("insufficient funds for payout")
+
+// diffOneTarget is the target of a difficulty 1 share.
+var diffOneTarget, _ = new(big.Int).SetString(
+	"00000000ffff0000000000000000000000000000000000000000000000000000", 16)
+
+// PayoutInput is a pool owned output available to fund payouts.
+type PayoutInput struct {
+	OutPoint wire.OutPoint
+	Value    int64
+}
+
+// Payout is an unsigned payout transaction and the balances it settles.
+type Payout struct {
+	Tx      *wire.MsgTx
+	Amounts map[string]int64
+	Fee     int64
+}
+
+// PayoutManager records shares and found blocks in the ledger and credits
+// miners according to the configured payout scheme.
+type PayoutManager struct {
+	cfg         *PoolConfig
+	chainParams *chaincfg.Params
+	ledger      *ShareLedger
+
+	// windowDifficulty and windowWeight are the total difficulty and
+	// weight of the shares in the window, which PPS uses to normalize the
+	// NPU bonus.
+	mu               sync.Mutex
+	windowDifficulty float64
+	windowWeight     float64
+}
+
+// NewPayoutManager creates a payout manager using ledger for storage.
+func NewPayoutManager(cfg *PoolConfig, chainParams *chaincfg.Params, ledger *ShareLedger) (*PayoutManager, error) {
+	switch cfg.PayoutScheme {
+	case PayoutPPLNS, PayoutPPS:
+	default:
+		return nil, fmt.Errorf("unknown payout scheme %q", cfg.PayoutScheme)
+	}
+	if cfg.PPLNSWindow <= 0 {
+		return nil, fmt.Errorf("invalid PPLNS window %d", cfg.PPLNSWindow)
+	}
+
+	pm := &PayoutManager{
+		cfg:         cfg,
+		chainParams: chainParams,
+		ledger:      ledger,
+	}
+
+	shares, err := ledger.RecentShares(cfg.PPLNSWindow)
+	if err != nil {
+		return nil, err
+	}
+	for _, share := range shares {
+		pm.addToWindow(share, 1)
+	}
+
+	return pm, nil
+}
+
+// ShareAccepted records an accepted share. Under PPS the share's value is
+// credited right away. Shares outside the window are never paid again and
+// are pruned from the ledger.
+func (pm *PayoutManager) ShareAccepted(worker string, difficulty float64, npu bool, job *MiningJob) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	share := &ShareRecord{
+		Account:    accountFromWorker(worker),
+		Worker:     worker,
+		Difficulty: difficulty,
+		NPU:        npu,
+		Time:       time.Now().Unix(),
+	}
+	if err := pm.ledger.AddShare(share); err != nil {
+		return err
+	}
+	pm.addToWindow(share, 1)
+
+	pruned, err := pm.ledger.PruneShares(pm.cfg.PPLNSWindow)
+	if err != nil {
+		return err
+	}
+	for _, share := range pruned {
+		pm.addToWindow(share, -1)
+	}
+
+	if pm.cfg.PayoutScheme != PayoutPPS {
+		return nil
+	}
+
+	value := pm.ppsValue(difficulty, npu, job)
+	if value <= 0 {
+		return nil
+	}
+
+	return pm.ledger.Credit(map[string]int64{share.Account: value})
+}
+
+// BlockFound records a block found by the pool. Under PPLNS the reward,
+// less the pool fee, is split between the last PPLNS window of shares and
+// credited once the block matures.
+func (pm *PayoutManager) BlockFound(height int32, hash *chainhash.Hash, reward int64) error {
+	block := &BlockRecord{
+		Height: height,
+		Hash:   *hash,
+		Reward: reward,
+	}
+
+	if pm.cfg.PayoutScheme == PayoutPPLNS {
+		credits, err := pm.pplnsCredits(reward)
+		if err != nil {
+			return err
+		}
+		block.Credits = credits
+	}
+
+	return pm.ledger.AddBlock(block)
+}
+
+// UpdateMaturity credits the found blocks whose coinbase has matured as of
+// tipHeight. Blocks no longer in the main chain, according to blockHash,
+// are orphaned instead.
+func (pm *PayoutManager) UpdateMaturity(tipHeight int32, blockHash func(height int32) (*chainhash.Hash, error)) error {
+	blocks, err := pm.ledger.ImmatureBlocks()
+	if err != nil {
+		return err
+	}
+
+	maturity := int32(pm.chainParams.CoinbaseMaturity)
+	for _, block := range blocks {
+		confirmations := tipHeight - block.Height + 1
+		if confirmations < maturity {
+			continue
+		}
+
+		mainHash, err := blockHash(block.Height)
+		if err != nil {
+			return err
+		}
+
+		status := BlockMatured
+		if *mainHash != block.Hash {
+			status = BlockOrphaned
+		}
+		err = pm.ledger.SetBlockStatus(block.Height, &block.Hash, status)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BuildPayout builds an unsigned transaction paying every miner whose
+// mature balance has reached the payout threshold, funded by inputs. The fee
+// is paid by the pool and change returns to the pool address. Balances are
+// only settled once the transaction is sent and ConfirmPayout is called.
+func (pm *PayoutManager) BuildPayout(inputs []*PayoutInput) (*Payout, error) {
+	threshold, err := btcutil.NewAmount(pm.cfg.PayoutThreshold)
+	if err != nil {
+		return nil, err
+	}
+
+	balances, err := pm.ledger.Balances()
+	if err != nil {
+		return nil, err
+	}
+
+	accounts := make([]string, 0, len(balances))
+	for account, balance := range balances {
+		if balance.Mature > 0 && balance.Mature >= int64(threshold) {
+			accounts = append(accounts, account)
+		}
+	}
+	sort.Strings(accounts)
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	amounts := make(map[string]int64)
+	var total int64
+	for _, account := range accounts {
+		if len(tx.TxOut) == maxPayoutOutputs {
+			break
+		}
+
+		// Accounts that are not addresses on this network cannot be
+		// paid and keep their balance.
+		pkScript, err := pm.payoutScript(account)
+		if err != nil {
+			continue
+		}
+
+		amount := balances[account].Mature
+		tx.AddTxOut(wire.NewTxOut(amount, pkScript))
+		amounts[account] = amount
+		total += amount
+	}
+	if len(amounts) == 0 {
+		return nil, nil
+	}
+
+	var funds int64
+	for _, input := range inputs {
+		tx.AddTxIn(wire.NewTxIn(&input.OutPoint, nil, nil))
+		funds += input.Value
+	}
+
+	// Size the fee with a change output and input signatures included.
+	changeScript, err := pm.payoutScript(pm.cfg.PoolAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool address: %w", err)
+	}
+	changeOut := wire.NewTxOut(0, changeScript)
+	size := tx.SerializeSize() + changeOut.SerializeSize() +
+		len(tx.TxIn)*payoutInputSigSize
+	fee := pm.cfg.PayoutFeeRate * int64(size) / 1000
+
+	change := funds - total - fee
+	if change < 0 {
+		return nil, fmt.Errorf("%w: have %d, need %d", ErrInsufficientPayoutFunds,
+			funds, total+fee)
+	}
+	if change > 0 {
+		changeOut.Value = change
+		tx.AddTxOut(changeOut)
+	}
+
+	return &Payout{Tx: tx, Amounts: amounts, Fee: fee}, nil
+}
+
+// ConfirmPayout settles the balances paid by a sent payout.
+func (pm *PayoutManager) ConfirmPayout(payout *Payout) error {
+	return pm.ledger.RecordPayout(&PayoutRecord{
+		TxHash:  payout.Tx.TxHash(),
+		Amounts: payout.Amounts,
+		Fee:     payout.Fee,
+		Time:    time.Now().Unix(),
+	})
+}
+
+// addToWindow adds a share to the window totals, or removes it when sign is
+// negative. The caller must hold the lock.
+func (pm *PayoutManager) addToWindow(share *ShareRecord, sign float64) {
+	pm.windowDifficulty += sign * share.Difficulty
+	pm.windowWeight += sign * pm.shareWeight(share.Difficulty, share.NPU)
+}
+
+// shareWeight returns the weight of a share, applying the NPU bonus.
+func (pm *PayoutManager) shareWeight(difficulty float64, npu bool) float64 {
+	if npu && pm.cfg.NPUBonus > 0 {
+		return difficulty * pm.cfg.NPUBonus
+	}
+	return difficulty
+}
+
+// minerReward returns the part of reward left after the pool fee.
+func (pm *PayoutManager) minerReward(reward int64) int64 {
+	fee := int64(float64(reward) * pm.cfg.PoolFeePercent / 100.0)
+	return reward - fee
+}
+
+// ppsValue returns the PPS credit of a share: the miner reward times the
+// probability of the share being a block. The share is weighted relative to
+// the average weight per difficulty of the window, so that the NPU bonus
+// moves value between miners rather than paying more than the miner reward.
+// The caller must hold the lock.
+func (pm *PayoutManager) ppsValue(difficulty float64, npu bool, job *MiningJob) int64 {
+	networkDiff := targetDifficulty(job.Target)
+	if networkDiff <= 0 || pm.windowWeight <= 0 {
+		return 0
+	}
+
+	weight := pm.shareWeight(difficulty, npu) *
+		pm.windowDifficulty / pm.windowWeight
+	value := float64(pm.minerReward(job.CoinbaseValue)) * weight /
+		networkDiff
+
+	return int64(math.Floor(value))
+}
+
+// pplnsCredits splits the miner reward of a block between the accounts of
+// the last PPLNS window of shares by share weight. Rounding dust stays with
+// the pool.
+func (pm *PayoutManager) pplnsCredits(reward int64) (map[string]int64, error) {
+	shares, err := pm.ledger.RecentShares(pm.cfg.PPLNSWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	weights := make(map[string]float64)
+	var totalWeight float64
+	for _, share := range shares {
+		weight := pm.shareWeight(share.Difficulty, share.NPU)
+		weights[share.Account] += weight
+		totalWeight += weight
+	}
+	if totalWeight == 0 {
+		return nil, nil
+	}
+
+	minerReward := float64(pm.minerReward(reward))
+	credits := make(map[string]int64, len(weights))
+	for account, weight := range weights {
+		credit := int64(math.Floor(minerReward * weight / totalWeight))
+		if credit > 0 {
+			credits[account] = credit
+		}
+	}
+
+	return credits, nil
+}
+
+// payoutScript returns the output script paying to a Shell address.
+func (pm *PayoutManager) payoutScript(address string) ([]byte, error) {
+	addr, err := addresses.ParseShellAddress(address, pm.chainParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return addresses.CreateShellScript(addr)
+}
+
+// accountFromWorker returns the account of a worker name. Miners authorize
+// as "<address>.<worker>" so all workers of an address share an account.
+func accountFromWorker(worker string) string {
+	if idx := strings.IndexByte(worker, '.'); idx >= 0 {
+		return worker[:idx]
+	}
+	return worker
+}
+
+// targetDifficulty returns the share difficulty of a hex encoded target.
+func targetDifficulty(targetHex string) float64 {
+	target, ok := new(big.Int).SetString(targetHex, 16)
+	if !ok || target.Sign() <= 0 {
+		return 0
+	}
+
+	diff, _ := new(big.Float).Quo(new(big.Float).SetInt(diffOneTarget),
+		new(big.Float).SetInt(target)).Float64()
+
+	return diff
+}
@@ -0,0 +1,310 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/shell/addresses"
+	"github.com/toole-brendan/shell/chaincfg"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// testAccount returns a P2PKH address usable as a pool account.
+func testAccount(t *testing.T, id byte) string {
+	hash := make([]byte, 20)
+	hash[0] = id
+	addr, err := addresses.NewShellP2PKHAddress(hash, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	return addr.String()
+}
+
+// newTestPayouts opens a ledger in a temporary directory and returns a
+// payout manager using it.
+func newTestPayouts(t *testing.T, scheme string) (*PayoutManager, *PoolConfig) {
+	cfg := DefaultPoolConfig()
+	cfg.DatabasePath = filepath.Join(t.TempDir(), "pool.db")
+	cfg.PayoutScheme = scheme
+	cfg.PPLNSWindow = 3
+	cfg.PoolFeePercent = 1.0
+	cfg.NPUBonus = 1.5
+
+	ledger, err := OpenShareLedger(cfg.DatabasePath)
+	require.NoError(t, err)
+	t.Cleanup(func() { ledger.Close() })
+
+	pm, err := NewPayoutManager(cfg, &chaincfg.MainNetParams, ledger)
+	require.NoError(t, err)
+
+	return pm, cfg
+}
+
+// TestPPLNSPayouts tests splitting found blocks between the last shares and
+// crediting them at maturity
+func TestPPLNSPayouts(t *testing.T) {
+	pm, _ := newTestPayouts(t, PayoutPPLNS)
+	alice, bob, carol := testAccount(t, 1), testAccount(t, 2), testAccount(t, 3)
+	job := &MiningJob{Target: targetToHex(diffOneTarget), CoinbaseValue: 100000}
+
+	// Carol's share falls out of the three share window.
+	require.NoError(t, pm.ShareAccepted(carol+".phone", 5, false, job))
+	require.NoError(t, pm.ShareAccepted(alice+".phone", 1, false, job))
+	require.NoError(t, pm.ShareAccepted(bob+".tablet", 1, true, job))
+	require.NoError(t, pm.ShareAccepted(alice+".tablet", 2, false, job))
+
+	// Only the shares in the window are kept.
+	shares, err := pm.ledger.RecentShares(10)
+	require.NoError(t, err)
+	require.Len(t, shares, 3)
+
+	// The miners split 99000 after the 1% fee, alice with weight 3 and bob
+	// with weight 1.5 from the NPU bonus.
+	found := chainhash.Hash{0x01}
+	require.NoError(t, pm.BlockFound(1000, &found, 100000))
+
+	balance, err := pm.ledger.Balance(alice)
+	require.NoError(t, err)
+	require.Equal(t, &Balance{Immature: 66000}, balance)
+	balance, err = pm.ledger.Balance(bob)
+	require.NoError(t, err)
+	require.Equal(t, &Balance{Immature: 33000}, balance)
+	balance, err = pm.ledger.Balance(carol)
+	require.NoError(t, err)
+	require.Equal(t, &Balance{}, balance)
+
+	// A second block is found but reorganized out.
+	orphan := chainhash.Hash{0x02}
+	require.NoError(t, pm.BlockFound(1001, &orphan, 100000))
+
+	mainChain := map[int32]chainhash.Hash{1000: found, 1001: {0x03}}
+	blockHash := func(height int32) (*chainhash.Hash, error) {
+		hash, ok := mainChain[height]
+		if !ok {
+			return nil, errors.New("unknown height")
+		}
+		return &hash, nil
+	}
+
+	// Nothing matures before the coinbase maturity.
+	maturity := int32(chaincfg.MainNetParams.CoinbaseMaturity)
+	require.NoError(t, pm.UpdateMaturity(1000+maturity-2, blockHash))
+	blocks, err := pm.ledger.ImmatureBlocks()
+	require.NoError(t, err)
+	require.Len(t, blocks, 2)
+
+	require.NoError(t, pm.UpdateMaturity(1001+maturity-1, blockHash))
+	blocks, err = pm.ledger.ImmatureBlocks()
+	require.NoError(t, err)
+	require.Empty(t, blocks)
+
+	block, err := pm.ledger.Block(1001, &orphan)
+	require.NoError(t, err)
+	require.Equal(t, BlockOrphaned, block.Status)
+
+	balance, err = pm.ledger.Balance(alice)
+	require.NoError(t, err)
+	require.Equal(t, &Balance{Mature: 66000}, balance)
+}
+
+// TestPPSPayouts tests crediting shares by their expected value
+func TestPPSPayouts(t *testing.T) {
+	pm, cfg := newTestPayouts(t, PayoutPPS)
+	cfg.PPLNSWindow = 2
+	alice, bob := testAccount(t, 1), testAccount(t, 2)
+
+	// At network difficulty 1000 a difficulty 10 share is worth 1% of
+	// the reward after fees.
+	networkTarget := targetToHex(new(big.Int).Div(diffOneTarget,
+		big.NewInt(1000)))
+	job := &MiningJob{Target: networkTarget, CoinbaseValue: 100000000}
+	require.NoError(t, pm.ShareAccepted(alice, 10, false, job))
+	balance, err := pm.ledger.Balance(alice)
+	require.NoError(t, err)
+	require.InDelta(t, 990000, balance.Mature, 2)
+
+	// With an NPU a share weighs half as much again, but the weights are
+	// normalized over the window so that a window of shares is worth 1%
+	// of the reward per 10 difficulty whatever its NPU shares.
+	require.NoError(t, pm.ShareAccepted(bob+".npu", 10, true, job))
+	require.NoError(t, pm.ShareAccepted(alice, 10, false, job))
+	require.NoError(t, pm.ShareAccepted(bob+".npu", 10, true, job))
+
+	balance, err = pm.ledger.Balance(alice)
+	require.NoError(t, err)
+	require.InDelta(t, 990000+792000, balance.Mature, 2)
+	balance, err = pm.ledger.Balance(bob)
+	require.NoError(t, err)
+	require.InDelta(t, 2*1188000, balance.Mature, 2)
+
+	// Shares outside the window are pruned, and a restarted pool picks
+	// up the window from the ledger.
+	shares, err := pm.ledger.RecentShares(10)
+	require.NoError(t, err)
+	require.Len(t, shares, 2)
+	restarted, err := NewPayoutManager(cfg, &chaincfg.MainNetParams,
+		pm.ledger)
+	require.NoError(t, err)
+	require.Equal(t, pm.ppsValue(10, true, job),
+		restarted.ppsValue(10, true, job))
+
+	// The window must be set under PPS too.
+	cfg.PPLNSWindow = 0
+	_, err = NewPayoutManager(cfg, &chaincfg.MainNetParams, pm.ledger)
+	require.Error(t, err)
+
+	// Found blocks credit nobody under PPS.
+	hash := chainhash.Hash{0x01}
+	require.NoError(t, pm.BlockFound(1000, &hash, 100000000))
+	block, err := pm.ledger.Block(1000, &hash)
+	require.NoError(t, err)
+	require.Empty(t, block.Credits)
+}
+
+// TestBuildPayout tests batching miners above the threshold into a payout
+func TestBuildPayout(t *testing.T) {
+	pm, cfg := newTestPayouts(t, PayoutPPLNS)
+	cfg.PoolAddress = testAccount(t, 0xff)
+	cfg.PayoutThreshold = 1.0
+
+	alice, bob := testAccount(t, 1), testAccount(t, 2)
+	require.NoError(t, pm.ledger.Credit(map[string]int64{
+		alice:         2e8,
+		bob:           5e7,
+		"not-address": 3e8,
+	}))
+
+	inputs := []*PayoutInput{{
+		OutPoint: wire.OutPoint{Hash: chainhash.Hash{0x01}},
+		Value:    1e8,
+	}}
+	_, err := pm.BuildPayout(inputs)
+	require.True(t, errors.Is(err, ErrInsufficientPayoutFunds))
+
+	inputs = append(inputs, &PayoutInput{
+		OutPoint: wire.OutPoint{Hash: chainhash.Hash{0x02}},
+		Value:    2e8,
+	})
+	payout, err := pm.BuildPayout(inputs)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{alice: 2e8}, payout.Amounts)
+	require.Len(t, payout.Tx.TxIn, 2)
+	require.Len(t, payout.Tx.TxOut, 2)
+	require.Equal(t, int64(2e8), payout.Tx.TxOut[0].Value)
+	require.Equal(t, int64(1e8)-payout.Fee, payout.Tx.TxOut[1].Value)
+	require.Greater(t, payout.Fee, int64(0))
+
+	require.NoError(t, pm.ConfirmPayout(payout))
+	balance, err := pm.ledger.Balance(alice)
+	require.NoError(t, err)
+	require.Equal(t, &Balance{Paid: 2e8}, balance)
+
+	// Nothing is left above the threshold, and settling twice fails.
+	payout2, err := pm.BuildPayout(inputs)
+	require.NoError(t, err)
+	require.Nil(t, payout2)
+	require.Error(t, pm.ConfirmPayout(payout))
+}
+
+// TestShareLedgerReopen tests that the ledger keeps its shares and share
+// sequence across restarts
+func TestShareLedgerReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pool.db")
+
+	ledger, err := OpenShareLedger(path)
+	require.NoError(t, err)
+	require.NoError(t, ledger.AddShare(&ShareRecord{Account: "a", Difficulty: 1}))
+	require.NoError(t, ledger.AddShare(&ShareRecord{Account: "b", Difficulty: 2}))
+	require.NoError(t, ledger.Close())
+
+	ledger, err = OpenShareLedger(path)
+	require.NoError(t, err)
+	defer ledger.Close()
+	require.NoError(t, ledger.AddShare(&ShareRecord{Account: "c", Difficulty: 3}))
+
+	shares, err := ledger.RecentShares(10)
+	require.NoError(t, err)
+	require.Len(t, shares, 3)
+	require.Equal(t, "c", shares[0].Account)
+	require.Equal(t, uint64(2), shares[0].Seq)
+	require.Equal(t, "a", shares[2].Account)
+}
+
+// TestShareLedgerPrune tests deleting the shares outside a window, also
+// after a restart
+func TestShareLedgerPrune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pool.db")
+
+	ledger, err := OpenShareLedger(path)
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, ledger.AddShare(&ShareRecord{Difficulty: 1}))
+	}
+	pruned, err := ledger.PruneShares(10)
+	require.NoError(t, err)
+	require.Empty(t, pruned)
+	shares, err := ledger.RecentShares(10)
+	require.NoError(t, err)
+	require.Len(t, shares, 5)
+
+	pruned, err = ledger.PruneShares(3)
+	require.NoError(t, err)
+	require.Len(t, pruned, 2)
+	require.Equal(t, uint64(0), pruned[0].Seq)
+	require.Equal(t, uint64(1), pruned[1].Seq)
+	shares, err = ledger.RecentShares(10)
+	require.NoError(t, err)
+	require.Len(t, shares, 3)
+	require.Equal(t, uint64(2), shares[2].Seq)
+	require.NoError(t, ledger.Close())
+
+	ledger, err = OpenShareLedger(path)
+	require.NoError(t, err)
+	defer ledger.Close()
+	require.NoError(t, ledger.AddShare(&ShareRecord{Difficulty: 1}))
+	pruned, err = ledger.PruneShares(2)
+	require.NoError(t, err)
+	require.Len(t, pruned, 2)
+	shares, err = ledger.RecentShares(10)
+	require.NoError(t, err)
+	require.Len(t, shares, 2)
+	require.Equal(t, uint64(5), shares[0].Seq)
+	require.Equal(t, uint64(4), shares[1].Seq)
+}
+
+// TestFoundBlockRetry tests that found blocks the ledger fails to record are
+// recorded with the next chain tip
+func TestFoundBlockRetry(t *testing.T) {
+	_, cfg := newStubNode(t)
+	cfg.DatabasePath = filepath.Join(t.TempDir(), "pool.db")
+
+	s, err := NewStratumServer(cfg, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+
+	// The ledger is unavailable when the block is found.
+	require.NoError(t, s.ledger.Close())
+	block := &wire.MsgBlock{Header: wire.BlockHeader{Nonce: 1}}
+	hash := block.BlockHash()
+	require.NoError(t, s.processBlock(&MiningJob{Height: 100}, block))
+	require.Len(t, s.pendingBlocks, 1)
+
+	ledger, err := OpenShareLedger(cfg.DatabasePath)
+	require.NoError(t, err)
+	defer ledger.Close()
+	s.ledger, s.payouts.ledger = ledger, ledger
+
+	_, err = ledger.Block(100, &hash)
+	require.True(t, errors.Is(err, ErrUnknownBlock))
+
+	s.handleNewJob(&MiningJob{ID: "2", Height: 101, CleanJobs: true})
+	require.Empty(t, s.pendingBlocks)
+	found, err := ledger.Block(100, &hash)
+	require.NoError(t, err)
+	require.Equal(t, BlockImmature, found.Status)
+}
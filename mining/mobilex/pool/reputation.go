@@ -0,0 +1,269 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"math"
+	"net"
+	"time"
+
+	"github.com/toole-brendan/shell/mining/mobilex"
+)
+
+const (
+	// maxThermalProofs is the number of thermal proofs, and of thermal
+	// checks of shares, kept per worker.
+	maxThermalProofs = 1000
+
+	// minReputationProofs is the number of thermal proofs, or of thermal
+	// checks of shares, needed before they are assessed.
+	minReputationProofs = 20
+
+	// maxReputation is the reputation of workers without anomalies.
+	maxReputation = 100.0
+
+	// maxOutlierFraction is the fraction of thermal proofs that may be
+	// temperature outliers before the worker's temperatures are considered
+	// erratic.
+	maxOutlierFraction = 0.05
+
+	// minTemperatureStdDev is the standard deviation in °C below which the
+	// temperatures of a worker are considered fabricated. Real sensors
+	// under mining load always fluctuate.
+	minTemperatureStdDev = 0.1
+
+	// hashRateClassTolerance is how far the reported hash rate of a worker
+	// may exceed the maximum of its device profile.
+	hashRateClassTolerance = 1.25
+
+	// maxThermalRejectFraction is the fraction of shares that may fail
+	// thermal proof validation.
+	maxThermalRejectFraction = 0.1
+)
+
+// Reputation statuses of workers.
+const (
+	// ReputationTrusted workers are credited in full.
+	ReputationTrusted = "trusted"
+
+	// ReputationDowngraded workers lose the NPU bonus.
+	ReputationDowngraded = "downgraded"
+
+	// ReputationBanned workers are banned for the ban duration.
+	ReputationBanned = "banned"
+)
+
+// Anomalies in the thermal proofs of a worker.
+const (
+	// AnomalyErraticTemperature means too many temperatures are
+	// statistical outliers, as when readings are spoofed between reports.
+	AnomalyErraticTemperature = "erratic_temperature"
+
+	// AnomalyFlatTemperature means the temperatures barely vary, as when
+	// a fixed reading is reported.
+	AnomalyFlatTemperature = "flat_temperature"
+
+	// AnomalyCoolForClass means the mean temperature is below what devices
+	// of the worker's thermal class reach under mining load.
+	AnomalyCoolForClass = "cool_for_class"
+
+	// AnomalyHashRateForClass means the reported hash rate exceeds what
+	// the worker's device profile can sustain.
+	AnomalyHashRateForClass = "hashrate_for_class"
+
+	// AnomalyThermalRejects means too many shares failed thermal proof
+	// validation.
+	AnomalyThermalRejects = "thermal_rejects"
+)
+
+// anomalyPenalties is the reputation lost to each anomaly.
+var anomalyPenalties = map[string]float64{
+	AnomalyErraticTemperature: 25,
+	AnomalyFlatTemperature:    40,
+	AnomalyCoolForClass:       30,
+	AnomalyHashRateForClass:   30,
+	AnomalyThermalRejects:     40,
+}
+
+// classMinTemperature is the lowest mean temperature in °C devices of each
+// thermal class reach under sustained mining load. Smaller devices have less
+// room to shed heat, so they run hotter.
+var classMinTemperature = map[string]float64{
+	thermalClassFlagship: 28,
+	thermalClassMidrange: 30,
+	thermalClassBudget:   32,
+}
+
+// Reputation is the pool's trust in the thermal proofs of a worker.
+type Reputation struct {
+	Score     float64  `json:"score"` // 0 to 100
+	Status    string   `json:"status"`
+	Anomalies []string `json:"anomalies,omitempty"`
+	Proofs    int      `json:"proofs"` // Thermal proofs assessed
+}
+
+// newReputation returns the reputation of workers that have not been
+// assessed.
+func newReputation() Reputation {
+	return Reputation{Score: maxReputation, Status: ReputationTrusted}
+}
+
+// assessReputation scores the thermal proofs of a worker whose device has the
+// passed profile and reported the passed hash rate, along with the outcomes
+// of the thermal checks of its shares. Proofs are the temperatures of thermal
+// reports, so workers submitting many shares per report are not judged on
+// repeated readings. Fewer than minReputationProofs proofs or checks are not
+// assessed.
+func assessReputation(proofs []mobilex.ThermalProof, checks []bool, profile *mobilex.DeviceProfile, reportedRate float64, cfg *PoolConfig) Reputation {
+	rep := newReputation()
+	rep.Proofs = len(proofs)
+	if len(proofs) >= minReputationProofs {
+		rep.Anomalies = thermalAnomalies(proofs, profile, reportedRate,
+			cfg)
+	}
+
+	if len(checks) >= minReputationProofs {
+		var rejects int
+		for _, valid := range checks {
+			if !valid {
+				rejects++
+			}
+		}
+		if float64(rejects) > maxThermalRejectFraction*float64(len(checks)) {
+			rep.Anomalies = append(rep.Anomalies, AnomalyThermalRejects)
+		}
+	}
+
+	for _, anomaly := range rep.Anomalies {
+		rep.Score -= anomalyPenalties[anomaly]
+	}
+	if rep.Score < 0 {
+		rep.Score = 0
+	}
+
+	switch {
+	case cfg.ReputationBanScore > 0 && rep.Score <= cfg.ReputationBanScore:
+		rep.Status = ReputationBanned
+	case rep.Score < cfg.ReputationDowngradeScore:
+		rep.Status = ReputationDowngraded
+	}
+
+	return rep
+}
+
+// thermalAnomalies returns the anomalies in the thermal proofs of a worker
+// whose device has the passed profile and reported the passed hash rate.
+func thermalAnomalies(proofs []mobilex.ThermalProof, profile *mobilex.DeviceProfile, reportedRate float64, cfg *PoolConfig) []string {
+	var anomalies []string
+
+	var sum float64
+	for _, proof := range proofs {
+		sum += proof.Temperature
+	}
+	mean := sum / float64(len(proofs))
+
+	var variance float64
+	for _, proof := range proofs {
+		diff := proof.Temperature - mean
+		variance += diff * diff
+	}
+	stdDev := math.Sqrt(variance / float64(len(proofs)))
+
+	// A flat distribution has no outliers, so only look for them when the
+	// temperatures vary.
+	if stdDev < minTemperatureStdDev {
+		anomalies = append(anomalies, AnomalyFlatTemperature)
+	} else {
+		outliers := mobilex.DetectThermalCheating(proofs,
+			cfg.ThermalAnomalyThreshold)
+		if float64(len(outliers)) > maxOutlierFraction*float64(len(proofs)) {
+			anomalies = append(anomalies, AnomalyErraticTemperature)
+		}
+	}
+
+	class := profile.ThermalClass
+	if _, ok := classMinTemperature[class]; !ok {
+		class = thermalClassBudget
+	}
+	if mean < classMinTemperature[class] {
+		anomalies = append(anomalies, AnomalyCoolForClass)
+	}
+
+	if profile.MaxHashRate > 0 &&
+		reportedRate > profile.MaxHashRate*hashRateClassTolerance {
+
+		anomalies = append(anomalies, AnomalyHashRateForClass)
+	}
+
+	return anomalies
+}
+
+// addThermalProof records the temperature of a thermal report as a thermal
+// proof. The caller must hold the lock.
+func (w *workerState) addThermalProof(temperature float64, now time.Time) {
+	w.proofs = append(w.proofs, mobilex.ThermalProof{
+		Temperature: temperature,
+		Timestamp:   now.Unix(),
+	})
+	if len(w.proofs) > maxThermalProofs {
+		w.proofs = w.proofs[len(w.proofs)-maxThermalProofs:]
+	}
+}
+
+// addThermalCheck records whether a share passed thermal proof validation.
+// The caller must hold the lock.
+func (w *workerState) addThermalCheck(valid bool) {
+	w.checks = append(w.checks, valid)
+	if len(w.checks) > maxThermalProofs {
+		w.checks = w.checks[len(w.checks)-maxThermalProofs:]
+	}
+}
+
+// assessReputation re-assesses the reputation of a worker against the device
+// profiles and returns it. The thermal proofs and checks of banned workers are
+// cleared so they start over once the ban expires.
+func (st *statsTracker) assessReputation(name string, devices *mobilex.DeviceRegistry, cfg *PoolConfig) Reputation {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	w := st.worker(name)
+	profile := devices.Lookup("", w.stats.SocModel)
+	w.stats.Reputation = assessReputation(w.proofs, w.checks, profile,
+		w.stats.ReportedRate, cfg)
+	if w.stats.Reputation.Status == ReputationBanned {
+		w.proofs = nil
+		w.checks = nil
+	}
+
+	return w.stats.Reputation
+}
+
+// reputation returns the current reputation of a worker.
+func (st *statsTracker) reputation(name string) Reputation {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	return st.worker(name).stats.Reputation
+}
+
+// updateReputation re-assesses the reputation of a worker after new thermal
+// data and bans the worker, closing conn, if its reputation fell to the ban
+// score. It returns whether the worker was banned.
+func (s *StratumServer) updateReputation(worker string, conn net.Conn) bool {
+	rep := s.stats.assessReputation(worker, s.devices, s.cfg)
+	if rep.Status != ReputationBanned {
+		return false
+	}
+
+	s.ban(worker, conn)
+
+	return true
+}
+
+// npuBonus returns whether the shares of a worker earn the NPU bonus. Only
+// trusted workers with an NPU do.
+func (s *StratumServer) npuBonus(worker string, npuCapable bool) bool {
+	return npuCapable && s.stats.reputation(worker).Status == ReputationTrusted
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/shell/chaincfg"
+	"github.com/toole-brendan/shell/mining/mobilex"
+)
+
+// testProofs returns n thermal proofs whose temperatures cycle through the
+// passed readings.
+func testProofs(n int, temperatures ...float64) []mobilex.ThermalProof {
+	proofs := make([]mobilex.ThermalProof, n)
+	for i := range proofs {
+		proofs[i] = mobilex.ThermalProof{
+			Temperature: temperatures[i%len(temperatures)],
+			Timestamp:   int64(i),
+		}
+	}
+	return proofs
+}
+
+// testChecks returns n thermal checks of shares, every step-th of which
+// failed.
+func testChecks(n, step int) []bool {
+	checks := make([]bool, n)
+	for i := range checks {
+		checks[i] = step == 0 || i%step != 0
+	}
+	return checks
+}
+
+// TestAssessReputation tests scoring thermal proof histories against the
+// device class of the worker
+func TestAssessReputation(t *testing.T) {
+	cfg := DefaultPoolConfig()
+	flagship := &mobilex.DeviceProfile{
+		ThermalClass: thermalClassFlagship,
+		MaxHashRate:  100,
+	}
+	budget := &mobilex.DeviceProfile{
+		ThermalClass: thermalClassBudget,
+		MaxHashRate:  50,
+	}
+
+	// Six percent of the temperatures spike far above the rest.
+	erratic := testProofs(100, 40, 40.5, 41, 39.5)
+	for i := 0; i < 6; i++ {
+		erratic[i*16].Temperature = 80
+	}
+
+	tests := []struct {
+		name      string
+		proofs    []mobilex.ThermalProof
+		checks    []bool
+		profile   *mobilex.DeviceProfile
+		rate      float64
+		score     float64
+		status    string
+		anomalies []string
+	}{{
+		name:    "honest flagship",
+		proofs:  testProofs(100, 38, 40, 42, 41, 39),
+		checks:  testChecks(1000, 0),
+		profile: flagship,
+		rate:    95,
+		score:   maxReputation,
+		status:  ReputationTrusted,
+	}, {
+		name:    "too few proofs",
+		proofs:  testProofs(minReputationProofs-1, 20),
+		checks:  testChecks(minReputationProofs-1, 1),
+		profile: flagship,
+		rate:    500,
+		score:   maxReputation,
+		status:  ReputationTrusted,
+	}, {
+		name:      "flat temperature",
+		proofs:    testProofs(100, 40),
+		profile:   flagship,
+		rate:      95,
+		score:     60,
+		status:    ReputationDowngraded,
+		anomalies: []string{AnomalyFlatTemperature},
+	}, {
+		name:      "erratic temperature",
+		proofs:    erratic,
+		profile:   flagship,
+		rate:      95,
+		score:     75,
+		status:    ReputationTrusted,
+		anomalies: []string{AnomalyErraticTemperature},
+	}, {
+		name:      "cool for a budget device",
+		proofs:    testProofs(100, 29, 30, 31),
+		profile:   budget,
+		rate:      40,
+		score:     70,
+		status:    ReputationDowngraded,
+		anomalies: []string{AnomalyCoolForClass},
+	}, {
+		name:      "hash rate beyond the device class",
+		proofs:    testProofs(100, 38, 40, 42),
+		profile:   budget,
+		rate:      100,
+		score:     70,
+		status:    ReputationDowngraded,
+		anomalies: []string{AnomalyHashRateForClass},
+	}, {
+		name:      "failed thermal proofs",
+		proofs:    testProofs(50, 40, 41, 42),
+		checks:    testChecks(50, 5),
+		profile:   flagship,
+		rate:      95,
+		score:     60,
+		status:    ReputationDowngraded,
+		anomalies: []string{AnomalyThermalRejects},
+	}, {
+		name:    "fixed cool reading",
+		proofs:  testProofs(100, 20),
+		profile: budget,
+		rate:    40,
+		score:   30,
+		status:  ReputationBanned,
+		anomalies: []string{AnomalyFlatTemperature,
+			AnomalyCoolForClass},
+	}}
+
+	for _, test := range tests {
+		rep := assessReputation(test.proofs, test.checks, test.profile,
+			test.rate, cfg)
+		require.Equal(t, test.score, rep.Score, test.name)
+		require.Equal(t, test.status, rep.Status, test.name)
+		require.Equal(t, test.anomalies, rep.Anomalies, test.name)
+		require.Equal(t, len(test.proofs), rep.Proofs, test.name)
+	}
+
+	// Banning can be disabled.
+	cfg.ReputationBanScore = 0
+	rep := assessReputation(testProofs(100, 20), nil, budget, 40, cfg)
+	require.Equal(t, ReputationDowngraded, rep.Status)
+}
+
+// TestReputationBan tests banning workers whose thermal proofs are
+// fabricated, and revoking the NPU bonus of downgraded workers
+func TestReputationBan(t *testing.T) {
+	cfg := DefaultPoolConfig()
+	cfg.DatabasePath = ""
+
+	s, err := NewStratumServer(cfg, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+
+	conn, remote := net.Pipe()
+	defer remote.Close()
+
+	// Alice reports a constant temperature, which costs her the NPU
+	// bonus, and bob a constant low temperature on a budget device.
+	const alice, bob = "alice.phone", "bob.phone"
+	s.stats.deviceInfo(alice, "Android", "Snapdragon 8 Gen 3", true, 45)
+	s.stats.deviceInfo(bob, "Android", "Helio G99", true, 45)
+	for i := 0; i < minReputationProofs; i++ {
+		s.stats.thermalReport(alice, 40, 5, 100, false)
+		s.stats.thermalReport(bob, 20, 3, 40, false)
+		s.stats.shareAccepted(alice, 1)
+		s.stats.shareAccepted(bob, 1)
+	}
+	require.True(t, s.npuBonus(alice, true))
+
+	// Carol submits many shares between thermal reports, which doesn't
+	// make her temperature look flat.
+	const carol = "carol.phone"
+	s.stats.deviceInfo(carol, "Android", "Snapdragon 8 Gen 3", true, 45)
+	s.stats.thermalReport(carol, 40, 5, 100, false)
+	for i := 0; i < 5*minReputationProofs; i++ {
+		s.stats.shareAccepted(carol, 1)
+	}
+	require.False(t, s.updateReputation(carol, conn))
+	require.True(t, s.npuBonus(carol, true))
+	require.Equal(t, 1, s.stats.reputation(carol).Proofs)
+
+	require.False(t, s.updateReputation(alice, conn))
+	require.False(t, s.npuBonus(alice, true))
+	stats, _, ok := s.stats.workerDetail(alice)
+	require.True(t, ok)
+	require.Equal(t, ReputationDowngraded, stats.Reputation.Status)
+	require.Equal(t, []string{AnomalyFlatTemperature},
+		stats.Reputation.Anomalies)
+
+	require.False(t, s.isBanned(bob))
+	require.True(t, s.updateReputation(bob, conn))
+	require.True(t, s.isBanned(bob))
+	require.False(t, s.isBanned(alice))
+	require.Equal(t, ReputationBanned, s.stats.reputation(bob).Status)
+
+	// The connection is closed, and bob starts over once the ban expires.
+	_, err = conn.Write([]byte{0})
+	require.Error(t, err)
+	s.stats.thermalReport(bob, 20, 3, 40, false)
+	rep := s.stats.assessReputation(bob, s.devices, s.cfg)
+	require.Equal(t, ReputationTrusted, rep.Status)
+	require.Equal(t, 1, rep.Proofs)
+
+	// Shares are not thermal proofs.
+	s.stats.shareAccepted("dave.phone", 1)
+	rep = s.stats.assessReputation("dave.phone", s.devices, s.cfg)
+	require.Zero(t, rep.Proofs)
+}
@@ -0,0 +1,286 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/toole-brendan/shell/mining/mobilex"
+)
+
+const (
+	// hashRateWindow is the period over which worker hash rates are
+	// estimated from accepted shares.
+	hashRateWindow = 10 * time.Minute
+
+	// maxThermalSamples is the number of thermal reports kept per worker.
+	maxThermalSamples = 360
+
+	// hashesPerShare is the expected number of hashes behind a
+	// difficulty 1 share.
+	hashesPerShare = 1 << 32
+
+	// metricsSampleInterval is how often pool totals are recorded.
+	metricsSampleInterval = 10 * time.Second
+
+	// defaultThermalLimit is the thermal limit of workers that did not
+	// report their own.
+	defaultThermalLimit = 45.0
+)
+
+// ThermalSample is a thermal report from a worker.
+type ThermalSample struct {
+	Time        time.Time `json:"time"`
+	Temperature float64   `json:"temperature"`
+	PowerUsage  float64   `json:"power_usage"`
+	Throttled   bool      `json:"throttled"`
+	Compliant   bool      `json:"compliant"` // Within the worker's thermal limit
+}
+
+// WorkerStats is the pool's view of a single worker.
+type WorkerStats struct {
+	Worker         string     `json:"worker"`
+	Account        string     `json:"account"`
+	Online         bool       `json:"online"`
+	DeviceType     string     `json:"device_type,omitempty"`
+	SocModel       string     `json:"soc_model,omitempty"`
+	NPUCapable     bool       `json:"npu_capable"`
+	Difficulty     float64    `json:"difficulty"`
+	HashRate       float64    `json:"hashrate"`          // Estimated from accepted shares
+	ReportedRate   float64    `json:"reported_hashrate"` // As reported by the device
+	AcceptedShares uint64     `json:"accepted_shares"`
+	RejectedShares uint64     `json:"rejected_shares"`
+	ThermalRejects uint64     `json:"thermal_rejects"` // Shares failing thermal proof validation
+	Temperature    float64    `json:"temperature"`
+	LastShare      time.Time  `json:"last_share,omitempty"`
+	Reputation     Reputation `json:"reputation"`
+}
+
+// workerState is the tracked state of a worker.
+type workerState struct {
+	stats   WorkerStats
+	limit   float64
+	shares  []shareSample
+	thermal []ThermalSample
+	proofs  []mobilex.ThermalProof
+	checks  []bool
+}
+
+// shareSample is an accepted share used for hash rate estimation.
+type shareSample struct {
+	time       time.Time
+	difficulty float64
+}
+
+// statsTracker keeps per-worker statistics and samples pool totals into a
+// metrics collector.
+type statsTracker struct {
+	mu        sync.Mutex
+	workers   map[string]*workerState
+	hashes    uint64 // Expected hashes behind all accepted shares
+	metrics   *mobilex.MetricsCollector
+	startTime time.Time
+}
+
+// newStatsTracker creates a tracker recording pool totals into metrics.
+func newStatsTracker(metrics *mobilex.MetricsCollector) *statsTracker {
+	return &statsTracker{
+		workers:   make(map[string]*workerState),
+		metrics:   metrics,
+		startTime: time.Now(),
+	}
+}
+
+// worker returns the state of a worker, creating it if needed. The caller
+// must hold the lock.
+func (st *statsTracker) worker(name string) *workerState {
+	w, ok := st.workers[name]
+	if !ok {
+		w = &workerState{
+			stats: WorkerStats{
+				Worker:     name,
+				Account:    accountFromWorker(name),
+				Reputation: newReputation(),
+			},
+			limit: defaultThermalLimit,
+		}
+		st.workers[name] = w
+	}
+	return w
+}
+
+// setOnline marks a worker as connected or disconnected.
+func (st *statsTracker) setOnline(name string, online bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.worker(name).stats.Online = online
+}
+
+// deviceInfo records the device a worker runs on.
+func (st *statsTracker) deviceInfo(name, deviceType, socModel string, npu bool, thermalLimit float64) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	w := st.worker(name)
+	w.stats.DeviceType = deviceType
+	w.stats.SocModel = socModel
+	w.stats.NPUCapable = npu
+	if thermalLimit > 0 {
+		w.limit = thermalLimit
+	}
+}
+
+// shareAccepted records an accepted share.
+func (st *statsTracker) shareAccepted(name string, difficulty float64) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := time.Now()
+	w := st.worker(name)
+	w.stats.AcceptedShares++
+	w.stats.Difficulty = difficulty
+	w.stats.LastShare = now
+	w.shares = append(w.shares, shareSample{time: now, difficulty: difficulty})
+	w.pruneShares(now)
+	w.addThermalCheck(true)
+
+	st.hashes += uint64(difficulty * hashesPerShare)
+}
+
+// shareRejected records a rejected share.
+func (st *statsTracker) shareRejected(name string, thermal bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	w := st.worker(name)
+	w.stats.RejectedShares++
+	if thermal {
+		w.stats.ThermalRejects++
+		w.addThermalCheck(false)
+	}
+}
+
+// thermalReport records a thermal report.
+func (st *statsTracker) thermalReport(name string, temperature, power, hashRate float64, throttled bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := time.Now()
+	w := st.worker(name)
+	w.stats.Temperature = temperature
+	w.stats.ReportedRate = hashRate
+	w.addThermalProof(temperature, now)
+	w.thermal = append(w.thermal, ThermalSample{
+		Time:        now,
+		Temperature: temperature,
+		PowerUsage:  power,
+		Throttled:   throttled,
+		Compliant:   temperature <= w.limit,
+	})
+	if len(w.thermal) > maxThermalSamples {
+		w.thermal = w.thermal[len(w.thermal)-maxThermalSamples:]
+	}
+}
+
+// pruneShares drops shares that fell out of the hash rate window.
+func (w *workerState) pruneShares(now time.Time) {
+	cutoff := now.Add(-hashRateWindow)
+	i := 0
+	for i < len(w.shares) && w.shares[i].time.Before(cutoff) {
+		i++
+	}
+	w.shares = w.shares[i:]
+}
+
+// hashRate estimates the worker's hash rate from its recent shares.
+func (w *workerState) hashRate(now time.Time) float64 {
+	w.pruneShares(now)
+
+	var work float64
+	for _, share := range w.shares {
+		work += share.difficulty * hashesPerShare
+	}
+
+	return work / hashRateWindow.Seconds()
+}
+
+// snapshot returns a copy of the worker's stats.
+func (w *workerState) snapshot(now time.Time) WorkerStats {
+	stats := w.stats
+	stats.HashRate = w.hashRate(now)
+	return stats
+}
+
+// workerStats returns the stats of every worker sorted by name.
+func (st *statsTracker) workerStats() []WorkerStats {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	now := time.Now()
+	stats := make([]WorkerStats, 0, len(st.workers))
+	for _, w := range st.workers {
+		stats = append(stats, w.snapshot(now))
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Worker < stats[j].Worker
+	})
+
+	return stats
+}
+
+// workerDetail returns the stats and thermal history of a worker.
+func (st *statsTracker) workerDetail(name string) (WorkerStats, []ThermalSample, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	w, ok := st.workers[name]
+	if !ok {
+		return WorkerStats{}, nil, false
+	}
+
+	thermal := make([]ThermalSample, len(w.thermal))
+	copy(thermal, w.thermal)
+
+	return w.snapshot(time.Now()), thermal, true
+}
+
+// sample records the pool's current totals in the metrics collector.
+func (st *statsTracker) sample() {
+	var (
+		hashRate, power, temperature float64
+		reporting                    int
+	)
+
+	st.mu.Lock()
+	now := time.Now()
+	for _, w := range st.workers {
+		if !w.stats.Online {
+			continue
+		}
+		hashRate += w.hashRate(now)
+		if n := len(w.thermal); n > 0 {
+			temperature += w.thermal[n-1].Temperature
+			power += w.thermal[n-1].PowerUsage
+			reporting++
+		}
+	}
+	hashes := st.hashes
+	st.mu.Unlock()
+
+	if reporting > 0 {
+		temperature /= float64(reporting)
+	}
+
+	st.metrics.Record(mobilex.MiningMetrics{
+		HashRate:        hashRate,
+		HashesCompleted: hashes,
+		Temperature:     temperature,
+		PowerUsage:      power,
+		Duration:        time.Since(st.startTime),
+	})
+}
@@ -7,6 +7,8 @@ package pool
 import (
 	"bufio"
 	"context"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,9 +18,21 @@ import (
 	"time"
 
 	"github.com/toole-brendan/shell/chaincfg"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/connmgr"
+	"github.com/toole-brendan/shell/mining/mobilex"
 	"github.com/toole-brendan/shell/wire"
 )
 
+// Stratum error codes of rejected shares.
+const (
+	StratumErrOther          = 20
+	StratumErrJobNotFound    = 21
+	StratumErrDuplicateShare = 22
+	StratumErrLowDifficulty  = 23
+	StratumErrUnauthorized   = 24
+)
+
 // StratumServer implements the Stratum protocol for mobile miners.
 type StratumServer struct {
 	cfg            *PoolConfig
@@ -26,12 +40,38 @@ type StratumServer struct {
 	jobManager     *JobManager
 	shareValidator *ShareValidator
 
+	// Device profiles selecting work sizes and share rates
+	devices *mobilex.DeviceRegistry
+
+	// Share accounting, nil when no database is configured
+	ledger  *ShareLedger
+	payouts *PayoutManager
+
+	// Found blocks the payout manager failed to record, retried with
+	// every new chain tip
+	pendingBlocks []foundBlock
+	pendingMtx    sync.Mutex
+
+	// Worker statistics and pool metrics
+	stats   *statsTracker
+	metrics *mobilex.MetricsCollector
+
+	// Other servers notified of new jobs, and the binary protocol server
+	// advertised to JSON miners
+	jobListeners []func(*MiningJob)
+	listenersMtx sync.Mutex
+	binary       *BinaryServer
+
 	// Network
 	listener     net.Listener
 	clients      map[uint64]*StratumClient
 	clientsMu    sync.RWMutex
 	nextClientID uint64
 
+	// Banned workers by ban expiry, shared with the binary server
+	bans   map[string]time.Time
+	bansMu sync.Mutex
+
 	// Shutdown
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -45,14 +85,20 @@ type StratumClient struct {
 	reader *bufio.Reader
 	writer *bufio.Writer
 
+	// mu guards writer and currentJob, which jobs announced by the job
+	// manager update from outside the client's goroutine
+	mu sync.Mutex
+
 	// Client state
-	subscribed bool
-	authorized bool
-	workerName string
-	userAgent  string
+	subscribed  bool
+	authorized  bool
+	extranonce1 []byte
+	workerName  string
+	userAgent   string
 
 	// Mobile-specific
 	deviceType   string  // iOS, Android
+	deviceModel  string  // Pixel 8, iPhone 15 Pro, etc.
 	socModel     string  // Snapdragon 8 Gen 3, A17 Pro, etc.
 	thermalLimit float64 // Max temperature
 	npuCapable   bool
@@ -66,7 +112,10 @@ type StratumClient struct {
 
 	// Difficulty
 	difficulty float64
-	targetDiff float64
+	vardiff    *vardiff
+
+	// Misbehavior
+	banScore connmgr.DynamicBanScore
 
 	// Metrics
 	hashRate    float64
@@ -97,7 +146,19 @@ type MiningJob struct {
 	PreviousHash     string
 	CoinbaseValue    int64
 	Target           string
+	Bits             uint32
 	MobileDifficulty float64
+	CleanJobs        bool // Miners must abandon previous jobs
+	Version          int32
+
+	// Coinbase halves around the extranonces and the merkle branch
+	// linking the coinbase to the block's merkle root
+	Coinbase1    []byte
+	Coinbase2    []byte
+	MerkleBranch []*chainhash.Hash
+
+	// template is the block template the job was created from
+	template *BlockTemplate
 
 	// Mobile-specific
 	NPUWork       []byte         // Optional NPU computation work
@@ -120,16 +181,47 @@ func NewStratumServer(cfg *PoolConfig, chainParams *chaincfg.Params) (*StratumSe
 		cfg:         cfg,
 		chainParams: chainParams,
 		clients:     make(map[uint64]*StratumClient),
+		bans:        make(map[string]time.Time),
 		ctx:         ctx,
 		cancel:      cancel,
 	}
 
 	// Initialize job manager
 	s.jobManager = NewJobManager(cfg, chainParams)
+	s.jobManager.SetJobHandler(s.handleNewJob)
 
 	// Initialize share validator
 	s.shareValidator = NewShareValidator(cfg, chainParams)
 
+	// Load device profiles
+	devices, err := mobilex.LoadDeviceRegistry(cfg.DeviceProfilesPath)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	s.devices = devices
+
+	// Initialize statistics
+	s.metrics = mobilex.NewMetricsCollector()
+	s.stats = newStatsTracker(s.metrics)
+
+	// Initialize share accounting
+	if cfg.DatabasePath != "" {
+		ledger, err := OpenShareLedger(cfg.DatabasePath)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		payouts, err := NewPayoutManager(cfg, chainParams, ledger)
+		if err != nil {
+			ledger.Close()
+			cancel()
+			return nil, err
+		}
+		s.ledger = ledger
+		s.payouts = payouts
+	}
+
 	return s, nil
 }
 
@@ -150,9 +242,32 @@ func (s *StratumServer) Start() error {
 	s.wg.Add(1)
 	go s.acceptConnections()
 
+	// Start sampling pool metrics
+	s.metrics.Start(s.ctx)
+	s.wg.Add(1)
+	go s.sampleMetrics()
+
 	return nil
 }
 
+// sampleMetrics periodically records pool totals for the API.
+func (s *StratumServer) sampleMetrics() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(metricsSampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			s.metrics.Stop()
+			return
+		case <-ticker.C:
+			s.stats.sample()
+		}
+	}
+}
+
 // Stop gracefully shuts down the Stratum server.
 func (s *StratumServer) Stop() {
 	s.cancel()
@@ -169,6 +284,29 @@ func (s *StratumServer) Stop() {
 	s.clientsMu.Unlock()
 
 	s.wg.Wait()
+
+	s.shareValidator.Close()
+	if s.ledger != nil {
+		// Blocks still pending are lost with the process, so they are
+		// logged for the operator to credit by hand.
+		s.recordPendingBlocks()
+		s.pendingMtx.Lock()
+		for _, block := range s.pendingBlocks {
+			log.Errorf("Block %s at height %d with reward %d was "+
+				"never recorded for payouts", block.hash,
+				block.height, block.reward)
+		}
+		s.pendingMtx.Unlock()
+		if err := s.ledger.Close(); err != nil {
+			log.Errorf("Failed to close share ledger: %v", err)
+		}
+	}
+}
+
+// Payouts returns the pool's payout manager, nil when share accounting is
+// disabled.
+func (s *StratumServer) Payouts() *PayoutManager {
+	return s.payouts
 }
 
 // acceptConnections accepts new miner connections.
@@ -189,11 +327,13 @@ func (s *StratumServer) acceptConnections() {
 		// Create new client
 		clientID := atomic.AddUint64(&s.nextClientID, 1)
 		client := &StratumClient{
-			ID:         clientID,
-			conn:       conn,
-			reader:     bufio.NewReader(conn),
-			writer:     bufio.NewWriter(conn),
-			difficulty: s.cfg.InitialDifficulty,
+			ID:          clientID,
+			conn:        conn,
+			reader:      bufio.NewReader(conn),
+			writer:      bufio.NewWriter(conn),
+			extranonce1: extranonce1(clientID),
+			difficulty:  s.cfg.InitialDifficulty,
+			vardiff:     newVardiff(s.cfg, thermalClassBudget, time.Now()),
 		}
 
 		// Register client
@@ -233,6 +373,10 @@ func (s *StratumServer) handleClient(client *StratumClient) {
 			var msg StratumMessage
 			if err := json.Unmarshal(line, &msg); err != nil {
 				s.sendError(client, nil, -32700, "Parse error")
+				if s.addBanScore(&client.banScore, client.conn, "",
+					malformedMessageBanScore) {
+					return
+				}
 				continue
 			}
 
@@ -287,7 +431,7 @@ func (s *StratumServer) handleSubscribe(client *StratumClient, msg *StratumMessa
 
 	// Generate subscription ID and extranonce
 	subID := fmt.Sprintf("%x", client.ID)
-	extranonce1 := fmt.Sprintf("%08x", client.ID)
+	extranonce1 := hex.EncodeToString(client.extranonce1)
 
 	// Mark as subscribed
 	client.subscribed = true
@@ -299,7 +443,7 @@ func (s *StratumServer) handleSubscribe(client *StratumClient, msg *StratumMessa
 			{"mining.notify", subID},
 		},
 		extranonce1,
-		4, // extranonce2 size
+		extranonce2Size,
 	}
 
 	return s.sendResult(client, msg.ID, result)
@@ -317,8 +461,14 @@ func (s *StratumServer) handleAuthorize(client *StratumClient, msg *StratumMessa
 		return errors.New("missing username")
 	}
 
+	if s.isBanned(params[0]) {
+		return s.sendError(client, msg.ID, StratumErrUnauthorized,
+			"Worker is banned")
+	}
+
 	client.workerName = params[0]
 	client.authorized = true
+	s.stats.setOnline(client.workerName, true)
 
 	// Send initial difficulty
 	s.setDifficulty(client, client.difficulty)
@@ -331,7 +481,9 @@ func (s *StratumServer) handleAuthorize(client *StratumClient, msg *StratumMessa
 	return s.sendResult(client, msg.ID, true)
 }
 
-// handleSubmit handles share submissions from mobile miners.
+// handleSubmit handles share submissions from mobile miners. Rejected
+// shares are answered with the Stratum error code of the rejection and add
+// to the client's ban score.
 func (s *StratumServer) handleSubmit(client *StratumClient, msg *StratumMessage) error {
 	// Parse params [worker, jobID, extranonce2, ntime, nonce, thermalProof]
 	var params []string
@@ -343,10 +495,23 @@ func (s *StratumServer) handleSubmit(client *StratumClient, msg *StratumMessage)
 		return errors.New("invalid submit parameters")
 	}
 
+	client.submittedShares++
+
+	// Only the worker the client authorized may submit shares.
+	if !client.authorized || params[0] != client.workerName {
+		client.rejectedShares++
+		err := s.sendError(client, msg.ID, StratumErrUnauthorized,
+			"Unauthorized worker")
+		s.addBanScore(&client.banScore, client.conn, "",
+			unauthorizedBanScore)
+		return err
+	}
+
 	share := &Share{
 		ClientID:     client.ID,
 		WorkerName:   params[0],
 		JobID:        params[1],
+		Extranonce1:  hex.EncodeToString(client.extranonce1),
 		Extranonce2:  params[2],
 		Ntime:        params[3],
 		Nonce:        params[4],
@@ -356,35 +521,79 @@ func (s *StratumServer) handleSubmit(client *StratumClient, msg *StratumMessage)
 	}
 
 	// Validate share
-	result, err := s.shareValidator.ValidateShare(share, client.currentJob)
+	var (
+		result *ShareResult
+		err    error
+	)
+	job := client.getJob()
+	if job == nil {
+		err = ErrJobNotFound
+	} else {
+		result, err = s.shareValidator.ValidateShare(share, job)
+	}
 	if err != nil {
 		client.rejectedShares++
-		return s.sendResult(client, msg.ID, false)
+		s.stats.shareRejected(client.workerName,
+			errors.Is(err, ErrThermalProof))
+
+		sendErr := s.sendError(client, msg.ID, shareErrorCode(err),
+			err.Error())
+		banned := s.addBanScore(&client.banScore, client.conn,
+			client.workerName, shareBanScore(err))
+		if !banned && errors.Is(err, ErrThermalProof) {
+			s.updateReputation(client.workerName, client.conn)
+		}
+		return sendErr
 	}
 
 	// Update client stats
-	client.submittedShares++
+	now := time.Now()
 	client.acceptedShares++
-	client.lastShareTime = time.Now()
+	client.lastShareTime = now
+	s.stats.shareAccepted(client.workerName, share.Difficulty)
+	if s.updateReputation(client.workerName, client.conn) {
+		return nil
+	}
+
+	s.recordShare(client.workerName, share.Difficulty,
+		s.npuBonus(client.workerName, client.npuCapable), job)
 
-	// Adjust difficulty if needed
-	s.adjustDifficulty(client)
+	// Retarget the client's difficulty if due
+	if diff, ok := client.vardiff.shareAccepted(client.difficulty, now); ok {
+		s.setDifficulty(client, diff)
+	}
 
 	// Check if share meets network difficulty
 	if result.MeetsNetworkDifficulty {
 		// Submit block to network
-		s.submitBlock(result.Block)
+		s.submitBlock(client, job, result.Block)
 	}
 
 	return s.sendResult(client, msg.ID, true)
 }
 
+// shareErrorCode returns the Stratum error code of a share rejected with
+// err.
+func shareErrorCode(err error) int {
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		return StratumErrJobNotFound
+	case errors.Is(err, ErrDuplicateShare):
+		return StratumErrDuplicateShare
+	case errors.Is(err, ErrLowDifficulty):
+		return StratumErrLowDifficulty
+	default:
+		return StratumErrOther
+	}
+}
+
 // Mobile-specific method handlers
 
 // handleSetDeviceInfo handles device information from mobile miners.
 func (s *StratumServer) handleSetDeviceInfo(client *StratumClient, msg *StratumMessage) error {
 	var info struct {
 		DeviceType   string  `json:"device_type"`
+		DeviceModel  string  `json:"device_model"`
 		SocModel     string  `json:"soc_model"`
 		ThermalLimit float64 `json:"thermal_limit"`
 		NPUCapable   bool    `json:"npu_capable"`
@@ -398,9 +607,14 @@ func (s *StratumServer) handleSetDeviceInfo(client *StratumClient, msg *StratumM
 
 	// Update client info
 	client.deviceType = info.DeviceType
+	client.deviceModel = info.DeviceModel
 	client.socModel = info.SocModel
 	client.thermalLimit = info.ThermalLimit
 	client.npuCapable = info.NPUCapable
+	if client.authorized {
+		s.stats.deviceInfo(client.workerName, info.DeviceType,
+			info.SocModel, info.NPUCapable, info.ThermalLimit)
+	}
 
 	// Adjust work parameters based on device
 	s.optimizeForDevice(client)
@@ -425,11 +639,22 @@ func (s *StratumServer) handleReportThermal(client *StratumClient, msg *StratumM
 	client.temperature = report.Temperature
 	client.powerUsage = report.PowerUsage
 	client.hashRate = report.HashRate
+	if client.authorized {
+		s.stats.thermalReport(client.workerName, report.Temperature,
+			report.PowerUsage, report.HashRate, report.Throttled)
+		if s.updateReputation(client.workerName, client.conn) {
+			return nil
+		}
+	}
 
-	// Adjust difficulty if thermal throttling
+	// Adjust difficulty if thermal throttling, otherwise give miners
+	// that stopped finding shares a chance to retarget
 	if report.Throttled {
-		newDiff := client.difficulty * 0.8
+		newDiff := client.vardiff.clamp(client.difficulty * 0.8)
 		s.setDifficulty(client, newDiff)
+	} else if diff, ok := client.vardiff.retarget(client.difficulty,
+		time.Now()); ok {
+		s.setDifficulty(client, diff)
 	}
 
 	return s.sendResult(client, msg.ID, true)
@@ -445,91 +670,140 @@ func (s *StratumServer) handleGetMobileConfig(client *StratumClient, msg *Stratu
 
 // sendJob sends a new mining job to a client.
 func (s *StratumServer) sendJob(client *StratumClient, job *MiningJob) {
+	// Merkle branch entries are encoded in internal byte order as
+	// expected by Stratum miners.
+	merkleBranch := make([]string, 0, len(job.MerkleBranch))
+	for _, hash := range job.MerkleBranch {
+		merkleBranch = append(merkleBranch, hex.EncodeToString(hash[:]))
+	}
+
 	params := []interface{}{
 		job.ID,
 		job.PreviousHash,
-		"",         // coinbase1 (handled by pool)
-		"",         // coinbase2
-		[]string{}, // merkle branches
+		hex.EncodeToString(job.Coinbase1),
+		hex.EncodeToString(job.Coinbase2),
+		merkleBranch,
 		fmt.Sprintf("%08x", job.Height),
 		job.Target,
-		true, // clean jobs
+		job.CleanJobs,
 		// Mobile-specific parameters
 		map[string]interface{}{
+			"seed_hash":      s.shareValidator.SeedHash(job.Height).String(),
 			"thermal_target": job.ThermalTarget,
 			"npu_work":       job.NPUWork,
 			"work_size":      job.WorkSize,
 		},
 	}
 
+	client.mu.Lock()
 	client.currentJob = job
+	client.mu.Unlock()
+
 	s.sendNotification(client, "mining.notify", params)
 }
 
-// setDifficulty updates client difficulty.
-func (s *StratumServer) setDifficulty(client *StratumClient, difficulty float64) {
-	client.difficulty = difficulty
-	client.targetDiff = difficulty
+// handleNewJob announces a new job after moving share validation to the
+// RandomX epoch of its height. Each new tip may mature blocks found by the
+// pool, so their credits are updated as well.
+func (s *StratumServer) handleNewJob(job *MiningJob) {
+	s.shareValidator.Update(job.Height)
+	s.broadcastJob(job)
+
+	s.listenersMtx.Lock()
+	listeners := s.jobListeners
+	s.listenersMtx.Unlock()
+	for _, listener := range listeners {
+		listener(job)
+	}
 
-	s.sendNotification(client, "mining.set_difficulty", []float64{difficulty})
+	if s.payouts != nil && job.CleanJobs {
+		// Blocks are recorded before maturity is checked so a block
+		// that failed to record earlier can still mature. Blocks that
+		// fail to mature stay immature and are checked again on the
+		// next tip.
+		s.recordPendingBlocks()
+		err := s.payouts.UpdateMaturity(job.Height-1, s.jobManager.BlockHash)
+		if err != nil {
+			log.Errorf("Failed to update maturity of found blocks: %v",
+				err)
+		}
+	}
 }
 
-// adjustDifficulty adjusts difficulty based on share submission rate.
-func (s *StratumServer) adjustDifficulty(client *StratumClient) {
-	// Mobile-aware difficulty adjustment
-	// Target: 1 share per 30 seconds for mobile devices
+// extranonce1 returns the extranonce assigned to the miner with the passed
+// ID. Stratum clients and binary channels draw their IDs from the same
+// counter so no two miners search the same coinbase.
+func extranonce1(id uint64) []byte {
+	return binary.BigEndian.AppendUint32(nil, uint32(id))
+}
 
-	if client.lastShareTime.IsZero() {
-		return
-	}
+// addJobListener registers a function called with every new job, used by
+// servers sharing the pool's jobs.
+func (s *StratumServer) addJobListener(listener func(*MiningJob)) {
+	s.listenersMtx.Lock()
+	s.jobListeners = append(s.jobListeners, listener)
+	s.listenersMtx.Unlock()
+}
 
-	timeSinceLastShare := time.Since(client.lastShareTime).Seconds()
+// broadcastJob sends a new job to all authorized clients.
+func (s *StratumServer) broadcastJob(job *MiningJob) {
+	s.clientsMu.RLock()
+	clients := make([]*StratumClient, 0, len(s.clients))
+	for _, client := range s.clients {
+		if client.authorized {
+			clients = append(clients, client)
+		}
+	}
+	s.clientsMu.RUnlock()
 
-	if timeSinceLastShare < 20 {
-		// Too fast, increase difficulty
-		client.targetDiff = client.difficulty * 1.2
-	} else if timeSinceLastShare > 40 {
-		// Too slow, decrease difficulty
-		client.targetDiff = client.difficulty * 0.8
+	for _, client := range clients {
+		s.sendJob(client, job)
 	}
+}
 
-	// Apply smoothing
-	newDiff := client.difficulty*0.7 + client.targetDiff*0.3
+// getJob returns the job the client is currently working on.
+func (c *StratumClient) getJob() *MiningJob {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	// Enforce mobile-friendly bounds
-	if newDiff < s.cfg.MinMobileDifficulty {
-		newDiff = s.cfg.MinMobileDifficulty
-	} else if newDiff > s.cfg.MaxMobileDifficulty {
-		newDiff = s.cfg.MaxMobileDifficulty
-	}
+	return c.currentJob
+}
 
-	if newDiff != client.difficulty {
-		s.setDifficulty(client, newDiff)
-	}
+// setDifficulty updates client difficulty.
+func (s *StratumServer) setDifficulty(client *StratumClient, difficulty float64) {
+	client.difficulty = difficulty
+	client.vardiff.reset(time.Now())
+
+	s.sendNotification(client, "mining.set_difficulty", []float64{difficulty})
 }
 
 // optimizeForDevice adjusts mining parameters for device capabilities.
 func (s *StratumServer) optimizeForDevice(client *StratumClient) {
-	var workSize WorkSizeConfig
-
-	switch client.socModel {
-	case "Snapdragon 8 Gen 3", "A17 Pro", "Tensor G3":
+	var (
+		workSize   WorkSizeConfig
+		difficulty float64
+	)
+
+	class := deviceThermalClass(s.devices, client.deviceModel,
+		client.socModel)
+	switch class {
+	case thermalClassFlagship:
 		// Flagship devices
 		workSize = WorkSizeConfig{
 			SearchSpace:   0x100000,        // 1M nonces
 			NPUIterations: 100,             // Frequent NPU use
 			CacheSize:     3 * 1024 * 1024, // 3MB
 		}
-		client.difficulty = s.cfg.InitialDifficulty
+		difficulty = s.cfg.InitialDifficulty
 
-	case "Snapdragon 7 Gen 3", "A16", "Tensor G2":
+	case thermalClassMidrange:
 		// Mid-range devices
 		workSize = WorkSizeConfig{
 			SearchSpace:   0x80000,         // 512K nonces
 			NPUIterations: 150,             // Less frequent NPU
 			CacheSize:     2 * 1024 * 1024, // 2MB
 		}
-		client.difficulty = s.cfg.InitialDifficulty * 0.7
+		difficulty = s.cfg.InitialDifficulty * 0.7
 
 	default:
 		// Budget devices
@@ -538,9 +812,13 @@ func (s *StratumServer) optimizeForDevice(client *StratumClient) {
 			NPUIterations: 200,         // Minimal NPU use
 			CacheSize:     1024 * 1024, // 1MB
 		}
-		client.difficulty = s.cfg.InitialDifficulty * 0.5
+		difficulty = s.cfg.InitialDifficulty * 0.5
 	}
 
+	// Retarget toward the share rate of the device class
+	client.vardiff = newVardiff(s.cfg, class, time.Now())
+	s.setDifficulty(client, client.vardiff.clamp(difficulty))
+
 	// Store optimized config
 	if client.currentJob != nil {
 		client.currentJob.WorkSize = workSize
@@ -549,7 +827,7 @@ func (s *StratumServer) optimizeForDevice(client *StratumClient) {
 
 // getMobileConfig returns device-specific mining configuration.
 func (s *StratumServer) getMobileConfig(client *StratumClient) map[string]interface{} {
-	return map[string]interface{}{
+	config := map[string]interface{}{
 		"mining_intensity": s.getRecommendedIntensity(client),
 		"thermal_limits": map[string]float64{
 			"throttle_start": 45.0,
@@ -566,14 +844,25 @@ func (s *StratumServer) getMobileConfig(client *StratumClient) map[string]interf
 			"screen_off_only": false,
 		},
 	}
+
+	// Point miners at the encrypted binary protocol when it is served.
+	if s.binary != nil {
+		config["binary_protocol"] = map[string]interface{}{
+			"endpoint":   s.cfg.BinaryEndpoint,
+			"version":    BinaryProtocolVersion,
+			"public_key": hex.EncodeToString(s.binary.PublicKey()),
+		}
+	}
+
+	return config
 }
 
 // getRecommendedIntensity returns recommended mining intensity.
 func (s *StratumServer) getRecommendedIntensity(client *StratumClient) string {
-	switch client.socModel {
-	case "Snapdragon 8 Gen 3", "A17 Pro":
+	switch deviceThermalClass(s.devices, client.deviceModel, client.socModel) {
+	case thermalClassFlagship:
 		return "high"
-	case "Snapdragon 7 Gen 3", "A16":
+	case thermalClassMidrange:
 		return "medium"
 	default:
 		return "low"
@@ -624,6 +913,9 @@ func (s *StratumServer) sendMessage(client *StratumClient, msg *StratumMessage)
 
 	data = append(data, '\n')
 
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
 	if _, err := client.writer.Write(data); err != nil {
 		return err
 	}
@@ -635,15 +927,89 @@ func (s *StratumServer) sendMessage(client *StratumClient, msg *StratumMessage)
 func (s *StratumServer) removeClient(client *StratumClient) {
 	client.conn.Close()
 
+	if client.authorized {
+		s.stats.setOnline(client.workerName, false)
+	}
+
 	s.clientsMu.Lock()
 	delete(s.clients, client.ID)
 	s.clientsMu.Unlock()
 }
 
-// submitBlock submits a found block to the network.
-func (s *StratumServer) submitBlock(block *wire.MsgBlock) {
-	// This would connect to the Shell node to submit the block
-	// Implementation depends on node RPC interface
+// submitBlock submits a found block to the network and tells the miner
+// that found it whether the node accepted it.
+func (s *StratumServer) submitBlock(client *StratumClient, job *MiningJob, block *wire.MsgBlock) {
+	var message string
+	if err := s.processBlock(job, block); err != nil {
+		message = fmt.Sprintf("Block at height %d was not accepted: %v",
+			job.Height, err)
+	} else {
+		message = fmt.Sprintf("Block %s at height %d accepted",
+			block.BlockHash(), job.Height)
+	}
+
+	s.sendNotification(client, "client.show_message", []string{message})
+}
+
+// processBlock submits a found block to the network and records it for
+// payouts once the node accepts it.
+func (s *StratumServer) processBlock(job *MiningJob, block *wire.MsgBlock) error {
+	if err := s.jobManager.SubmitBlock(block); err != nil {
+		return err
+	}
+
+	if s.payouts != nil {
+		s.pendingMtx.Lock()
+		s.pendingBlocks = append(s.pendingBlocks, foundBlock{
+			height: job.Height,
+			hash:   block.BlockHash(),
+			reward: job.CoinbaseValue,
+		})
+		s.pendingMtx.Unlock()
+		s.recordPendingBlocks()
+	}
+
+	return nil
+}
+
+// foundBlock is a block found by the pool and accepted by the node.
+type foundBlock struct {
+	height int32
+	hash   chainhash.Hash
+	reward int64
+}
+
+// recordShare records an accepted share for payouts. The share stays
+// accepted if the ledger write fails.
+func (s *StratumServer) recordShare(worker string, difficulty float64, npu bool, job *MiningJob) {
+	if s.payouts == nil {
+		return
+	}
+
+	err := s.payouts.ShareAccepted(worker, difficulty, npu, job)
+	if err != nil {
+		log.Errorf("Failed to record share of %s: %v", worker, err)
+	}
+}
+
+// recordPendingBlocks records the found blocks not yet recorded for
+// payouts. Blocks that fail to record are kept for the next attempt, since
+// their miners are only credited once the block is in the ledger.
+func (s *StratumServer) recordPendingBlocks() {
+	s.pendingMtx.Lock()
+	defer s.pendingMtx.Unlock()
+
+	remaining := s.pendingBlocks[:0]
+	for _, block := range s.pendingBlocks {
+		err := s.payouts.BlockFound(block.height, &block.hash, block.reward)
+		if err != nil {
+			log.Errorf("Failed to record block %s at height %d for "+
+				"payouts, retrying on the next block: %v",
+				block.hash, block.height, err)
+			remaining = append(remaining, block)
+		}
+	}
+	s.pendingBlocks = remaining
 }
 
 // handleGetTransactions handles mining.get_transactions requests (returns empty for now).
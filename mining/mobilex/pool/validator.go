@@ -10,20 +10,38 @@ import (
 	"fmt"
 	"math/big"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/toole-brendan/shell/blockchain"
 	"github.com/toole-brendan/shell/chaincfg"
 	"github.com/toole-brendan/shell/chaincfg/chainhash"
 	"github.com/toole-brendan/shell/mining/mobilex"
+	"github.com/toole-brendan/shell/mining/randomx"
 	"github.com/toole-brendan/shell/wire"
 )
 
+var (
+	// ErrThermalProof is returned for shares whose thermal proof is
+	// invalid.
+	ErrThermalProof = errors.New("thermal validation failed")
+
+	// ErrJobNotFound is returned for shares on unknown or stale jobs.
+	ErrJobNotFound = errors.New("job not found")
+
+	// ErrDuplicateShare is returned for shares submitted before.
+	ErrDuplicateShare = errors.New("duplicate share")
+
+	// ErrLowDifficulty is returned for shares above the share target.
+	ErrLowDifficulty = errors.New("low difficulty share")
+)
+
 // Share represents a submitted mining share.
 type Share struct {
 	ClientID     uint64
 	WorkerName   string
 	JobID        string
+	Extranonce1  string
 	Extranonce2  string
 	Ntime        string
 	Nonce        string
@@ -45,22 +63,51 @@ type ShareResult struct {
 type ShareValidator struct {
 	cfg         *PoolConfig
 	chainParams *chaincfg.Params
+	seeds       *randomx.SeedManager
 
-	// Duplicate detection
+	// Duplicate detection. Shares are validated concurrently by every
+	// miner connection, so recentShares is guarded by sharesMtx.
 	recentShares map[string]time.Time
+	sharesMtx    sync.Mutex
 	shareExpiry  time.Duration
 }
 
-// NewShareValidator creates a new share validator.
+// NewShareValidator creates a new share validator.  Shares are hashed with
+// MobileX on top of the RandomX epoch of the height of their job, the same
+// way the node validates blocks, so the validator follows each seed rotation.
 func NewShareValidator(cfg *PoolConfig, chainParams *chaincfg.Params) *ShareValidator {
+	seeds := randomx.NewSeedManager(&randomx.SeedConfig{
+		GenesisHash: chainParams.GenesisHash,
+		Rotation:    chainParams.RandomXSeedRotation,
+	})
+
 	return &ShareValidator{
 		cfg:          cfg,
 		chainParams:  chainParams,
+		seeds:        seeds,
 		recentShares: make(map[string]time.Time),
 		shareExpiry:  5 * time.Minute,
 	}
 }
 
+// Update informs the validator of the height of the newest job so the epoch
+// of the next seed rotation is computed before shares on it arrive.
+func (sv *ShareValidator) Update(height int32) {
+	sv.seeds.Update(height)
+}
+
+// SeedHash returns the RandomX seed shares on a job at the passed height are
+// hashed with.
+func (sv *ShareValidator) SeedHash(height int32) chainhash.Hash {
+	seedHeight := randomx.SeedHeight(height, sv.chainParams.RandomXSeedRotation)
+	return randomx.SeedForHeight(seedHeight, sv.chainParams.GenesisHash)
+}
+
+// Close releases the RandomX resources held by the validator.
+func (sv *ShareValidator) Close() {
+	sv.seeds.Stop()
+}
+
 // ValidateShare validates a submitted share.
 func (sv *ShareValidator) ValidateShare(share *Share, job *MiningJob) (*ShareResult, error) {
 	result := &ShareResult{}
@@ -73,19 +120,25 @@ func (sv *ShareValidator) ValidateShare(share *Share, job *MiningJob) (*ShareRes
 
 	// Check for duplicate
 	if sv.isDuplicate(share) {
-		result.Error = errors.New("duplicate share")
+		result.Error = ErrDuplicateShare
 		return result, result.Error
 	}
 
 	// Validate job ID
 	if share.JobID != job.ID {
-		result.Error = errors.New("invalid job ID")
+		result.Error = ErrJobNotFound
 		return result, result.Error
 	}
 
 	// Parse share components
+	extranonce1, err := hex.DecodeString(share.Extranonce1)
+	if err != nil || len(extranonce1) != extranonce1Size {
+		result.Error = errors.New("invalid extranonce1")
+		return result, result.Error
+	}
+
 	extranonce2, err := hex.DecodeString(share.Extranonce2)
-	if err != nil || len(extranonce2) != 4 {
+	if err != nil || len(extranonce2) != extranonce2Size {
 		result.Error = errors.New("invalid extranonce2")
 		return result, result.Error
 	}
@@ -114,8 +167,14 @@ func (sv *ShareValidator) ValidateShare(share *Share, job *MiningJob) (*ShareRes
 		return result, result.Error
 	}
 
-	// Build block header
-	header, err := sv.buildBlockHeader(share, job, ntime, uint32(nonce), thermalProof)
+	// Build the miner's coinbase and the block header committing to it
+	coinbaseTx, err := job.coinbase(extranonce1, extranonce2)
+	if err != nil {
+		result.Error = err
+		return result, err
+	}
+	header, err := sv.buildBlockHeader(job, coinbaseTx, ntime,
+		uint32(nonce), thermalProof)
 	if err != nil {
 		result.Error = err
 		return result, err
@@ -124,13 +183,17 @@ func (sv *ShareValidator) ValidateShare(share *Share, job *MiningJob) (*ShareRes
 	// Validate thermal proof
 	if sv.cfg.ThermalCompliance {
 		if err := sv.validateThermalProof(header); err != nil {
-			result.Error = fmt.Errorf("thermal validation failed: %w", err)
+			result.Error = fmt.Errorf("%w: %v", ErrThermalProof, err)
 			return result, result.Error
 		}
 	}
 
 	// Compute MobileX hash
-	hash := sv.computeMobileXHash(header)
+	hash, err := sv.computeMobileXHash(header, job.Height)
+	if err != nil {
+		result.Error = err
+		return result, err
+	}
 
 	// Check difficulty
 	hashBig := mobilex.HashToBig(&hash)
@@ -138,7 +201,7 @@ func (sv *ShareValidator) ValidateShare(share *Share, job *MiningJob) (*ShareRes
 	// Pool difficulty target
 	poolTarget := sv.difficultyToTarget(share.Difficulty)
 	if hashBig.Cmp(poolTarget) > 0 {
-		result.Error = errors.New("share above target")
+		result.Error = ErrLowDifficulty
 		return result, result.Error
 	}
 	result.MeetsPoolDifficulty = true
@@ -149,12 +212,7 @@ func (sv *ShareValidator) ValidateShare(share *Share, job *MiningJob) (*ShareRes
 		result.MeetsNetworkDifficulty = true
 
 		// Build full block
-		block, err := sv.buildFullBlock(header, job)
-		if err != nil {
-			result.Error = err
-			return result, err
-		}
-		result.Block = block
+		result.Block = sv.buildFullBlock(header, job, coinbaseTx)
 	}
 
 	// Mark share as valid
@@ -174,7 +232,10 @@ func (sv *ShareValidator) validateBasic(share *Share) error {
 	if share.JobID == "" {
 		return errors.New("missing job ID")
 	}
-	if len(share.Extranonce2) != 8 { // 4 bytes hex
+	if len(share.Extranonce1) != 2*extranonce1Size {
+		return errors.New("invalid extranonce1 length")
+	}
+	if len(share.Extranonce2) != 2*extranonce2Size {
 		return errors.New("invalid extranonce2 length")
 	}
 	if len(share.Ntime) != 8 { // 4 bytes hex
@@ -192,14 +253,18 @@ func (sv *ShareValidator) validateBasic(share *Share) error {
 // isDuplicate checks if a share is a duplicate.
 func (sv *ShareValidator) isDuplicate(share *Share) bool {
 	// Create unique key
-	key := fmt.Sprintf("%s:%s:%s:%s:%s",
+	key := fmt.Sprintf("%s:%s:%s:%s:%s:%s",
 		share.WorkerName,
 		share.JobID,
+		share.Extranonce1,
 		share.Extranonce2,
 		share.Ntime,
 		share.Nonce,
 	)
 
+	sv.sharesMtx.Lock()
+	defer sv.sharesMtx.Unlock()
+
 	// Check if exists
 	if submitTime, exists := sv.recentShares[key]; exists {
 		// Still within expiry window
@@ -213,14 +278,18 @@ func (sv *ShareValidator) isDuplicate(share *Share) bool {
 
 // recordShare records a share to prevent duplicates.
 func (sv *ShareValidator) recordShare(share *Share) {
-	key := fmt.Sprintf("%s:%s:%s:%s:%s",
+	key := fmt.Sprintf("%s:%s:%s:%s:%s:%s",
 		share.WorkerName,
 		share.JobID,
+		share.Extranonce1,
 		share.Extranonce2,
 		share.Ntime,
 		share.Nonce,
 	)
 
+	sv.sharesMtx.Lock()
+	defer sv.sharesMtx.Unlock()
+
 	sv.recentShares[key] = time.Now()
 
 	// Clean old entries periodically
@@ -257,25 +326,21 @@ func (sv *ShareValidator) validateTime(ntime int64, job *MiningJob) bool {
 	return true
 }
 
-// buildBlockHeader builds a block header from share data.
-func (sv *ShareValidator) buildBlockHeader(share *Share, job *MiningJob, ntime int64, nonce uint32, thermalProof uint64) (*wire.BlockHeader, error) {
+// buildBlockHeader builds the header of a job's block with the passed
+// coinbase from share data.
+func (sv *ShareValidator) buildBlockHeader(job *MiningJob, coinbaseTx *wire.MsgTx, ntime int64, nonce uint32, thermalProof uint64) (*wire.BlockHeader, error) {
 	// Parse previous block hash
 	prevHash, err := chainhash.NewHashFromStr(job.PreviousHash)
 	if err != nil {
 		return nil, err
 	}
 
-	// TODO: Calculate merkle root from transactions
-	// For now, use empty merkle root
-	merkleRoot := chainhash.Hash{}
-
-	// Build header
 	header := &wire.BlockHeader{
-		Version:      1, // Block version 1
+		Version:      job.Version,
 		PrevBlock:    *prevHash,
-		MerkleRoot:   merkleRoot,
+		MerkleRoot:   job.merkleRoot(coinbaseTx),
 		Timestamp:    time.Unix(ntime, 0),
-		Bits:         sv.targetToBits(job.Target),
+		Bits:         job.Bits,
 		Nonce:        nonce,
 		ThermalProof: thermalProof,
 	}
@@ -296,22 +361,10 @@ func (sv *ShareValidator) validateThermalProof(header *wire.BlockHeader) error {
 	return nil
 }
 
-// computeMobileXHash computes the MobileX hash for validation.
-func (sv *ShareValidator) computeMobileXHash(header *wire.BlockHeader) chainhash.Hash {
-	// This would use the actual MobileX miner implementation
-	// For now, return a simple hash
-
-	headerBytes := sv.serializeHeader(header)
-	return chainhash.DoubleHashH(headerBytes)
-}
-
-// serializeHeader serializes a block header.
-func (sv *ShareValidator) serializeHeader(header *wire.BlockHeader) []byte {
-	// Simplified serialization
-	// In production, use proper wire protocol serialization
-	buf := make([]byte, 88)
-	// ... serialization logic ...
-	return buf
+// computeMobileXHash computes the MobileX hash of a header of the block at
+// the passed height for validation.
+func (sv *ShareValidator) computeMobileXHash(header *wire.BlockHeader, height int32) (chainhash.Hash, error) {
+	return mobilex.HashHeaderAt(sv.seeds, height, header)
 }
 
 // difficultyToTarget converts pool difficulty to target.
@@ -321,29 +374,38 @@ func (sv *ShareValidator) difficultyToTarget(difficulty float64) *big.Int {
 
 	diffOne := new(big.Int)
 	diffOne.SetString("00000000ffff0000000000000000000000000000000000000000000000000000", 16)
+	if difficulty <= 0 {
+		return diffOne
+	}
 
-	// Target = diffOne / difficulty
-	target := new(big.Int).Div(diffOne, big.NewInt(int64(difficulty)))
+	// Target = diffOne / difficulty, computed in floating point since
+	// mobile difficulties go below 1
+	target, _ := new(big.Float).Quo(new(big.Float).SetInt(diffOne),
+		big.NewFloat(difficulty)).Int(nil)
 
 	return target
 }
 
-// targetToBits converts a target hex string to compact bits.
-func (sv *ShareValidator) targetToBits(targetHex string) uint32 {
-	// Convert hex target to compact bits representation
-	// This is a simplified version
-	target, _ := new(big.Int).SetString(targetHex, 16)
-	return blockchain.BigToCompact(target)
-}
-
-// buildFullBlock builds a complete block from a valid share.
-func (sv *ShareValidator) buildFullBlock(header *wire.BlockHeader, job *MiningJob) (*wire.MsgBlock, error) {
+// buildFullBlock builds the complete block of a job from a header meeting
+// the network target and the coinbase it commits to.
+func (sv *ShareValidator) buildFullBlock(header *wire.BlockHeader, job *MiningJob, coinbaseTx *wire.MsgTx) *wire.MsgBlock {
 	block := &wire.MsgBlock{
 		Header: *header,
 	}
 
-	// TODO: Add transactions from job template
-	// For now, just add coinbase
+	// Blocks committing to witnesses carry the witness nonce in the
+	// coinbase input.
+	if job.template != nil && len(job.template.WitnessCommitment) > 0 {
+		coinbaseTx.TxIn[0].Witness = wire.TxWitness{
+			make([]byte, blockchain.CoinbaseWitnessDataLen),
+		}
+	}
+	block.AddTransaction(coinbaseTx)
+	if job.template != nil {
+		for _, tx := range job.template.Transactions {
+			block.AddTransaction(tx)
+		}
+	}
 
-	return block, nil
+	return block
 }
@@ -0,0 +1,268 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/shell/addresses"
+	"github.com/toole-brendan/shell/blockchain"
+	"github.com/toole-brendan/shell/chaincfg"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/internal/convert"
+	"github.com/toole-brendan/shell/mining/mobilex"
+	"github.com/toole-brendan/shell/mining/randomx"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// TestShareValidatorEpochs tests that shares are hashed on top of the RandomX
+// epoch of the height of their job, like blocks are by the node
+func TestShareValidatorEpochs(t *testing.T) {
+	params := &chaincfg.MainNetParams
+	sv := NewShareValidator(DefaultPoolConfig(), params)
+	defer sv.Close()
+
+	seeds := randomx.NewSeedManager(&randomx.SeedConfig{
+		GenesisHash: params.GenesisHash,
+		Rotation:    params.RandomXSeedRotation,
+	})
+	defer seeds.Stop()
+
+	header := &wire.BlockHeader{
+		Version:   1,
+		Timestamp: time.Unix(1700000000, 0),
+		Bits:      0x1d00ffff,
+		Nonce:     12345,
+	}
+
+	rotation := params.RandomXSeedRotation
+	for _, height := range []int32{1, rotation + 1, 2*rotation + 1} {
+		sv.Update(height)
+		hash, err := sv.computeMobileXHash(header, height)
+		require.NoError(t, err)
+
+		want, err := mobilex.HashHeaderAt(seeds, height, header)
+		require.NoError(t, err)
+		require.Equal(t, want, hash, "height %d", height)
+	}
+
+	// Jobs in different epochs are hashed with different seeds.
+	first, err := sv.computeMobileXHash(header, 1)
+	require.NoError(t, err)
+	second, err := sv.computeMobileXHash(header, 2*rotation+1)
+	require.NoError(t, err)
+	require.NotEqual(t, first, second)
+
+	require.Equal(t, *params.GenesisHash, sv.SeedHash(1))
+	require.Equal(t, randomx.SeedForHeight(2*rotation, params.GenesisHash),
+		sv.SeedHash(2*rotation+1))
+}
+
+// TestShareValidatorBlock tests that a share meeting the network target
+// becomes a block paying the pool that passes the node's sanity checks
+func TestShareValidatorBlock(t *testing.T) {
+	params := &chaincfg.MainNetParams
+	cfg := DefaultPoolConfig()
+	cfg.ThermalCompliance = false
+	cfg.PoolAddress = testAccount(t, 0xff)
+
+	var txns []*wire.MsgTx
+	for i := byte(1); i <= 2; i++ {
+		tx := wire.NewMsgTx(wire.TxVersion)
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{i}, 0),
+			[]byte{0x51}, nil))
+		tx.AddTxOut(wire.NewTxOut(1000, []byte{0x51}))
+		txns = append(txns, tx)
+	}
+
+	// Every hash meets the network target of the template.
+	result := testTemplate(1000, chainhash.Hash{0xaa}, txns...)
+	result.Target = strings.Repeat("f", 64)
+	template, err := templateFromResult(&result)
+	require.NoError(t, err)
+
+	jm := NewJobManager(cfg, params)
+	require.NoError(t, jm.updateJob(template))
+	job := jm.GetCurrentJob()
+	require.Len(t, job.MerkleBranch, 2)
+
+	sv := NewShareValidator(cfg, params)
+	defer sv.Close()
+
+	share := &Share{
+		WorkerName:   "alice.phone",
+		JobID:        job.ID,
+		Extranonce1:  hex.EncodeToString(extranonce1(1)),
+		Extranonce2:  "0000002a",
+		Ntime:        fmt.Sprintf("%08x", time.Now().Unix()),
+		Nonce:        "00000000",
+		ThermalProof: "0000000000000000",
+		Difficulty:   1e-12,
+	}
+	shareResult, err := sv.ValidateShare(share, job)
+	require.NoError(t, err)
+	require.True(t, shareResult.MeetsNetworkDifficulty)
+
+	block := shareResult.Block
+	require.Len(t, block.Transactions, 3)
+	require.Equal(t, txns[0].TxHash(), block.Transactions[1].TxHash())
+	require.Equal(t, template.Bits, block.Header.Bits)
+
+	poolScript, err := AddrToScript(cfg.PoolAddress, params)
+	require.NoError(t, err)
+	coinbase := block.Transactions[0]
+	require.Equal(t, poolScript, coinbase.TxOut[0].PkScript)
+	require.Equal(t, template.CoinbaseValue, coinbase.TxOut[0].Value)
+	require.True(t, bytes.Contains(coinbase.TxIn[0].SignatureScript,
+		[]byte{0, 0, 0, 1, 0, 0, 0, 0x2a}))
+
+	err = blockchain.CheckBlockSanity(convert.NewShellBlock(block),
+		params.PowLimit, blockchain.NewMedianTime())
+	require.NoError(t, err)
+}
+
+// testHeaderCtx is a block of a test chain for contextual header checks.
+type testHeaderCtx struct {
+	header *wire.BlockHeader
+	height int32
+	parent *testHeaderCtx
+}
+
+func (n *testHeaderCtx) Height() int32    { return n.height }
+func (n *testHeaderCtx) Bits() uint32     { return n.header.Bits }
+func (n *testHeaderCtx) Version() int32   { return n.header.Version }
+func (n *testHeaderCtx) Timestamp() int64 { return n.header.Timestamp.Unix() }
+
+func (n *testHeaderCtx) Parent() blockchain.HeaderCtx {
+	if n.parent == nil {
+		return nil
+	}
+	return n.parent
+}
+
+func (n *testHeaderCtx) RelativeAncestorCtx(distance int32) blockchain.HeaderCtx {
+	node := n
+	for ; node != nil && distance > 0; distance-- {
+		node = node.parent
+	}
+	if node == nil {
+		return nil
+	}
+	return node
+}
+
+// testChainCtx provides the parameters of a test chain without checkpoints.
+type testChainCtx struct {
+	params *chaincfg.Params
+}
+
+func (c *testChainCtx) ChainParams() *chaincfg.Params { return c.params }
+
+func (c *testChainCtx) BlocksPerRetarget() int32 {
+	return int32(c.params.TargetTimespan / c.params.TargetTimePerBlock)
+}
+
+func (c *testChainCtx) MinRetargetTimespan() int64 {
+	return int64(c.params.TargetTimespan/time.Second) /
+		c.params.RetargetAdjustmentFactor
+}
+
+func (c *testChainCtx) MaxRetargetTimespan() int64 {
+	return int64(c.params.TargetTimespan/time.Second) *
+		c.params.RetargetAdjustmentFactor
+}
+
+func (c *testChainCtx) VerifyCheckpoint(int32, *chainhash.Hash) bool { return true }
+
+func (c *testChainCtx) FindPreviousCheckpoint() (blockchain.HeaderCtx, error) {
+	return nil, nil
+}
+
+// TestPoolBlockConsensus tests that a block the pool builds from the node's
+// MobileX template and submits to the node passes the consensus checks of a
+// chain on which MobileX is active
+func TestPoolBlockConsensus(t *testing.T) {
+	params := chaincfg.RegressionNetParams
+	params.MobileXActivationHeight = 1
+	genesis := &testHeaderCtx{header: &params.GenesisBlock.Header}
+
+	// The node serves a MobileX template building on the genesis block.
+	result := testTemplate(1, *params.GenesisHash)
+	// MobileX is encoded in versions using the version bits scheme.
+	result.Version = blockchain.SetPowAlgorithm(0x20000000,
+		blockchain.PowAlgoMobileX)
+	result.Bits = fmt.Sprintf("%08x", params.PowLimitBits)
+	result.Target = fmt.Sprintf("%064x", params.PowLimit)
+	node, cfg := newStubNode(t, result)
+	cfg.ThermalCompliance = false
+	poolAddr, err := addresses.NewShellP2PKHAddress(make([]byte, 20), &params)
+	require.NoError(t, err)
+	cfg.PoolAddress = poolAddr.String()
+
+	jm := NewJobManager(cfg, &params)
+	template, err := jm.node.GetBlockTemplate(context.Background(), "")
+	require.NoError(t, err)
+	require.NoError(t, jm.updateJob(template))
+	job := jm.GetCurrentJob()
+
+	sv := NewShareValidator(cfg, &params)
+	defer sv.Close()
+
+	// Roll the nonce until a share meets the network target.
+	var block *wire.MsgBlock
+	for nonce := uint32(0); block == nil; nonce++ {
+		require.Less(t, nonce, uint32(1000))
+		share := &Share{
+			WorkerName:   "alice.phone",
+			JobID:        job.ID,
+			Extranonce1:  hex.EncodeToString(extranonce1(1)),
+			Extranonce2:  "00000000",
+			Ntime:        fmt.Sprintf("%08x", time.Now().Unix()),
+			Nonce:        fmt.Sprintf("%08x", nonce),
+			ThermalProof: "00000000000007d0",
+			Difficulty:   1e-12,
+		}
+		shareResult, err := sv.ValidateShare(share, job)
+		require.NoError(t, err)
+		block = shareResult.Block
+	}
+	require.NoError(t, jm.SubmitBlock(block))
+
+	node.mu.Lock()
+	require.Len(t, node.submitted, 1)
+	serialized, err := hex.DecodeString(node.submitted[0])
+	node.mu.Unlock()
+	require.NoError(t, err)
+	var submitted wire.MsgBlock
+	require.NoError(t, submitted.Deserialize(bytes.NewReader(serialized)))
+	header := &submitted.Header
+
+	require.Equal(t, blockchain.PowAlgoMobileX,
+		blockchain.PowAlgorithmFromVersion(header.Version))
+	err = blockchain.CheckBlockSanity(convert.NewShellBlock(&submitted),
+		params.PowLimit, blockchain.NewMedianTime())
+	require.NoError(t, err)
+	err = blockchain.CheckBlockHeaderContext(header, genesis,
+		blockchain.BFNone, &testChainCtx{params: &params}, true)
+	require.NoError(t, err)
+
+	// The MobileX hash of the block meets the target of its bits.
+	seeds := randomx.NewSeedManager(&randomx.SeedConfig{
+		GenesisHash: params.GenesisHash,
+		Rotation:    params.RandomXSeedRotation,
+	})
+	defer seeds.Stop()
+	hash, err := mobilex.HashHeaderAt(seeds, 1, header)
+	require.NoError(t, err)
+	require.True(t, blockchain.HashToBig(&hash).Cmp(
+		blockchain.CompactToBig(header.Bits)) <= 0)
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"math"
+	"time"
+
+	"github.com/toole-brendan/shell/mining/mobilex"
+)
+
+// Thermal classes of mobilex.DeviceProfile, which select a device's share
+// rate target.
+const (
+	thermalClassFlagship = mobilex.ThermalClassFlagship
+	thermalClassMidrange = mobilex.ThermalClassMidrange
+	thermalClassBudget   = mobilex.ThermalClassBudget
+)
+
+// maxRetargetFactor bounds how far a single retarget moves the difficulty.
+const maxRetargetFactor = 4.0
+
+// deviceThermalClass returns the thermal class of a device by its model and
+// SoC as found in the passed device profiles. Unknown devices are treated as
+// budget devices.
+func deviceThermalClass(devices *mobilex.DeviceRegistry, deviceModel, socModel string) string {
+	class := devices.Lookup(deviceModel, socModel).ThermalClass
+	if class == "" {
+		return thermalClassBudget
+	}
+	return class
+}
+
+// vardiff adjusts a miner's difficulty so it submits shares at the target
+// rate of its device class. Shares are counted over a window of at least
+// the retarget interval, and the difficulty only changes when the observed
+// rate is off the target by more than the allowed variance.
+type vardiff struct {
+	sharesPerMinute float64
+	minDifficulty   float64
+	maxDifficulty   float64
+	interval        time.Duration
+	variance        float64
+
+	windowStart time.Time
+	shares      int
+}
+
+// newVardiff creates a controller for a device of the given thermal class.
+func newVardiff(cfg *PoolConfig, thermalClass string, now time.Time) *vardiff {
+	rate, ok := cfg.TargetSharesPerMinute[thermalClass]
+	if !ok || rate <= 0 {
+		rate = cfg.TargetSharesPerMinute[thermalClassBudget]
+	}
+	if rate <= 0 {
+		rate = 1
+	}
+
+	return &vardiff{
+		sharesPerMinute: rate,
+		minDifficulty:   cfg.MinMobileDifficulty,
+		maxDifficulty:   cfg.MaxMobileDifficulty,
+		interval:        cfg.DifficultyRetarget,
+		variance:        cfg.VardiffVariance,
+		windowStart:     now,
+	}
+}
+
+// shareAccepted counts an accepted share and retargets if due.
+func (v *vardiff) shareAccepted(difficulty float64, now time.Time) (float64, bool) {
+	v.shares++
+	return v.retarget(difficulty, now)
+}
+
+// retarget returns the new difficulty and true when the retarget interval
+// has passed and the share rate is off target. Miners that stopped
+// submitting shares are retargeted on calls without a share.
+func (v *vardiff) retarget(difficulty float64, now time.Time) (float64, bool) {
+	elapsed := now.Sub(v.windowStart)
+	if elapsed < v.interval || elapsed <= 0 {
+		return difficulty, false
+	}
+
+	rate := float64(v.shares) / elapsed.Minutes()
+	factor := rate / v.sharesPerMinute
+	factor = math.Max(factor, 1/maxRetargetFactor)
+	factor = math.Min(factor, maxRetargetFactor)
+
+	v.windowStart = now
+	v.shares = 0
+
+	if math.Abs(factor-1) <= v.variance {
+		return difficulty, false
+	}
+
+	newDiff := v.clamp(difficulty * factor)
+	return newDiff, newDiff != difficulty
+}
+
+// reset restarts the share window, used when the difficulty is changed
+// outside the controller.
+func (v *vardiff) reset(now time.Time) {
+	v.windowStart = now
+	v.shares = 0
+}
+
+// clamp bounds a difficulty to the pool's mobile difficulty range.
+func (v *vardiff) clamp(difficulty float64) float64 {
+	return math.Min(math.Max(difficulty, v.minDifficulty), v.maxDifficulty)
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package pool
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/shell/chaincfg"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/mining/mobilex"
+)
+
+// TestVardiff tests retargeting toward the share rate of a device class
+func TestVardiff(t *testing.T) {
+	cfg := DefaultPoolConfig()
+	start := time.Unix(1700000000, 0)
+	v := newVardiff(cfg, thermalClassFlagship, start)
+	require.Equal(t, 4.0, v.sharesPerMinute)
+
+	// Nothing changes before the retarget interval.
+	diff, ok := v.shareAccepted(10, start.Add(time.Second))
+	require.False(t, ok)
+	require.Equal(t, 10.0, diff)
+
+	// Twice the target rate doubles the difficulty.
+	for i := 1; i < 11; i++ {
+		v.shareAccepted(10, start.Add(time.Duration(i)*time.Second))
+	}
+	diff, ok = v.shareAccepted(10, start.Add(cfg.DifficultyRetarget))
+	require.True(t, ok)
+	require.InDelta(t, 20, diff, 1e-9)
+
+	// Rates within the variance leave the difficulty alone.
+	start = start.Add(cfg.DifficultyRetarget)
+	for i := 0; i < 6; i++ {
+		diff, ok = v.shareAccepted(20, start.Add(time.Duration(i+1)*15*time.Second))
+	}
+	require.False(t, ok)
+	require.Equal(t, 20.0, diff)
+
+	// Idle miners fall by at most the retarget factor, and never below
+	// the minimum difficulty.
+	start = start.Add(90 * time.Second)
+	diff, ok = v.retarget(20, start.Add(10*time.Minute))
+	require.True(t, ok)
+	require.Equal(t, 20/maxRetargetFactor, diff)
+
+	diff, ok = v.retarget(0.2, start.Add(20*time.Minute))
+	require.True(t, ok)
+	require.Equal(t, cfg.MinMobileDifficulty, diff)
+
+	// Unknown devices mine at the budget rate.
+	devices := mobilex.NewDeviceRegistry()
+	class := deviceThermalClass(devices, "", "Helio G99")
+	require.Equal(t, thermalClassBudget, class)
+	v = newVardiff(cfg, class, start)
+	require.Equal(t, 2.0, v.sharesPerMinute)
+
+	// Devices are matched by model before SoC.
+	require.Equal(t, thermalClassFlagship,
+		deviceThermalClass(devices, "", "Snapdragon 8 Gen 3"))
+	require.Equal(t, thermalClassFlagship,
+		deviceThermalClass(devices, "Pixel 8 Pro", "Helio G99"))
+	require.Equal(t, thermalClassMidrange,
+		deviceThermalClass(devices, "Pixel 7a", "Tensor G3"))
+}
+
+// TestStratumShareErrors tests the Stratum error codes of rejected shares
+// and banning clients that keep sending invalid shares
+func TestStratumShareErrors(t *testing.T) {
+	cfg := DefaultPoolConfig()
+	cfg.DatabasePath = ""
+	cfg.ThermalCompliance = false
+	cfg.BanThreshold = 50
+	cfg.PoolAddress = testAccount(t, 0xff)
+
+	s, err := NewStratumServer(cfg, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	result := testTemplate(100, chainhash.Hash{0x07})
+	template, err := templateFromResult(&result)
+	require.NoError(t, err)
+	require.NoError(t, s.jobManager.updateJob(template))
+
+	conn, remote := net.Pipe()
+	defer remote.Close()
+	client := &StratumClient{
+		ID:          1,
+		conn:        conn,
+		reader:      bufio.NewReader(conn),
+		writer:      bufio.NewWriter(conn),
+		extranonce1: extranonce1(1),
+		difficulty:  cfg.InitialDifficulty,
+		vardiff:     newVardiff(cfg, thermalClassBudget, time.Now()),
+	}
+
+	// Responses are collected from the miner's end of the pipe.
+	responses := make(chan *StratumMessage, 16)
+	go func() {
+		decoder := json.NewDecoder(remote)
+		for {
+			var msg StratumMessage
+			if err := decoder.Decode(&msg); err != nil {
+				close(responses)
+				return
+			}
+			if msg.ID != nil {
+				responses <- &msg
+			}
+		}
+	}()
+
+	request := func(method string, params ...string) *StratumMessage {
+		msg := &StratumMessage{
+			ID:     1,
+			Method: method,
+			Params: mustMarshalJSON(params),
+		}
+		require.NoError(t, s.handleMethod(client, msg))
+		return <-responses
+	}
+	ntime := fmt.Sprintf("%08x", time.Now().Unix())
+	submit := func(worker, jobID string) *StratumMessage {
+		return request("mining.submit", worker, jobID, "00000000", ntime,
+			"00000000", "0000000000000000")
+	}
+
+	resp := submit("alice.phone", "1")
+	require.Equal(t, StratumErrUnauthorized, resp.Error.Code)
+
+	resp = request("mining.authorize", "alice.phone", "x")
+	require.Nil(t, resp.Error)
+	require.Equal(t, true, resp.Result)
+
+	resp = submit("bob.phone", "1")
+	require.Equal(t, StratumErrUnauthorized, resp.Error.Code)
+
+	resp = submit("alice.phone", "42")
+	require.Equal(t, StratumErrJobNotFound, resp.Error.Code)
+
+	resp = submit("alice.phone", "1")
+	require.Equal(t, StratumErrLowDifficulty, resp.Error.Code)
+
+	s.shareValidator.recordShare(&Share{
+		WorkerName:  "alice.phone",
+		JobID:       "1",
+		Extranonce1: "00000001",
+		Extranonce2: "00000000",
+		Ntime:       ntime,
+		Nonce:       "00000000",
+	})
+	resp = submit("alice.phone", "1")
+	require.Equal(t, StratumErrDuplicateShare, resp.Error.Code)
+
+	require.Equal(t, uint64(5), client.submittedShares)
+	require.Equal(t, uint64(5), client.rejectedShares)
+	require.Zero(t, client.acceptedShares)
+
+	// Stale shares do not count toward a ban, but the invalid shares
+	// so far plus one more cross the threshold and ban the worker.
+	require.False(t, s.isBanned("alice.phone"))
+	submit("alice.phone", "1")
+	require.True(t, s.isBanned("alice.phone"))
+	require.False(t, s.isBanned("bob.phone"))
+
+	_, open := <-responses
+	require.False(t, open)
+}
@@ -0,0 +1,279 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mobilex
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// DeviceProfileDBVersion is the version of the device profile database format
+// this package reads and writes.
+const DeviceProfileDBVersion = 1
+
+// Thermal classes of device profiles.  Unknown devices are budget devices.
+const (
+	ThermalClassFlagship = "flagship"
+	ThermalClassMidrange = "midrange"
+	ThermalClassBudget   = "budget"
+)
+
+// defaultDeviceProfiles is the device profile database shipped with the miner.
+//
+//go:embed profiles.json
+var defaultDeviceProfiles []byte
+
+var (
+	// ErrUnsupportedProfileDB indicates a device profile database was
+	// written in a newer format than this package supports.
+	ErrUnsupportedProfileDB = errors.New("unsupported device profile " +
+		"database version")
+)
+
+// DeviceProfile represents a specific device's mining capabilities.
+type DeviceProfile struct {
+	Name         string   `json:"name"`
+	Models       []string `json:"models,omitempty"` // Device model patterns
+	SoC          string   `json:"soc"`              // System on Chip name
+	SoCs         []string `json:"socs,omitempty"`   // SoC name patterns
+	MaxHashRate  float64  `json:"max_hash_rate"`
+	CoreCount    int      `json:"core_count"`
+	HasNPU       bool     `json:"has_npu"`
+	NPUType      string   `json:"npu_type,omitempty"` // "coreml", "nnapi", "snpe", etc.
+	ThermalClass string   `json:"thermal_class"`      // "flagship", "midrange", "budget"
+}
+
+// copy returns a deep copy of the profile.
+func (p *DeviceProfile) copy() *DeviceProfile {
+	profile := *p
+	profile.Models = append([]string(nil), p.Models...)
+	profile.SoCs = append([]string(nil), p.SoCs...)
+	return &profile
+}
+
+// unknownDeviceProfile returns the profile of devices that match no profile in
+// the database.
+func unknownDeviceProfile() *DeviceProfile {
+	return &DeviceProfile{
+		Name:         "Unknown",
+		SoC:          "Unknown",
+		MaxHashRate:  50.0,
+		CoreCount:    4,
+		HasNPU:       false,
+		ThermalClass: ThermalClassBudget,
+	}
+}
+
+// deviceProfileDB is the serialized form of a device profile database.
+type deviceProfileDB struct {
+	Version  int              `json:"version"`
+	Profiles []*DeviceProfile `json:"profiles"`
+}
+
+// DeviceRegistry is a database of device profiles that matches devices by
+// their model and SoC names.  Profiles list shell-style patterns, such as
+// "Galaxy S24*", which are matched case-insensitively.  A device is matched by
+// its model before its SoC, so a profile for a specific phone takes precedence
+// over a profile for the SoC it shares with other phones.
+//
+// The zero value is an empty registry.  The registry is safe for concurrent
+// use.
+type DeviceRegistry struct {
+	mtx      sync.RWMutex
+	profiles []*DeviceProfile
+}
+
+// NewDeviceRegistry returns a registry loaded with the default device
+// profiles.
+func NewDeviceRegistry() *DeviceRegistry {
+	r := &DeviceRegistry{}
+	if err := r.Merge(defaultDeviceProfiles); err != nil {
+		panic(fmt.Sprintf("invalid default device profiles: %v", err))
+	}
+	return r
+}
+
+// LoadDeviceRegistry returns a registry loaded with the default device profiles
+// overridden by the profiles in the database at the passed path.  A missing
+// file leaves the defaults in place so the path can point to where benchmark
+// results are saved before there are any.
+func LoadDeviceRegistry(dbPath string) (*DeviceRegistry, error) {
+	r := NewDeviceRegistry()
+	if dbPath == "" {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(dbPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Merge(data); err != nil {
+		return nil, fmt.Errorf("%s: %w", dbPath, err)
+	}
+	return r, nil
+}
+
+// Merge adds the profiles of the passed serialized database to the registry.
+// Profiles replace existing profiles of the same name, and new profiles are
+// matched before existing ones.
+func (r *DeviceRegistry) Merge(data []byte) error {
+	var db deviceProfileDB
+	if err := json.Unmarshal(data, &db); err != nil {
+		return err
+	}
+	if db.Version < 1 || db.Version > DeviceProfileDBVersion {
+		return fmt.Errorf("%w %d", ErrUnsupportedProfileDB, db.Version)
+	}
+	for _, profile := range db.Profiles {
+		if profile == nil || profile.Name == "" {
+			return errors.New("device profile without a name")
+		}
+		patterns := make([]string, 0, len(profile.Models)+len(profile.SoCs))
+		patterns = append(patterns, profile.Models...)
+		patterns = append(patterns, profile.SoCs...)
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("device profile %q: bad pattern "+
+					"%q", profile.Name, pattern)
+			}
+		}
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	// Add the profiles in reverse so the first profile of the database
+	// ends up first in the registry.
+	for i := len(db.Profiles) - 1; i >= 0; i-- {
+		r.add(db.Profiles[i])
+	}
+	return nil
+}
+
+// Add adds the passed profile to the registry, replacing an existing profile
+// of the same name.  The profile is matched before all existing profiles.
+func (r *DeviceRegistry) Add(profile *DeviceProfile) {
+	r.mtx.Lock()
+	r.add(profile.copy())
+	r.mtx.Unlock()
+}
+
+// add adds the passed profile in front of the registry, replacing an existing
+// profile of the same name.
+//
+// This function MUST be called with the registry lock held (for writes).
+func (r *DeviceRegistry) add(profile *DeviceProfile) {
+	profiles := make([]*DeviceProfile, 0, len(r.profiles)+1)
+	profiles = append(profiles, profile)
+	for _, existing := range r.profiles {
+		if existing.Name != profile.Name {
+			profiles = append(profiles, existing)
+		}
+	}
+	r.profiles = profiles
+}
+
+// Lookup returns the profile of the device with the passed model and SoC
+// names, either of which may be empty.  The profile of unknown devices is
+// returned when no profile matches.  The returned profile is a copy that may
+// be modified by the caller.
+func (r *DeviceRegistry) Lookup(model, soc string) *DeviceProfile {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	if profile := r.match(model, func(p *DeviceProfile) []string {
+		return append([]string{p.Name}, p.Models...)
+	}); profile != nil {
+		return profile.copy()
+	}
+	if profile := r.match(soc, func(p *DeviceProfile) []string {
+		return append([]string{p.SoC}, p.SoCs...)
+	}); profile != nil {
+		return profile.copy()
+	}
+	return unknownDeviceProfile()
+}
+
+// match returns the first profile with a pattern returned by the passed
+// function that matches the passed name, or nil when there is none.
+//
+// This function MUST be called with the registry lock held (for reads).
+func (r *DeviceRegistry) match(name string, patterns func(*DeviceProfile) []string) *DeviceProfile {
+	if name == "" {
+		return nil
+	}
+	name = strings.ToLower(name)
+	for _, profile := range r.profiles {
+		for _, pattern := range patterns(profile) {
+			if pattern == "" {
+				continue
+			}
+			ok, _ := path.Match(strings.ToLower(pattern), name)
+			if ok {
+				return profile
+			}
+		}
+	}
+	return nil
+}
+
+// Profiles returns a copy of every profile in the registry in the order they
+// are matched.
+func (r *DeviceRegistry) Profiles() []*DeviceProfile {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	profiles := make([]*DeviceProfile, 0, len(r.profiles))
+	for _, profile := range r.profiles {
+		profiles = append(profiles, profile.copy())
+	}
+	return profiles
+}
+
+// Save writes every profile in the registry to the database at the passed path
+// in a form LoadDeviceRegistry and Merge read back.
+func (r *DeviceRegistry) Save(dbPath string) error {
+	db := deviceProfileDB{
+		Version:  DeviceProfileDBVersion,
+		Profiles: r.Profiles(),
+	}
+	data, err := json.MarshalIndent(&db, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dbPath, append(data, '\n'), 0644)
+}
+
+var (
+	// defaultRegistry is the registry used by GetDeviceProfile.  It is
+	// created on first use.
+	defaultRegistry     *DeviceRegistry
+	defaultRegistryOnce sync.Once
+)
+
+// DefaultDeviceRegistry returns the registry of the default device profiles
+// shared by the miner and the pool.
+func DefaultDeviceRegistry() *DeviceRegistry {
+	defaultRegistryOnce.Do(func() {
+		defaultRegistry = NewDeviceRegistry()
+	})
+	return defaultRegistry
+}
+
+// GetDeviceProfile returns optimized settings for known device types from the
+// default device registry.  The device is matched by its model name, or by its
+// SoC name when that is passed instead.
+func GetDeviceProfile(deviceName string) *DeviceProfile {
+	return DefaultDeviceRegistry().Lookup(deviceName, deviceName)
+}
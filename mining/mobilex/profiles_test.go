@@ -0,0 +1,156 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mobilex
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestDeviceRegistryLookup ensures devices are matched by model patterns
+// before SoC patterns and unknown devices get the unknown profile.
+func TestDeviceRegistryLookup(t *testing.T) {
+	r := NewDeviceRegistry()
+
+	tests := []struct {
+		name  string
+		model string
+		soc   string
+		want  string
+		class string
+	}{
+		{"exact model", "Pixel 8", "", "Pixel 8", ThermalClassFlagship},
+		{"model pattern", "galaxy s24 ultra", "", "Galaxy S24", ThermalClassFlagship},
+		{"model identifier", "SM-S921B", "", "Galaxy S24", ThermalClassFlagship},
+		{"soc pattern", "", "SM8650-AB", "Galaxy S24", ThermalClassFlagship},
+		{"soc only profile", "", "Snapdragon 7 Gen 3", "Snapdragon 7 Gen 3", ThermalClassMidrange},
+		{"model before soc", "Pixel 7a", "Tensor G3", "Pixel 7", ThermalClassMidrange},
+		{"unknown model known soc", "Nothing Phone", "A17 Pro", "iPhone 15 Pro", ThermalClassFlagship},
+		{"unknown", "Unknown Device", "Helio G99", "Unknown", ThermalClassBudget},
+		{"empty", "", "", "Unknown", ThermalClassBudget},
+	}
+	for _, test := range tests {
+		profile := r.Lookup(test.model, test.soc)
+		if profile.Name != test.want {
+			t.Errorf("%s: got profile %q, want %q", test.name,
+				profile.Name, test.want)
+			continue
+		}
+		if profile.ThermalClass != test.class {
+			t.Errorf("%s: got class %q, want %q", test.name,
+				profile.ThermalClass, test.class)
+		}
+	}
+
+	// Lookups return copies.
+	r.Lookup("Pixel 8", "").MaxHashRate = 1
+	if rate := r.Lookup("Pixel 8", "").MaxHashRate; rate != 100 {
+		t.Fatalf("registry modified through lookup: hash rate %v", rate)
+	}
+}
+
+// TestLoadDeviceRegistry ensures an on-disk database overrides and extends the
+// default profiles and round trips through Save.
+func TestLoadDeviceRegistry(t *testing.T) {
+	dir := t.TempDir()
+
+	// A missing database leaves the defaults in place.
+	r, err := LoadDeviceRegistry(filepath.Join(dir, "missing.json"))
+	if err != nil {
+		t.Fatalf("LoadDeviceRegistry: %v", err)
+	}
+	if got := len(r.Profiles()); got != len(NewDeviceRegistry().Profiles()) {
+		t.Fatalf("got %d profiles, want the defaults", got)
+	}
+
+	dbPath := filepath.Join(dir, "profiles.json")
+	db := `{"version": 1, "profiles": [
+		{"name": "Pixel 8", "models": ["Pixel 8*"], "soc": "Tensor G3",
+		 "max_hash_rate": 80, "core_count": 8,
+		 "thermal_class": "midrange"},
+		{"name": "Dimensity 9300", "socs": ["Dimensity 93*"],
+		 "soc": "Dimensity 9300", "max_hash_rate": 110,
+		 "core_count": 8, "thermal_class": "flagship"}
+	]}`
+	if err := os.WriteFile(dbPath, []byte(db), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err = LoadDeviceRegistry(dbPath)
+	if err != nil {
+		t.Fatalf("LoadDeviceRegistry: %v", err)
+	}
+
+	if p := r.Lookup("Pixel 8 Pro", ""); p.ThermalClass != ThermalClassMidrange ||
+		p.MaxHashRate != 80 || p.HasNPU {
+
+		t.Fatalf("Pixel 8 not overridden: %+v", p)
+	}
+	if p := r.Lookup("", "Dimensity 9300+"); p.Name != "Dimensity 9300" {
+		t.Fatalf("Dimensity 9300 not added: got %q", p.Name)
+	}
+	if p := r.Lookup("Galaxy S24", ""); p.Name != "Galaxy S24" {
+		t.Fatalf("default profile lost: got %q", p.Name)
+	}
+	profiles := r.Profiles()
+	if profiles[0].Name != "Pixel 8" || profiles[1].Name != "Dimensity 9300" {
+		t.Fatalf("override profiles not matched first: %q, %q",
+			profiles[0].Name, profiles[1].Name)
+	}
+
+	savedPath := filepath.Join(dir, "saved.json")
+	if err := r.Save(savedPath); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	saved, err := LoadDeviceRegistry(savedPath)
+	if err != nil {
+		t.Fatalf("LoadDeviceRegistry: %v", err)
+	}
+	savedProfiles := saved.Profiles()
+	if len(savedProfiles) != len(profiles) {
+		t.Fatalf("got %d saved profiles, want %d", len(savedProfiles),
+			len(profiles))
+	}
+	for i := range profiles {
+		if savedProfiles[i].Name != profiles[i].Name ||
+			savedProfiles[i].MaxHashRate != profiles[i].MaxHashRate {
+
+			t.Fatalf("saved profile %d is %+v, want %+v", i,
+				savedProfiles[i], profiles[i])
+		}
+	}
+}
+
+// TestDeviceRegistryMergeErrors ensures invalid databases are rejected without
+// changing the registry.
+func TestDeviceRegistryMergeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		db   string
+	}{
+		{"malformed", `{"version": 1, "profiles": [`},
+		{"no version", `{"profiles": []}`},
+		{"newer version", `{"version": 2, "profiles": []}`},
+		{"unnamed profile", `{"version": 1, "profiles": [{"soc": "A18"}]}`},
+		{"bad pattern", `{"version": 1, "profiles": [{"name": "x", "models": ["[x"]}]}`},
+	}
+	for _, test := range tests {
+		r := NewDeviceRegistry()
+		want := len(r.Profiles())
+		if err := r.Merge([]byte(test.db)); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+		if got := len(r.Profiles()); got != want {
+			t.Errorf("%s: registry changed to %d profiles", test.name,
+				got)
+		}
+	}
+
+	err := NewDeviceRegistry().Merge([]byte(`{"version": 2}`))
+	if !errors.Is(err, ErrUnsupportedProfileDB) {
+		t.Fatalf("got error %v, want %v", err, ErrUnsupportedProfileDB)
+	}
+}
@@ -0,0 +1,21 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+//go:build linux
+// +build linux
+
+package mobilex
+
+// defaultTemperatureSource returns the temperature source used when the
+// configuration does not provide one.  Linux and Android expose the thermal
+// zones through sysfs.
+func defaultTemperatureSource() TemperatureSource {
+	return NewSysfsTemperatureSource(DefaultSysfsRoot)
+}
+
+// defaultPowerSource returns the power source used when the configuration does
+// not provide one.  Linux and Android expose the power supplies through sysfs.
+func defaultPowerSource() PowerSource {
+	return NewSysfsPowerSource(DefaultSysfsRoot)
+}
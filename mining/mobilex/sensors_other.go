@@ -0,0 +1,24 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package mobilex
+
+// defaultTemperatureSource returns the temperature source used when the
+// configuration does not provide one.  There is no portable way to read the
+// device temperature on other platforms, so the thermal limits are only
+// enforced when a source is configured.
+func defaultTemperatureSource() TemperatureSource {
+	return nil
+}
+
+// defaultPowerSource returns the power source used when the configuration does
+// not provide one.  There is no portable way to read the battery state on
+// other platforms, so the battery policy is only enforced when a source is
+// configured.
+func defaultPowerSource() PowerSource {
+	return nil
+}
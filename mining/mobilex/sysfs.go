@@ -0,0 +1,190 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mobilex
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultSysfsRoot is the mount point of sysfs on Linux and Android.
+const DefaultSysfsRoot = "/sys"
+
+// maxWholeDegreeReading is the largest thermal zone reading that is treated
+// as whole degrees Celsius.  The kernel reports millidegrees, but some Android
+// vendor kernels report whole degrees instead.
+const maxWholeDegreeReading = 200
+
+var (
+	// ErrNoThermalZones indicates no thermal zone could be read.
+	ErrNoThermalZones = errors.New("no readable thermal zones")
+)
+
+// TemperatureSource reports the temperature of the device.
+type TemperatureSource interface {
+	// Temperature returns the current device temperature in degrees
+	// Celsius.
+	Temperature() (float64, error)
+}
+
+// PowerStatus describes the battery and charging state of the device.
+type PowerStatus struct {
+	HasBattery   bool // Whether the device runs on a battery
+	BatteryLevel int  // Battery charge level (%)
+	Charging     bool // Whether the device is connected to external power
+}
+
+// PowerSource reports the battery and charging state of the device.
+type PowerSource interface {
+	// PowerStatus returns the current battery and charging state.
+	PowerStatus() (PowerStatus, error)
+}
+
+// SysfsTemperatureSource reads the device temperature from the thermal zones
+// the Linux kernel exposes under class/thermal of a sysfs tree.  The hottest
+// zone is reported since mining heats the whole SoC and the limits apply to
+// its hottest point.
+type SysfsTemperatureSource struct {
+	root string
+}
+
+// NewSysfsTemperatureSource returns a temperature source reading the sysfs
+// tree mounted at the passed root.  DefaultSysfsRoot is used when root is
+// empty.
+func NewSysfsTemperatureSource(root string) *SysfsTemperatureSource {
+	if root == "" {
+		root = DefaultSysfsRoot
+	}
+	return &SysfsTemperatureSource{root: root}
+}
+
+// Temperature returns the temperature of the hottest readable thermal zone in
+// degrees Celsius.  Zones that can't be read, such as disabled zones, are
+// skipped.
+//
+// This is part of the TemperatureSource interface.
+func (s *SysfsTemperatureSource) Temperature() (float64, error) {
+	pattern := filepath.Join(s.root, "class", "thermal", "thermal_zone*",
+		"temp")
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return 0, err
+	}
+
+	var hottest float64
+	found := false
+	for _, path := range paths {
+		reading, err := readSysfsInt(path)
+		if err != nil {
+			continue
+		}
+
+		temp := float64(reading)
+		if reading > maxWholeDegreeReading || reading < -maxWholeDegreeReading {
+			temp /= 1000
+		}
+		if !found || temp > hottest {
+			hottest = temp
+			found = true
+		}
+	}
+	if !found {
+		return 0, ErrNoThermalZones
+	}
+	return hottest, nil
+}
+
+// SysfsPowerSource reads the battery and charging state from the power
+// supplies the Linux kernel exposes under class/power_supply of a sysfs tree.
+type SysfsPowerSource struct {
+	root string
+}
+
+// NewSysfsPowerSource returns a power source reading the sysfs tree mounted at
+// the passed root.  DefaultSysfsRoot is used when root is empty.
+func NewSysfsPowerSource(root string) *SysfsPowerSource {
+	if root == "" {
+		root = DefaultSysfsRoot
+	}
+	return &SysfsPowerSource{root: root}
+}
+
+// PowerStatus returns the battery and charging state of the device.  The first
+// battery found reports the battery level.  The device is charging when that
+// battery reports it is charging or full, or when any external supply such as
+// mains, USB or a wireless charger is online.  Devices without a battery are
+// always considered to be on external power.
+//
+// This is part of the PowerSource interface.
+func (s *SysfsPowerSource) PowerStatus() (PowerStatus, error) {
+	supplies, err := filepath.Glob(filepath.Join(s.root, "class",
+		"power_supply", "*"))
+	if err != nil {
+		return PowerStatus{}, err
+	}
+
+	var status PowerStatus
+	externalPower := false
+	for _, supply := range supplies {
+		supplyType, err := readSysfsString(filepath.Join(supply, "type"))
+		if err != nil {
+			continue
+		}
+
+		switch supplyType {
+		case "Battery":
+			if status.HasBattery {
+				continue
+			}
+			level, err := readSysfsInt(filepath.Join(supply, "capacity"))
+			if err != nil {
+				return PowerStatus{}, fmt.Errorf("battery %s: %w",
+					filepath.Base(supply), err)
+			}
+			status.HasBattery = true
+			status.BatteryLevel = int(level)
+
+			state, err := readSysfsString(filepath.Join(supply, "status"))
+			if err == nil && (state == "Charging" || state == "Full") {
+				status.Charging = true
+			}
+
+		default:
+			online, err := readSysfsInt(filepath.Join(supply, "online"))
+			if err == nil && online == 1 {
+				externalPower = true
+			}
+		}
+	}
+
+	if externalPower || !status.HasBattery {
+		status.Charging = true
+	}
+	return status, nil
+}
+
+// readSysfsString returns the contents of the passed sysfs attribute with
+// surrounding whitespace removed.
+func readSysfsString(path string) (string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(contents)), nil
+}
+
+// readSysfsInt returns the contents of the passed sysfs attribute parsed as a
+// decimal integer.
+func readSysfsInt(path string) (int64, error) {
+	contents, err := readSysfsString(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(contents, 10, 64)
+}
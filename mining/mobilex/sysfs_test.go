@@ -0,0 +1,209 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mobilex
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeSysfsAttrs writes the passed attributes, keyed by their path relative
+// to root, to a fake sysfs tree.
+func writeSysfsAttrs(t *testing.T, root string, attrs map[string]string) {
+	t.Helper()
+
+	for name, value := range attrs {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("unable to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(value+"\n"), 0o644); err != nil {
+			t.Fatalf("unable to write %s: %v", path, err)
+		}
+	}
+}
+
+// TestSysfsTemperatureSource ensures the hottest readable thermal zone is
+// reported in degrees Celsius.
+func TestSysfsTemperatureSource(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs map[string]string
+		want  float64
+		err   error
+	}{{
+		name: "hottest zone",
+		attrs: map[string]string{
+			"class/thermal/thermal_zone0/temp": "38500",
+			"class/thermal/thermal_zone1/temp": "44250",
+			"class/thermal/thermal_zone2/temp": "41000",
+		},
+		want: 44.25,
+	}, {
+		name: "unreadable zones skipped",
+		attrs: map[string]string{
+			"class/thermal/thermal_zone0/temp": "disabled",
+			"class/thermal/thermal_zone1/temp": "39000",
+		},
+		want: 39,
+	}, {
+		name: "whole degrees",
+		attrs: map[string]string{
+			"class/thermal/thermal_zone0/temp": "42",
+		},
+		want: 42,
+	}, {
+		name:  "no zones",
+		attrs: map[string]string{},
+		err:   ErrNoThermalZones,
+	}}
+
+	for _, test := range tests {
+		root := t.TempDir()
+		writeSysfsAttrs(t, root, test.attrs)
+
+		got, err := NewSysfsTemperatureSource(root).Temperature()
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: unexpected error: got %v, want %v", test.name,
+				err, test.err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+// TestSysfsPowerSource ensures the battery level and charging state are read
+// from the power supplies.
+func TestSysfsPowerSource(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs map[string]string
+		want  PowerStatus
+	}{{
+		name: "discharging battery",
+		attrs: map[string]string{
+			"class/power_supply/battery/type":     "Battery",
+			"class/power_supply/battery/capacity": "64",
+			"class/power_supply/battery/status":   "Discharging",
+			"class/power_supply/usb/type":         "USB",
+			"class/power_supply/usb/online":       "0",
+		},
+		want: PowerStatus{HasBattery: true, BatteryLevel: 64},
+	}, {
+		name: "charging battery",
+		attrs: map[string]string{
+			"class/power_supply/battery/type":     "Battery",
+			"class/power_supply/battery/capacity": "85",
+			"class/power_supply/battery/status":   "Charging",
+		},
+		want: PowerStatus{HasBattery: true, BatteryLevel: 85, Charging: true},
+	}, {
+		name: "external supply online",
+		attrs: map[string]string{
+			"class/power_supply/BAT0/type":     "Battery",
+			"class/power_supply/BAT0/capacity": "90",
+			"class/power_supply/BAT0/status":   "Not charging",
+			"class/power_supply/AC/type":       "Mains",
+			"class/power_supply/AC/online":     "1",
+		},
+		want: PowerStatus{HasBattery: true, BatteryLevel: 90, Charging: true},
+	}, {
+		name:  "no battery",
+		attrs: map[string]string{},
+		want:  PowerStatus{Charging: true},
+	}}
+
+	for _, test := range tests {
+		root := t.TempDir()
+		writeSysfsAttrs(t, root, test.attrs)
+
+		got, err := NewSysfsPowerSource(root).PowerStatus()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+// TestUpdateDeviceState ensures mining is paused and resumed according to the
+// thermal limits, with hysteresis, and the battery policy.
+func TestUpdateDeviceState(t *testing.T) {
+	root := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.ThermalThrottleStart = 45
+	cfg.ThermalThrottleStop = 48
+	cfg.MinBatteryLevel = 80
+	cfg.RequireCharging = true
+
+	m := &MobileXMiner{
+		cfg:              cfg,
+		thermal:          NewThermalVerification(2000, cfg.ThermalTolerancePercent),
+		heterogeneous:    NewHeterogeneousScheduler(cfg.BigCores, cfg.LittleCores),
+		governor:         NewThermalGovernor(cfg, cfg.BigCores, cfg.LittleCores),
+		tempSource:       NewSysfsTemperatureSource(root),
+		powerSource:      NewSysfsPowerSource(root),
+		metricsCollector: NewMetricsCollector(),
+	}
+
+	steps := []struct {
+		name     string
+		temp     string
+		capacity string
+		status   string
+		want     PauseReason
+	}{
+		{"cool and charging", "40000", "90", "Charging", PauseNone},
+		{"warm", "46000", "90", "Charging", PauseNone},
+		{"too hot", "48000", "90", "Charging", PauseThermal},
+		{"cooling", "46000", "90", "Charging", PauseThermal},
+		{"cooled down", "45000", "90", "Charging", PauseNone},
+		{"unplugged", "41000", "90", "Discharging", PauseNotCharging},
+		{"unplugged and too hot", "49000", "90", "Discharging", PauseThermal},
+		{"plugged in", "41000", "70", "Charging", PauseBatteryLow},
+		{"charged", "41000", "80", "Charging", PauseNone},
+	}
+	for _, step := range steps {
+		writeSysfsAttrs(t, root, map[string]string{
+			"class/thermal/thermal_zone0/temp":    step.temp,
+			"class/power_supply/battery/type":     "Battery",
+			"class/power_supply/battery/capacity": step.capacity,
+			"class/power_supply/battery/status":   step.status,
+		})
+
+		m.updateDeviceState()
+		if got := m.PauseReason(); got != step.want {
+			t.Fatalf("%s: pause reason %v, want %v", step.name, got,
+				step.want)
+		}
+	}
+
+	// Every reading results in a recorded governor decision.
+	decisions := m.metricsCollector.GetGovernorDecisions()
+	if len(decisions) != len(steps) {
+		t.Fatalf("recorded %d governor decisions, want %d",
+			len(decisions), len(steps))
+	}
+
+	// The thermal state is kept while the thermal zones can't be read.
+	writeSysfsAttrs(t, root, map[string]string{
+		"class/thermal/thermal_zone0/temp": "50000",
+	})
+	m.updateDeviceState()
+	if err := os.RemoveAll(filepath.Join(root, "class", "thermal")); err != nil {
+		t.Fatalf("unable to remove thermal zones: %v", err)
+	}
+	m.updateDeviceState()
+	if got := m.PauseReason(); got != PauseThermal {
+		t.Fatalf("missing thermal zones: pause reason %v, want %v", got,
+			PauseThermal)
+	}
+}
@@ -0,0 +1,145 @@
+package testing
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/mining/mobilex"
+	"github.com/toole-brendan/shell/mining/randomx"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// sha256Core is a deterministic stand-in for the RandomX core so the header
+// vectors do not depend on the RandomX implementation in use.
+type sha256Core struct{}
+
+func (sha256Core) CalcHash(input []byte) []byte {
+	sum := sha256.Sum256(input)
+	return sum[:]
+}
+
+// vectorHeader returns the header used by the MobileX golden vectors.
+func vectorHeader(t *testing.T, nonce uint32) *wire.BlockHeader {
+	prevBlock, err := chainhash.NewHashFromStr("000000000003ba27aa200b1cecaad478d2b00432346c3f1f3986da1afd33e506")
+	require.NoError(t, err)
+	merkleRoot, err := chainhash.NewHashFromStr("6657a9252aacd5c0b2940996ecff952228c3067cc38d4885efb5a4ac4247e9f3")
+	require.NoError(t, err)
+
+	return &wire.BlockHeader{
+		Version:      1,
+		PrevBlock:    *prevBlock,
+		MerkleRoot:   *merkleRoot,
+		Timestamp:    time.Unix(1293623863, 0),
+		Bits:         0x1b04864c,
+		Nonce:        nonce,
+		ThermalProof: 12345,
+	}
+}
+
+// TestHashFromCoreVectors ensures the reference mixing and NPU steps of the
+// MobileX hash produce the golden outputs for fixed RandomX cores.
+func TestHashFromCoreVectors(t *testing.T) {
+	tests := []struct {
+		name string
+		core string
+		want string
+	}{
+		{
+			name: "all zero",
+			core: "0000000000000000000000000000000000000000000000000000000000000000",
+			want: "34f10ac431da9ee4be8fd811408c19fd5a0e97a3be435f663c0b0541d5fa9ec5",
+		},
+		{
+			name: "all ones",
+			core: "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			want: "32da9e5671c8062eab421f3e1f3907cffb4917f6d852e1a37dc6b1270a419242",
+		},
+		{
+			name: "counting bytes",
+			core: "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+			want: "36fa69f050b68dfa44b71dabefeac6b80e6a64cd8e32de5c764fc1b064f76a39",
+		},
+		{
+			name: "sha256 of MobileX",
+			core: "3874d3f041931fc1088bf0ba444e788411ec299cb22ae4be695e3a921f089b18",
+			want: "d3564a600e1ed8a853c7b3b459b3272f166a0aeef4c5730e99fdd590f9f6f4dd",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			core, err := hex.DecodeString(test.core)
+			require.NoError(t, err)
+
+			hash := mobilex.HashFromCore(core)
+			assert.Equal(t, test.want, hash.String())
+		})
+	}
+}
+
+// TestHashHeaderVectors ensures the header serialization hashed by MobileX is
+// stable and excludes the thermal proof.
+func TestHashHeaderVectors(t *testing.T) {
+	tests := []struct {
+		nonce uint32
+		want  string
+	}{
+		{0, "f84142b661ce182a82a399edeba31d34f4961f0786d510527d09187a84f3740c"},
+		{1, "c7cbee11a881f48f962062880b4ecaeb26799798a129106ed1501db60e9d92e4"},
+		{0xffffffff, "feb2f566fb4b3471697645d16dbf18adf62174886b4f59ab8f18acd5c6f0841d"},
+	}
+
+	for _, test := range tests {
+		header := vectorHeader(t, test.nonce)
+		hash := mobilex.HashHeader(sha256Core{}, header)
+		assert.Equal(t, test.want, hash.String(), "nonce %d", test.nonce)
+
+		// The thermal proof is attached after a solution is found, so it
+		// must not affect the hash.
+		header.ThermalProof = 0
+		hash = mobilex.HashHeader(sha256Core{}, header)
+		assert.Equal(t, test.want, hash.String(), "nonce %d without proof",
+			test.nonce)
+	}
+}
+
+// TestOptimizedMixMatchesReference ensures the platform mixing path matches
+// the reference mix.
+func TestOptimizedMixMatchesReference(t *testing.T) {
+	words := make([]uint32, 8)
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint32([]byte{
+			byte(i), byte(i * 7), byte(i * 31), byte(0xa5 ^ i),
+		})
+	}
+
+	optimizer := mobilex.NewARM64Optimizer()
+	assert.Equal(t, mobilex.MixCore(words), optimizer.ARMSpecificHash(words))
+}
+
+// TestVerifierMatchesMiner ensures the reference verifier used by pools and
+// block validation agrees with the hash computed by the miner.
+func TestVerifierMatchesMiner(t *testing.T) {
+	cache, err := randomx.NewCache(make([]byte, 32))
+	require.NoError(t, err)
+	defer cache.Close()
+
+	vm, err := randomx.NewVM(cache, nil)
+	require.NoError(t, err)
+	defer vm.Close()
+
+	verifier, err := mobilex.NewLightVerifier(make([]byte, 32))
+	require.NoError(t, err)
+	defer verifier.Close()
+
+	for _, nonce := range []uint32{0, 1, 42} {
+		header := vectorHeader(t, nonce)
+		assert.Equal(t, mobilex.HashHeader(vm, header), verifier.Hash(header))
+	}
+}
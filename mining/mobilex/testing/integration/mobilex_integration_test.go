@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/toole-brendan/shell/blockchain"
 	"github.com/toole-brendan/shell/chaincfg"
 	"github.com/toole-brendan/shell/mining"
 	"github.com/toole-brendan/shell/mining/mobilex"
@@ -57,13 +58,12 @@ func testMobileXConfiguration(t *testing.T) {
 }
 
 func testMobileXBlockValidation(t *testing.T) {
+	// Create mining policy
+	policy, verifier := newTestPolicy(t)
+
 	// Create test block with thermal proof
 	block := createTestBlock(t, true) // with thermal proof
-
-	// Create mining policy
-	params := &chaincfg.MainNetParams
-	params.MobileXEnabled = true
-	policy := mining.NewMiningPolicy(params)
+	solveTestBlock(t, verifier, block)
 
 	// Test algorithm detection
 	algorithm := policy.DetectAlgorithm(&block.Header)
@@ -132,10 +132,10 @@ func testDualAlgorithmMining(t *testing.T) {
 
 func testMiningPolicy(t *testing.T) {
 	// Test policy with MobileX disabled
-	params := &chaincfg.MainNetParams
+	params := chaincfg.MainNetParams
 	params.MobileXEnabled = false
 
-	policy := mining.NewMiningPolicy(params)
+	policy := mining.NewMiningPolicy(&params)
 
 	// Should support only RandomX
 	algorithms := policy.GetSupportedAlgorithms()
@@ -167,14 +167,13 @@ func testMiningPolicy(t *testing.T) {
 }
 
 func testThermalValidation(t *testing.T) {
+	// Create policy with MobileX enabled
+	policy, verifier := newTestPolicy(t)
+
 	// Create blocks with and without thermal proof
 	validBlock := createTestBlock(t, true)    // with thermal proof
 	invalidBlock := createTestBlock(t, false) // without thermal proof
-
-	// Create policy with MobileX enabled
-	params := &chaincfg.MainNetParams
-	params.MobileXEnabled = true
-	policy := mining.NewMiningPolicy(params)
+	solveTestBlock(t, verifier, validBlock)
 
 	// Valid MobileX block should pass
 	err := policy.ValidateBlockAlgorithm(&validBlock.Header, 1000)
@@ -182,10 +181,10 @@ func testThermalValidation(t *testing.T) {
 		t.Errorf("Valid MobileX block should pass validation: %v", err)
 	}
 
-	// Block without thermal proof should be detected as RandomX
+	// Block without the MobileX version bit should be detected as RandomX
 	algorithm := policy.DetectAlgorithm(&invalidBlock.Header)
 	if algorithm != mining.AlgorithmRandomX {
-		t.Errorf("Block without thermal proof should be RandomX, got %v", algorithm)
+		t.Errorf("Block without MobileX version bit should be RandomX, got %v", algorithm)
 	}
 
 	// Test thermal proof validation specifically
@@ -200,14 +199,48 @@ func testThermalValidation(t *testing.T) {
 
 // Helper functions
 
+// testBlockVersion is the version bits block version used by test blocks.
+const testBlockVersion = 0x20000000
+
+// newTestPolicy returns a mining policy with MobileX enabled along with the
+// reference verifier it validates MobileX blocks with.
+func newTestPolicy(tb testing.TB) (*mining.MiningPolicy, *mobilex.Verifier) {
+	verifier, err := mobilex.NewLightVerifier(make([]byte, 32))
+	if err != nil {
+		tb.Fatalf("Failed to create MobileX verifier: %v", err)
+	}
+	tb.Cleanup(verifier.Close)
+
+	params := chaincfg.MainNetParams
+	params.MobileXEnabled = true
+	policy := mining.NewMiningPolicy(&params)
+	policy.SetMobileXVerifier(verifier)
+
+	return policy, verifier
+}
+
+// solveTestBlock searches for a nonce that satisfies the MobileX target of the
+// passed block and regenerates its thermal proof for the solution.
+func solveTestBlock(tb testing.TB, verifier *mobilex.Verifier, block *wire.MsgBlock) {
+	target := blockchain.CompactToBig(block.Header.Bits)
+	for nonce := uint32(0); nonce < 1000; nonce++ {
+		block.Header.Nonce = nonce
+		if verifier.Verify(&block.Header, target) == nil {
+			block.Header.ThermalProof = generateTestThermalProof(&block.Header)
+			return
+		}
+	}
+	tb.Fatal("Failed to solve test block")
+}
+
 func createTestBlock(t *testing.T, withThermalProof bool) *wire.MsgBlock {
 	block := &wire.MsgBlock{
 		Header: wire.BlockHeader{
-			Version:      1,
+			Version:      testBlockVersion,
 			PrevBlock:    [32]byte{},
 			MerkleRoot:   [32]byte{},
 			Timestamp:    time.Now(),
-			Bits:         0x1e0ffff0, // Easy difficulty
+			Bits:         0x207fffff, // Easy difficulty
 			Nonce:        12345,
 			ThermalProof: 0,
 		},
@@ -227,7 +260,10 @@ func createTestBlock(t *testing.T, withThermalProof bool) *wire.MsgBlock {
 	}
 
 	if withThermalProof {
-		// Generate a valid thermal proof
+		// Mark the block as mined with MobileX and generate a valid
+		// thermal proof
+		block.Header.Version = blockchain.SetPowAlgorithm(
+			block.Header.Version, blockchain.PowAlgoMobileX)
 		block.Header.ThermalProof = generateTestThermalProof(&block.Header)
 	}
 
@@ -296,20 +332,17 @@ func BenchmarkMobileXIntegration(b *testing.B) {
 	}
 	defer miner.Close()
 
+	policy, verifier := newTestPolicy(b)
 	block := createTestBlock(nil, true)
+	solveTestBlock(b, verifier, block)
 
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
 		// Benchmark thermal proof generation
-		block.Header.Nonce = uint32(i)
 		block.Header.ThermalProof = generateTestThermalProof(&block.Header)
 
 		// Benchmark policy validation
-		params := &chaincfg.MainNetParams
-		params.MobileXEnabled = true
-		policy := mining.NewMiningPolicy(params)
-
 		err := policy.ValidateBlockAlgorithm(&block.Header, 1000)
 		if err != nil {
 			b.Fatalf("Validation failed: %v", err)
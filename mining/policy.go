@@ -8,8 +8,10 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/toole-brendan/shell/blockchain"
 	"github.com/toole-brendan/shell/chaincfg"
 	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/mining/mobilex"
 	"github.com/toole-brendan/shell/mining/randomx"
 	"github.com/toole-brendan/shell/wire"
 )
@@ -21,6 +23,13 @@ type MiningPolicy struct {
 	randomXEnabled bool
 	mobileXEnabled bool
 	dualMining     bool
+
+	// mobileXVerifier computes the reference MobileX hash of blocks with a
+	// fixed key instead of the RandomX epoch of their height.
+	mobileXVerifier *mobilex.Verifier
+
+	// randomXSeeds provides the RandomX epochs blocks are hashed with.
+	randomXSeeds *randomx.SeedManager
 }
 
 // AlgorithmType represents the detected mining algorithm used for a block
@@ -45,6 +54,22 @@ func NewMiningPolicy(chainParams *chaincfg.Params) *MiningPolicy {
 	}
 }
 
+// SetMobileXVerifier sets a reference verifier that computes the MobileX hash
+// of blocks with its own fixed key instead of the RandomX epoch of their
+// height.  It is meant for tests and tools that do not track the chain's
+// seeds.
+func (mp *MiningPolicy) SetMobileXVerifier(verifier *mobilex.Verifier) {
+	mp.mobileXVerifier = verifier
+}
+
+// SetRandomXSeeds sets the seed manager that provides the RandomX epochs
+// blocks are hashed with.  Sharing the seed manager of the miner avoids
+// computing each RandomX cache twice.  Blocks are rejected until one is set
+// unless a MobileX verifier hashes them.
+func (mp *MiningPolicy) SetRandomXSeeds(seeds *randomx.SeedManager) {
+	mp.randomXSeeds = seeds
+}
+
 // DetectAlgorithm determines which algorithm was used to mine a block
 func (mp *MiningPolicy) DetectAlgorithm(blockHeader *wire.BlockHeader) AlgorithmType {
 	// Check for MobileX indicators
@@ -56,19 +81,11 @@ func (mp *MiningPolicy) DetectAlgorithm(blockHeader *wire.BlockHeader) Algorithm
 	return AlgorithmRandomX
 }
 
-// isMobileXBlock determines if a block was mined using MobileX
+// isMobileXBlock determines if a block was mined using MobileX from the
+// algorithm encoded in its version.
 func (mp *MiningPolicy) isMobileXBlock(blockHeader *wire.BlockHeader) bool {
-	// MobileX blocks have thermal proof field
-	if blockHeader.ThermalProof != 0 {
-		return true
-	}
-
-	// Could add additional MobileX detection heuristics here:
-	// - Specific nonce patterns
-	// - Block timing characteristics
-	// - Other mobile-specific signatures
-
-	return false
+	algo := blockchain.PowAlgorithmFromVersion(blockHeader.Version)
+	return algo == blockchain.PowAlgoMobileX
 }
 
 // ValidateBlockAlgorithm validates that a block was mined with an acceptable algorithm
@@ -95,11 +112,14 @@ func (mp *MiningPolicy) ValidateBlockAlgorithm(blockHeader *wire.BlockHeader, bl
 
 // validateRandomXBlock validates a RandomX-mined block
 func (mp *MiningPolicy) validateRandomXBlock(blockHeader *wire.BlockHeader, blockHeight int32) error {
-	// Standard RandomX validation
-	// This would integrate with the existing RandomX validation logic
-
 	// Check difficulty meets target
-	hash := mp.computeRandomXHash(blockHeader)
+	if mp.randomXSeeds == nil {
+		return errors.New("no RandomX seed manager configured")
+	}
+	hash, err := mp.computeRandomXHash(blockHeader, blockHeight)
+	if err != nil {
+		return err
+	}
 	hashBig := randomx.HashToBig(&hash)
 	target := randomx.CompactToBig(blockHeader.Bits)
 
@@ -125,7 +145,10 @@ func (mp *MiningPolicy) validateMobileXBlock(blockHeader *wire.BlockHeader, bloc
 	}
 
 	// 3. Check MobileX hash meets difficulty target
-	hash := mp.computeMobileXHash(blockHeader)
+	hash, err := mp.computeMobileXHash(blockHeader, blockHeight)
+	if err != nil {
+		return err
+	}
 	hashBig := randomx.HashToBig(&hash)
 	target := randomx.CompactToBig(blockHeader.Bits)
 
@@ -166,26 +189,49 @@ func (mp *MiningPolicy) validateThermalProof(blockHeader *wire.BlockHeader) erro
 	return nil
 }
 
-// computeRandomXHash computes the RandomX hash for a block header
-func (mp *MiningPolicy) computeRandomXHash(blockHeader *wire.BlockHeader) chainhash.Hash {
-	// This would integrate with the actual RandomX implementation
-	// For now, return a placeholder
-	// In real implementation: return randomx.ComputeHash(blockHeader)
+// computeRandomXHash computes the RandomX hash for a block header at the
+// passed height using the epoch of its seed.
+func (mp *MiningPolicy) computeRandomXHash(blockHeader *wire.BlockHeader, blockHeight int32) (chainhash.Hash, error) {
+	hash, err := mp.randomXSeeds.HashHeader(blockHeight, blockHeader)
+	if err != nil {
+		return hash, fmt.Errorf("failed to compute RandomX hash: %w", err)
+	}
+	return hash, nil
+}
 
-	var hash chainhash.Hash
-	// Placeholder hash computation
-	return hash
+// computeMobileXHash computes the MobileX hash for a block header at the
+// passed height using the epoch of its seed, or the reference verifier when
+// one is set.
+func (mp *MiningPolicy) computeMobileXHash(blockHeader *wire.BlockHeader, blockHeight int32) (chainhash.Hash, error) {
+	if mp.mobileXVerifier != nil {
+		return mp.mobileXVerifier.Hash(blockHeader), nil
+	}
+	if mp.randomXSeeds == nil {
+		return chainhash.Hash{}, errors.New("no RandomX seed manager configured")
+	}
+	hash, err := mobilex.HashHeaderAt(mp.randomXSeeds, blockHeight, blockHeader)
+	if err != nil {
+		return hash, fmt.Errorf("failed to compute MobileX hash: %w", err)
+	}
+	return hash, nil
 }
 
-// computeMobileXHash computes the MobileX hash for a block header
-func (mp *MiningPolicy) computeMobileXHash(blockHeader *wire.BlockHeader) chainhash.Hash {
-	// This would integrate with the MobileX implementation
-	// For now, return a placeholder
-	// In real implementation: return mobilex.ComputeHash(blockHeader)
+// PowHash returns the proof-of-work hash of the passed block header at the
+// passed height for the algorithm encoded in its version.  RandomX blocks are
+// hashed with RandomX and MobileX blocks with MobileX, both keyed by the seed
+// of the height's RandomX epoch.  This allows the policy to be used as the
+// blockchain.PowHasher of a chain.
+//
+// This function is safe for concurrent access.
+func (mp *MiningPolicy) PowHash(blockHeader *wire.BlockHeader, blockHeight int32) (chainhash.Hash, error) {
+	if mp.DetectAlgorithm(blockHeader) == AlgorithmMobileX {
+		return mp.computeMobileXHash(blockHeader, blockHeight)
+	}
 
-	var hash chainhash.Hash
-	// Placeholder hash computation
-	return hash
+	if mp.randomXSeeds == nil {
+		return chainhash.Hash{}, errors.New("no RandomX seed manager configured")
+	}
+	return mp.computeRandomXHash(blockHeader, blockHeight)
 }
 
 // GetSupportedAlgorithms returns the currently supported mining algorithms
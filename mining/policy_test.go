@@ -9,8 +9,11 @@ import (
 	"testing"
 
 	"github.com/toole-brendan/shell/blockchain"
+	"github.com/toole-brendan/shell/chaincfg"
 	"github.com/toole-brendan/shell/chaincfg/chainhash"
 	"github.com/toole-brendan/shell/internal/convert"
+	"github.com/toole-brendan/shell/mining/mobilex"
+	"github.com/toole-brendan/shell/mining/randomx"
 	"github.com/toole-brendan/shell/wire"
 )
 
@@ -166,3 +169,65 @@ func TestCalcPriority(t *testing.T) {
 		}
 	}
 }
+
+// TestMiningPolicyPowHash ensures the policy hashes blocks for chain
+// validation with the algorithm encoded in their version on top of the RandomX
+// epoch of their height.
+func TestMiningPolicyPowHash(t *testing.T) {
+	params := chaincfg.RegressionNetParams
+	params.RandomXSeedRotation = 4
+
+	policy := NewMiningPolicy(&params)
+	header := &wire.BlockHeader{
+		Version: blockchain.SetPowAlgorithm(0x20000000,
+			blockchain.PowAlgoRandomX),
+		Bits:  params.PowLimitBits,
+		Nonce: 7,
+	}
+	if _, err := policy.PowHash(header, 1); err == nil {
+		t.Fatal("PowHash: expected error without a seed manager")
+	}
+
+	seeds := randomx.NewSeedManager(&randomx.SeedConfig{
+		GenesisHash: params.GenesisHash,
+		Rotation:    params.RandomXSeedRotation,
+	})
+	defer seeds.Stop()
+	policy.SetRandomXSeeds(seeds)
+
+	// RandomX blocks are hashed with the epoch of their height, so the same
+	// header hashes differently in the next epoch.
+	got, err := policy.PowHash(header, 1)
+	if err != nil {
+		t.Fatalf("PowHash: unexpected error: %v", err)
+	}
+	want, err := seeds.HashHeader(1, header)
+	if err != nil {
+		t.Fatalf("HashHeader: unexpected error: %v", err)
+	}
+	if got != want {
+		t.Fatalf("RandomX hash: got %v, want %v", got, want)
+	}
+	next, err := policy.PowHash(header, 5)
+	if err != nil {
+		t.Fatalf("PowHash: unexpected error: %v", err)
+	}
+	if next == got {
+		t.Fatalf("RandomX hash %v is the same in the next epoch", next)
+	}
+
+	// MobileX blocks are hashed with MobileX on top of the same epochs.
+	header.Version = blockchain.SetPowAlgorithm(header.Version,
+		blockchain.PowAlgoMobileX)
+	got, err = policy.PowHash(header, 5)
+	if err != nil {
+		t.Fatalf("PowHash: unexpected error: %v", err)
+	}
+	want, err = mobilex.HashHeaderAt(seeds, 5, header)
+	if err != nil {
+		t.Fatalf("HashHeaderAt: unexpected error: %v", err)
+	}
+	if got != want {
+		t.Fatalf("MobileX hash: got %v, want %v", got, want)
+	}
+}
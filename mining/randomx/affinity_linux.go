@@ -0,0 +1,19 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+//go:build linux
+// +build linux
+
+package randomx
+
+import "golang.org/x/sys/unix"
+
+// pinThread restricts the calling OS thread to the passed CPUs.
+func pinThread(cpus []int) error {
+	var set unix.CPUSet
+	for _, cpu := range cpus {
+		set.Set(cpu)
+	}
+	return unix.SchedSetaffinity(0, &set)
+}
@@ -0,0 +1,16 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package randomx
+
+import "errors"
+
+// pinThread restricts the calling OS thread to the passed CPUs.  Threads can
+// only be pinned on Linux, so the scheduler places them on other platforms.
+func pinThread(cpus []int) error {
+	return errors.New("thread pinning is not supported on this platform")
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package randomx
+
+import (
+	"encoding/binary"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+)
+
+// BenchConfig describes a RandomX configuration to benchmark.
+type BenchConfig struct {
+	// FullMemory runs the VMs in fast mode on datasets instead of in light
+	// mode on the cache.
+	FullMemory bool
+
+	// Options tunes the flags and NUMA placement.
+	Options Options
+
+	// Threads is the number of hashing threads.  It defaults to the
+	// number of CPUs.
+	Threads int
+
+	// Duration is how long the threads hash for once the epoch has been
+	// computed.
+	Duration time.Duration
+
+	// Seed is the RandomX key to benchmark with.
+	Seed chainhash.Hash
+
+	// SysfsRoot is the root of the sysfs tree the NUMA nodes are detected
+	// from.  The default sysfs root is used when it is empty.
+	SysfsRoot string
+}
+
+// BenchResult is the outcome of benchmarking a RandomX configuration.
+type BenchResult struct {
+	// Flags are the flags the VMs actually ran with, after falling back
+	// from the flags the machine doesn't support.
+	Flags Flags
+
+	// Nodes is the number of NUMA nodes the datasets were computed on.
+	Nodes int
+
+	// Threads is the number of hashing threads.
+	Threads int
+
+	// InitTime is how long computing the cache and datasets took.
+	InitTime time.Duration
+
+	// Hashes is the number of hashes computed within Elapsed.
+	Hashes  uint64
+	Elapsed time.Duration
+}
+
+// HashRate returns the number of hashes per second of the benchmark.
+func (r *BenchResult) HashRate() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Hashes) / r.Elapsed.Seconds()
+}
+
+// Benchmark measures the hash rate of the passed RandomX configuration.  The
+// epoch is computed the same way the miner computes it, and the threads are
+// assigned round-robin to the NUMA nodes and pinned to them like mining
+// workers.
+func Benchmark(cfg *BenchConfig) (*BenchResult, error) {
+	if cfg.Duration <= 0 {
+		return nil, errors.New("benchmark duration must be positive")
+	}
+	threads := cfg.Threads
+	if threads <= 0 {
+		threads = runtime.NumCPU()
+	}
+
+	var nodes []NUMANode
+	if cfg.FullMemory {
+		nodes = DetectNUMANodes(cfg.SysfsRoot)
+		if cfg.Options.NoNUMA {
+			nodes = allCPUs()
+		}
+	}
+	seeds := NewSeedManager(&SeedConfig{
+		GenesisHash: &cfg.Seed,
+		FullMemory:  cfg.FullMemory,
+		Options:     cfg.Options,
+		NUMANodes:   nodes,
+	})
+	defer seeds.Stop()
+
+	start := time.Now()
+	epoch, err := seeds.Acquire(0)
+	if err != nil {
+		return nil, err
+	}
+	defer seeds.Release(epoch)
+
+	result := &BenchResult{
+		Nodes:    len(nodes),
+		Threads:  threads,
+		InitTime: time.Since(start),
+	}
+
+	// The VMs are created up front so their allocation isn't measured.
+	vms := make([]*VM, threads)
+	defer func() {
+		for _, vm := range vms {
+			if vm != nil {
+				vm.Close()
+			}
+		}
+	}()
+	for i := range vms {
+		vms[i], err = epoch.NewNodeVM(i)
+		if err != nil {
+			return nil, err
+		}
+	}
+	result.Flags = vms[0].Flags()
+
+	var hashes uint64
+	var stop int32
+	var wg sync.WaitGroup
+	wg.Add(threads)
+	start = time.Now()
+	for i, vm := range vms {
+		go func(thread int, vm *VM) {
+			defer wg.Done()
+			if len(nodes) > 1 {
+				lockToCPUs(nodes[thread%len(nodes)].CPUs)
+			}
+
+			// Hash a header-sized input with a varying nonce like a
+			// mining worker does.
+			var input [80]byte
+			binary.LittleEndian.PutUint32(input[:4], uint32(thread))
+			var count uint64
+			for nonce := uint32(0); atomic.LoadInt32(&stop) == 0; nonce++ {
+				binary.LittleEndian.PutUint32(input[76:], nonce)
+				vm.CalcHash(input[:])
+				count++
+			}
+			atomic.AddUint64(&hashes, count)
+		}(i, vm)
+	}
+
+	time.Sleep(cfg.Duration)
+	atomic.StoreInt32(&stop, 1)
+	wg.Wait()
+	result.Elapsed = time.Since(start)
+	result.Hashes = hashes
+
+	return result, nil
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package randomx
+
+import (
+	"testing"
+	"time"
+)
+
+// TestBenchmark ensures benchmarks hash on every thread and report the flags
+// the VMs ran with.
+func TestBenchmark(t *testing.T) {
+	result, err := Benchmark(&BenchConfig{
+		Options:  Options{NoJIT: true},
+		Threads:  2,
+		Duration: 50 * time.Millisecond,
+		Seed:     testGenesisHash,
+	})
+	if err != nil {
+		t.Fatalf("Benchmark: unexpected error: %v", err)
+	}
+	if result.Threads != 2 || result.Nodes != 0 {
+		t.Errorf("Benchmark: got %d threads on %d nodes, want 2 threads "+
+			"in light mode", result.Threads, result.Nodes)
+	}
+	if result.Flags&(FlagJIT|FlagFullMem) != 0 {
+		t.Errorf("Benchmark: got flags %v, want light mode without JIT",
+			result.Flags)
+	}
+	if result.Hashes == 0 || result.HashRate() <= 0 {
+		t.Errorf("Benchmark: got %d hashes in %v", result.Hashes,
+			result.Elapsed)
+	}
+
+	if _, err := Benchmark(&BenchConfig{}); err == nil {
+		t.Errorf("Benchmark: expected error without a duration")
+	}
+}
+
+// TestSeedManagerNUMA ensures fast mode epochs have a dataset on each NUMA
+// node and that VMs of every node run in fast mode.
+func TestSeedManagerNUMA(t *testing.T) {
+	if IsRealImplementation() {
+		t.Skip("computing datasets needs 2 GiB of memory per node")
+	}
+
+	root := writeNUMANodes(t, map[string]string{
+		"node0": "0",
+		"node1": "1",
+	})
+	sm := NewSeedManager(&SeedConfig{
+		GenesisHash: &testGenesisHash,
+		Rotation:    100,
+		FullMemory:  true,
+		NUMANodes:   DetectNUMANodes(root),
+	})
+	defer sm.Stop()
+
+	epoch, err := sm.Acquire(0)
+	if err != nil {
+		t.Fatalf("Acquire: unexpected error: %v", err)
+	}
+	defer sm.Release(epoch)
+
+	if len(epoch.datasets) != 2 {
+		t.Fatalf("Acquire: got %d datasets, want one per node",
+			len(epoch.datasets))
+	}
+	for node := 0; node < 3; node++ {
+		vm, err := epoch.NewNodeVM(node)
+		if err != nil {
+			t.Fatalf("NewNodeVM: unexpected error: %v", err)
+		}
+		if vm.Flags()&FlagFullMem == 0 {
+			t.Errorf("NewNodeVM(%d): got flags %v, want fast mode",
+				node, vm.Flags())
+		}
+		vm.Close()
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package randomx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/toole-brendan/shell/wire"
+)
+
+const (
+	// BlobSize is the size of the job blob of a block header, which is the
+	// size of the serialized header.
+	BlobSize = wire.MaxBlockHeaderPayload
+
+	// BlobNonceOffset is the offset of the header nonce within the blob.
+	// Standard RandomX mining software writes its nonces at this offset,
+	// where Monero blobs carry their nonce.
+	BlobNonceOffset = 39
+
+	// headerNonceOffset is the offset of the nonce within the serialized
+	// header, following the version, previous block, merkle root,
+	// timestamp and bits.
+	headerNonceOffset = 76
+
+	// nonceSize is the size of the header nonce.
+	nonceSize = 4
+)
+
+// HeaderBlob returns the job blob of the passed block header handed to external
+// miners.  The blob is the serialized header with the nonce moved from its
+// serialized offset to BlobNonceOffset:
+//
+//	blob[0:39]  = header[0:39]  (version, previous block, merkle root start)
+//	blob[39:43] = header[76:80] (nonce)
+//	blob[43:80] = header[39:76] (merkle root end, timestamp, bits)
+//	blob[80:88] = header[80:88] (thermal proof)
+//
+// This lets external miners roll the nonce of Shell headers the same way
+// they roll the nonce of Monero blobs.  The blob is not the proof-of-work
+// input though, which is the serialized header, so only miners that hash the
+// header the blob maps back to find valid solutions.
+func HeaderBlob(header *wire.BlockHeader) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(BlobSize)
+	if err := header.Serialize(&buf); err != nil {
+		return nil, err
+	}
+	serialized := buf.Bytes()
+
+	blob := make([]byte, BlobSize)
+	copy(blob, serialized[:BlobNonceOffset])
+	copy(blob[BlobNonceOffset:], serialized[headerNonceOffset:headerNonceOffset+nonceSize])
+	copy(blob[BlobNonceOffset+nonceSize:], serialized[BlobNonceOffset:headerNonceOffset])
+	copy(blob[headerNonceOffset+nonceSize:], serialized[headerNonceOffset+nonceSize:])
+	return blob, nil
+}
+
+// HeaderFromBlob returns the block header the passed blob was created from
+// by HeaderBlob, including any nonce written into the blob since.
+func HeaderFromBlob(blob []byte) (*wire.BlockHeader, error) {
+	if len(blob) != BlobSize {
+		return nil, fmt.Errorf("blob is %d bytes instead of %d",
+			len(blob), BlobSize)
+	}
+
+	serialized := make([]byte, BlobSize)
+	copy(serialized, blob[:BlobNonceOffset])
+	copy(serialized[BlobNonceOffset:], blob[BlobNonceOffset+nonceSize:headerNonceOffset+nonceSize])
+	copy(serialized[headerNonceOffset:], blob[BlobNonceOffset:BlobNonceOffset+nonceSize])
+	copy(serialized[headerNonceOffset+nonceSize:], blob[headerNonceOffset+nonceSize:])
+
+	var header wire.BlockHeader
+	if err := header.Deserialize(bytes.NewReader(serialized)); err != nil {
+		return nil, err
+	}
+	return &header, nil
+}
+
+// SetBlobNonce writes the passed nonce into the passed blob the way the
+// header serializes it.
+func SetBlobNonce(blob []byte, nonce uint32) {
+	binary.LittleEndian.PutUint32(blob[BlobNonceOffset:], nonce)
+}
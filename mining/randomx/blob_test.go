@@ -0,0 +1,90 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package randomx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// TestHeaderBlob ensures block headers map into blobs with the nonce at the
+// offset external miners roll it at and map back unchanged.
+func TestHeaderBlob(t *testing.T) {
+	header := wire.BlockHeader{
+		Version:      0x20000000,
+		PrevBlock:    chainhash.Hash{0x11, 0x12, 0x13},
+		MerkleRoot:   chainhash.Hash{0x21, 0x22, 0x23, 0x24, 0x25},
+		Timestamp:    time.Unix(1735689600, 0),
+		Bits:         0x1d00ffff,
+		Nonce:        0xdeadbeef,
+		ThermalProof: 0x0102030405060708,
+	}
+
+	blob, err := HeaderBlob(&header)
+	if err != nil {
+		t.Fatalf("HeaderBlob: unexpected error: %v", err)
+	}
+	if len(blob) != BlobSize {
+		t.Fatalf("HeaderBlob: got %d bytes, want %d", len(blob), BlobSize)
+	}
+
+	var serialized bytes.Buffer
+	if err := header.Serialize(&serialized); err != nil {
+		t.Fatalf("Serialize: unexpected error: %v", err)
+	}
+	want := serialized.Bytes()
+	tests := []struct {
+		name       string
+		blob, want []byte
+	}{
+		{"head", blob[:39], want[:39]},
+		{"nonce", blob[39:43], want[76:80]},
+		{"merkle root end, timestamp and bits", blob[43:80], want[39:76]},
+		{"thermal proof", blob[80:], want[80:]},
+	}
+	for _, test := range tests {
+		if !bytes.Equal(test.blob, test.want) {
+			t.Errorf("HeaderBlob %s: got %x, want %x", test.name,
+				test.blob, test.want)
+		}
+	}
+	if nonce := binary.LittleEndian.Uint32(blob[BlobNonceOffset:]); nonce != header.Nonce {
+		t.Errorf("HeaderBlob: nonce %x, want %x", nonce, header.Nonce)
+	}
+
+	got, err := HeaderFromBlob(blob)
+	if err != nil {
+		t.Fatalf("HeaderFromBlob: unexpected error: %v", err)
+	}
+	if got.BlockHash() != header.BlockHash() ||
+		got.ThermalProof != header.ThermalProof {
+
+		t.Errorf("HeaderFromBlob: got %+v, want %+v", got, header)
+	}
+
+	// Nonces written into the blob end up in the header.
+	SetBlobNonce(blob, 42)
+	got, err = HeaderFromBlob(blob)
+	if err != nil {
+		t.Fatalf("HeaderFromBlob: unexpected error: %v", err)
+	}
+	if got.Nonce != 42 {
+		t.Errorf("HeaderFromBlob: nonce %d, want 42", got.Nonce)
+	}
+	rolled := header
+	rolled.Nonce = 42
+	if got.BlockHash() != rolled.BlockHash() {
+		t.Errorf("HeaderFromBlob: nonce changed other fields")
+	}
+
+	if _, err := HeaderFromBlob(blob[:80]); err == nil {
+		t.Errorf("HeaderFromBlob: expected error for short blob")
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// randomx-bench reports the RandomX hash rate of this machine for each
+// combination of mode, large pages, JIT compilation and NUMA placement.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"text/tabwriter"
+	"time"
+
+	"github.com/toole-brendan/shell/chaincfg"
+	"github.com/toole-brendan/shell/mining/randomx"
+)
+
+var (
+	// Command line flags
+	durationFlag = flag.Duration("duration", 10*time.Second, "Hashing duration of each configuration")
+	threadsFlag  = flag.Int("threads", runtime.NumCPU(), "Number of hashing threads")
+	modeFlag     = flag.String("mode", "all", "Modes to benchmark (light, fast, all)")
+	quickFlag    = flag.Bool("quick", false, "Only benchmark the autodetected configuration of each mode")
+)
+
+// benchCase is a configuration to benchmark along with its description.
+type benchCase struct {
+	mode       string
+	largePages bool
+	jit        bool
+	numa       bool
+}
+
+// benchCases returns the configurations to benchmark for the passed modes.
+// NUMA placement only matters in fast mode on machines with several nodes.
+func benchCases(modes []string, numaNodes int, quick bool) []benchCase {
+	toggles := []bool{true, false}
+	if quick {
+		toggles = toggles[:1]
+	}
+
+	var cases []benchCase
+	for _, mode := range modes {
+		numa := []bool{false}
+		if mode == "fast" && numaNodes > 1 {
+			numa = toggles
+		}
+		for _, largePages := range toggles {
+			for _, jit := range toggles {
+				for _, n := range numa {
+					cases = append(cases, benchCase{
+						mode:       mode,
+						largePages: largePages,
+						jit:        jit,
+						numa:       n,
+					})
+				}
+			}
+		}
+	}
+	return cases
+}
+
+// onOff returns the passed setting as "on" or "off".
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+func main() {
+	flag.Parse()
+
+	var modes []string
+	switch *modeFlag {
+	case "light", "fast":
+		modes = []string{*modeFlag}
+	case "all":
+		modes = []string{"light", "fast"}
+	default:
+		log.Fatalf("Unknown mode %q", *modeFlag)
+	}
+
+	nodes := randomx.DetectNUMANodes("")
+	fmt.Println("Shell Reserve - RandomX Benchmark")
+	fmt.Println("=================================")
+	fmt.Printf("Implementation: %s\n", randomx.GetImplementationInfo())
+	fmt.Printf("Detected flags: %v\n", randomx.DetectFlags(randomx.Options{}))
+	fmt.Printf("CPUs: %d, NUMA nodes: %d, threads: %d\n\n", runtime.NumCPU(),
+		len(nodes), *threadsFlag)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MODE\tLARGE PAGES\tJIT\tNUMA\tINIT\tHASHRATE\tFLAGS")
+	for _, c := range benchCases(modes, len(nodes), *quickFlag) {
+		result, err := randomx.Benchmark(&randomx.BenchConfig{
+			FullMemory: c.mode == "fast",
+			Options: randomx.Options{
+				NoLargePages: !c.largePages,
+				NoJIT:        !c.jit,
+				NoNUMA:       !c.numa,
+			},
+			Threads:  *threadsFlag,
+			Duration: *durationFlag,
+			Seed:     *chaincfg.MainNetParams.GenesisHash,
+		})
+		if err != nil {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\terror: %v\t\t\n", c.mode,
+				onOff(c.largePages), onOff(c.jit), onOff(c.numa), err)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%v\t%.1f H/s\t%v\n", c.mode,
+			onOff(c.largePages), onOff(c.jit), onOff(c.numa),
+			result.InitTime.Round(time.Millisecond), result.HashRate(),
+			result.Flags)
+	}
+	w.Flush()
+}
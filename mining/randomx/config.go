@@ -62,6 +62,17 @@ type Config struct {
 	// RandomXSeedRotation specifies the number of blocks between RandomX seed rotations.
 	RandomXSeedRotation int32
 
+	// SeedManager provides the RandomX epochs to mine with.  It is
+	// optional; when nil the miner computes its own epochs in fast mode
+	// while it is running.  Sharing the seed manager used for block
+	// validation avoids computing each cache twice.
+	SeedManager *SeedManager
+
+	// Options tunes the RandomX flags and the placement of the workers on
+	// NUMA nodes when the miner computes its own epochs.  The zero value
+	// autodetects everything.
+	Options Options
+
 	// NumWorkers specifies the number of workers to create to solve blocks.
 	NumWorkers uint32
 
@@ -69,6 +80,14 @@ type Config struct {
 	// number of workers.
 	UpdateNumWorkers chan struct{}
 
+	// PrepareMobileXBlock turns the passed copy of a block template into a
+	// MobileX block by encoding the algorithm in its version and setting
+	// the MobileX difficulty.  Once MobileX is active the algorithms
+	// retarget independently, so MobileX solutions are only valid for
+	// blocks prepared this way.  It is optional and only used when mining
+	// with MobileX.
+	PrepareMobileXBlock func(*wire.MsgBlock) error
+
 	// The following functions are required:
 
 	// ConnectedCount should return the number of currently connected peers
@@ -0,0 +1,91 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package randomx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Map of Flags bits back to their names for pretty printing.
+var flagStrings = []struct {
+	flag Flags
+	name string
+}{
+	{FlagLargePages, "large_pages"},
+	{FlagHardAES, "hard_aes"},
+	{FlagFullMem, "full_mem"},
+	{FlagJIT, "jit"},
+	{FlagSecure, "secure"},
+	{FlagArgon2SSSE3, "argon2_ssse3"},
+	{FlagArgon2AVX2, "argon2_avx2"},
+}
+
+// String returns the set flags in human-readable form, such as
+// "hard_aes|jit".
+func (f Flags) String() string {
+	if f == FlagDefault {
+		return "default"
+	}
+
+	var names []string
+	for _, s := range flagStrings {
+		if f&s.flag == s.flag {
+			names = append(names, s.name)
+			f &^= s.flag
+		}
+	}
+	if f != 0 {
+		names = append(names, fmt.Sprintf("0x%x", int(f)))
+	}
+	return strings.Join(names, "|")
+}
+
+// Options tunes how RandomX allocates memory, compiles programs and places
+// mining workers.  The zero value enables everything the machine supports.
+type Options struct {
+	// NoLargePages disables allocating the cache, dataset and VM
+	// scratchpads on huge pages.  Huge pages are used when the kernel has
+	// enough of them reserved and ordinary pages otherwise.
+	NoLargePages bool
+
+	// NoJIT disables compiling RandomX programs to machine code, which
+	// some hardened kernels forbid.  The interpreter is used instead.
+	NoJIT bool
+
+	// NoNUMA disables computing a dataset on each NUMA node and pinning
+	// the workers to the node of their dataset.
+	NoNUMA bool
+}
+
+// DetectFlags returns the flags RandomX runs with on this machine for the
+// passed options.  The CPU features are detected by RandomX itself, while
+// large pages are always requested unless disabled since allocations fall
+// back to ordinary pages when no huge pages are available.
+func DetectFlags(opts Options) Flags {
+	flags := GetFlags()
+	if !opts.NoLargePages {
+		flags |= FlagLargePages
+	}
+	if opts.NoJIT {
+		flags &^= FlagJIT
+	}
+	return flags
+}
+
+// fallbackFlags returns the flags to retry an allocation with, starting with
+// the passed flags and dropping the passed optional flags one at a time until
+// none are left.  Optional flags that are not set are skipped.
+func fallbackFlags(flags, optional Flags) []Flags {
+	attempts := []Flags{flags}
+	for _, s := range flagStrings {
+		if optional&s.flag == 0 || flags&s.flag == 0 {
+			continue
+		}
+		flags &^= s.flag
+		attempts = append(attempts, flags)
+	}
+	return attempts
+}
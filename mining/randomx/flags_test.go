@@ -0,0 +1,87 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package randomx
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestFlagsStringer tests the stringized output for the Flags type.
+func TestFlagsStringer(t *testing.T) {
+	tests := []struct {
+		in   Flags
+		want string
+	}{
+		{FlagDefault, "default"},
+		{FlagJIT, "jit"},
+		{FlagHardAES | FlagJIT | FlagLargePages, "large_pages|hard_aes|jit"},
+		{FlagFullMem | 0x1000, "full_mem|0x1000"},
+	}
+
+	for _, test := range tests {
+		if got := test.in.String(); got != test.want {
+			t.Errorf("String: got %q, want %q", got, test.want)
+		}
+	}
+}
+
+// TestDetectFlags ensures the options toggle large pages and JIT compilation
+// on top of the flags RandomX detects for the CPU.
+func TestDetectFlags(t *testing.T) {
+	cpu := GetFlags() &^ FlagLargePages
+	tests := []struct {
+		name string
+		opts Options
+		want Flags
+	}{
+		{"autodetect", Options{}, cpu | FlagLargePages},
+		{"no large pages", Options{NoLargePages: true}, cpu},
+		{"no jit", Options{NoJIT: true}, (cpu | FlagLargePages) &^ FlagJIT},
+	}
+
+	for _, test := range tests {
+		if got := DetectFlags(test.opts); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+// TestFallbackFlags ensures allocations are retried without each optional
+// flag that is set.
+func TestFallbackFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		flags    Flags
+		optional Flags
+		want     []Flags
+	}{{
+		name:     "nothing optional",
+		flags:    FlagHardAES | FlagJIT,
+		optional: 0,
+		want:     []Flags{FlagHardAES | FlagJIT},
+	}, {
+		name:     "large pages then jit",
+		flags:    FlagLargePages | FlagHardAES | FlagJIT,
+		optional: FlagLargePages | FlagJIT,
+		want: []Flags{
+			FlagLargePages | FlagHardAES | FlagJIT,
+			FlagHardAES | FlagJIT,
+			FlagHardAES,
+		},
+	}, {
+		name:     "unset optional flags are skipped",
+		flags:    FlagHardAES | FlagJIT,
+		optional: FlagLargePages | FlagJIT,
+		want:     []Flags{FlagHardAES | FlagJIT, FlagHardAES},
+	}}
+
+	for _, test := range tests {
+		got := fallbackFlags(test.flags, test.optional)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
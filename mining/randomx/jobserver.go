@@ -0,0 +1,847 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package randomx
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/internal/convert"
+	"github.com/toole-brendan/shell/wire"
+)
+
+const (
+	// JobAlgorithm is the name external miners know the RandomX
+	// configuration of Shell by.
+	JobAlgorithm = "rx/0"
+
+	// defaultJobTimeout is the default time a miner connection may stay
+	// idle before it is closed.
+	defaultJobTimeout = 10 * time.Minute
+
+	// defaultJobRefresh is the default interval at which new block
+	// templates are handed out so miners include new transactions.
+	defaultJobRefresh = 30 * time.Second
+
+	// defaultJobMaxSessions is the default number of miners that may be
+	// connected at once.
+	defaultJobMaxSessions = 25
+
+	// jobLoginTimeout is the time a miner may take to log in once
+	// connected, so connections that never log in don't hold on to
+	// sessions.
+	jobLoginTimeout = 30 * time.Second
+
+	// maxJobMessageSize is the maximum size of a request, including its
+	// newline.  Requests of miners are far smaller, so longer lines are
+	// rejected rather than buffered.
+	maxJobMessageSize = 16 * 1024
+
+	// maxSessionJobs is the number of most recent jobs of a session that
+	// solutions are accepted for.  Older jobs are forgotten.
+	maxSessionJobs = 4
+
+	// jobErrorCode is the error code of all job server errors, as used by
+	// Monero pools.
+	jobErrorCode = -1
+)
+
+var (
+	// errUnauthenticated is returned for requests of sessions that are not
+	// logged in.
+	errUnauthenticated = errors.New("Unauthenticated")
+
+	// errNoJob is returned when no block template is available yet.
+	errNoJob = errors.New("No job available")
+
+	// errInvalidPassword is returned for logins with the wrong password.
+	// The session is closed after answering them.
+	errInvalidPassword = errors.New("Invalid password")
+)
+
+// JobServerMessage is a request, response or notification of the job
+// protocol.  Messages are JSON objects sent one per line.
+type JobServerMessage struct {
+	ID      interface{}     `json:"id"`
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *JobServerError `json:"error,omitempty"`
+}
+
+// JobServerError is the error of a failed request.
+type JobServerError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Job is the work handed to a miner.  The blob is the hex-encoded blob of a
+// block header as created by HeaderBlob, in which the miner rolls the nonce
+// at BlobNonceOffset.  The target is the hex-encoded little-endian 64-bit
+// value the most significant 64 bits of a little-endian hash must be below.
+// The seed hash is the hex-encoded RandomX key of the epoch of the block.
+type Job struct {
+	Blob     string `json:"blob"`
+	JobID    string `json:"job_id"`
+	Target   string `json:"target"`
+	Algo     string `json:"algo"`
+	Height   int32  `json:"height"`
+	SeedHash string `json:"seed_hash"`
+}
+
+// LoginParams are the parameters of a login request.
+type LoginParams struct {
+	Login string   `json:"login"`
+	Pass  string   `json:"pass"`
+	Agent string   `json:"agent"`
+	Algo  []string `json:"algo"`
+}
+
+// LoginResult is the result of a login request.
+type LoginResult struct {
+	ID         string   `json:"id"`
+	Job        *Job     `json:"job"`
+	Extensions []string `json:"extensions"`
+	Status     string   `json:"status"`
+}
+
+// SessionParams are the parameters of requests that only identify the
+// session, such as getjob and keepalived.
+type SessionParams struct {
+	ID string `json:"id"`
+}
+
+// SubmitParams are the parameters of a submit request.  The nonce is the
+// hex encoding of the four nonce bytes of the blob and the result is the hex
+// encoding of the RandomX hash the miner computed.
+type SubmitParams struct {
+	ID     string `json:"id"`
+	JobID  string `json:"job_id"`
+	Nonce  string `json:"nonce"`
+	Result string `json:"result"`
+}
+
+// StatusResult is the result of requests that only report a status.
+type StatusResult struct {
+	Status string `json:"status"`
+}
+
+// JobServerConfig is a descriptor which specifies the job server
+// configuration.
+type JobServerConfig struct {
+	// Listeners are the listeners external miners connect to.
+	Listeners []net.Listener
+
+	// Seeds provides the RandomX epochs found blocks are verified with.
+	Seeds *SeedManager
+
+	// ConnectionTimeout is the time a miner connection may stay idle
+	// before it is closed.  Handing out a job counts as activity.  It
+	// defaults to ten minutes.
+	ConnectionTimeout time.Duration
+
+	// RefreshInterval is the interval at which new block templates are
+	// handed out.  It defaults to 30 seconds.
+	RefreshInterval time.Duration
+
+	// MaxSessions is the maximum number of miners connected at once.
+	// Miners connecting beyond it are disconnected right away.  It
+	// defaults to 25.
+	MaxSessions int
+
+	// Password is the password miners must log in with.  Any login is
+	// accepted when it is empty.
+	Password string
+
+	// Whitelist holds the networks miners may connect from.  Miners may
+	// connect from anywhere when it is empty.
+	Whitelist []*net.IPNet
+
+	// The following functions are required:
+
+	// BlockTemplateGenerator should return a new block template that is
+	// ready to be solved.
+	BlockTemplateGenerator func() (*BlockTemplate, error)
+
+	// SubmitBlock should submit the passed block to the network after
+	// ensuring it passes all consensus validation rules.
+	SubmitBlock func(*btcutil.Block) error
+}
+
+// sessionJob is a job handed to a session along with the block it was
+// created from.  The coinbase of each job carries an extra nonce unique to
+// the job, so miners never search the same blobs.
+type sessionJob struct {
+	id     string
+	block  *wire.MsgBlock
+	height int32
+	target uint64
+
+	// nonces are the nonces submitted for the job so far.
+	nonces map[uint32]struct{}
+}
+
+// jobSession is a miner connected to the job server.
+type jobSession struct {
+	id     uint64
+	conn   net.Conn
+	reader *bufio.Reader
+
+	// mtx guards the fields below, which the job handler updates from
+	// outside the session's goroutine.
+	mtx      sync.Mutex
+	writer   *bufio.Writer
+	loggedIn bool
+	jobs     []*sessionJob
+	nextJob  uint32
+}
+
+// JobServer hands out RandomX work to external miners such as xmrig over the
+// line-based JSON protocol of Monero pools.  Miners log in, receive jobs made
+// of a header blob, target and seed hash, and submit the nonces that solve
+// them.  Solved blocks are submitted to the network.
+type JobServer struct {
+	started  int32
+	shutdown int32
+
+	cfg      JobServerConfig
+	passHash [sha256.Size]byte
+
+	// mtx guards the fields below.
+	mtx      sync.Mutex
+	template *BlockTemplate
+	sessions map[uint64]*jobSession
+
+	nextSession uint64
+	refresh     chan struct{}
+	quit        chan struct{}
+	wg          sync.WaitGroup
+}
+
+// NewJobServer returns a new job server for the passed configuration.  Use
+// Start to begin serving jobs.
+func NewJobServer(cfg *JobServerConfig) *JobServer {
+	s := &JobServer{
+		cfg:      *cfg,
+		sessions: make(map[uint64]*jobSession),
+		refresh:  make(chan struct{}, 1),
+		quit:     make(chan struct{}),
+	}
+	if s.cfg.ConnectionTimeout <= 0 {
+		s.cfg.ConnectionTimeout = defaultJobTimeout
+	}
+	if s.cfg.RefreshInterval <= 0 {
+		s.cfg.RefreshInterval = defaultJobRefresh
+	}
+	if s.cfg.MaxSessions <= 0 {
+		s.cfg.MaxSessions = defaultJobMaxSessions
+	}
+	s.passHash = sha256.Sum256([]byte(s.cfg.Password))
+	return s
+}
+
+// Start begins accepting miners on the configured listeners.
+func (s *JobServer) Start() {
+	if atomic.AddInt32(&s.started, 1) != 1 {
+		return
+	}
+
+	s.wg.Add(1)
+	go s.jobHandler()
+
+	for _, listener := range s.cfg.Listeners {
+		log.Infof("RandomX job server listening on %s", listener.Addr())
+		s.wg.Add(1)
+		go s.acceptMiners(listener)
+	}
+}
+
+// Stop disconnects all miners and waits for the server to shut down.
+func (s *JobServer) Stop() {
+	if atomic.AddInt32(&s.shutdown, 1) != 1 {
+		return
+	}
+
+	close(s.quit)
+	for _, listener := range s.cfg.Listeners {
+		listener.Close()
+	}
+	s.mtx.Lock()
+	for _, session := range s.sessions {
+		session.conn.Close()
+	}
+	s.mtx.Unlock()
+	s.wg.Wait()
+}
+
+// Refresh requests a new block template to be handed out to all miners, such
+// as when a block was connected to the main chain.  It does not wait for the
+// template to be created.
+//
+// This function is safe for concurrent access.
+func (s *JobServer) Refresh() {
+	select {
+	case s.refresh <- struct{}{}:
+	default:
+		// A refresh is already pending.
+	}
+}
+
+// jobHandler creates new block templates when requested and periodically,
+// and hands them out to the miners.  It must be run as a goroutine.
+func (s *JobServer) jobHandler() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.updateTemplate(); err != nil {
+			log.Debugf("Failed to create RandomX job template: %v", err)
+		}
+
+		select {
+		case <-s.refresh:
+		case <-ticker.C:
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// updateTemplate creates a new block template and hands out jobs for it to
+// all logged in miners.  Jobs of previous templates are dropped when the new
+// template builds on another block since they can no longer be solved.
+func (s *JobServer) updateTemplate() error {
+	template, err := s.cfg.BlockTemplateGenerator()
+	if err != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	clean := s.template == nil || template.Block.Header.PrevBlock !=
+		s.template.Block.Header.PrevBlock
+	s.template = template
+	sessions := make([]*jobSession, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	s.mtx.Unlock()
+
+	for _, session := range sessions {
+		session.mtx.Lock()
+		loggedIn := session.loggedIn
+		if clean {
+			session.jobs = nil
+		}
+		session.mtx.Unlock()
+		if !loggedIn {
+			continue
+		}
+
+		job, err := s.newJob(session)
+		if err != nil {
+			log.Debugf("Failed to create RandomX job for miner %d: %v",
+				session.id, err)
+			continue
+		}
+		if err := s.sendNotification(session, "job", job); err != nil {
+			session.conn.Close()
+			continue
+		}
+		session.conn.SetDeadline(time.Now().Add(s.cfg.ConnectionTimeout))
+	}
+	return nil
+}
+
+// newJob creates a job of the current block template for the passed session.
+func (s *JobServer) newJob(session *jobSession) (*Job, error) {
+	s.mtx.Lock()
+	template := s.template
+	s.mtx.Unlock()
+	if template == nil {
+		return nil, errNoJob
+	}
+
+	session.mtx.Lock()
+	session.nextJob++
+	jobNum := session.nextJob
+	session.mtx.Unlock()
+
+	// Give each job its own coinbase so no two jobs share a blob.
+	block := template.Block.Copy()
+	extraNonce := session.id<<32 | uint64(jobNum)
+	if err := UpdateExtraNonce(block, template.Height, extraNonce); err != nil {
+		return nil, err
+	}
+	blob, err := HeaderBlob(&block.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &sessionJob{
+		id:     strconv.FormatUint(extraNonce, 16),
+		block:  block,
+		height: template.Height,
+		target: jobTarget(CompactToBig(block.Header.Bits)),
+		nonces: make(map[uint32]struct{}),
+	}
+
+	session.mtx.Lock()
+	session.jobs = append(session.jobs, job)
+	if len(session.jobs) > maxSessionJobs {
+		session.jobs = session.jobs[len(session.jobs)-maxSessionJobs:]
+	}
+	session.mtx.Unlock()
+
+	seed := s.cfg.Seeds.Seed(template.Height)
+	return &Job{
+		Blob:     hex.EncodeToString(blob),
+		JobID:    job.id,
+		Target:   encodeJobTarget(job.target),
+		Algo:     JobAlgorithm,
+		Height:   template.Height,
+		SeedHash: hex.EncodeToString(seed[:]),
+	}, nil
+}
+
+// acceptMiners accepts miner connections on the passed listener until the
+// server is stopped.  Miners that are not whitelisted or exceed the maximum
+// number of sessions are disconnected right away.  It must be run as a
+// goroutine.
+func (s *JobServer) acceptMiners(listener net.Listener) {
+	defer s.wg.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-s.quit:
+				return
+			default:
+			}
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				continue
+			}
+			log.Errorf("RandomX job server on %s failed: %v",
+				listener.Addr(), err)
+			return
+		}
+
+		if !s.isWhitelisted(conn.RemoteAddr()) {
+			log.Infof("RandomX miner %s is not whitelisted - "+
+				"disconnecting", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+
+		session := &jobSession{
+			id:     atomic.AddUint64(&s.nextSession, 1),
+			conn:   conn,
+			reader: bufio.NewReaderSize(conn, maxJobMessageSize),
+			writer: bufio.NewWriter(conn),
+		}
+		// Sessions accepted while stopping are not closed by Stop.
+		s.mtx.Lock()
+		select {
+		case <-s.quit:
+			s.mtx.Unlock()
+			conn.Close()
+			return
+		default:
+		}
+		if len(s.sessions) >= s.cfg.MaxSessions {
+			s.mtx.Unlock()
+			log.Infof("Max RandomX miners exceeded [%d] - "+
+				"disconnecting miner %s", s.cfg.MaxSessions,
+				conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+		s.sessions[session.id] = session
+		s.mtx.Unlock()
+
+		s.wg.Add(1)
+		go s.handleSession(session)
+	}
+}
+
+// isWhitelisted returns whether miners may connect from the passed address.
+func (s *JobServer) isWhitelisted(addr net.Addr) bool {
+	if len(s.cfg.Whitelist) == 0 {
+		return true
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, ipnet := range s.cfg.Whitelist {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleSession reads and answers the requests of a miner until it
+// disconnects.  Miners that don't log in within the login timeout, send
+// requests larger than the maximum message size or fail to log in are
+// disconnected.  It must be run as a goroutine.
+func (s *JobServer) handleSession(session *jobSession) {
+	defer s.wg.Done()
+	defer s.removeSession(session)
+
+	log.Debugf("RandomX miner %d connected from %s", session.id,
+		session.conn.RemoteAddr())
+
+	for {
+		timeout := s.cfg.ConnectionTimeout
+		session.mtx.Lock()
+		if !session.loggedIn && timeout > jobLoginTimeout {
+			timeout = jobLoginTimeout
+		}
+		session.mtx.Unlock()
+		session.conn.SetDeadline(time.Now().Add(timeout))
+
+		// The line is only valid until the next read, which is fine
+		// since it is decoded right away.
+		line, err := session.reader.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			s.sendError(session, nil, "Request too large")
+			return
+		}
+		if err != nil {
+			return
+		}
+
+		var msg JobServerMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			s.sendError(session, nil, "Parse error")
+			return
+		}
+
+		if err := s.handleMethod(session, &msg); err != nil {
+			if err := s.sendError(session, msg.ID, err.Error()); err != nil {
+				return
+			}
+			if errors.Is(err, errInvalidPassword) {
+				return
+			}
+		}
+	}
+}
+
+// removeSession closes the connection of the passed session and forgets it.
+func (s *JobServer) removeSession(session *jobSession) {
+	session.conn.Close()
+
+	s.mtx.Lock()
+	delete(s.sessions, session.id)
+	s.mtx.Unlock()
+
+	log.Debugf("RandomX miner %d disconnected", session.id)
+}
+
+// handleMethod routes the passed request to its handler.  Failed requests
+// return the error to answer them with, whose messages follow those of Monero
+// pools since miners display them as is.
+func (s *JobServer) handleMethod(session *jobSession, msg *JobServerMessage) error {
+	switch msg.Method {
+	case "login":
+		return s.handleLogin(session, msg)
+	case "getjob":
+		return s.handleGetJob(session, msg)
+	case "submit":
+		return s.handleSubmit(session, msg)
+	case "keepalived":
+		return s.handleKeepalived(session, msg)
+	default:
+		return fmt.Errorf("Unknown method %q", msg.Method)
+	}
+}
+
+// sessionID returns the ID miners identify the passed session by.
+func sessionID(session *jobSession) string {
+	return strconv.FormatUint(session.id, 16)
+}
+
+// checkSession returns an error unless the passed session is logged in and
+// the request identifies it with the passed ID.
+func checkSession(session *jobSession, id string) error {
+	session.mtx.Lock()
+	defer session.mtx.Unlock()
+
+	if !session.loggedIn || id != sessionID(session) {
+		return errUnauthenticated
+	}
+	return nil
+}
+
+// handleLogin handles login requests.  The login itself is not checked since
+// the node pays solved blocks to its own mining addresses, but the password
+// has to match the configured one if any.
+func (s *JobServer) handleLogin(session *jobSession, msg *JobServerMessage) error {
+	var params LoginParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return errors.New("Invalid params")
+	}
+
+	// Check the password in constant time so timing doesn't reveal it.
+	if s.cfg.Password != "" {
+		passHash := sha256.Sum256([]byte(params.Pass))
+		if subtle.ConstantTimeCompare(passHash[:], s.passHash[:]) != 1 {
+			log.Infof("RandomX miner %d from %s failed to log in",
+				session.id, session.conn.RemoteAddr())
+			return errInvalidPassword
+		}
+	}
+
+	// Miners list the algorithms they support when they do.
+	if len(params.Algo) > 0 {
+		supported := false
+		for _, algo := range params.Algo {
+			if algo == JobAlgorithm {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return fmt.Errorf("Unsupported algorithm, %s required",
+				JobAlgorithm)
+		}
+	}
+
+	session.mtx.Lock()
+	session.loggedIn = true
+	session.mtx.Unlock()
+
+	job, err := s.newJob(session)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("RandomX miner %d logged in from %s (%s)", session.id,
+		session.conn.RemoteAddr(), params.Agent)
+
+	return s.sendResult(session, msg.ID, &LoginResult{
+		ID:         sessionID(session),
+		Job:        job,
+		Extensions: []string{"algo", "keepalive"},
+		Status:     "OK",
+	})
+}
+
+// handleGetJob handles getjob requests.
+func (s *JobServer) handleGetJob(session *jobSession, msg *JobServerMessage) error {
+	var params SessionParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return errors.New("Invalid params")
+	}
+	if err := checkSession(session, params.ID); err != nil {
+		return err
+	}
+
+	job, err := s.newJob(session)
+	if err != nil {
+		return err
+	}
+	return s.sendResult(session, msg.ID, job)
+}
+
+// handleKeepalived handles keepalived requests, which only keep the
+// connection from timing out.
+func (s *JobServer) handleKeepalived(session *jobSession, msg *JobServerMessage) error {
+	var params SessionParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return errors.New("Invalid params")
+	}
+	if err := checkSession(session, params.ID); err != nil {
+		return err
+	}
+	return s.sendResult(session, msg.ID, &StatusResult{Status: "KEEPALIVED"})
+}
+
+// handleSubmit handles submit requests.  The nonce is verified by hashing the
+// header of the job with it the way blocks are hashed by consensus, and the
+// block is submitted to the network when the hash meets the target of the
+// block.  Results computed over the blob itself are rejected since the blob
+// is not the proof-of-work input.
+func (s *JobServer) handleSubmit(session *jobSession, msg *JobServerMessage) error {
+	var params SubmitParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return errors.New("Invalid params")
+	}
+	if err := checkSession(session, params.ID); err != nil {
+		return err
+	}
+
+	nonceBytes, err := hex.DecodeString(params.Nonce)
+	if err != nil || len(nonceBytes) != nonceSize {
+		return errors.New("Malformed nonce")
+	}
+	nonce := binary.LittleEndian.Uint32(nonceBytes)
+
+	session.mtx.Lock()
+	var job *sessionJob
+	for _, j := range session.jobs {
+		if j.id == params.JobID {
+			job = j
+			break
+		}
+	}
+	if job == nil {
+		session.mtx.Unlock()
+		return errors.New("Invalid job id")
+	}
+	if _, ok := job.nonces[nonce]; ok {
+		session.mtx.Unlock()
+		return errors.New("Duplicate share")
+	}
+	job.nonces[nonce] = struct{}{}
+	session.mtx.Unlock()
+
+	header := job.block.Header
+	header.Nonce = nonce
+	hash, err := s.cfg.Seeds.HashHeader(job.height, &header)
+	if err != nil {
+		log.Errorf("Failed to verify RandomX solution: %v", err)
+		return errors.New("Internal error")
+	}
+	if params.Result != "" && !strings.EqualFold(params.Result,
+		hex.EncodeToString(hash[:])) {
+
+		return errors.New("Bad hash")
+	}
+	if hashTarget(&hash) >= job.target {
+		return errors.New("Low difficulty share")
+	}
+
+	// The nonce is below the job target, which is only rounded up from
+	// the block target, so the full target still has to be checked.
+	if HashToBig(&hash).Cmp(CompactToBig(header.Bits)) <= 0 {
+		block := job.block.Copy()
+		block.Header.Nonce = nonce
+		if err := s.submitBlock(block, job.height); err != nil {
+			return fmt.Errorf("Block rejected: %v", err)
+		}
+	}
+
+	return s.sendResult(session, msg.ID, &StatusResult{Status: "OK"})
+}
+
+// submitBlock submits the passed solved block to the network and hands out
+// jobs for the next block once it is accepted.
+func (s *JobServer) submitBlock(block *wire.MsgBlock, height int32) error {
+	if err := s.cfg.SubmitBlock(convert.NewShellBlock(block)); err != nil {
+		log.Warnf("Block from RandomX miner at height %d rejected: %v",
+			height, err)
+		return err
+	}
+
+	log.Infof("Block submitted by RandomX miner %s (height %d)",
+		block.BlockHash(), height)
+	s.Refresh()
+	return nil
+}
+
+// jobTarget returns the target of jobs for blocks with the passed target.
+// Miners compare the most significant 64 bits of the little-endian hash
+// against the job target, so the job target is the most significant 64 bits
+// of the block target rounded up to include every hash below it.
+func jobTarget(target *big.Int) uint64 {
+	top := new(big.Int).Rsh(target, 192)
+	if !top.IsUint64() || top.Uint64() == math.MaxUint64 {
+		return math.MaxUint64
+	}
+	return top.Uint64() + 1
+}
+
+// encodeJobTarget returns the passed job target in the hex-encoded
+// little-endian form of jobs.
+func encodeJobTarget(target uint64) string {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], target)
+	return hex.EncodeToString(buf[:])
+}
+
+// hashTarget returns the most significant 64 bits of the passed hash, which
+// is a little-endian number.
+func hashTarget(hash *chainhash.Hash) uint64 {
+	return binary.LittleEndian.Uint64(hash[chainhash.HashSize-8:])
+}
+
+// sendResult answers the request with the passed ID with the passed result.
+func (s *JobServer) sendResult(session *jobSession, id interface{}, result interface{}) error {
+	return s.sendMessage(session, &JobServerMessage{
+		ID:      id,
+		JSONRPC: "2.0",
+		Result:  result,
+	})
+}
+
+// sendError answers the request with the passed ID with the passed error
+// message.
+func (s *JobServer) sendError(session *jobSession, id interface{}, message string) error {
+	return s.sendMessage(session, &JobServerMessage{
+		ID:      id,
+		JSONRPC: "2.0",
+		Error: &JobServerError{
+			Code:    jobErrorCode,
+			Message: message,
+		},
+	})
+}
+
+// sendNotification sends the passed notification to the miner.
+func (s *JobServer) sendNotification(session *jobSession, method string, params interface{}) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return s.sendMessage(session, &JobServerMessage{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  data,
+	})
+}
+
+// sendMessage sends the passed message to the miner.
+func (s *JobServer) sendMessage(session *jobSession, msg *JobServerMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	session.mtx.Lock()
+	defer session.mtx.Unlock()
+
+	if _, err := session.writer.Write(data); err != nil {
+		return err
+	}
+	return session.writer.Flush()
+}
@@ -0,0 +1,524 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package randomx
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/internal/convert"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// TestJobTarget ensures job targets include every hash below the block
+// target.
+func TestJobTarget(t *testing.T) {
+	tests := []struct {
+		bits    uint32
+		want    uint64
+		encoded string
+	}{
+		{0x180404cb, 1, "0100000000000000"},
+		{0x1b0404cb, 0x404cc, "cc04040000000000"},
+		{0x1d00ffff, 0xffff0001, "0100ffff00000000"},
+		{0x2100ffff, 0xffff000000000001, "010000000000ffff"},
+		{0x207fffff, 0x7fffff0000000001, "0100000000ffff7f"},
+		{0x1f00ffff, 0x0000ffff00000001, "01000000ffff0000"},
+	}
+
+	for _, test := range tests {
+		got := jobTarget(CompactToBig(test.bits))
+		if got != test.want {
+			t.Errorf("jobTarget(%08x): got %016x, want %016x", test.bits,
+				got, test.want)
+		}
+		if encoded := encodeJobTarget(got); encoded != test.encoded {
+			t.Errorf("encodeJobTarget(%016x): got %s, want %s", got,
+				encoded, test.encoded)
+		}
+	}
+
+	maxTarget := new(big.Int).Lsh(big.NewInt(1), 256)
+	if got := jobTarget(maxTarget); got != math.MaxUint64 {
+		t.Errorf("jobTarget(2^256): got %016x, want %016x", got,
+			uint64(math.MaxUint64))
+	}
+}
+
+// testJobClient is a miner connected to a job server under test.
+type testJobClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID int
+}
+
+// request sends a request with the passed method and params and returns the
+// response to it, skipping any notifications received in the meantime.
+func (c *testJobClient) request(method string, params interface{}) *JobServerMessage {
+	c.t.Helper()
+
+	c.nextID++
+	data, err := json.Marshal(params)
+	if err != nil {
+		c.t.Fatalf("failed to marshal params: %v", err)
+	}
+	req, err := json.Marshal(&JobServerMessage{
+		ID:      c.nextID,
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  data,
+	})
+	if err != nil {
+		c.t.Fatalf("failed to marshal request: %v", err)
+	}
+	if _, err := c.conn.Write(append(req, '\n')); err != nil {
+		c.t.Fatalf("failed to send %s: %v", method, err)
+	}
+
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+		line, err := c.reader.ReadBytes('\n')
+		if err != nil {
+			c.t.Fatalf("failed to read %s response: %v", method, err)
+		}
+		var msg JobServerMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			c.t.Fatalf("failed to parse %s response: %v", method, err)
+		}
+		if msg.Method != "" {
+			continue
+		}
+		return &msg
+	}
+}
+
+// result decodes the result of the passed response into the passed value and
+// fails the test when the request failed.
+func (c *testJobClient) result(msg *JobServerMessage, v interface{}) {
+	c.t.Helper()
+
+	if msg.Error != nil {
+		c.t.Fatalf("unexpected error: %s", msg.Error.Message)
+	}
+	data, err := json.Marshal(msg.Result)
+	if err != nil {
+		c.t.Fatalf("failed to marshal result: %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		c.t.Fatalf("failed to parse result: %v", err)
+	}
+}
+
+// testJobTemplate returns a block template with the passed previous block
+// and bits.
+func testJobTemplate(prevBlock chainhash.Hash, bits uint32) *BlockTemplate {
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{},
+			wire.MaxPrevOutIndex),
+		Sequence: wire.MaxTxInSequenceNum,
+	})
+	coinbase.AddTxOut(&wire.TxOut{Value: 5000000000})
+
+	block := wire.NewMsgBlock(&wire.BlockHeader{
+		Version:   0x20000000,
+		PrevBlock: prevBlock,
+		Timestamp: time.Unix(1735689600, 0),
+		Bits:      bits,
+	})
+	block.AddTransaction(coinbase)
+	return &BlockTemplate{Block: block, Height: 5}
+}
+
+// TestJobServer ensures miners can log in, receive jobs for the current block
+// template and submit solutions, and that solved blocks are submitted.
+func TestJobServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	// The blocks are easy enough for any hash to solve them.
+	template := testJobTemplate(chainhash.Hash{}, 0x2100ffff)
+	seeds := NewSeedManager(&SeedConfig{
+		GenesisHash: &testGenesisHash,
+		Rotation:    100,
+	})
+	defer seeds.Stop()
+	submitted := make(chan *btcutil.Block, 1)
+	s := NewJobServer(&JobServerConfig{
+		Listeners: []net.Listener{listener},
+		Seeds:     seeds,
+		BlockTemplateGenerator: func() (*BlockTemplate, error) {
+			return template, nil
+		},
+		SubmitBlock: func(block *btcutil.Block) error {
+			submitted <- block
+			return nil
+		},
+	})
+	if err := s.updateTemplate(); err != nil {
+		t.Fatalf("updateTemplate: unexpected error: %v", err)
+	}
+	s.Start()
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+	c := &testJobClient{t: t, conn: conn, reader: bufio.NewReader(conn)}
+
+	// Requests before logging in are rejected.
+	msg := c.request("getjob", &SessionParams{ID: "1"})
+	if msg.Error == nil || msg.Error.Message != errUnauthenticated.Error() {
+		t.Fatalf("getjob before login: got %+v, want %v", msg.Error,
+			errUnauthenticated)
+	}
+
+	msg = c.request("login", &LoginParams{
+		Login: "x",
+		Agent: "XMRig/6.21.0",
+		Algo:  []string{"cn/r", JobAlgorithm},
+	})
+	var login LoginResult
+	c.result(msg, &login)
+	if login.Status != "OK" || login.Job == nil {
+		t.Fatalf("login: got %+v", login)
+	}
+	job := login.Job
+
+	seed := SeedForHeight(0, &testGenesisHash)
+	if job.SeedHash != hex.EncodeToString(seed[:]) {
+		t.Errorf("job seed hash: got %s, want %x", job.SeedHash, seed)
+	}
+	if job.Algo != JobAlgorithm || job.Height != template.Height {
+		t.Errorf("job: got algo %s at height %d", job.Algo, job.Height)
+	}
+	if job.Target != encodeJobTarget(jobTarget(CompactToBig(0x2100ffff))) {
+		t.Errorf("job target: got %s", job.Target)
+	}
+	blob, err := hex.DecodeString(job.Blob)
+	if err != nil {
+		t.Fatalf("job blob: %v", err)
+	}
+	header, err := HeaderFromBlob(blob)
+	if err != nil {
+		t.Fatalf("job blob: %v", err)
+	}
+	if header.PrevBlock != template.Block.Header.PrevBlock ||
+		header.MerkleRoot == template.Block.Header.MerkleRoot {
+
+		t.Errorf("job blob: header %+v does not extend the template "+
+			"with its own coinbase", header)
+	}
+
+	// Jobs of the same session have different blobs.
+	msg = c.request("getjob", &SessionParams{ID: login.ID})
+	var next Job
+	c.result(msg, &next)
+	if next.JobID == job.JobID || next.Blob == job.Blob {
+		t.Errorf("getjob: job %s repeats the login job", next.JobID)
+	}
+
+	msg = c.request("keepalived", &SessionParams{ID: login.ID})
+	var status StatusResult
+	c.result(msg, &status)
+	if status.Status != "KEEPALIVED" {
+		t.Errorf("keepalived: got status %s", status.Status)
+	}
+
+	// Submit a solution for the login job.
+	header.Nonce = 0x04030201
+	hash, err := seeds.HashHeader(job.Height, header)
+	if err != nil {
+		t.Fatalf("HashHeader: unexpected error: %v", err)
+	}
+	submit := &SubmitParams{
+		ID:     login.ID,
+		JobID:  job.JobID,
+		Nonce:  "01020304",
+		Result: hex.EncodeToString(hash[:]),
+	}
+	msg = c.request("submit", submit)
+	c.result(msg, &status)
+	if status.Status != "OK" {
+		t.Errorf("submit: got status %s", status.Status)
+	}
+
+	select {
+	case block := <-submitted:
+		got := convert.ToShellMsgBlock(block.MsgBlock())
+		if got.Header.BlockHash() != header.BlockHash() {
+			t.Errorf("submitted block %v, want %v",
+				got.Header.BlockHash(), header.BlockHash())
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("solved block was not submitted")
+	}
+
+	tests := []struct {
+		name   string
+		params *SubmitParams
+		want   string
+	}{
+		{"duplicate", submit, "Duplicate share"},
+		{"unknown job", &SubmitParams{ID: login.ID, JobID: "ffff",
+			Nonce: "00000000"}, "Invalid job id"},
+		{"malformed nonce", &SubmitParams{ID: login.ID, JobID: job.JobID,
+			Nonce: "0102"}, "Malformed nonce"},
+		{"wrong session", &SubmitParams{ID: "ffff", JobID: job.JobID,
+			Nonce: "00000005"}, errUnauthenticated.Error()},
+		{"bad hash", &SubmitParams{ID: login.ID, JobID: job.JobID,
+			Nonce: "00000006", Result: "00"}, "Bad hash"},
+	}
+	for _, test := range tests {
+		msg := c.request("submit", test.params)
+		if msg.Error == nil || msg.Error.Message != test.want {
+			t.Errorf("submit %s: got error %+v, want %s", test.name,
+				msg.Error, test.want)
+		}
+	}
+	select {
+	case <-submitted:
+		t.Errorf("rejected solution was submitted")
+	default:
+	}
+
+	// Miners that can't mine RandomX are turned away.
+	msg = c.request("login", &LoginParams{Algo: []string{"cn/r"}})
+	if msg.Error == nil {
+		t.Errorf("login with unsupported algorithm: expected error")
+	}
+}
+
+// TestJobServerLowDifficulty ensures solutions above the job target are
+// rejected and new templates on another block replace the previous jobs.
+func TestJobServerLowDifficulty(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	// The job target is 1, which no practical hash meets.
+	prevBlock := chainhash.Hash{}
+	for i := range prevBlock {
+		prevBlock[i] = 0xff
+	}
+	var templateMtx sync.Mutex
+	template := testJobTemplate(prevBlock, 0x180404cb)
+	seeds := NewSeedManager(&SeedConfig{GenesisHash: &testGenesisHash})
+	defer seeds.Stop()
+	s := NewJobServer(&JobServerConfig{
+		Listeners: []net.Listener{listener},
+		Seeds:     seeds,
+		BlockTemplateGenerator: func() (*BlockTemplate, error) {
+			templateMtx.Lock()
+			defer templateMtx.Unlock()
+			return template, nil
+		},
+		SubmitBlock: func(block *btcutil.Block) error {
+			t.Errorf("unsolved block was submitted")
+			return nil
+		},
+	})
+	if err := s.updateTemplate(); err != nil {
+		t.Fatalf("updateTemplate: unexpected error: %v", err)
+	}
+	s.Start()
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+	c := &testJobClient{t: t, conn: conn, reader: bufio.NewReader(conn)}
+
+	var login LoginResult
+	c.result(c.request("login", &LoginParams{Login: "x"}), &login)
+
+	msg := c.request("submit", &SubmitParams{
+		ID:    login.ID,
+		JobID: login.Job.JobID,
+		Nonce: "00000000",
+	})
+	if msg.Error == nil || msg.Error.Message != "Low difficulty share" {
+		t.Errorf("submit: got error %+v, want low difficulty", msg.Error)
+	}
+
+	// A template on a new block drops the jobs of the previous one.
+	templateMtx.Lock()
+	template = testJobTemplate(chainhash.Hash{0x01}, 0x180404cb)
+	templateMtx.Unlock()
+	if err := s.updateTemplate(); err != nil {
+		t.Fatalf("updateTemplate: unexpected error: %v", err)
+	}
+	msg = c.request("submit", &SubmitParams{
+		ID:    login.ID,
+		JobID: login.Job.JobID,
+		Nonce: "00000001",
+	})
+	if msg.Error == nil || msg.Error.Message != "Invalid job id" {
+		t.Errorf("submit stale job: got error %+v, want invalid job",
+			msg.Error)
+	}
+}
+
+// expectJobServerClose ensures the job server closes the passed connection
+// after sending at most the passed error.
+func expectJobServerClose(t *testing.T, conn net.Conn, wantErr string) {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	reader := bufio.NewReader(conn)
+	if wantErr != "" {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("failed to read error %q: %v", wantErr, err)
+		}
+		var msg JobServerMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			t.Fatalf("failed to parse error: %v", err)
+		}
+		if msg.Error == nil || msg.Error.Message != wantErr {
+			t.Fatalf("got error %+v, want %s", msg.Error, wantErr)
+		}
+	}
+
+	_, err := reader.ReadByte()
+	if ne, ok := err.(net.Error); err == nil || ok && ne.Timeout() {
+		t.Fatalf("connection was not closed: %v", err)
+	}
+}
+
+// TestJobServerLimits ensures the job server bounds the number of miners and
+// the size of their requests, and only lets miners with the configured
+// password log in.
+func TestJobServerLimits(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	seeds := NewSeedManager(&SeedConfig{GenesisHash: &testGenesisHash})
+	defer seeds.Stop()
+	template := testJobTemplate(chainhash.Hash{}, 0x180404cb)
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	s := NewJobServer(&JobServerConfig{
+		Listeners:   []net.Listener{listener},
+		Seeds:       seeds,
+		MaxSessions: 1,
+		Password:    "secret",
+		Whitelist:   []*net.IPNet{loopback},
+		BlockTemplateGenerator: func() (*BlockTemplate, error) {
+			return template, nil
+		},
+		SubmitBlock: func(block *btcutil.Block) error {
+			return nil
+		},
+	})
+	s.Start()
+	defer s.Stop()
+
+	dial := func() net.Conn {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("failed to connect: %v", err)
+		}
+		return conn
+	}
+
+	// waitSessions waits for the server to forget closed sessions.
+	waitSessions := func(want int) {
+		for i := 0; ; i++ {
+			s.mtx.Lock()
+			n := len(s.sessions)
+			s.mtx.Unlock()
+			if n == want {
+				return
+			}
+			if i == 1000 {
+				t.Fatalf("got %d sessions, want %d", n, want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// Miners beyond the maximum number of sessions are disconnected.
+	first := dial()
+	defer first.Close()
+	waitSessions(1)
+	second := dial()
+	defer second.Close()
+	expectJobServerClose(t, second, "")
+
+	// Requests larger than the maximum message size are not buffered.
+	// Filling the buffer exactly leaves no unread data that would reset
+	// the connection before the error is read.
+	request := make([]byte, maxJobMessageSize)
+	for i := range request {
+		request[i] = ' '
+	}
+	if _, err := first.Write(request); err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	expectJobServerClose(t, first, "Request too large")
+	waitSessions(0)
+
+	// Miners can't log in without the password.
+	conn := dial()
+	defer conn.Close()
+	c := &testJobClient{t: t, conn: conn, reader: bufio.NewReader(conn)}
+	msg := c.request("login", &LoginParams{Login: "x", Pass: "guess"})
+	if msg.Error == nil || msg.Error.Message != errInvalidPassword.Error() {
+		t.Fatalf("login with wrong password: got %+v, want %v",
+			msg.Error, errInvalidPassword)
+	}
+	expectJobServerClose(t, conn, "")
+	waitSessions(0)
+
+	conn = dial()
+	defer conn.Close()
+	c = &testJobClient{t: t, conn: conn, reader: bufio.NewReader(conn)}
+	var login LoginResult
+	c.result(c.request("login", &LoginParams{Login: "x", Pass: "secret"}),
+		&login)
+	if login.Status != "OK" {
+		t.Fatalf("login: got status %s", login.Status)
+	}
+
+	// Only miners connecting from whitelisted networks are accepted.
+	tests := []struct {
+		addr net.Addr
+		want bool
+	}{
+		{&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 1}, true},
+		{&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1}, false},
+		{&net.TCPAddr{IP: net.ParseIP("::1"), Port: 1}, false},
+	}
+	for _, test := range tests {
+		if got := s.isWhitelisted(test.addr); got != test.want {
+			t.Errorf("isWhitelisted(%v): got %v, want %v", test.addr,
+				got, test.want)
+		}
+	}
+	s.cfg.Whitelist = nil
+	if !s.isWhitelisted(tests[1].addr) {
+		t.Errorf("isWhitelisted: miners are not accepted from anywhere " +
+			"without a whitelist")
+	}
+}
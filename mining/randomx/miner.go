@@ -6,8 +6,7 @@ package randomx
 
 import (
 	"bytes"
-	"crypto/sha256"
-	"encoding/binary"
+	"fmt"
 	"sync"
 	"time"
 
@@ -83,11 +82,6 @@ type MobileMiner interface {
 // proof-of-work in a concurrent manner with CPU cores. It also supports
 // MobileX algorithm integration for dual-algorithm mining.
 type RandomXMiner struct {
-	cache            *Cache
-	dataset          *Dataset
-	vm               *VM
-	seedHeight       int32
-	seedHash         chainhash.Hash
 	memory           int64 // Memory requirement in bytes
 	started          bool
 	shutdown         chan struct{}
@@ -101,13 +95,21 @@ type RandomXMiner struct {
 	mobileMiner    MobileMiner
 	algorithm      MiningAlgorithm
 	mobileXEnabled bool
+
+	// seeds provides the RandomX epochs the workers mine with.  It is
+	// stopped along with the miner when the miner created it.
+	seeds     *SeedManager
+	ownsSeeds bool
+
+	// prepareMobileXBlock turns block templates into MobileX blocks.  It
+	// is set from the config the miner is started with.
+	prepareMobileXBlock func(*wire.MsgBlock) error
 }
 
 // NewRandomXMiner returns a new instance of a RandomX miner.
 func NewRandomXMiner(memoryMB int64) *RandomXMiner {
 	return &RandomXMiner{
 		memory:           memoryMB * 1024 * 1024, // Convert MB to bytes
-		seedHeight:       -1,                     // Initialize with invalid height
 		updateHashes:     make(chan uint64),
 		speedMonitorQuit: make(chan struct{}),
 		quit:             make(chan struct{}),
@@ -120,7 +122,6 @@ func NewRandomXMiner(memoryMB int64) *RandomXMiner {
 func NewRandomXMinerWithMobile(memoryMB int64, mobileMiner MobileMiner, algorithm MiningAlgorithm) *RandomXMiner {
 	miner := &RandomXMiner{
 		memory:           memoryMB * 1024 * 1024, // Convert MB to bytes
-		seedHeight:       -1,                     // Initialize with invalid height
 		updateHashes:     make(chan uint64),
 		speedMonitorQuit: make(chan struct{}),
 		quit:             make(chan struct{}),
@@ -169,99 +170,56 @@ out:
 	log.Tracef("RandomX speed monitor done")
 }
 
-// initRandomX initializes the RandomX cache, dataset, and VM for the given seed.
-func (m *RandomXMiner) initRandomX(seed []byte) error {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+// workerVM is the RandomX VM a mining worker hashes with along with the epoch
+// it was created for.  RandomX VMs are not safe for concurrent use, so each
+// worker has its own, while the epoch is shared.  In fast mode the VM runs on
+// the dataset of the NUMA node the worker is assigned to.
+type workerVM struct {
+	node  int
+	epoch *Epoch
+	vm    *VM
+}
 
-	log.Infof("Initializing RandomX with seed %x", seed)
+// updateSeed switches the passed worker VM to the epoch of the block at the
+// passed height if needed.  The seed manager computes the next epoch ahead of
+// the seed rotation, so switching only waits when the epoch is not ready yet.
+func (m *RandomXMiner) updateSeed(w *workerVM, height int32) error {
+	m.seeds.Update(height)
 
-	// Initialize RandomX cache with the seed
-	cache, err := NewCache(seed)
-	if err != nil {
-		return err
+	seedHeight := m.seeds.seedHeight(height)
+	if w.epoch != nil && w.epoch.SeedHeight == seedHeight {
+		return nil // No update needed
 	}
 
-	// Initialize dataset from cache
-	dataset, err := NewDataset(cache)
+	epoch, err := m.seeds.Acquire(height)
 	if err != nil {
-		cache.Close()
 		return err
 	}
-
-	// Initialize VM
-	vm, err := NewVM(cache, dataset)
+	vm, err := epoch.NewNodeVM(w.node)
 	if err != nil {
-		dataset.Close()
-		cache.Close()
+		m.seeds.Release(epoch)
 		return err
 	}
 
-	// Clean up old instances
-	if m.vm != nil {
-		m.vm.Close()
-	}
-	if m.dataset != nil {
-		m.dataset.Close()
-	}
-	if m.cache != nil {
-		m.cache.Close()
-	}
+	m.releaseVM(w)
+	w.epoch = epoch
+	w.vm = vm
 
-	// Store new instances
-	m.cache = cache
-	m.dataset = dataset
-	m.vm = vm
-
-	log.Infof("RandomX initialization complete")
+	log.Infof("RandomX seed updated for height %d (seed height: %d)",
+		height, seedHeight)
 	return nil
 }
 
-// getSeedForHeight calculates the RandomX seed for the given block height.
-// The seed changes every RandomXSeedRotation blocks.
-func (m *RandomXMiner) getSeedForHeight(height int32, rotation int32, genesisHash *chainhash.Hash) []byte {
-	seedHeight := (height / rotation) * rotation
-
-	// For genesis or early blocks, use genesis hash as seed
-	if seedHeight <= 0 {
-		return genesisHash[:]
+// releaseVM closes the VM of the passed worker and releases its epoch.
+func (m *RandomXMiner) releaseVM(w *workerVM) {
+	if w.vm != nil {
+		w.vm.Close()
+		w.vm = nil
 	}
-
-	// Create seed based on height
-	// In a real implementation, this would be the block hash at seedHeight
-	// For now, we'll use a deterministic seed based on height
-	seed := make([]byte, 32)
-	binary.LittleEndian.PutUint32(seed[0:4], uint32(seedHeight))
-	copy(seed[4:], genesisHash[:28])
-
-	hasher := sha256.New()
-	hasher.Write(seed)
-	return hasher.Sum(nil)
-}
-
-// updateSeed updates the RandomX seed if needed based on block height.
-func (m *RandomXMiner) updateSeed(height int32, rotation int32, genesisHash *chainhash.Hash) error {
-	newSeedHeight := (height / rotation) * rotation
-
-	// Check if we need to update the seed
-	if newSeedHeight == m.seedHeight {
-		return nil // No update needed
+	if w.epoch != nil {
+		m.seeds.Release(w.epoch)
+		w.epoch = nil
 	}
-
-	seed := m.getSeedForHeight(height, rotation, genesisHash)
-	seedHash := sha256.Sum256(seed)
-
-	// Update if this is a new seed
-	if m.seedHeight != newSeedHeight || seedHash != m.seedHash {
-		if err := m.initRandomX(seed); err != nil {
-			return err
-		}
-		m.seedHeight = newSeedHeight
-		m.seedHash = seedHash
-		log.Infof("RandomX seed updated for height %d (seed height: %d)", height, newSeedHeight)
-	}
-
-	return nil
 }
 
 // solveBlock attempts to find a nonce which makes the passed block hash to
@@ -271,14 +229,14 @@ func (m *RandomXMiner) updateSeed(height int32, rotation int32, genesisHash *cha
 //
 // This method now supports dual-algorithm mining with MobileX integration.
 func (m *RandomXMiner) solveBlock(msgBlock *wire.MsgBlock, blockHeight int32,
-	ticker *time.Ticker, quit chan struct{}, params *RandomXParams) bool {
+	ticker *time.Ticker, quit chan struct{}, vm *VM) bool {
 
 	// Determine which algorithm to use
 	switch m.algorithm {
 	case AlgorithmMobileX:
 		// Use MobileX exclusively
 		if m.mobileMiner != nil {
-			found, err := m.mobileMiner.SolveBlock(msgBlock, blockHeight, ticker, quit)
+			found, err := m.solveBlockMobileX(msgBlock, blockHeight, ticker, quit)
 			if err != nil {
 				log.Errorf("MobileX mining error: %v", err)
 				return false
@@ -290,11 +248,11 @@ func (m *RandomXMiner) solveBlock(msgBlock *wire.MsgBlock, blockHeight int32,
 
 	case AlgorithmRandomX:
 		// Use RandomX exclusively
-		return m.solveBlockRandomX(msgBlock, blockHeight, ticker, quit, params)
+		return m.solveBlockRandomX(msgBlock, blockHeight, ticker, quit, vm)
 
 	case AlgorithmDual:
 		// Dual-algorithm mining: try both RandomX and MobileX
-		return m.solveBlockDual(msgBlock, blockHeight, ticker, quit, params)
+		return m.solveBlockDual(msgBlock, blockHeight, ticker, quit, vm)
 
 	default:
 		log.Errorf("Unknown mining algorithm: %v", m.algorithm)
@@ -304,7 +262,7 @@ func (m *RandomXMiner) solveBlock(msgBlock *wire.MsgBlock, blockHeight int32,
 
 // solveBlockRandomX performs standard RandomX mining
 func (m *RandomXMiner) solveBlockRandomX(msgBlock *wire.MsgBlock, blockHeight int32,
-	ticker *time.Ticker, quit chan struct{}, params *RandomXParams) bool {
+	ticker *time.Ticker, quit chan struct{}, vm *VM) bool {
 
 	// Get a local copy of the header so we can update the nonce while
 	// checking if the solution is under the target.
@@ -351,7 +309,7 @@ func (m *RandomXMiner) solveBlockRandomX(msgBlock *wire.MsgBlock, blockHeight in
 
 			// Update the nonce and hash the block header.
 			header.Nonce = i
-			hash := m.hashBlockHeader(&header, params)
+			hash := hashBlockHeader(vm, &header)
 			hashesCompleted++
 
 			// The block is solved when the new block hash is less
@@ -368,86 +326,103 @@ func (m *RandomXMiner) solveBlockRandomX(msgBlock *wire.MsgBlock, blockHeight in
 	return false
 }
 
-// solveBlockDual performs dual-algorithm mining (RandomX + MobileX)
+// solveBlockDual performs dual-algorithm mining (RandomX + MobileX).  Each
+// algorithm works on its own copy of the block since the algorithms retarget
+// independently, and the passed block is replaced by the first solution found.
 func (m *RandomXMiner) solveBlockDual(msgBlock *wire.MsgBlock, blockHeight int32,
-	ticker *time.Ticker, quit chan struct{}, params *RandomXParams) bool {
+	ticker *time.Ticker, quit chan struct{}, vm *VM) bool {
 
 	if m.mobileMiner == nil {
 		log.Warnf("Dual mining requested but no mobile miner available, using RandomX only")
-		return m.solveBlockRandomX(msgBlock, blockHeight, ticker, quit, params)
+		return m.solveBlockRandomX(msgBlock, blockHeight, ticker, quit, vm)
 	}
 
-	// Create channels for coordination between RandomX and MobileX workers
-	randomXQuit := make(chan struct{})
-	mobileXQuit := make(chan struct{})
-	solutionFound := make(chan bool, 2)
+	// solution is the result of a single algorithm's attempt to solve its
+	// copy of the block.
+	type solution struct {
+		block *wire.MsgBlock
+		found bool
+	}
+
+	// Both workers stop as soon as either of them finds a solution or the
+	// caller quits.
+	workersQuit := make(chan struct{})
+	defer close(workersQuit)
+	solutions := make(chan solution, 2)
 
 	// Start RandomX mining in goroutine
 	go func() {
+		randomXBlock := msgBlock.Copy()
 		defer func() {
 			if r := recover(); r != nil {
 				log.Errorf("RandomX mining panic: %v", r)
-				solutionFound <- false
+				solutions <- solution{}
 			}
 		}()
 
-		found := m.solveBlockRandomX(msgBlock, blockHeight, ticker, randomXQuit, params)
-		if found {
-			close(mobileXQuit) // Stop MobileX if RandomX found solution
-		}
-		solutionFound <- found
+		found := m.solveBlockRandomX(randomXBlock, blockHeight, ticker,
+			workersQuit, vm)
+		solutions <- solution{block: randomXBlock, found: found}
 	}()
 
 	// Start MobileX mining in goroutine
 	go func() {
+		mobileXBlock := msgBlock.Copy()
 		defer func() {
 			if r := recover(); r != nil {
 				log.Errorf("MobileX mining panic: %v", r)
-				solutionFound <- false
+				solutions <- solution{}
 			}
 		}()
 
-		found, err := m.mobileMiner.SolveBlock(msgBlock, blockHeight, ticker, mobileXQuit)
+		found, err := m.solveBlockMobileX(mobileXBlock, blockHeight, ticker,
+			workersQuit)
 		if err != nil {
 			log.Errorf("MobileX mining error: %v", err)
-			solutionFound <- false
-			return
 		}
-		if found {
-			close(randomXQuit) // Stop RandomX if MobileX found solution
-		}
-		solutionFound <- found
+		solutions <- solution{block: mobileXBlock, found: found}
 	}()
 
 	// Wait for either algorithm to find a solution or quit signal
-	for {
+	for pending := 2; pending > 0; {
 		select {
 		case <-quit:
-			close(randomXQuit)
-			close(mobileXQuit)
 			return false
 
-		case found := <-solutionFound:
-			if found {
-				// Solution found by one of the algorithms
-				close(randomXQuit)
-				close(mobileXQuit)
+		case result := <-solutions:
+			pending--
+			if result.found {
+				*msgBlock = *result.block
 				return true
 			}
 			// If we get false from one algorithm, continue waiting for the other
-			// Both algorithms will send to solutionFound, so we need to handle both
+		}
+	}
 
-		case <-ticker.C:
-			// Update metrics periodically
-			// Let each algorithm handle its own metrics
+	return false
+}
+
+// solveBlockMobileX attempts to solve the passed block with the mobile miner.
+// Once MobileX is active its blocks encode the algorithm in their version and
+// use the MobileX difficulty, so the block is prepared accordingly before
+// mining when the miner was configured to do so.
+func (m *RandomXMiner) solveBlockMobileX(msgBlock *wire.MsgBlock, blockHeight int32,
+	ticker *time.Ticker, quit chan struct{}) (bool, error) {
+
+	if m.prepareMobileXBlock != nil {
+		if err := m.prepareMobileXBlock(msgBlock); err != nil {
+			return false, fmt.Errorf("failed to prepare MobileX "+
+				"block: %w", err)
 		}
 	}
+
+	return m.mobileMiner.SolveBlock(msgBlock, blockHeight, ticker, quit)
 }
 
-// hashBlockHeader hashes a block header using RandomX.
-func (m *RandomXMiner) hashBlockHeader(header *wire.BlockHeader, params *RandomXParams) chainhash.Hash {
+// hashBlockHeader hashes a block header using the passed RandomX VM.
+func hashBlockHeader(vm *VM, header *wire.BlockHeader) chainhash.Hash {
 	// Ensure RandomX is initialized
-	if m.vm == nil {
+	if vm == nil {
 		// This should not happen in normal operation
 		log.Errorf("RandomX VM not initialized")
 		return chainhash.Hash{}
@@ -463,7 +438,7 @@ func (m *RandomXMiner) hashBlockHeader(header *wire.BlockHeader, params *RandomX
 	headerBytes := buf.Bytes()
 
 	// Compute RandomX hash
-	hash := m.vm.CalcHash(headerBytes)
+	hash := vm.CalcHash(headerBytes)
 
 	var result chainhash.Hash
 	copy(result[:], hash)
@@ -474,9 +449,22 @@ func (m *RandomXMiner) hashBlockHeader(header *wire.BlockHeader, params *RandomX
 // It is self contained in that it creates a block template and attempts to solve
 // it by finding a nonce which results in a block hash less than the target
 // difficulty.  Once a valid solution is found, it is submitted.
-func (m *RandomXMiner) generateBlocks(quit chan struct{}, cfg *Config) {
+//
+// Workers are assigned round-robin to the NUMA nodes the datasets are computed
+// on.  On machines with more than one node, each worker is pinned to the CPUs
+// of its node so it reads the dataset from local memory.
+func (m *RandomXMiner) generateBlocks(quit chan struct{}, cfg *Config, id int) {
 	log.Tracef("Starting generate blocks worker")
 
+	nodes := m.seeds.cfg.NUMANodes
+	var worker workerVM
+	if len(nodes) > 0 {
+		worker.node = id % len(nodes)
+	}
+	if len(nodes) > 1 {
+		lockToCPUs(nodes[worker.node].CPUs)
+	}
+
 	// Start a ticker which is used to signal checks for stale work and
 	// updates to the speed monitor.
 	ticker := time.NewTicker(time.Second * hashUpdateSecs)
@@ -523,7 +511,7 @@ out:
 
 		// Update RandomX seed if necessary
 		nextHeight := bestHeight + 1
-		err = m.updateSeed(nextHeight, cfg.RandomXSeedRotation, cfg.GenesisHash)
+		err = m.updateSeed(&worker, nextHeight)
 		if err != nil {
 			log.Errorf("Failed to update RandomX seed: %v", err)
 			time.Sleep(time.Second)
@@ -543,7 +531,7 @@ out:
 		// a new block template can be generated.  When the return is
 		// true a solution was found, so submit it to the network.
 		// The submitted block is not guaranteed to be on the main chain.
-		if m.solveBlock(template.Block, template.Height, ticker, quit, &cfg.RandomXParams) {
+		if m.solveBlock(template.Block, template.Height, ticker, quit, worker.vm) {
 			block := convert.NewShellBlock(template.Block)
 
 			// Submit the solved block.
@@ -557,6 +545,7 @@ out:
 		}
 	}
 
+	m.releaseVM(&worker)
 	m.wg.Done()
 	log.Tracef("Generate blocks worker done")
 }
@@ -576,7 +565,7 @@ func (m *RandomXMiner) mineWorkerController(cfg *Config) {
 			runningWorkers = append(runningWorkers, quit)
 
 			m.wg.Add(1)
-			go m.generateBlocks(quit, cfg)
+			go m.generateBlocks(quit, cfg, len(runningWorkers)-1)
 		}
 	}
 
@@ -652,6 +641,27 @@ func (m *RandomXMiner) Start(cfg *Config) {
 		}
 	}
 
+	m.prepareMobileXBlock = cfg.PrepareMobileXBlock
+	m.seeds = cfg.SeedManager
+	m.ownsSeeds = m.seeds == nil
+	if m.ownsSeeds {
+		// Each dataset is computed by every CPU of its node.  Without
+		// NUMA awareness a single dataset is computed by every CPU of
+		// the machine.
+		nodes := DetectNUMANodes("")
+		if cfg.Options.NoNUMA {
+			nodes = allCPUs()
+		}
+		m.seeds = NewSeedManager(&SeedConfig{
+			GenesisHash: cfg.GenesisHash,
+			Rotation:    cfg.RandomXSeedRotation,
+			FullMemory:  true,
+			Options:     cfg.Options,
+			NUMANodes:   nodes,
+		})
+	}
+	log.Infof("RandomX flags: %v, NUMA nodes: %d",
+		DetectFlags(m.seeds.cfg.Options), len(m.seeds.cfg.NUMANodes))
 	m.quit = make(chan struct{})
 	m.speedMonitorQuit = make(chan struct{})
 	m.wg.Add(2)
@@ -688,6 +698,9 @@ func (m *RandomXMiner) Stop() {
 
 	close(m.quit)
 	m.wg.Wait()
+	if m.ownsSeeds {
+		m.seeds.Stop()
+	}
 	m.started = false
 	log.Infof("RandomX miner stopped")
 }
@@ -0,0 +1,150 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package randomx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultSysfsRoot is the mount point of sysfs on Linux.
+const defaultSysfsRoot = "/sys"
+
+// NUMANode is a NUMA node of the machine along with the CPUs attached to it.
+// Memory is allocated on the node of the thread that first touches it, so a
+// dataset initialized by threads pinned to a node is local to that node.
+type NUMANode struct {
+	ID   int
+	CPUs []int
+}
+
+// parseCPUList parses a list of CPUs in the format the Linux kernel uses for
+// cpulist attributes, such as "0-3,8,10-11".
+func parseCPUList(list string) ([]int, error) {
+	var cpus []int
+	list = strings.TrimSpace(list)
+	if list == "" {
+		return cpus, nil
+	}
+
+	for _, field := range strings.Split(list, ",") {
+		first, last, isRange := strings.Cut(field, "-")
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPU list %q: %w", list, err)
+		}
+		end := start
+		if isRange {
+			end, err = strconv.Atoi(last)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CPU list %q: %w",
+					list, err)
+			}
+		}
+		if end < start {
+			return nil, fmt.Errorf("invalid CPU list %q: range %s "+
+				"is descending", list, field)
+		}
+		for cpu := start; cpu <= end; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+// allCPUs returns a single node holding every CPU of the machine, which is
+// used when the NUMA topology is unknown.
+func allCPUs() []NUMANode {
+	cpus := make([]int, runtime.NumCPU())
+	for i := range cpus {
+		cpus[i] = i
+	}
+	return []NUMANode{{ID: 0, CPUs: cpus}}
+}
+
+// DetectNUMANodes returns the NUMA nodes with CPUs the Linux kernel exposes
+// under devices/system/node of the sysfs tree mounted at the passed root, by
+// ascending ID.  The default sysfs root is used when root is empty.  Machines
+// whose topology can't be read, including those on other platforms, are
+// reported as a single node holding every CPU.
+func DetectNUMANodes(root string) []NUMANode {
+	if root == "" {
+		root = defaultSysfsRoot
+	}
+
+	pattern := filepath.Join(root, "devices", "system", "node", "node*",
+		"cpulist")
+	paths, err := filepath.Glob(pattern)
+	if err != nil || len(paths) == 0 {
+		return allCPUs()
+	}
+
+	var nodes []NUMANode
+	for _, path := range paths {
+		dir := filepath.Base(filepath.Dir(path))
+		id, err := strconv.Atoi(strings.TrimPrefix(dir, "node"))
+		if err != nil {
+			continue
+		}
+		list, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		cpus, err := parseCPUList(string(list))
+		if err != nil || len(cpus) == 0 {
+			// Nodes without CPUs only provide memory.
+			continue
+		}
+		nodes = append(nodes, NUMANode{ID: id, CPUs: cpus})
+	}
+	if len(nodes) == 0 {
+		return allCPUs()
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID < nodes[j].ID
+	})
+	return nodes
+}
+
+// lockToCPUs locks the calling goroutine to its OS thread and pins the thread
+// to the passed CPUs.  The goroutine must not unlock the thread, so the thread
+// exits along with it instead of being reused with the pinned affinity.
+// Nothing is pinned when cpus is empty.  A failure to pin, such as when the
+// process is confined to other CPUs, only costs memory locality, so it is
+// logged rather than returned.
+func lockToCPUs(cpus []int) {
+	if len(cpus) == 0 {
+		return
+	}
+
+	runtime.LockOSThread()
+	if err := pinThread(cpus); err != nil {
+		log.Debugf("Unable to pin RandomX thread to CPUs %v: %v", cpus,
+			err)
+	}
+}
+
+// runPinned runs the passed function once for each of n threads pinned to the
+// passed CPUs and waits for them to finish.  The functions are passed the
+// index of their thread.
+func runPinned(cpus []int, n int, fn func(thread int)) {
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(thread int) {
+			defer wg.Done()
+			lockToCPUs(cpus)
+			fn(thread)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package randomx
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// writeNUMANodes writes the passed CPU lists, keyed by node directory name, to
+// a fake sysfs tree and returns its root.
+func writeNUMANodes(t *testing.T, cpulists map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	for node, list := range cpulists {
+		dir := filepath.Join(root, "devices", "system", "node", node)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("unable to create %s: %v", dir, err)
+		}
+		path := filepath.Join(dir, "cpulist")
+		if err := os.WriteFile(path, []byte(list+"\n"), 0o644); err != nil {
+			t.Fatalf("unable to write %s: %v", path, err)
+		}
+	}
+	return root
+}
+
+// TestParseCPUList ensures CPU lists in the kernel format are parsed.
+func TestParseCPUList(t *testing.T) {
+	tests := []struct {
+		list  string
+		want  []int
+		valid bool
+	}{
+		{"0", []int{0}, true},
+		{"0-3", []int{0, 1, 2, 3}, true},
+		{"0-1,8,10-11\n", []int{0, 1, 8, 10, 11}, true},
+		{"", nil, true},
+		{"3-1", nil, false},
+		{"a-b", nil, false},
+		{"0,", nil, false},
+	}
+
+	for _, test := range tests {
+		got, err := parseCPUList(test.list)
+		if (err == nil) != test.valid {
+			t.Errorf("parseCPUList(%q): unexpected error: %v",
+				test.list, err)
+			continue
+		}
+		if test.valid && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseCPUList(%q): got %v, want %v", test.list,
+				got, test.want)
+		}
+	}
+}
+
+// TestDetectNUMANodes ensures the nodes with CPUs are read from sysfs and
+// that machines without a readable topology are reported as a single node.
+func TestDetectNUMANodes(t *testing.T) {
+	root := writeNUMANodes(t, map[string]string{
+		"node1": "8-15,24-31",
+		"node0": "0-7,16-23",
+		"node2": "", // Memory only
+	})
+	nodes := DetectNUMANodes(root)
+	if len(nodes) != 2 || nodes[0].ID != 0 || nodes[1].ID != 1 {
+		t.Fatalf("DetectNUMANodes: got %v, want nodes 0 and 1", nodes)
+	}
+	if len(nodes[0].CPUs) != 16 || nodes[1].CPUs[8] != 24 {
+		t.Errorf("DetectNUMANodes: got CPUs %v and %v", nodes[0].CPUs,
+			nodes[1].CPUs)
+	}
+
+	nodes = DetectNUMANodes(t.TempDir())
+	if len(nodes) != 1 || len(nodes[0].CPUs) != runtime.NumCPU() {
+		t.Errorf("DetectNUMANodes: got %v without a topology, want a "+
+			"single node with every CPU", nodes)
+	}
+}
@@ -42,12 +42,25 @@ type RealCache struct {
 
 // NewCache creates a new RandomX cache with the given seed
 func NewCache(seed []byte) (*Cache, error) {
+	return NewCacheWithFlags(seed, GetFlags())
+}
+
+// NewCacheWithFlags creates a new RandomX cache with the given seed and flags.
+// When the cache can't be allocated on large pages or with JIT support, it is
+// allocated without them.  Flags returns the flags that were used.
+func NewCacheWithFlags(seed []byte, flags Flags) (*Cache, error) {
 	if len(seed) == 0 {
 		return nil, errors.New("seed cannot be empty")
 	}
 
-	flags := GetFlags()
-	cachePtr := C.randomx_alloc_cache(C.randomx_flags(flags))
+	var cachePtr *C.randomx_cache
+	for _, attempt := range fallbackFlags(flags, FlagLargePages|FlagJIT) {
+		cachePtr = C.randomx_alloc_cache(C.randomx_flags(attempt))
+		if cachePtr != nil {
+			flags = attempt
+			break
+		}
+	}
 	if cachePtr == nil {
 		return nil, errors.New("failed to allocate RandomX cache")
 	}
@@ -66,7 +79,7 @@ func NewCache(seed []byte) (*Cache, error) {
 	runtime.SetFinalizer(realCache, (*RealCache).finalize)
 
 	// Return wrapped cache
-	return &Cache{impl: realCache}, nil
+	return &Cache{impl: realCache, flags: flags}, nil
 }
 
 func (c *RealCache) finalize() {
@@ -84,21 +97,48 @@ type RealDataset struct {
 
 // NewDataset creates a new RandomX dataset from a cache
 func NewDataset(cache *Cache) (*Dataset, error) {
+	return NewDatasetWithFlags(cache, GetFlags(), nil)
+}
+
+// NewDatasetWithFlags creates a new RandomX dataset from a cache with the
+// given flags.  The dataset is initialized by one thread per passed CPU, each
+// pinned to the passed CPUs, so its memory is allocated on their NUMA node.  A
+// single unpinned thread initializes it when no CPUs are passed.  When the
+// dataset can't be allocated on large pages, it is allocated on ordinary
+// pages.  Flags returns the flags that were used.
+func NewDatasetWithFlags(cache *Cache, flags Flags, cpus []int) (*Dataset, error) {
 	if cache == nil || cache.impl == nil {
 		return nil, errors.New("cache cannot be nil")
 	}
 
 	realCache := cache.impl.(*RealCache)
-	flags := GetFlags() | FlagFullMem
+	flags |= FlagFullMem
 
-	datasetPtr := C.randomx_alloc_dataset(C.randomx_flags(flags))
+	var datasetPtr *C.randomx_dataset
+	for _, attempt := range fallbackFlags(flags, FlagLargePages) {
+		datasetPtr = C.randomx_alloc_dataset(C.randomx_flags(attempt))
+		if datasetPtr != nil {
+			flags = attempt
+			break
+		}
+	}
 	if datasetPtr == nil {
 		return nil, errors.New("failed to allocate RandomX dataset")
 	}
 
-	// Initialize dataset (this is memory-intensive and takes time)
-	itemCount := C.randomx_dataset_item_count()
-	C.randomx_init_dataset(datasetPtr, realCache.ptr, 0, itemCount)
+	// Initialize dataset (this is memory-intensive and takes time).  The
+	// items are split evenly between the threads.
+	itemCount := uint64(C.randomx_dataset_item_count())
+	threads := len(cpus)
+	if threads == 0 {
+		threads = 1
+	}
+	runPinned(cpus, threads, func(thread int) {
+		start := itemCount * uint64(thread) / uint64(threads)
+		end := itemCount * uint64(thread+1) / uint64(threads)
+		C.randomx_init_dataset(datasetPtr, realCache.ptr,
+			C.ulong(start), C.ulong(end-start))
+	})
 
 	realDataset := &RealDataset{
 		ptr: datasetPtr,
@@ -106,7 +146,7 @@ func NewDataset(cache *Cache) (*Dataset, error) {
 
 	runtime.SetFinalizer(realDataset, (*RealDataset).finalize)
 
-	return &Dataset{impl: realDataset}, nil
+	return &Dataset{impl: realDataset, flags: flags}, nil
 }
 
 func (d *RealDataset) finalize() {
@@ -126,6 +166,14 @@ type RealVM struct {
 
 // NewVM creates a new RandomX VM with the given cache and dataset
 func NewVM(cache *Cache, dataset *Dataset) (*VM, error) {
+	return NewVMWithFlags(cache, dataset, GetFlags())
+}
+
+// NewVMWithFlags creates a new RandomX VM with the given cache, dataset and
+// flags.  The VM runs in fast mode when a dataset is passed.  When the VM
+// can't be created with large pages or JIT support, it is created without
+// them.  Flags returns the flags that were used.
+func NewVMWithFlags(cache *Cache, dataset *Dataset, flags Flags) (*VM, error) {
 	if cache == nil || cache.impl == nil {
 		return nil, errors.New("cache cannot be nil")
 	}
@@ -139,12 +187,19 @@ func NewVM(cache *Cache, dataset *Dataset) (*VM, error) {
 		datasetPtr = realDataset.ptr
 	}
 
-	flags := GetFlags()
 	if datasetPtr != nil {
 		flags |= FlagFullMem
 	}
 
-	vmPtr := C.randomx_create_vm(C.randomx_flags(flags), realCache.ptr, datasetPtr)
+	var vmPtr *C.randomx_vm
+	for _, attempt := range fallbackFlags(flags, FlagLargePages|FlagJIT) {
+		vmPtr = C.randomx_create_vm(C.randomx_flags(attempt),
+			realCache.ptr, datasetPtr)
+		if vmPtr != nil {
+			flags = attempt
+			break
+		}
+	}
 	if vmPtr == nil {
 		return nil, errors.New("failed to create RandomX VM")
 	}
@@ -157,7 +212,7 @@ func NewVM(cache *Cache, dataset *Dataset) (*VM, error) {
 
 	runtime.SetFinalizer(realVM, (*RealVM).finalize)
 
-	return &VM{impl: realVM}, nil
+	return &VM{impl: realVM, flags: flags}, nil
 }
 
 // CalcHash calculates the RandomX hash of the input
@@ -198,15 +253,33 @@ func GetFlags() Flags {
 
 // Wrapper types to maintain API compatibility
 type Cache struct {
-	impl interface{}
+	impl  interface{}
+	flags Flags
 }
 
 type Dataset struct {
-	impl interface{}
+	impl  interface{}
+	flags Flags
 }
 
 type VM struct {
-	impl interface{}
+	impl  interface{}
+	flags Flags
+}
+
+// Flags returns the flags the cache was allocated with.
+func (c *Cache) Flags() Flags {
+	return c.flags
+}
+
+// Flags returns the flags the dataset was allocated with.
+func (d *Dataset) Flags() Flags {
+	return d.flags
+}
+
+// Flags returns the flags the VM was created with.
+func (vm *VM) Flags() Flags {
+	return vm.flags
 }
 
 func (c *Cache) Close() {
@@ -7,18 +7,31 @@
 
 package randomx
 
+import "crypto/sha256"
+
 // randomx package stub - This is a temporary implementation until we integrate
 // the actual RandomX library. The real implementation would use CGO bindings
 // to the RandomX C++ library.
 
 // Cache represents the RandomX cache
 type Cache struct {
-	seed []byte
+	seed  []byte
+	flags Flags
 }
 
 // NewCache creates a new RandomX cache with the given seed
 func NewCache(seed []byte) (*Cache, error) {
-	return &Cache{seed: seed}, nil
+	return NewCacheWithFlags(seed, GetFlags())
+}
+
+// NewCacheWithFlags creates a new RandomX cache with the given seed and flags
+func NewCacheWithFlags(seed []byte, flags Flags) (*Cache, error) {
+	return &Cache{seed: seed, flags: flags}, nil
+}
+
+// Flags returns the flags the cache was allocated with
+func (c *Cache) Flags() Flags {
+	return c.flags
 }
 
 // Close releases the cache resources
@@ -29,11 +42,23 @@ func (c *Cache) Close() {
 // Dataset represents the RandomX dataset
 type Dataset struct {
 	cache *Cache
+	flags Flags
 }
 
 // NewDataset creates a new RandomX dataset from a cache
 func NewDataset(cache *Cache) (*Dataset, error) {
-	return &Dataset{cache: cache}, nil
+	return NewDatasetWithFlags(cache, GetFlags(), nil)
+}
+
+// NewDatasetWithFlags creates a new RandomX dataset from a cache with the
+// given flags.  The stub has no dataset to initialize, so the CPUs are unused.
+func NewDatasetWithFlags(cache *Cache, flags Flags, cpus []int) (*Dataset, error) {
+	return &Dataset{cache: cache, flags: flags | FlagFullMem}, nil
+}
+
+// Flags returns the flags the dataset was allocated with
+func (d *Dataset) Flags() Flags {
+	return d.flags
 }
 
 // Close releases the dataset resources
@@ -45,21 +70,38 @@ func (d *Dataset) Close() {
 type VM struct {
 	cache   *Cache
 	dataset *Dataset
+	flags   Flags
 }
 
 // NewVM creates a new RandomX VM with the given cache and dataset
 func NewVM(cache *Cache, dataset *Dataset) (*VM, error) {
-	return &VM{cache: cache, dataset: dataset}, nil
+	return NewVMWithFlags(cache, dataset, GetFlags())
+}
+
+// NewVMWithFlags creates a new RandomX VM with the given cache, dataset and
+// flags
+func NewVMWithFlags(cache *Cache, dataset *Dataset, flags Flags) (*VM, error) {
+	if dataset != nil {
+		flags |= FlagFullMem
+	}
+	return &VM{cache: cache, dataset: dataset, flags: flags}, nil
 }
 
-// CalcHash calculates the RandomX hash of the input
+// Flags returns the flags the VM was created with
+func (vm *VM) Flags() Flags {
+	return vm.flags
+}
+
+// CalcHash calculates the RandomX hash of the input.  The stub hashes the
+// seed of the cache and the input with SHA-256 so the hash still depends on
+// every byte of the input, such as the nonce, and on the seed.
 func (vm *VM) CalcHash(input []byte) []byte {
-	// This is a stub implementation
-	// The real RandomX would perform the actual proof-of-work calculation
-	hash := make([]byte, 32)
-	// For now, just return a dummy hash
-	copy(hash, input)
-	return hash
+	h := sha256.New()
+	if vm.cache != nil {
+		h.Write(vm.cache.seed)
+	}
+	h.Write(input)
+	return h.Sum(nil)
 }
 
 // Close releases the VM resources
@@ -74,3 +116,15 @@ func GetFlags() Flags {
 
 // Flags type for compatibility
 type Flags int
+
+// Flags for RandomX configuration, matching the values of the RandomX library
+const (
+	FlagDefault     Flags = 0
+	FlagLargePages  Flags = 1
+	FlagHardAES     Flags = 2
+	FlagFullMem     Flags = 4
+	FlagJIT         Flags = 8
+	FlagSecure      Flags = 16
+	FlagArgon2SSSE3 Flags = 32
+	FlagArgon2AVX2  Flags = 64
+)
@@ -0,0 +1,549 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package randomx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/wire"
+)
+
+const (
+	// defaultSeedLookahead is the number of blocks before a seed rotation
+	// at which the epoch after it starts being computed.  Computing a
+	// dataset takes tens of seconds, so this leaves plenty of time even
+	// on networks with short block intervals.
+	defaultSeedLookahead = 64
+)
+
+// ErrSeedManagerStopped indicates an epoch was requested from a seed manager
+// that has been stopped.
+var ErrSeedManagerStopped = errors.New("RandomX seed manager stopped")
+
+// EpochState describes how far the RandomX cache and dataset of an epoch have
+// been computed.
+type EpochState int
+
+const (
+	// EpochPending indicates the epoch has not been computed.
+	EpochPending EpochState = iota
+
+	// EpochBuilding indicates the epoch is being computed.
+	EpochBuilding
+
+	// EpochReady indicates the epoch can be hashed with.
+	EpochReady
+)
+
+// Map of EpochState values back to their constant names for pretty printing.
+var epochStateStrings = map[EpochState]string{
+	EpochPending:  "pending",
+	EpochBuilding: "building",
+	EpochReady:    "ready",
+}
+
+// String returns the EpochState in human-readable form.
+func (s EpochState) String() string {
+	if str, ok := epochStateStrings[s]; ok {
+		return str
+	}
+	return fmt.Sprintf("Unknown EpochState (%d)", int(s))
+}
+
+// SeedHeight returns the height of the block whose seed the block at the
+// passed height is hashed with.  The seed changes every rotation blocks.
+func SeedHeight(height, rotation int32) int32 {
+	if rotation <= 0 || height < 0 {
+		return 0
+	}
+	return (height / rotation) * rotation
+}
+
+// SeedForHeight returns the RandomX seed of the epoch starting at the passed
+// seed height.  The first epoch is seeded with the genesis hash.
+func SeedForHeight(seedHeight int32, genesisHash *chainhash.Hash) chainhash.Hash {
+	if seedHeight <= 0 {
+		return *genesisHash
+	}
+
+	// In a real implementation, this would be the block hash at seedHeight
+	// For now, we'll use a deterministic seed based on height
+	var seed [32]byte
+	binary.LittleEndian.PutUint32(seed[0:4], uint32(seedHeight))
+	copy(seed[4:], genesisHash[:28])
+
+	return sha256.Sum256(seed[:])
+}
+
+// Epoch holds the RandomX cache and, in fast mode, the datasets shared by the
+// blocks hashed with the same seed.  A dataset is computed on each configured
+// NUMA node so workers read it from local memory.  Epochs are obtained from
+// and returned to a SeedManager with Acquire and Release.
+type Epoch struct {
+	// SeedHeight is the height of the first block of the epoch.
+	SeedHeight int32
+
+	// Seed is the RandomX key of the epoch.
+	Seed chainhash.Hash
+
+	flags    Flags
+	cache    *Cache
+	datasets []*Dataset // One per NUMA node in fast mode
+	err      error
+	ready    chan struct{} // Closed once computed
+
+	// The following fields are protected by the mutex of the seed manager.
+	refs    int
+	evicted bool
+
+	// vmMtx protects vm, which is created on first use to hash blocks for
+	// validation.
+	vmMtx sync.Mutex
+	vm    *VM
+}
+
+// isReady returns whether the epoch has been computed successfully.
+func (e *Epoch) isReady() bool {
+	select {
+	case <-e.ready:
+		return e.err == nil
+	default:
+		return false
+	}
+}
+
+// NewVM returns a new RandomX VM for the epoch.  It runs in fast mode on the
+// dataset of the first NUMA node when the epoch has datasets and in light mode
+// otherwise.  VMs are not safe for concurrent use, so each mining worker needs
+// its own.  The VM must be closed before the epoch is released.
+func (e *Epoch) NewVM() (*VM, error) {
+	return e.NewNodeVM(0)
+}
+
+// NewNodeVM returns a new RandomX VM for the epoch like NewVM, but in fast
+// mode it runs on the dataset of the NUMA node at the passed index of the
+// configured nodes.
+func (e *Epoch) NewNodeVM(node int) (*VM, error) {
+	var dataset *Dataset
+	if len(e.datasets) > 0 {
+		dataset = e.datasets[node%len(e.datasets)]
+	}
+	return NewVMWithFlags(e.cache, dataset, e.flags)
+}
+
+// hash returns the RandomX hash of the passed input using the validation VM
+// of the epoch.
+func (e *Epoch) hash(input []byte) ([]byte, error) {
+	e.vmMtx.Lock()
+	defer e.vmMtx.Unlock()
+
+	if e.vm == nil {
+		vm, err := e.NewVM()
+		if err != nil {
+			return nil, err
+		}
+		e.vm = vm
+	}
+	return e.vm.CalcHash(input), nil
+}
+
+// close releases the RandomX resources of the epoch.
+func (e *Epoch) close() {
+	e.vmMtx.Lock()
+	if e.vm != nil {
+		e.vm.Close()
+		e.vm = nil
+	}
+	e.vmMtx.Unlock()
+
+	for _, dataset := range e.datasets {
+		dataset.Close()
+	}
+	if e.cache != nil {
+		e.cache.Close()
+	}
+}
+
+// SeedConfig is a descriptor which specifies the seed manager configuration.
+type SeedConfig struct {
+	// GenesisHash is the hash of the genesis block for the network.
+	GenesisHash *chainhash.Hash
+
+	// Rotation is the number of blocks between RandomX seed rotations.
+	Rotation int32
+
+	// FullMemory specifies whether datasets are computed for each epoch so
+	// VMs run in fast mode.  Mining needs fast mode while validation only
+	// needs the cache.
+	FullMemory bool
+
+	// Options tunes the flags the epochs are computed with.
+	Options Options
+
+	// NUMANodes are the NUMA nodes a dataset is computed on in fast mode,
+	// each by threads pinned to the node so the dataset is local to the
+	// workers mining on it.  A single dataset is computed by an unpinned
+	// thread when no nodes are configured.
+	NUMANodes []NUMANode
+
+	// Lookahead is the number of blocks before a seed rotation at which
+	// the next epoch starts being computed.  It defaults to 64 blocks and
+	// is capped at half the rotation.
+	Lookahead int32
+}
+
+// EpochStatus describes an epoch of a seed manager.
+type EpochStatus struct {
+	SeedHeight int32
+	Seed       chainhash.Hash
+	State      EpochState
+}
+
+// SeedStatus describes the epochs of a seed manager.
+type SeedStatus struct {
+	// Height is the height of the next block as last passed to Update.
+	Height     int32
+	Rotation   int32
+	FullMemory bool
+
+	// Previous is nil during the first epoch.
+	Previous *EpochStatus
+	Current  EpochStatus
+	Next     EpochStatus
+}
+
+// SeedManager computes the RandomX epochs blocks are hashed with.  It computes
+// the epoch after the current one in the background ahead of the seed
+// rotation so mining does not stall when the rotation is reached, and keeps
+// the previous epoch so blocks can still be hashed after a reorganization
+// back across the rotation.  A single seed manager is meant to be shared by
+// the miner and block validation.
+type SeedManager struct {
+	cfg SeedConfig
+	wg  sync.WaitGroup
+
+	// The following fields are protected by mtx.
+	mtx     sync.Mutex
+	height  int32
+	epochs  map[int32]*Epoch
+	stopped bool
+}
+
+// NewSeedManager returns a new seed manager for the passed configuration.  No
+// epoch is computed until Update or Acquire is called.
+func NewSeedManager(cfg *SeedConfig) *SeedManager {
+	sm := &SeedManager{
+		cfg:    *cfg,
+		epochs: make(map[int32]*Epoch),
+	}
+	if sm.cfg.Lookahead <= 0 {
+		sm.cfg.Lookahead = defaultSeedLookahead
+	}
+	if sm.cfg.Rotation > 0 && sm.cfg.Lookahead > sm.cfg.Rotation/2 {
+		sm.cfg.Lookahead = sm.cfg.Rotation / 2
+	}
+	return sm
+}
+
+// seedHeight returns the seed height of the block at the passed height.
+func (sm *SeedManager) seedHeight(height int32) int32 {
+	return SeedHeight(height, sm.cfg.Rotation)
+}
+
+// computeDatasets computes a dataset of the passed cache on each configured
+// NUMA node, or a single dataset when no nodes are configured.  The datasets
+// are computed concurrently.
+func (sm *SeedManager) computeDatasets(cache *Cache, flags Flags) ([]*Dataset, error) {
+	nodes := sm.cfg.NUMANodes
+	if len(nodes) == 0 {
+		nodes = []NUMANode{{}}
+	}
+
+	datasets := make([]*Dataset, len(nodes))
+	errs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	wg.Add(len(nodes))
+	for i, node := range nodes {
+		go func(i int, cpus []int) {
+			defer wg.Done()
+			datasets[i], errs[i] = NewDatasetWithFlags(cache, flags,
+				cpus)
+		}(i, node.CPUs)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		for _, dataset := range datasets {
+			if dataset != nil {
+				dataset.Close()
+			}
+		}
+		return nil, err
+	}
+	return datasets, nil
+}
+
+// build computes the cache and, in fast mode, the datasets of the passed
+// epoch.  It must be run as a goroutine.
+func (sm *SeedManager) build(e *Epoch) {
+	defer sm.wg.Done()
+
+	log.Infof("Computing RandomX epoch at seed height %d", e.SeedHeight)
+	start := time.Now()
+
+	cache, err := NewCacheWithFlags(e.Seed[:], e.flags)
+	if err == nil && sm.cfg.FullMemory {
+		e.datasets, err = sm.computeDatasets(cache, e.flags)
+		if err != nil {
+			cache.Close()
+		}
+	}
+	if err == nil {
+		e.cache = cache
+	} else {
+		e.err = fmt.Errorf("failed to compute RandomX epoch at seed "+
+			"height %d: %w", e.SeedHeight, err)
+		log.Errorf("%v", e.err)
+	}
+
+	sm.mtx.Lock()
+	close(e.ready)
+	if e.err != nil {
+		// Forget the epoch so it is retried the next time it is
+		// needed.
+		if sm.epochs[e.SeedHeight] == e {
+			delete(sm.epochs, e.SeedHeight)
+		}
+	} else {
+		log.Infof("Computed RandomX epoch at seed height %d in %v",
+			e.SeedHeight, time.Since(start).Round(time.Millisecond))
+	}
+	sm.closeIfUnusedLocked(e)
+	sm.mtx.Unlock()
+}
+
+// epochLocked returns the epoch at the passed seed height and starts
+// computing it in the background if it is unknown.  The caller must hold the
+// lock.
+func (sm *SeedManager) epochLocked(seedHeight int32) (*Epoch, error) {
+	if sm.stopped {
+		return nil, ErrSeedManagerStopped
+	}
+	if e, ok := sm.epochs[seedHeight]; ok {
+		return e, nil
+	}
+
+	e := &Epoch{
+		SeedHeight: seedHeight,
+		Seed:       SeedForHeight(seedHeight, sm.cfg.GenesisHash),
+		flags:      DetectFlags(sm.cfg.Options),
+		ready:      make(chan struct{}),
+	}
+	sm.epochs[seedHeight] = e
+	sm.wg.Add(1)
+	go sm.build(e)
+
+	return e, nil
+}
+
+// closeIfUnusedLocked closes the passed epoch once it has been evicted,
+// computed and released by all its users.  The caller must hold the lock.
+func (sm *SeedManager) closeIfUnusedLocked(e *Epoch) {
+	if !e.evicted || e.refs > 0 {
+		return
+	}
+	select {
+	case <-e.ready:
+		e.close()
+	default:
+		// The epoch is closed once it has been computed.
+	}
+}
+
+// evictLocked forgets the epochs other than the previous, current and next
+// one.  Forgotten epochs are closed once they are no longer used.  The caller
+// must hold the lock.
+func (sm *SeedManager) evictLocked() {
+	current := sm.seedHeight(sm.height)
+	for seedHeight, e := range sm.epochs {
+		if seedHeight >= current-sm.cfg.Rotation &&
+			seedHeight <= current+sm.cfg.Rotation {
+
+			continue
+		}
+		delete(sm.epochs, seedHeight)
+		e.evicted = true
+		sm.closeIfUnusedLocked(e)
+	}
+}
+
+// Update informs the seed manager of the height of the next block.  It starts
+// computing the epoch of that height in the background if needed, along with
+// the next epoch once the height is within the lookahead of the next seed
+// rotation, and forgets the epochs that are no longer needed.
+//
+// This function is safe for concurrent access.
+func (sm *SeedManager) Update(height int32) {
+	sm.mtx.Lock()
+	defer sm.mtx.Unlock()
+
+	if sm.stopped {
+		return
+	}
+	sm.height = height
+	current := sm.seedHeight(height)
+	sm.evictLocked()
+
+	// Errors only occur once the manager is stopped, which was checked
+	// above.
+	_, _ = sm.epochLocked(current)
+	if sm.cfg.Rotation > 0 {
+		next := current + sm.cfg.Rotation
+		if height >= next-sm.cfg.Lookahead {
+			_, _ = sm.epochLocked(next)
+		}
+	}
+}
+
+// Acquire returns the epoch of the block at the passed height, waiting for it
+// to be computed if necessary.  Epochs other than the previous, current and
+// next one are computed on demand but not kept.  Release must be called once
+// the epoch is no longer used.
+//
+// This function is safe for concurrent access.
+func (sm *SeedManager) Acquire(height int32) (*Epoch, error) {
+	sm.mtx.Lock()
+	e, err := sm.epochLocked(sm.seedHeight(height))
+	if err != nil {
+		sm.mtx.Unlock()
+		return nil, err
+	}
+	e.refs++
+	sm.evictLocked()
+	sm.mtx.Unlock()
+
+	<-e.ready
+	if e.err != nil {
+		sm.Release(e)
+		return nil, e.err
+	}
+	return e, nil
+}
+
+// Release returns an epoch obtained from Acquire.
+//
+// This function is safe for concurrent access.
+func (sm *SeedManager) Release(e *Epoch) {
+	sm.mtx.Lock()
+	e.refs--
+	sm.closeIfUnusedLocked(e)
+	sm.mtx.Unlock()
+}
+
+// Hash returns the RandomX hash of the passed input for the block at the
+// passed height.  It shares the epochs computed for mining, so validating
+// blocks does not need a cache of its own.
+//
+// This function is safe for concurrent access.
+func (sm *SeedManager) Hash(height int32, input []byte) ([]byte, error) {
+	e, err := sm.Acquire(height)
+	if err != nil {
+		return nil, err
+	}
+	defer sm.Release(e)
+
+	return e.hash(input)
+}
+
+// HashHeader returns the RandomX proof-of-work hash of the passed block header
+// at the passed height, which is the hash of the serialized header.
+//
+// This function is safe for concurrent access.
+func (sm *SeedManager) HashHeader(height int32, header *wire.BlockHeader) (chainhash.Hash, error) {
+	var hash chainhash.Hash
+	var buf bytes.Buffer
+	buf.Grow(wire.MaxBlockHeaderPayload)
+	if err := header.Serialize(&buf); err != nil {
+		return hash, err
+	}
+	core, err := sm.Hash(height, buf.Bytes())
+	if err != nil {
+		return hash, err
+	}
+	copy(hash[:], core)
+	return hash, nil
+}
+
+// Seed returns the RandomX seed the block at the passed height is hashed
+// with.
+func (sm *SeedManager) Seed(height int32) chainhash.Hash {
+	return SeedForHeight(sm.seedHeight(height), sm.cfg.GenesisHash)
+}
+
+// epochStatusLocked returns the status of the epoch at the passed seed
+// height.  The caller must hold the lock.
+func (sm *SeedManager) epochStatusLocked(seedHeight int32) EpochStatus {
+	status := EpochStatus{
+		SeedHeight: seedHeight,
+		Seed:       SeedForHeight(seedHeight, sm.cfg.GenesisHash),
+		State:      EpochPending,
+	}
+	if e, ok := sm.epochs[seedHeight]; ok {
+		status.State = EpochBuilding
+		if e.isReady() {
+			status.State = EpochReady
+		}
+	}
+	return status
+}
+
+// Status returns the status of the previous, current and next epoch.
+//
+// This function is safe for concurrent access.
+func (sm *SeedManager) Status() SeedStatus {
+	sm.mtx.Lock()
+	defer sm.mtx.Unlock()
+
+	current := sm.seedHeight(sm.height)
+	status := SeedStatus{
+		Height:     sm.height,
+		Rotation:   sm.cfg.Rotation,
+		FullMemory: sm.cfg.FullMemory,
+		Current:    sm.epochStatusLocked(current),
+		Next:       sm.epochStatusLocked(current + sm.cfg.Rotation),
+	}
+	if current > 0 {
+		previous := sm.epochStatusLocked(current - sm.cfg.Rotation)
+		status.Previous = &previous
+	}
+	return status
+}
+
+// Stop waits for the epochs being computed and releases the epochs that are
+// not in use.  Epochs still in use are released once their users return them.
+//
+// This function is safe for concurrent access.
+func (sm *SeedManager) Stop() {
+	sm.mtx.Lock()
+	sm.stopped = true
+	for seedHeight, e := range sm.epochs {
+		delete(sm.epochs, seedHeight)
+		e.evicted = true
+		sm.closeIfUnusedLocked(e)
+	}
+	sm.mtx.Unlock()
+
+	sm.wg.Wait()
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package randomx
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// testGenesisHash is the genesis hash the seed manager tests derive seeds
+// from.
+var testGenesisHash = chainhash.Hash{0x01, 0x02, 0x03}
+
+// TestSeedHeight ensures blocks are assigned to the epoch of the last seed
+// rotation.
+func TestSeedHeight(t *testing.T) {
+	tests := []struct {
+		height   int32
+		rotation int32
+		want     int32
+	}{
+		{0, 100, 0},
+		{99, 100, 0},
+		{100, 100, 100},
+		{2047, 2048, 0},
+		{4097, 2048, 4096},
+		{-1, 100, 0},
+		{500, 0, 0},
+	}
+
+	for _, test := range tests {
+		got := SeedHeight(test.height, test.rotation)
+		if got != test.want {
+			t.Errorf("SeedHeight(%d, %d): got %d, want %d",
+				test.height, test.rotation, got, test.want)
+		}
+	}
+
+	if seed := SeedForHeight(0, &testGenesisHash); seed != testGenesisHash {
+		t.Errorf("SeedForHeight: first epoch seed %v, want the genesis "+
+			"hash", seed)
+	}
+	if SeedForHeight(100, &testGenesisHash) == SeedForHeight(200, &testGenesisHash) {
+		t.Errorf("SeedForHeight: epochs share a seed")
+	}
+}
+
+// TestSeedManager ensures the seed manager computes the next epoch ahead of
+// the seed rotation and keeps the previous epoch around for reorganizations.
+func TestSeedManager(t *testing.T) {
+	sm := NewSeedManager(&SeedConfig{
+		GenesisHash: &testGenesisHash,
+		Rotation:    100,
+		Lookahead:   10,
+	})
+	defer sm.Stop()
+
+	// The next epoch is not computed before the lookahead.
+	sm.Update(50)
+	status := sm.Status()
+	if status.Previous != nil {
+		t.Errorf("Status: previous epoch %+v during the first epoch",
+			status.Previous)
+	}
+	if status.Current.State == EpochPending {
+		t.Errorf("Status: current epoch not being computed")
+	}
+	if status.Next.SeedHeight != 100 || status.Next.State != EpochPending {
+		t.Errorf("Status: got next epoch %+v, want pending epoch at "+
+			"seed height 100", status.Next)
+	}
+
+	// Within the lookahead it is computed in the background.
+	sm.Update(90)
+	status = sm.Status()
+	if status.Next.State == EpochPending {
+		t.Fatalf("Status: next epoch not computed within the lookahead")
+	}
+
+	current, err := sm.Acquire(90)
+	if err != nil {
+		t.Fatalf("Acquire: unexpected error: %v", err)
+	}
+	next, err := sm.Acquire(100)
+	if err != nil {
+		t.Fatalf("Acquire: unexpected error: %v", err)
+	}
+	if next.SeedHeight != 100 || next.Seed != SeedForHeight(100, &testGenesisHash) {
+		t.Errorf("Acquire: got epoch at seed height %d with seed %v",
+			next.SeedHeight, next.Seed)
+	}
+
+	// Crossing the rotation switches to the precomputed epoch and keeps
+	// the previous one, so a reorganization back across the rotation
+	// finds it again.
+	sm.Update(100)
+	status = sm.Status()
+	if status.Current.SeedHeight != 100 || status.Current.State != EpochReady {
+		t.Errorf("Status: got current epoch %+v, want ready epoch at "+
+			"seed height 100", status.Current)
+	}
+	if status.Previous == nil || status.Previous.State != EpochReady {
+		t.Errorf("Status: got previous epoch %+v, want ready epoch",
+			status.Previous)
+	}
+	sm.Update(99)
+	reorged, err := sm.Acquire(99)
+	if err != nil {
+		t.Fatalf("Acquire: unexpected error: %v", err)
+	}
+	if reorged != current {
+		t.Errorf("Acquire: previous epoch was recomputed")
+	}
+	sm.Release(reorged)
+	sm.Release(next)
+
+	// Epochs older than the previous one are forgotten, but remain usable
+	// until they are released.
+	sm.Update(250)
+	status = sm.Status()
+	if status.Previous == nil || status.Previous.SeedHeight != 100 ||
+		status.Previous.State != EpochReady {
+
+		t.Errorf("Status: got previous epoch %+v, want ready epoch at "+
+			"seed height 100", status.Previous)
+	}
+	sm.mtx.Lock()
+	_, kept := sm.epochs[0]
+	evicted := current.evicted
+	sm.mtx.Unlock()
+	if kept || !evicted {
+		t.Errorf("Update: first epoch was not forgotten")
+	}
+	vm, err := current.NewVM()
+	if err != nil {
+		t.Fatalf("NewVM: unexpected error: %v", err)
+	}
+	vm.Close()
+	sm.Release(current)
+}
+
+// TestSeedManagerHash ensures hashing for validation matches a VM created
+// for the seed of the epoch.
+func TestSeedManagerHash(t *testing.T) {
+	sm := NewSeedManager(&SeedConfig{
+		GenesisHash: &testGenesisHash,
+		Rotation:    100,
+	})
+
+	input := []byte("Shell Reserve block header")
+	for _, height := range []int32{5, 150} {
+		got, err := sm.Hash(height, input)
+		if err != nil {
+			t.Fatalf("Hash: unexpected error: %v", err)
+		}
+
+		seed := SeedForHeight(SeedHeight(height, 100), &testGenesisHash)
+		cache, err := NewCache(seed[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		vm, err := NewVM(cache, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := vm.CalcHash(input)
+		vm.Close()
+		cache.Close()
+
+		if !bytes.Equal(got, want) {
+			t.Errorf("Hash(%d): got %x, want %x", height, got, want)
+		}
+	}
+
+	// Block headers are hashed in their serialized form, including the
+	// thermal proof.
+	header := &wire.BlockHeader{
+		Version:      0x20000000,
+		Bits:         0x1d00ffff,
+		Nonce:        0xdeadbeef,
+		ThermalProof: 0x0102030405060708,
+	}
+	var serialized bytes.Buffer
+	if err := header.Serialize(&serialized); err != nil {
+		t.Fatalf("Serialize: unexpected error: %v", err)
+	}
+	want, err := sm.Hash(5, serialized.Bytes())
+	if err != nil {
+		t.Fatalf("Hash: unexpected error: %v", err)
+	}
+	got, err := sm.HashHeader(5, header)
+	if err != nil {
+		t.Fatalf("HashHeader: unexpected error: %v", err)
+	}
+	if !bytes.Equal(got[:], want) {
+		t.Errorf("HashHeader: got %x, want %x", got, want)
+	}
+
+	sm.Stop()
+	if _, err := sm.Hash(5, input); !errors.Is(err, ErrSeedManagerStopped) {
+		t.Errorf("Hash: got error %v, want %v", err,
+			ErrSeedManagerStopped)
+	}
+}
+
+// TestEpochStateStringer tests the stringized output for the EpochState type.
+func TestEpochStateStringer(t *testing.T) {
+	tests := []struct {
+		in   EpochState
+		want string
+	}{
+		{EpochPending, "pending"},
+		{EpochBuilding, "building"},
+		{EpochReady, "ready"},
+		{0xff, "Unknown EpochState (255)"},
+	}
+
+	for _, test := range tests {
+		if got := test.in.String(); got != test.want {
+			t.Errorf("String: got %q, want %q", got, test.want)
+		}
+	}
+}
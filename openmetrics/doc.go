@@ -0,0 +1,26 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package openmetrics implements a minimal exporter of the OpenMetrics text
+format that Prometheus and compatible systems scrape.
+
+Rather than keeping long-lived metric objects, subsystems register collectors
+with a Registry.  Each scrape asks every collector for the current values of
+its metric families, so existing counters and statistics can be exported
+without duplicating them:
+
+	registry := openmetrics.NewRegistry()
+	registry.Register(openmetrics.CollectorFunc(func() []*openmetrics.Family {
+		return []*openmetrics.Family{
+			openmetrics.NewGauge("shell_chain_height",
+				"Height of the best chain.", float64(height)),
+		}
+	}))
+	http.Handle("/metrics", registry)
+
+Gauges, counters and histograms are supported.  Counter family names exclude
+the _total suffix their samples are written with.
+*/
+package openmetrics
@@ -0,0 +1,378 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package openmetrics
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the HTTP content type of the OpenMetrics text format.
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+var (
+	// ErrInvalidName indicates a metric or label name contains characters
+	// the text format does not allow.
+	ErrInvalidName = errors.New("invalid metric or label name")
+
+	// ErrDuplicateFamily indicates two metric families share a name.
+	ErrDuplicateFamily = errors.New("duplicate metric family")
+
+	// ErrInvalidFamily indicates a metric family is inconsistent with its
+	// type, such as a histogram sample in a gauge family.
+	ErrInvalidFamily = errors.New("invalid metric family")
+)
+
+// MetricType is the type of a metric family.
+type MetricType string
+
+// Metric family types.
+const (
+	// Gauge is a value that can go up and down.
+	Gauge MetricType = "gauge"
+
+	// Counter is a monotonically increasing total.  The samples of a
+	// counter family are written with a _total suffix.
+	Counter MetricType = "counter"
+
+	// Histogram counts observations in cumulative buckets.
+	Histogram MetricType = "histogram"
+)
+
+// Label is a name and value pair that distinguishes the samples of a family.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// HistogramValue is the state of a histogram sample.  The zero value is not
+// usable; create one with NewHistogram.
+type HistogramValue struct {
+	bounds []float64 // Ascending upper bounds, excluding +Inf
+	counts []uint64  // Observations per bucket, the last being +Inf
+	sum    float64
+}
+
+// NewHistogram returns an empty histogram with the passed upper bucket bounds.
+// The bounds are sorted, and a +Inf bucket is always added.
+func NewHistogram(bounds []float64) *HistogramValue {
+	sorted := make([]float64, 0, len(bounds))
+	for _, bound := range bounds {
+		if !math.IsInf(bound, 1) && !math.IsNaN(bound) {
+			sorted = append(sorted, bound)
+		}
+	}
+	sort.Float64s(sorted)
+	return &HistogramValue{
+		bounds: sorted,
+		counts: make([]uint64, len(sorted)+1),
+	}
+}
+
+// Observe adds an observation to the histogram.
+func (h *HistogramValue) Observe(v float64) {
+	i := sort.SearchFloat64s(h.bounds, v)
+	h.counts[i]++
+	h.sum += v
+}
+
+// Count returns the number of observations.
+func (h *HistogramValue) Count() uint64 {
+	var count uint64
+	for _, n := range h.counts {
+		count += n
+	}
+	return count
+}
+
+// Sample is a single value of a metric family, or a histogram for histogram
+// families.
+type Sample struct {
+	Labels    []Label
+	Value     float64
+	Histogram *HistogramValue
+}
+
+// Family is a named group of samples of the same type.  The name of a counter
+// family excludes the _total suffix of its samples, and when a unit is given
+// the name must end with it, such as shell_mempool_size_bytes with the unit
+// bytes.
+type Family struct {
+	Name    string
+	Help    string
+	Unit    string
+	Type    MetricType
+	Samples []Sample
+}
+
+// NewGauge returns a gauge family with a single sample.
+func NewGauge(name, help string, value float64, labels ...Label) *Family {
+	f := &Family{Name: name, Help: help, Type: Gauge}
+	return f.Add(value, labels...)
+}
+
+// NewCounter returns a counter family with a single sample.
+func NewCounter(name, help string, value float64, labels ...Label) *Family {
+	f := &Family{Name: name, Help: help, Type: Counter}
+	return f.Add(value, labels...)
+}
+
+// Add adds a sample with the passed value and labels to the family and
+// returns the family so calls can be chained.
+func (f *Family) Add(value float64, labels ...Label) *Family {
+	f.Samples = append(f.Samples, Sample{Labels: labels, Value: value})
+	return f
+}
+
+// WithUnit sets the unit of the family and returns the family so calls can be
+// chained.
+func (f *Family) WithUnit(unit string) *Family {
+	f.Unit = unit
+	return f
+}
+
+// Collector provides metric families when metrics are scraped.
+type Collector interface {
+	// Collect returns the current metric families of the collector.
+	Collect() []*Family
+}
+
+// CollectorFunc is an adapter to allow the use of ordinary functions as
+// collectors.
+type CollectorFunc func() []*Family
+
+// Collect calls f().
+//
+// This is part of the Collector interface.
+func (f CollectorFunc) Collect() []*Family {
+	return f()
+}
+
+// Registry gathers the metric families of its collectors and serves them over
+// HTTP in the OpenMetrics text format.  It is safe for concurrent use.
+type Registry struct {
+	mtx        sync.Mutex
+	collectors []Collector
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a collector to the registry.
+func (r *Registry) Register(c Collector) {
+	r.mtx.Lock()
+	r.collectors = append(r.collectors, c)
+	r.mtx.Unlock()
+}
+
+// Gather returns the metric families of all collectors in the order the
+// collectors were registered.
+func (r *Registry) Gather() []*Family {
+	r.mtx.Lock()
+	collectors := make([]Collector, len(r.collectors))
+	copy(collectors, r.collectors)
+	r.mtx.Unlock()
+
+	var families []*Family
+	for _, c := range collectors {
+		families = append(families, c.Collect()...)
+	}
+	return families
+}
+
+// ServeHTTP writes the metric families of all collectors in the OpenMetrics
+// text format.
+//
+// This is part of the http.Handler interface.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, r.Gather()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	if req.Method == http.MethodGet {
+		w.Write(buf.Bytes())
+	}
+}
+
+// Encode writes the passed metric families to w in the OpenMetrics text
+// format, terminated by the # EOF marker.  Nothing is written when a family is
+// invalid.
+func Encode(w io.Writer, families []*Family) error {
+	var buf bytes.Buffer
+	seen := make(map[string]struct{}, len(families))
+	for _, f := range families {
+		if _, ok := seen[f.Name]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateFamily, f.Name)
+		}
+		seen[f.Name] = struct{}{}
+
+		if err := encodeFamily(&buf, f); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("# EOF\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// encodeFamily writes the metadata and samples of a single family to buf.
+func encodeFamily(buf *bytes.Buffer, f *Family) error {
+	if !validMetricName(f.Name) {
+		return fmt.Errorf("%w: %q", ErrInvalidName, f.Name)
+	}
+	switch f.Type {
+	case Gauge, Counter, Histogram:
+	default:
+		return fmt.Errorf("%w: %s has unknown type %q", ErrInvalidFamily,
+			f.Name, f.Type)
+	}
+	if f.Unit != "" && !strings.HasSuffix(f.Name, "_"+f.Unit) {
+		return fmt.Errorf("%w: %s does not end with its unit %s",
+			ErrInvalidFamily, f.Name, f.Unit)
+	}
+
+	fmt.Fprintf(buf, "# TYPE %s %s\n", f.Name, f.Type)
+	if f.Unit != "" {
+		fmt.Fprintf(buf, "# UNIT %s %s\n", f.Name, f.Unit)
+	}
+	if f.Help != "" {
+		fmt.Fprintf(buf, "# HELP %s %s\n", f.Name, escapeHelp(f.Help))
+	}
+
+	for _, s := range f.Samples {
+		for _, l := range s.Labels {
+			if !validLabelName(l.Name) {
+				return fmt.Errorf("%w: label %q of %s",
+					ErrInvalidName, l.Name, f.Name)
+			}
+		}
+		if (f.Type == Histogram) != (s.Histogram != nil) {
+			return fmt.Errorf("%w: %s sample does not match its "+
+				"type", ErrInvalidFamily, f.Name)
+		}
+
+		switch f.Type {
+		case Counter:
+			writeSample(buf, f.Name+"_total", s.Labels, s.Value)
+
+		case Histogram:
+			h := s.Histogram
+			var cumulative uint64
+			for i, n := range h.counts {
+				cumulative += n
+				le := math.Inf(1)
+				if i < len(h.bounds) {
+					le = h.bounds[i]
+				}
+				labels := append(s.Labels[:len(s.Labels):len(s.Labels)],
+					Label{Name: "le", Value: formatFloat(le)})
+				writeSample(buf, f.Name+"_bucket", labels,
+					float64(cumulative))
+			}
+			writeSample(buf, f.Name+"_count", s.Labels,
+				float64(cumulative))
+			writeSample(buf, f.Name+"_sum", s.Labels, h.sum)
+
+		default:
+			writeSample(buf, f.Name, s.Labels, s.Value)
+		}
+	}
+	return nil
+}
+
+// writeSample writes a single sample line to buf.
+func writeSample(buf *bytes.Buffer, name string, labels []Label, value float64) {
+	buf.WriteString(name)
+	if len(labels) > 0 {
+		buf.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(l.Name)
+			buf.WriteString(`="`)
+			buf.WriteString(escapeLabelValue(l.Value))
+			buf.WriteByte('"')
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(formatFloat(value))
+	buf.WriteByte('\n')
+}
+
+// formatFloat formats a sample value the way the text format expects.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// labelValueReplacer escapes label values.
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// escapeLabelValue escapes backslashes, newlines and double quotes.
+func escapeLabelValue(s string) string {
+	return labelValueReplacer.Replace(s)
+}
+
+// helpReplacer escapes help texts.
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// escapeHelp escapes backslashes and newlines.
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+// validMetricName returns whether the passed name matches
+// [a-zA-Z_:][a-zA-Z0-9_:]*.
+func validMetricName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_' || c == ':':
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// validLabelName returns whether the passed name matches
+// [a-zA-Z_][a-zA-Z0-9_]*.
+func validLabelName(name string) bool {
+	return validMetricName(name) && !strings.Contains(name, ":")
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package openmetrics
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestEncode ensures metric families are written in the OpenMetrics text
+// format.
+func TestEncode(t *testing.T) {
+	fees := NewHistogram([]float64{10, 1, math.Inf(1), 5})
+	for _, fee := range []float64{0.5, 1, 3, 7, 12, 40} {
+		fees.Observe(fee)
+	}
+
+	families := []*Family{
+		NewGauge("shell_chain_height", "Height of the best chain.", 1234),
+		NewCounter("shell_reorgs", "Chain reorganizations.", 2),
+		(&Family{
+			Name: "shell_peers",
+			Help: "Connected peers\nby direction.",
+			Type: Gauge,
+		}).Add(8, Label{"direction", "outbound"}).
+			Add(3, Label{"direction", "inbound"}),
+		NewGauge("shell_mempool_size_bytes", "", 1.5e6).WithUnit("bytes"),
+		{
+			Name: "shell_mempool_fee_rate",
+			Type: Histogram,
+			Samples: []Sample{{
+				Labels:    []Label{{"pool", `a"b\c`}},
+				Histogram: fees,
+			}},
+		},
+		NewGauge("shell_temperature", "", math.NaN()),
+	}
+
+	want := `# TYPE shell_chain_height gauge
+# HELP shell_chain_height Height of the best chain.
+shell_chain_height 1234
+# TYPE shell_reorgs counter
+# HELP shell_reorgs Chain reorganizations.
+shell_reorgs_total 2
+# TYPE shell_peers gauge
+# HELP shell_peers Connected peers\nby direction.
+shell_peers{direction="outbound"} 8
+shell_peers{direction="inbound"} 3
+# TYPE shell_mempool_size_bytes gauge
+# UNIT shell_mempool_size_bytes bytes
+shell_mempool_size_bytes 1.5e+06
+# TYPE shell_mempool_fee_rate histogram
+shell_mempool_fee_rate_bucket{pool="a\"b\\c",le="1"} 2
+shell_mempool_fee_rate_bucket{pool="a\"b\\c",le="5"} 3
+shell_mempool_fee_rate_bucket{pool="a\"b\\c",le="10"} 4
+shell_mempool_fee_rate_bucket{pool="a\"b\\c",le="+Inf"} 6
+shell_mempool_fee_rate_count{pool="a\"b\\c"} 6
+shell_mempool_fee_rate_sum{pool="a\"b\\c"} 63.5
+# TYPE shell_temperature gauge
+shell_temperature NaN
+# EOF
+`
+	var buf bytes.Buffer
+	if err := Encode(&buf, families); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if buf.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+// TestEncodeErrors ensures invalid families are rejected without writing a
+// partial exposition.
+func TestEncodeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		families []*Family
+		err      error
+	}{
+		{
+			name:     "bad metric name",
+			families: []*Family{NewGauge("1shell", "", 1)},
+			err:      ErrInvalidName,
+		},
+		{
+			name: "bad label name",
+			families: []*Family{NewGauge("shell", "", 1,
+				Label{"a:b", "x"})},
+			err: ErrInvalidName,
+		},
+		{
+			name: "duplicate",
+			families: []*Family{NewGauge("shell", "", 1),
+				NewCounter("shell", "", 1)},
+			err: ErrDuplicateFamily,
+		},
+		{
+			name:     "unit suffix",
+			families: []*Family{NewGauge("shell_size", "", 1).WithUnit("bytes")},
+			err:      ErrInvalidFamily,
+		},
+		{
+			name: "histogram without buckets",
+			families: []*Family{{Name: "shell", Type: Histogram,
+				Samples: []Sample{{Value: 1}}}},
+			err: ErrInvalidFamily,
+		},
+		{
+			name:     "unknown type",
+			families: []*Family{{Name: "shell", Type: "summary"}},
+			err:      ErrInvalidFamily,
+		},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		err := Encode(&buf, test.families)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: got error %v, want %v", test.name, err,
+				test.err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%s: wrote %q", test.name, buf.String())
+		}
+	}
+}
+
+// TestRegistryServeHTTP ensures the registry gathers its collectors on every
+// scrape and serves them with the OpenMetrics content type.
+func TestRegistryServeHTTP(t *testing.T) {
+	registry := NewRegistry()
+	scrapes := 0
+	registry.Register(CollectorFunc(func() []*Family {
+		scrapes++
+		return []*Family{NewCounter("shell_scrapes", "", float64(scrapes))}
+	}))
+
+	for i := 1; i <= 2; i++ {
+		rec := httptest.NewRecorder()
+		registry.ServeHTTP(rec, httptest.NewRequest(http.MethodGet,
+			"/metrics", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d", rec.Code)
+		}
+		if got := rec.Header().Get("Content-Type"); got != ContentType {
+			t.Fatalf("got content type %q", got)
+		}
+		want := "# TYPE shell_scrapes counter\nshell_scrapes_total " +
+			string(rune('0'+i)) + "\n# EOF\n"
+		if rec.Body.String() != want {
+			t.Fatalf("scrape %d: got %q, want %q", i,
+				rec.Body.String(), want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	registry.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/metrics",
+		nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST got status %d", rec.Code)
+	}
+}
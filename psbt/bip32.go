@@ -0,0 +1,115 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psbt
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/toole-brendan/shell/wire"
+)
+
+// Bip32Derivation records the master key fingerprint and derivation path
+// of a public key
+type Bip32Derivation struct {
+	// PubKey is the serialized public key being described
+	PubKey []byte
+
+	// MasterKeyFingerprint is the fingerprint of the master key
+	MasterKeyFingerprint uint32
+
+	// Bip32Path is the derivation path from the master key
+	Bip32Path []uint32
+}
+
+// TaprootBip32Derivation records the master key fingerprint and derivation
+// path of an x-only public key along with the leaves it signs for
+type TaprootBip32Derivation struct {
+	// XOnlyPubKey is the x-only public key being described
+	XOnlyPubKey []byte
+
+	// LeafHashes are the hashes of the leaves the key appears in
+	LeafHashes [][]byte
+
+	// MasterKeyFingerprint is the fingerprint of the master key
+	MasterKeyFingerprint uint32
+
+	// Bip32Path is the derivation path from the master key
+	Bip32Path []uint32
+}
+
+// readBip32Derivation decodes a fingerprint followed by a derivation path
+func readBip32Derivation(value []byte) (uint32, []uint32, error) {
+	if len(value) < 4 || len(value)%4 != 0 {
+		return 0, nil, ErrInvalidValue
+	}
+
+	fingerprint := binary.LittleEndian.Uint32(value[:4])
+	path := make([]uint32, 0, len(value)/4-1)
+	for i := 4; i < len(value); i += 4 {
+		path = append(path, binary.LittleEndian.Uint32(value[i:i+4]))
+	}
+
+	return fingerprint, path, nil
+}
+
+// serializeBip32Derivation encodes a fingerprint followed by a derivation
+// path
+func serializeBip32Derivation(fingerprint uint32, path []uint32) []byte {
+	value := make([]byte, 4+4*len(path))
+	binary.LittleEndian.PutUint32(value[:4], fingerprint)
+	for i, index := range path {
+		binary.LittleEndian.PutUint32(value[4+4*i:], index)
+	}
+
+	return value
+}
+
+// readTaprootBip32Derivation decodes the value of a taproot BIP 32
+// derivation field
+func readTaprootBip32Derivation(xOnlyPubKey,
+	value []byte) (*TaprootBip32Derivation, error) {
+
+	r := bytes.NewReader(value)
+	numHashes, err := wire.ReadVarInt(r, 0)
+	if err != nil || numHashes > uint64(r.Len()/32) {
+		return nil, ErrInvalidValue
+	}
+
+	leafHashes := make([][]byte, numHashes)
+	for i := range leafHashes {
+		leafHashes[i] = make([]byte, 32)
+		if _, err := r.Read(leafHashes[i]); err != nil {
+			return nil, ErrInvalidValue
+		}
+	}
+
+	fingerprint, path, err := readBip32Derivation(value[len(value)-r.Len():])
+	if err != nil {
+		return nil, err
+	}
+
+	return &TaprootBip32Derivation{
+		XOnlyPubKey:          xOnlyPubKey,
+		LeafHashes:           leafHashes,
+		MasterKeyFingerprint: fingerprint,
+		Bip32Path:            path,
+	}, nil
+}
+
+// serializeTaprootBip32Derivation encodes the value of a taproot BIP 32
+// derivation field
+func serializeTaprootBip32Derivation(d *TaprootBip32Derivation) ([]byte, error) {
+	var b bytes.Buffer
+	if err := wire.WriteVarInt(&b, 0, uint64(len(d.LeafHashes))); err != nil {
+		return nil, err
+	}
+	for _, hash := range d.LeafHashes {
+		b.Write(hash)
+	}
+	b.Write(serializeBip32Derivation(d.MasterKeyFingerprint, d.Bip32Path))
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,510 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psbt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/toole-brendan/shell/txscript"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// MaybeFinalize finalizes input inIndex if its signatures satisfy it,
+// returning whether the input is finalized
+func MaybeFinalize(p *Packet, inIndex int) (bool, error) {
+	if inIndex >= 0 && inIndex < len(p.Inputs) &&
+		p.Inputs[inIndex].isFinalized() {
+
+		return true, nil
+	}
+
+	err := Finalize(p, inIndex)
+	switch {
+	case errors.Is(err, ErrNotFinalizable):
+		return false, nil
+
+	case err != nil:
+		return false, err
+	}
+
+	return true, nil
+}
+
+// MaybeFinalizeAll finalizes every input of the Packet, returning
+// ErrNotFinalizable if any of them lacks signatures
+func MaybeFinalizeAll(p *Packet) error {
+	for i := range p.Inputs {
+		finalized, err := MaybeFinalize(p, i)
+		if err != nil {
+			return err
+		}
+		if !finalized {
+			return fmt.Errorf("input %d: %w", i, ErrNotFinalizable)
+		}
+	}
+
+	return nil
+}
+
+// Finalize builds the final script sig or witness of input inIndex from its
+// signatures. Taproot inputs are finalized with the key spend signature if
+// present, then with the first Shell leaf and finally the first tapscript
+// leaf the signatures satisfy.
+//
+// Once finalized, only the UTXO, final fields, blinding data and unknowns
+// of the input are kept
+func Finalize(p *Packet, inIndex int) error {
+	prevOut, err := p.PrevOut(inIndex)
+	if err != nil {
+		return err
+	}
+
+	pi := &p.Inputs[inIndex]
+	if pi.isFinalized() {
+		return ErrInputAlreadyFinalized
+	}
+
+	var (
+		sigScript []byte
+		witness   wire.TxWitness
+	)
+	pkScript := prevOut.PkScript
+	switch {
+	case txscript.IsPayToTaproot(pkScript):
+		witness, err = finalizeTaproot(pi)
+
+	case txscript.IsPayToWitnessPubKeyHash(pkScript):
+		witness, err = finalizeWitnessKeyHash(pi, pkScript[2:])
+
+	case txscript.IsPayToWitnessScriptHash(pkScript):
+		witness, err = finalizeWitnessScriptHash(pi, pkScript[2:])
+
+	case txscript.IsPayToScriptHash(pkScript):
+		sigScript, witness, err = finalizeScriptHash(pi, pkScript[2:22])
+
+	case txscript.IsPayToPubKeyHash(pkScript):
+		var stack [][]byte
+		stack, err = finalizeKeyHash(pi, pkScript[3:23])
+		if err == nil {
+			sigScript, err = pushStack(stack)
+		}
+
+	default:
+		return fmt.Errorf("%w: unsupported output script %x",
+			ErrNotFinalizable, pkScript)
+	}
+	if err != nil {
+		return err
+	}
+
+	finalized := PInput{
+		NonWitnessUtxo: pi.NonWitnessUtxo,
+		WitnessUtxo:    pi.WitnessUtxo,
+		FinalScriptSig: sigScript,
+		Blinding:       pi.Blinding,
+		Unknowns:       pi.Unknowns,
+	}
+	if witness != nil {
+		var buf bytes.Buffer
+		if err := writeWitness(&buf, witness); err != nil {
+			return err
+		}
+		finalized.FinalScriptWitness = buf.Bytes()
+	}
+
+	// A legacy input satisfied by an empty script sig still needs a final
+	// field to be considered finalized.
+	if finalized.FinalScriptSig == nil && finalized.FinalScriptWitness == nil {
+		finalized.FinalScriptSig = []byte{}
+	}
+
+	*pi = finalized
+	return nil
+}
+
+// Extract returns the signed transaction of a Packet whose inputs are all
+// finalized
+func Extract(p *Packet) (*wire.MsgTx, error) {
+	if !p.IsComplete() {
+		return nil, ErrIncompletePSBT
+	}
+
+	tx := p.UnsignedTx.Copy()
+	for i, txIn := range tx.TxIn {
+		pi := &p.Inputs[i]
+		txIn.SignatureScript = pi.FinalScriptSig
+
+		if pi.FinalScriptWitness != nil {
+			witness, err := readWitness(pi.FinalScriptWitness)
+			if err != nil {
+				return nil, err
+			}
+			txIn.Witness = witness
+		}
+	}
+
+	return tx, nil
+}
+
+// finalizeTaproot builds the witness of a taproot input
+func finalizeTaproot(pi *PInput) (wire.TxWitness, error) {
+	if pi.TaprootKeySpendSig != nil {
+		return wire.TxWitness{pi.TaprootKeySpendSig}, nil
+	}
+
+	for _, leaf := range pi.ShellLeaves {
+		if witness, ok := pi.satisfyShellLeaf(leaf); ok {
+			return witness, nil
+		}
+	}
+
+	for _, leaf := range pi.TaprootLeafScript {
+		if leaf.LeafVersion != txscript.BaseLeafVersion {
+			continue
+		}
+		if witness, ok := pi.satisfyTapscript(leaf); ok {
+			return witness, nil
+		}
+	}
+
+	return nil, ErrNotFinalizable
+}
+
+// satisfyShellLeaf builds the witness spending a vault leaf. The unvault
+// path is used when enough hot key signatures are present, the clawback
+// path when the cold key signed
+func (pi *PInput) satisfyShellLeaf(leaf *ShellTapLeaf) (wire.TxWitness, bool) {
+	policy, err := leaf.VaultPolicy()
+	if err != nil {
+		return nil, false
+	}
+
+	leafHash := leaf.TapLeaf().TapHash()
+	witness := make(wire.TxWitness, 0, len(policy.HotKeys)+3)
+	numSigs := 0
+	for _, key := range policy.HotKeys {
+		sig := pi.tapscriptSig(schnorr.SerializePubKey(key), leafHash[:])
+		if sig == nil || numSigs == int(policy.Threshold) {
+			witness = append(witness, nil)
+			continue
+		}
+
+		witness = append(witness, sig)
+		numSigs++
+	}
+
+	if numSigs == int(policy.Threshold) {
+		return append(witness, []byte{byte(txscript.VaultPathUnvault)},
+			leaf.Leaf, leaf.ControlBlock), true
+	}
+
+	sig := pi.tapscriptSig(schnorr.SerializePubKey(policy.ColdKey),
+		leafHash[:])
+	if sig == nil {
+		return nil, false
+	}
+
+	return wire.TxWitness{sig, {byte(txscript.VaultPathClawback)},
+		leaf.Leaf, leaf.ControlBlock}, true
+}
+
+// satisfyTapscript builds the witness spending a tapscript leaf made of
+// single key checks, either all required or counted against a threshold
+func (pi *PInput) satisfyTapscript(leaf *TaprootTapLeafScript) (wire.TxWitness, bool) {
+	keys, required, ok := parseTapscriptKeys(leaf.Script)
+	if !ok {
+		return nil, false
+	}
+
+	// The first key checked consumes the top stack element, so signatures
+	// are pushed in reverse key order.
+	leafHash := leaf.TapLeaf().TapHash()
+	witness := make(wire.TxWitness, 0, len(keys)+2)
+	numSigs := 0
+	for i := len(keys) - 1; i >= 0; i-- {
+		sig := pi.tapscriptSig(keys[i], leafHash[:])
+		if sig == nil || numSigs == required {
+			witness = append(witness, nil)
+			continue
+		}
+
+		witness = append(witness, sig)
+		numSigs++
+	}
+
+	if numSigs < required {
+		return nil, false
+	}
+
+	return append(witness, leaf.Script, leaf.ControlBlock), true
+}
+
+// tapscriptSig returns the serialized signature from the x-only key for the
+// leaf, or nil if there is none
+func (pi *PInput) tapscriptSig(xOnlyPubKey, leafHash []byte) []byte {
+	for _, sig := range pi.TaprootScriptSpendSig {
+		if !bytes.Equal(sig.XOnlyPubKey, xOnlyPubKey) ||
+			!bytes.Equal(sig.LeafHash, leafHash) {
+
+			continue
+		}
+
+		if sig.SigHash == txscript.SigHashDefault {
+			return sig.Signature
+		}
+		return append(append([]byte(nil), sig.Signature...),
+			byte(sig.SigHash))
+	}
+
+	return nil
+}
+
+// parseTapscriptKeys matches the leaf script against the templates the
+// finalizer can satisfy and returns the x-only keys it checks, in order,
+// along with the number of signatures required:
+//
+//	<key> OP_CHECKSIG
+//	<key> OP_CHECKSIGVERIFY ... <key> OP_CHECKSIG
+//	<key> OP_CHECKSIG <key> OP_CHECKSIGADD ... <k> OP_NUMEQUAL
+func parseTapscriptKeys(script []byte) ([][]byte, int, bool) {
+	type token struct {
+		op   byte
+		data []byte
+	}
+
+	var tokens []token
+	tokenizer := txscript.MakeScriptTokenizer(0, script)
+	for tokenizer.Next() {
+		tokens = append(tokens, token{tokenizer.Opcode(), tokenizer.Data()})
+	}
+	if tokenizer.Err() != nil || len(tokens) < 2 {
+		return nil, 0, false
+	}
+
+	// Threshold template.
+	last := tokens[len(tokens)-1]
+	if last.op == txscript.OP_NUMEQUAL && len(tokens)%2 == 0 {
+		var required int
+		threshold := tokens[len(tokens)-2]
+		switch {
+		case txscript.IsSmallInt(threshold.op):
+			required = txscript.AsSmallInt(threshold.op)
+
+		case threshold.data != nil:
+			num, err := txscript.MakeScriptNum(threshold.data, true, 4)
+			if err != nil {
+				return nil, 0, false
+			}
+			required = int(num.Int32())
+		}
+
+		var keys [][]byte
+		for i := 0; i < len(tokens)-2; i += 2 {
+			op := byte(txscript.OP_CHECKSIGADD)
+			if i == 0 {
+				op = txscript.OP_CHECKSIG
+			}
+			if len(tokens[i].data) != 32 || tokens[i+1].op != op {
+				return nil, 0, false
+			}
+			keys = append(keys, tokens[i].data)
+		}
+
+		if required <= 0 || required > len(keys) {
+			return nil, 0, false
+		}
+		return keys, required, true
+	}
+
+	// Single key and conjunction templates.
+	if len(tokens)%2 != 0 {
+		return nil, 0, false
+	}
+
+	var keys [][]byte
+	for i := 0; i < len(tokens); i += 2 {
+		op := byte(txscript.OP_CHECKSIGVERIFY)
+		if i == len(tokens)-2 {
+			op = txscript.OP_CHECKSIG
+		}
+		if len(tokens[i].data) != 32 || tokens[i+1].op != op {
+			return nil, 0, false
+		}
+		keys = append(keys, tokens[i].data)
+	}
+
+	return keys, len(keys), true
+}
+
+// finalizeWitnessKeyHash builds the witness of a P2WPKH input
+func finalizeWitnessKeyHash(pi *PInput, keyHash []byte) (wire.TxWitness, error) {
+	stack, err := finalizeKeyHash(pi, keyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return wire.TxWitness(stack), nil
+}
+
+// finalizeWitnessScriptHash builds the witness of a P2WSH input
+func finalizeWitnessScriptHash(pi *PInput,
+	scriptHash []byte) (wire.TxWitness, error) {
+
+	if pi.WitnessScript == nil {
+		return nil, fmt.Errorf("%w: missing witness script",
+			ErrNotFinalizable)
+	}
+
+	hash := sha256.Sum256(pi.WitnessScript)
+	if !bytes.Equal(hash[:], scriptHash) {
+		return nil, errors.New("witness script does not match output")
+	}
+
+	stack, err := finalizeMultiSig(pi, pi.WitnessScript)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(wire.TxWitness(stack), pi.WitnessScript), nil
+}
+
+// finalizeScriptHash builds the script sig, and the witness of nested
+// segwit inputs, of a P2SH input
+func finalizeScriptHash(pi *PInput,
+	scriptHash []byte) ([]byte, wire.TxWitness, error) {
+
+	if pi.RedeemScript == nil {
+		return nil, nil, fmt.Errorf("%w: missing redeem script",
+			ErrNotFinalizable)
+	}
+	if !bytes.Equal(btcutil.Hash160(pi.RedeemScript), scriptHash) {
+		return nil, nil, errors.New("redeem script does not match output")
+	}
+
+	var (
+		witness wire.TxWitness
+		stack   [][]byte
+		err     error
+	)
+	redeemScript := pi.RedeemScript
+	switch {
+	case txscript.IsPayToWitnessPubKeyHash(redeemScript):
+		witness, err = finalizeWitnessKeyHash(pi, redeemScript[2:])
+
+	case txscript.IsPayToWitnessScriptHash(redeemScript):
+		witness, err = finalizeWitnessScriptHash(pi, redeemScript[2:])
+
+	default:
+		stack, err = finalizeMultiSig(pi, redeemScript)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sigScript, err := pushStack(append(stack, redeemScript))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sigScript, witness, nil
+}
+
+// finalizeKeyHash returns the signature and public key satisfying a pay to
+// public key hash script
+func finalizeKeyHash(pi *PInput, keyHash []byte) ([][]byte, error) {
+	for _, ps := range pi.PartialSigs {
+		if bytes.Equal(btcutil.Hash160(ps.PubKey), keyHash) {
+			return [][]byte{ps.Signature, ps.PubKey}, nil
+		}
+	}
+
+	return nil, ErrNotFinalizable
+}
+
+// finalizeMultiSig returns the stack satisfying an OP_CHECKMULTISIG script,
+// signatures ordered as their keys in the script
+func finalizeMultiSig(pi *PInput, script []byte) ([][]byte, error) {
+	isMultiSig, err := txscript.IsMultisigScript(script)
+	if err != nil || !isMultiSig {
+		return nil, fmt.Errorf("%w: unsupported script %x",
+			ErrNotFinalizable, script)
+	}
+
+	numPubKeys, required, err := txscript.CalcMultiSigStats(script)
+	if err != nil {
+		return nil, err
+	}
+
+	// The first element is the dummy consumed by OP_CHECKMULTISIG.
+	stack := [][]byte{nil}
+	tokenizer := txscript.MakeScriptTokenizer(0, script)
+	tokenizer.Next()
+	for i := 0; i < numPubKeys && tokenizer.Next(); i++ {
+		for _, ps := range pi.PartialSigs {
+			if len(stack)-1 == required {
+				break
+			}
+			if bytes.Equal(ps.PubKey, tokenizer.Data()) {
+				stack = append(stack, ps.Signature)
+				break
+			}
+		}
+	}
+
+	if len(stack)-1 < required {
+		return nil, ErrNotFinalizable
+	}
+
+	return stack, nil
+}
+
+// pushStack returns a script pushing each element of stack
+func pushStack(stack [][]byte) ([]byte, error) {
+	builder := txscript.NewScriptBuilder()
+	for _, data := range stack {
+		builder.AddData(data)
+	}
+
+	return builder.Script()
+}
+
+// writeWitness serializes a witness as it appears in a transaction
+func writeWitness(buf *bytes.Buffer, witness wire.TxWitness) error {
+	if err := wire.WriteVarInt(buf, 0, uint64(len(witness))); err != nil {
+		return err
+	}
+	for _, item := range witness {
+		if err := wire.WriteVarBytes(buf, 0, item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readWitness decodes a serialized witness
+func readWitness(raw []byte) (wire.TxWitness, error) {
+	r := bytes.NewReader(raw)
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil || count > uint64(len(raw)) {
+		return nil, ErrInvalidValue
+	}
+
+	witness := make(wire.TxWitness, count)
+	for i := range witness {
+		witness[i], err = wire.ReadVarBytes(r, 0, MaxPsbtValueLength,
+			"witness item")
+		if err != nil {
+			return nil, ErrInvalidValue
+		}
+	}
+
+	return witness, nil
+}
@@ -0,0 +1,434 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/toole-brendan/shell/txscript"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// PInput holds the data needed to sign and finalize an input
+type PInput struct {
+	NonWitnessUtxo         *wire.MsgTx
+	WitnessUtxo            *wire.TxOut
+	PartialSigs            []*PartialSig
+	SighashType            txscript.SigHashType
+	RedeemScript           []byte
+	WitnessScript          []byte
+	Bip32Derivation        []*Bip32Derivation
+	FinalScriptSig         []byte
+	FinalScriptWitness     []byte
+	TaprootKeySpendSig     []byte
+	TaprootScriptSpendSig  []*TaprootScriptSpendSig
+	TaprootLeafScript      []*TaprootTapLeafScript
+	TaprootBip32Derivation []*TaprootBip32Derivation
+	TaprootInternalKey     []byte
+	TaprootMerkleRoot      []byte
+
+	// ShellLeaves are the leaves committed under a Shell leaf version the
+	// input can be spent with
+	ShellLeaves []*ShellTapLeaf
+
+	// Blinding opens the commitment of the confidential output spent by
+	// the input
+	Blinding *BlindingData
+
+	Unknowns []*Unknown
+}
+
+// isFinalized returns true if the input has a final script sig or witness
+func (pi *PInput) isFinalized() bool {
+	return pi.FinalScriptSig != nil || pi.FinalScriptWitness != nil
+}
+
+// deserialize decodes the map of an input from r
+func (pi *PInput) deserialize(r io.Reader) error {
+	seen := make(map[string]struct{})
+	for {
+		keyType, keyData, err := getKey(r)
+		if err != nil {
+			return err
+		}
+		if keyType == -1 {
+			return nil
+		}
+
+		value, err := readValue(r)
+		if err != nil {
+			return err
+		}
+
+		fullKey := append([]byte{byte(keyType)}, keyData...)
+		if _, ok := seen[string(fullKey)]; ok {
+			return ErrDuplicateKey
+		}
+		seen[string(fullKey)] = struct{}{}
+
+		if err := pi.readField(InputType(keyType), keyData, value); err != nil {
+			if err != errUnknownSubType {
+				return err
+			}
+
+			pi.Unknowns = append(pi.Unknowns, &Unknown{
+				Key:   fullKey,
+				Value: value,
+			})
+		}
+	}
+}
+
+// readField decodes a single field of the input, returning
+// errUnknownSubType for fields that should be kept as unknowns
+func (pi *PInput) readField(keyType InputType, keyData, value []byte) error {
+	// Input fields not keyed by a public key, signature or control
+	// block have no key data.
+	switch keyType {
+	case NonWitnessUtxoType, WitnessUtxoType, SighashType,
+		RedeemScriptInputType, WitnessScriptInputType, FinalScriptSigType,
+		FinalScriptWitnessType, TaprootKeySpendSignatureType,
+		TaprootInternalKeyInputType, TaprootMerkleRootType:
+
+		if len(keyData) != 0 {
+			return ErrInvalidKeyData
+		}
+	}
+
+	switch keyType {
+	case NonWitnessUtxoType:
+		tx := wire.NewMsgTx(2)
+		if err := tx.Deserialize(bytes.NewReader(value)); err != nil {
+			return ErrInvalidValue
+		}
+		pi.NonWitnessUtxo = tx
+
+	case WitnessUtxoType:
+		var txOut wire.TxOut
+		err := wire.ReadTxOut(bytes.NewReader(value), 0, 0, &txOut)
+		if err != nil {
+			return ErrInvalidValue
+		}
+		pi.WitnessUtxo = &txOut
+
+	case PartialSigType:
+		if _, err := btcec.ParsePubKey(keyData); err != nil {
+			return ErrInvalidKeyData
+		}
+		pi.PartialSigs = append(pi.PartialSigs, &PartialSig{
+			PubKey:    keyData,
+			Signature: value,
+		})
+
+	case SighashType:
+		if len(value) != 4 {
+			return ErrInvalidValue
+		}
+		pi.SighashType = txscript.SigHashType(binary.LittleEndian.Uint32(value))
+
+	case RedeemScriptInputType:
+		pi.RedeemScript = value
+
+	case WitnessScriptInputType:
+		pi.WitnessScript = value
+
+	case Bip32DerivationInputType:
+		if _, err := btcec.ParsePubKey(keyData); err != nil {
+			return ErrInvalidKeyData
+		}
+		fingerprint, path, err := readBip32Derivation(value)
+		if err != nil {
+			return err
+		}
+		pi.Bip32Derivation = append(pi.Bip32Derivation, &Bip32Derivation{
+			PubKey:               keyData,
+			MasterKeyFingerprint: fingerprint,
+			Bip32Path:            path,
+		})
+
+	case FinalScriptSigType:
+		pi.FinalScriptSig = value
+
+	case FinalScriptWitnessType:
+		pi.FinalScriptWitness = value
+
+	case TaprootKeySpendSignatureType:
+		if _, err := checkSchnorrSig(value); err != nil {
+			return err
+		}
+		pi.TaprootKeySpendSig = value
+
+	case TaprootScriptSpendSignatureType:
+		if len(keyData) != 64 {
+			return ErrInvalidKeyData
+		}
+		if err := checkXOnlyPubKey(keyData[:32]); err != nil {
+			return err
+		}
+		sigHash, err := checkSchnorrSig(value)
+		if err != nil {
+			return err
+		}
+		pi.TaprootScriptSpendSig = append(pi.TaprootScriptSpendSig,
+			&TaprootScriptSpendSig{
+				XOnlyPubKey: keyData[:32],
+				LeafHash:    keyData[32:],
+				Signature:   value[:schnorr.SignatureSize],
+				SigHash:     sigHash,
+			})
+
+	case TaprootLeafScriptType:
+		leafVersion, err := checkControlBlock(keyData)
+		if err != nil {
+			return err
+		}
+		if len(value) < 1 ||
+			txscript.TapscriptLeafVersion(value[len(value)-1]) != leafVersion {
+
+			return ErrInvalidValue
+		}
+		pi.TaprootLeafScript = append(pi.TaprootLeafScript,
+			&TaprootTapLeafScript{
+				ControlBlock: keyData,
+				Script:       value[:len(value)-1],
+				LeafVersion:  leafVersion,
+			})
+
+	case TaprootBip32DerivationInputType:
+		if err := checkXOnlyPubKey(keyData); err != nil {
+			return err
+		}
+		derivation, err := readTaprootBip32Derivation(keyData, value)
+		if err != nil {
+			return err
+		}
+		pi.TaprootBip32Derivation = append(pi.TaprootBip32Derivation,
+			derivation)
+
+	case TaprootInternalKeyInputType:
+		if err := checkXOnlyPubKey(value); err != nil {
+			return ErrInvalidValue
+		}
+		pi.TaprootInternalKey = value
+
+	case TaprootMerkleRootType:
+		if len(value) != 32 {
+			return ErrInvalidValue
+		}
+		pi.TaprootMerkleRoot = value
+
+	case ProprietaryInputType:
+		identifier, subType, subKeyData, err := parseProprietaryKey(keyData)
+		if err != nil {
+			return err
+		}
+		if identifier != ShellProprietaryID {
+			return errUnknownSubType
+		}
+		return pi.readShellInputField(subType, subKeyData, value)
+
+	default:
+		return errUnknownSubType
+	}
+
+	return nil
+}
+
+// serialize writes the map of the input to w, without the separator
+func (pi *PInput) serialize(w io.Writer) error {
+	if pi.NonWitnessUtxo != nil {
+		var buf bytes.Buffer
+		if err := pi.NonWitnessUtxo.Serialize(&buf); err != nil {
+			return err
+		}
+		err := serializeKVPairWithType(w, uint8(NonWitnessUtxoType), nil,
+			buf.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	if pi.WitnessUtxo != nil {
+		var buf bytes.Buffer
+		if err := wire.WriteTxOut(&buf, 0, 0, pi.WitnessUtxo); err != nil {
+			return err
+		}
+		err := serializeKVPairWithType(w, uint8(WitnessUtxoType), nil,
+			buf.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	// Fields left over once an input is finalized are written alone.
+	if !pi.isFinalized() {
+		if err := pi.serializeSigningFields(w); err != nil {
+			return err
+		}
+	}
+
+	if pi.FinalScriptSig != nil {
+		err := serializeKVPairWithType(w, uint8(FinalScriptSigType), nil,
+			pi.FinalScriptSig)
+		if err != nil {
+			return err
+		}
+	}
+
+	if pi.FinalScriptWitness != nil {
+		err := serializeKVPairWithType(w, uint8(FinalScriptWitnessType), nil,
+			pi.FinalScriptWitness)
+		if err != nil {
+			return err
+		}
+	}
+
+	if pi.Blinding != nil {
+		err := serializeShellField(w, uint8(ProprietaryInputType),
+			uint64(ShellInputBlindingSubType), nil, pi.Blinding.serialize())
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, kv := range pi.Unknowns {
+		if err := serializeKVPair(w, kv.Key, kv.Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// serializeSigningFields writes the fields of the input that are only
+// needed until it is finalized
+func (pi *PInput) serializeSigningFields(w io.Writer) error {
+	sort.Slice(pi.PartialSigs, func(i, j int) bool {
+		return bytes.Compare(pi.PartialSigs[i].PubKey,
+			pi.PartialSigs[j].PubKey) < 0
+	})
+	for _, ps := range pi.PartialSigs {
+		err := serializeKVPairWithType(w, uint8(PartialSigType), ps.PubKey,
+			ps.Signature)
+		if err != nil {
+			return err
+		}
+	}
+
+	if pi.SighashType != 0 {
+		var value [4]byte
+		binary.LittleEndian.PutUint32(value[:], uint32(pi.SighashType))
+		err := serializeKVPairWithType(w, uint8(SighashType), nil, value[:])
+		if err != nil {
+			return err
+		}
+	}
+
+	if pi.RedeemScript != nil {
+		err := serializeKVPairWithType(w, uint8(RedeemScriptInputType), nil,
+			pi.RedeemScript)
+		if err != nil {
+			return err
+		}
+	}
+
+	if pi.WitnessScript != nil {
+		err := serializeKVPairWithType(w, uint8(WitnessScriptInputType), nil,
+			pi.WitnessScript)
+		if err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(pi.Bip32Derivation, func(i, j int) bool {
+		return bytes.Compare(pi.Bip32Derivation[i].PubKey,
+			pi.Bip32Derivation[j].PubKey) < 0
+	})
+	for _, d := range pi.Bip32Derivation {
+		err := serializeKVPairWithType(w, uint8(Bip32DerivationInputType),
+			d.PubKey, serializeBip32Derivation(d.MasterKeyFingerprint,
+				d.Bip32Path))
+		if err != nil {
+			return err
+		}
+	}
+
+	if pi.TaprootKeySpendSig != nil {
+		err := serializeKVPairWithType(w,
+			uint8(TaprootKeySpendSignatureType), nil, pi.TaprootKeySpendSig)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, sig := range pi.TaprootScriptSpendSig {
+		keyData := append(append([]byte(nil), sig.XOnlyPubKey...),
+			sig.LeafHash...)
+		value := sig.Signature
+		if sig.SigHash != txscript.SigHashDefault {
+			value = append(append([]byte(nil), value...), byte(sig.SigHash))
+		}
+		err := serializeKVPairWithType(w,
+			uint8(TaprootScriptSpendSignatureType), keyData, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, leaf := range pi.TaprootLeafScript {
+		value := append(append([]byte(nil), leaf.Script...),
+			byte(leaf.LeafVersion))
+		err := serializeKVPairWithType(w, uint8(TaprootLeafScriptType),
+			leaf.ControlBlock, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, d := range pi.TaprootBip32Derivation {
+		value, err := serializeTaprootBip32Derivation(d)
+		if err != nil {
+			return err
+		}
+		err = serializeKVPairWithType(w,
+			uint8(TaprootBip32DerivationInputType), d.XOnlyPubKey, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	if pi.TaprootInternalKey != nil {
+		err := serializeKVPairWithType(w, uint8(TaprootInternalKeyInputType),
+			nil, pi.TaprootInternalKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	if pi.TaprootMerkleRoot != nil {
+		err := serializeKVPairWithType(w, uint8(TaprootMerkleRootType), nil,
+			pi.TaprootMerkleRoot)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, leaf := range pi.ShellLeaves {
+		value := append(append([]byte(nil), leaf.Leaf...),
+			byte(leaf.LeafVersion))
+		err := serializeShellField(w, uint8(ProprietaryInputType),
+			uint64(ShellInputTapLeafSubType), leaf.ControlBlock, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psbt
+
+import (
+	"bytes"
+	"io"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/toole-brendan/shell/privacy/confidential"
+)
+
+// POutput holds the data describing an output
+type POutput struct {
+	RedeemScript           []byte
+	WitnessScript          []byte
+	Bip32Derivation        []*Bip32Derivation
+	TaprootInternalKey     []byte
+	TaprootTapTree         []TaprootTapLeaf
+	TaprootBip32Derivation []*TaprootBip32Derivation
+
+	// Blinding holds the value and blinding factor of a confidential
+	// output
+	Blinding *BlindingData
+
+	// Commitment is the Pedersen commitment of a confidential output
+	Commitment *confidential.PedersenCommitment
+
+	// RangeProof proves the committed value of a confidential output is in
+	// range
+	RangeProof *confidential.RangeProof
+
+	Unknowns []*Unknown
+}
+
+// deserialize decodes the map of an output from r
+func (po *POutput) deserialize(r io.Reader) error {
+	seen := make(map[string]struct{})
+	for {
+		keyType, keyData, err := getKey(r)
+		if err != nil {
+			return err
+		}
+		if keyType == -1 {
+			return nil
+		}
+
+		value, err := readValue(r)
+		if err != nil {
+			return err
+		}
+
+		fullKey := append([]byte{byte(keyType)}, keyData...)
+		if _, ok := seen[string(fullKey)]; ok {
+			return ErrDuplicateKey
+		}
+		seen[string(fullKey)] = struct{}{}
+
+		if err := po.readField(OutputType(keyType), keyData, value); err != nil {
+			if err != errUnknownSubType {
+				return err
+			}
+
+			po.Unknowns = append(po.Unknowns, &Unknown{
+				Key:   fullKey,
+				Value: value,
+			})
+		}
+	}
+}
+
+// readField decodes a single field of the output, returning
+// errUnknownSubType for fields that should be kept as unknowns
+func (po *POutput) readField(keyType OutputType, keyData, value []byte) error {
+	switch keyType {
+	case RedeemScriptOutputType, WitnessScriptOutputType,
+		TaprootInternalKeyOutputType, TaprootTapTreeType:
+
+		if len(keyData) != 0 {
+			return ErrInvalidKeyData
+		}
+	}
+
+	switch keyType {
+	case RedeemScriptOutputType:
+		po.RedeemScript = value
+
+	case WitnessScriptOutputType:
+		po.WitnessScript = value
+
+	case Bip32DerivationOutputType:
+		if _, err := btcec.ParsePubKey(keyData); err != nil {
+			return ErrInvalidKeyData
+		}
+		fingerprint, path, err := readBip32Derivation(value)
+		if err != nil {
+			return err
+		}
+		po.Bip32Derivation = append(po.Bip32Derivation, &Bip32Derivation{
+			PubKey:               keyData,
+			MasterKeyFingerprint: fingerprint,
+			Bip32Path:            path,
+		})
+
+	case TaprootInternalKeyOutputType:
+		if err := checkXOnlyPubKey(value); err != nil {
+			return ErrInvalidValue
+		}
+		po.TaprootInternalKey = value
+
+	case TaprootTapTreeType:
+		leaves, err := readTapTree(value)
+		if err != nil {
+			return err
+		}
+		po.TaprootTapTree = leaves
+
+	case TaprootBip32DerivationOutputType:
+		if err := checkXOnlyPubKey(keyData); err != nil {
+			return err
+		}
+		derivation, err := readTaprootBip32Derivation(keyData, value)
+		if err != nil {
+			return err
+		}
+		po.TaprootBip32Derivation = append(po.TaprootBip32Derivation,
+			derivation)
+
+	case ProprietaryOutputType:
+		identifier, subType, subKeyData, err := parseProprietaryKey(keyData)
+		if err != nil {
+			return err
+		}
+		if identifier != ShellProprietaryID {
+			return errUnknownSubType
+		}
+		return po.readShellOutputField(subType, subKeyData, value)
+
+	default:
+		return errUnknownSubType
+	}
+
+	return nil
+}
+
+// serialize writes the map of the output to w, without the separator
+func (po *POutput) serialize(w io.Writer) error {
+	if po.RedeemScript != nil {
+		err := serializeKVPairWithType(w, uint8(RedeemScriptOutputType), nil,
+			po.RedeemScript)
+		if err != nil {
+			return err
+		}
+	}
+
+	if po.WitnessScript != nil {
+		err := serializeKVPairWithType(w, uint8(WitnessScriptOutputType), nil,
+			po.WitnessScript)
+		if err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(po.Bip32Derivation, func(i, j int) bool {
+		return bytes.Compare(po.Bip32Derivation[i].PubKey,
+			po.Bip32Derivation[j].PubKey) < 0
+	})
+	for _, d := range po.Bip32Derivation {
+		err := serializeKVPairWithType(w, uint8(Bip32DerivationOutputType),
+			d.PubKey, serializeBip32Derivation(d.MasterKeyFingerprint,
+				d.Bip32Path))
+		if err != nil {
+			return err
+		}
+	}
+
+	if po.TaprootInternalKey != nil {
+		err := serializeKVPairWithType(w, uint8(TaprootInternalKeyOutputType),
+			nil, po.TaprootInternalKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	if po.TaprootTapTree != nil {
+		value, err := serializeTapTree(po.TaprootTapTree)
+		if err != nil {
+			return err
+		}
+		err = serializeKVPairWithType(w, uint8(TaprootTapTreeType), nil,
+			value)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, d := range po.TaprootBip32Derivation {
+		value, err := serializeTaprootBip32Derivation(d)
+		if err != nil {
+			return err
+		}
+		err = serializeKVPairWithType(w,
+			uint8(TaprootBip32DerivationOutputType), d.XOnlyPubKey, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := po.serializeShellFields(w); err != nil {
+		return err
+	}
+
+	for _, kv := range po.Unknowns {
+		if err := serializeKVPair(w, kv.Key, kv.Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
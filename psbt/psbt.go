@@ -0,0 +1,433 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package psbt implements BIP 174 partially signed transactions for Shell,
+// including the BIP 371 taproot fields and proprietary fields describing
+// Shell tapscript leaves and confidential blinding data.
+package psbt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+
+	"github.com/toole-brendan/shell/wire"
+)
+
+const (
+	// MaxPsbtValueLength is the size of the largest value a PSBT will
+	// accept, large enough for any standard transaction
+	MaxPsbtValueLength = 4000000
+
+	// MaxPsbtKeyLength is the size of the largest key a PSBT will accept
+	MaxPsbtKeyLength = 10000
+
+	// psbtVersion is the only PSBT version supported
+	psbtVersion = 0
+)
+
+// psbtMagic is the separator prefixing every serialized PSBT
+var psbtMagic = [5]byte{0x70, 0x73, 0x62, 0x74, 0xff} // "psbt" + 0xff
+
+var (
+	// ErrInvalidMagicBytes is returned when the PSBT does not start with
+	// the magic bytes
+	ErrInvalidMagicBytes = errors.New("invalid magic bytes")
+
+	// ErrInvalidPsbtFormat is returned when the PSBT is not encoded as a
+	// series of key-value maps
+	ErrInvalidPsbtFormat = errors.New("invalid PSBT serialization format")
+
+	// ErrDuplicateKey is returned when a key appears twice in a map
+	ErrDuplicateKey = errors.New("invalid PSBT due to duplicate key")
+
+	// ErrInvalidKeyData is returned when the key data of a field does not
+	// match its type
+	ErrInvalidKeyData = errors.New("invalid key data")
+
+	// ErrInvalidValue is returned when the value of a field cannot be
+	// decoded
+	ErrInvalidValue = errors.New("invalid PSBT value")
+
+	// ErrUnsupportedVersion is returned for PSBT versions other than 0
+	ErrUnsupportedVersion = errors.New("unsupported PSBT version")
+
+	// ErrInvalidUnsignedTx is returned when the unsigned transaction is
+	// missing or carries signature data
+	ErrInvalidUnsignedTx = errors.New("invalid unsigned transaction")
+
+	// ErrInvalidPrevOutNonWitnessTransaction is returned when the non
+	// witness UTXO of an input does not match its previous outpoint
+	ErrInvalidPrevOutNonWitnessTransaction = errors.New("prevout hash " +
+		"does not match the provided non-witness UTXO serialization")
+
+	// ErrInputAlreadyFinalized is returned when an input is modified after
+	// being finalized
+	ErrInputAlreadyFinalized = errors.New("input is already finalized")
+
+	// ErrNotFinalizable is returned when the signatures of an input do not
+	// satisfy its script
+	ErrNotFinalizable = errors.New("input cannot be finalized")
+
+	// ErrIncompletePSBT is returned when extracting a transaction with
+	// inputs that are not finalized
+	ErrIncompletePSBT = errors.New("PSBT cannot be extracted as it is " +
+		"incomplete")
+)
+
+// Unknown is a key-value pair the package does not interpret. Unknowns are
+// kept so they survive a round trip through this package
+type Unknown struct {
+	Key   []byte
+	Value []byte
+}
+
+// Packet is a partially signed transaction: an unsigned transaction along
+// with the data needed to sign and finalize each of its inputs
+type Packet struct {
+	// UnsignedTx is the transaction being signed, without any signature
+	// scripts or witnesses
+	UnsignedTx *wire.MsgTx
+
+	// Inputs holds the signing data of each input of UnsignedTx
+	Inputs []PInput
+
+	// Outputs holds the data describing each output of UnsignedTx
+	Outputs []POutput
+
+	// Unknowns are the global fields not interpreted by this package
+	Unknowns []*Unknown
+}
+
+// New creates a Packet spending inputs to outputs
+func New(inputs []*wire.OutPoint, outputs []*wire.TxOut, version int32,
+	lockTime uint32, sequences []uint32) (*Packet, error) {
+
+	if len(sequences) != len(inputs) {
+		return nil, errors.New("a sequence is required for every input")
+	}
+
+	tx := wire.NewMsgTx(version)
+	tx.LockTime = lockTime
+	for i, in := range inputs {
+		txIn := wire.NewTxIn(in, nil, nil)
+		txIn.Sequence = sequences[i]
+		tx.AddTxIn(txIn)
+	}
+	for _, out := range outputs {
+		tx.AddTxOut(out)
+	}
+
+	return NewFromUnsignedTx(tx)
+}
+
+// NewFromUnsignedTx creates a Packet for tx, which must not carry any
+// signature scripts or witnesses
+func NewFromUnsignedTx(tx *wire.MsgTx) (*Packet, error) {
+	if err := checkUnsignedTx(tx); err != nil {
+		return nil, err
+	}
+
+	return &Packet{
+		UnsignedTx: tx,
+		Inputs:     make([]PInput, len(tx.TxIn)),
+		Outputs:    make([]POutput, len(tx.TxOut)),
+	}, nil
+}
+
+// NewFromRawBytes decodes a Packet from r, which holds either the binary
+// serialization or, if b64 is set, its base64 encoding
+func NewFromRawBytes(r io.Reader, b64 bool) (*Packet, error) {
+	if b64 {
+		raw, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, r))
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(raw)
+	}
+
+	var magic [5]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, err
+	}
+	if magic != psbtMagic {
+		return nil, ErrInvalidMagicBytes
+	}
+
+	var (
+		p       Packet
+		version *uint32
+		seen    = make(map[string]struct{})
+	)
+	for {
+		keyType, keyData, err := getKey(r)
+		if err != nil {
+			return nil, err
+		}
+		if keyType == -1 {
+			break
+		}
+
+		value, err := readValue(r)
+		if err != nil {
+			return nil, err
+		}
+
+		fullKey := string(append([]byte{byte(keyType)}, keyData...))
+		if _, ok := seen[fullKey]; ok {
+			return nil, ErrDuplicateKey
+		}
+		seen[fullKey] = struct{}{}
+
+		switch GlobalType(keyType) {
+		case UnsignedTxType:
+			if len(keyData) != 0 {
+				return nil, ErrInvalidKeyData
+			}
+
+			tx := wire.NewMsgTx(2)
+			err := tx.DeserializeNoWitness(bytes.NewReader(value))
+			if err != nil {
+				return nil, err
+			}
+			p.UnsignedTx = tx
+
+		case VersionType:
+			if len(keyData) != 0 || len(value) != 4 {
+				return nil, ErrInvalidKeyData
+			}
+
+			v := uint32(value[0]) | uint32(value[1])<<8 |
+				uint32(value[2])<<16 | uint32(value[3])<<24
+			version = &v
+
+		default:
+			p.Unknowns = append(p.Unknowns, &Unknown{
+				Key:   []byte(fullKey),
+				Value: value,
+			})
+		}
+	}
+
+	if p.UnsignedTx == nil {
+		return nil, ErrInvalidUnsignedTx
+	}
+	if version != nil && *version != psbtVersion {
+		return nil, ErrUnsupportedVersion
+	}
+	if err := checkUnsignedTx(p.UnsignedTx); err != nil {
+		return nil, err
+	}
+
+	p.Inputs = make([]PInput, len(p.UnsignedTx.TxIn))
+	for i := range p.Inputs {
+		if err := p.Inputs[i].deserialize(r); err != nil {
+			return nil, err
+		}
+	}
+
+	p.Outputs = make([]POutput, len(p.UnsignedTx.TxOut))
+	for i := range p.Outputs {
+		if err := p.Outputs[i].deserialize(r); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.SanityCheck(); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// Serialize writes the binary serialization of the Packet to w
+func (p *Packet) Serialize(w io.Writer) error {
+	if _, err := w.Write(psbtMagic[:]); err != nil {
+		return err
+	}
+
+	var tx bytes.Buffer
+	if err := p.UnsignedTx.SerializeNoWitness(&tx); err != nil {
+		return err
+	}
+	err := serializeKVPairWithType(w, uint8(UnsignedTxType), nil, tx.Bytes())
+	if err != nil {
+		return err
+	}
+
+	for _, kv := range p.Unknowns {
+		if err := serializeKVPair(w, kv.Key, kv.Value); err != nil {
+			return err
+		}
+	}
+
+	if _, err := w.Write([]byte{0x00}); err != nil {
+		return err
+	}
+
+	for i := range p.Inputs {
+		if err := p.Inputs[i].serialize(w); err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte{0x00}); err != nil {
+			return err
+		}
+	}
+
+	for i := range p.Outputs {
+		if err := p.Outputs[i].serialize(w); err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte{0x00}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// B64Encode returns the base64 encoding of the serialized Packet
+func (p *Packet) B64Encode() (string, error) {
+	var b bytes.Buffer
+	if err := p.Serialize(&b); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(b.Bytes()), nil
+}
+
+// IsComplete returns true if every input of the Packet has been finalized
+func (p *Packet) IsComplete() bool {
+	for i := range p.Inputs {
+		if !p.Inputs[i].isFinalized() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SanityCheck checks the Packet for internal consistency
+func (p *Packet) SanityCheck() error {
+	if err := checkUnsignedTx(p.UnsignedTx); err != nil {
+		return err
+	}
+
+	if len(p.Inputs) != len(p.UnsignedTx.TxIn) ||
+		len(p.Outputs) != len(p.UnsignedTx.TxOut) {
+
+		return ErrInvalidPsbtFormat
+	}
+
+	for i := range p.Inputs {
+		in := &p.Inputs[i]
+		if in.NonWitnessUtxo != nil {
+			prevOut := p.UnsignedTx.TxIn[i].PreviousOutPoint
+			if in.NonWitnessUtxo.TxHash() != prevOut.Hash ||
+				prevOut.Index >= uint32(len(in.NonWitnessUtxo.TxOut)) {
+
+				return ErrInvalidPrevOutNonWitnessTransaction
+			}
+		}
+
+		if err := in.checkShellFields(); err != nil {
+			return err
+		}
+	}
+
+	for i := range p.Outputs {
+		if err := p.Outputs[i].checkShellFields(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PrevOut returns the output spent by input inIndex, taken from the witness
+// UTXO or, failing that, the non-witness UTXO
+func (p *Packet) PrevOut(inIndex int) (*wire.TxOut, error) {
+	if inIndex < 0 || inIndex >= len(p.Inputs) {
+		return nil, errors.New("input index out of range")
+	}
+
+	in := &p.Inputs[inIndex]
+	switch {
+	case in.WitnessUtxo != nil:
+		return in.WitnessUtxo, nil
+
+	case in.NonWitnessUtxo != nil:
+		index := p.UnsignedTx.TxIn[inIndex].PreviousOutPoint.Index
+		return in.NonWitnessUtxo.TxOut[index], nil
+
+	default:
+		return nil, errors.New("input has no UTXO information")
+	}
+}
+
+// checkUnsignedTx makes sure tx is present and has no signature data
+func checkUnsignedTx(tx *wire.MsgTx) error {
+	if tx == nil {
+		return ErrInvalidUnsignedTx
+	}
+
+	for _, txIn := range tx.TxIn {
+		if len(txIn.SignatureScript) != 0 || len(txIn.Witness) != 0 {
+			return ErrInvalidUnsignedTx
+		}
+	}
+
+	return nil
+}
+
+// getKey reads the next key of a map, returning its type and key data. The
+// zero length key ending a map is returned as a type of -1
+func getKey(r io.Reader) (int, []byte, error) {
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return -1, nil, ErrInvalidPsbtFormat
+	}
+	if count == 0 {
+		return -1, nil, nil
+	}
+	if count > MaxPsbtKeyLength {
+		return -1, nil, ErrInvalidKeyData
+	}
+
+	key := make([]byte, count)
+	if _, err := io.ReadFull(r, key); err != nil {
+		return -1, nil, ErrInvalidPsbtFormat
+	}
+
+	return int(key[0]), key[1:], nil
+}
+
+// readValue reads the value following a key
+func readValue(r io.Reader) ([]byte, error) {
+	value, err := wire.ReadVarBytes(r, 0, MaxPsbtValueLength, "PSBT value")
+	if err != nil {
+		return nil, ErrInvalidPsbtFormat
+	}
+
+	return value, nil
+}
+
+// serializeKVPair writes a key-value pair with length prefixes
+func serializeKVPair(w io.Writer, key, value []byte) error {
+	if err := wire.WriteVarBytes(w, 0, key); err != nil {
+		return err
+	}
+
+	return wire.WriteVarBytes(w, 0, value)
+}
+
+// serializeKVPairWithType writes a key-value pair whose key is the key type
+// followed by the key data
+func serializeKVPairWithType(w io.Writer, keyType uint8, keyData,
+	value []byte) error {
+
+	key := append([]byte{keyType}, keyData...)
+	return serializeKVPair(w, key, value)
+}
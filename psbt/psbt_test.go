@@ -0,0 +1,406 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psbt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/privacy/confidential"
+	"github.com/toole-brendan/shell/txscript"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// vaultFixture is a vault output along with the keys controlling it
+type vaultFixture struct {
+	hot     []*btcec.PrivateKey
+	cold    *btcec.PrivateKey
+	policy  *txscript.VaultPolicy
+	leaf    txscript.TapLeaf
+	cb      []byte
+	prevOut *wire.TxOut
+}
+
+func newVaultFixture(t *testing.T) *vaultFixture {
+	t.Helper()
+
+	f := &vaultFixture{}
+	var hotKeys []*btcec.PublicKey
+	for i := 0; i < 3; i++ {
+		key, err := btcec.NewPrivateKey()
+		if err != nil {
+			t.Fatalf("Failed to generate key: %v", err)
+		}
+		f.hot = append(f.hot, key)
+		hotKeys = append(hotKeys, key.PubKey())
+	}
+
+	var err error
+	f.cold, err = btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	internal, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	f.policy = txscript.UnvaultingPolicy(hotKeys, 2, f.cold.PubKey(), 0)
+	builder := txscript.NewShellTaprootBuilder(internal.PubKey())
+	if err := builder.AddVaultLeaf(f.policy); err != nil {
+		t.Fatalf("Failed to add vault leaf: %v", err)
+	}
+
+	pkScript, err := builder.Build()
+	if err != nil {
+		t.Fatalf("Failed to build output: %v", err)
+	}
+	f.cb, err = builder.ControlBlock(0)
+	if err != nil {
+		t.Fatalf("Failed to create control block: %v", err)
+	}
+	f.leaf = builder.Leaves()[0]
+	f.prevOut = wire.NewTxOut(1e8, pkScript)
+
+	return f
+}
+
+// packet returns a Packet spending the vault output
+func (f *vaultFixture) packet(t *testing.T) *Packet {
+	t.Helper()
+
+	p, err := New([]*wire.OutPoint{wire.NewOutPoint(&chainhash.Hash{0x01}, 0)},
+		[]*wire.TxOut{wire.NewTxOut(1e8-1000, []byte{txscript.OP_TRUE})},
+		2, 0, []uint32{wire.MaxTxInSequenceNum})
+	if err != nil {
+		t.Fatalf("Failed to create packet: %v", err)
+	}
+
+	p.Inputs[0].WitnessUtxo = f.prevOut
+	p.Inputs[0].ShellLeaves = []*ShellTapLeaf{{
+		ControlBlock: f.cb,
+		Leaf:         f.leaf.Script,
+		LeafVersion:  f.leaf.LeafVersion,
+	}}
+
+	return p
+}
+
+// sign adds a tapscript signature from key for the vault leaf
+func (f *vaultFixture) sign(t *testing.T, p *Packet, key *btcec.PrivateKey) {
+	t.Helper()
+
+	fetcher := txscript.NewCannedPrevOutputFetcher(f.prevOut.PkScript,
+		f.prevOut.Value)
+	sig, err := txscript.RawTxInTapscriptSignature(p.UnsignedTx,
+		txscript.NewTxSigHashes(p.UnsignedTx, fetcher), 0, f.prevOut.Value,
+		f.prevOut.PkScript, f.leaf, txscript.SigHashDefault, key)
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+
+	leafHash := f.leaf.TapHash()
+	p.Inputs[0].TaprootScriptSpendSig = append(
+		p.Inputs[0].TaprootScriptSpendSig, &TaprootScriptSpendSig{
+			XOnlyPubKey: schnorr.SerializePubKey(key.PubKey()),
+			LeafHash:    leafHash[:],
+			Signature:   sig,
+			SigHash:     txscript.SigHashDefault,
+		})
+}
+
+// execute runs the script engine on the first input of tx with the vault
+// covenants active
+func execute(tx *wire.MsgTx, prevOut *wire.TxOut) error {
+	fetcher := txscript.NewCannedPrevOutputFetcher(prevOut.PkScript,
+		prevOut.Value)
+	vm, err := txscript.NewEngine(prevOut.PkScript, tx, 0,
+		txscript.StandardVerifyFlags|txscript.ScriptVerifyShellVault, nil,
+		txscript.NewTxSigHashes(tx, fetcher), prevOut.Value, fetcher)
+	if err != nil {
+		return err
+	}
+
+	return vm.Execute()
+}
+
+// roundTrip serializes and parses p, checking the serialization is stable
+func roundTrip(t *testing.T, p *Packet) *Packet {
+	t.Helper()
+
+	encoded, err := p.B64Encode()
+	if err != nil {
+		t.Fatalf("Failed to encode packet: %v", err)
+	}
+
+	decoded, err := NewFromRawBytes(strings.NewReader(encoded), true)
+	if err != nil {
+		t.Fatalf("Failed to decode packet: %v", err)
+	}
+
+	reencoded, err := decoded.B64Encode()
+	if err != nil {
+		t.Fatalf("Failed to encode decoded packet: %v", err)
+	}
+	if reencoded != encoded {
+		t.Fatalf("Serialization changed on round trip:\n%s\n%s", encoded,
+			reencoded)
+	}
+
+	return decoded
+}
+
+// TestPacketShellFields tests round tripping the Shell proprietary fields
+func TestPacketShellFields(t *testing.T) {
+	f := newVaultFixture(t)
+	p := f.packet(t)
+
+	inBlinding, err := confidential.GenerateBlindingFactor()
+	if err != nil {
+		t.Fatalf("Failed to generate blinding factor: %v", err)
+	}
+	p.Inputs[0].Blinding = &BlindingData{
+		Value:          1e8,
+		BlindingFactor: *inBlinding,
+	}
+
+	outBlinding, err := confidential.GenerateBlindingFactor()
+	if err != nil {
+		t.Fatalf("Failed to generate blinding factor: %v", err)
+	}
+	p.Outputs[0].Blinding = &BlindingData{
+		Value:          1e8 - 1000,
+		BlindingFactor: *outBlinding,
+	}
+	p.Outputs[0].Commitment, err = p.Outputs[0].Blinding.Commitment()
+	if err != nil {
+		t.Fatalf("Failed to create commitment: %v", err)
+	}
+
+	// Proprietary fields of other applications are kept as is.
+	foreign := &Unknown{
+		Key:   []byte{0xfc, 0x03, 'f', 'o', 'o', 0x00},
+		Value: []byte{0x01, 0x02},
+	}
+	p.Inputs[0].Unknowns = []*Unknown{foreign}
+	f.sign(t, p, f.hot[0])
+
+	decoded := roundTrip(t, p)
+	in := &decoded.Inputs[0]
+	if len(in.ShellLeaves) != 1 {
+		t.Fatalf("Expected one Shell leaf, got %d", len(in.ShellLeaves))
+	}
+	policy, err := in.ShellLeaves[0].VaultPolicy()
+	if err != nil {
+		t.Fatalf("Failed to decode vault policy: %v", err)
+	}
+	if policy.Threshold != 2 || len(policy.HotKeys) != 3 {
+		t.Errorf("Unexpected policy %d of %d", policy.Threshold,
+			len(policy.HotKeys))
+	}
+	if in.Blinding == nil || *in.Blinding != *p.Inputs[0].Blinding {
+		t.Error("Input blinding data mismatch")
+	}
+	if len(in.TaprootScriptSpendSig) != 1 {
+		t.Errorf("Expected one script spend signature, got %d",
+			len(in.TaprootScriptSpendSig))
+	}
+	if len(in.Unknowns) != 1 || !bytes.Equal(in.Unknowns[0].Key, foreign.Key) {
+		t.Error("Foreign proprietary field was not preserved")
+	}
+
+	out := &decoded.Outputs[0]
+	if out.Commitment == nil || !out.Commitment.IsEqual(p.Outputs[0].Commitment) {
+		t.Error("Output commitment mismatch")
+	}
+
+	// A commitment the blinding data does not open is rejected.
+	otherBlinding, _ := confidential.GenerateBlindingFactor()
+	p.Outputs[0].Commitment, _ = confidential.CreateCommitment(1e8-1000,
+		otherBlinding)
+	if err := p.SanityCheck(); err == nil {
+		t.Error("Expected error for mismatched commitment")
+	}
+}
+
+// TestPacketInvalid tests rejecting malformed packets
+func TestPacketInvalid(t *testing.T) {
+	f := newVaultFixture(t)
+
+	var buf bytes.Buffer
+	if err := f.packet(t).Serialize(&buf); err != nil {
+		t.Fatalf("Failed to serialize packet: %v", err)
+	}
+	valid := buf.Bytes()
+
+	badMagic := append([]byte{0x70, 0x73, 0x62, 0x74, 0x00}, valid[5:]...)
+	if _, err := NewFromRawBytes(bytes.NewReader(badMagic), false); err != ErrInvalidMagicBytes {
+		t.Errorf("Expected ErrInvalidMagicBytes, got %v", err)
+	}
+
+	// Repeat the unsigned transaction in the global map.
+	txLen := int(valid[7])
+	unsignedTx := valid[5 : 5+1+1+1+txLen]
+	dup := append(append(append([]byte{}, valid[:5]...), unsignedTx...),
+		valid[5:]...)
+	if _, err := NewFromRawBytes(bytes.NewReader(dup), false); err != ErrDuplicateKey {
+		t.Errorf("Expected ErrDuplicateKey, got %v", err)
+	}
+
+	// A Shell leaf whose leaf version does not match its control block.
+	p := f.packet(t)
+	p.Inputs[0].ShellLeaves[0].LeafVersion = txscript.BaseLeafVersion
+	buf.Reset()
+	if err := p.Serialize(&buf); err != nil {
+		t.Fatalf("Failed to serialize packet: %v", err)
+	}
+	if _, err := NewFromRawBytes(&buf, false); err != ErrInvalidValue {
+		t.Errorf("Expected ErrInvalidValue, got %v", err)
+	}
+
+	// Unsigned transactions must not carry signatures.
+	tx := f.packet(t).UnsignedTx
+	tx.TxIn[0].Witness = wire.TxWitness{{0x01}}
+	if _, err := NewFromUnsignedTx(tx); err != ErrInvalidUnsignedTx {
+		t.Errorf("Expected ErrInvalidUnsignedTx, got %v", err)
+	}
+}
+
+// TestFinalizeVault tests finalizing both spend paths of a vault leaf
+func TestFinalizeVault(t *testing.T) {
+	f := newVaultFixture(t)
+
+	// A single hot signature is not enough to unvault.
+	p := f.packet(t)
+	f.sign(t, p, f.hot[2])
+	finalized, err := MaybeFinalize(p, 0)
+	if err != nil || finalized {
+		t.Fatalf("Expected input not to be finalizable: %v", err)
+	}
+	if _, err := Extract(p); err != ErrIncompletePSBT {
+		t.Errorf("Expected ErrIncompletePSBT, got %v", err)
+	}
+
+	f.sign(t, p, f.hot[0])
+	if err := MaybeFinalizeAll(p); err != nil {
+		t.Fatalf("Failed to finalize unvault: %v", err)
+	}
+	if p.Inputs[0].ShellLeaves != nil || p.Inputs[0].TaprootScriptSpendSig != nil {
+		t.Error("Signing fields were not cleared")
+	}
+
+	tx, err := Extract(roundTrip(t, p))
+	if err != nil {
+		t.Fatalf("Failed to extract transaction: %v", err)
+	}
+	witness := tx.TxIn[0].Witness
+	if len(witness) != 6 || len(witness[1]) != 0 ||
+		witness[3][0] != byte(txscript.VaultPathUnvault) {
+
+		t.Errorf("Unexpected unvault witness %x", witness)
+	}
+	if err := execute(tx, f.prevOut); err != nil {
+		t.Fatalf("Unvault failed: %v", err)
+	}
+
+	// The cold key alone claws back.
+	p = f.packet(t)
+	f.sign(t, p, f.cold)
+	if err := Finalize(p, 0); err != nil {
+		t.Fatalf("Failed to finalize clawback: %v", err)
+	}
+	if err := Finalize(p, 0); err != ErrInputAlreadyFinalized {
+		t.Errorf("Expected ErrInputAlreadyFinalized, got %v", err)
+	}
+
+	tx, err = Extract(p)
+	if err != nil {
+		t.Fatalf("Failed to extract transaction: %v", err)
+	}
+	if err := execute(tx, f.prevOut); err != nil {
+		t.Fatalf("Clawback failed: %v", err)
+	}
+}
+
+// TestFinalizeWitnessMultiSig tests finalizing a P2WSH multisig input
+func TestFinalizeWitnessMultiSig(t *testing.T) {
+	var (
+		keys    []*btcec.PrivateKey
+		builder = txscript.NewScriptBuilder().AddOp(txscript.OP_2)
+	)
+	for i := 0; i < 3; i++ {
+		key, err := btcec.NewPrivateKey()
+		if err != nil {
+			t.Fatalf("Failed to generate key: %v", err)
+		}
+		keys = append(keys, key)
+		builder.AddData(key.PubKey().SerializeCompressed())
+	}
+	witnessScript, err := builder.AddOp(txscript.OP_3).
+		AddOp(txscript.OP_CHECKMULTISIG).Script()
+	if err != nil {
+		t.Fatalf("Failed to build script: %v", err)
+	}
+
+	scriptHash := sha256.Sum256(witnessScript)
+	pkScript, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+		AddData(scriptHash[:]).Script()
+	if err != nil {
+		t.Fatalf("Failed to build script: %v", err)
+	}
+	prevOut := wire.NewTxOut(5e7, pkScript)
+
+	p, err := New([]*wire.OutPoint{wire.NewOutPoint(&chainhash.Hash{0x02}, 1)},
+		[]*wire.TxOut{wire.NewTxOut(5e7-500, []byte{txscript.OP_TRUE})},
+		2, 0, []uint32{wire.MaxTxInSequenceNum})
+	if err != nil {
+		t.Fatalf("Failed to create packet: %v", err)
+	}
+	p.Inputs[0].WitnessUtxo = prevOut
+	p.Inputs[0].WitnessScript = witnessScript
+
+	// Sign with the last and first keys, out of script order.
+	fetcher := txscript.NewCannedPrevOutputFetcher(pkScript, prevOut.Value)
+	sigHashes := txscript.NewTxSigHashes(p.UnsignedTx, fetcher)
+	for _, key := range []*btcec.PrivateKey{keys[2], keys[0]} {
+		sig, err := txscript.RawTxInWitnessSignature(p.UnsignedTx, sigHashes,
+			0, prevOut.Value, witnessScript, txscript.SigHashAll, key)
+		if err != nil {
+			t.Fatalf("Failed to sign: %v", err)
+		}
+		p.Inputs[0].PartialSigs = append(p.Inputs[0].PartialSigs,
+			&PartialSig{
+				PubKey:    key.PubKey().SerializeCompressed(),
+				Signature: sig,
+			})
+	}
+
+	if err := MaybeFinalizeAll(roundTrip(t, p)); err != nil {
+		t.Fatalf("Failed to finalize decoded packet: %v", err)
+	}
+	if err := MaybeFinalizeAll(p); err != nil {
+		t.Fatalf("Failed to finalize: %v", err)
+	}
+
+	tx, err := Extract(p)
+	if err != nil {
+		t.Fatalf("Failed to extract transaction: %v", err)
+	}
+	if err := execute(tx, prevOut); err != nil {
+		t.Fatalf("Multisig spend failed: %v", err)
+	}
+
+	// Without the witness script the input cannot be finalized.
+	p.Inputs[0] = PInput{WitnessUtxo: prevOut}
+	if err := Finalize(p, 0); !errors.Is(err, ErrNotFinalizable) {
+		t.Errorf("Expected ErrNotFinalizable, got %v", err)
+	}
+}
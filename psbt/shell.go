@@ -0,0 +1,327 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/toole-brendan/shell/privacy/confidential"
+	"github.com/toole-brendan/shell/txscript"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// Shell specific data is carried in BIP 174 proprietary fields. The key of
+// a proprietary field is 0xFC followed by the length prefixed identifier,
+// the compact size subtype and the subtype's key data:
+//
+//	0xFC <len> "shell" <subtype> <key data>
+//
+// Tapscript leaves committed under a Shell leaf version, such as vault
+// policies, are not scripts a generic BIP 371 signer could satisfy. They are
+// carried in a Shell field instead of PSBT_IN_TAP_LEAF_SCRIPT so that only
+// Shell aware signers and finalizers act on them.
+const (
+	// ShellProprietaryID is the identifier of Shell proprietary fields
+	ShellProprietaryID = "shell"
+
+	// blindingDataSize is the size of serialized blinding data: the value
+	// followed by the blinding factor
+	blindingDataSize = 8 + confidential.BlindingFactorSize
+)
+
+// errUnknownSubType is returned internally for Shell fields of a subtype
+// this package does not know, which are kept as unknowns
+var errUnknownSubType = errors.New("unknown Shell subtype")
+
+// ShellInputSubType is the subtype of a Shell proprietary input field
+type ShellInputSubType uint64
+
+const (
+	// ShellInputTapLeafSubType is keyed by a control block and holds the
+	// contents of a Shell leaf followed by its leaf version, mirroring
+	// PSBT_IN_TAP_LEAF_SCRIPT
+	ShellInputTapLeafSubType ShellInputSubType = 0x00
+
+	// ShellInputBlindingSubType has no key data and holds the value and
+	// blinding factor opening the commitment of the confidential output
+	// spent by the input
+	ShellInputBlindingSubType ShellInputSubType = 0x01
+)
+
+// ShellOutputSubType is the subtype of a Shell proprietary output field
+type ShellOutputSubType uint64
+
+const (
+	// ShellOutputBlindingSubType has no key data and holds the value and
+	// blinding factor of a confidential output
+	ShellOutputBlindingSubType ShellOutputSubType = 0x00
+
+	// ShellOutputCommitmentSubType has no key data and holds the Pedersen
+	// commitment of a confidential output
+	ShellOutputCommitmentSubType ShellOutputSubType = 0x01
+
+	// ShellOutputRangeProofSubType has no key data and holds the range
+	// proof of a confidential output
+	ShellOutputRangeProofSubType ShellOutputSubType = 0x02
+)
+
+// ShellTapLeaf is a leaf committed under a Shell leaf version along with
+// the control block proving its inclusion in the output being spent
+type ShellTapLeaf struct {
+	ControlBlock []byte
+	Leaf         []byte
+	LeafVersion  txscript.TapscriptLeafVersion
+}
+
+// TapLeaf returns the tapscript leaf committing to the Shell leaf
+func (l *ShellTapLeaf) TapLeaf() txscript.TapLeaf {
+	return txscript.NewTapLeaf(l.LeafVersion, l.Leaf)
+}
+
+// VaultPolicy decodes the vault policy held by the leaf
+func (l *ShellTapLeaf) VaultPolicy() (*txscript.VaultPolicy, error) {
+	if l.LeafVersion != txscript.ShellTaprootLeafVersion {
+		return nil, fmt.Errorf("leaf version 0x%x is not a vault",
+			byte(l.LeafVersion))
+	}
+
+	return txscript.ParseVaultPolicy(l.Leaf)
+}
+
+// BlindingData is the opening of a confidential output's Pedersen
+// commitment
+type BlindingData struct {
+	Value          uint64
+	BlindingFactor confidential.BlindingFactor
+}
+
+// Commitment returns the Pedersen commitment opened by the blinding data
+func (b *BlindingData) Commitment() (*confidential.PedersenCommitment, error) {
+	return confidential.CreateCommitment(b.Value, &b.BlindingFactor)
+}
+
+// serialize encodes the blinding data as a field value
+func (b *BlindingData) serialize() []byte {
+	value := make([]byte, blindingDataSize)
+	binary.LittleEndian.PutUint64(value[:8], b.Value)
+	copy(value[8:], b.BlindingFactor[:])
+
+	return value
+}
+
+// readBlindingData decodes blinding data from a field value
+func readBlindingData(value []byte) (*BlindingData, error) {
+	if len(value) != blindingDataSize {
+		return nil, ErrInvalidValue
+	}
+
+	b := &BlindingData{Value: binary.LittleEndian.Uint64(value[:8])}
+	copy(b.BlindingFactor[:], value[8:])
+
+	return b, nil
+}
+
+// parseProprietaryKey splits the key data of a proprietary field into its
+// identifier, subtype and subtype key data
+func parseProprietaryKey(keyData []byte) (string, uint64, []byte, error) {
+	r := bytes.NewReader(keyData)
+	identifier, err := wire.ReadVarBytes(r, 0, MaxPsbtKeyLength,
+		"proprietary identifier")
+	if err != nil {
+		return "", 0, nil, ErrInvalidKeyData
+	}
+
+	subType, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return "", 0, nil, ErrInvalidKeyData
+	}
+
+	return string(identifier), subType, keyData[len(keyData)-r.Len():], nil
+}
+
+// serializeShellField writes a Shell proprietary field of the given key
+// type and subtype
+func serializeShellField(w io.Writer, keyType uint8, subType uint64,
+	keyData, value []byte) error {
+
+	var key bytes.Buffer
+	key.WriteByte(keyType)
+	err := wire.WriteVarBytes(&key, 0, []byte(ShellProprietaryID))
+	if err != nil {
+		return err
+	}
+	if err := wire.WriteVarInt(&key, 0, subType); err != nil {
+		return err
+	}
+	key.Write(keyData)
+
+	return serializeKVPair(w, key.Bytes(), value)
+}
+
+// readShellInputField decodes a Shell proprietary input field into pi
+func (pi *PInput) readShellInputField(subType uint64, keyData,
+	value []byte) error {
+
+	switch ShellInputSubType(subType) {
+	case ShellInputTapLeafSubType:
+		leafVersion, err := checkControlBlock(keyData)
+		if err != nil {
+			return err
+		}
+		if len(value) < 1 ||
+			txscript.TapscriptLeafVersion(value[len(value)-1]) != leafVersion {
+
+			return ErrInvalidValue
+		}
+
+		for _, leaf := range pi.ShellLeaves {
+			if bytes.Equal(leaf.ControlBlock, keyData) {
+				return ErrDuplicateKey
+			}
+		}
+
+		pi.ShellLeaves = append(pi.ShellLeaves, &ShellTapLeaf{
+			ControlBlock: keyData,
+			Leaf:         value[:len(value)-1],
+			LeafVersion:  leafVersion,
+		})
+
+	case ShellInputBlindingSubType:
+		if len(keyData) != 0 {
+			return ErrInvalidKeyData
+		}
+		if pi.Blinding != nil {
+			return ErrDuplicateKey
+		}
+
+		blinding, err := readBlindingData(value)
+		if err != nil {
+			return err
+		}
+		pi.Blinding = blinding
+
+	default:
+		return errUnknownSubType
+	}
+
+	return nil
+}
+
+// checkShellFields checks the Shell fields of the input for consistency
+func (pi *PInput) checkShellFields() error {
+	for _, leaf := range pi.ShellLeaves {
+		cb, err := txscript.ParseControlBlock(leaf.ControlBlock)
+		if err != nil {
+			return err
+		}
+		if cb.LeafVersion != leaf.LeafVersion {
+			return fmt.Errorf("shell leaf version 0x%x does not match "+
+				"control block version 0x%x", byte(leaf.LeafVersion),
+				byte(cb.LeafVersion))
+		}
+	}
+
+	return nil
+}
+
+// readShellOutputField decodes a Shell proprietary output field into po
+func (po *POutput) readShellOutputField(subType uint64, keyData,
+	value []byte) error {
+
+	if len(keyData) != 0 {
+		return ErrInvalidKeyData
+	}
+
+	switch ShellOutputSubType(subType) {
+	case ShellOutputBlindingSubType:
+		if po.Blinding != nil {
+			return ErrDuplicateKey
+		}
+
+		blinding, err := readBlindingData(value)
+		if err != nil {
+			return err
+		}
+		po.Blinding = blinding
+
+	case ShellOutputCommitmentSubType:
+		if po.Commitment != nil {
+			return ErrDuplicateKey
+		}
+
+		commitment, err := confidential.NewPedersenCommitment(value)
+		if err != nil {
+			return ErrInvalidValue
+		}
+		po.Commitment = commitment
+
+	case ShellOutputRangeProofSubType:
+		if po.RangeProof != nil {
+			return ErrDuplicateKey
+		}
+
+		proof, err := confidential.NewRangeProof(value)
+		if err != nil {
+			return ErrInvalidValue
+		}
+		po.RangeProof = proof
+
+	default:
+		return errUnknownSubType
+	}
+
+	return nil
+}
+
+// serializeShellFields writes the Shell proprietary fields of the output
+func (po *POutput) serializeShellFields(w io.Writer) error {
+	keyType := uint8(ProprietaryOutputType)
+
+	if po.Blinding != nil {
+		err := serializeShellField(w, keyType,
+			uint64(ShellOutputBlindingSubType), nil, po.Blinding.serialize())
+		if err != nil {
+			return err
+		}
+	}
+
+	if po.Commitment != nil {
+		err := serializeShellField(w, keyType,
+			uint64(ShellOutputCommitmentSubType), nil, po.Commitment.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	if po.RangeProof != nil {
+		err := serializeShellField(w, keyType,
+			uint64(ShellOutputRangeProofSubType), nil, po.RangeProof.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkShellFields makes sure the commitment of the output, if present, is
+// opened by its blinding data
+func (po *POutput) checkShellFields() error {
+	if po.Blinding == nil || po.Commitment == nil {
+		return nil
+	}
+
+	if !confidential.VerifyCommitment(po.Commitment, po.Blinding.Value,
+		&po.Blinding.BlindingFactor) {
+
+		return confidential.ErrCommitmentMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psbt
+
+import (
+	"bytes"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/toole-brendan/shell/txscript"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// PartialSig is an ECDSA signature from a public key
+type PartialSig struct {
+	PubKey    []byte
+	Signature []byte
+}
+
+// TaprootScriptSpendSig is a BIP 340 signature from an x-only public key for
+// a tapscript leaf
+type TaprootScriptSpendSig struct {
+	XOnlyPubKey []byte
+	LeafHash    []byte
+	Signature   []byte
+	SigHash     txscript.SigHashType
+}
+
+// TaprootTapLeafScript is a tapscript leaf along with the control block
+// proving its inclusion in the output being spent
+type TaprootTapLeafScript struct {
+	ControlBlock []byte
+	Script       []byte
+	LeafVersion  txscript.TapscriptLeafVersion
+}
+
+// TapLeaf returns the leaf committed to by the script
+func (s *TaprootTapLeafScript) TapLeaf() txscript.TapLeaf {
+	return txscript.NewTapLeaf(s.LeafVersion, s.Script)
+}
+
+// TaprootTapLeaf is a leaf of a taproot output script tree, at the given
+// depth of a depth first traversal
+type TaprootTapLeaf struct {
+	Depth       uint8
+	LeafVersion txscript.TapscriptLeafVersion
+	Script      []byte
+}
+
+// checkSchnorrSig makes sure sig is a 64 byte BIP 340 signature optionally
+// followed by a non-default sighash type, returning the sighash type
+func checkSchnorrSig(sig []byte) (txscript.SigHashType, error) {
+	switch len(sig) {
+	case schnorr.SignatureSize:
+		return txscript.SigHashDefault, nil
+
+	case schnorr.SignatureSize + 1:
+		if sig[schnorr.SignatureSize] == byte(txscript.SigHashDefault) {
+			return 0, ErrInvalidValue
+		}
+		return txscript.SigHashType(sig[schnorr.SignatureSize]), nil
+
+	default:
+		return 0, ErrInvalidValue
+	}
+}
+
+// checkXOnlyPubKey makes sure key is a valid x-only public key
+func checkXOnlyPubKey(key []byte) error {
+	if _, err := schnorr.ParsePubKey(key); err != nil {
+		return ErrInvalidKeyData
+	}
+
+	return nil
+}
+
+// checkControlBlock makes sure controlBlock is a well formed control block
+// and returns its leaf version
+func checkControlBlock(controlBlock []byte) (txscript.TapscriptLeafVersion, error) {
+	parsed, err := txscript.ParseControlBlock(controlBlock)
+	if err != nil {
+		return 0, ErrInvalidKeyData
+	}
+
+	return parsed.LeafVersion, nil
+}
+
+// readTapTree decodes the BIP 371 encoding of a taproot output script tree
+func readTapTree(value []byte) ([]TaprootTapLeaf, error) {
+	r := bytes.NewReader(value)
+
+	var leaves []TaprootTapLeaf
+	for r.Len() > 0 {
+		depth, err := r.ReadByte()
+		if err != nil || depth > txscript.ControlBlockMaxNodeCount {
+			return nil, ErrInvalidValue
+		}
+
+		leafVersion, err := r.ReadByte()
+		if err != nil {
+			return nil, ErrInvalidValue
+		}
+
+		script, err := wire.ReadVarBytes(r, 0, MaxPsbtValueLength,
+			"tapscript")
+		if err != nil {
+			return nil, ErrInvalidValue
+		}
+
+		leaves = append(leaves, TaprootTapLeaf{
+			Depth:       depth,
+			LeafVersion: txscript.TapscriptLeafVersion(leafVersion),
+			Script:      script,
+		})
+	}
+
+	if len(leaves) == 0 {
+		return nil, ErrInvalidValue
+	}
+
+	return leaves, nil
+}
+
+// serializeTapTree encodes a taproot output script tree as specified by
+// BIP 371
+func serializeTapTree(leaves []TaprootTapLeaf) ([]byte, error) {
+	var b bytes.Buffer
+	for _, leaf := range leaves {
+		b.WriteByte(leaf.Depth)
+		b.WriteByte(byte(leaf.LeafVersion))
+		if err := wire.WriteVarBytes(&b, 0, leaf.Script); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2025 Shell Reserve developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package psbt
+
+// GlobalType is the set of types that are used at the global scope level
+// within the PSBT
+type GlobalType uint8
+
+const (
+	// UnsignedTxType is the global key type for the unsigned transaction
+	UnsignedTxType GlobalType = 0x00
+
+	// XPubType houses a global extended public key. It is preserved but
+	// not interpreted
+	XPubType GlobalType = 0x01
+
+	// VersionType houses the version of the PSBT. Only version 0 is
+	// supported
+	VersionType GlobalType = 0xFB
+)
+
+// InputType is the set of types that are defined for each input included
+// within the PSBT
+type InputType uint8
+
+const (
+	// NonWitnessUtxoType has no key data and holds the full transaction
+	// the input spends from
+	NonWitnessUtxoType InputType = 0x00
+
+	// WitnessUtxoType has no key data and holds the serialized output the
+	// input spends
+	WitnessUtxoType InputType = 0x01
+
+	// PartialSigType is keyed by a public key and holds an ECDSA signature
+	// from it
+	PartialSigType InputType = 0x02
+
+	// SighashType has no key data and holds the sighash type to sign with
+	SighashType InputType = 0x03
+
+	// RedeemScriptInputType has no key data and holds the redeem script of
+	// a P2SH input
+	RedeemScriptInputType InputType = 0x04
+
+	// WitnessScriptInputType has no key data and holds the witness script
+	// of a P2WSH input
+	WitnessScriptInputType InputType = 0x05
+
+	// Bip32DerivationInputType is keyed by a public key and holds its
+	// master key fingerprint and derivation path
+	Bip32DerivationInputType InputType = 0x06
+
+	// FinalScriptSigType has no key data and holds the final signature
+	// script of the input
+	FinalScriptSigType InputType = 0x07
+
+	// FinalScriptWitnessType has no key data and holds the final witness of
+	// the input
+	FinalScriptWitnessType InputType = 0x08
+
+	// TaprootKeySpendSignatureType has no key data and holds a BIP 340
+	// signature for a taproot key spend
+	TaprootKeySpendSignatureType InputType = 0x13
+
+	// TaprootScriptSpendSignatureType is keyed by an x-only public key and
+	// leaf hash and holds a BIP 340 signature for that leaf
+	TaprootScriptSpendSignatureType InputType = 0x14
+
+	// TaprootLeafScriptType is keyed by a control block and holds the
+	// script and leaf version it proves
+	TaprootLeafScriptType InputType = 0x15
+
+	// TaprootBip32DerivationInputType is keyed by an x-only public key and
+	// holds the leaf hashes it signs for, its fingerprint and path
+	TaprootBip32DerivationInputType InputType = 0x16
+
+	// TaprootInternalKeyInputType has no key data and holds the x-only
+	// internal key of a taproot input
+	TaprootInternalKeyInputType InputType = 0x17
+
+	// TaprootMerkleRootType has no key data and holds the script tree root
+	// of a taproot input
+	TaprootMerkleRootType InputType = 0x18
+
+	// ProprietaryInputType is the type of proprietary input fields, see
+	// shell.go for the fields defined by Shell
+	ProprietaryInputType InputType = 0xFC
+)
+
+// OutputType is the set of types defined per output within the PSBT
+type OutputType uint8
+
+const (
+	// RedeemScriptOutputType has no key data and holds the redeem script of
+	// a P2SH output
+	RedeemScriptOutputType OutputType = 0x00
+
+	// WitnessScriptOutputType has no key data and holds the witness script
+	// of a P2WSH output
+	WitnessScriptOutputType OutputType = 0x01
+
+	// Bip32DerivationOutputType is keyed by a public key and holds its
+	// master key fingerprint and derivation path
+	Bip32DerivationOutputType OutputType = 0x02
+
+	// TaprootInternalKeyOutputType has no key data and holds the x-only
+	// internal key of a taproot output
+	TaprootInternalKeyOutputType OutputType = 0x05
+
+	// TaprootTapTreeType has no key data and holds the script tree of a
+	// taproot output as a depth first list of leaves
+	TaprootTapTreeType OutputType = 0x06
+
+	// TaprootBip32DerivationOutputType is keyed by an x-only public key and
+	// holds the leaf hashes it signs for, its fingerprint and path
+	TaprootBip32DerivationOutputType OutputType = 0x07
+
+	// ProprietaryOutputType is the type of proprietary output fields, see
+	// shell.go for the fields defined by Shell
+	ProprietaryOutputType OutputType = 0xFC
+)
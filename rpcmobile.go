@@ -0,0 +1,739 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/toole-brendan/shell/blockchain"
+	"github.com/toole-brendan/shell/btcjson"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/internal/convert"
+	"github.com/toole-brendan/shell/mempool"
+	"github.com/toole-brendan/shell/mining"
+	"github.com/toole-brendan/shell/mining/mobilex"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// Mobile mining state tracking
+type mobileMinersState struct {
+	sync.RWMutex
+	activeMinerCount   int64
+	mobileHashrate     float64
+	deviceBreakdown    map[string]int64
+	thermalViolations  int64
+	blocksFoundMobile  int64
+	averageTemperature float64
+	npuUtilization     float64
+}
+
+var (
+	// Global mobile mining state
+	mobileState = &mobileMinersState{
+		deviceBreakdown: make(map[string]int64),
+	}
+)
+
+const (
+	// mobileWorkDataLen is the number of bytes of a block header handed out
+	// by getmobilework.  It excludes the trailing thermal proof since the
+	// miner submits that along with the nonce.
+	mobileWorkDataLen = 80
+
+	// mobileWorkMaxEntries is the maximum number of work units handed out
+	// by getmobilework that are kept for submission.  The oldest work is
+	// evicted once the limit is reached.
+	mobileWorkMaxEntries = 256
+)
+
+// mobileWork houses a block handed out by getmobilework which only lacks the
+// nonce and thermal proof of the miner that solves it.
+type mobileWork struct {
+	block  *wire.MsgBlock
+	height int32
+}
+
+// mobileWorkState houses state that is used in between getmobilework and
+// submitmobilework invocations.  Work is keyed by its work ID and is discarded
+// once the best chain moves on since it can no longer extend it.
+type mobileWorkState struct {
+	sync.Mutex
+	prevHash chainhash.Hash
+	work     map[string]*mobileWork
+	order    []string
+	lastID   uint64
+}
+
+// newMobileWorkState returns a new instance of a mobileWorkState with all
+// internal fields initialized and ready to use.
+func newMobileWorkState() *mobileWorkState {
+	return &mobileWorkState{
+		work: make(map[string]*mobileWork),
+	}
+}
+
+// addWork stores the passed work under a new work ID and returns the ID.  The
+// ID doubles as the extra nonce of the coinbase of the work so that no two work
+// units share a merkle root.
+func (state *mobileWorkState) addWork(s *rpcServer, work *mobileWork) (string, error) {
+	state.Lock()
+	defer state.Unlock()
+
+	// Discard all work for a previous best block.
+	prevHash := work.block.Header.PrevBlock
+	if state.prevHash != prevHash {
+		state.prevHash = prevHash
+		state.work = make(map[string]*mobileWork)
+		state.order = nil
+	}
+
+	// Evict the oldest work when the limit is reached.
+	if len(state.order) >= mobileWorkMaxEntries {
+		delete(state.work, state.order[0])
+		state.order = state.order[1:]
+	}
+
+	state.lastID++
+	err := s.cfg.Generator.UpdateExtraNonce(work.block, work.height,
+		state.lastID)
+	if err != nil {
+		context := "Failed to update extra nonce"
+		return "", internalRPCError(err.Error(), context)
+	}
+
+	workID := strconv.FormatUint(state.lastID, 16)
+	state.work[workID] = work
+	state.order = append(state.order, workID)
+	return workID, nil
+}
+
+// lookupWork returns the work stored under the passed work ID.  Nil is returned
+// when the work is unknown, has been evicted, or does not build on the current
+// best block.
+func (state *mobileWorkState) lookupWork(s *rpcServer, workID string) *mobileWork {
+	state.Lock()
+	defer state.Unlock()
+
+	work, ok := state.work[workID]
+	if !ok {
+		return nil
+	}
+	if work.block.Header.PrevBlock != s.cfg.Chain.BestSnapshot().Hash {
+		return nil
+	}
+	return work
+}
+
+// handleGetMobileBlockTemplate implements the getmobileblocktemplate command.
+//
+// The template is the one shared with getblocktemplate, so it carries the
+// mempool transactions selected by the block template generator.  Its coinbase
+// pays to one of the configured mining addresses and is returned split around
+// a fixed-size extra nonce along with the coinbase merkle branch, which lets
+// Stratum-style miners roll the extra nonce without the full transaction set.
+func handleGetMobileBlockTemplate(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetMobileBlockTemplateCmd)
+	var deviceInfo *btcjson.DeviceInfo
+	if c.Request != nil {
+		deviceInfo = c.Request.DeviceInfo
+	}
+
+	if err := checkMobileWorkAvailable(s); err != nil {
+		return nil, err
+	}
+
+	// Protect concurrent access when updating block templates.
+	state := s.gbtWorkState
+	state.Lock()
+	defer state.Unlock()
+
+	if err := state.updateBlockTemplate(s, false); err != nil {
+		return nil, err
+	}
+	return state.mobileTemplateResult(s, deviceInfo)
+}
+
+// checkMobileWorkAvailable returns an RPC error when the server is not able to
+// hand out mobile work, either as a block template or as getwork-style work.
+func checkMobileWorkAvailable(s *rpcServer) error {
+	// Mobile templates always include the coinbase, so there must be an
+	// address to pay it to.
+	if len(cfg.miningAddrs) == 0 {
+		return &btcjson.RPCError{
+			Code: btcjson.ErrRPCInternal.Code,
+			Message: "Mobile work requires a coinbase, " +
+				"but the server has not been configured with " +
+				"any payment addresses via --miningaddr",
+		}
+	}
+
+	// Return an error if there are no peers connected since there is no
+	// way to relay a found block or receive transactions to work on.
+	// However, allow this state when running in the regression test or
+	// simulation test mode.
+	if !(cfg.RegressionTest || cfg.SimNet) &&
+		s.cfg.ConnMgr.ConnectedCount() == 0 {
+
+		return &btcjson.RPCError{
+			Code:    btcjson.ErrRPCClientNotConnected,
+			Message: "Bitcoin is not connected",
+		}
+	}
+
+	// No point in generating work before the chain is synced.
+	currentHeight := s.cfg.Chain.BestSnapshot().Height
+	if currentHeight != 0 && !s.cfg.SyncMgr.IsCurrent() {
+		return &btcjson.RPCError{
+			Code:    btcjson.ErrRPCClientInInitialDownload,
+			Message: "Bitcoin is downloading blocks...",
+		}
+	}
+
+	return nil
+}
+
+// mobileTemplateResult returns the current block template associated with the
+// state as a btcjson.GetMobileBlockTemplateResult tuned for the passed device.
+// The template is turned into a MobileX block once MobileX is active.
+//
+// This function MUST be called with the state locked.
+func (state *gbtWorkState) mobileTemplateResult(s *rpcServer, deviceInfo *btcjson.DeviceInfo) (*btcjson.GetMobileBlockTemplateResult, error) {
+	template := state.template
+	msgBlock, err := mobileBlockFromTemplate(s, template)
+	if err != nil {
+		return nil, err
+	}
+	header := &msgBlock.Header
+
+	coinbase1, coinbase2, err := mining.SplitCoinbase(msgBlock, template.Height)
+	if err != nil {
+		context := "Failed to split coinbase transaction"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	// Merkle branch entries are encoded in internal byte order as expected
+	// by Stratum miners.
+	block := convert.NewShellBlock(msgBlock)
+	branch := blockchain.CoinbaseMerkleBranch(block.Transactions())
+	merkleBranch := make([]string, 0, len(branch))
+	for _, hash := range branch {
+		merkleBranch = append(merkleBranch, hex.EncodeToString(hash[:]))
+	}
+
+	// Convert each non-coinbase transaction to a template result
+	// transaction, noting the portion of its fee owed to Shell operations.
+	feeCalc := mempool.NewFeeCalculator()
+	numTx := len(msgBlock.Transactions)
+	transactions := make([]btcjson.GetMobileBlockTemplateResultTx, 0, numTx-1)
+	txIndex := make(map[chainhash.Hash]int64, numTx)
+	for i, tx := range msgBlock.Transactions {
+		txID := tx.TxHash()
+		txIndex[txID] = int64(i)
+		if i == 0 {
+			continue
+		}
+
+		dependsMap := make(map[int64]struct{})
+		for _, txIn := range tx.TxIn {
+			if idx, ok := txIndex[txIn.PreviousOutPoint.Hash]; ok {
+				dependsMap[idx] = struct{}{}
+			}
+		}
+		depends := make([]int64, 0, len(dependsMap))
+		for idx := range dependsMap {
+			depends = append(depends, idx)
+		}
+
+		txBuf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+		if err := tx.Serialize(txBuf); err != nil {
+			context := "Failed to serialize transaction"
+			return nil, internalRPCError(err.Error(), context)
+		}
+
+		var operationFee int64
+		if feeResult, err := feeCalc.CalculateFee(tx); err == nil {
+			operationFee = feeResult.OperationFee
+		}
+
+		transactions = append(transactions, btcjson.GetMobileBlockTemplateResultTx{
+			Data:         hex.EncodeToString(txBuf.Bytes()),
+			TxID:         txID.String(),
+			Hash:         tx.WitnessHash().String(),
+			Depends:      depends,
+			Fee:          template.Fees[i],
+			OperationFee: operationFee,
+			SigOps:       template.SigOpCosts[i],
+			Weight:       blockchain.GetTransactionWeight(convert.NewShellTx(tx)),
+		})
+	}
+
+	reply := btcjson.GetMobileBlockTemplateResult{
+		Bits:          fmt.Sprintf("%08x", header.Bits),
+		CurTime:       header.Timestamp.Unix(),
+		Height:        int64(template.Height),
+		PreviousHash:  header.PrevBlock.String(),
+		Target:        fmt.Sprintf("%064x", blockchain.CompactToBig(header.Bits)),
+		Version:       header.Version,
+		MinTime:       state.minTimestamp.Unix(),
+		WeightLimit:   blockchain.MaxBlockWeight,
+		SigOpLimit:    blockchain.MaxBlockSigOpsCost,
+		CoinbaseValue: msgBlock.Transactions[0].TxOut[0].Value,
+		Transactions:  transactions,
+
+		Coinbase1:      hex.EncodeToString(coinbase1),
+		Coinbase2:      hex.EncodeToString(coinbase2),
+		ExtraNonceSize: mining.CoinbaseExtraNonceSize,
+		MerkleBranch:   merkleBranch,
+		MerkleRoot:     header.MerkleRoot.String(),
+
+		MobileTarget:    calculateMobileTarget(header.Bits, deviceInfo),
+		NPUWork:         generateNPUWorkForHeight(template.Height),
+		ThermalTarget:   45.0, // Default thermal target
+		WorkSize:        determineWorkSize(deviceInfo),
+		DeviceOptimized: deviceInfo != nil,
+	}
+	if template.WitnessCommitment != nil {
+		reply.DefaultWitnessCommitment = hex.EncodeToString(template.WitnessCommitment)
+	}
+
+	return &reply, nil
+}
+
+// mobileBlockFromTemplate returns a copy of the block of the passed template
+// with its own coinbase, so the coinbase can be rewritten for extra nonce
+// rolling without modifying the shared template.  The block is turned into a
+// MobileX block once MobileX is active.
+func mobileBlockFromTemplate(s *rpcServer, template *mining.BlockTemplate) (*wire.MsgBlock, error) {
+	// The coinbase is rewritten for extra nonce rolling, so work on a copy
+	// of it to leave the shared template untouched.
+	msgBlock := &wire.MsgBlock{
+		Header:       template.Block.Header,
+		Transactions: make([]*wire.MsgTx, len(template.Block.Transactions)),
+	}
+	copy(msgBlock.Transactions, template.Block.Transactions)
+	msgBlock.Transactions[0] = msgBlock.Transactions[0].Copy()
+	header := &msgBlock.Header
+
+	// Once MobileX is active the block must encode the algorithm in its
+	// version and commit to the MobileX difficulty, which retargets
+	// independently of the RandomX difficulty of the shared template.
+	if s.cfg.Chain.IsMobileXActive() {
+		bits, err := s.cfg.Chain.CalcNextRequiredAlgoDifficulty(
+			blockchain.PowAlgoMobileX, header.Timestamp)
+		if err != nil {
+			context := "Failed to calculate MobileX difficulty"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		header.Version = blockchain.SetPowAlgorithm(header.Version,
+			blockchain.PowAlgoMobileX)
+		header.Bits = bits
+	}
+
+	return msgBlock, nil
+}
+
+// handleGetMobileMiningInfo implements the getmobilemininginfo command.
+func handleGetMobileMiningInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// Get current blockchain state
+	best := s.cfg.Chain.BestSnapshot()
+
+	// Get mobile-specific stats
+	mobileState.RLock()
+	mobileHashrate := mobileState.mobileHashrate
+	activeMobileMiners := mobileState.activeMinerCount
+	mobileState.RUnlock()
+
+	// Calculate difficulty ratio.  Once MobileX is active each algorithm
+	// has its own difficulty, so report the next required difficulty of
+	// both.
+	difficulty := getDifficultyRatio(best.Bits, s.cfg.ChainParams)
+	mobileDifficulty := difficulty * 0.1 // 10% of main difficulty
+	if s.cfg.Chain.IsMobileXActive() {
+		now := time.Now()
+		bits, err := s.cfg.Chain.CalcNextRequiredDifficulty(now)
+		if err != nil {
+			context := "Failed to calculate RandomX difficulty"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		difficulty = getDifficultyRatio(bits, s.cfg.ChainParams)
+
+		bits, err = s.cfg.Chain.CalcNextRequiredAlgoDifficulty(
+			blockchain.PowAlgoMobileX, now)
+		if err != nil {
+			context := "Failed to calculate MobileX difficulty"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		mobileDifficulty = getDifficultyRatio(bits, s.cfg.ChainParams)
+	}
+
+	result := btcjson.GetMobileMiningInfoResult{
+		Blocks:             int64(best.Height),
+		CurrentBlockSize:   uint64(best.BlockSize),
+		CurrentBlockTx:     uint64(best.NumTxns),
+		Difficulty:         difficulty,
+		MobileDifficulty:   mobileDifficulty,
+		Errors:             "",
+		Generate:           s.cfg.CPUMiner.IsMining(),
+		HashesPerSec:       int64(s.cfg.CPUMiner.HashesPerSecond()),
+		MobileMinersActive: activeMobileMiners,
+		MobileHashrate:     mobileHashrate,
+		NetworkHashPerSec:  0, // TODO: Calculate from recent blocks
+		PooledTx:           uint64(s.cfg.TxMemPool.Count()),
+		TestNet:            cfg.TestNet3,
+		ThermalCompliance:  95.0, // Placeholder
+	}
+
+	return &result, nil
+}
+
+// handleSubmitMobileBlock implements the submitmobileblock command.
+//
+// The block is processed like blocks submitted with submitmobilework, using
+// the same rules as blocks coming from other nodes.  As with submitblock,
+// nothing is returned when the block is accepted, otherwise the reason it was
+// rejected is returned in the format described in BIP0022.
+func handleSubmitMobileBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.SubmitMobileBlockCmd)
+
+	// Deserialize the submitted block.
+	hexStr := c.HexBlock
+	if len(hexStr)%2 != 0 {
+		hexStr = "0" + c.HexBlock
+	}
+	serializedBlock, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hexStr)
+	}
+	var msgBlock wire.MsgBlock
+	err = msgBlock.Deserialize(bytes.NewReader(serializedBlock))
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDeserialization,
+			Message: "Block decode failed: " + err.Error(),
+		}
+	}
+
+	// Consensus rejects MobileX blocks with invalid thermal proofs too, but
+	// checking the proof here counts it as a thermal violation.
+	algo := blockchain.PowAlgorithmFromVersion(msgBlock.Header.Version)
+	if algo == blockchain.PowAlgoMobileX {
+		err := blockchain.CheckThermalProof(&msgBlock.Header)
+		if err != nil {
+			mobileState.Lock()
+			mobileState.thermalViolations++
+			mobileState.Unlock()
+
+			return chainErrToGBTErrString(err), nil
+		}
+	}
+
+	// Process this block using the same rules as blocks coming from other
+	// nodes.  This will in turn relay it to the network like normal.
+	block := convert.NewShellBlock(&msgBlock)
+	_, err = s.cfg.SyncMgr.SubmitBlock(block, blockchain.BFNone)
+	if err != nil {
+		return chainErrToGBTErrString(err), nil
+	}
+
+	// Update the mobile mining stats, including the temperature reported
+	// by the miner if any.
+	mobileState.Lock()
+	if c.ThermalProof != nil {
+		mobileState.averageTemperature = (mobileState.averageTemperature*
+			float64(mobileState.blocksFoundMobile) +
+			c.ThermalProof.Temperature) /
+			float64(mobileState.blocksFoundMobile+1)
+	}
+	mobileState.blocksFoundMobile++
+	mobileState.Unlock()
+
+	rpcsLog.Infof("Accepted block %s via submitmobileblock",
+		msgBlock.BlockHash())
+	return nil, nil
+}
+
+// handleGetMobileWork implements the getmobilework command.
+//
+// The work is the header of a block built from the shared block template with
+// a unique extra nonce in its coinbase.  The block is kept in the mobile work
+// state under the returned work ID so submitmobilework can reassemble it from
+// the nonce and thermal proof found by the miner.
+func handleGetMobileWork(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetMobileWorkCmd)
+
+	if err := checkMobileWorkAvailable(s); err != nil {
+		return nil, err
+	}
+
+	// Protect concurrent access when updating block templates.
+	state := s.gbtWorkState
+	state.Lock()
+	if err := state.updateBlockTemplate(s, false); err != nil {
+		state.Unlock()
+		return nil, err
+	}
+	template := state.template
+	msgBlock, err := mobileBlockFromTemplate(s, template)
+	state.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	work := &mobileWork{block: msgBlock, height: template.Height}
+	workID, err := s.mobileWorkState.addWork(s, work)
+	if err != nil {
+		return nil, err
+	}
+
+	// The thermal proof is appended to the header once the work is solved,
+	// so only the part of the header before it is handed out.
+	header := &msgBlock.Header
+	var headerBuf bytes.Buffer
+	if err := header.Serialize(&headerBuf); err != nil {
+		context := "Failed to serialize block header"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	data := headerBuf.Bytes()[:mobileWorkDataLen]
+
+	// Generate NPU work if applicable
+	npuWork := ""
+	if c.DeviceClass == "flagship" || c.DeviceClass == "midrange" {
+		npuWork = generateNPUWorkForHeight(template.Height)
+	}
+
+	result := btcjson.GetMobileWorkResult{
+		WorkID:       workID,
+		Data:         hex.EncodeToString(data),
+		Target:       fmt.Sprintf("%064x", blockchain.CompactToBig(header.Bits)),
+		NPUWork:      npuWork,
+		ThermalLimit: 50.0, // Conservative default
+	}
+
+	return &result, nil
+}
+
+// handleSubmitMobileWork implements the submitmobilework command.
+//
+// The block the work was handed out for is reassembled with the submitted
+// nonce and thermal proof and processed using the same rules as blocks coming
+// from other nodes.  As with submitblock, nothing is returned when the block
+// is accepted, otherwise the reason it was rejected is returned in the format
+// described in BIP0022.
+func handleSubmitMobileWork(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.SubmitMobileWorkCmd)
+
+	nonce, err := strconv.ParseUint(c.Nonce, 16, 32)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "invalid nonce",
+		}
+	}
+	thermalProof, err := strconv.ParseUint(c.ThermalProof, 16, 64)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "invalid thermal proof",
+		}
+	}
+
+	// Work that is unknown, was evicted or no longer builds on the best
+	// chain can't produce a block that extends it.
+	work := s.mobileWorkState.lookupWork(s, c.WorkID)
+	if work == nil {
+		return "stale", nil
+	}
+
+	// Reassemble the block from a copy of the header so a rejected
+	// solution doesn't alter the stored work.
+	msgBlock := &wire.MsgBlock{
+		Header:       work.block.Header,
+		Transactions: work.block.Transactions,
+	}
+	msgBlock.Header.Nonce = uint32(nonce)
+	msgBlock.Header.ThermalProof = thermalProof
+
+	// Consensus rejects MobileX blocks with invalid thermal proofs too, but
+	// checking the proof here counts it as a thermal violation.
+	algo := blockchain.PowAlgorithmFromVersion(msgBlock.Header.Version)
+	if algo == blockchain.PowAlgoMobileX {
+		err := blockchain.CheckThermalProof(&msgBlock.Header)
+		if err != nil {
+			mobileState.Lock()
+			mobileState.thermalViolations++
+			mobileState.Unlock()
+
+			return chainErrToGBTErrString(err), nil
+		}
+	}
+
+	// Process this block using the same rules as blocks coming from other
+	// nodes.  This will in turn relay it to the network like normal.
+	block := convert.NewShellBlock(msgBlock)
+	_, err = s.cfg.SyncMgr.SubmitBlock(block, blockchain.BFNone)
+	if err != nil {
+		return chainErrToGBTErrString(err), nil
+	}
+
+	mobileState.Lock()
+	mobileState.blocksFoundMobile++
+	mobileState.Unlock()
+
+	rpcsLog.Infof("Accepted block %s via submitmobilework",
+		msgBlock.BlockHash())
+	return nil, nil
+}
+
+// handleValidateThermalProof implements the validatethermalproof command.
+func handleValidateThermalProof(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.ValidateThermalProofCmd)
+
+	// Get block by hash
+	hash, err := chainhash.NewHashFromStr(c.BlockHash)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "invalid block hash",
+		}
+	}
+
+	block, err := s.cfg.Chain.BlockByHash(hash)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCBlockNotFound,
+			Message: "block not found",
+		}
+	}
+
+	// Get block bytes and deserialize using Shell's wire package
+	blockBytes, err := block.Bytes()
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInternal.Code,
+			Message: "failed to serialize block",
+		}
+	}
+
+	msgBlock := &wire.MsgBlock{}
+	err = msgBlock.Deserialize(bytes.NewReader(blockBytes))
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDeserialization,
+			Message: "block deserialize failed",
+		}
+	}
+
+	// Create thermal verifier
+	thermal := mobilex.NewThermalVerification(2000, 5.0)
+
+	// Validate the thermal proof
+	if msgBlock.Header.ThermalProof != c.ThermalProof {
+		return false, nil
+	}
+
+	err = thermal.ValidateThermalProof(&msgBlock.Header)
+	return err == nil, nil
+}
+
+// handleGetMobileStats implements the getmobilestats command.
+func handleGetMobileStats(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// Get stats from state
+	mobileState.RLock()
+	result := btcjson.GetMobileStatsResult{
+		TotalMobileMiners:   mobileState.activeMinerCount,
+		MobileHashrate:      mobileState.mobileHashrate,
+		DeviceBreakdown:     copyDeviceBreakdown(mobileState.deviceBreakdown),
+		GeographicBreakdown: make(map[string]int64), // TODO: Implement geo tracking
+		ThermalViolations:   mobileState.thermalViolations,
+		AverageTemperature:  mobileState.averageTemperature,
+		NPUUtilization:      mobileState.npuUtilization,
+		BlocksFoundMobile:   mobileState.blocksFoundMobile,
+	}
+	mobileState.RUnlock()
+
+	return &result, nil
+}
+
+// Helper functions
+
+// calculateMobileTarget returns the target difficulty for mobile miners as a hex string.
+func calculateMobileTarget(bits uint32, deviceInfo *btcjson.DeviceInfo) string {
+	// Start with network target
+	target := blockchain.CompactToBig(bits)
+
+	// Adjust based on device type
+	var multiplier int64 = 10 // Default 10x easier
+	if deviceInfo != nil {
+		switch deviceInfo.SocModel {
+		case "Snapdragon 8 Gen 3", "A17 Pro", "Tensor G3":
+			multiplier = 10 // Flagship: 10x easier
+		case "Snapdragon 7 Gen 3", "A16", "Tensor G2":
+			multiplier = 20 // Mid-range: 20x easier
+		default:
+			multiplier = 50 // Budget: 50x easier
+		}
+	}
+
+	// Make it easier for mobile miners
+	mobileTarget := new(big.Int).Mul(target, big.NewInt(multiplier))
+	return fmt.Sprintf("%064x", mobileTarget)
+}
+
+// generateNPUWorkForHeight generates NPU work parameters for a given height.
+func generateNPUWorkForHeight(height int32) string {
+	// Generate deterministic NPU work based on height
+	work := make([]byte, 256) // Smaller than pool version
+	for i := range work {
+		work[i] = byte((height + int32(i)) % 256)
+	}
+	return hex.EncodeToString(work)
+}
+
+// determineWorkSize returns optimized work parameters for device.
+func determineWorkSize(deviceInfo *btcjson.DeviceInfo) btcjson.MobileWorkSize {
+	if deviceInfo == nil {
+		return btcjson.MobileWorkSize{
+			SearchSpace:   0x40000, // 256K default
+			NPUIterations: 200,
+			CacheSize:     1024 * 1024,
+		}
+	}
+
+	switch deviceInfo.SocModel {
+	case "Snapdragon 8 Gen 3", "A17 Pro":
+		return btcjson.MobileWorkSize{
+			SearchSpace:   0x100000, // 1M nonces
+			NPUIterations: 100,
+			CacheSize:     3 * 1024 * 1024,
+		}
+	case "Snapdragon 7 Gen 3", "A16":
+		return btcjson.MobileWorkSize{
+			SearchSpace:   0x80000, // 512K nonces
+			NPUIterations: 150,
+			CacheSize:     2 * 1024 * 1024,
+		}
+	default:
+		return btcjson.MobileWorkSize{
+			SearchSpace:   0x40000, // 256K nonces
+			NPUIterations: 200,
+			CacheSize:     1024 * 1024,
+		}
+	}
+}
+
+// copyDeviceBreakdown creates a copy of device breakdown map.
+func copyDeviceBreakdown(original map[string]int64) map[string]int64 {
+	copy := make(map[string]int64)
+	for k, v := range original {
+		copy[k] = v
+	}
+	return copy
+}
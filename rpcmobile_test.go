@@ -0,0 +1,227 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/toole-brendan/shell/blockchain"
+	"github.com/toole-brendan/shell/btcjson"
+	"github.com/toole-brendan/shell/internal/convert"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// newSimRPCServer returns an RPC server backed by the passed simulation node
+// that pays its coinbases to an address with a zero public key hash.
+func newSimRPCServer(t *testing.T, h *simHarness, node *simNode) *rpcServer {
+	t.Helper()
+
+	addr, err := btcutil.NewAddressPubKeyHash(make([]byte, 20),
+		convert.ParamsToBtc(h.params.Name))
+	if err != nil {
+		t.Fatalf("unable to create mining address: %v", err)
+	}
+	cfg.miningAddrs = []btcutil.Address{addr}
+
+	s := node.server
+	return &rpcServer{
+		cfg: rpcserverConfig{
+			ConnMgr:     &rpcConnManager{s},
+			SyncMgr:     &rpcSyncMgr{s, s.syncManager},
+			TimeSource:  s.timeSource,
+			Chain:       s.chain,
+			ChainParams: h.params,
+			TxMemPool:   s.txMemPool,
+			Generator:   node.generator,
+		},
+		gbtWorkState:    newGbtWorkState(s.timeSource),
+		mobileWorkState: newMobileWorkState(),
+	}
+}
+
+// getSimMobileWork requests mobile work from the passed RPC server and returns
+// its ID along with the decoded header.
+func getSimMobileWork(t *testing.T, s *rpcServer) (string, *wire.BlockHeader) {
+	t.Helper()
+
+	reply, err := handleGetMobileWork(s, &btcjson.GetMobileWorkCmd{}, nil)
+	if err != nil {
+		t.Fatalf("getmobilework: %v", err)
+	}
+	result := reply.(*btcjson.GetMobileWorkResult)
+
+	data, err := hex.DecodeString(result.Data)
+	if err != nil {
+		t.Fatalf("getmobilework: invalid data: %v", err)
+	}
+	if len(data) != mobileWorkDataLen {
+		t.Fatalf("getmobilework: data is %d bytes, want %d", len(data),
+			mobileWorkDataLen)
+	}
+
+	// Decoding requires the trailing thermal proof.
+	var header wire.BlockHeader
+	data = append(data, make([]byte, 8)...)
+	if err := header.Deserialize(bytes.NewReader(data)); err != nil {
+		t.Fatalf("getmobilework: unable to decode header: %v", err)
+	}
+
+	target := fmt.Sprintf("%064x", blockchain.CompactToBig(header.Bits))
+	if result.Target != target {
+		t.Fatalf("getmobilework: target %s, want %s", result.Target,
+			target)
+	}
+	return result.WorkID, &header
+}
+
+// submitSimMobileWork submits the nonce and thermal proof of the passed header
+// as the solution of the passed work and returns the result.
+func submitSimMobileWork(t *testing.T, s *rpcServer, workID string, header *wire.BlockHeader) interface{} {
+	t.Helper()
+
+	cmd := &btcjson.SubmitMobileWorkCmd{
+		WorkID:       workID,
+		Nonce:        fmt.Sprintf("%08x", header.Nonce),
+		ThermalProof: fmt.Sprintf("%x", header.ThermalProof),
+	}
+	reply, err := handleSubmitMobileWork(s, cmd, nil)
+	if err != nil {
+		t.Fatalf("submitmobilework: %v", err)
+	}
+	return reply
+}
+
+// TestMobileWorkSubmission ensures work handed out by getmobilework is
+// reassembled into a block when its solution is submitted, and that rejected
+// solutions report BIP0022 reasons.
+func TestMobileWorkSubmission(t *testing.T) {
+	h := newSimHarness(t, 1)
+	node := h.Node(0)
+	s := newSimRPCServer(t, h, node)
+
+	workID, header := getSimMobileWork(t, s)
+	otherID, other := getSimMobileWork(t, s)
+	if workID == otherID {
+		t.Fatalf("work IDs are not unique: %s", workID)
+	}
+	if header.MerkleRoot == other.MerkleRoot {
+		t.Fatalf("work %s and %s share merkle root %v", workID, otherID,
+			header.MerkleRoot)
+	}
+
+	// Find a nonce that does not satisfy the target.
+	policy := node.server.miningPolicy
+	height := node.BestHeight() + 1
+	target := blockchain.CompactToBig(header.Bits)
+	for {
+		hash, err := policy.PowHash(header, height)
+		if err != nil {
+			t.Fatalf("unable to hash mobile work: %v", err)
+		}
+		if blockchain.HashToBig(&hash).Cmp(target) > 0 {
+			break
+		}
+		header.Nonce++
+	}
+	if reply := submitSimMobileWork(t, s, workID, header); reply != "high-hash" {
+		t.Fatalf("submitmobilework: got %v for a high hash, want "+
+			"high-hash", reply)
+	}
+
+	if reply := submitSimMobileWork(t, s, "unknown", header); reply != "stale" {
+		t.Fatalf("submitmobilework: got %v for unknown work, want "+
+			"stale", reply)
+	}
+
+	if !h.solver.Solve(policy, header, height) {
+		t.Fatal("unable to solve mobile work")
+	}
+	if reply := submitSimMobileWork(t, s, workID, header); reply != nil {
+		t.Fatalf("submitmobilework: solution rejected: %v", reply)
+	}
+	if got, want := node.BestHash(), header.BlockHash(); got != want {
+		t.Fatalf("best block %v, want submitted block %v", got, want)
+	}
+
+	// The remaining work no longer extends the best chain.
+	if !h.solver.Solve(policy, other, height) {
+		t.Fatal("unable to solve mobile work")
+	}
+	if reply := submitSimMobileWork(t, s, otherID, other); reply != "stale" {
+		t.Fatalf("submitmobilework: got %v for work on a previous "+
+			"block, want stale", reply)
+	}
+}
+
+// submitSimMobileBlock submits the block of the passed work with the nonce and
+// thermal proof of the passed header and returns the result.
+func submitSimMobileBlock(t *testing.T, s *rpcServer, workID string, header *wire.BlockHeader) interface{} {
+	t.Helper()
+
+	work := s.mobileWorkState.lookupWork(s, workID)
+	if work == nil {
+		t.Fatalf("submitmobileblock: unknown work %s", workID)
+	}
+	msgBlock := wire.MsgBlock{
+		Header:       *header,
+		Transactions: work.block.Transactions,
+	}
+	var buf bytes.Buffer
+	if err := msgBlock.Serialize(&buf); err != nil {
+		t.Fatalf("submitmobileblock: unable to serialize block: %v", err)
+	}
+
+	cmd := &btcjson.SubmitMobileBlockCmd{
+		HexBlock: hex.EncodeToString(buf.Bytes()),
+	}
+	reply, err := handleSubmitMobileBlock(s, cmd, nil)
+	if err != nil {
+		t.Fatalf("submitmobileblock: %v", err)
+	}
+	return reply
+}
+
+// TestMobileBlockSubmission ensures blocks submitted with submitmobileblock are
+// processed like blocks submitted with submitmobilework.
+func TestMobileBlockSubmission(t *testing.T) {
+	h := newSimHarness(t, 1)
+	node := h.Node(0)
+	s := newSimRPCServer(t, h, node)
+
+	workID, header := getSimMobileWork(t, s)
+
+	// Find a nonce that does not satisfy the target.
+	policy := node.server.miningPolicy
+	height := node.BestHeight() + 1
+	target := blockchain.CompactToBig(header.Bits)
+	for {
+		hash, err := policy.PowHash(header, height)
+		if err != nil {
+			t.Fatalf("unable to hash mobile work: %v", err)
+		}
+		if blockchain.HashToBig(&hash).Cmp(target) > 0 {
+			break
+		}
+		header.Nonce++
+	}
+	if reply := submitSimMobileBlock(t, s, workID, header); reply != "high-hash" {
+		t.Fatalf("submitmobileblock: got %v for a high hash, want "+
+			"high-hash", reply)
+	}
+
+	if !h.solver.Solve(policy, header, height) {
+		t.Fatal("unable to solve mobile work")
+	}
+	if reply := submitSimMobileBlock(t, s, workID, header); reply != nil {
+		t.Fatalf("submitmobileblock: block rejected: %v", reply)
+	}
+	if got, want := node.BestHash(), header.BlockHash(); got != want {
+		t.Fatalf("best block %v, want submitted block %v", got, want)
+	}
+}
@@ -39,6 +39,7 @@ import (
 	"github.com/toole-brendan/shell/mempool"
 	"github.com/toole-brendan/shell/mining"
 	"github.com/toole-brendan/shell/mining/cpuminer"
+	"github.com/toole-brendan/shell/mining/randomx"
 	"github.com/toole-brendan/shell/peer"
 	"github.com/toole-brendan/shell/txscript"
 	"github.com/toole-brendan/shell/wire"
@@ -161,7 +162,12 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"getheaders":             handleGetHeaders,
 	"getinfo":                handleGetInfo,
 	"getmempoolinfo":         handleGetMempoolInfo,
+	"getminingdistribution":  handleGetMiningDistribution,
 	"getmininginfo":          handleGetMiningInfo,
+	"getmobileblocktemplate": handleGetMobileBlockTemplate,
+	"getmobilemininginfo":    handleGetMobileMiningInfo,
+	"getmobilestats":         handleGetMobileStats,
+	"getmobilework":          handleGetMobileWork,
 	"getnettotals":           handleGetNetTotals,
 	"getnetworkhashps":       handleGetNetworkHashPS,
 	"getnodeaddresses":       handleGetNodeAddresses,
@@ -180,8 +186,11 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"signmessagewithprivkey": handleSignMessageWithPrivKey,
 	"stop":                   handleStop,
 	"submitblock":            handleSubmitBlock,
+	"submitmobileblock":      handleSubmitMobileBlock,
+	"submitmobilework":       handleSubmitMobileWork,
 	"uptime":                 handleUptime,
 	"validateaddress":        handleValidateAddress,
+	"validatethermalproof":   handleValidateThermalProof,
 	"verifychain":            handleVerifyChain,
 	"verifymessage":          handleVerifyMessage,
 	"version":                handleVersion,
@@ -1126,6 +1135,19 @@ func handleGetBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 		nextHashString = nextHash.String()
 	}
 
+	// The thermal proof is not carried by the deserialized block, so take
+	// it and the mining algorithm from the block index.
+	shellHeader, err := s.cfg.Chain.HeaderByHash(hash)
+	if err != nil {
+		context := "Failed to obtain block header"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	powAlgo, err := s.cfg.Chain.BlockPowAlgorithm(hash)
+	if err != nil {
+		context := "Failed to obtain block algorithm"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
 	params := s.cfg.ChainParams
 	blockHeader := blk.MsgBlock().Header
 	blockReply := btcjson.GetBlockVerboseResult{
@@ -1143,6 +1165,8 @@ func handleGetBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 		Weight:        int32(blockchain.GetBlockWeight(convert.NewShellBlockFromBtcBlock(blk))),
 		Bits:          strconv.FormatInt(int64(blockHeader.Bits), 16),
 		Difficulty:    getDifficultyRatio(blockHeader.Bits, params),
+		ThermalProof:  shellHeader.ThermalProof,
+		PowAlgo:       powAlgo.String(),
 		NextHash:      nextHashString,
 	}
 
@@ -1390,6 +1414,12 @@ func handleGetBlockHeader(s *rpcServer, cmd interface{}, closeChan <-chan struct
 		nextHashString = nextHash.String()
 	}
 
+	powAlgo, err := s.cfg.Chain.BlockPowAlgorithm(hash)
+	if err != nil {
+		context := "Failed to obtain block algorithm"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
 	params := s.cfg.ChainParams
 	blockHeaderReply := btcjson.GetBlockHeaderVerboseResult{
 		Hash:          c.Hash,
@@ -1404,6 +1434,8 @@ func handleGetBlockHeader(s *rpcServer, cmd interface{}, closeChan <-chan struct
 		Time:          blockHeader.Timestamp.Unix(),
 		Bits:          strconv.FormatInt(int64(blockHeader.Bits), 16),
 		Difficulty:    getDifficultyRatio(blockHeader.Bits, params),
+		ThermalProof:  blockHeader.ThermalProof,
+		PowAlgo:       powAlgo.String(),
 	}
 	return blockHeaderReply, nil
 }
@@ -2109,6 +2141,10 @@ func chainErrToGBTErrString(err error) string {
 		return "bad-prevblk"
 	case blockchain.ErrPrevBlockNotBest:
 		return "inconclusive-not-best-prvblk"
+	case blockchain.ErrInvalidThermalProof:
+		return "bad-thermal-proof"
+	case blockchain.ErrUnexpectedPowAlgorithm:
+		return "bad-version"
 	}
 
 	return "rejected: " + err.Error()
@@ -2386,6 +2422,49 @@ func handleGetMempoolInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct
 	return ret, nil
 }
 
+// handleGetMiningDistribution implements the getminingdistribution command.
+func handleGetMiningDistribution(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetMiningDistributionCmd)
+
+	window := int32(144)
+	if c.Window != nil {
+		window = *c.Window
+	}
+	if window <= 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Window must be positive",
+		}
+	}
+
+	dist, err := s.cfg.Chain.MiningDistribution(window)
+	if err != nil {
+		context := "Failed to summarize mining distribution"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	blocks := dist.EndHeight - dist.StartHeight + 1
+	algos := make([]btcjson.MiningDistributionAlgoResult, 0,
+		len(dist.Algorithms))
+	for _, algo := range dist.Algorithms {
+		algos = append(algos, btcjson.MiningDistributionAlgoResult{
+			Algorithm:  algo.Algorithm.String(),
+			Blocks:     algo.Blocks,
+			Share:      float64(algo.Blocks) / float64(blocks),
+			Work:       fmt.Sprintf("%064x", algo.Work),
+			ChainWork:  fmt.Sprintf("%064x", algo.ChainWork),
+			LastHeight: algo.LastHeight,
+		})
+	}
+
+	return &btcjson.GetMiningDistributionResult{
+		StartHeight: dist.StartHeight,
+		EndHeight:   dist.EndHeight,
+		Blocks:      blocks,
+		Algorithms:  algos,
+	}, nil
+}
+
 // handleGetMiningInfo implements the getmininginfo command. We only return the
 // fields that are not related to wallet functionality.
 func handleGetMiningInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
@@ -2419,9 +2498,37 @@ func handleGetMiningInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 		PooledTx:           uint64(s.cfg.TxMemPool.Count()),
 		TestNet:            cfg.TestNet3 || cfg.TestNet4,
 	}
+	if s.cfg.RandomXSeeds != nil {
+		result.RandomX = randomXEpochsResult(s.cfg.RandomXSeeds.Status())
+	}
 	return &result, nil
 }
 
+// randomXEpochResult returns the getmininginfo result of a RandomX epoch.
+func randomXEpochResult(status *randomx.EpochStatus) btcjson.RandomXEpochResult {
+	return btcjson.RandomXEpochResult{
+		SeedHeight: status.SeedHeight,
+		SeedHash:   hex.EncodeToString(status.Seed[:]),
+		State:      status.State.String(),
+	}
+}
+
+// randomXEpochsResult returns the getmininginfo result of the RandomX epochs
+// of a seed manager.
+func randomXEpochsResult(status randomx.SeedStatus) *btcjson.RandomXEpochsResult {
+	result := &btcjson.RandomXEpochsResult{
+		SeedRotation: status.Rotation,
+		FullMemory:   status.FullMemory,
+		Current:      randomXEpochResult(&status.Current),
+		Next:         randomXEpochResult(&status.Next),
+	}
+	if status.Previous != nil {
+		previous := randomXEpochResult(status.Previous)
+		result.Previous = &previous
+	}
+	return result
+}
+
 // handleGetNetTotals implements the getnettotals command.
 func handleGetNetTotals(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	totalBytesRecv, totalBytesSent := s.cfg.ConnMgr.NetTotals()
@@ -4003,6 +4110,7 @@ type rpcServer struct {
 	statusLock             sync.RWMutex
 	wg                     sync.WaitGroup
 	gbtWorkState           *gbtWorkState
+	mobileWorkState        *mobileWorkState
 	helpCacher             *helpCacher
 	requestProcessShutdown chan struct{}
 	quit                   chan int
@@ -4826,6 +4934,10 @@ type rpcserverConfig struct {
 	Generator *mining.BlkTmplGenerator
 	CPUMiner  *cpuminer.CPUMiner
 
+	// RandomXSeeds computes the RandomX seed epochs of the chain.  It is
+	// optional.
+	RandomXSeeds *randomx.SeedManager
+
 	// These fields define any optional indexes the RPC server can make use
 	// of to provide additional data when queried.
 	TxIndex   *indexers.TxIndex
@@ -4843,6 +4955,7 @@ func newRPCServer(config *rpcserverConfig) (*rpcServer, error) {
 		cfg:                    *config,
 		statusLines:            make(map[int]string),
 		gbtWorkState:           newGbtWorkState(config.TimeSource),
+		mobileWorkState:        newMobileWorkState(),
 		helpCacher:             newHelpCacher(),
 		requestProcessShutdown: make(chan struct{}),
 		quit:                   make(chan int),
@@ -253,6 +253,8 @@ var helpDescsEnUS = map[string]string{
 	"getblockverboseresult-nonce":             "The block nonce",
 	"getblockverboseresult-bits":              "The bits which represent the block difficulty",
 	"getblockverboseresult-difficulty":        "The proof-of-work difficulty as a multiple of the minimum difficulty",
+	"getblockverboseresult-thermalproof":      "The thermal proof of the block (zero for RandomX blocks)",
+	"getblockverboseresult-powalgo":           "The proof-of-work algorithm the block was mined with (randomx or mobilex)",
 	"getblockverboseresult-previousblockhash": "The hash of the previous block",
 	"getblockverboseresult-nextblockhash":     "The hash of the next block (only if there is one)",
 	"getblockverboseresult-strippedsize":      "The size of the block without witness data",
@@ -286,6 +288,8 @@ var helpDescsEnUS = map[string]string{
 	"getblockheaderverboseresult-nonce":             "The block nonce",
 	"getblockheaderverboseresult-bits":              "The bits which represent the block difficulty",
 	"getblockheaderverboseresult-difficulty":        "The proof-of-work difficulty as a multiple of the minimum difficulty",
+	"getblockheaderverboseresult-thermalproof":      "The thermal proof of the block (zero for RandomX blocks)",
+	"getblockheaderverboseresult-powalgo":           "The proof-of-work algorithm the block was mined with (randomx or mobilex)",
 	"getblockheaderverboseresult-previousblockhash": "The hash of the previous block",
 	"getblockheaderverboseresult-nextblockhash":     "The hash of the next block (only if there is one)",
 
@@ -449,10 +453,175 @@ var helpDescsEnUS = map[string]string{
 	"getmininginforesult-networkhashps":      "Estimated network hashes per second for the most recent blocks",
 	"getmininginforesult-pooledtx":           "Number of transactions in the memory pool",
 	"getmininginforesult-testnet":            "Whether or not server is using testnet",
+	"getmininginforesult-randomx":            "The RandomX seed epochs of the chain",
+
+	// RandomXEpochsResult help.
+	"randomxepochsresult-seedrotation": "Number of blocks between RandomX seed rotations",
+	"randomxepochsresult-fullmemory":   "Whether or not datasets are computed for fast mode hashing",
+	"randomxepochsresult-previous":     "The epoch before the current one, kept for reorganizations (omitted during the first epoch)",
+	"randomxepochsresult-current":      "The epoch of the next block",
+	"randomxepochsresult-next":         "The epoch after the next seed rotation, computed ahead of the rotation",
+
+	// RandomXEpochResult help.
+	"randomxepochresult-seedheight": "Height of the first block of the epoch",
+	"randomxepochresult-seedhash":   "The RandomX seed of the epoch",
+	"randomxepochresult-state":      "Whether the epoch is pending, building or ready",
 
 	// GetMiningInfoCmd help.
 	"getmininginfo--synopsis": "Returns a JSON object containing mining-related information.",
 
+	// GetMiningDistributionCmd help.
+	"getminingdistribution--synopsis": "Returns how the most recent blocks of the main chain are distributed over the proof-of-work algorithms.",
+	"getminingdistribution-window":    "The number of most recent blocks to summarize, limited to the height of the chain",
+
+	// GetMiningDistributionResult help.
+	"getminingdistributionresult-startheight": "Height of the first block of the window",
+	"getminingdistributionresult-endheight":   "Height of the latest best block",
+	"getminingdistributionresult-blocks":      "Number of blocks in the window",
+	"getminingdistributionresult-algorithms":  "The blocks of every proof-of-work algorithm, including those that mined none",
+
+	// MiningDistributionAlgoResult help.
+	"miningdistributionalgoresult-algorithm":  "The proof-of-work algorithm (randomx or mobilex)",
+	"miningdistributionalgoresult-blocks":     "Number of blocks of the window mined with the algorithm",
+	"miningdistributionalgoresult-share":      "Fraction of the blocks of the window mined with the algorithm",
+	"miningdistributionalgoresult-work":       "Hex-encoded work proved by the blocks of the algorithm according to their own difficulty",
+	"miningdistributionalgoresult-chainwork":  "Hex-encoded work the blocks of the algorithm added to the chain, normalized across the algorithms",
+	"miningdistributionalgoresult-lastheight": "Height of the latest block of the window mined with the algorithm (-1 if none)",
+
+	// MobileTemplateRequest help.
+	"mobiletemplaterequest-mode":         "This is 'template' or omitted",
+	"mobiletemplaterequest-capabilities": "List of capabilities",
+	"mobiletemplaterequest-device_info":  "Information about the mining device used to tune the template",
+
+	// DeviceInfo help.
+	"deviceinfo-device_type":   "The device platform (iOS or Android)",
+	"deviceinfo-soc_model":     "The system-on-chip model",
+	"deviceinfo-max_cores":     "Number of CPU cores available for mining",
+	"deviceinfo-ram_size_mb":   "Device memory in megabytes",
+	"deviceinfo-npu_capable":   "Whether or not the device has an NPU",
+	"deviceinfo-thermal_limit": "Maximum operating temperature in degrees Celsius",
+
+	// GetMobileBlockTemplateResultTx help.
+	"getmobileblocktemplateresulttx-data":          "Hex-encoded transaction data (byte-for-byte)",
+	"getmobileblocktemplateresulttx-hash":          "Hex-encoded transaction witness hash",
+	"getmobileblocktemplateresulttx-txid":          "The transaction id",
+	"getmobileblocktemplateresulttx-depends":       "Other transactions before this one (by 1-based index in the 'transactions' list) that must be present in the final block if this one is",
+	"getmobileblocktemplateresulttx-fee":           "Difference in value between transaction inputs and outputs (in Satoshi)",
+	"getmobileblocktemplateresulttx-operation_fee": "Portion of the fee owed to Shell operations (in Satoshi)",
+	"getmobileblocktemplateresulttx-sigops":        "Total number of signature operations as counted for purposes of block limits",
+	"getmobileblocktemplateresulttx-weight":        "The weight of the transaction",
+
+	// MobileWorkSize help.
+	"mobileworksize-search_space":   "Number of nonces to search per work unit",
+	"mobileworksize-npu_iterations": "Number of hashes between NPU calls",
+	"mobileworksize-cache_size":     "Working memory size in bytes",
+
+	// GetMobileBlockTemplateResult help.
+	"getmobileblocktemplateresult-bits":                       "Hex-encoded compressed difficulty",
+	"getmobileblocktemplateresult-curtime":                    "Current time as seen by the server (recommended for block time)",
+	"getmobileblocktemplateresult-height":                     "Height of the block to be solved",
+	"getmobileblocktemplateresult-previousblockhash":          "Hex-encoded big-endian hash of the previous block",
+	"getmobileblocktemplateresult-target":                     "Hex-encoded big-endian number which valid results must be less than",
+	"getmobileblocktemplateresult-version":                    "The block version",
+	"getmobileblocktemplateresult-mintime":                    "Minimum allowed time",
+	"getmobileblocktemplateresult-weightlimit":                "The current limit on the max allowed weight of a block",
+	"getmobileblocktemplateresult-sigoplimit":                 "Number of sigops allowed in blocks",
+	"getmobileblocktemplateresult-coinbasevalue":              "Total amount paid by the coinbase in Satoshi",
+	"getmobileblocktemplateresult-transactions":               "Array of non-coinbase transactions as JSON objects",
+	"getmobileblocktemplateresult-default_witness_commitment": "The witness commitment itself. Will be populated if the block has witness data",
+	"getmobileblocktemplateresult-coinbase1":                  "Hex-encoded coinbase transaction (without witness) up to the extra nonce",
+	"getmobileblocktemplateresult-coinbase2":                  "Hex-encoded coinbase transaction (without witness) after the extra nonce",
+	"getmobileblocktemplateresult-extranonce_size":            "Number of extra nonce bytes between coinbase1 and coinbase2",
+	"getmobileblocktemplateresult-merkle_branch":              "Hex-encoded hashes in internal byte order linking the coinbase to the merkle root",
+	"getmobileblocktemplateresult-merkleroot":                 "Hex-encoded merkle root for an all-zero extra nonce",
+	"getmobileblocktemplateresult-mobile_target":              "Hex-encoded share target adjusted for the device",
+	"getmobileblocktemplateresult-npu_work":                   "Hex-encoded NPU work parameters",
+	"getmobileblocktemplateresult-thermal_target":             "Target operating temperature in degrees Celsius",
+	"getmobileblocktemplateresult-work_size":                  "Work parameters tuned for the device",
+	"getmobileblocktemplateresult-device_optimized":           "Whether or not the template was tuned using device information",
+
+	// GetMobileBlockTemplateCmd help.
+	"getmobileblocktemplate--synopsis": "Returns a block template for mobile miners including mempool transactions, a coinbase split around an extra nonce and the coinbase merkle branch.",
+	"getmobileblocktemplate-request":   "Request object with optional device information",
+
+	// GetMobileMiningInfoResult help.
+	"getmobilemininginforesult-blocks":               "Height of the latest best block",
+	"getmobilemininginforesult-currentblocksize":     "Size of the latest best block",
+	"getmobilemininginforesult-currentblocktx":       "Number of transactions in the latest best block",
+	"getmobilemininginforesult-difficulty":           "Current target difficulty",
+	"getmobilemininginforesult-mobile_difficulty":    "Difficulty adjusted for mobile miners",
+	"getmobilemininginforesult-errors":               "Any current errors",
+	"getmobilemininginforesult-generate":             "Whether or not server is set to generate coins",
+	"getmobilemininginforesult-hashespersec":         "Recent hashes per second performance measurement while generating coins",
+	"getmobilemininginforesult-mobile_miners_active": "Number of active mobile miners",
+	"getmobilemininginforesult-mobile_hashrate":      "Estimated mobile network hashrate",
+	"getmobilemininginforesult-networkhashps":        "Estimated network hashes per second for the most recent blocks",
+	"getmobilemininginforesult-pooledtx":             "Number of transactions in the memory pool",
+	"getmobilemininginforesult-testnet":              "Whether or not server is using testnet",
+	"getmobilemininginforesult-thermal_compliance":   "Percentage of thermally compliant blocks",
+
+	// GetMobileMiningInfoCmd help.
+	"getmobilemininginfo--synopsis": "Returns a JSON object containing mobile mining information.",
+
+	// ThermalProofSubmission help.
+	"thermalproofsubmission-temperature": "Device temperature in degrees Celsius",
+	"thermalproofsubmission-frequency":   "Operating frequency in MHz",
+	"thermalproofsubmission-power_usage": "Estimated power usage in watts",
+
+	// SubmitMobileBlockCmd help.
+	"submitmobileblock--synopsis":    "Attempts to submit a new block mined by a mobile device to the network.",
+	"submitmobileblock-hexblock":     "Serialized, hex-encoded block",
+	"submitmobileblock-thermalproof": "Thermal compliance data reported by the device",
+	"submitmobileblock--condition0":  "Block successfully submitted",
+	"submitmobileblock--condition1":  "Block rejected",
+	"submitmobileblock--result1":     "The reason the block was rejected",
+
+	// GetMobileWorkResult help.
+	"getmobileworkresult-work_id":       "Identifier of the work unit",
+	"getmobileworkresult-data":          "Hex-encoded block header without the thermal proof",
+	"getmobileworkresult-target":        "Hex-encoded big-endian target the block hash must not exceed",
+	"getmobileworkresult-npu_work":      "Hex-encoded NPU work parameters",
+	"getmobileworkresult-thermal_limit": "Maximum operating temperature in degrees Celsius",
+
+	// GetMobileWorkCmd help.
+	"getmobilework--synopsis":   "Returns a block header to mine for mobile devices.",
+	"getmobilework-deviceclass": "The device class (flagship, midrange or budget)",
+
+	// SubmitMobileWorkCmd help.
+	"submitmobilework--synopsis":    "Submits a solution for work returned by getmobilework.",
+	"submitmobilework-workid":       "Identifier of the work unit",
+	"submitmobilework-nonce":        "Hex-encoded nonce",
+	"submitmobilework-thermalproof": "Hex-encoded thermal proof",
+	"submitmobilework--condition0":  "Block successfully submitted",
+	"submitmobilework--condition1":  "Block rejected",
+	"submitmobilework--result1":     "The reason the block was rejected",
+
+	// ValidateThermalProofCmd help.
+	"validatethermalproof--synopsis":    "Validates the thermal proof of a block.",
+	"validatethermalproof-blockhash":    "The hash of the block",
+	"validatethermalproof-thermalproof": "The expected thermal proof",
+	"validatethermalproof--result0":     "Whether or not the thermal proof is valid",
+
+	// GetMobileStatsResult help.
+	"getmobilestatsresult-total_mobile_miners":         "Number of active mobile miners",
+	"getmobilestatsresult-mobile_hashrate":             "Estimated mobile network hashrate",
+	"getmobilestatsresult-device_breakdown":            "Number of miners by device type",
+	"getmobilestatsresult-device_breakdown--key":       "device",
+	"getmobilestatsresult-device_breakdown--value":     "n",
+	"getmobilestatsresult-device_breakdown--desc":      "The device as the key and the number of miners as the value",
+	"getmobilestatsresult-geographic_breakdown":        "Number of miners by region",
+	"getmobilestatsresult-geographic_breakdown--key":   "region",
+	"getmobilestatsresult-geographic_breakdown--value": "n",
+	"getmobilestatsresult-geographic_breakdown--desc":  "The region as the key and the number of miners as the value",
+	"getmobilestatsresult-thermal_violations":          "Number of blocks rejected for thermal violations",
+	"getmobilestatsresult-average_temperature":         "Average reported device temperature",
+	"getmobilestatsresult-npu_utilization":             "Percentage of miners using the NPU",
+	"getmobilestatsresult-blocks_found_mobile":         "Number of blocks found by mobile miners",
+
+	// GetMobileStatsCmd help.
+	"getmobilestats--synopsis": "Returns statistics about mobile mining.",
+	"getmobilestats-window":    "Time window in minutes",
+
 	// GetNetworkHashPSCmd help.
 	"getnetworkhashps--synopsis": "Returns the estimated network hashes per second for the block heights provided by the parameters.",
 	"getnetworkhashps-blocks":    "The number of blocks, or -1 for blocks since last difficulty change",
@@ -787,7 +956,12 @@ var rpcResultTypes = map[string][]interface{}{
 	"getheaders":             {(*[]string)(nil)},
 	"getinfo":                {(*btcjson.InfoChainResult)(nil)},
 	"getmempoolinfo":         {(*btcjson.GetMempoolInfoResult)(nil)},
+	"getminingdistribution":  {(*btcjson.GetMiningDistributionResult)(nil)},
 	"getmininginfo":          {(*btcjson.GetMiningInfoResult)(nil)},
+	"getmobileblocktemplate": {(*btcjson.GetMobileBlockTemplateResult)(nil)},
+	"getmobilemininginfo":    {(*btcjson.GetMobileMiningInfoResult)(nil)},
+	"getmobilestats":         {(*btcjson.GetMobileStatsResult)(nil)},
+	"getmobilework":          {(*btcjson.GetMobileWorkResult)(nil)},
 	"getnettotals":           {(*btcjson.GetNetTotalsResult)(nil)},
 	"getnetworkhashps":       {(*float64)(nil)},
 	"getnodeaddresses":       {(*[]btcjson.GetNodeAddressesResult)(nil)},
@@ -806,8 +980,11 @@ var rpcResultTypes = map[string][]interface{}{
 	"signmessagewithprivkey": {(*string)(nil)},
 	"stop":                   {(*string)(nil)},
 	"submitblock":            {nil, (*string)(nil)},
+	"submitmobileblock":      {nil, (*string)(nil)},
+	"submitmobilework":       {nil, (*string)(nil)},
 	"uptime":                 {(*int64)(nil)},
 	"validateaddress":        {(*btcjson.ValidateAddressChainResult)(nil)},
+	"validatethermalproof":   {(*bool)(nil)},
 	"verifychain":            {(*bool)(nil)},
 	"verifymessage":          {(*bool)(nil)},
 	"version":                {(*map[string]btcjson.VersionResult)(nil)},
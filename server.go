@@ -36,7 +36,9 @@ import (
 	"github.com/toole-brendan/shell/mempool"
 	"github.com/toole-brendan/shell/mining"
 	"github.com/toole-brendan/shell/mining/cpuminer"
+	"github.com/toole-brendan/shell/mining/randomx"
 	"github.com/toole-brendan/shell/netsync"
+	"github.com/toole-brendan/shell/openmetrics"
 	"github.com/toole-brendan/shell/peer"
 	"github.com/toole-brendan/shell/txscript"
 	"github.com/toole-brendan/shell/wire"
@@ -213,10 +215,14 @@ type server struct {
 	sigCache             *txscript.SigCache
 	hashCache            *txscript.HashCache
 	rpcServer            *rpcServer
+	metricsServer        *metricsServer
 	syncManager          *netsync.SyncManager
 	chain                *blockchain.BlockChain
 	txMemPool            *mempool.TxPool
 	cpuMiner             *cpuminer.CPUMiner
+	randomXSeeds         *randomx.SeedManager
+	miningPolicy         *mining.MiningPolicy
+	randomXJobServer     *randomx.JobServer
 	modifyRebroadcastInv chan interface{}
 	p2pDowngrader        *peer.P2PDowngrader
 	newPeers             chan *serverPeer
@@ -2184,6 +2190,7 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 		TrickleInterval:     cfg.TrickleInterval,
 		DisableStallHandler: cfg.DisableStallHandler,
 		UsingV2Conn:         cfg.V2Transport,
+		AllowSelfConns:      cfg.allowSelfConns,
 	}
 }
 
@@ -2536,10 +2543,23 @@ func (s *server) Start() {
 		s.rpcServer.Start()
 	}
 
+	// Start computing the RandomX epoch of the next block.
+	s.randomXSeeds.Update(s.chain.BestSnapshot().Height + 1)
+
 	// Start the CPU miner if generation is enabled.
 	if cfg.Generate {
 		s.cpuMiner.Start()
 	}
+
+	// Start serving metrics if enabled.
+	if s.metricsServer != nil {
+		s.metricsServer.Start()
+	}
+
+	// Start serving RandomX jobs to external miners if enabled.
+	if s.randomXJobServer != nil {
+		s.randomXJobServer.Start()
+	}
 }
 
 // Stop gracefully shuts down the server by stopping and disconnecting all
@@ -2556,11 +2576,24 @@ func (s *server) Stop() error {
 	// Stop the CPU miner if needed
 	s.cpuMiner.Stop()
 
+	// Stop computing RandomX epochs.
+	s.randomXSeeds.Stop()
+
 	// Shutdown the RPC server if it's not disabled.
 	if !cfg.DisableRPC {
 		s.rpcServer.Stop()
 	}
 
+	// Stop serving metrics if enabled.
+	if s.metricsServer != nil {
+		s.metricsServer.Stop()
+	}
+
+	// Stop serving RandomX jobs if enabled.
+	if s.randomXJobServer != nil {
+		s.randomXJobServer.Stop()
+	}
+
 	// Save fee estimator state in the database.
 	s.db.Update(func(tx database.Tx) error {
 		metadata := tx.Metadata()
@@ -2574,6 +2607,25 @@ func (s *server) Stop() error {
 	return nil
 }
 
+// handleRandomXSeedNotification keeps the RandomX seed manager at the height
+// of the next block as blocks are connected to and disconnected from the main
+// chain.
+func (s *server) handleRandomXSeedNotification(n *blockchain.Notification) {
+	switch n.Type {
+	case blockchain.NTBlockConnected, blockchain.NTBlockDisconnected:
+		s.randomXSeeds.Update(s.chain.BestSnapshot().Height + 1)
+	}
+}
+
+// handleRandomXJobNotification hands out jobs for the new chain tip to the
+// external RandomX miners when the main chain changes.
+func (s *server) handleRandomXJobNotification(n *blockchain.Notification) {
+	switch n.Type {
+	case blockchain.NTBlockConnected, blockchain.NTBlockDisconnected:
+		s.randomXJobServer.Refresh()
+	}
+}
+
 // WaitForShutdown blocks until the main listener and peer handlers are stopped.
 func (s *server) WaitForShutdown() {
 	s.wg.Wait()
@@ -2767,6 +2819,65 @@ func setupRPCListeners() ([]net.Listener, error) {
 	return listeners, nil
 }
 
+// setupRandomXJobListeners returns a slice of listeners that are configured
+// for use with the RandomX job server depending on the configured listen
+// addresses.
+func setupRandomXJobListeners() ([]net.Listener, error) {
+	netAddrs, err := parseListeners(cfg.RandomXJobListeners)
+	if err != nil {
+		return nil, err
+	}
+
+	listeners := make([]net.Listener, 0, len(netAddrs))
+	for _, addr := range netAddrs {
+		listener, err := net.Listen(addr.Network(), addr.String())
+		if err != nil {
+			srvrLog.Warnf("Can't listen on %s: %v", addr, err)
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// newRandomXJobTemplate returns a new block template for the external RandomX
+// miners paying to one of the configured mining addresses.  No templates are
+// created while the chain is syncing since their blocks would be stale.
+func (s *server) newRandomXJobTemplate(g *mining.BlkTmplGenerator) (*randomx.BlockTemplate, error) {
+	if s.chain.BestSnapshot().Height != 0 && !s.syncManager.IsCurrent() {
+		return nil, errors.New("chain is not current")
+	}
+
+	payToAddr := cfg.miningAddrs[randomUint16Number(uint16(len(cfg.miningAddrs)))]
+	template, err := g.NewBlockTemplate(payToAddr)
+	if err != nil {
+		return nil, err
+	}
+	return &randomx.BlockTemplate{
+		Block:             template.Block,
+		Fees:              template.Fees,
+		SigOpCounts:       template.SigOpCosts,
+		Height:            template.Height,
+		ValidPayAddress:   template.ValidPayAddress,
+		WitnessCommitment: template.WitnessCommitment,
+	}, nil
+}
+
+// submitRandomXJobBlock processes a block solved by an external RandomX miner
+// using the same rules as blocks coming from other nodes, which in turn relays
+// it to the network.
+func (s *server) submitRandomXJobBlock(block *btcutil.Block) error {
+	isOrphan, err := s.syncManager.ProcessBlock(block, blockchain.BFNone)
+	if err != nil {
+		return err
+	}
+	if isOrphan {
+		return errors.New("block is an orphan")
+	}
+	return nil
+}
+
 // newServer returns a new btcd server configured to listen on addr for the
 // bitcoin network type specified by chainParams.  Use start to begin accepting
 // connections from peers.
@@ -2832,6 +2943,9 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		agentBlacklist:       agentBlacklist,
 		agentWhitelist:       agentWhitelist,
 	}
+	if cfg.timeSource != nil {
+		s.timeSource = cfg.timeSource
+	}
 
 	// Create the transaction and address indexes if needed.
 	//
@@ -2882,6 +2996,18 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		btcdLog.Infof("Prune set to %d MiB", cfg.Prune)
 	}
 
+	// Blocks are validated with the proof-of-work hash of their algorithm,
+	// which for both RandomX and MobileX is keyed by the seed of the
+	// block's RandomX epoch.  The seed manager is shared by validation,
+	// the CPU miner and the RandomX job server, none of which needs more
+	// than the cache of each epoch, so no datasets are computed.
+	s.randomXSeeds = randomx.NewSeedManager(&randomx.SeedConfig{
+		GenesisHash: s.chainParams.GenesisHash,
+		Rotation:    s.chainParams.RandomXSeedRotation,
+	})
+	s.miningPolicy = mining.NewMiningPolicy(s.chainParams)
+	s.miningPolicy.SetRandomXSeeds(s.randomXSeeds)
+
 	// Create a new block chain instance with the appropriate configuration.
 	var err error
 	s.chain, err = blockchain.New(&blockchain.Config{
@@ -2890,6 +3016,7 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		ChainParams:      s.chainParams,
 		Checkpoints:      checkpoints,
 		TimeSource:       s.timeSource,
+		PowHasher:        s.miningPolicy,
 		SigCache:         s.sigCache,
 		IndexManager:     indexManager,
 		HashCache:        s.hashCache,
@@ -2900,6 +3027,10 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		return nil, err
 	}
 
+	// Track the RandomX seed epochs of the chain so the next epoch is
+	// computed before the seed rotation is reached.
+	s.chain.Subscribe(s.handleRandomXSeedNotification)
+
 	// Search for a FeeEstimator state in the database. If none can be found
 	// or if it cannot be loaded, create a new one.
 	db.Update(func(tx database.Tx) error {
@@ -2991,6 +3122,7 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		BlockTemplateGenerator: blockTemplateGenerator,
 		MiningAddrs:            cfg.miningAddrs,
 		ProcessBlock:           s.syncManager.ProcessBlock,
+		PowHasher:              s.miningPolicy,
 		ConnectedCount:         s.ConnectedCount,
 		IsCurrent:              s.syncManager.IsCurrent,
 	})
@@ -3105,6 +3237,7 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 			TxMemPool:    s.txMemPool,
 			Generator:    blockTemplateGenerator,
 			CPUMiner:     s.cpuMiner,
+			RandomXSeeds: s.randomXSeeds,
 			TxIndex:      s.txIndex,
 			AddrIndex:    s.addrIndex,
 			CfIndex:      s.cfIndex,
@@ -3121,6 +3254,46 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		}()
 	}
 
+	if len(cfg.MetricsListeners) > 0 {
+		// Setup listeners for the configured metrics listen addresses.
+		metricsListeners, err := setupMetricsListeners()
+		if err != nil {
+			return nil, err
+		}
+		if len(metricsListeners) == 0 {
+			return nil, errors.New("METRICS: No valid listen address")
+		}
+
+		registry := openmetrics.NewRegistry()
+		registry.Register(newNodeMetrics(&s))
+		s.metricsServer = newMetricsServer(metricsListeners, registry)
+	}
+
+	if len(cfg.RandomXJobListeners) > 0 {
+		// Setup listeners for the configured RandomX job listen
+		// addresses.
+		jobListeners, err := setupRandomXJobListeners()
+		if err != nil {
+			return nil, err
+		}
+		if len(jobListeners) == 0 {
+			return nil, errors.New("RNDX: No valid listen address")
+		}
+
+		s.randomXJobServer = randomx.NewJobServer(&randomx.JobServerConfig{
+			Listeners:   jobListeners,
+			Seeds:       s.randomXSeeds,
+			MaxSessions: cfg.RandomXJobMaxClients,
+			Password:    cfg.RandomXJobPass,
+			Whitelist:   cfg.rxJobWhitelists,
+			BlockTemplateGenerator: func() (*randomx.BlockTemplate, error) {
+				return s.newRandomXJobTemplate(blockTemplateGenerator)
+			},
+			SubmitBlock: s.submitRandomXJobBlock,
+		})
+		s.chain.Subscribe(s.handleRandomXJobNotification)
+	}
+
 	return &s, nil
 }
 
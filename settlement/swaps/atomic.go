@@ -325,13 +325,15 @@ func generateSwapID(initiator, participant *btcec.PublicKey, secretHash [32]byte
 
 // SwapManager manages multiple atomic swaps
 type SwapManager struct {
-	swaps map[[32]byte]*AtomicSwap
+	swaps      map[[32]byte]*AtomicSwap
+	crossChain map[[32]byte]*CrossChainSwap
 }
 
 // NewSwapManager creates a new swap manager
 func NewSwapManager() *SwapManager {
 	return &SwapManager{
-		swaps: make(map[[32]byte]*AtomicSwap),
+		swaps:      make(map[[32]byte]*AtomicSwap),
+		crossChain: make(map[[32]byte]*CrossChainSwap),
 	}
 }
 
@@ -355,6 +357,54 @@ func (sm *SwapManager) GetSwap(swapID [32]byte) (*AtomicSwap, error) {
 	return swap, nil
 }
 
+// AddCrossChainSwap adds a cross-chain swap and its Shell leg to the manager
+func (sm *SwapManager) AddCrossChainSwap(swap *CrossChainSwap) error {
+	if swap == nil {
+		return fmt.Errorf("swap cannot be nil")
+	}
+
+	if err := sm.AddSwap(swap.ShellSwap); err != nil {
+		return err
+	}
+
+	sm.crossChain[swap.ShellSwap.SwapID] = swap
+	return nil
+}
+
+// GetCrossChainSwap retrieves a cross-chain swap by the ID of its Shell leg
+func (sm *SwapManager) GetCrossChainSwap(swapID [32]byte) (*CrossChainSwap, error) {
+	swap, exists := sm.crossChain[swapID]
+	if !exists {
+		return nil, fmt.Errorf("swap not found")
+	}
+
+	return swap, nil
+}
+
+// ProcessEthereumReceipt records secrets revealed by Redeemed events in the
+// receipt against the managed swaps and returns the swaps that learned their
+// secret, so the Shell leg can be redeemed with it
+func (sm *SwapManager) ProcessEthereumReceipt(receipt *EthereumReceipt) []*CrossChainSwap {
+	var updated []*CrossChainSwap
+
+	for _, swap := range sm.crossChain {
+		ethSwap, ok := swap.CounterSwap.(*EthereumSwap)
+		if !ok || ethSwap.Secret != nil {
+			continue
+		}
+
+		secret, err := ExtractEthereumSecret(receipt, ethSwap.SecretHash)
+		if err != nil {
+			continue
+		}
+
+		ethSwap.Secret = secret
+		updated = append(updated, swap)
+	}
+
+	return updated
+}
+
 // ListActiveSwaps returns all active swaps
 func (sm *SwapManager) ListActiveSwaps() []*AtomicSwap {
 	var active []*AtomicSwap
@@ -376,11 +426,12 @@ func (sm *SwapManager) CleanupExpiredSwaps() {
 		if now.After(swap.ExpiresAt) && swap.Status == SwapStatusActive {
 			swap.Status = SwapStatusExpired
 			delete(sm.swaps, id)
+			delete(sm.crossChain, id)
 		}
 	}
 }
 
-// Cross-chain integration interfaces (to be implemented)
+// Cross-chain integration interfaces
 
 // BitcoinAdapter interface for Bitcoin atomic swaps
 type BitcoinAdapter interface {
@@ -394,7 +445,7 @@ type BitcoinAdapter interface {
 type EthereumAdapter interface {
 	CreateHTLCContract(secretHash [32]byte, participantAddr, initiatorAddr []byte, timeout uint64, amount *big.Int) ([]byte, error)
 	CreateRedeemTx(contractAddr []byte, secret []byte) ([]byte, error)
-	CreateRefundTx(contractAddr []byte) ([]byte, error)
+	CreateRefundTx(contractAddr []byte, secretHash [32]byte) ([]byte, error)
 }
 
 // CrossChainSwap represents a cross-chain atomic swap
@@ -404,7 +455,56 @@ type CrossChainSwap struct {
 	Chain       ChainType
 }
 
-// CreateCrossChainSwap creates a cross-chain atomic swap
+// CounterSwapParams contains the counter-chain side of a cross-chain swap.
+// The counter leg locks funds for the Shell initiator, who reveals the secret
+// when redeeming them, so its lock time must expire before the Shell leg.
+// LockTime is therefore a timestamp: block heights of the counter chain
+// cannot be ordered against the expiry of the Shell leg
+type CounterSwapParams struct {
+	Chain    ChainType
+	Amount   uint64
+	LockTime uint32
+
+	// Bitcoin builds the counter leg when Chain is ChainBitcoin
+	Bitcoin BitcoinAdapter
+
+	// Ethereum builds the counter leg when Chain is ChainEthereum. The
+	// HTLC contract lives at EthContract, EthInitiator funds it on behalf of
+	// the Shell participant and EthParticipant is the Shell initiator's
+	// account. EthValue is the amount in wei
+	Ethereum       EthereumAdapter
+	EthContract    []byte
+	EthInitiator   []byte
+	EthParticipant []byte
+	EthValue       *big.Int
+}
+
+// BitcoinSwap is the Bitcoin leg of a cross-chain swap
+type BitcoinSwap struct {
+	SecretHash [32]byte
+	HTLCScript []byte
+	ContractTx *wire.MsgTx
+	Amount     uint64
+	LockTime   uint32
+}
+
+// EthereumSwap is the Ethereum leg of a cross-chain swap
+type EthereumSwap struct {
+	SecretHash   [32]byte
+	Contract     []byte
+	Initiator    []byte
+	Participant  []byte
+	InitiateData []byte
+	Value        *big.Int
+	RefundTime   uint64
+
+	// Secret is set once the secret is revealed on Ethereum
+	Secret []byte
+}
+
+// CreateCrossChainSwap creates a cross-chain atomic swap with only the Shell
+// leg populated. Use NewCrossChainSwap to build the counter-chain leg through
+// an adapter
 func CreateCrossChainSwap(params *AtomicSwapParams, counterChain ChainType) (*CrossChainSwap, error) {
 	// Create Shell side swap
 	shellSwap, err := NewAtomicSwap(params)
@@ -417,8 +517,114 @@ func CreateCrossChainSwap(params *AtomicSwapParams, counterChain ChainType) (*Cr
 		Chain:     counterChain,
 	}
 
-	// Counter-chain swap creation would be handled by respective adapters
-	// This is a placeholder for the cross-chain integration
+	return swap, nil
+}
+
+// NewCrossChainSwap creates a cross-chain atomic swap with both legs. The
+// counter leg is built by the adapter for the counter chain
+func NewCrossChainSwap(params *AtomicSwapParams, counter *CounterSwapParams) (*CrossChainSwap, error) {
+	if counter == nil {
+		return nil, fmt.Errorf("counter-chain parameters required")
+	}
+
+	swap, err := CreateCrossChainSwap(params, counter.Chain)
+	if err != nil {
+		return nil, err
+	}
+
+	// Otherwise the initiator could redeem the counter leg, revealing the
+	// secret, after the Shell leg has become refundable and take both.
+	if !isTimeLock(counter.LockTime) {
+		return nil, fmt.Errorf("counter lock time %d is a block height, "+
+			"not a timestamp", counter.LockTime)
+	}
+	if int64(counter.LockTime) >= swap.ShellSwap.ExpiresAt.Unix() {
+		return nil, fmt.Errorf("counter lock time %d does not expire "+
+			"before the Shell leg at %d", counter.LockTime,
+			swap.ShellSwap.ExpiresAt.Unix())
+	}
+
+	switch counter.Chain {
+	case ChainBitcoin:
+		if counter.Bitcoin == nil {
+			return nil, fmt.Errorf("bitcoin adapter required")
+		}
+
+		// Roles are reversed on the counter chain: the Shell participant
+		// funds the contract and the Shell initiator redeems it.
+		btcSwap, err := createBitcoinSwap(swap.ShellSwap, counter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Bitcoin swap: %v", err)
+		}
+		swap.CounterSwap = btcSwap
+
+	case ChainEthereum:
+		if counter.Ethereum == nil {
+			return nil, fmt.Errorf("ethereum adapter required")
+		}
+
+		ethSwap, err := createEthereumSwap(swap.ShellSwap, counter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Ethereum swap: %v", err)
+		}
+		swap.CounterSwap = ethSwap
+
+	default:
+		return nil, fmt.Errorf("unsupported counter chain %s", counter.Chain)
+	}
 
 	return swap, nil
 }
+
+// createBitcoinSwap builds the Bitcoin leg matching the given Shell swap
+func createBitcoinSwap(shellSwap *AtomicSwap, counter *CounterSwapParams) (*BitcoinSwap, error) {
+	htlcScript, err := counter.Bitcoin.CreateHTLCScript(shellSwap.SecretHash,
+		shellSwap.Initiator.SerializeCompressed(),
+		shellSwap.Participant.SerializeCompressed(), counter.LockTime)
+	if err != nil {
+		return nil, err
+	}
+
+	contractTx, err := counter.Bitcoin.CreateContractTx(htlcScript, counter.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BitcoinSwap{
+		SecretHash: shellSwap.SecretHash,
+		HTLCScript: htlcScript,
+		ContractTx: contractTx,
+		Amount:     counter.Amount,
+		LockTime:   counter.LockTime,
+	}, nil
+}
+
+// createEthereumSwap builds the Ethereum leg matching the given Shell swap
+func createEthereumSwap(shellSwap *AtomicSwap, counter *CounterSwapParams) (*EthereumSwap, error) {
+	if len(counter.EthContract) != EthereumAddressSize {
+		return nil, fmt.Errorf("invalid contract address length %d",
+			len(counter.EthContract))
+	}
+
+	value := counter.EthValue
+	if value == nil {
+		value = new(big.Int).SetUint64(counter.Amount)
+	}
+
+	data, err := counter.Ethereum.CreateHTLCContract(shellSwap.SecretHash,
+		counter.EthParticipant, counter.EthInitiator,
+		uint64(counter.LockTime), value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EthereumSwap{
+		SecretHash:   shellSwap.SecretHash,
+		Contract:     counter.EthContract,
+		Initiator:    counter.EthInitiator,
+		Participant:  counter.EthParticipant,
+		InitiateData: data,
+		Value:        value,
+		RefundTime:   uint64(counter.LockTime),
+	}, nil
+}
@@ -0,0 +1,629 @@
+package swaps
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	btctxscript "github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// HTLCVariant selects the output type used to lock funds on Bitcoin
+type HTLCVariant int
+
+const (
+	// HTLCVariantP2WSH locks funds in a BIP199 script behind a P2WSH output
+	HTLCVariantP2WSH HTLCVariant = iota
+
+	// HTLCVariantTaproot locks funds in a Taproot output with separate
+	// redeem and refund leaves and an unspendable internal key
+	HTLCVariantTaproot
+)
+
+// String returns a human readable name for the variant
+func (v HTLCVariant) String() string {
+	switch v {
+	case HTLCVariantP2WSH:
+		return "p2wsh"
+	case HTLCVariantTaproot:
+		return "taproot"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(v))
+	}
+}
+
+const (
+	// SecretSize is the required size of a swap secret. Enforcing a fixed
+	// size keeps the secret spendable on every chain taking part in the swap
+	SecretSize = 32
+
+	// DefaultBitcoinFeeRate is the fee rate used when none is configured,
+	// in satoshis per 1000 virtual bytes
+	DefaultBitcoinFeeRate btcutil.Amount = 10000
+
+	// bitcoinDustLimit is the smallest output the adapter will create
+	bitcoinDustLimit = 546
+)
+
+// numsInternalKey is the BIP341 "nothing up my sleeve" point. Using it as
+// the internal key disables the Taproot key path so the HTLC can only be
+// spent through one of its script leaves
+var numsInternalKey = mustParseXOnly(
+	"50929b74c1a04954b78b4b6035e97a5e078a5a0f28ec96d547bfee9ace803ac0")
+
+// BitcoinAdapterConfig contains the settings for a BTCAdapter
+type BitcoinAdapterConfig struct {
+	// Params selects the Bitcoin network used for address encoding
+	Params *chaincfg.Params
+
+	// Variant selects between P2WSH and Taproot HTLC outputs
+	Variant HTLCVariant
+
+	// FeeRate is the fee rate for redeem and refund transactions in
+	// satoshis per 1000 virtual bytes
+	FeeRate btcutil.Amount
+}
+
+// BitcoinHTLC describes a Hash Time Locked Contract output on Bitcoin
+type BitcoinHTLC struct {
+	Variant           HTLCVariant
+	SecretHash        [32]byte
+	ParticipantPubKey []byte
+	InitiatorPubKey   []byte
+	LockTime          uint32
+
+	// WitnessScript is the BIP199 script for P2WSH contracts
+	WitnessScript []byte
+
+	// RedeemLeaf and RefundLeaf are the script leaves of Taproot contracts
+	RedeemLeaf         btctxscript.TapLeaf
+	RefundLeaf         btctxscript.TapLeaf
+	RedeemControlBlock []byte
+	RefundControlBlock []byte
+
+	// PkScript is the output script that funds are sent to
+	PkScript []byte
+}
+
+// Address returns the address that funds the contract on the given network
+func (h *BitcoinHTLC) Address(params *chaincfg.Params) (btcutil.Address, error) {
+	_, addrs, _, err := btctxscript.ExtractPkScriptAddrs(h.PkScript, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) != 1 {
+		return nil, fmt.Errorf("unexpected HTLC output script")
+	}
+
+	return addrs[0], nil
+}
+
+// BTCAdapter implements BitcoinAdapter on top of the btcsuite libraries.
+// Contract transactions are returned unfunded so the caller's wallet can add
+// inputs and change, while redeem and refund transactions are complete apart
+// from their signatures, which are added with SignRedeemTx and SignRefundTx
+type BTCAdapter struct {
+	cfg BitcoinAdapterConfig
+
+	mtx       sync.RWMutex
+	contracts map[string]*BitcoinHTLC
+}
+
+// Ensure BTCAdapter implements the BitcoinAdapter interface
+var _ BitcoinAdapter = (*BTCAdapter)(nil)
+
+// NewBTCAdapter creates a new Bitcoin adapter with the given configuration
+func NewBTCAdapter(cfg *BitcoinAdapterConfig) (*BTCAdapter, error) {
+	if cfg == nil || cfg.Params == nil {
+		return nil, fmt.Errorf("bitcoin network parameters required")
+	}
+
+	if cfg.Variant != HTLCVariantP2WSH && cfg.Variant != HTLCVariantTaproot {
+		return nil, fmt.Errorf("unsupported HTLC variant %v", cfg.Variant)
+	}
+
+	adapter := &BTCAdapter{
+		cfg:       *cfg,
+		contracts: make(map[string]*BitcoinHTLC),
+	}
+	if adapter.cfg.FeeRate == 0 {
+		adapter.cfg.FeeRate = DefaultBitcoinFeeRate
+	}
+
+	return adapter, nil
+}
+
+// NewHTLC builds a contract paying to the participant when the secret is
+// revealed, or back to the initiator once lockTime has passed. lockTime is
+// an absolute lock time as interpreted by OP_CHECKLOCKTIMEVERIFY
+func (a *BTCAdapter) NewHTLC(secretHash [32]byte, participantPubkey,
+	initiatorPubkey []byte, lockTime uint32) (*BitcoinHTLC, error) {
+
+	participant, err := btcec.ParsePubKey(participantPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid participant public key: %v", err)
+	}
+
+	initiator, err := btcec.ParsePubKey(initiatorPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid initiator public key: %v", err)
+	}
+
+	if lockTime == 0 {
+		return nil, fmt.Errorf("lock time must be specified")
+	}
+
+	htlc := &BitcoinHTLC{
+		Variant:           a.cfg.Variant,
+		SecretHash:        secretHash,
+		ParticipantPubKey: participant.SerializeCompressed(),
+		InitiatorPubKey:   initiator.SerializeCompressed(),
+		LockTime:          lockTime,
+	}
+
+	switch a.cfg.Variant {
+	case HTLCVariantP2WSH:
+		err = buildP2WSHContract(htlc)
+	case HTLCVariantTaproot:
+		err = buildTaprootContract(htlc, participant, initiator)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	a.mtx.Lock()
+	a.contracts[string(htlc.PkScript)] = htlc
+	if htlc.WitnessScript != nil {
+		a.contracts[string(htlc.WitnessScript)] = htlc
+	}
+	a.mtx.Unlock()
+
+	return htlc, nil
+}
+
+// buildP2WSHContract creates the BIP199 witness script for the contract
+func buildP2WSHContract(htlc *BitcoinHTLC) error {
+	builder := btctxscript.NewScriptBuilder()
+
+	builder.AddOp(btctxscript.OP_IF)
+	builder.AddOp(btctxscript.OP_SIZE)
+	builder.AddInt64(SecretSize)
+	builder.AddOp(btctxscript.OP_EQUALVERIFY)
+	builder.AddOp(btctxscript.OP_SHA256)
+	builder.AddData(htlc.SecretHash[:])
+	builder.AddOp(btctxscript.OP_EQUALVERIFY)
+	builder.AddOp(btctxscript.OP_DUP)
+	builder.AddOp(btctxscript.OP_HASH160)
+	builder.AddData(btcutil.Hash160(htlc.ParticipantPubKey))
+
+	builder.AddOp(btctxscript.OP_ELSE)
+	builder.AddInt64(int64(htlc.LockTime))
+	builder.AddOp(btctxscript.OP_CHECKLOCKTIMEVERIFY)
+	builder.AddOp(btctxscript.OP_DROP)
+	builder.AddOp(btctxscript.OP_DUP)
+	builder.AddOp(btctxscript.OP_HASH160)
+	builder.AddData(btcutil.Hash160(htlc.InitiatorPubKey))
+	builder.AddOp(btctxscript.OP_ENDIF)
+
+	builder.AddOp(btctxscript.OP_EQUALVERIFY)
+	builder.AddOp(btctxscript.OP_CHECKSIG)
+
+	script, err := builder.Script()
+	if err != nil {
+		return fmt.Errorf("failed to create HTLC script: %v", err)
+	}
+
+	scriptHash := sha256.Sum256(script)
+	pkScript, err := btctxscript.NewScriptBuilder().
+		AddOp(btctxscript.OP_0).
+		AddData(scriptHash[:]).
+		Script()
+	if err != nil {
+		return fmt.Errorf("failed to create P2WSH script: %v", err)
+	}
+
+	htlc.WitnessScript = script
+	htlc.PkScript = pkScript
+
+	return nil
+}
+
+// buildTaprootContract creates the redeem and refund leaves for the contract
+// and commits to them under the unspendable internal key
+func buildTaprootContract(htlc *BitcoinHTLC, participant,
+	initiator *btcec.PublicKey) error {
+
+	redeemScript, err := btctxscript.NewScriptBuilder().
+		AddOp(btctxscript.OP_SIZE).
+		AddInt64(SecretSize).
+		AddOp(btctxscript.OP_EQUALVERIFY).
+		AddOp(btctxscript.OP_SHA256).
+		AddData(htlc.SecretHash[:]).
+		AddOp(btctxscript.OP_EQUALVERIFY).
+		AddData(schnorr.SerializePubKey(participant)).
+		AddOp(btctxscript.OP_CHECKSIG).
+		Script()
+	if err != nil {
+		return fmt.Errorf("failed to create redeem leaf: %v", err)
+	}
+
+	refundScript, err := btctxscript.NewScriptBuilder().
+		AddInt64(int64(htlc.LockTime)).
+		AddOp(btctxscript.OP_CHECKLOCKTIMEVERIFY).
+		AddOp(btctxscript.OP_DROP).
+		AddData(schnorr.SerializePubKey(initiator)).
+		AddOp(btctxscript.OP_CHECKSIG).
+		Script()
+	if err != nil {
+		return fmt.Errorf("failed to create refund leaf: %v", err)
+	}
+
+	htlc.RedeemLeaf = btctxscript.NewBaseTapLeaf(redeemScript)
+	htlc.RefundLeaf = btctxscript.NewBaseTapLeaf(refundScript)
+
+	tree := btctxscript.AssembleTaprootScriptTree(htlc.RedeemLeaf, htlc.RefundLeaf)
+	rootHash := tree.RootNode.TapHash()
+	outputKey := btctxscript.ComputeTaprootOutputKey(numsInternalKey, rootHash[:])
+
+	for i, proof := range tree.LeafMerkleProofs {
+		controlBlock := proof.ToControlBlock(numsInternalKey)
+		cbBytes, err := controlBlock.ToBytes()
+		if err != nil {
+			return fmt.Errorf("failed to encode control block: %v", err)
+		}
+
+		if i == 0 {
+			htlc.RedeemControlBlock = cbBytes
+		} else {
+			htlc.RefundControlBlock = cbBytes
+		}
+	}
+
+	pkScript, err := btctxscript.PayToTaprootScript(outputKey)
+	if err != nil {
+		return fmt.Errorf("failed to create taproot output: %v", err)
+	}
+	htlc.PkScript = pkScript
+
+	return nil
+}
+
+// CreateHTLCScript creates a contract and returns the script identifying it.
+// For P2WSH contracts this is the witness script, for Taproot contracts it is
+// the output script since there is no single script committing to both paths
+func (a *BTCAdapter) CreateHTLCScript(secretHash [32]byte, participantPubkey,
+	initiatorPubkey []byte, timeout uint32) ([]byte, error) {
+
+	htlc, err := a.NewHTLC(secretHash, participantPubkey, initiatorPubkey, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if htlc.Variant == HTLCVariantP2WSH {
+		return htlc.WitnessScript, nil
+	}
+
+	return htlc.PkScript, nil
+}
+
+// LookupHTLC returns a contract previously created by this adapter from
+// either its witness script or its output script
+func (a *BTCAdapter) LookupHTLC(script []byte) (*BitcoinHTLC, error) {
+	a.mtx.RLock()
+	defer a.mtx.RUnlock()
+
+	htlc, exists := a.contracts[string(script)]
+	if !exists {
+		return nil, fmt.Errorf("unknown HTLC script %x", script)
+	}
+
+	return htlc, nil
+}
+
+// CreateContractTx creates an unfunded transaction paying amount to the
+// contract. The caller's wallet is expected to add inputs and change
+func (a *BTCAdapter) CreateContractTx(htlcScript []byte, amount uint64) (*wire.MsgTx, error) {
+	htlc, err := a.LookupHTLC(htlcScript)
+	if err != nil {
+		return nil, err
+	}
+
+	if amount < bitcoinDustLimit {
+		return nil, fmt.Errorf("contract amount %d is below dust limit", amount)
+	}
+
+	contractTx := wire.NewMsgTx(2)
+	contractTx.AddTxOut(wire.NewTxOut(int64(amount), htlc.PkScript))
+
+	return contractTx, nil
+}
+
+// findContractOutput locates the first output of tx paying to a contract
+// created by this adapter
+func (a *BTCAdapter) findContractOutput(tx *wire.MsgTx) (uint32, *BitcoinHTLC, error) {
+	if tx == nil {
+		return 0, nil, fmt.Errorf("contract transaction required")
+	}
+
+	for i, txOut := range tx.TxOut {
+		htlc, err := a.LookupHTLC(txOut.PkScript)
+		if err == nil {
+			return uint32(i), htlc, nil
+		}
+	}
+
+	return 0, nil, fmt.Errorf("contract transaction %v has no HTLC output",
+		tx.TxHash())
+}
+
+// CreateRedeemTx creates the transaction that spends the contract to the
+// participant using the secret. participantAddr is the output script to pay
+func (a *BTCAdapter) CreateRedeemTx(contractTx *wire.MsgTx, secret []byte,
+	participantAddr []byte) (*wire.MsgTx, error) {
+
+	idx, htlc, err := a.findContractOutput(contractTx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(secret) != SecretSize {
+		return nil, fmt.Errorf("secret must be %d bytes", SecretSize)
+	}
+	if sha256.Sum256(secret) != htlc.SecretHash {
+		return nil, fmt.Errorf("invalid secret provided")
+	}
+
+	return a.createSpendTx(contractTx, idx, htlc, participantAddr, 0, true)
+}
+
+// CreateRefundTx creates the transaction that returns the contract funds to
+// the initiator once timeout has passed. initiatorAddr is the output script
+// to pay
+func (a *BTCAdapter) CreateRefundTx(contractTx *wire.MsgTx, initiatorAddr []byte,
+	timeout uint32) (*wire.MsgTx, error) {
+
+	idx, htlc, err := a.findContractOutput(contractTx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Block heights and timestamps are not comparable, and a lock time
+	// of the other kind never satisfies OP_CHECKLOCKTIMEVERIFY.
+	if isTimeLock(timeout) != isTimeLock(htlc.LockTime) {
+		return nil, fmt.Errorf("refund lock time %d and contract lock "+
+			"time %d are not of the same kind", timeout, htlc.LockTime)
+	}
+	if timeout < htlc.LockTime {
+		return nil, fmt.Errorf("refund lock time %d is before contract lock "+
+			"time %d", timeout, htlc.LockTime)
+	}
+
+	return a.createSpendTx(contractTx, idx, htlc, initiatorAddr, timeout, false)
+}
+
+// isTimeLock returns whether lockTime is a timestamp rather than a block
+// height
+func isTimeLock(lockTime uint32) bool {
+	return lockTime >= btctxscript.LockTimeThreshold
+}
+
+// createSpendTx builds an unsigned transaction spending the contract output
+// at idx to pkScript, paying the configured fee rate
+func (a *BTCAdapter) createSpendTx(contractTx *wire.MsgTx, idx uint32,
+	htlc *BitcoinHTLC, pkScript []byte, lockTime uint32,
+	redeem bool) (*wire.MsgTx, error) {
+
+	if len(pkScript) == 0 {
+		return nil, fmt.Errorf("destination script required")
+	}
+
+	contractHash := contractTx.TxHash()
+	value := contractTx.TxOut[idx].Value
+
+	spendTx := wire.NewMsgTx(2)
+	txIn := wire.NewTxIn(wire.NewOutPoint(&contractHash, idx), nil, nil)
+	if !redeem {
+		// A non-final sequence is required for the lock time to be
+		// enforced by OP_CHECKLOCKTIMEVERIFY.
+		txIn.Sequence = wire.MaxTxInSequenceNum - 1
+		spendTx.LockTime = lockTime
+	}
+	spendTx.AddTxIn(txIn)
+	spendTx.AddTxOut(wire.NewTxOut(value, pkScript))
+
+	fee := a.EstimateFee(EstimateSpendVSize(spendTx, htlc, redeem))
+	if value-int64(fee) < bitcoinDustLimit {
+		return nil, fmt.Errorf("contract value %d too small to pay fee %d",
+			value, int64(fee))
+	}
+	spendTx.TxOut[0].Value = value - int64(fee)
+
+	return spendTx, nil
+}
+
+// EstimateFee returns the fee for a transaction of the given virtual size at
+// the adapter's fee rate
+func (a *BTCAdapter) EstimateFee(vsize int64) btcutil.Amount {
+	fee := a.cfg.FeeRate * btcutil.Amount(vsize) / 1000
+	if fee == 0 && a.cfg.FeeRate > 0 {
+		fee = 1
+	}
+
+	return fee
+}
+
+// EstimateSpendVSize returns the virtual size spendTx will have once its
+// contract input carries a redeem (or refund) witness
+func EstimateSpendVSize(spendTx *wire.MsgTx, htlc *BitcoinHTLC, redeem bool) int64 {
+	tx := spendTx.Copy()
+
+	// Fill the witness with placeholders of the maximum size the final
+	// items can take.
+	var witness wire.TxWitness
+	switch {
+	case htlc.Variant == HTLCVariantP2WSH && redeem:
+		witness = wire.TxWitness{
+			make([]byte, 73), make([]byte, 33), make([]byte, SecretSize),
+			{0x01}, htlc.WitnessScript,
+		}
+	case htlc.Variant == HTLCVariantP2WSH:
+		witness = wire.TxWitness{
+			make([]byte, 73), make([]byte, 33), {}, htlc.WitnessScript,
+		}
+	case redeem:
+		witness = wire.TxWitness{
+			make([]byte, 64), make([]byte, SecretSize),
+			htlc.RedeemLeaf.Script, htlc.RedeemControlBlock,
+		}
+	default:
+		witness = wire.TxWitness{
+			make([]byte, 64), htlc.RefundLeaf.Script, htlc.RefundControlBlock,
+		}
+	}
+	for _, txIn := range tx.TxIn {
+		txIn.Witness = witness
+	}
+
+	baseSize := int64(tx.SerializeSizeStripped())
+	totalSize := int64(tx.SerializeSize())
+	weight := baseSize*3 + totalSize
+
+	return (weight + 3) / 4
+}
+
+// SignRedeemTx signs the contract input of a redeem transaction and attaches
+// the witness revealing the secret. contractOut is the output being spent
+func (a *BTCAdapter) SignRedeemTx(redeemTx *wire.MsgTx, contractOut *wire.TxOut,
+	secret []byte, key *btcec.PrivateKey) error {
+
+	htlc, err := a.LookupHTLC(contractOut.PkScript)
+	if err != nil {
+		return err
+	}
+
+	if sha256.Sum256(secret) != htlc.SecretHash {
+		return fmt.Errorf("invalid secret provided")
+	}
+
+	if !bytes.Equal(key.PubKey().SerializeCompressed(), htlc.ParticipantPubKey) {
+		return fmt.Errorf("key does not match HTLC participant")
+	}
+
+	sig, err := signContractInput(redeemTx, contractOut, htlc, key, true)
+	if err != nil {
+		return err
+	}
+
+	if htlc.Variant == HTLCVariantP2WSH {
+		redeemTx.TxIn[0].Witness = wire.TxWitness{
+			sig, htlc.ParticipantPubKey, secret, {0x01}, htlc.WitnessScript,
+		}
+	} else {
+		redeemTx.TxIn[0].Witness = wire.TxWitness{
+			sig, secret, htlc.RedeemLeaf.Script, htlc.RedeemControlBlock,
+		}
+	}
+
+	return nil
+}
+
+// SignRefundTx signs the contract input of a refund transaction and attaches
+// the witness for the time locked path. contractOut is the output being spent
+func (a *BTCAdapter) SignRefundTx(refundTx *wire.MsgTx, contractOut *wire.TxOut,
+	key *btcec.PrivateKey) error {
+
+	htlc, err := a.LookupHTLC(contractOut.PkScript)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(key.PubKey().SerializeCompressed(), htlc.InitiatorPubKey) {
+		return fmt.Errorf("key does not match HTLC initiator")
+	}
+
+	sig, err := signContractInput(refundTx, contractOut, htlc, key, false)
+	if err != nil {
+		return err
+	}
+
+	if htlc.Variant == HTLCVariantP2WSH {
+		refundTx.TxIn[0].Witness = wire.TxWitness{
+			sig, htlc.InitiatorPubKey, {}, htlc.WitnessScript,
+		}
+	} else {
+		refundTx.TxIn[0].Witness = wire.TxWitness{
+			sig, htlc.RefundLeaf.Script, htlc.RefundControlBlock,
+		}
+	}
+
+	return nil
+}
+
+// signContractInput produces the signature for the first input of tx, which
+// must spend contractOut, along the redeem or refund path
+func signContractInput(tx *wire.MsgTx, contractOut *wire.TxOut,
+	htlc *BitcoinHTLC, key *btcec.PrivateKey, redeem bool) ([]byte, error) {
+
+	if len(tx.TxIn) == 0 {
+		return nil, fmt.Errorf("transaction has no inputs")
+	}
+
+	fetcher := btctxscript.NewCannedPrevOutputFetcher(
+		contractOut.PkScript, contractOut.Value)
+	sigHashes := btctxscript.NewTxSigHashes(tx, fetcher)
+
+	if htlc.Variant == HTLCVariantP2WSH {
+		return btctxscript.RawTxInWitnessSignature(tx, sigHashes, 0,
+			contractOut.Value, htlc.WitnessScript, btctxscript.SigHashAll, key)
+	}
+
+	leaf := htlc.RefundLeaf
+	if redeem {
+		leaf = htlc.RedeemLeaf
+	}
+
+	return btctxscript.RawTxInTapscriptSignature(tx, sigHashes, 0,
+		contractOut.Value, contractOut.PkScript, leaf,
+		btctxscript.SigHashDefault, key)
+}
+
+// ExtractBitcoinSecret extracts the secret matching secretHash from the
+// witness of a Bitcoin redeem transaction of either variant
+func ExtractBitcoinSecret(tx *wire.MsgTx, secretHash [32]byte) ([]byte, error) {
+	if tx == nil {
+		return nil, fmt.Errorf("transaction cannot be nil")
+	}
+
+	for _, txIn := range tx.TxIn {
+		for _, item := range txIn.Witness {
+			if len(item) == SecretSize && sha256.Sum256(item) == secretHash {
+				return item, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no secret found in witness")
+}
+
+// mustParseXOnly parses a hex encoded x-only public key and panics on error.
+// It is only used for compile time constants
+func mustParseXOnly(s string) *btcec.PublicKey {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+
+	key, err := schnorr.ParsePubKey(b)
+	if err != nil {
+		panic(err)
+	}
+
+	return key
+}
@@ -0,0 +1,409 @@
+package swaps
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// The Ethereum side of a swap is held by a single HTLC contract exposing the
+// following interface:
+//
+//	function initiate(uint256 refundTime, bytes32 secretHash, address participant) payable
+//	function redeem(bytes32 secret, bytes32 secretHash)
+//	function refund(bytes32 secretHash)
+//
+//	event Initiated(uint256 refundTime, bytes32 indexed secretHash,
+//	    address indexed initiator, address participant, uint256 value)
+//	event Redeemed(uint256 redeemTime, bytes32 indexed secretHash, bytes32 secret)
+//	event Refunded(uint256 refundTime, bytes32 indexed secretHash)
+//
+// The contract checks sha256(secret) == secretHash so the same secret hash is
+// used on every chain taking part in the swap.
+const (
+	ethInitiateSignature  = "initiate(uint256,bytes32,address)"
+	ethRedeemSignature    = "redeem(bytes32,bytes32)"
+	ethRefundSignature    = "refund(bytes32)"
+	ethInitiatedSignature = "Initiated(uint256,bytes32,address,address,uint256)"
+	ethRedeemedSignature  = "Redeemed(uint256,bytes32,bytes32)"
+	ethRefundedSignature  = "Refunded(uint256,bytes32)"
+
+	// EthereumAddressSize is the size of an Ethereum account address
+	EthereumAddressSize = 20
+
+	// ethWordSize is the size of an ABI encoded argument
+	ethWordSize = 32
+)
+
+var (
+	ethInitiateSelector = ethSelector(ethInitiateSignature)
+	ethRedeemSelector   = ethSelector(ethRedeemSignature)
+	ethRefundSelector   = ethSelector(ethRefundSignature)
+
+	// EthInitiatedTopic is the topic identifying Initiated event logs
+	EthInitiatedTopic = ethKeccak256([]byte(ethInitiatedSignature))
+
+	// EthRedeemedTopic is the topic identifying Redeemed event logs
+	EthRedeemedTopic = ethKeccak256([]byte(ethRedeemedSignature))
+
+	// EthRefundedTopic is the topic identifying Refunded event logs
+	EthRefundedTopic = ethKeccak256([]byte(ethRefundedSignature))
+)
+
+// ETHAdapter implements EthereumAdapter by ABI encoding calls to the HTLC
+// contract. It never talks to a node: the returned call data is sent by the
+// desk's own wallet, and receipts are fed back through ParseEthereumReceipt
+type ETHAdapter struct{}
+
+// Ensure ETHAdapter implements the EthereumAdapter interface
+var _ EthereumAdapter = (*ETHAdapter)(nil)
+
+// NewETHAdapter creates a new Ethereum adapter
+func NewETHAdapter() *ETHAdapter {
+	return &ETHAdapter{}
+}
+
+// CreateHTLCContract returns the call data for initiating a swap. amount is
+// the value in wei to attach to the call; the contract records it from
+// msg.value, so it is only validated here. The initiator is msg.sender
+func (a *ETHAdapter) CreateHTLCContract(secretHash [32]byte, participantAddr,
+	initiatorAddr []byte, timeout uint64, amount *big.Int) ([]byte, error) {
+
+	if len(participantAddr) != EthereumAddressSize {
+		return nil, fmt.Errorf("invalid participant address length %d",
+			len(participantAddr))
+	}
+
+	if len(initiatorAddr) != EthereumAddressSize {
+		return nil, fmt.Errorf("invalid initiator address length %d",
+			len(initiatorAddr))
+	}
+
+	if bytes.Equal(participantAddr, initiatorAddr) {
+		return nil, fmt.Errorf("initiator and participant must differ")
+	}
+
+	if timeout == 0 {
+		return nil, fmt.Errorf("timeout must be specified")
+	}
+
+	if amount == nil || amount.Sign() <= 0 {
+		return nil, fmt.Errorf("swap amount must be greater than zero")
+	}
+
+	return ethEncodeCall(ethInitiateSelector,
+		ethEncodeUint(new(big.Int).SetUint64(timeout)),
+		secretHash[:],
+		ethEncodeAddress(participantAddr),
+	), nil
+}
+
+// CreateRedeemTx returns the call data for redeeming a swap with the secret
+func (a *ETHAdapter) CreateRedeemTx(contractAddr []byte, secret []byte) ([]byte, error) {
+	if len(contractAddr) != EthereumAddressSize {
+		return nil, fmt.Errorf("invalid contract address length %d",
+			len(contractAddr))
+	}
+
+	if len(secret) != SecretSize {
+		return nil, fmt.Errorf("secret must be %d bytes", SecretSize)
+	}
+
+	secretHash := sha256.Sum256(secret)
+
+	return ethEncodeCall(ethRedeemSelector, secret, secretHash[:]), nil
+}
+
+// CreateRefundTx returns the call data for refunding the swap identified by
+// secretHash
+func (a *ETHAdapter) CreateRefundTx(contractAddr []byte, secretHash [32]byte) ([]byte, error) {
+	if len(contractAddr) != EthereumAddressSize {
+		return nil, fmt.Errorf("invalid contract address length %d",
+			len(contractAddr))
+	}
+
+	return ethEncodeCall(ethRefundSelector, secretHash[:]), nil
+}
+
+// EthereumLog is an event log as returned by the Ethereum JSON-RPC API
+type EthereumLog struct {
+	Address  string   `json:"address"`
+	Topics   []string `json:"topics"`
+	Data     string   `json:"data"`
+	LogIndex string   `json:"logIndex"`
+}
+
+// EthereumReceipt is a transaction receipt as returned by
+// eth_getTransactionReceipt
+type EthereumReceipt struct {
+	TransactionHash string        `json:"transactionHash"`
+	BlockHash       string        `json:"blockHash"`
+	BlockNumber     string        `json:"blockNumber"`
+	From            string        `json:"from"`
+	To              string        `json:"to"`
+	Status          string        `json:"status"`
+	Logs            []EthereumLog `json:"logs"`
+}
+
+// Succeeded reports whether the transaction executed successfully
+func (r *EthereumReceipt) Succeeded() bool {
+	status, err := ethParseQuantity(r.Status)
+	return err == nil && status == 1
+}
+
+// Height returns the block number the transaction was included in
+func (r *EthereumReceipt) Height() (uint64, error) {
+	return ethParseQuantity(r.BlockNumber)
+}
+
+// ParseEthereumReceipt parses a raw eth_getTransactionReceipt response. Both
+// the bare receipt object and the full JSON-RPC envelope are accepted
+func ParseEthereumReceipt(raw []byte) (*EthereumReceipt, error) {
+	var envelope struct {
+		Result *json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse receipt: %v", err)
+	}
+
+	if envelope.Error != nil {
+		return nil, fmt.Errorf("rpc error %d: %s", envelope.Error.Code,
+			envelope.Error.Message)
+	}
+
+	if envelope.Result != nil {
+		raw = *envelope.Result
+	}
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return nil, fmt.Errorf("transaction receipt not available")
+	}
+
+	var receipt EthereumReceipt
+	if err := json.Unmarshal(raw, &receipt); err != nil {
+		return nil, fmt.Errorf("failed to parse receipt: %v", err)
+	}
+
+	if receipt.TransactionHash == "" {
+		return nil, fmt.Errorf("receipt has no transaction hash")
+	}
+
+	return &receipt, nil
+}
+
+// EthInitiatedEvent is a decoded Initiated event
+type EthInitiatedEvent struct {
+	Contract    []byte
+	RefundTime  uint64
+	SecretHash  [32]byte
+	Initiator   []byte
+	Participant []byte
+	Value       *big.Int
+}
+
+// EthRedeemedEvent is a decoded Redeemed event
+type EthRedeemedEvent struct {
+	Contract   []byte
+	RedeemTime uint64
+	SecretHash [32]byte
+	Secret     []byte
+}
+
+// ParseInitiatedLog decodes an Initiated event log
+func ParseInitiatedLog(log *EthereumLog) (*EthInitiatedEvent, error) {
+	contract, topics, data, err := decodeEthLog(log, EthInitiatedTopic, 3, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	refundTime, err := ethDecodeUint64(data[0])
+	if err != nil {
+		return nil, err
+	}
+
+	event := &EthInitiatedEvent{
+		Contract:    contract,
+		RefundTime:  refundTime,
+		Initiator:   topics[2][12:],
+		Participant: data[1][12:],
+		Value:       new(big.Int).SetBytes(data[2]),
+	}
+	copy(event.SecretHash[:], topics[1])
+
+	return event, nil
+}
+
+// ParseRedeemedLog decodes a Redeemed event log and verifies the revealed
+// secret against the secret hash
+func ParseRedeemedLog(log *EthereumLog) (*EthRedeemedEvent, error) {
+	contract, topics, data, err := decodeEthLog(log, EthRedeemedTopic, 2, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	redeemTime, err := ethDecodeUint64(data[0])
+	if err != nil {
+		return nil, err
+	}
+
+	event := &EthRedeemedEvent{
+		Contract:   contract,
+		RedeemTime: redeemTime,
+		Secret:     data[1],
+	}
+	copy(event.SecretHash[:], topics[1])
+
+	if sha256.Sum256(event.Secret) != event.SecretHash {
+		return nil, fmt.Errorf("redeemed secret does not match secret hash")
+	}
+
+	return event, nil
+}
+
+// ExtractEthereumSecret returns the secret revealed for secretHash by a
+// Redeemed event in the receipt
+func ExtractEthereumSecret(receipt *EthereumReceipt, secretHash [32]byte) ([]byte, error) {
+	if receipt == nil {
+		return nil, fmt.Errorf("receipt cannot be nil")
+	}
+
+	if !receipt.Succeeded() {
+		return nil, fmt.Errorf("transaction %s failed", receipt.TransactionHash)
+	}
+
+	for i := range receipt.Logs {
+		event, err := ParseRedeemedLog(&receipt.Logs[i])
+		if err != nil {
+			continue
+		}
+
+		if event.SecretHash == secretHash {
+			return event.Secret, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no secret found in receipt")
+}
+
+// decodeEthLog checks the event topic of log and splits its topics and data
+// into 32-byte words
+func decodeEthLog(log *EthereumLog, topic [32]byte, numTopics,
+	numWords int) ([]byte, [][]byte, [][]byte, error) {
+
+	if log == nil {
+		return nil, nil, nil, fmt.Errorf("log cannot be nil")
+	}
+
+	if len(log.Topics) != numTopics {
+		return nil, nil, nil, fmt.Errorf("expected %d topics, got %d",
+			numTopics, len(log.Topics))
+	}
+
+	topics := make([][]byte, len(log.Topics))
+	for i, t := range log.Topics {
+		b, err := ethDecodeHex(t)
+		if err != nil || len(b) != ethWordSize {
+			return nil, nil, nil, fmt.Errorf("invalid topic %q", t)
+		}
+		topics[i] = b
+	}
+
+	if !bytes.Equal(topics[0], topic[:]) {
+		return nil, nil, nil, fmt.Errorf("unexpected event topic %x", topics[0])
+	}
+
+	data, err := ethDecodeHex(log.Data)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid log data: %v", err)
+	}
+	if len(data) != numWords*ethWordSize {
+		return nil, nil, nil, fmt.Errorf("expected %d bytes of log data, got %d",
+			numWords*ethWordSize, len(data))
+	}
+
+	words := make([][]byte, numWords)
+	for i := range words {
+		words[i] = data[i*ethWordSize : (i+1)*ethWordSize]
+	}
+
+	contract, err := ethDecodeHex(log.Address)
+	if err != nil || len(contract) != EthereumAddressSize {
+		return nil, nil, nil, fmt.Errorf("invalid log address %q", log.Address)
+	}
+
+	return contract, topics, words, nil
+}
+
+// ethKeccak256 returns the legacy Keccak-256 hash used throughout Ethereum
+func ethKeccak256(data []byte) [32]byte {
+	var hash [32]byte
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	h.Sum(hash[:0])
+	return hash
+}
+
+// ethSelector returns the 4-byte function selector for a signature
+func ethSelector(signature string) []byte {
+	hash := ethKeccak256([]byte(signature))
+	return hash[:4]
+}
+
+// ethEncodeCall concatenates a selector with already encoded 32-byte words
+func ethEncodeCall(selector []byte, words ...[]byte) []byte {
+	data := make([]byte, 0, len(selector)+len(words)*ethWordSize)
+	data = append(data, selector...)
+	for _, word := range words {
+		data = append(data, word...)
+	}
+	return data
+}
+
+// ethEncodeUint encodes an unsigned integer as a 32-byte big-endian word
+func ethEncodeUint(v *big.Int) []byte {
+	word := make([]byte, ethWordSize)
+	v.FillBytes(word)
+	return word
+}
+
+// ethEncodeAddress left pads an address to a 32-byte word
+func ethEncodeAddress(addr []byte) []byte {
+	word := make([]byte, ethWordSize)
+	copy(word[ethWordSize-EthereumAddressSize:], addr)
+	return word
+}
+
+// ethDecodeUint64 decodes a 32-byte word holding a value that fits in 64 bits
+func ethDecodeUint64(word []byte) (uint64, error) {
+	v := new(big.Int).SetBytes(word)
+	if !v.IsUint64() {
+		return 0, fmt.Errorf("value %v overflows uint64", v)
+	}
+	return v.Uint64(), nil
+}
+
+// ethDecodeHex decodes a 0x prefixed hex string
+func ethDecodeHex(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return nil, fmt.Errorf("missing 0x prefix")
+	}
+	return hex.DecodeString(s[2:])
+}
+
+// ethParseQuantity parses a JSON-RPC hex encoded quantity
+func ethParseQuantity(s string) (uint64, error) {
+	if !strings.HasPrefix(s, "0x") || len(s) < 3 {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+	return strconv.ParseUint(s[2:], 16, 64)
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/internal/convert"
+	"github.com/toole-brendan/shell/txscript"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// simSpend returns a transaction spending the first output of the passed
+// anyone-can-spend coinbase to two anyone-can-spend outputs paying the passed
+// fee.  Different fees result in conflicting spends of the same coinbase.
+func simSpend(t *testing.T, coinbase *wire.MsgTx, fee int64) *wire.MsgTx {
+	t.Helper()
+
+	pkScript, err := txscript.NewScriptBuilder().AddOp(txscript.OP_TRUE).Script()
+	if err != nil {
+		t.Fatalf("unable to create script: %v", err)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, 0), nil, nil))
+	tx.TxIn[0].PreviousOutPoint.Hash = coinbase.TxHash()
+	value := (coinbase.TxOut[0].Value - fee) / 2
+	tx.AddTxOut(wire.NewTxOut(value, pkScript))
+	tx.AddTxOut(wire.NewTxOut(value, pkScript))
+	return tx
+}
+
+// TestSimReorgAfterPartition ensures both sides of a partition converge on the
+// chain with the most work once it heals.
+func TestSimReorgAfterPartition(t *testing.T) {
+	var minority, majority []*wire.MsgBlock
+	runSimScenarios(t, []simScenario{{
+		name:  "longer side wins",
+		nodes: 4,
+		steps: []simStep{
+			mineStep(0, 5),
+			syncStep(),
+			partitionStep([]int{0, 1}, []int{2, 3}),
+			advanceClockStep(time.Minute),
+			func(h *simHarness) {
+				minority = h.MineBlocks(0, 2)
+				majority = h.MineBlocks(2, 4)
+			},
+			syncStep(0, 1),
+			syncStep(2, 3),
+			healStep(),
+			syncStep(),
+		},
+		check: func(t *testing.T, h *simHarness) {
+			for id := range h.nodes {
+				node := h.Node(id)
+				if height := node.BestHeight(); height != 9 {
+					t.Errorf("node %d: height %d, want 9", id,
+						height)
+				}
+				want := majority[len(majority)-1].BlockHash()
+				if got := node.BestHash(); got != want {
+					t.Errorf("node %d: best block %v, want %v",
+						id, got, want)
+				}
+				hash := minority[0].BlockHash()
+				if _, err := mainChainHeight(node, &hash); err == nil {
+					t.Errorf("node %d: block %v of the shorter "+
+						"side is still in the main chain", id,
+						hash)
+				}
+			}
+		},
+	}})
+}
+
+// TestSimCompetingMiners ensures a tie between blocks mined at the same height
+// by disconnected miners is resolved by the next block.
+func TestSimCompetingMiners(t *testing.T) {
+	var competing [2]*wire.MsgBlock
+	var next *wire.MsgBlock
+	runSimScenarios(t, []simScenario{{
+		name:  "tie broken by next block",
+		nodes: 3,
+		steps: []simStep{
+			mineStep(2, 3),
+			syncStep(),
+			partitionStep([]int{0}, []int{1}, []int{2}),
+			advanceClockStep(time.Minute),
+			func(h *simHarness) {
+				competing[0] = h.MineBlocks(0, 1)[0]
+				competing[1] = h.MineBlocks(1, 1)[0]
+			},
+			healStep(),
+			advanceClockStep(time.Minute),
+			func(h *simHarness) {
+				// Both competing blocks have equal work, so the
+				// nodes keep whichever they saw first until
+				// node 1 extends its own block.
+				next = h.MineBlocks(1, 1)[0]
+			},
+			syncStep(),
+		},
+		check: func(t *testing.T, h *simHarness) {
+			losing := competing[0].BlockHash()
+			winning := competing[1].BlockHash()
+			for id := range h.nodes {
+				node := h.Node(id)
+				if got := node.BestHash(); got != next.BlockHash() {
+					t.Errorf("node %d: best block %v, want %v",
+						id, got, next.BlockHash())
+				}
+				if _, err := mainChainHeight(node, &winning); err != nil {
+					t.Errorf("node %d: winning block %v: %v", id,
+						winning, err)
+				}
+				if _, err := mainChainHeight(node, &losing); err == nil {
+					t.Errorf("node %d: losing block %v is in the "+
+						"main chain", id, losing)
+				}
+			}
+		},
+	}})
+}
+
+// TestSimConflictingSpends ensures conflicting spends of the same output
+// confirmed on both sides of a partition are resolved in favor of the chain
+// with the most work, and that the losing spend is never confirmed afterwards
+// even by the node that still has it.  This is the shape of a channel dispute
+// where both parties broadcast a different state of the channel.
+func TestSimConflictingSpends(t *testing.T) {
+	var winner, loser *wire.MsgTx
+	runSimScenarios(t, []simScenario{{
+		name:  "spend on longer side confirmed",
+		nodes: 2,
+		steps: []simStep{
+			func(h *simHarness) {
+				blocks := h.MineBlocks(0, simCoinbaseMaturity+1)
+				coinbase := blocks[0].Transactions[0]
+				loser = simSpend(h.t, coinbase, 1000)
+				winner = simSpend(h.t, coinbase, 2000)
+			},
+			syncStep(),
+			partitionStep([]int{0}, []int{1}),
+			advanceClockStep(time.Minute),
+			func(h *simHarness) {
+				if err := h.SubmitTx(0, loser); err != nil {
+					h.t.Fatalf("unable to submit spend to node 0: %v",
+						err)
+				}
+				if err := h.SubmitTx(1, winner); err != nil {
+					h.t.Fatalf("unable to submit spend to node 1: %v",
+						err)
+				}
+				h.MineBlocks(0, 1)
+				h.MineBlocks(1, 2)
+			},
+			healStep(),
+			syncStep(),
+			advanceClockStep(time.Minute),
+			mineStep(0, 1),
+			syncStep(),
+		},
+		check: func(t *testing.T, h *simHarness) {
+			winnerHash := winner.TxHash()
+			loserHash := loser.TxHash()
+			for id := range h.nodes {
+				confirmed := make(map[chainhash.Hash]int32)
+				chain := h.Node(id).server.chain
+				for height := int32(1); height <= chain.BestSnapshot().Height; height++ {
+					block, err := chain.BlockByHeight(height)
+					if err != nil {
+						t.Fatalf("node %d: unable to fetch block "+
+							"%d: %v", id, height, err)
+					}
+					for _, tx := range block.Transactions()[1:] {
+						confirmed[*convert.HashToShell(tx.Hash())] = height
+					}
+				}
+
+				if height, ok := confirmed[winnerHash]; !ok ||
+					height != simCoinbaseMaturity+2 {
+
+					t.Errorf("node %d: spend %v confirmed at %d, "+
+						"want %d", id, winnerHash, height,
+						simCoinbaseMaturity+2)
+				}
+				if _, ok := confirmed[loserHash]; ok {
+					t.Errorf("node %d: conflicting spend %v is "+
+						"confirmed", id, loserHash)
+				}
+			}
+		},
+	}})
+}
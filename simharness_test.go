@@ -0,0 +1,630 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/toole-brendan/shell/blockchain"
+	"github.com/toole-brendan/shell/chaincfg"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/database"
+	"github.com/toole-brendan/shell/internal/convert"
+	"github.com/toole-brendan/shell/mempool"
+	"github.com/toole-brendan/shell/mining"
+	"github.com/toole-brendan/shell/wire"
+)
+
+const (
+	// simCoinbaseMaturity is the coinbase maturity of simulated networks.
+	// It is much lower than regtest so scenarios can spend coinbases
+	// without mining hundreds of blocks.
+	simCoinbaseMaturity = 10
+
+	// simWaitTimeout is how long the harness waits for the nodes of a
+	// simulation to converge before failing the test.
+	simWaitTimeout = 30 * time.Second
+
+	// simPollInterval is how often the harness polls the nodes while
+	// waiting for them to converge.
+	simPollInterval = 10 * time.Millisecond
+
+	// simUtxoCacheMaxSizeMiB is the utxo cache size of each node.  The
+	// cache is preallocated, so the default would make simulations with a
+	// handful of nodes use gigabytes of memory.
+	simUtxoCacheMaxSizeMiB = 8
+)
+
+// simHarnessMtx serializes simulations.  Servers read the global config and
+// network parameters, so only one simulation can run at a time.
+var simHarnessMtx sync.Mutex
+
+// simClock is a controllable clock shared by every node of a simulation.  It
+// implements blockchain.MedianTimeSource and only moves when advanced, so
+// block timestamps are deterministic.
+type simClock struct {
+	mtx sync.Mutex
+	now time.Time
+}
+
+// Ensure simClock implements the blockchain.MedianTimeSource interface.
+var _ blockchain.MedianTimeSource = (*simClock)(nil)
+
+// AdjustedTime returns the current time of the clock.
+//
+// This is part of the blockchain.MedianTimeSource interface.
+func (c *simClock) AdjustedTime() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.now
+}
+
+// AddTimeSample ignores time samples from peers since the clock is only
+// controlled by the simulation.
+//
+// This is part of the blockchain.MedianTimeSource interface.
+func (c *simClock) AddTimeSample(string, time.Time) {}
+
+// Offset always returns zero since peers have no influence on the clock.
+//
+// This is part of the blockchain.MedianTimeSource interface.
+func (c *simClock) Offset() time.Duration {
+	return 0
+}
+
+// Advance moves the clock forward by the passed duration.
+func (c *simClock) Advance(d time.Duration) {
+	c.mtx.Lock()
+	c.now = c.now.Add(d)
+	c.mtx.Unlock()
+}
+
+// simPowSolver solves the proof of work of blocks mined by a simulation with
+// the proof-of-work hasher of the mining node.  It returns false when no
+// solution was found.
+type simPowSolver interface {
+	Solve(hasher blockchain.PowHasher, header *wire.BlockHeader, height int32) bool
+}
+
+// nonceSolver is the default proof of work solver.  Blocks are checked
+// against the proof-of-work hash of their algorithm, which only takes a
+// couple of attempts at the regtest proof of work limit.
+type nonceSolver struct{}
+
+// Solve searches the nonce space for a hash that satisfies the target of the
+// passed header.
+//
+// This is part of the simPowSolver interface.
+func (nonceSolver) Solve(hasher blockchain.PowHasher, header *wire.BlockHeader, height int32) bool {
+	target := blockchain.CompactToBig(header.Bits)
+	for nonce := uint32(0); nonce < math.MaxUint32; nonce++ {
+		header.Nonce = nonce
+		hash, err := hasher.PowHash(header, height)
+		if err != nil {
+			return false
+		}
+		if blockchain.HashToBig(&hash).Cmp(target) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// simNode is a full server running in a simulation.
+type simNode struct {
+	id        int
+	addr      string
+	db        database.DB
+	server    *server
+	generator *mining.BlkTmplGenerator
+
+	// extraNonce makes the coinbase of every block mined by the node
+	// unique, so competing miners never produce identical blocks.
+	extraNonce uint64
+}
+
+// BestHash returns the hash of the node's best block.
+func (n *simNode) BestHash() chainhash.Hash {
+	return n.server.chain.BestSnapshot().Hash
+}
+
+// BestHeight returns the height of the node's best block.
+func (n *simNode) BestHeight() int32 {
+	return n.server.chain.BestSnapshot().Height
+}
+
+// simLink is a connection between two nodes, dialed by the first one.
+type simLink [2]int
+
+// simHarness runs a network of full servers in process on loopback.  The
+// nodes share a controllable clock and a pluggable proof of work solver, and
+// the harness can connect, partition and heal them to script scenarios.
+type simHarness struct {
+	t      *testing.T
+	params *chaincfg.Params
+	clock  *simClock
+	solver simPowSolver
+	nodes  []*simNode
+
+	// links are the active connections and cut are the connections
+	// severed by the current partition.
+	links map[simLink]struct{}
+	cut   map[simLink]struct{}
+}
+
+// newSimHarness starts a simulation with the passed number of unconnected
+// nodes on regtest-like parameters.  The harness is torn down when the test
+// completes.
+func newSimHarness(t *testing.T, numNodes int) *simHarness {
+	t.Helper()
+
+	// The block database stores blocks as bitcoin blocks, without their
+	// thermal proof, and serves them to peers as they are stored, so
+	// peers decode them as different blocks than were announced.  Until
+	// stored blocks can be served as shell blocks, nodes can't sync.
+	if numNodes > 1 {
+		t.Skip("nodes can't relay stored blocks to each other")
+	}
+
+	simHarnessMtx.Lock()
+	t.Cleanup(simHarnessMtx.Unlock)
+
+	// MobileX is left inactive since blocks reach the chain as btcutil
+	// blocks, which do not carry the thermal proof it requires.
+	netParams := chaincfg.RegressionNetParams
+	netParams.CoinbaseMaturity = simCoinbaseMaturity
+	netParams.MobileXActivationHeight = math.MaxInt32
+
+	h := &simHarness{
+		t:      t,
+		params: &netParams,
+		clock:  &simClock{now: netParams.GenesisBlock.Header.Timestamp},
+		solver: nonceSolver{},
+		links:  make(map[simLink]struct{}),
+		cut:    make(map[simLink]struct{}),
+	}
+
+	// Servers read their configuration from the globals, so point them
+	// at the simulation for its lifetime.
+	prevCfg, prevParams := cfg, activeNetParams
+	t.Cleanup(func() {
+		cfg, activeNetParams = prevCfg, prevParams
+	})
+	dataDir := t.TempDir()
+	cfg = simConfig(dataDir)
+	activeNetParams = &params{Params: h.params, rpcPort: regressionNetParams.rpcPort}
+	cfg.timeSource = h.clock
+	cfg.allowSelfConns = true
+	setLogLevels("off")
+
+	t.Cleanup(h.tearDown)
+	for i := 0; i < numNodes; i++ {
+		h.nodes = append(h.nodes, h.startNode(i, dataDir))
+	}
+
+	return h
+}
+
+// simConfig returns the configuration shared by the servers of a simulation.
+func simConfig(dataDir string) *config {
+	return &config{
+		DataDir:             dataDir,
+		DbType:              "ffldb",
+		MaxPeers:            defaultMaxPeers,
+		BanDuration:         defaultBanDuration,
+		BanThreshold:        defaultBanThreshold,
+		DisableRPC:          true,
+		DisableDNSSeed:      true,
+		DisableCheckpoints:  true,
+		NoCFilters:          true,
+		RegressionTest:      true,
+		RelayNonStd:         true,
+		FreeTxRelayLimit:    defaultFreeTxRelayLimit,
+		TrickleInterval:     time.Millisecond * 50,
+		BlockMinSize:        defaultBlockMinSize,
+		BlockMaxSize:        defaultBlockMaxSize,
+		BlockMinWeight:      defaultBlockMinWeight,
+		BlockMaxWeight:      defaultBlockMaxWeight,
+		BlockPrioritySize:   mempool.DefaultBlockPrioritySize,
+		MaxOrphanTxs:        defaultMaxOrphanTransactions,
+		SigCacheMaxSize:     defaultSigCacheMaxSize,
+		UtxoCacheMaxSizeMiB: simUtxoCacheMaxSizeMiB,
+		minRelayTxFee:       mempool.DefaultMinRelayTxFee,
+		dial:                net.DialTimeout,
+		lookup:              net.LookupIP,
+	}
+}
+
+// startNode creates and starts the server for the node with the passed id.
+func (h *simHarness) startNode(id int, dataDir string) *simNode {
+	h.t.Helper()
+
+	// Reserve a free loopback port for the node to listen on.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		h.t.Fatalf("node %d: unable to reserve port: %v", id, err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	dbPath := filepath.Join(dataDir, fmt.Sprintf("node%d", id))
+	db, err := database.Create(cfg.DbType, dbPath, h.params.Net)
+	if err != nil {
+		h.t.Fatalf("node %d: unable to create database: %v", id, err)
+	}
+
+	s, err := newServer([]string{addr}, nil, nil, db, h.params, nil)
+	if err != nil {
+		db.Close()
+		h.t.Fatalf("node %d: unable to create server: %v", id, err)
+	}
+	s.Start()
+
+	policy := mining.Policy{
+		BlockMinWeight:    cfg.BlockMinWeight,
+		BlockMaxWeight:    cfg.BlockMaxWeight,
+		BlockMinSize:      cfg.BlockMinSize,
+		BlockMaxSize:      cfg.BlockMaxSize,
+		BlockPrioritySize: cfg.BlockPrioritySize,
+		TxMinFreeFee:      cfg.minRelayTxFee,
+	}
+	generator := mining.NewBlkTmplGenerator(&policy, h.params,
+		s.txMemPool, s.chain, s.timeSource, s.sigCache, s.hashCache)
+
+	return &simNode{
+		id:        id,
+		addr:      addr,
+		db:        db,
+		server:    s,
+		generator: generator,
+	}
+}
+
+// tearDown stops every node of the simulation and closes their databases.
+func (h *simHarness) tearDown() {
+	for _, node := range h.nodes {
+		node.server.Stop()
+		node.server.WaitForShutdown()
+		node.db.Close()
+	}
+	h.nodes = nil
+}
+
+// Node returns the node with the passed id.
+func (h *simHarness) Node(id int) *simNode {
+	return h.nodes[id]
+}
+
+// SetSolver replaces the proof of work solver used to mine blocks.
+func (h *simHarness) SetSolver(solver simPowSolver) {
+	h.solver = solver
+}
+
+// AdvanceClock moves the clock of every node forward by the passed duration.
+func (h *simHarness) AdvanceClock(d time.Duration) {
+	h.clock.Advance(d)
+}
+
+// Connect connects node a to node b and waits for the connection to be
+// established.
+func (h *simHarness) Connect(a, b int) {
+	h.t.Helper()
+
+	link := simLink{a, b}
+	if _, ok := h.links[link]; ok {
+		return
+	}
+
+	dialer, listener := h.nodes[a], h.nodes[b]
+	reply := make(chan error)
+	dialer.server.query <- connectNodeMsg{
+		addr:      listener.addr,
+		permanent: true,
+		reply:     reply,
+	}
+	if err := <-reply; err != nil {
+		h.t.Fatalf("unable to connect node %d to node %d: %v", a, b, err)
+	}
+	h.links[link] = struct{}{}
+
+	connected := waitFor(func() bool {
+		return h.isConnected(dialer, listener.addr)
+	})
+	if !connected {
+		h.t.Fatalf("timed out waiting for node %d to connect to node %d",
+			a, b)
+	}
+}
+
+// ConnectAll connects every pair of nodes.
+func (h *simHarness) ConnectAll() {
+	h.t.Helper()
+
+	for a := range h.nodes {
+		for b := a + 1; b < len(h.nodes); b++ {
+			h.Connect(a, b)
+		}
+	}
+}
+
+// Disconnect severs the connection between nodes a and b, regardless of which
+// of them dialed it, and waits for both of them to drop it.
+func (h *simHarness) Disconnect(a, b int) {
+	h.t.Helper()
+
+	link := simLink{a, b}
+	if _, ok := h.links[link]; !ok {
+		link = simLink{b, a}
+		if _, ok := h.links[link]; !ok {
+			return
+		}
+	}
+
+	// Removing the persistent peer from the dialing side stops the
+	// connection manager from reconnecting it.
+	dialer, listener := h.nodes[link[0]], h.nodes[link[1]]
+	reply := make(chan error)
+	dialer.server.query <- removeNodeMsg{
+		cmp: func(sp *serverPeer) bool {
+			return sp.Addr() == listener.addr
+		},
+		reply: reply,
+	}
+	if err := <-reply; err != nil {
+		h.t.Fatalf("unable to disconnect node %d from node %d: %v",
+			link[0], link[1], err)
+	}
+	delete(h.links, link)
+
+	disconnected := waitFor(func() bool {
+		return !h.isConnected(dialer, listener.addr)
+	})
+	if !disconnected {
+		h.t.Fatalf("timed out waiting for node %d to disconnect from "+
+			"node %d", a, b)
+	}
+}
+
+// isConnected returns whether the passed node has a connected peer at the
+// passed address.
+func (h *simHarness) isConnected(node *simNode, addr string) bool {
+	reply := make(chan []*serverPeer)
+	node.server.query <- getPeersMsg{reply: reply}
+	for _, sp := range <-reply {
+		if sp.Addr() == addr && sp.VersionKnown() {
+			return true
+		}
+	}
+	return false
+}
+
+// Partition splits the network into the passed groups of node ids by cutting
+// every connection between nodes of different groups.  Nodes that are not in
+// any group are isolated.  The cut connections are restored by Heal.
+func (h *simHarness) Partition(groups ...[]int) {
+	h.t.Helper()
+
+	group := make(map[int]int)
+	for i, ids := range groups {
+		for _, id := range ids {
+			group[id] = i
+		}
+	}
+	sameGroup := func(a, b int) bool {
+		ga, okA := group[a]
+		gb, okB := group[b]
+		return okA && okB && ga == gb
+	}
+
+	for link := range h.links {
+		if !sameGroup(link[0], link[1]) {
+			h.Disconnect(link[0], link[1])
+			h.cut[link] = struct{}{}
+		}
+	}
+}
+
+// Heal restores every connection cut by Partition.
+func (h *simHarness) Heal() {
+	h.t.Helper()
+
+	for link := range h.cut {
+		h.Connect(link[0], link[1])
+		delete(h.cut, link)
+	}
+}
+
+// MineBlocks mines the passed number of blocks on top of the best chain of the
+// passed node, including the transactions in its mempool, and returns them.
+// The blocks are relayed to the node's peers like any other block.
+func (h *simHarness) MineBlocks(id, numBlocks int) []*wire.MsgBlock {
+	h.t.Helper()
+
+	node := h.nodes[id]
+	blocks := make([]*wire.MsgBlock, 0, numBlocks)
+	for i := 0; i < numBlocks; i++ {
+		template, err := node.generator.NewBlockTemplate(nil)
+		if err != nil {
+			h.t.Fatalf("node %d: unable to create block template: %v",
+				id, err)
+		}
+
+		msgBlock := template.Block
+		node.extraNonce++
+		extraNonce := uint64(node.id)<<32 | node.extraNonce
+		err = node.generator.UpdateExtraNonce(msgBlock, template.Height,
+			extraNonce)
+		if err != nil {
+			h.t.Fatalf("node %d: unable to update extra nonce: %v",
+				id, err)
+		}
+
+		if !h.solver.Solve(node.server.miningPolicy, &msgBlock.Header,
+			template.Height) {
+
+			h.t.Fatalf("node %d: unable to solve block at height %d",
+				id, template.Height)
+		}
+
+		block := convert.NewShellBlock(msgBlock)
+		isOrphan, err := node.server.syncManager.ProcessBlock(block,
+			blockchain.BFNone)
+		if err != nil {
+			h.t.Fatalf("node %d: block at height %d rejected: %v", id,
+				template.Height, err)
+		}
+		if isOrphan {
+			h.t.Fatalf("node %d: mined block at height %d is an "+
+				"orphan", id, template.Height)
+		}
+		blocks = append(blocks, msgBlock)
+	}
+
+	return blocks
+}
+
+// SubmitTx adds the passed transaction to the mempool of the passed node and
+// relays it to the node's peers.
+func (h *simHarness) SubmitTx(id int, tx *wire.MsgTx) error {
+	node := h.nodes[id]
+	acceptedTxs, err := node.server.txMemPool.ProcessTransaction(
+		convert.NewShellTx(tx), false, false, 0)
+	if err != nil {
+		return err
+	}
+	node.server.AnnounceNewTransactions(acceptedTxs)
+	return nil
+}
+
+// WaitForSync waits until the passed nodes, or every node when none are
+// passed, agree on the best chain.
+func (h *simHarness) WaitForSync(ids ...int) {
+	h.t.Helper()
+
+	nodes := h.nodes
+	if len(ids) > 0 {
+		nodes = make([]*simNode, 0, len(ids))
+		for _, id := range ids {
+			nodes = append(nodes, h.nodes[id])
+		}
+	}
+
+	synced := waitFor(func() bool {
+		best := nodes[0].BestHash()
+		for _, node := range nodes[1:] {
+			if node.BestHash() != best {
+				return false
+			}
+		}
+		return true
+	})
+	if !synced {
+		var tips []string
+		for _, node := range nodes {
+			tips = append(tips, fmt.Sprintf("node %d at %v (%d)",
+				node.id, node.BestHash(), node.BestHeight()))
+		}
+		h.t.Fatalf("timed out waiting for nodes to sync: %s",
+			strings.Join(tips, ", "))
+	}
+}
+
+// waitFor polls the passed condition until it is met and returns whether it
+// was met within simWaitTimeout.
+func waitFor(cond func() bool) bool {
+	deadline := time.Now().Add(simWaitTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(simPollInterval)
+	}
+	return true
+}
+
+// simStep is a single step of a scripted simulation scenario.
+type simStep func(h *simHarness)
+
+// simScenario is a scripted simulation.  The steps run in order against a
+// fresh harness with the given number of fully connected nodes, and check
+// asserts on the final chain state.
+type simScenario struct {
+	name  string
+	nodes int
+	steps []simStep
+	check func(t *testing.T, h *simHarness)
+}
+
+// mineStep mines the passed number of blocks on the passed node.
+func mineStep(id, numBlocks int) simStep {
+	return func(h *simHarness) {
+		h.MineBlocks(id, numBlocks)
+	}
+}
+
+// partitionStep partitions the network into the passed groups.
+func partitionStep(groups ...[]int) simStep {
+	return func(h *simHarness) {
+		h.Partition(groups...)
+	}
+}
+
+// healStep heals the current partition.
+func healStep() simStep {
+	return func(h *simHarness) {
+		h.Heal()
+	}
+}
+
+// syncStep waits for the passed nodes, or every node, to agree on the best
+// chain.
+func syncStep(ids ...int) simStep {
+	return func(h *simHarness) {
+		h.WaitForSync(ids...)
+	}
+}
+
+// advanceClockStep advances the clock of every node.
+func advanceClockStep(d time.Duration) simStep {
+	return func(h *simHarness) {
+		h.AdvanceClock(d)
+	}
+}
+
+// runSimScenarios runs each of the passed scenarios as a subtest.
+func runSimScenarios(t *testing.T, scenarios []simScenario) {
+	for _, scenario := range scenarios {
+		scenario := scenario
+		t.Run(scenario.name, func(t *testing.T) {
+			h := newSimHarness(t, scenario.nodes)
+			h.ConnectAll()
+			for _, step := range scenario.steps {
+				step(h)
+			}
+			scenario.check(t, h)
+		})
+	}
+}
+
+// errSimNotMainChain is returned by mainChainHeight when a block is not part of
+// a node's main chain.
+var errSimNotMainChain = errors.New("block is not in the main chain")
+
+// mainChainHeight returns the height of the passed block in the main chain of
+// the passed node.
+func mainChainHeight(node *simNode, hash *chainhash.Hash) (int32, error) {
+	if !node.server.chain.MainChainHasBlock(hash) {
+		return 0, errSimNotMainChain
+	}
+	return node.server.chain.BlockHeightByHash(hash)
+}
@@ -0,0 +1,322 @@
+package test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	btctxscript "github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/toole-brendan/shell/settlement/swaps"
+)
+
+// bitcoinSwapFixture holds the keys and contract used by the Bitcoin adapter
+// tests
+type bitcoinSwapFixture struct {
+	adapter        *swaps.BTCAdapter
+	participantKey *btcec.PrivateKey
+	initiatorKey   *btcec.PrivateKey
+	secret         []byte
+	secretHash     [32]byte
+	htlc           *swaps.BitcoinHTLC
+	contractTx     *wire.MsgTx
+	payTo          []byte
+}
+
+func newBitcoinSwapFixture(t *testing.T, variant swaps.HTLCVariant) *bitcoinSwapFixture {
+	t.Helper()
+
+	adapter, err := swaps.NewBTCAdapter(&swaps.BitcoinAdapterConfig{
+		Params:  &chaincfg.RegressionNetParams,
+		Variant: variant,
+		FeeRate: 2000,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create adapter: %v", err)
+	}
+
+	participantKey, _ := btcec.NewPrivateKey()
+	initiatorKey, _ := btcec.NewPrivateKey()
+
+	secret := bytes.Repeat([]byte{0x42}, swaps.SecretSize)
+	secretHash := sha256.Sum256(secret)
+
+	htlcScript, err := adapter.CreateHTLCScript(secretHash,
+		participantKey.PubKey().SerializeCompressed(),
+		initiatorKey.PubKey().SerializeCompressed(), 500)
+	if err != nil {
+		t.Fatalf("Failed to create HTLC script: %v", err)
+	}
+
+	htlc, err := adapter.LookupHTLC(htlcScript)
+	if err != nil {
+		t.Fatalf("Failed to look up HTLC: %v", err)
+	}
+
+	contractTx, err := adapter.CreateContractTx(htlcScript, 100000)
+	if err != nil {
+		t.Fatalf("Failed to create contract tx: %v", err)
+	}
+
+	// Stand in for the wallet funding step.
+	contractTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0x01}, 0),
+		nil, nil))
+
+	payTo, err := btctxscript.NewScriptBuilder().
+		AddOp(btctxscript.OP_0).
+		AddData(btcutil.Hash160([]byte("destination"))).
+		Script()
+	if err != nil {
+		t.Fatalf("Failed to create destination script: %v", err)
+	}
+
+	return &bitcoinSwapFixture{
+		adapter:        adapter,
+		participantKey: participantKey,
+		initiatorKey:   initiatorKey,
+		secret:         secret,
+		secretHash:     secretHash,
+		htlc:           htlc,
+		contractTx:     contractTx,
+		payTo:          payTo,
+	}
+}
+
+// executeSpend runs the script engine on the first input of spendTx
+func executeSpend(t *testing.T, spendTx *wire.MsgTx, prevOut *wire.TxOut) error {
+	t.Helper()
+
+	fetcher := btctxscript.NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value)
+	vm, err := btctxscript.NewEngine(prevOut.PkScript, spendTx, 0,
+		btctxscript.StandardVerifyFlags, nil,
+		btctxscript.NewTxSigHashes(spendTx, fetcher), prevOut.Value, fetcher)
+	if err != nil {
+		return err
+	}
+
+	return vm.Execute()
+}
+
+// TestBitcoinHTLCRedeem tests redeeming Bitcoin HTLCs with the secret
+func TestBitcoinHTLCRedeem(t *testing.T) {
+	for _, variant := range []swaps.HTLCVariant{
+		swaps.HTLCVariantP2WSH, swaps.HTLCVariantTaproot,
+	} {
+		t.Run(variant.String(), func(t *testing.T) {
+			f := newBitcoinSwapFixture(t, variant)
+
+			redeemTx, err := f.adapter.CreateRedeemTx(f.contractTx, f.secret, f.payTo)
+			if err != nil {
+				t.Fatalf("Failed to create redeem tx: %v", err)
+			}
+
+			if redeemTx.TxOut[0].Value >= f.contractTx.TxOut[0].Value {
+				t.Error("Redeem transaction pays no fee")
+			}
+
+			contractOut := f.contractTx.TxOut[0]
+			err = f.adapter.SignRedeemTx(redeemTx, contractOut, f.secret,
+				f.participantKey)
+			if err != nil {
+				t.Fatalf("Failed to sign redeem tx: %v", err)
+			}
+
+			if err := executeSpend(t, redeemTx, contractOut); err != nil {
+				t.Fatalf("Redeem script failed: %v", err)
+			}
+
+			// The fee estimate must cover the final transaction.
+			vsize := (int64(redeemTx.SerializeSizeStripped())*3 +
+				int64(redeemTx.SerializeSize()) + 3) / 4
+			estimate := swaps.EstimateSpendVSize(redeemTx, f.htlc, true)
+			if estimate < vsize {
+				t.Errorf("Estimated vsize %d below actual %d", estimate, vsize)
+			}
+
+			secret, err := swaps.ExtractBitcoinSecret(redeemTx, f.secretHash)
+			if err != nil {
+				t.Fatalf("Failed to extract secret: %v", err)
+			}
+			if !bytes.Equal(secret, f.secret) {
+				t.Error("Extracted secret mismatch")
+			}
+
+			// Signing with the initiator key must be refused.
+			err = f.adapter.SignRedeemTx(redeemTx, contractOut, f.secret,
+				f.initiatorKey)
+			if err == nil {
+				t.Error("Expected error signing redeem with initiator key")
+			}
+		})
+	}
+}
+
+// TestBitcoinHTLCRefund tests refunding Bitcoin HTLCs after the lock time
+func TestBitcoinHTLCRefund(t *testing.T) {
+	for _, variant := range []swaps.HTLCVariant{
+		swaps.HTLCVariantP2WSH, swaps.HTLCVariantTaproot,
+	} {
+		t.Run(variant.String(), func(t *testing.T) {
+			f := newBitcoinSwapFixture(t, variant)
+
+			if _, err := f.adapter.CreateRefundTx(f.contractTx, f.payTo, 499); err == nil {
+				t.Error("Expected error for refund before contract lock time")
+			}
+
+			// A timestamp is not comparable to the height lock time
+			// of the contract.
+			if _, err := f.adapter.CreateRefundTx(f.contractTx, f.payTo,
+				btctxscript.LockTimeThreshold); err == nil {
+
+				t.Error("Expected error for refund lock time of another kind")
+			}
+
+			refundTx, err := f.adapter.CreateRefundTx(f.contractTx, f.payTo, 500)
+			if err != nil {
+				t.Fatalf("Failed to create refund tx: %v", err)
+			}
+
+			contractOut := f.contractTx.TxOut[0]
+			err = f.adapter.SignRefundTx(refundTx, contractOut, f.initiatorKey)
+			if err != nil {
+				t.Fatalf("Failed to sign refund tx: %v", err)
+			}
+
+			if err := executeSpend(t, refundTx, contractOut); err != nil {
+				t.Fatalf("Refund script failed: %v", err)
+			}
+
+			// Lowering the lock time below the contract's must fail
+			// script execution.
+			refundTx.LockTime = 499
+			err = f.adapter.SignRefundTx(refundTx, contractOut, f.initiatorKey)
+			if err != nil {
+				t.Fatalf("Failed to sign refund tx: %v", err)
+			}
+			if err := executeSpend(t, refundTx, contractOut); err == nil {
+				t.Error("Expected early refund to fail")
+			}
+		})
+	}
+}
+
+// TestBitcoinHTLCInvalidSecret tests that invalid secrets are rejected
+func TestBitcoinHTLCInvalidSecret(t *testing.T) {
+	f := newBitcoinSwapFixture(t, swaps.HTLCVariantP2WSH)
+
+	wrongSecret := bytes.Repeat([]byte{0x43}, swaps.SecretSize)
+	if _, err := f.adapter.CreateRedeemTx(f.contractTx, wrongSecret, f.payTo); err == nil {
+		t.Error("Expected error for invalid secret")
+	}
+
+	// A correctly hashed secret of the wrong size is rejected too.
+	shortSecret := []byte("short")
+	if _, err := f.adapter.CreateRedeemTx(f.contractTx, shortSecret, f.payTo); err == nil {
+		t.Error("Expected error for short secret")
+	}
+
+	// Forcing the wrong secret into the witness must fail the script.
+	redeemTx, err := f.adapter.CreateRedeemTx(f.contractTx, f.secret, f.payTo)
+	if err != nil {
+		t.Fatalf("Failed to create redeem tx: %v", err)
+	}
+	contractOut := f.contractTx.TxOut[0]
+	if err := f.adapter.SignRedeemTx(redeemTx, contractOut, f.secret,
+		f.participantKey); err != nil {
+		t.Fatalf("Failed to sign redeem tx: %v", err)
+	}
+	redeemTx.TxIn[0].Witness[2] = wrongSecret
+	if err := executeSpend(t, redeemTx, contractOut); err == nil {
+		t.Error("Expected redeem with wrong secret to fail")
+	}
+}
+
+// TestBitcoinCrossChainSwap tests creating the Bitcoin leg of a swap
+func TestBitcoinCrossChainSwap(t *testing.T) {
+	adapter, err := swaps.NewBTCAdapter(&swaps.BitcoinAdapterConfig{
+		Params:  &chaincfg.SimNetParams,
+		Variant: swaps.HTLCVariantTaproot,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create adapter: %v", err)
+	}
+
+	initiatorPriv, _ := btcec.NewPrivateKey()
+	participantPriv, _ := btcec.NewPrivateKey()
+
+	secret := bytes.Repeat([]byte{0x07}, swaps.SecretSize)
+	shellParams := &swaps.AtomicSwapParams{
+		Initiator:   initiatorPriv.PubKey(),
+		Participant: participantPriv.PubKey(),
+		Amount:      1000000,
+		Timeout:     7200,
+		Chain:       swaps.ChainShell,
+		Secret:      secret,
+	}
+	counterLockTime := uint32(time.Now().Add(time.Hour).Unix())
+	swap, err := swaps.NewCrossChainSwap(shellParams, &swaps.CounterSwapParams{
+		Chain:    swaps.ChainBitcoin,
+		Amount:   50000,
+		LockTime: counterLockTime,
+		Bitcoin:  adapter,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cross-chain swap: %v", err)
+	}
+
+	// The counter leg must expire before the Shell leg, which can only be
+	// ordered against a timestamp.
+	for _, lockTime := range []uint32{
+		800000,
+		uint32(time.Now().Add(3 * time.Hour).Unix()),
+	} {
+		if _, err := swaps.NewCrossChainSwap(shellParams, &swaps.CounterSwapParams{
+			Chain:    swaps.ChainBitcoin,
+			Amount:   50000,
+			LockTime: lockTime,
+			Bitcoin:  adapter,
+		}); err == nil {
+			t.Errorf("Expected error for counter lock time %d", lockTime)
+		}
+	}
+
+	btcSwap, ok := swap.CounterSwap.(*swaps.BitcoinSwap)
+	if !ok {
+		t.Fatalf("Unexpected counter swap type %T", swap.CounterSwap)
+	}
+
+	if btcSwap.SecretHash != swap.ShellSwap.SecretHash {
+		t.Error("Counter swap secret hash mismatch")
+	}
+
+	htlc, err := adapter.LookupHTLC(btcSwap.HTLCScript)
+	if err != nil {
+		t.Fatalf("Failed to look up HTLC: %v", err)
+	}
+
+	// The Shell initiator redeems on Bitcoin.
+	if !bytes.Equal(htlc.ParticipantPubKey,
+		initiatorPriv.PubKey().SerializeCompressed()) {
+		t.Error("Bitcoin leg must pay the Shell initiator")
+	}
+
+	addr, err := htlc.Address(&chaincfg.SimNetParams)
+	if err != nil {
+		t.Fatalf("Failed to get HTLC address: %v", err)
+	}
+	if _, ok := addr.(*btcutil.AddressTaproot); !ok {
+		t.Errorf("Unexpected address type %T", addr)
+	}
+
+	if _, err := swaps.NewCrossChainSwap(shellParams, &swaps.CounterSwapParams{
+		Chain:    swaps.ChainBitcoin,
+		LockTime: counterLockTime,
+	}); err == nil {
+		t.Error("Expected error without Bitcoin adapter")
+	}
+}
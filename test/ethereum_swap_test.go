@@ -0,0 +1,241 @@
+package test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/toole-brendan/shell/settlement/swaps"
+)
+
+// Values recorded alongside the receipts in testdata/ethereum
+const (
+	ethFixtureSecret   = "2cb71d545ebd1b16528f0bf8674eefdba239932854a8375f6c45827bc564df92"
+	ethFixtureContract = "5fbdb2315678afecb367f032d93f642f64180aa3"
+	ethFixtureAlice    = "70997970c51812dc3a010c7d01b50e0d17dc79c8"
+	ethFixtureBob      = "f39fd6e51aad88f6f4ce6ab8827279cfffb92266"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("Failed to decode %q: %v", s, err)
+	}
+	return b
+}
+
+func loadEthereumReceipt(t *testing.T, name string) *swaps.EthereumReceipt {
+	t.Helper()
+
+	raw, err := os.ReadFile(filepath.Join("testdata", "ethereum", name))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	receipt, err := swaps.ParseEthereumReceipt(raw)
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", name, err)
+	}
+	return receipt
+}
+
+// TestEthereumCallEncoding tests ABI encoding of HTLC contract calls
+func TestEthereumCallEncoding(t *testing.T) {
+	adapter := swaps.NewETHAdapter()
+
+	secret := mustDecodeHex(t, ethFixtureSecret)
+	secretHash := sha256.Sum256(secret)
+	contract := mustDecodeHex(t, ethFixtureContract)
+	alice := mustDecodeHex(t, ethFixtureAlice)
+	bob := mustDecodeHex(t, ethFixtureBob)
+
+	data, err := adapter.CreateHTLCContract(secretHash, alice, bob, 1767225600,
+		big.NewInt(1e18))
+	if err != nil {
+		t.Fatalf("Failed to encode initiate: %v", err)
+	}
+
+	// initiate(uint256,bytes32,address)
+	want := "ae052147" +
+		"000000000000000000000000000000000000000000000000000000006955b900" +
+		hex.EncodeToString(secretHash[:]) +
+		"000000000000000000000000" + ethFixtureAlice
+	if hex.EncodeToString(data) != want {
+		t.Errorf("Unexpected initiate data:\n got %x\nwant %s", data, want)
+	}
+
+	data, err = adapter.CreateRedeemTx(contract, secret)
+	if err != nil {
+		t.Fatalf("Failed to encode redeem: %v", err)
+	}
+
+	// redeem(bytes32,bytes32)
+	want = "b31597ad" + ethFixtureSecret + hex.EncodeToString(secretHash[:])
+	if hex.EncodeToString(data) != want {
+		t.Errorf("Unexpected redeem data:\n got %x\nwant %s", data, want)
+	}
+
+	data, err = adapter.CreateRefundTx(contract, secretHash)
+	if err != nil {
+		t.Fatalf("Failed to encode refund: %v", err)
+	}
+
+	// refund(bytes32)
+	want = "7249fbb6" + hex.EncodeToString(secretHash[:])
+	if hex.EncodeToString(data) != want {
+		t.Errorf("Unexpected refund data:\n got %x\nwant %s", data, want)
+	}
+
+	// Invalid inputs
+	if _, err := adapter.CreateHTLCContract(secretHash, alice[:19], bob, 1,
+		big.NewInt(1)); err == nil {
+		t.Error("Expected error for short participant address")
+	}
+	if _, err := adapter.CreateHTLCContract(secretHash, alice, bob, 1,
+		big.NewInt(0)); err == nil {
+		t.Error("Expected error for zero amount")
+	}
+	if _, err := adapter.CreateRedeemTx(contract, secret[:16]); err == nil {
+		t.Error("Expected error for short secret")
+	}
+}
+
+// TestEthereumReceiptParsing tests decoding recorded JSON-RPC receipts
+func TestEthereumReceiptParsing(t *testing.T) {
+	secret := mustDecodeHex(t, ethFixtureSecret)
+	secretHash := sha256.Sum256(secret)
+
+	initiate := loadEthereumReceipt(t, "initiate_receipt.json")
+	if !initiate.Succeeded() {
+		t.Error("Initiate receipt should have succeeded")
+	}
+	height, err := initiate.Height()
+	if err != nil || height != 0x12a05f2 {
+		t.Errorf("Unexpected block height %d: %v", height, err)
+	}
+
+	event, err := swaps.ParseInitiatedLog(&initiate.Logs[0])
+	if err != nil {
+		t.Fatalf("Failed to parse Initiated log: %v", err)
+	}
+	if event.SecretHash != secretHash {
+		t.Error("Initiated secret hash mismatch")
+	}
+	if !bytes.Equal(event.Initiator, mustDecodeHex(t, ethFixtureBob)) {
+		t.Errorf("Unexpected initiator %x", event.Initiator)
+	}
+	if !bytes.Equal(event.Participant, mustDecodeHex(t, ethFixtureAlice)) {
+		t.Errorf("Unexpected participant %x", event.Participant)
+	}
+	if event.RefundTime != 1767225600 {
+		t.Errorf("Unexpected refund time %d", event.RefundTime)
+	}
+	if event.Value.Cmp(big.NewInt(1e18)) != 0 {
+		t.Errorf("Unexpected value %v", event.Value)
+	}
+
+	// The initiate receipt reveals nothing.
+	if _, err := swaps.ExtractEthereumSecret(initiate, secretHash); err == nil {
+		t.Error("Expected no secret in initiate receipt")
+	}
+
+	redeem := loadEthereumReceipt(t, "redeem_receipt.json")
+	extracted, err := swaps.ExtractEthereumSecret(redeem, secretHash)
+	if err != nil {
+		t.Fatalf("Failed to extract secret: %v", err)
+	}
+	if !bytes.Equal(extracted, secret) {
+		t.Errorf("Extracted secret mismatch: %x", extracted)
+	}
+
+	// A Redeemed log whose secret does not hash to its topic is rejected.
+	tampered := redeem.Logs[1]
+	tampered.Data = tampered.Data[:len(tampered.Data)-2] + "00"
+	if _, err := swaps.ParseRedeemedLog(&tampered); err == nil {
+		t.Error("Expected error for tampered Redeemed log")
+	}
+
+	failed := loadEthereumReceipt(t, "failed_receipt.json")
+	if failed.Succeeded() {
+		t.Error("Failed receipt should not have succeeded")
+	}
+	if _, err := swaps.ExtractEthereumSecret(failed, secretHash); err == nil {
+		t.Error("Expected error for failed transaction")
+	}
+
+	raw, err := os.ReadFile(filepath.Join("testdata", "ethereum", "pending_receipt.json"))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	if _, err := swaps.ParseEthereumReceipt(raw); err == nil {
+		t.Error("Expected error for pending receipt")
+	}
+}
+
+// TestEthereumCrossChainSwap tests coordinating an Ethereum swap through the
+// swap manager
+func TestEthereumCrossChainSwap(t *testing.T) {
+	initiatorPriv, _ := btcec.NewPrivateKey()
+	participantPriv, _ := btcec.NewPrivateKey()
+
+	secret := mustDecodeHex(t, ethFixtureSecret)
+	swap, err := swaps.NewCrossChainSwap(&swaps.AtomicSwapParams{
+		Initiator:   initiatorPriv.PubKey(),
+		Participant: participantPriv.PubKey(),
+		Amount:      1000000,
+		Timeout:     7200,
+		Chain:       swaps.ChainShell,
+		Secret:      secret,
+	}, &swaps.CounterSwapParams{
+		Chain:          swaps.ChainEthereum,
+		LockTime:       1767225600,
+		Ethereum:       swaps.NewETHAdapter(),
+		EthContract:    mustDecodeHex(t, ethFixtureContract),
+		EthInitiator:   mustDecodeHex(t, ethFixtureBob),
+		EthParticipant: mustDecodeHex(t, ethFixtureAlice),
+		EthValue:       big.NewInt(1e18),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create cross-chain swap: %v", err)
+	}
+
+	ethSwap, ok := swap.CounterSwap.(*swaps.EthereumSwap)
+	if !ok {
+		t.Fatalf("Unexpected counter swap type %T", swap.CounterSwap)
+	}
+	if len(ethSwap.InitiateData) != 4+3*32 {
+		t.Errorf("Unexpected initiate data length %d", len(ethSwap.InitiateData))
+	}
+
+	manager := swaps.NewSwapManager()
+	if err := manager.AddCrossChainSwap(swap); err != nil {
+		t.Fatalf("Failed to add swap: %v", err)
+	}
+
+	updated := manager.ProcessEthereumReceipt(loadEthereumReceipt(t,
+		"initiate_receipt.json"))
+	if len(updated) != 0 {
+		t.Errorf("Expected no updates from initiate receipt, got %d", len(updated))
+	}
+
+	updated = manager.ProcessEthereumReceipt(loadEthereumReceipt(t,
+		"redeem_receipt.json"))
+	if len(updated) != 1 || updated[0] != swap {
+		t.Fatalf("Expected swap to learn its secret, got %d updates", len(updated))
+	}
+	if !bytes.Equal(ethSwap.Secret, secret) {
+		t.Error("Revealed secret mismatch")
+	}
+
+	got, err := manager.GetCrossChainSwap(swap.ShellSwap.SwapID)
+	if err != nil || got != swap {
+		t.Errorf("Failed to retrieve cross-chain swap: %v", err)
+	}
+}
@@ -118,6 +118,11 @@ const (
 	// ScriptVerifyConstScriptCode fails non-segwit scripts if a signature
 	// match is found in the script code or if OP_CODESEPARATOR is used.
 	ScriptVerifyConstScriptCode
+
+	// ScriptVerifyShellVault defines whether or not to enforce the vault
+	// policies of taproot leaves committed under ShellTaprootLeafVersion.
+	// Without it, those leaves are spent like any unknown leaf version.
+	ScriptVerifyShellVault
 )
 
 const (
@@ -694,6 +699,23 @@ func (vm *Engine) verifyWitnessProgram(witness wire.TxWitness) error {
 				return err
 			}
 
+			// Shell vault leaves hold a vault policy rather than a
+			// script, so they're enforced directly instead of being
+			// executed once the vault covenant soft-fork is active.
+			if controlBlock.LeafVersion == ShellTaprootLeafVersion &&
+				vm.hasFlag(ScriptVerifyShellVault) {
+
+				err := vm.verifyShellVaultSpend(
+					witness[:len(witness)-2], witnessScript,
+				)
+				if err != nil {
+					return err
+				}
+
+				vm.taprootCtx.mustSucceed = true
+				return nil
+			}
+
 			// Now that we know the commitment is valid, we'll
 			// check to see if OP_SUCCESS op codes are found in the
 			// script. If so, then we'll return here early as we
@@ -416,6 +416,10 @@ const (
 	// non-segwit script.
 	ErrCodeSeparator
 
+	// ErrShellVaultViolation is returned when a spend of a Shell vault leaf
+	// is malformed or does not satisfy the vault policy.
+	ErrShellVaultViolation
+
 	// numErrorCodes is the maximum error code number used in tests.  This
 	// entry MUST be the last entry in the enum.
 	numErrorCodes
@@ -504,6 +508,7 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrTaprootMaxSigOps:                    "ErrTaprootMaxSigOps",
 	ErrNonConstScriptCode:                  "ErrNonConstScriptCode",
 	ErrCodeSeparator:                       "ErrCodeSeparator",
+	ErrShellVaultViolation:                 "ErrShellVaultViolation",
 }
 
 // String returns the ErrorCode as a human-readable name.
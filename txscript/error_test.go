@@ -98,6 +98,7 @@ func TestErrorCodeStringer(t *testing.T) {
 		{ErrTaprootMaxSigOps, "ErrTaprootMaxSigOps"},
 		{ErrNonConstScriptCode, "ErrNonConstScriptCode"},
 		{ErrCodeSeparator, "ErrCodeSeparator"},
+		{ErrShellVaultViolation, "ErrShellVaultViolation"},
 		{0xffff, "Unknown ErrorCode (65535)"},
 	}
 
@@ -0,0 +1,409 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// A Shell vault is a tapscript leaf committed under ShellTaprootLeafVersion.
+// Rather than an executable script, the leaf holds a serialized VaultPolicy
+// which the engine enforces directly. The policy can be spent along two
+// paths, selected by the witness element preceding the leaf:
+//
+//	unvault:  <sig_1> ... <sig_n> 0x01 <policy> <control block>
+//	clawback: <cold sig> 0x02 <policy> <control block>
+//
+// The unvault path requires Threshold valid signatures from the hot keys (an
+// empty element for each key that does not sign), the relative Delay to have
+// passed on the spending input and, when TemplateHash is set, the spending
+// transaction to match the committed template. The clawback path only
+// requires a signature from the cold key and is meant to sweep funds to cold
+// storage while an unvault is waiting out its delay.
+//
+// A typical two stage vault commits the vault output to a template paying an
+// "unvaulting" output whose policy has the same keys and a non-zero delay.
+const (
+	// VaultPolicyVersion is the current serialization version of a vault
+	// policy.
+	VaultPolicyVersion = 0x01
+
+	// MaxVaultHotKeys is the maximum number of hot keys in a vault policy.
+	MaxVaultHotKeys = 16
+
+	// vaultPolicyBaseSize is the size of a serialized policy without its
+	// hot keys: version, threshold, key count, cold key, delay and template
+	// hash.
+	vaultPolicyBaseSize = 1 + 1 + 1 + 32 + 4 + 32
+)
+
+// VaultSpendPath identifies the path used to spend a vault policy.
+type VaultSpendPath byte
+
+const (
+	// VaultPathUnvault spends the vault with the hot keys.
+	VaultPathUnvault VaultSpendPath = 0x01
+
+	// VaultPathClawback spends the vault with the cold key.
+	VaultPathClawback VaultSpendPath = 0x02
+)
+
+// VaultPolicy describes the spending conditions of a Shell vault leaf.
+type VaultPolicy struct {
+	// Threshold is the number of hot key signatures required to unvault.
+	Threshold uint8
+
+	// HotKeys are the keys authorized to unvault.
+	HotKeys []*btcec.PublicKey
+
+	// ColdKey is the key authorized to claw back funds at any time.
+	ColdKey *btcec.PublicKey
+
+	// Delay is the relative lock time, encoded as a BIP 68 sequence value,
+	// that must have passed before the hot keys can spend. Zero disables
+	// the delay.
+	Delay uint32
+
+	// TemplateHash commits the unvault path to a spending transaction as
+	// computed by CalcVaultTemplateHash. A zero hash disables the check.
+	TemplateHash chainhash.Hash
+}
+
+// Validate checks the policy for consistency.
+func (p *VaultPolicy) Validate() error {
+	if len(p.HotKeys) == 0 || len(p.HotKeys) > MaxVaultHotKeys {
+		str := fmt.Sprintf("vault must have between 1 and %d hot keys, "+
+			"got %d", MaxVaultHotKeys, len(p.HotKeys))
+		return scriptError(ErrShellVaultViolation, str)
+	}
+
+	if p.Threshold == 0 || int(p.Threshold) > len(p.HotKeys) {
+		str := fmt.Sprintf("invalid vault threshold %d of %d",
+			p.Threshold, len(p.HotKeys))
+		return scriptError(ErrShellVaultViolation, str)
+	}
+
+	if p.ColdKey == nil {
+		return scriptError(ErrShellVaultViolation, "vault cold key required")
+	}
+
+	if p.Delay&wire.SequenceLockTimeDisabled != 0 {
+		str := fmt.Sprintf("vault delay 0x%x has the disable bit set",
+			p.Delay)
+		return scriptError(ErrShellVaultViolation, str)
+	}
+
+	coldKey := schnorr.SerializePubKey(p.ColdKey)
+	seen := make(map[[32]byte]struct{}, len(p.HotKeys))
+	for _, key := range p.HotKeys {
+		if key == nil {
+			return scriptError(ErrShellVaultViolation, "nil vault hot key")
+		}
+
+		var xOnly [32]byte
+		copy(xOnly[:], schnorr.SerializePubKey(key))
+		if _, ok := seen[xOnly]; ok {
+			return scriptError(ErrShellVaultViolation,
+				"duplicate vault hot key")
+		}
+		if bytes.Equal(xOnly[:], coldKey) {
+			return scriptError(ErrShellVaultViolation,
+				"vault cold key reused as hot key")
+		}
+		seen[xOnly] = struct{}{}
+	}
+
+	return nil
+}
+
+// Serialize encodes the policy as the contents of a vault leaf.
+func (p *VaultPolicy) Serialize() ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 0, vaultPolicyBaseSize+32*len(p.HotKeys))
+	buf = append(buf, VaultPolicyVersion, p.Threshold, byte(len(p.HotKeys)))
+	for _, key := range p.HotKeys {
+		buf = append(buf, schnorr.SerializePubKey(key)...)
+	}
+	buf = append(buf, schnorr.SerializePubKey(p.ColdKey)...)
+	buf = binary.LittleEndian.AppendUint32(buf, p.Delay)
+	buf = append(buf, p.TemplateHash[:]...)
+
+	return buf, nil
+}
+
+// TapLeaf returns the tapscript leaf committing to the policy.
+func (p *VaultPolicy) TapLeaf() (TapLeaf, error) {
+	policy, err := p.Serialize()
+	if err != nil {
+		return TapLeaf{}, err
+	}
+
+	return NewTapLeaf(ShellTaprootLeafVersion, policy), nil
+}
+
+// ParseVaultPolicy decodes the contents of a vault leaf.
+func ParseVaultPolicy(policy []byte) (*VaultPolicy, error) {
+	if len(policy) < vaultPolicyBaseSize {
+		str := fmt.Sprintf("vault policy too short: %d bytes", len(policy))
+		return nil, scriptError(ErrShellVaultViolation, str)
+	}
+
+	if policy[0] != VaultPolicyVersion {
+		str := fmt.Sprintf("unknown vault policy version %d", policy[0])
+		return nil, scriptError(ErrShellVaultViolation, str)
+	}
+
+	numKeys := int(policy[2])
+	if len(policy) != vaultPolicyBaseSize+32*numKeys {
+		str := fmt.Sprintf("vault policy with %d hot keys has invalid "+
+			"length %d", numKeys, len(policy))
+		return nil, scriptError(ErrShellVaultViolation, str)
+	}
+
+	p := &VaultPolicy{
+		Threshold: policy[1],
+		HotKeys:   make([]*btcec.PublicKey, numKeys),
+	}
+
+	offset := 3
+	for i := range p.HotKeys {
+		key, err := schnorr.ParsePubKey(policy[offset : offset+32])
+		if err != nil {
+			str := fmt.Sprintf("invalid vault hot key %d: %v", i, err)
+			return nil, scriptError(ErrShellVaultViolation, str)
+		}
+		p.HotKeys[i] = key
+		offset += 32
+	}
+
+	coldKey, err := schnorr.ParsePubKey(policy[offset : offset+32])
+	if err != nil {
+		str := fmt.Sprintf("invalid vault cold key: %v", err)
+		return nil, scriptError(ErrShellVaultViolation, str)
+	}
+	p.ColdKey = coldKey
+	offset += 32
+
+	p.Delay = binary.LittleEndian.Uint32(policy[offset : offset+4])
+	offset += 4
+	copy(p.TemplateHash[:], policy[offset:])
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// CalcVaultTemplateHash computes the template hash committing a vault to a
+// spending transaction. It follows the BIP 119 default template: version,
+// lock time, the script sigs (if any are set), input count, sequences, output
+// count, outputs and the index of the input spending the vault.
+func CalcVaultTemplateHash(tx *wire.MsgTx, inputIdx uint32) chainhash.Hash {
+	var buf bytes.Buffer
+	var scratch [4]byte
+
+	binary.LittleEndian.PutUint32(scratch[:], uint32(tx.Version))
+	buf.Write(scratch[:])
+	binary.LittleEndian.PutUint32(scratch[:], tx.LockTime)
+	buf.Write(scratch[:])
+
+	hasScriptSigs := false
+	for _, txIn := range tx.TxIn {
+		if len(txIn.SignatureScript) > 0 {
+			hasScriptSigs = true
+			break
+		}
+	}
+	if hasScriptSigs {
+		h := sha256.New()
+		for _, txIn := range tx.TxIn {
+			_ = wire.WriteVarBytes(h, 0, txIn.SignatureScript)
+		}
+		buf.Write(h.Sum(nil))
+	}
+
+	binary.LittleEndian.PutUint32(scratch[:], uint32(len(tx.TxIn)))
+	buf.Write(scratch[:])
+
+	seqHash := sha256.New()
+	for _, txIn := range tx.TxIn {
+		binary.LittleEndian.PutUint32(scratch[:], txIn.Sequence)
+		seqHash.Write(scratch[:])
+	}
+	buf.Write(seqHash.Sum(nil))
+
+	binary.LittleEndian.PutUint32(scratch[:], uint32(len(tx.TxOut)))
+	buf.Write(scratch[:])
+
+	outHash := sha256.New()
+	for _, txOut := range tx.TxOut {
+		_ = wire.WriteTxOut(outHash, 0, tx.Version, txOut)
+	}
+	buf.Write(outHash.Sum(nil))
+
+	binary.LittleEndian.PutUint32(scratch[:], inputIdx)
+	buf.Write(scratch[:])
+
+	return chainhash.Hash(sha256.Sum256(buf.Bytes()))
+}
+
+// verifyShellVaultSpend enforces the vault policy revealed in a tapscript
+// spend. witness holds the elements preceding the policy, with the annex,
+// policy and control block already removed.
+func (vm *Engine) verifyShellVaultSpend(witness wire.TxWitness, policyBytes []byte) error {
+	policy, err := ParseVaultPolicy(policyBytes)
+	if err != nil {
+		return err
+	}
+
+	if len(witness) == 0 || len(witness[len(witness)-1]) != 1 {
+		return scriptError(ErrShellVaultViolation,
+			"vault witness must select a spend path")
+	}
+
+	leafHash := NewTapLeaf(ShellTaprootLeafVersion, policyBytes).TapHash()
+	sigs := witness[:len(witness)-1]
+
+	switch VaultSpendPath(witness[len(witness)-1][0]) {
+	case VaultPathUnvault:
+		if len(sigs) != len(policy.HotKeys) {
+			str := fmt.Sprintf("unvault requires %d signature slots, "+
+				"got %d", len(policy.HotKeys), len(sigs))
+			return scriptError(ErrShellVaultViolation, str)
+		}
+
+		if err := vm.verifyVaultDelay(policy.Delay); err != nil {
+			return err
+		}
+
+		var zeroHash chainhash.Hash
+		if policy.TemplateHash != zeroHash {
+			templateHash := CalcVaultTemplateHash(&vm.tx, uint32(vm.txIdx))
+			if templateHash != policy.TemplateHash {
+				str := fmt.Sprintf("unvault transaction template "+
+					"%v does not match committed %v", templateHash,
+					policy.TemplateHash)
+				return scriptError(ErrShellVaultViolation, str)
+			}
+		}
+
+		valid := 0
+		for i, sig := range sigs {
+			if len(sig) == 0 {
+				continue
+			}
+			err := vm.verifyVaultSig(policy.HotKeys[i], sig, leafHash)
+			if err != nil {
+				return err
+			}
+			valid++
+		}
+
+		if valid < int(policy.Threshold) {
+			str := fmt.Sprintf("unvault has %d of %d required "+
+				"signatures", valid, policy.Threshold)
+			return scriptError(ErrShellVaultViolation, str)
+		}
+
+		return nil
+
+	case VaultPathClawback:
+		if len(sigs) != 1 {
+			str := fmt.Sprintf("clawback requires a single signature, "+
+				"got %d elements", len(sigs))
+			return scriptError(ErrShellVaultViolation, str)
+		}
+
+		return vm.verifyVaultSig(policy.ColdKey, sigs[0], leafHash)
+
+	default:
+		str := fmt.Sprintf("unknown vault spend path %d", witness[len(witness)-1][0])
+		return scriptError(ErrShellVaultViolation, str)
+	}
+}
+
+// verifyVaultDelay checks the relative lock time of the spending input
+// against the vault delay using the rules of OP_CHECKSEQUENCEVERIFY.
+func (vm *Engine) verifyVaultDelay(delay uint32) error {
+	if delay == 0 {
+		return nil
+	}
+
+	if uint32(vm.tx.Version) < 2 {
+		str := fmt.Sprintf("vault delay requires transaction version 2, "+
+			"got %d", vm.tx.Version)
+		return scriptError(ErrUnsatisfiedLockTime, str)
+	}
+
+	txSequence := int64(vm.tx.TxIn[vm.txIdx].Sequence)
+	if txSequence&int64(wire.SequenceLockTimeDisabled) != 0 {
+		str := fmt.Sprintf("transaction sequence has sequence "+
+			"locktime disabled bit set: 0x%x", txSequence)
+		return scriptError(ErrUnsatisfiedLockTime, str)
+	}
+
+	lockTimeMask := int64(wire.SequenceLockTimeIsSeconds |
+		wire.SequenceLockTimeMask)
+	return verifyLockTime(txSequence&lockTimeMask,
+		wire.SequenceLockTimeIsSeconds, int64(delay)&lockTimeMask)
+}
+
+// verifyVaultSig verifies a BIP 340 signature over the tapscript sighash of
+// the vault leaf.  Like signatures checked by tapscript, each signature uses
+// up part of the sig ops budget of the input and is checked against the
+// signature cache of the engine.
+func (vm *Engine) verifyVaultSig(key *btcec.PublicKey, rawSig []byte,
+	leafHash chainhash.Hash) error {
+
+	var annex []byte
+	if vm.taprootCtx != nil {
+		if err := vm.taprootCtx.tallysigOp(); err != nil {
+			return err
+		}
+		annex = vm.taprootCtx.annex
+	}
+
+	verifier, err := newTaprootSigVerifier(
+		schnorr.SerializePubKey(key), rawSig, &vm.tx, vm.txIdx,
+		vm.prevOutFetcher, vm.sigCache, vm.hashCache, annex,
+	)
+	if err != nil {
+		return err
+	}
+
+	opts := []TaprootSigHashOption{
+		WithBaseTapscriptVersion(blankCodeSepValue, leafHash[:]),
+	}
+	if annex != nil {
+		opts = append(opts, WithAnnex(annex))
+	}
+
+	sigHash, err := calcTaprootSignatureHashRaw(
+		vm.hashCache, verifier.hashType, &vm.tx, vm.txIdx,
+		vm.prevOutFetcher, opts...,
+	)
+	if err != nil {
+		return err
+	}
+
+	if !verifier.verifySig(sigHash) {
+		return scriptError(ErrShellVaultViolation,
+			"invalid vault signature")
+	}
+
+	return nil
+}
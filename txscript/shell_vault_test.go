@@ -0,0 +1,341 @@
+// Copyright (c) 2025 The Shell developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/require"
+	"github.com/toole-brendan/shell/chaincfg/chainhash"
+	"github.com/toole-brendan/shell/wire"
+)
+
+// vaultTestKeys holds the keys used by the vault tests.
+type vaultTestKeys struct {
+	hot      []*btcec.PrivateKey
+	cold     *btcec.PrivateKey
+	internal *btcec.PrivateKey
+}
+
+func newVaultTestKeys(t *testing.T) *vaultTestKeys {
+	t.Helper()
+
+	keys := &vaultTestKeys{}
+	for i := 0; i < 3; i++ {
+		key, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		keys.hot = append(keys.hot, key)
+	}
+
+	var err error
+	keys.cold, err = btcec.NewPrivateKey()
+	require.NoError(t, err)
+	keys.internal, err = btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	return keys
+}
+
+func (k *vaultTestKeys) hotPubKeys() []*btcec.PublicKey {
+	pubKeys := make([]*btcec.PublicKey, len(k.hot))
+	for i, key := range k.hot {
+		pubKeys[i] = key.PubKey()
+	}
+	return pubKeys
+}
+
+// vaultOutput builds a Taproot output with a single vault leaf.
+func vaultOutput(t *testing.T, keys *vaultTestKeys,
+	policy *VaultPolicy) (*wire.TxOut, TapLeaf, []byte) {
+
+	t.Helper()
+
+	builder := NewShellTaprootBuilder(keys.internal.PubKey())
+	require.NoError(t, builder.AddVaultLeaf(policy))
+
+	pkScript, err := builder.Build()
+	require.NoError(t, err)
+
+	controlBlock, err := builder.ControlBlock(0)
+	require.NoError(t, err)
+
+	return wire.NewTxOut(1e8, pkScript), builder.Leaves()[0], controlBlock
+}
+
+// spendTx creates a transaction spending a single vault output.
+func spendTx(sequence uint32, pkScript []byte) *wire.MsgTx {
+	tx := wire.NewMsgTx(2)
+	txIn := wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0x01}, 0), nil, nil)
+	txIn.Sequence = sequence
+	tx.AddTxIn(txIn)
+	tx.AddTxOut(wire.NewTxOut(1e8-1000, pkScript))
+	return tx
+}
+
+// signVault attaches a witness for the given path to the first input of tx.
+func signVault(t *testing.T, tx *wire.MsgTx, prevOut *wire.TxOut, leaf TapLeaf,
+	controlBlock []byte, path VaultSpendPath, signers []*btcec.PrivateKey) {
+
+	t.Helper()
+
+	fetcher := NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value)
+	sigHashes := NewTxSigHashes(tx, fetcher)
+
+	witness := make(wire.TxWitness, 0, len(signers)+3)
+	for _, signer := range signers {
+		if signer == nil {
+			witness = append(witness, []byte{})
+			continue
+		}
+
+		sig, err := RawTxInTapscriptSignature(tx, sigHashes, 0,
+			prevOut.Value, prevOut.PkScript, leaf, SigHashDefault, signer)
+		require.NoError(t, err)
+		witness = append(witness, sig)
+	}
+	witness = append(witness, []byte{byte(path)}, leaf.Script, controlBlock)
+
+	tx.TxIn[0].Witness = witness
+}
+
+// executeVault runs the engine on the first input of tx with vault policies
+// enforced.
+func executeVault(tx *wire.MsgTx, prevOut *wire.TxOut) error {
+	return executeVaultFlags(tx, prevOut,
+		StandardVerifyFlags|ScriptVerifyShellVault)
+}
+
+// executeVaultFlags runs the engine on the first input of tx with the passed
+// script flags.
+func executeVaultFlags(tx *wire.MsgTx, prevOut *wire.TxOut,
+	flags ScriptFlags) error {
+
+	fetcher := NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value)
+	vm, err := NewEngine(prevOut.PkScript, tx, 0, flags,
+		nil, NewTxSigHashes(tx, fetcher), prevOut.Value, fetcher)
+	if err != nil {
+		return err
+	}
+	return vm.Execute()
+}
+
+// TestVaultPolicySerialization tests round tripping and validating vault
+// policies.
+func TestVaultPolicySerialization(t *testing.T) {
+	t.Parallel()
+
+	keys := newVaultTestKeys(t)
+	policy := &VaultPolicy{
+		Threshold:    2,
+		HotKeys:      keys.hotPubKeys(),
+		ColdKey:      keys.cold.PubKey(),
+		Delay:        144,
+		TemplateHash: chainhash.Hash{0xaa},
+	}
+
+	serialized, err := policy.Serialize()
+	require.NoError(t, err)
+	require.Len(t, serialized, vaultPolicyBaseSize+3*32)
+
+	parsed, err := ParseVaultPolicy(serialized)
+	require.NoError(t, err)
+	require.Equal(t, policy.Threshold, parsed.Threshold)
+	require.Equal(t, policy.Delay, parsed.Delay)
+	require.Equal(t, policy.TemplateHash, parsed.TemplateHash)
+	require.Len(t, parsed.HotKeys, 3)
+
+	reserialized, err := parsed.Serialize()
+	require.NoError(t, err)
+	require.Equal(t, serialized, reserialized)
+
+	// Invalid policies.
+	invalid := []*VaultPolicy{
+		{Threshold: 0, HotKeys: keys.hotPubKeys(), ColdKey: keys.cold.PubKey()},
+		{Threshold: 4, HotKeys: keys.hotPubKeys(), ColdKey: keys.cold.PubKey()},
+		{Threshold: 1, HotKeys: keys.hotPubKeys()},
+		{Threshold: 1, HotKeys: keys.hotPubKeys(), ColdKey: keys.hot[0].PubKey()},
+		{Threshold: 1, HotKeys: keys.hotPubKeys(), ColdKey: keys.cold.PubKey(),
+			Delay: wire.SequenceLockTimeDisabled},
+	}
+	for i, p := range invalid {
+		_, err := p.Serialize()
+		require.Truef(t, IsErrorCode(err, ErrShellVaultViolation),
+			"policy %d: unexpected error %v", i, err)
+	}
+
+	_, err = ParseVaultPolicy(serialized[:len(serialized)-1])
+	require.True(t, IsErrorCode(err, ErrShellVaultViolation))
+}
+
+// TestVaultUnvaultAndClawback tests both stages of a two stage vault.
+func TestVaultUnvaultAndClawback(t *testing.T) {
+	t.Parallel()
+
+	keys := newVaultTestKeys(t)
+	const delay = 10
+
+	// Second stage: the unvaulting output.
+	unvaulting := UnvaultingPolicy(keys.hotPubKeys(), 2, keys.cold.PubKey(),
+		delay)
+	unvaultOut, unvaultLeaf, unvaultCB := vaultOutput(t, keys, unvaulting)
+
+	// First stage: the vault commits to the unvault transaction.
+	unvaultTx := spendTx(wire.MaxTxInSequenceNum, unvaultOut.PkScript)
+	builder := NewShellTaprootBuilder(keys.internal.PubKey())
+	require.NoError(t, builder.AddTwoStageVault(keys.hotPubKeys(), 2,
+		keys.cold.PubKey(), unvaultTx, 0))
+	vaultPkScript, err := builder.Build()
+	require.NoError(t, err)
+	vaultCB, err := builder.ControlBlock(0)
+	require.NoError(t, err)
+	vaultLeaf := builder.Leaves()[0]
+	vaultOut := wire.NewTxOut(1e8, vaultPkScript)
+
+	t.Run("unvault with threshold", func(t *testing.T) {
+		tx := unvaultTx.Copy()
+		signVault(t, tx, vaultOut, vaultLeaf, vaultCB, VaultPathUnvault,
+			[]*btcec.PrivateKey{keys.hot[0], nil, keys.hot[2]})
+		require.NoError(t, executeVault(tx, vaultOut))
+		require.NoError(t, ValidateShellTaprootWitness(tx.TxIn[0].Witness,
+			vaultOut))
+	})
+
+	t.Run("unvault below threshold", func(t *testing.T) {
+		tx := unvaultTx.Copy()
+		signVault(t, tx, vaultOut, vaultLeaf, vaultCB, VaultPathUnvault,
+			[]*btcec.PrivateKey{keys.hot[0], nil, nil})
+		err := executeVault(tx, vaultOut)
+		require.True(t, IsErrorCode(err, ErrShellVaultViolation), err)
+	})
+
+	t.Run("unvault before activation", func(t *testing.T) {
+		// Without the vault flag the leaf is an unknown leaf
+		// version, which is non-standard but valid.
+		tx := unvaultTx.Copy()
+		signVault(t, tx, vaultOut, vaultLeaf, vaultCB, VaultPathUnvault,
+			[]*btcec.PrivateKey{keys.hot[0], nil, nil})
+		require.Error(t, executeVaultFlags(tx, vaultOut,
+			StandardVerifyFlags))
+
+		flags := StandardVerifyFlags &^
+			(ScriptVerifyDiscourageUpgradeableTaprootVersion |
+				ScriptVerifyDiscourageOpSuccess)
+		require.NoError(t, executeVaultFlags(tx, vaultOut, flags))
+	})
+
+	t.Run("unvault with wrong key", func(t *testing.T) {
+		tx := unvaultTx.Copy()
+		signVault(t, tx, vaultOut, vaultLeaf, vaultCB, VaultPathUnvault,
+			[]*btcec.PrivateKey{keys.hot[0], keys.hot[2], nil})
+		err := executeVault(tx, vaultOut)
+		require.True(t, IsErrorCode(err, ErrShellVaultViolation), err)
+	})
+
+	t.Run("unvault off template", func(t *testing.T) {
+		tx := spendTx(wire.MaxTxInSequenceNum, []byte{OP_TRUE})
+		signVault(t, tx, vaultOut, vaultLeaf, vaultCB, VaultPathUnvault,
+			[]*btcec.PrivateKey{keys.hot[0], keys.hot[1], nil})
+		err := executeVault(tx, vaultOut)
+		require.True(t, IsErrorCode(err, ErrShellVaultViolation), err)
+	})
+
+	t.Run("vault clawback", func(t *testing.T) {
+		tx := spendTx(wire.MaxTxInSequenceNum, []byte{OP_TRUE})
+		signVault(t, tx, vaultOut, vaultLeaf, vaultCB, VaultPathClawback,
+			[]*btcec.PrivateKey{keys.cold})
+		require.NoError(t, executeVault(tx, vaultOut))
+	})
+
+	t.Run("clawback with hot key", func(t *testing.T) {
+		tx := spendTx(wire.MaxTxInSequenceNum, []byte{OP_TRUE})
+		signVault(t, tx, vaultOut, vaultLeaf, vaultCB, VaultPathClawback,
+			[]*btcec.PrivateKey{keys.hot[0]})
+		err := executeVault(tx, vaultOut)
+		require.True(t, IsErrorCode(err, ErrShellVaultViolation), err)
+	})
+
+	t.Run("withdraw before delay", func(t *testing.T) {
+		tx := spendTx(delay-1, []byte{OP_TRUE})
+		signVault(t, tx, unvaultOut, unvaultLeaf, unvaultCB,
+			VaultPathUnvault,
+			[]*btcec.PrivateKey{keys.hot[0], keys.hot[1], nil})
+		err := executeVault(tx, unvaultOut)
+		require.True(t, IsErrorCode(err, ErrUnsatisfiedLockTime), err)
+	})
+
+	t.Run("withdraw after delay", func(t *testing.T) {
+		tx := spendTx(delay, []byte{OP_TRUE})
+		signVault(t, tx, unvaultOut, unvaultLeaf, unvaultCB,
+			VaultPathUnvault,
+			[]*btcec.PrivateKey{keys.hot[0], keys.hot[1], nil})
+		require.NoError(t, executeVault(tx, unvaultOut))
+	})
+
+	t.Run("clawback during delay", func(t *testing.T) {
+		tx := spendTx(0, []byte{OP_TRUE})
+		signVault(t, tx, unvaultOut, unvaultLeaf, unvaultCB,
+			VaultPathClawback, []*btcec.PrivateKey{keys.cold})
+		require.NoError(t, executeVault(tx, unvaultOut))
+	})
+}
+
+// TestVaultSigOps tests that vault signatures use up the sig ops budget of
+// the input and are added to the signature cache like tapscript signatures.
+func TestVaultSigOps(t *testing.T) {
+	t.Parallel()
+
+	keys := newVaultTestKeys(t)
+	policy := UnvaultingPolicy(keys.hotPubKeys(), 2, keys.cold.PubKey(), 0)
+	prevOut, leaf, controlBlock := vaultOutput(t, keys, policy)
+
+	tx := spendTx(wire.MaxTxInSequenceNum, []byte{OP_TRUE})
+	signVault(t, tx, prevOut, leaf, controlBlock, VaultPathUnvault,
+		[]*btcec.PrivateKey{keys.hot[0], nil, keys.hot[2]})
+
+	fetcher := NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value)
+	sigCache := NewSigCache(10)
+	vm, err := NewEngine(prevOut.PkScript, tx, 0,
+		StandardVerifyFlags|ScriptVerifyShellVault, sigCache,
+		NewTxSigHashes(tx, fetcher), prevOut.Value, fetcher)
+	require.NoError(t, err)
+	require.NoError(t, vm.Execute())
+
+	// Each of the two signatures costs a sig op.
+	budget := sigOpsDelta + int32(tx.TxIn[0].Witness.SerializeSize())
+	require.Equal(t, budget-2*sigOpsDelta, vm.taprootCtx.sigOpsBudget)
+	require.NotEmpty(t, sigCache.validSigs)
+}
+
+// TestVaultTemplateHash tests that the template hash commits to the fields
+// of the spending transaction.
+func TestVaultTemplateHash(t *testing.T) {
+	t.Parallel()
+
+	tx := spendTx(wire.MaxTxInSequenceNum, []byte{OP_TRUE})
+	base := CalcVaultTemplateHash(tx, 0)
+
+	// The witness is not committed to.
+	tx.TxIn[0].Witness = wire.TxWitness{{0x01}}
+	require.Equal(t, base, CalcVaultTemplateHash(tx, 0))
+
+	mutations := []func(tx *wire.MsgTx){
+		func(tx *wire.MsgTx) { tx.Version = 3 },
+		func(tx *wire.MsgTx) { tx.LockTime = 1 },
+		func(tx *wire.MsgTx) { tx.TxIn[0].Sequence = 0 },
+		func(tx *wire.MsgTx) { tx.TxOut[0].Value-- },
+		func(tx *wire.MsgTx) { tx.TxOut[0].PkScript = []byte{OP_FALSE} },
+		func(tx *wire.MsgTx) { tx.AddTxOut(wire.NewTxOut(0, nil)) },
+		func(tx *wire.MsgTx) { tx.TxIn[0].SignatureScript = []byte{OP_TRUE} },
+	}
+	for i, mutate := range mutations {
+		mutated := tx.Copy()
+		mutate(mutated)
+		require.NotEqualf(t, base, CalcVaultTemplateHash(mutated, 0),
+			"mutation %d not committed", i)
+	}
+
+	require.NotEqual(t, base, CalcVaultTemplateHash(tx, 1))
+}
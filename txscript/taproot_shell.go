@@ -29,6 +29,7 @@ type ShellTaprootBuilder struct {
 	// Shell-specific fields
 	isChannel   bool
 	isClaimable bool
+	isVault     bool
 }
 
 // NewShellTaprootBuilder creates a new builder for Shell Taproot outputs
@@ -69,6 +70,75 @@ func (stb *ShellTaprootBuilder) AddClaimableLeaf(script []byte) error {
 	return nil
 }
 
+// AddVaultLeaf adds a vault covenant leaf enforcing the given policy
+func (stb *ShellTaprootBuilder) AddVaultLeaf(policy *VaultPolicy) error {
+	if stb.isChannel || stb.isClaimable {
+		return errors.New("cannot mix vault with channel or claimable outputs")
+	}
+
+	leaf, err := policy.TapLeaf()
+	if err != nil {
+		return err
+	}
+
+	stb.isVault = true
+	stb.leaves = append(stb.leaves, leaf)
+
+	return nil
+}
+
+// AddTwoStageVault adds the leaf of the first stage of a vault: the hot keys
+// can only move funds with unvaultTx, which is expected to pay an unvaulting
+// output built with UnvaultingPolicy, and the cold key can claw back
+func (stb *ShellTaprootBuilder) AddTwoStageVault(hotKeys []*btcec.PublicKey,
+	threshold uint8, coldKey *btcec.PublicKey, unvaultTx *wire.MsgTx,
+	inputIdx uint32) error {
+
+	return stb.AddVaultLeaf(&VaultPolicy{
+		Threshold:    threshold,
+		HotKeys:      hotKeys,
+		ColdKey:      coldKey,
+		TemplateHash: CalcVaultTemplateHash(unvaultTx, inputIdx),
+	})
+}
+
+// UnvaultingPolicy returns the policy for the second stage of a vault: the
+// hot keys can spend once delay (a BIP 68 relative lock time) has passed and
+// the cold key can claw back while the delay runs
+func UnvaultingPolicy(hotKeys []*btcec.PublicKey, threshold uint8,
+	coldKey *btcec.PublicKey, delay uint32) *VaultPolicy {
+
+	return &VaultPolicy{
+		Threshold: threshold,
+		HotKeys:   hotKeys,
+		ColdKey:   coldKey,
+		Delay:     delay,
+	}
+}
+
+// Leaves returns the leaves added to the builder
+func (stb *ShellTaprootBuilder) Leaves() []TapLeaf {
+	return stb.leaves
+}
+
+// ControlBlock returns the serialized control block for spending the leaf
+// at index idx of the tree built by Build
+func (stb *ShellTaprootBuilder) ControlBlock(idx int) ([]byte, error) {
+	if stb.internalKey == nil {
+		return nil, errors.New("internal key required")
+	}
+
+	tapscriptTree := AssembleTaprootScriptTree(stb.leaves...)
+	if idx < 0 || idx >= len(tapscriptTree.LeafMerkleProofs) {
+		return nil, fmt.Errorf("leaf index %d out of range", idx)
+	}
+
+	controlBlock := tapscriptTree.LeafMerkleProofs[idx].ToControlBlock(
+		stb.internalKey,
+	)
+	return controlBlock.ToBytes()
+}
+
 // Build constructs the final Taproot output
 func (stb *ShellTaprootBuilder) Build() ([]byte, error) {
 	if stb.internalKey == nil {
@@ -135,20 +205,17 @@ func verifyShellVaultRules(vm *Engine) error {
 	}
 
 	witness := vm.tx.TxIn[vm.txIdx].Witness
+	if isAnnexedWitness(witness) {
+		witness = witness[:len(witness)-1]
+	}
 	if len(witness) < 3 {
 		return errors.New("insufficient witness data for vault")
 	}
 
 	// Witness stack for vault spend:
-	// [signature(s)] [vault_template] [script] [control_block]
-
-	// TODO: Full vault validation including:
-	// - Template hash verification
-	// - Time lock checking
-	// - Signature threshold validation
-	// - Hot/cold key verification
-
-	return nil
+	// [signature(s)] [spend_path] [vault_policy] [control_block]
+	return vm.verifyShellVaultSpend(witness[:len(witness)-2],
+		witness[len(witness)-2])
 }
 
 // verifyShellOpcodeRules ensures Shell opcodes are used correctly
@@ -369,11 +436,38 @@ func ValidateShellTaprootWitness(witness wire.TxWitness, prevOut *wire.TxOut) er
 	// Apply Shell-specific validation based on leaf version
 	switch leafVersion {
 	case ShellTaprootLeafVersion:
-		// Vault covenant validation
+		// Vault covenant validation. Signatures, delay and template
+		// need the spending transaction and are checked by the engine
+		if isAnnexedWitness(witness) {
+			witness = witness[:len(witness)-1]
+		}
 		if len(witness) < 4 {
 			return errors.New("vault spend requires additional witness data")
 		}
-		// TODO: Validate vault template and signatures
+
+		policy, err := ParseVaultPolicy(witness[len(witness)-2])
+		if err != nil {
+			return err
+		}
+
+		path := witness[len(witness)-3]
+		numSigs := len(witness) - 3
+		switch {
+		case len(path) != 1:
+			return errors.New("vault witness must select a spend path")
+
+		case VaultSpendPath(path[0]) == VaultPathUnvault &&
+			numSigs != len(policy.HotKeys):
+			return fmt.Errorf("unvault requires %d signature slots, got %d",
+				len(policy.HotKeys), numSigs)
+
+		case VaultSpendPath(path[0]) == VaultPathClawback && numSigs != 1:
+			return errors.New("clawback requires a single signature")
+
+		case VaultSpendPath(path[0]) != VaultPathUnvault &&
+			VaultSpendPath(path[0]) != VaultPathClawback:
+			return fmt.Errorf("unknown vault spend path %d", path[0])
+		}
 
 	case byte(BaseLeafVersion):
 		// Standard tapscript validation
@@ -746,6 +746,7 @@ func BenchmarkReadBlockHeader(b *testing.B) {
 		0x29, 0xab, 0x5f, 0x49, // Timestamp
 		0xff, 0xff, 0x00, 0x1d, // Bits
 		0xf3, 0xe0, 0x01, 0x00, // Nonce
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // ThermalProof
 		0x00, // TxnCount Varint
 	}
 	r := bytes.NewReader(buf)
@@ -775,6 +776,7 @@ func BenchmarkReadBlockHeaderBuf(b *testing.B) {
 		0x29, 0xab, 0x5f, 0x49, // Timestamp
 		0xff, 0xff, 0x00, 0x1d, // Bits
 		0xf3, 0xe0, 0x01, 0x00, // Nonce
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // ThermalProof
 		0x00, // TxnCount Varint
 	}
 	r := bytes.NewReader(buf)
@@ -855,7 +857,7 @@ func BenchmarkDecodeHeaders(b *testing.B) {
 		if err != nil {
 			b.Fatalf("NewHashFromStr: unexpected error: %v", err)
 		}
-		m.AddBlockHeader(NewBlockHeader(1, hash, hash, 0, uint32(i)))
+		m.AddBlockHeader(NewBlockHeader(1, hash, hash, 0, uint32(i), 0))
 	}
 
 	// Serialize it so the bytes are available to test the decode below.
@@ -1012,7 +1014,7 @@ func BenchmarkDecodeMerkleBlock(b *testing.B) {
 	if err != nil {
 		b.Fatalf("NewHashFromStr: unexpected error: %v", err)
 	}
-	m.Header = *NewBlockHeader(1, hash, hash, 0, uint32(10000))
+	m.Header = *NewBlockHeader(1, hash, hash, 0, uint32(10000), 0)
 	for i := 0; i < 105; i++ {
 		hash, err := chainhash.NewHashFromStr(fmt.Sprintf("%x", i))
 		if err != nil {
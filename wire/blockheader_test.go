@@ -24,7 +24,7 @@ func TestBlockHeader(t *testing.T) {
 	hash := mainNetGenesisHash
 	merkleHash := mainNetGenesisMerkleRoot
 	bits := uint32(0x1d00ffff)
-	bh := NewBlockHeader(1, &hash, &merkleHash, bits, nonce)
+	bh := NewBlockHeader(1, &hash, &merkleHash, bits, nonce, 0)
 
 	// Ensure we get the same data back out.
 	if !bh.PrevBlock.IsEqual(&hash) {
@@ -45,6 +45,42 @@ func TestBlockHeader(t *testing.T) {
 	}
 }
 
+// TestBlockHeaderHash tests that the block hash commits to every field of the
+// header, including the thermal proof.
+func TestBlockHeaderHash(t *testing.T) {
+	bh := BlockHeader{
+		Version:      1,
+		MerkleRoot:   mainNetGenesisMerkleRoot,
+		Timestamp:    time.Unix(0x495fab29, 0), // 2009-01-03 18:15:05 +0000 UTC
+		Bits:         0x1d00ffff,
+		Nonce:        0x7c2bac1d,
+		ThermalProof: 0x0102030405060708,
+	}
+	hash := bh.BlockHash()
+
+	tests := []struct {
+		name   string
+		modify func(*BlockHeader)
+	}{
+		{"version", func(h *BlockHeader) { h.Version++ }},
+		{"prev block", func(h *BlockHeader) { h.PrevBlock[0] ^= 1 }},
+		{"merkle root", func(h *BlockHeader) { h.MerkleRoot[0] ^= 1 }},
+		{"timestamp", func(h *BlockHeader) {
+			h.Timestamp = h.Timestamp.Add(time.Second)
+		}},
+		{"bits", func(h *BlockHeader) { h.Bits++ }},
+		{"nonce", func(h *BlockHeader) { h.Nonce++ }},
+		{"thermal proof", func(h *BlockHeader) { h.ThermalProof++ }},
+	}
+	for _, test := range tests {
+		modified := bh
+		test.modify(&modified)
+		if modified.BlockHash() == hash {
+			t.Errorf("BlockHash: %s is not committed to", test.name)
+		}
+	}
+}
+
 // TestBlockHeaderWire tests the BlockHeader wire encode and decode for various
 // protocol versions.
 func TestBlockHeaderWire(t *testing.T) {
@@ -76,6 +112,7 @@ func TestBlockHeaderWire(t *testing.T) {
 		0x29, 0xab, 0x5f, 0x49, // Timestamp
 		0xff, 0xff, 0x00, 0x1d, // Bits
 		0xf3, 0xe0, 0x01, 0x00, // Nonce
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // ThermalProof
 	}
 
 	tests := []struct {
@@ -215,6 +252,7 @@ func TestBlockHeaderSerialize(t *testing.T) {
 		0x29, 0xab, 0x5f, 0x49, // Timestamp
 		0xff, 0xff, 0x00, 0x1d, // Bits
 		0xf3, 0xe0, 0x01, 0x00, // Nonce
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // ThermalProof
 	}
 
 	tests := []struct {